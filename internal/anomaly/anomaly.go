@@ -0,0 +1,167 @@
+// Package anomaly keeps a local log of package manager output that poxy
+// could not parse, so changes in the output formats of upstream tools show
+// up in poxy doctor instead of as silently empty results.
+package anomaly
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
+)
+
+const (
+	// maxLogSize is the size at which the log is trimmed to its newest
+	// keepEvents events
+	maxLogSize = 256 << 10
+	keepEvents = 100
+)
+
+// Log is an append-only log of parser anomalies, one JSON object per line.
+type Log struct {
+	path string
+}
+
+// Open returns the log at the default location.
+func Open() *Log {
+	return OpenPath(config.AnomalyPath())
+}
+
+// OpenPath returns the log at path. The file is created on the first
+// record.
+func OpenPath(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the path of the log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends an anomaly to the log.
+func (l *Log) Record(a manager.ParserAnomaly) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(l.path); err == nil && info.Size() > maxLogSize {
+		return l.trim()
+	}
+	return nil
+}
+
+// Read returns the anomalies in the log, oldest first. A missing log has
+// none. Lines that cannot be decoded are skipped.
+func (l *Log) Read() ([]manager.ParserAnomaly, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var anomalies []manager.ParserAnomaly
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLogSize)
+	for scanner.Scan() {
+		var a manager.ParserAnomaly
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			continue
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, scanner.Err()
+}
+
+// trim rewrites the log with only its newest events.
+func (l *Log) trim() error {
+	anomalies, err := l.Read()
+	if err != nil {
+		return err
+	}
+	if len(anomalies) > keepEvents {
+		anomalies = anomalies[len(anomalies)-keepEvents:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, a := range anomalies {
+		if err := enc.Encode(a); err != nil {
+			return err
+		}
+	}
+
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Count is the number of anomalies of one command of a source.
+type Count struct {
+	Source  string
+	Command string
+	Count   int
+	Last    manager.ParserAnomaly // Newest anomaly
+}
+
+// Summarize counts the anomalies since a time by source and command, most
+// frequent first.
+func Summarize(anomalies []manager.ParserAnomaly, since time.Time) []Count {
+	byKey := make(map[string]*Count)
+	for _, a := range anomalies {
+		if a.Time.Before(since) {
+			continue
+		}
+		key := a.Source + "\x00" + a.Command
+		c, ok := byKey[key]
+		if !ok {
+			c = &Count{Source: a.Source, Command: a.Command}
+			byKey[key] = c
+		}
+		c.Count++
+		if !a.Time.Before(c.Last.Time) {
+			c.Last = a
+		}
+	}
+
+	counts := make([]Count, 0, len(byKey))
+	for _, c := range byKey {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].Source != counts[j].Source {
+			return counts[i].Source < counts[j].Source
+		}
+		return counts[i].Command < counts[j].Command
+	})
+	return counts
+}
@@ -0,0 +1,81 @@
+package anomaly
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"poxy/pkg/manager"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	log := OpenPath(filepath.Join(t.TempDir(), "data", "anomalies.jsonl"))
+
+	if anomalies, err := log.Read(); err != nil || len(anomalies) != 0 {
+		t.Fatalf("Read() of a missing log = %v, %v", anomalies, err)
+	}
+
+	now := time.Now()
+	for _, cmd := range []string{"dnf list installed", "dnf search"} {
+		if err := log.Record(manager.ParserAnomaly{Time: now, Source: "dnf", Command: cmd, Sample: "Installed packages"}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	anomalies, err := log.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(anomalies) != 2 || anomalies[0].Command != "dnf list installed" || anomalies[1].Sample != "Installed packages" {
+		t.Errorf("Read() = %+v", anomalies)
+	}
+}
+
+func TestRecordTrims(t *testing.T) {
+	log := OpenPath(filepath.Join(t.TempDir(), "anomalies.jsonl"))
+
+	sample := strings.Repeat("x", 500)
+	for i := 0; i < maxLogSize/500+10; i++ {
+		if err := log.Record(manager.ParserAnomaly{Source: "apt", Command: "apt-cache search", Sample: sample}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	info, err := os.Stat(log.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > maxLogSize {
+		t.Errorf("log size = %d, want at most %d", info.Size(), maxLogSize)
+	}
+	anomalies, err := log.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anomalies) < keepEvents || len(anomalies) > maxLogSize/500 {
+		t.Errorf("kept %d anomalies", len(anomalies))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Now()
+	anomalies := []manager.ParserAnomaly{
+		{Time: now.Add(-60 * 24 * time.Hour), Source: "snap", Command: "snap find"},
+		{Time: now.Add(-2 * time.Hour), Source: "apt", Command: "apt-cache search", Sample: "old"},
+		{Time: now.Add(-time.Hour), Source: "dnf", Command: "dnf search"},
+		{Time: now.Add(-time.Hour), Source: "apt", Command: "apt-cache search", Sample: "new"},
+	}
+
+	counts := Summarize(anomalies, now.Add(-30*24*time.Hour))
+	if len(counts) != 2 {
+		t.Fatalf("Summarize() = %+v, want 2 counts", counts)
+	}
+	if counts[0].Source != "apt" || counts[0].Count != 2 || counts[0].Last.Sample != "new" {
+		t.Errorf("counts[0] = %+v, want 2 apt anomalies, last \"new\"", counts[0])
+	}
+	if counts[1].Source != "dnf" || counts[1].Count != 1 {
+		t.Errorf("counts[1] = %+v", counts[1])
+	}
+}
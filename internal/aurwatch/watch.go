@@ -0,0 +1,162 @@
+// Package aurwatch follows the maintainers of installed AUR packages, so a
+// package that is orphaned or taken over by a new maintainer, a common way
+// for malicious changes to reach users, is flagged before its next upgrade.
+package aurwatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"poxy/internal/config"
+)
+
+// AdoptionWindow is how long a package is flagged after its maintainer
+// changed.
+const AdoptionWindow = 30 * 24 * time.Hour
+
+// Alert kinds.
+const (
+	AlertOrphaned = "orphaned"       // The package has no maintainer
+	AlertAdopted  = "new maintainer" // The maintainer changed recently
+)
+
+// Record is what is known about the maintainer of one package.
+type Record struct {
+	Maintainer string `json:"maintainer"` // "" if orphaned
+	// Previous is the maintainer before the last change
+	Previous string    `json:"previous,omitempty"`
+	Changed  time.Time `json:"changed,omitempty"` // Zero if never seen to change
+	Checked  time.Time `json:"checked"`
+}
+
+// Alert is an installed AUR package whose maintainer needs a look.
+type Alert struct {
+	Package    string    `json:"package"`
+	Kind       string    `json:"kind"`
+	Maintainer string    `json:"maintainer,omitempty"`
+	Previous   string    `json:"previous,omitempty"`
+	Since      time.Time `json:"since,omitempty"` // When the change was seen
+}
+
+// Reason describes the alert, e.g. "maintainer changed from alice to bob".
+func (a Alert) Reason() string {
+	switch {
+	case a.Kind == AlertOrphaned && a.Previous != "":
+		return fmt.Sprintf("orphaned by %s", a.Previous)
+	case a.Kind == AlertOrphaned:
+		return "orphaned"
+	case a.Previous == "":
+		return fmt.Sprintf("adopted by %s after being orphaned", a.Maintainer)
+	default:
+		return fmt.Sprintf("maintainer changed from %s to %s", a.Previous, a.Maintainer)
+	}
+}
+
+// Watch is the record of the maintainers of installed AUR packages, kept
+// as a JSON file.
+type Watch struct {
+	path     string
+	Packages map[string]Record `json:"packages"`
+}
+
+// Load reads the watch at the default location.
+func Load() (*Watch, error) {
+	return LoadPath(config.AURWatchPath())
+}
+
+// LoadPath reads the watch at path. A missing file is an empty watch.
+func LoadPath(path string) (*Watch, error) {
+	w := &Watch{path: path, Packages: make(map[string]Record)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if w.Packages == nil {
+		w.Packages = make(map[string]Record)
+	}
+	return w, nil
+}
+
+// Save writes the watch back to its file.
+func (w *Watch) Save() error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := w.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, w.path)
+}
+
+// Observe records the current maintainers of the installed AUR packages,
+// "" for orphaned ones, and forgets packages that are no longer installed.
+// A package seen for the first time is not taken as adopted, since its
+// earlier maintainer is unknown.
+func (w *Watch) Observe(maintainers map[string]string, now time.Time) {
+	for name := range w.Packages {
+		if _, ok := maintainers[name]; !ok {
+			delete(w.Packages, name)
+		}
+	}
+
+	for name, maintainer := range maintainers {
+		rec, seen := w.Packages[name]
+		if seen && rec.Maintainer != maintainer {
+			rec.Previous = rec.Maintainer
+			rec.Changed = now
+		}
+		rec.Maintainer = maintainer
+		rec.Checked = now
+		w.Packages[name] = rec
+	}
+}
+
+// Alerts returns the orphaned packages and the packages whose maintainer
+// changed within window of now, sorted by package.
+func (w *Watch) Alerts(now time.Time, window time.Duration) []Alert {
+	var alerts []Alert
+	for name, rec := range w.Packages {
+		alert := Alert{Package: name, Maintainer: rec.Maintainer, Previous: rec.Previous, Since: rec.Changed}
+		switch {
+		case rec.Maintainer == "":
+			alert.Kind = AlertOrphaned
+		case !rec.Changed.IsZero() && now.Sub(rec.Changed) < window:
+			alert.Kind = AlertAdopted
+		default:
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Package < alerts[j].Package
+	})
+	return alerts
+}
+
+// ByPackage indexes alerts by package name.
+func ByPackage(alerts []Alert) map[string]Alert {
+	index := make(map[string]Alert, len(alerts))
+	for _, alert := range alerts {
+		index[alert.Package] = alert
+	}
+	return index
+}
@@ -0,0 +1,70 @@
+package aurwatch
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestObserveAndAlerts(t *testing.T) {
+	w, err := LoadPath(filepath.Join(t.TempDir(), "aur-maintainers.json"))
+	if err != nil {
+		t.Fatalf("LoadPath() of a missing file error = %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.Observe(map[string]string{"yay": "jguer", "spotify": "alice", "oldtool": "", "gone": "bob"}, start)
+
+	// Only the package orphaned when first seen is flagged
+	alerts := w.Alerts(start, AdoptionWindow)
+	if len(alerts) != 1 || alerts[0].Package != "oldtool" || alerts[0].Kind != AlertOrphaned {
+		t.Fatalf("Alerts() after the first check = %+v", alerts)
+	}
+
+	later := start.Add(24 * time.Hour)
+	w.Observe(map[string]string{"yay": "jguer", "spotify": "mallory", "oldtool": "carol"}, later)
+
+	if _, ok := w.Packages["gone"]; ok {
+		t.Error("Observe() kept a package that is no longer installed")
+	}
+
+	got := ByPackage(w.Alerts(later, AdoptionWindow))
+	if len(got) != 2 {
+		t.Fatalf("Alerts() = %+v, want spotify and oldtool", got)
+	}
+	if a := got["spotify"]; a.Kind != AlertAdopted || a.Reason() != "maintainer changed from alice to mallory" {
+		t.Errorf("spotify alert = %+v (%s)", a, a.Reason())
+	}
+	if a := got["oldtool"]; a.Kind != AlertAdopted || a.Reason() != "adopted by carol after being orphaned" {
+		t.Errorf("oldtool alert = %+v (%s)", a, a.Reason())
+	}
+
+	// Adoptions stop being flagged after the window
+	if alerts := w.Alerts(later.Add(AdoptionWindow), AdoptionWindow); len(alerts) != 0 {
+		t.Errorf("Alerts() after the window = %+v", alerts)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "aur-maintainers.json")
+	w, err := LoadPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	w.Observe(map[string]string{"yay": "jguer"}, now)
+	w.Observe(map[string]string{"yay": ""}, now)
+	if err := w.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadPath(path)
+	if err != nil {
+		t.Fatalf("LoadPath() error = %v", err)
+	}
+	alerts := loaded.Alerts(now, AdoptionWindow)
+	if len(alerts) != 1 || alerts[0].Reason() != "orphaned by jguer" {
+		t.Errorf("Alerts() after loading = %+v", alerts)
+	}
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var assertExcept []string
+
+var assertCmd = &cobra.Command{
+	Use:   "assert",
+	Short: "Check the installed packages in tests and automation",
+}
+
+var assertUnchangedCmd = &cobra.Command{
+	Use:   "unchanged [--except pattern]... -- <command> [args...]",
+	Short: "Fail if a command changes the installed packages",
+	Long: `Run a command and fail if it installed, removed, upgraded or downgraded
+any package, in any source, other than those allowed with --except. Use it
+to check that scripts and installers do not silently modify system
+packages.
+
+The installed packages are listed before and after the command; nothing
+is saved to the snapshot store. A pattern is a glob on the package name,
+such as 'vim*', or source:glob to only allow packages of one source, such
+as 'flatpak:*'.
+
+The assertion fails if the command fails, or if a source's packages could
+not be listed, since its changes cannot be checked.
+
+Examples:
+  poxy assert unchanged -- ./install.sh
+  poxy assert unchanged --except 'vim*' -- make install
+  poxy assert unchanged --except 'pip:*' --except 'python3-*' -- ./setup.sh`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAssertUnchanged,
+}
+
+func init() {
+	assertUnchangedCmd.Flags().StringArrayVar(&assertExcept, "except", nil, "allow changes to packages matching this pattern, may be repeated")
+	// Flags after the command belong to it
+	assertUnchangedCmd.Flags().SetInterspersed(false)
+
+	assertCmd.AddCommand(assertUnchangedCmd)
+}
+
+func runAssertUnchanged(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Check the patterns before running anything
+	if _, err := (&snapshot.Diff{}).Except(assertExcept); err != nil {
+		return err
+	}
+
+	command := strings.Join(args, " ")
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would run %s and check that no packages changed", command)
+		return nil
+	}
+
+	managers := getAvailableManagers()
+	if len(managers) == 0 {
+		return ErrNoManager
+	}
+
+	before, err := snapshot.Capture(ctx, snapshot.TriggerManual, "before "+command, managers)
+	if err != nil {
+		return fmt.Errorf("failed to list the installed packages: %w", err)
+	}
+	if verbose {
+		ui.MutedMsg("Listed %d installed packages", before.PackageCount())
+	}
+
+	ui.InfoMsg("Running %s", command)
+	run := exec.CommandContext(ctx, args[0], args[1:]...)
+	run.Stdin = os.Stdin
+	run.Stdout = os.Stdout
+	run.Stderr = os.Stderr
+	runErr := run.Run()
+
+	after, err := snapshot.Capture(ctx, snapshot.TriggerManual, "after "+command, managers)
+	if err != nil {
+		return fmt.Errorf("failed to list the installed packages: %w", err)
+	}
+
+	// A source missing from either listing would show as all its packages
+	// removed or added, so its changes are left out
+	failed := failedSources(before, after)
+	patterns := assertExcept
+	for _, source := range failed {
+		patterns = append(patterns, source+":*")
+	}
+	diff, err := snapshot.Compare(before, after).Except(patterns)
+	if err != nil {
+		return err
+	}
+
+	if !diff.IsEmpty() {
+		ui.ErrorMsg("%s changed %d packages (%s):", command, len(diff.Changes), diff.Summary())
+		for _, c := range diff.Changes {
+			ui.Println("  %s", c.String())
+		}
+	}
+
+	switch {
+	case !diff.IsEmpty() && runErr != nil:
+		return fmt.Errorf("packages changed, and %s failed: %w", command, runErr)
+	case !diff.IsEmpty():
+		return fmt.Errorf("packages changed outside the allowed patterns")
+	case len(failed) > 0:
+		return fmt.Errorf("could not list the %s packages to check them", strings.Join(failed, ", "))
+	case runErr != nil:
+		return fmt.Errorf("%s failed: %w", command, runErr)
+	}
+
+	ui.SuccessMsg("No packages changed")
+	return nil
+}
+
+// failedSources returns the sources whose packages could not be listed in
+// either snapshot, sorted.
+func failedSources(snaps ...*snapshot.Snapshot) []string {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, snap := range snaps {
+		for _, source := range snap.FailedSourceNames() {
+			if !seen[source] {
+				seen[source] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
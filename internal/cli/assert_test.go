@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
+)
+
+// changingManager is an apt whose installed packages are listed from
+// listings in turn, as if the command run between them changed them.
+type changingManager struct {
+	*catalogManager
+	listings [][]manager.Package
+}
+
+func (m *changingManager) ListInstalled(context.Context, manager.ListOpts) ([]manager.Package, error) {
+	if len(m.listings) == 0 {
+		return nil, errors.New("apt: listing failed")
+	}
+	listing := m.listings[0]
+	m.listings = m.listings[1:]
+	return listing, nil
+}
+
+func TestAssertUnchanged(t *testing.T) {
+	savedCfg, savedRegistry := cfg, registry
+	t.Cleanup(func() {
+		cfg, registry = savedCfg, savedRegistry
+		assertExcept = nil
+	})
+	cfg = config.Default()
+
+	vim := manager.Package{Name: "vim", Version: "9.1", Source: "apt"}
+	vimNew := manager.Package{Name: "vim", Version: "9.2", Source: "apt"}
+	htop := manager.Package{Name: "htop", Version: "3.3", Source: "apt"}
+
+	tests := []struct {
+		name     string
+		before   []manager.Package
+		after    []manager.Package
+		failList bool
+		except   []string
+		command  []string
+		wantErr  string
+	}{
+		{name: "unchanged", before: []manager.Package{vim}, after: []manager.Package{vim}, command: []string{"true"}},
+		{name: "installed", before: []manager.Package{vim}, after: []manager.Package{vim, htop}, command: []string{"true"}, wantErr: "packages changed"},
+		{name: "upgraded", before: []manager.Package{vim}, after: []manager.Package{vimNew}, command: []string{"true"}, wantErr: "packages changed"},
+		{name: "allowed change", before: []manager.Package{vim}, after: []manager.Package{vimNew, htop}, except: []string{"vim*", "apt:htop"}, command: []string{"true"}},
+		{name: "command failed", before: []manager.Package{vim}, after: []manager.Package{vim}, command: []string{"false"}, wantErr: "false failed"},
+		{name: "changed and failed", before: []manager.Package{vim}, after: nil, command: []string{"false"}, wantErr: "packages changed, and false failed"},
+		{name: "listing failed", before: []manager.Package{vim}, failList: true, command: []string{"true"}, wantErr: "could not list the apt packages"},
+		{name: "invalid pattern", except: []string{"vim["}, command: []string{"true"}, wantErr: "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &changingManager{catalogManager: &catalogManager{}, listings: [][]manager.Package{tt.before}}
+			if !tt.failList {
+				mgr.listings = append(mgr.listings, tt.after)
+			}
+			registry = manager.NewRegistry(cfg)
+			registry.Register(mgr)
+			assertExcept = tt.except
+
+			err := runAssertUnchanged(assertUnchangedCmd, tt.command)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("runAssertUnchanged() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("runAssertUnchanged() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
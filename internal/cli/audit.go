@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"poxy/internal/aurwatch"
+	"poxy/internal/ui"
+	"poxy/pkg/aur"
+	"poxy/pkg/manager/native"
+
+	"github.com/spf13/cobra"
+)
+
+var auditJSON bool
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check installed AUR packages for maintainer changes",
+	Long: `Check the maintainers of the installed AUR packages. A package changing
+hands is a common way for malicious changes to reach users, so these are
+flagged:
+
+  orphaned        the package has no maintainer, and anyone can adopt it
+  new maintainer  the package changed maintainer in the last 30 days
+
+Read the PKGBUILD of a flagged package before its next upgrade. Each check
+records the maintainers, so a change is seen from the check after it. The
+daemon checks on every refresh, and the Updates tab of the TUI marks the
+flagged packages.
+
+Exits with an error if any package is flagged.
+
+Examples:
+  poxy audit                        # Check the AUR packages now
+  poxy audit --json                 # Flagged packages as JSON`,
+	Args: cobra.NoArgs,
+	RunE: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "print the flagged packages as JSON")
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	pacman, err := getPacman()
+	if err != nil {
+		return err
+	}
+
+	var (
+		alerts  []aurwatch.Alert
+		checked int
+	)
+	check := func() error {
+		var err error
+		alerts, checked, err = checkAURMaintainers(ctx, pacman)
+		return err
+	}
+	if auditJSON {
+		err = check()
+	} else {
+		err = ui.WithSpinner("Checking AUR maintainers...", check)
+	}
+	if err != nil {
+		return err
+	}
+
+	if auditJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]any{"checked": checked, "alerts": alerts}); err != nil {
+			return err
+		}
+	} else if len(alerts) == 0 {
+		ui.SuccessMsg("No maintainer changes among %d AUR package(s)", checked)
+	} else {
+		ui.WarningMsg("%d of %d AUR package(s) changed hands:", len(alerts), checked)
+		for _, alert := range alerts {
+			since := ""
+			if !alert.Since.IsZero() {
+				since = ui.Muted.Sprintf(" (%s)", alert.Since.Format("2006-01-02"))
+			}
+			ui.Println("  %-30s %s%s", alert.Package, alert.Reason(), since)
+		}
+		ui.MutedMsg("Read their PKGBUILDs before upgrading them")
+	}
+
+	if len(alerts) > 0 {
+		return fmt.Errorf("%d AUR package(s) flagged", len(alerts))
+	}
+	return nil
+}
+
+// checkAURMaintainers records the current maintainers of the installed AUR
+// packages and returns the flagged ones and the number checked.
+func checkAURMaintainers(ctx context.Context, pacman *native.Pacman) ([]aurwatch.Alert, int, error) {
+	foreign, err := pacman.ListForeign(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var found []aur.Package
+	if len(foreign) > 0 {
+		found, err = aur.NewClient().InfoAll(ctx, foreign)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to query the AUR: %w", err)
+		}
+	}
+	maintainers := make(map[string]string, len(found))
+	for _, pkg := range found {
+		maintainers[pkg.Name] = pkg.Maintainer
+	}
+
+	watch, err := aurwatch.Load()
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	watch.Observe(maintainers, now)
+	if err := watch.Save(); err != nil {
+		return nil, 0, fmt.Errorf("failed to record the AUR maintainers: %w", err)
+	}
+	return watch.Alerts(now, aurwatch.AdoptionWindow), len(maintainers), nil
+}
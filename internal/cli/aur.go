@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+	"poxy/pkg/aur"
+
+	"github.com/spf13/cobra"
+)
+
+var aurCmd = &cobra.Command{
+	Use:   "aur",
+	Short: "AUR-specific tools",
+	Long: `Tools for parts of the native AUR builder that are not covered by the
+package-centric commands.
+
+Examples:
+  poxy aur repo list                # List the published AUR packages
+  poxy aur repo serve               # Share them with other machines`,
+}
+
+var aurRepoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Manage the repository of built AUR packages",
+	Long: `With publish_repo = true under [managers.aur], every package the native
+AUR builder builds is copied into a local pacman repository and added to
+its database with repo-add. Other machines can then install the packages
+instead of building them again: either set repo_server under
+[managers.aur] to the repository's URL, so poxy downloads a package when
+the repository has the AUR's current version, or add the repository to
+pacman.conf.
+
+Examples:
+  poxy aur repo list                # List the published packages
+  poxy aur repo serve               # Serve the repository over HTTP`,
+}
+
+var aurRepoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the packages in the repository of built AUR packages",
+	Long: `List the packages the repository's database lists, with the latest
+version of each. Older versions are kept in the repository directory; their
+number is shown at the end.
+
+Examples:
+  poxy aur repo list
+  poxy aur repo list --json`,
+	Args: cobra.NoArgs,
+	RunE: runAURRepoList,
+}
+
+var aurRepoServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the repository of built AUR packages over HTTP",
+	Long: `Serve the repository of built AUR packages over HTTP until interrupted,
+for other machines to install from. Point them at it with
+repo_server = "http://<this host>:<port>" under [managers.aur], or add the
+pacman.conf section that is printed.
+
+Other machines only install packages signed with the key set as repo_key
+under [managers.aur]. The repository is served without authentication or
+TLS, and only on this machine unless --addr says otherwise; serve it on a
+trusted network only, or behind a web server that adds them.
+
+Examples:
+  poxy aur repo serve                      # Serve on 127.0.0.1:8080
+  poxy aur repo serve --addr 10.0.0.5:8080 # Serve on the LAN`,
+	Args: cobra.NoArgs,
+	RunE: runAURRepoServe,
+}
+
+var (
+	aurRepoJSON bool
+	aurRepoAddr string
+)
+
+func init() {
+	aurCmd.AddCommand(aurRepoCmd)
+	aurRepoCmd.AddCommand(aurRepoListCmd)
+	aurRepoCmd.AddCommand(aurRepoServeCmd)
+
+	aurRepoListCmd.Flags().BoolVar(&aurRepoJSON, "json", false, "print the packages as JSON")
+	aurRepoServeCmd.Flags().StringVar(&aurRepoAddr, "addr", "127.0.0.1:8080", "address to listen on")
+}
+
+// aurRepo returns the configured repository of built AUR packages.
+func aurRepo() *aur.LocalRepo {
+	aurConfig := cfg.GetManagerConfig("aur")
+	dir := aurConfig.RepoDir
+	if dir == "" {
+		dir = config.AURRepoDir()
+	}
+	return aur.NewLocalRepo(dir, aurConfig.RepoName, aurConfig.RepoKey)
+}
+
+func runAURRepoList(cmd *cobra.Command, args []string) error {
+	repo := aurRepo()
+
+	pkgs, err := repo.Packages()
+	if err != nil {
+		return fmt.Errorf("failed to read the %s repository: %w", repo.Name(), err)
+	}
+	files, err := repo.Files()
+	if err != nil {
+		return err
+	}
+
+	if aurRepoJSON {
+		if pkgs == nil {
+			pkgs = []aur.RepoPackage{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pkgs)
+	}
+
+	if len(pkgs) == 0 {
+		ui.InfoMsg("The %s repository in %s is empty", repo.Name(), repo.Dir())
+		if !cfg.GetManagerConfig("aur").PublishRepo {
+			ui.MutedMsg("Set publish_repo = true under [managers.aur] to add the packages you build")
+		}
+		return nil
+	}
+
+	ui.HeaderMsg("%s (%s)", repo.Name(), repo.Dir())
+	for _, pkg := range pkgs {
+		built := ""
+		if !pkg.BuildDate.IsZero() {
+			built = pkg.BuildDate.Format("2006-01-02")
+		}
+		ui.Println("  %-30s %-20s %10s  %s", pkg.Name, pkg.Version, formatBytes(pkg.Size), ui.Muted.Sprint(built))
+	}
+	if older := len(files) - len(pkgs); older > 0 {
+		ui.MutedMsg("%d older package file(s) are kept in %s", older, repo.Dir())
+	}
+	return nil
+}
+
+func runAURRepoServe(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	repo := aurRepo()
+	if _, err := os.Stat(repo.DBPath()); err != nil {
+		return fmt.Errorf("the %s repository in %s has no packages yet; build some with publish_repo = true under [managers.aur]", repo.Name(), repo.Dir())
+	}
+
+	listener, err := net.Listen("tcp", aurRepoAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", aurRepoAddr, err)
+	}
+
+	server := &http.Server{
+		Handler:           repo.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx) //nolint:errcheck
+	}()
+
+	url := "http://" + serveHost(listener.Addr())
+	ui.InfoMsg("Serving the %s repository from %s at %s", repo.Name(), repo.Dir(), url)
+	key := repo.SigningKey()
+	if key == "" {
+		ui.WarningMsg("No repo_key is set under [managers.aur]; other machines refuse unsigned packages")
+		key = "<key>"
+	}
+	ui.MutedMsg("On other machines, import the signing key and set under [managers.aur]:")
+	ui.Println("  repo_server = %q", url)
+	ui.Println("  repo_key = %q", key)
+	ui.MutedMsg("or trust the key with pacman-key --recv-keys %s && pacman-key --lsign-key %s", key, key)
+	ui.MutedMsg("and add to /etc/pacman.conf:")
+	fmt.Println()
+	fmt.Print(repo.PacmanConf(url))
+	fmt.Println()
+	ui.MutedMsg("Press Ctrl-C to stop")
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveHost returns the host and port other machines reach addr at: the
+// host name when listening on every address.
+func serveHost(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		if name, err := os.Hostname(); err == nil {
+			host = name
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -29,6 +29,9 @@ func runAutoremove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
 
 	ui.InfoMsg("Removing orphaned packages using %s", mgr.DisplayName())
 
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// backendArgFlags are the --backend-arg values, as manager:arg
+var backendArgFlags []string
+
+// addBackendArgFlag adds the --backend-arg flag to a command that installs
+// or upgrades packages.
+func addBackendArgFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&backendArgFlags, "backend-arg", nil, "pass an argument to a manager's tool, as manager:arg, e.g. pacman:--overwrite=* (repeatable)")
+}
+
+// checkBackendArgs reports --backend-arg values that are not manager:arg
+// or name a manager poxy does not know.
+func checkBackendArgs() error {
+	for _, flag := range backendArgFlags {
+		name, arg, ok := strings.Cut(flag, ":")
+		if !ok || name == "" || arg == "" {
+			return fmt.Errorf("invalid --backend-arg %q (want manager:arg, e.g. pacman:--overwrite=*)", flag)
+		}
+		if _, ok := registry.Get(name); !ok {
+			return fmt.Errorf("unknown manager %q in --backend-arg %q", name, flag)
+		}
+	}
+	return nil
+}
+
+// backendArgs returns the extra arguments for the named manager's tool:
+// extra_args under [managers.<name>], then the --backend-arg values for it.
+func backendArgs(name string) []string {
+	args := append([]string(nil), cfg.GetManagerConfig(name).ExtraArgs...)
+	for _, flag := range backendArgFlags {
+		if mgr, arg, ok := strings.Cut(flag, ":"); ok && mgr == name {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
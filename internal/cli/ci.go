@@ -0,0 +1,19 @@
+package cli
+
+// ciMode is set by --ci.
+var ciMode bool
+
+// applyCIPreset sets poxy up for Dockerfiles and CI pipelines: it runs
+// non-interactively with every prompt accepted, nothing is recorded in the
+// history or snapshots, and commands fail at the first problem instead of
+// carrying on.
+func applyCIPreset() {
+	cfg.General.AutoConfirm = true
+	cfg.General.SearchPrompt = false
+	cfg.General.SuggestServices = false
+	cfg.General.SuggestShellSetup = false
+	cfg.General.Snapshots = false
+	cfg.Policy.PromptPrivileges = false
+
+	nonInteractive = true
+}
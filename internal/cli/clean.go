@@ -35,6 +35,9 @@ func runClean(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
 
 	ui.InfoMsg("Cleaning package cache using %s", mgr.DisplayName())
 
@@ -53,16 +56,13 @@ func runClean(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		entry.MarkFailed(err)
 		ui.ErrorMsg("Clean failed: %v", err)
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
 	} else {
 		entry.MarkSuccess()
 		ui.SuccessMsg("Cache cleaned successfully")
 	}
 
-	// Record in history (ignore errors)
-	if store, storeErr := history.Open(); storeErr == nil {
-		_ = store.Record(entry) //nolint:errcheck
-		_ = store.Close()       //nolint:errcheck
-	}
+	recordHistory(entry)
 
 	return err
 }
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"poxy/internal/doctor"
+	"poxy/internal/pin"
+	"poxy/pkg/database"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionLimit caps the number of suggestions returned to the shell.
+	completionLimit = 200
+
+	// completionTimeout bounds live package manager queries during completion.
+	completionTimeout = 3 * time.Second
+)
+
+// prepareCompletion sets up the application for a completion request.
+// Cobra does not run PersistentPreRunE for completions, so the config and
+// registry have to be initialized here.
+func prepareCompletion(cmd *cobra.Command) bool {
+	if cfg == nil || registry == nil {
+		if err := initializeApp(); err != nil {
+			return false
+		}
+	}
+	if s, err := cmd.Flags().GetString("source"); err == nil {
+		source = s
+	}
+	return true
+}
+
+// completeCachedPackages completes package names from the package cache, so
+// completion does not have to query every package manager.
+func completeCachedPackages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !prepareCompletion(cmd) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	store, err := database.Open()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer store.Close()
+
+	var entries []database.PackageEntry
+	if source != "" {
+		mgr, err := registry.GetManagerForSource(source)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		entries, err = store.GetPackagesBySource(mgr.Name())
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	} else if entries, err = store.GetAllPackages(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(entries)+len(cfg.Aliases))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	for alias := range cfg.Aliases {
+		names = append(names, alias)
+	}
+
+	return matchCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeInstalledPackages completes names of packages installed by the
+// manager the command would use.
+func completeInstalledPackages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !prepareCompletion(cmd) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	mgr, err := getManager()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	installed, err := mgr.ListInstalled(ctx, manager.ListOpts{Pattern: toComplete})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(installed))
+	for _, pkg := range installed {
+		names = append(names, pkg.Name)
+	}
+
+	return matchCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePinnedPackages completes names of pinned packages.
+func completePinnedPackages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	pins, err := pin.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(pins))
+	for _, p := range pins {
+		names = append(names, p.Name)
+	}
+
+	return matchCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRepos completes names of the repositories of the selected
+// manager.
+func completeRepos(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !prepareCompletion(cmd) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	mgr, err := getManager()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	rm, ok := mgr.(manager.RepoManager)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	repos, err := rm.ListRepos(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+
+	return matchCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDoctorChecks completes --check with the names of health checks.
+func completeDoctorChecks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, c := range doctor.Checks() {
+		if strings.HasPrefix(c.Name, toComplete) {
+			names = append(names, c.Name+"\t"+c.Description)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSources completes --source with available managers and source types.
+func completeSources(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !prepareCompletion(cmd) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var sources []string
+	for _, mgr := range registry.Available() {
+		if strings.HasPrefix(mgr.Name(), toComplete) {
+			sources = append(sources, mgr.Name()+"\t"+mgr.DisplayName())
+		}
+	}
+	for _, kind := range []string{"native", "universal"} {
+		if strings.HasPrefix(kind, toComplete) {
+			sources = append(sources, kind+"\t"+kind+" package manager")
+		}
+	}
+
+	return sources, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSingle limits a completion function to the first argument, for
+// commands that take exactly one.
+func completeSingle(fn cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return fn(cmd, args, toComplete)
+	}
+}
+
+// matchCompletions returns the sorted, unique names starting with toComplete,
+// leaving out names already given on the command line.
+func matchCompletions(names, args []string, toComplete string) []string {
+	seen := make(map[string]bool, len(args))
+	for _, arg := range args {
+		seen[arg] = true
+	}
+
+	var matches []string
+	for _, name := range names {
+		if seen[name] || !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+		seen[name] = true
+		matches = append(matches, name)
+	}
+
+	sort.Strings(matches)
+	if len(matches) > completionLimit {
+		matches = matches[:completionLimit]
+	}
+	return matches
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var configConvertStdout bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the config file",
+	Long: `Manage poxy's config file and its fragments.
+
+The config file is config.toml in the config directory, or config.yaml
+(or config.yml) if only that exists. Fragments in the conf.d directory
+beside it, such as conf.d/10-company.toml, are read after it in name order,
+each overriding the settings it has. Organizations can ship managed
+fragments there without touching the user's own settings.
+
+Examples:
+  poxy config files                 # Show which files are read
+  poxy config convert yaml          # Turn config.toml into config.yaml`,
+}
+
+var configConvertCmd = &cobra.Command{
+	Use:   "convert <toml|yaml> [file]",
+	Short: "Convert the config file, or a fragment, to another format",
+	Long: `Convert the config file, or the given file, to TOML or YAML. The
+converted file is written beside it with the new extension and the old one
+is kept as .bak, so poxy reads the new one from then on. Comments are not
+carried over.
+
+Examples:
+  poxy config convert yaml                       # config.toml to config.yaml
+  poxy config convert toml ~/.config/poxy/conf.d/10-team.yaml
+  poxy config convert yaml --stdout              # Print it, change nothing`,
+	Args:      cobra.RangeArgs(1, 2),
+	ValidArgs: []string{string(config.FormatTOML), string(config.FormatYAML)},
+	RunE:      runConfigConvert,
+}
+
+var configListFilesCmd = &cobra.Command{
+	Use:   "files",
+	Short: "List the config files poxy reads, in order",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigListFiles,
+}
+
+func init() {
+	configConvertCmd.Flags().BoolVar(&configConvertStdout, "stdout", false, "print the converted file instead of writing it")
+
+	configCmd.AddCommand(configConvertCmd)
+	configCmd.AddCommand(configListFilesCmd)
+}
+
+// configFilePath returns the config file in use: --config, or the default.
+func configFilePath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return config.ConfigPath()
+}
+
+func runConfigConvert(cmd *cobra.Command, args []string) error {
+	to, err := config.ParseFormat(args[0])
+	if err != nil {
+		return err
+	}
+	path := configFilePath()
+	if len(args) > 1 {
+		path = args[1]
+	}
+	from, err := config.FormatOf(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	converted, err := config.Convert(data, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", path, err)
+	}
+
+	if configConvertStdout {
+		_, err := os.Stdout.Write(converted)
+		return err
+	}
+	if from == to {
+		ui.InfoMsg("%s is already %s", path, strings.ToUpper(string(to)))
+		return nil
+	}
+
+	target := strings.TrimSuffix(path, filepath.Ext(path)) + to.Ext()
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("%s already exists; move it away first", target)
+	}
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would convert %s to %s, keeping the old file as %s.bak", path, target, path)
+		return nil
+	}
+
+	if err := os.WriteFile(target, converted, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		_ = os.Remove(target) //nolint:errcheck
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	ui.SuccessMsg("Converted %s to %s", path, target)
+	ui.MutedMsg("The old file is kept as %s.bak", path)
+	if cfgFile != "" && len(args) == 1 {
+		ui.MutedMsg("Pass --config %s from now on", target)
+	}
+	return nil
+}
+
+func runConfigListFiles(cmd *cobra.Command, args []string) error {
+	paths := []string{configFilePath()}
+	if cfgFile == "" {
+		fragments, err := config.Fragments(config.FragmentDir())
+		if err != nil {
+			return err
+		}
+		paths = append(paths, fragments...)
+	}
+
+	for i, path := range paths {
+		status := ""
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			status = ui.Muted.Sprint(" (not present, defaults apply)")
+		}
+		ui.Println("%d. %s%s", i+1, path, status)
+	}
+	if cfgFile == "" {
+		ui.MutedMsg("Fragments in %s override the config file, later ones earlier ones", config.FragmentDir())
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/internal/configfiles"
+	"poxy/internal/executor"
+	"poxy/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var configFilesDir string
+
+var configFilesCmd = &cobra.Command{
+	Use:     "config-files",
+	Aliases: []string{"pacnew"},
+	Short:   "Manage .pacnew, .pacsave and .rpmnew files",
+	Long: `List the configuration files package managers left next to a
+modified config: new defaults (.pacnew, .rpmnew, .dpkg-dist) and saved
+user versions (.pacsave, .rpmsave, .dpkg-old).
+
+Each file can be diffed against the live config and resolved:
+
+  merge    Open both files in $DIFFPROG (default: vim -d)
+  replace  Make the leftover the live config
+  keep     Keep the live config and delete the leftover
+
+Files are given as the leftover or the live config path.
+
+Examples:
+  poxy config-files                          # List leftovers in /etc
+  poxy config-files diff /etc/pacman.conf    # Show what the new default changes
+  poxy config-files resolve                  # Go through every leftover
+  poxy config-files keep /etc/locale.gen.pacnew
+  DIFFPROG=meld poxy config-files merge /etc/pacman.conf`,
+	Args: cobra.NoArgs,
+	RunE: runConfigFiles,
+}
+
+var configFilesDiffCmd = &cobra.Command{
+	Use:               "diff [file...]",
+	Short:             "Show leftovers as a diff against the live config",
+	RunE:              runConfigFilesDiff,
+	ValidArgsFunction: completeConfigFiles,
+}
+
+var configFilesMergeCmd = &cobra.Command{
+	Use:               "merge <file...>",
+	Short:             "Merge leftovers into the live config with $DIFFPROG",
+	Args:              cobra.MinimumNArgs(1),
+	RunE:              runConfigFilesAction(configFileMerge),
+	ValidArgsFunction: completeConfigFiles,
+}
+
+var configFilesReplaceCmd = &cobra.Command{
+	Use:               "replace <file...>",
+	Short:             "Replace the live config with the leftover",
+	Args:              cobra.MinimumNArgs(1),
+	RunE:              runConfigFilesAction(configFileReplace),
+	ValidArgsFunction: completeConfigFiles,
+}
+
+var configFilesKeepCmd = &cobra.Command{
+	Use:               "keep <file...>",
+	Short:             "Keep the live config and delete the leftover",
+	Args:              cobra.MinimumNArgs(1),
+	RunE:              runConfigFilesAction(configFileKeep),
+	ValidArgsFunction: completeConfigFiles,
+}
+
+var configFilesResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Go through the leftovers one by one",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigFilesResolve,
+}
+
+func init() {
+	configFilesCmd.PersistentFlags().StringVar(&configFilesDir, "dir", configfiles.DefaultDir, "directory to scan for leftovers")
+
+	configFilesCmd.AddCommand(configFilesDiffCmd)
+	configFilesCmd.AddCommand(configFilesMergeCmd)
+	configFilesCmd.AddCommand(configFilesReplaceCmd)
+	configFilesCmd.AddCommand(configFilesKeepCmd)
+	configFilesCmd.AddCommand(configFilesResolveCmd)
+}
+
+// configFileAction is a way to resolve a leftover configuration file.
+type configFileAction int
+
+const (
+	configFileMerge configFileAction = iota
+	configFileReplace
+	configFileKeep
+)
+
+func runConfigFiles(cmd *cobra.Command, args []string) error {
+	files, err := configfiles.Scan(context.Background(), configFilesDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		ui.SuccessMsg("No leftover configuration files in %s", configFilesDir)
+		return nil
+	}
+
+	ui.HeaderMsg("Leftover configuration files")
+	for _, f := range files {
+		ui.Println("  %-50s %s", f.Path, ui.Cyan(f.Describe()))
+	}
+	ui.Println("")
+	ui.MutedMsg("Run 'poxy config-files resolve' to go through them")
+	return nil
+}
+
+func runConfigFilesDiff(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	files, err := findConfigFiles(ctx, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		ui.SuccessMsg("No leftover configuration files in %s", configFilesDir)
+		return nil
+	}
+
+	for _, f := range files {
+		diff, err := f.Diff(ctx)
+		if err != nil {
+			ui.WarningMsg("%s: %v", f.Path, err)
+			continue
+		}
+		printConfigDiff(f, diff)
+	}
+	return nil
+}
+
+// runConfigFilesAction returns the RunE of the subcommand applying action
+// to the files given.
+func runConfigFilesAction(action configFileAction) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		files, err := findConfigFiles(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		if action != configFileMerge && !cfg.General.AutoConfirm && !cfg.General.DryRun {
+			ui.HeaderMsg("The following leftovers will be resolved:")
+			for _, f := range files {
+				ui.Println("  %s", f.Path)
+			}
+			question := "Replace the live configs with them?"
+			if action == configFileKeep {
+				question = "Keep the live configs and delete them?"
+			}
+			confirmed, err := ui.Confirm(question, false)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return ErrAborted
+			}
+		}
+
+		for _, f := range files {
+			if err := applyConfigFileAction(ctx, f, action); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func runConfigFilesResolve(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	files, err := configfiles.Scan(ctx, configFilesDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		ui.SuccessMsg("No leftover configuration files in %s", configFilesDir)
+		return nil
+	}
+
+	for i, f := range files {
+		diff, err := f.Diff(ctx)
+		if err != nil {
+			ui.WarningMsg("%s: %v", f.Path, err)
+			continue
+		}
+
+		ui.HeaderMsg("[%d/%d] %s", i+1, len(files), f.Path)
+		ui.MutedMsg("%s", f.Describe())
+		if diff == "" {
+			ui.InfoMsg("Identical to %s", f.Target)
+		} else {
+			printConfigDiff(f, diff)
+		}
+
+		// Identical files are safe to delete, so offer that first
+		def := "s"
+		if diff == "" {
+			def = "k"
+		}
+		answer, err := ui.Input("[m]erge, [r]eplace, [k]eep current, [s]kip, [q]uit", def)
+		if err != nil {
+			return ErrAborted
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "m", "merge":
+			if err := applyConfigFileAction(ctx, f, configFileMerge); err != nil {
+				return err
+			}
+			if cfg.General.DryRun {
+				continue
+			}
+			remove, err := ui.Confirm(fmt.Sprintf("Merged; delete %s?", f.Path), true)
+			if err != nil {
+				return err
+			}
+			if remove {
+				if err := applyConfigFileAction(ctx, f, configFileKeep); err != nil {
+					return err
+				}
+			}
+		case "r", "replace":
+			if err := applyConfigFileAction(ctx, f, configFileReplace); err != nil {
+				return err
+			}
+		case "k", "keep":
+			if err := applyConfigFileAction(ctx, f, configFileKeep); err != nil {
+				return err
+			}
+		case "q", "quit":
+			return nil
+		default:
+			ui.MutedMsg("Skipped %s", f.Path)
+		}
+	}
+	return nil
+}
+
+// findConfigFiles scans the config directory and returns the leftovers
+// named by args, by their own or their live config's path. Without args
+// all leftovers are returned.
+func findConfigFiles(ctx context.Context, args []string) ([]configfiles.File, error) {
+	files, err := configfiles.Scan(ctx, configFilesDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return files, nil
+	}
+
+	var found []configfiles.File
+	for _, arg := range args {
+		matched := false
+		for _, f := range files {
+			if f.Path == arg || f.Target == arg {
+				found = append(found, f)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no leftover configuration file for %s", arg)
+		}
+	}
+	return found, nil
+}
+
+// applyConfigFileAction resolves f with root privileges.
+func applyConfigFileAction(ctx context.Context, f configfiles.File, action configFileAction) error {
+	var args []string
+	var done string
+	switch action {
+	case configFileMerge:
+		args = f.MergeArgs()
+		done = fmt.Sprintf("Merged %s into %s", f.Path, f.Target)
+	case configFileReplace:
+		args = f.ReplaceArgs()
+		done = fmt.Sprintf("Replaced %s with %s", f.Target, f.Path)
+	case configFileKeep:
+		args = f.KeepArgs()
+		done = fmt.Sprintf("Deleted %s", f.Path)
+		if f.TargetExists() {
+			done = fmt.Sprintf("Kept %s, deleted %s", f.Target, f.Path)
+		}
+	}
+
+	runner := executor.New(cfg.General.DryRun, cfg.Output.Verbose)
+	if err := runner.RunSudo(ctx, args[0], args[1:]...); err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("%s", done)
+	}
+	return nil
+}
+
+// printConfigDiff prints a unified diff with added and removed lines colored.
+func printConfigDiff(f configfiles.File, diff string) {
+	if diff == "" {
+		ui.MutedMsg("%s is identical to %s", f.Path, f.Target)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			ui.Println("%s", ui.Bold(line))
+		case strings.HasPrefix(line, "@@"):
+			ui.Println("%s", ui.Cyan(line))
+		case strings.HasPrefix(line, "+"):
+			ui.Println("%s", ui.Green(line))
+		case strings.HasPrefix(line, "-"):
+			ui.Println("%s", ui.Red(line))
+		default:
+			ui.Println("%s", line)
+		}
+	}
+	ui.Println("")
+}
+
+// completeConfigFiles completes the leftover configuration files.
+func completeConfigFiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	files, err := configfiles.Scan(context.Background(), configFilesDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Path)
+	}
+	return matchCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
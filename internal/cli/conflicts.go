@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"poxy/internal/pathconflict"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+)
+
+// previewCommandConflicts warns, before an install, about the commands its
+// packages would add that clash with each other or with commands already
+// on the PATH, and tells which one a shell will run. packages lists the
+// packages of each manager in order. Sources that cannot tell which
+// commands a package installs are left out, and each lookup gets the probe
+// timeout.
+func previewCommandConflicts(ctx context.Context, order []manager.Manager, packages map[string][]string) {
+	if rootDir != "" {
+		return // Another system's commands are not on this PATH
+	}
+
+	var planned []pathconflict.Command
+	for _, mgr := range order {
+		for _, pkg := range packages[mgr.Name()] {
+			for _, cmdPath := range packageCommands(ctx, mgr, pkg) {
+				dir := pathconflict.ResolveDir(filepath.Dir(cmdPath))
+				planned = append(planned, pathconflict.Command{
+					Path:    filepath.Join(dir, filepath.Base(cmdPath)),
+					Package: pkg,
+					Source:  mgr.Name(),
+				})
+			}
+		}
+	}
+	findings := pathconflict.Check(planned, pathconflict.SearchPath(os.Getenv("PATH")), nil)
+	if len(findings) == 0 {
+		return
+	}
+
+	ui.WarningMsg("Some commands of this install clash with others of the same name:")
+	for _, f := range findings {
+		warning := describeConflict(ctx, f)
+		ui.MutedMsg("  - %s", warning)
+		if dryRunPlan != nil {
+			dryRunPlan.Warn(warning)
+		}
+	}
+}
+
+// packageCommands returns the paths pkg of mgr would install commands at.
+// Managers that cannot list them but install into a directory of their own
+// are taken to install a command named after the package, such as
+// ~/.cargo/bin/bat for bat.
+func packageCommands(ctx context.Context, mgr manager.Manager, pkg string) []string {
+	lookupCtx, cancel := withTimeout(ctx, cfg.Timeouts.Probe)
+	defer cancel()
+
+	if previewer, ok := mgr.(manager.CommandPreviewer); ok {
+		commands, err := previewer.PackageCommands(lookupCtx, pkg)
+		if err != nil && verbose {
+			ui.MutedMsg("Not checking the commands of %s for clashes: %v", pkg, err)
+		}
+		return commands
+	}
+	if provider, ok := mgr.(manager.BinDirProvider); ok && mgr.Name() != "flatpak" {
+		if dir := provider.BinDir(lookupCtx); dir != "" {
+			return []string{filepath.Join(dir, commandName(pkg))}
+		}
+	}
+	return nil
+}
+
+// commandName guesses the command a package is run by from its name:
+// golang.org/x/tools/gopls@latest gives gopls, @scope/tool gives tool.
+func commandName(pkg string) string {
+	if i := strings.LastIndex(pkg, "@"); i > 0 {
+		pkg = pkg[:i]
+	}
+	if i := strings.LastIndexByte(pkg, '#'); i >= 0 {
+		pkg = pkg[i+1:] // Nix flake references, e.g. nixpkgs#ripgrep
+	}
+	return path.Base(pkg)
+}
+
+// describeConflict says which command will run and why.
+func describeConflict(ctx context.Context, f pathconflict.Finding) string {
+	planned := f.Planned[0]
+	for _, cmd := range f.Planned {
+		if cmd.Path == f.Winner {
+			planned = cmd
+		}
+	}
+	from := func(cmd pathconflict.Command) string {
+		return fmt.Sprintf("%s (%s, %s)", cmd.Name(), sourceName(cmd.Source), cmd.Path)
+	}
+
+	switch f.Kind {
+	case pathconflict.Conflict:
+		var sources []string
+		for _, cmd := range f.Planned {
+			sources = append(sources, sourceName(cmd.Source))
+		}
+		return fmt.Sprintf("%s is installed by %s; %s comes first on PATH and runs",
+			f.Name, strings.Join(sources, " and "), f.Winner)
+	case pathconflict.Shadowed:
+		return fmt.Sprintf("%s will not run by name: %s%s comes first on PATH (run it by its path, or move %s earlier in PATH)",
+			from(planned), f.Winner, commandOwner(ctx, f.Winner), filepath.Dir(planned.Path))
+	default:
+		others := make([]string, len(f.Others))
+		for i, other := range f.Others {
+			others[i] = other + commandOwner(ctx, other)
+		}
+		return fmt.Sprintf("%s will run instead of %s", from(planned), strings.Join(others, ", "))
+	}
+}
+
+// sourceName returns the display name of a source.
+func sourceName(name string) string {
+	if mgr, ok := registry.Get(name); ok {
+		return mgr.DisplayName()
+	}
+	return name
+}
+
+// commandOwner describes where an installed command comes from, as
+// " (from Cargo)" or " (package ripgrep)", or "" if that is not known.
+func commandOwner(ctx context.Context, cmdPath string) string {
+	if checker, ok := registry.Native().(manager.FileOwnershipChecker); ok {
+		if owners, _ := checker.OwnsFile(ctx, cmdPath); len(owners) > 0 { //nolint:errcheck
+			return fmt.Sprintf(" (package %s)", strings.Join(owners, ", "))
+		}
+	}
+	// Otherwise from a source with a directory of its own. It comes second,
+	// since npm's can be /usr/bin
+	dir := filepath.Dir(cmdPath)
+	for _, mgr := range registry.Available() {
+		if provider, ok := mgr.(manager.BinDirProvider); ok {
+			if binDir := provider.BinDir(ctx); binDir != "" && pathconflict.ResolveDir(binDir) == dir {
+				return fmt.Sprintf(" (from %s)", mgr.DisplayName())
+			}
+		}
+	}
+	return ""
+}
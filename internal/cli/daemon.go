@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"poxy/internal/daemon"
+	"poxy/internal/pin"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonInterval time.Duration
+	daemonShort    bool
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Refresh the package index in the background",
+	Long: `Run a background process that periodically rebuilds the package index
+and counts available updates.
+
+The daemon serves its status on a unix socket, so the TUI and shell
+prompts can show fresh update counts without querying package managers.
+It runs in the foreground; start it from your session manager or a
+systemd user service.
+
+Examples:
+  poxy daemon                  # Refresh every [daemon] interval (1h)
+  poxy daemon --interval 30m   # Refresh every 30 minutes
+  poxy daemon status           # Show the daemon status
+  poxy daemon status --short   # Print the update count for a shell prompt
+  poxy daemon refresh          # Refresh now
+  poxy daemon stop             # Stop the daemon`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the daemon status and cached update counts",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Ask the daemon to refresh now",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonSend(daemon.CmdRefresh, "Refresh requested"),
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonSend(daemon.CmdStop, "Daemon stopped"),
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 0, "refresh interval, e.g. 30m (default from [daemon] interval)")
+	daemonStatusCmd.Flags().BoolVar(&daemonShort, "short", false, "print only the number of updates (nothing if the daemon is not running)")
+
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonRefreshCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !cmd.Flags().Changed("interval") {
+		daemonInterval = cfg.Daemon.Interval
+	}
+	if daemonInterval <= 0 {
+		return fmt.Errorf("daemon interval must be positive")
+	}
+
+	socket := cfg.Daemon.SocketPath()
+	d := daemon.New(socket, daemonInterval, refreshIndex)
+
+	ui.InfoMsg("Refreshing every %s, status on %s", daemonInterval, socket)
+	return d.Run(ctx)
+}
+
+// refreshIndex rebuilds the search index and counts pending upgrades.
+func refreshIndex(ctx context.Context) (daemon.Result, error) {
+	engine := NewSearchEngine(registry)
+	if err := engine.BuildIndex(ctx); err != nil {
+		return daemon.Result{}, fmt.Errorf("failed to build index: %w", err)
+	}
+
+	result := daemon.Result{
+		IndexSize:       engine.IndexSize(),
+		UpdatesBySource: make(map[string]int),
+		Catalogs:        engine.CatalogTimes(),
+	}
+
+	pins, _ := pin.Load() //nolint:errcheck
+
+	for _, mgr := range getAvailableManagers() {
+		checker, ok := mgr.(manager.UpgradeChecker)
+		if !ok {
+			continue
+		}
+
+		upgrades, err := checker.ListUpgradable(ctx)
+		if err != nil {
+			continue
+		}
+		pins.MarkHeld(upgrades)
+		for _, u := range upgrades {
+			if !u.Held {
+				result.UpdatesBySource[mgr.Name()]++
+			}
+		}
+	}
+
+	// A failed check keeps the maintainers of the last one
+	if pacman, err := getPacman(); err == nil {
+		if alerts, _, err := checkAURMaintainers(ctx, pacman); err == nil {
+			result.AURAlerts = len(alerts)
+		}
+	}
+
+	return result, nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	status, err := daemon.Query(cfg.Daemon.SocketPath())
+	if daemonShort {
+		// Prompts call this on every render; stay quiet when there is nothing to show
+		if err == nil && status.Updates > 0 {
+			fmt.Println(status.Updates)
+		}
+		return nil
+	}
+	if errors.Is(err, daemon.ErrNotRunning) {
+		ui.InfoMsg("Daemon is not running")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ui.HeaderMsg("Daemon")
+	ui.Println("  PID:          %d", status.PID)
+	ui.Println("  Started:      %s", status.StartedAt.Format("2006-01-02 15:04:05"))
+	ui.Println("  Interval:     %s", status.Interval)
+	switch {
+	case status.Refreshing:
+		ui.Println("  Last refresh: in progress")
+	case status.LastRefresh.IsZero():
+		ui.Println("  Last refresh: never")
+	default:
+		ui.Println("  Last refresh: %s", status.LastRefresh.Format("2006-01-02 15:04:05"))
+		ui.Println("  Next refresh: %s", status.NextRefresh.Format("2006-01-02 15:04:05"))
+	}
+	ui.Println("  Indexed:      %d packages", status.IndexSize)
+	ui.Println("  Updates:      %d", status.Updates)
+
+	sources := make([]string, 0, len(status.UpdatesBySource))
+	for src := range status.UpdatesBySource {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+	for _, src := range sources {
+		ui.Println("    %-12s %d", src, status.UpdatesBySource[src])
+	}
+
+	if len(status.Catalogs) > 0 {
+		ui.Println("  Catalogs:")
+		sources = sources[:0]
+		for src := range status.Catalogs {
+			sources = append(sources, src)
+		}
+		sort.Strings(sources)
+		for _, src := range sources {
+			ui.Println("    %-12s %s", src, status.Catalogs[src].Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	if status.AURAlerts > 0 {
+		ui.WarningMsg("%d AUR package(s) changed hands; see 'poxy audit'", status.AURAlerts)
+	}
+	if status.LastError != "" {
+		ui.WarningMsg("Last refresh failed: %s", status.LastError)
+	}
+
+	return nil
+}
+
+// runDaemonSend returns a command handler that sends cmd to the daemon.
+func runDaemonSend(cmd, done string) func(*cobra.Command, []string) error {
+	return func(*cobra.Command, []string) error {
+		if _, err := daemon.Send(cfg.Daemon.SocketPath(), cmd); err != nil {
+			return err
+		}
+		ui.SuccessMsg("%s", done)
+		return nil
+	}
+}
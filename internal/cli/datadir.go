@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+
+	"poxy/internal/config"
+	"poxy/internal/history"
+	"poxy/internal/log"
+	"poxy/internal/ui"
+)
+
+var dataDirWarning sync.Once
+
+// operationSnapshot is the ID of the snapshot captured before the current
+// operation, linked from its history entries so poxy undo <id> can find it.
+var operationSnapshot string
+
+// warnDataDirReadOnly warns, once per run, that history and snapshots are
+// not being saved because the data directory is not writable, as happens
+// under sudo or with a read-only home.
+func warnDataDirReadOnly() {
+	dataDirWarning.Do(func() {
+		ui.WarningMsg("%s is not writable; history and snapshots are not being saved", config.DataDir())
+	})
+}
+
+// recordHistory saves a finished operation to the history. Failures never
+// fail the operation itself.
+func recordHistory(entry *history.Entry) {
+	announceReboot(entry)
+	log.Operation(string(entry.Operation), entry.Source, entry.Packages, entry.Error)
+	if ciMode {
+		return
+	}
+	if !config.DataDirWritable() {
+		warnDataDirReadOnly()
+		return
+	}
+
+	store, err := history.Open()
+	if err != nil {
+		if verbose {
+			ui.WarningMsg("Failed to record history: %v", err)
+		}
+		return
+	}
+	defer store.Close()
+
+	if entry.Snapshot == "" {
+		entry.Snapshot = operationSnapshot
+	}
+	if err := store.Record(entry); err != nil {
+		if verbose {
+			ui.WarningMsg("Failed to record history: %v", err)
+		}
+		return
+	}
+	printOperationID(entry)
+}
+
+// printOperationID prints the handle of a recorded install, uninstall,
+// upgrade or undo and the commands that take it.
+func printOperationID(entry *history.Entry) {
+	if cfg.General.DryRun {
+		return
+	}
+	switch entry.Operation {
+	case history.OpInstall, history.OpUninstall, history.OpUpgrade, history.OpUndo:
+	default:
+		return
+	}
+
+	code := entry.Code()
+	hint := fmt.Sprintf("run 'poxy history show %s'", code)
+	switch {
+	case entry.Snapshot != "":
+		hint += fmt.Sprintf(" or 'poxy undo %s'", code)
+	case entry.CanRollback():
+		hint += fmt.Sprintf(" or 'poxy rollback --id %s'", code)
+	}
+	ui.MutedMsg("Operation %s; %s", entry.ShortID(), hint)
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+	"poxy/pkg/manager/demo"
+
+	"github.com/spf13/cobra"
+)
+
+var demoCmd = &cobra.Command{
+	Use:   "demo [command...]",
+	Short: "Try poxy on a simulated system",
+	Long: `Run any poxy command, or the TUI, against a simulated Debian system
+with APT, Flatpak and Snap and a few dozen fake packages. Nothing runs as
+root and nothing on this system changes: the commands the real managers
+would run are only shown.
+
+The simulated system keeps its state, history and snapshots between runs
+in a directory of its own, so installs, upgrades and undo can be tried one
+after another. 'poxy demo --reset' starts over.
+
+Useful for screenshots, for getting to know poxy, and for trying Linux
+flows on macOS or Windows.
+
+Examples:
+  poxy demo                       # Open the TUI on the simulated system
+  poxy demo search spotify        # Search the simulated sources
+  poxy demo install htop spotify  # Install from APT and Flatpak
+  poxy demo upgrade --dry-run     # Show the upgrade plan
+  poxy demo undo                  # Undo the last change
+  poxy demo --reset               # Start over`,
+	DisableFlagParsing: true, // Flags belong to the command being run
+}
+
+func init() {
+	// Set here, since runDemo runs the root command that demoCmd is part of
+	demoCmd.RunE = runDemo
+	rootCmd.AddCommand(demoCmd)
+}
+
+// demoMode is set while a command runs against the simulated system.
+var demoMode bool
+
+// demoDir returns the directory the simulated system keeps its state,
+// history and snapshots in.
+func demoDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("poxy-demo-%d", os.Getuid()))
+}
+
+func runDemo(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "--help", "help":
+			return cmd.Help()
+		case "--reset":
+			if err := os.RemoveAll(demoDir()); err != nil {
+				return fmt.Errorf("failed to reset the demo: %w", err)
+			}
+			ui.SuccessMsg("The simulated system starts over on the next 'poxy demo'")
+			return nil
+		case cmd.Name():
+			return errors.New("already running a demo")
+		}
+	}
+	if len(args) == 0 {
+		args = []string{tuiCmd.Name()}
+	}
+
+	demoMode = true
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// simulateSystem fills the registry with the managers of the simulated
+// system, and keeps history, snapshots and pins in the demo directory.
+func simulateSystem() error {
+	if rootDir != "" {
+		return errors.New("--root cannot be used in a demo")
+	}
+
+	config.SetDataRoot(demoDir())
+	cfg.Policy.PromptPrivileges = false // Nothing privileged runs
+	sys, err := demo.Open(filepath.Join(config.DataDir(), "demo-system.json"))
+	if err != nil {
+		return err
+	}
+
+	managers := sys.Managers()
+	for _, mgr := range managers {
+		registry.Register(mgr)
+	}
+	registry.Simulate(sys.Info(), managers[0], "APT is the package manager of the simulated Debian system")
+
+	// On stderr, to keep JSON output parseable
+	ui.Muted.Fprintln(os.Stderr, "Demo: a simulated system; nothing on this machine changes ('poxy demo --reset' starts over)")
+	return nil
+}
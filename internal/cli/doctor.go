@@ -3,27 +3,70 @@ package cli
 import (
 	"context"
 
+	"poxy/internal/anomaly"
+	"poxy/internal/config"
+	"poxy/internal/doctor"
 	"poxy/internal/ui"
 	"poxy/pkg/manager"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	doctorChecks []string
+	doctorList   bool
+)
+
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Diagnose system issues",
 	Long: `Check for common issues with package managers and system
 configuration.
 
+After an overview of the detected package managers, doctor runs the
+health checks that apply to the system and suggests a fix for each
+problem: stale package databases, partial upgrades and stale database
+locks on Arch, broken packages on Debian, low disk space in /var/cache,
+pacman keyring problems and unmerged .pacnew/.pacsave files.
+
+Use 'poxy doctor orphans' to find packages and runtimes that are no
+longer needed.
+
 Examples:
-  poxy doctor               # Run diagnostics`,
+  poxy doctor                         # Run diagnostics
+  poxy doctor --list                  # List the health checks
+  poxy doctor --check partial-upgrade # Run a single check
+  poxy doctor orphans                 # Report orphaned packages and cruft`,
 	RunE: runDoctor,
 }
 
+func init() {
+	doctorCmd.Flags().StringSliceVar(&doctorChecks, "check", nil, "run only the named health checks")
+	doctorCmd.Flags().BoolVar(&doctorList, "list", false, "list the health checks")
+
+	_ = doctorCmd.RegisterFlagCompletionFunc("check", completeDoctorChecks) //nolint:errcheck
+}
+
 func runDoctor(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 	issues := 0
 
+	if doctorList {
+		for _, c := range doctor.Checks() {
+			ui.Println("%-18s %s", c.Name, c.Description)
+		}
+		return nil
+	}
+
+	if len(doctorChecks) > 0 {
+		issues, err := runHealthChecks(ctx, doctorChecks)
+		if err != nil {
+			return err
+		}
+		printDoctorSummary(issues)
+		return nil
+	}
+
 	ui.HeaderMsg("Running diagnostics...")
 
 	// Check system detection
@@ -66,7 +109,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	// Check universal managers
 	ui.HeaderMsg("Universal Package Managers")
 
-	universalManagers := []string{"flatpak", "snap"}
+	universalManagers := []string{"flatpak", "snap", "cargo", "npm", "gobin"}
 	for _, name := range universalManagers {
 		mgr, ok := registry.Get(name)
 		if ok && mgr.IsAvailable() {
@@ -104,15 +147,75 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Summary
+	found, err := runHealthChecks(ctx, nil)
+	if err != nil {
+		return err
+	}
+	issues += found
+
+	printDoctorSummary(issues)
+	return nil
+}
+
+// runHealthChecks runs the named health checks, or all that apply, and
+// prints each result. It returns the number of warnings and errors.
+func runHealthChecks(ctx context.Context, names []string) (int, error) {
+	reports, err := doctor.Run(ctx, doctor.NewEnv(registry), names)
+	if err != nil {
+		return 0, err
+	}
+
+	ui.HeaderMsg("Health Checks")
+
+	issues := 0
+	for _, report := range reports {
+		r := report.Result
+		switch r.Status {
+		case doctor.StatusOK:
+			ui.SuccessMsg("%s", r.Summary)
+		case doctor.StatusWarning:
+			ui.WarningMsg("%s", r.Summary)
+			issues++
+		case doctor.StatusError:
+			ui.ErrorMsg("%s", r.Summary)
+			issues++
+		case doctor.StatusSkipped:
+			ui.MutedMsg("  %s: %s", report.Check.Name, r.Summary)
+		}
+		for _, detail := range r.Details {
+			ui.MutedMsg("    %s", detail)
+		}
+		if r.Fix != "" {
+			ui.MutedMsg("  Fix: %s", r.Fix)
+		}
+	}
+	return issues, nil
+}
+
+// printDoctorSummary prints the closing line of poxy doctor.
+func printDoctorSummary(issues int) {
 	ui.HeaderMsg("Summary")
 	if issues == 0 {
 		ui.SuccessMsg("No issues found! Poxy is ready to use.")
 	} else {
 		ui.WarningMsg("Found %d issue(s). Some features may not work correctly.", issues)
 	}
+}
 
-	return nil
+// recordParserAnomalies logs package manager output the parsers could not
+// make sense of, for the parser-anomalies check. Verbose runs also print
+// them.
+func recordParserAnomalies() {
+	if !config.DataDirWritable() {
+		return
+	}
+	log := anomaly.Open()
+	manager.SetAnomalyRecorder(func(a manager.ParserAnomaly) {
+		if verbose {
+			ui.WarningMsg("Could not parse the output of %s: %s", a.Command, a.Reason)
+		}
+		_ = log.Record(a) //nolint:errcheck
+	})
 }
 
 func defaultSearchOpts() manager.SearchOpts {
@@ -17,4 +17,8 @@ var (
 
 	// ErrAborted is returned when the user aborts an operation.
 	ErrAborted = errors.New("operation aborted by user")
+
+	// ErrPartialFailure is wrapped by errors of operations in which some
+	// packages were changed and others failed.
+	ErrPartialFailure = errors.New("operation partially failed")
 )
@@ -0,0 +1,330 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"poxy/internal/history"
+	"poxy/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var explainErrorCmd = &cobra.Command{
+	Use:   "explain-error [id]",
+	Short: "Explain why an operation failed",
+	Long: `Show a post-mortem for a failed operation: the parsed error,
+likely causes, and concrete commands that may fix it.
+
+Without an ID, the most recent failed operation is explained.
+Operation IDs are shown by 'poxy history'.
+
+Examples:
+  poxy explain-error                       # Explain the last failure
+  poxy explain-error 20240101120000.000000 # Explain a specific operation`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExplainError,
+}
+
+// errorKind describes a category of failure and how to recover from it.
+type errorKind struct {
+	name     string
+	title    string
+	patterns []*regexp.Regexp
+	causes   []string
+	remedies func(entry *history.Entry) []string
+}
+
+// errorKinds is checked in order; the first matching kind wins. Keyring
+// failures come before missing packages, since their messages can name
+// a key or signature that was not found.
+var errorKinds = []errorKind{
+	{
+		name:  "pkgbuild_risk",
+		title: "PKGBUILD risk score above the limit",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)PKGBUILD risk score too high`),
+		},
+		causes: []string{
+			"Static analysis of the PKGBUILD found risky commands, prebuilt binaries or unverified sources",
+			"The score is above [managers.aur] max_risk in the config",
+		},
+		remedies: func(entry *history.Entry) []string {
+			remedies := []string{}
+			for _, pkg := range entry.Packages {
+				remedies = append(remedies, fmt.Sprintf("poxy info -s aur %s   # check votes, maintainer and comments", pkg))
+			}
+			return append(remedies, fmt.Sprintf("poxy install --force -s aur %s   # only after reviewing the PKGBUILD", strings.Join(entry.Packages, " ")))
+		},
+	},
+	{
+		name:  "database_locked",
+		title: "Package database is locked",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)unable to lock database`),
+			regexp.MustCompile(`(?i)could not get lock`),
+			regexp.MustCompile(`(?i)waiting for cache lock`),
+			regexp.MustCompile(`(?i)another app is currently holding`),
+		},
+		causes: []string{
+			"Another package manager or software center is running",
+			"A previous operation crashed and left a stale lock file",
+		},
+		remedies: func(entry *history.Entry) []string {
+			switch entry.Source {
+			case "pacman", "aur":
+				return []string{
+					"pgrep -a 'pacman|yay|paru'   # check for running package managers",
+					"sudo rm /var/lib/pacman/db.lck   # only if nothing is running",
+				}
+			case "apt":
+				return []string{
+					"sudo lsof /var/lib/dpkg/lock-frontend   # find the lock holder",
+					"sudo dpkg --configure -a   # recover an interrupted dpkg run",
+				}
+			}
+			return []string{"Wait for the other package manager to finish, then retry"}
+		},
+	},
+	{
+		name:  "dependency_conflict",
+		title: "Dependency conflict",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)could not satisfy dependencies`),
+			regexp.MustCompile(`(?i)are in conflict`),
+			regexp.MustCompile(`(?i)unmet dependencies`),
+			regexp.MustCompile(`(?i)conflicting requests`),
+		},
+		causes: []string{
+			"Installed packages are older than the repository expects (partial upgrade)",
+			"Two packages provide the same files or capability",
+		},
+		remedies: func(entry *history.Entry) []string {
+			return []string{
+				"poxy update && poxy upgrade",
+				retryCommand(entry),
+			}
+		},
+	},
+	{
+		name:  "keyring",
+		title: "Signature or keyring problem",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)invalid or corrupted package`),
+			regexp.MustCompile(`(?i)signature .* is (unknown trust|invalid)`),
+			regexp.MustCompile(`(?i)NO_PUBKEY`),
+			regexp.MustCompile(`(?i)\bGPG (error|check FAILED)\b`),
+			regexp.MustCompile(`(?i)public key for \S+ is not installed`),
+			regexp.MustCompile(`(?i)trustdb`),
+		},
+		causes: []string{
+			"The distribution keyring is outdated",
+			"A repository signing key is missing",
+			"The keyring trust database is corrupted",
+		},
+		remedies: func(entry *history.Entry) []string {
+			if entry.Source == "pacman" || entry.Source == "aur" {
+				return []string{
+					"poxy keyring check",
+					"poxy keyring repair",
+					retryCommand(entry),
+				}
+			}
+			return []string{"poxy update", "poxy doctor"}
+		},
+	},
+	{
+		name:  "not_found",
+		title: "Package not found",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\btarget not found\b`),                 // pacman
+			regexp.MustCompile(`(?i)\bunable to locate package\b`),         // apt
+			regexp.MustCompile(`(?i)\bno match for argument\b`),            // dnf
+			regexp.MustCompile(`(?i)\bno package \S+ available\b`),         // yum
+			regexp.MustCompile(`(?i)\b(package|snap) '[^']+' not found\b`), // poxy's own lookups
+		},
+		causes: []string{
+			"The package name is misspelled or differs on this distribution",
+			"The package database is out of date",
+			"The package is only available from another source",
+		},
+		remedies: func(entry *history.Entry) []string {
+			remedies := []string{"poxy update"}
+			for _, pkg := range entry.Packages {
+				remedies = append(remedies, fmt.Sprintf("poxy search %s", pkg))
+			}
+			return remedies
+		},
+	},
+	{
+		name:  "permission",
+		title: "Insufficient privileges",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)permission denied`),
+			regexp.MustCompile(`(?i)requires root`),
+			regexp.MustCompile(`(?i)are you root`),
+			regexp.MustCompile(`(?i)unless you are root`),
+			regexp.MustCompile(`(?i)not in the sudoers`),
+		},
+		causes: []string{
+			"sudo is unavailable or the password prompt was cancelled",
+			"The user is not allowed to run the package manager as root",
+		},
+		remedies: func(entry *history.Entry) []string {
+			return []string{"sudo -v   # verify sudo access", "poxy doctor"}
+		},
+	},
+	{
+		name:  "network",
+		title: "Network failure",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)could not resolve`),
+			regexp.MustCompile(`(?i)temporary failure`),
+			regexp.MustCompile(`(?i)failed retrieving file`),
+			regexp.MustCompile(`(?i)connection (refused|timed out)`),
+		},
+		causes: []string{
+			"No network connection or DNS failure",
+			"A mirror is down or out of sync",
+		},
+		remedies: func(entry *history.Entry) []string {
+			return []string{"poxy update", retryCommand(entry)}
+		},
+	},
+	{
+		name:  "disk_full",
+		title: "Not enough disk space",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)no space left`),
+			regexp.MustCompile(`(?i)not enough free disk space`),
+		},
+		causes: []string{
+			"The package cache has grown large",
+			"The root or cache partition is full",
+		},
+		remedies: func(entry *history.Entry) []string {
+			return []string{"poxy clean --all", "poxy autoremove", retryCommand(entry)}
+		},
+	},
+}
+
+// unknownKind is used when no pattern matches.
+var unknownKind = errorKind{
+	name:  "unknown",
+	title: "Unrecognized failure",
+	causes: []string{
+		"The package manager reported an error poxy could not classify",
+	},
+	remedies: func(entry *history.Entry) []string {
+		return []string{retryCommand(entry) + " -v   # rerun with verbose output", "poxy doctor"}
+	},
+}
+
+func runExplainError(cmd *cobra.Command, args []string) error {
+	store, err := history.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer store.Close()
+
+	var entry *history.Entry
+	if len(args) > 0 {
+		entry, err = store.Get(args[0])
+		if err != nil {
+			return fmt.Errorf("operation not found: %s", args[0])
+		}
+	} else {
+		entry, err = store.LastFailed()
+		if err != nil {
+			ui.SuccessMsg("No failed operations in recent history")
+			return nil
+		}
+	}
+
+	if entry.Success {
+		ui.SuccessMsg("Operation %s succeeded; nothing to explain", entry.ID)
+		return nil
+	}
+
+	kind := classifyError(entry)
+
+	ui.HeaderMsg("Failure: %s", kind.title)
+	ui.Println("")
+	ui.Println("  Operation: %s %s [%s]", entry.Operation, formatPackages(entry.Packages), entry.Source)
+	ui.Println("  When:      %s", entry.FormatTime())
+	ui.Println("  ID:        %s", entry.ID)
+	if entry.Error != "" {
+		ui.Println("  Error:     %s", entry.Error)
+	}
+	ui.Println("")
+
+	if d := entry.ErrorDetails; d != nil {
+		if len(d.Packages) > 0 {
+			ui.InfoMsg("Affected packages:")
+			for _, pkg := range d.Packages {
+				ui.Println("  - %s", pkg)
+			}
+			ui.Println("")
+		}
+		if out := strings.TrimSpace(d.Output); out != "" {
+			ui.InfoMsg("Backend output:")
+			for _, line := range strings.Split(out, "\n") {
+				ui.MutedMsg("  %s", line)
+			}
+			ui.Println("")
+		}
+	}
+
+	ui.InfoMsg("Likely causes:")
+	for _, cause := range kind.causes {
+		ui.Println("  - %s", cause)
+	}
+	ui.Println("")
+
+	ui.InfoMsg("Suggested commands:")
+	if d := entry.ErrorDetails; d != nil && d.Suggestion != "" {
+		ui.Println("  %s", d.Suggestion)
+	}
+	for _, remedy := range kind.remedies(entry) {
+		ui.Println("  %s", ui.Cyan(remedy))
+	}
+
+	return nil
+}
+
+// classifyError determines the error kind from stored details or, failing that,
+// by matching known patterns against the error text.
+func classifyError(entry *history.Entry) errorKind {
+	text := entry.Error
+	if d := entry.ErrorDetails; d != nil {
+		for _, kind := range errorKinds {
+			if kind.name == d.Kind {
+				return kind
+			}
+		}
+		text += "\n" + d.Output
+	}
+
+	for _, kind := range errorKinds {
+		for _, pattern := range kind.patterns {
+			if pattern.MatchString(text) {
+				return kind
+			}
+		}
+	}
+
+	return unknownKind
+}
+
+// retryCommand returns the poxy command that would repeat the failed operation.
+func retryCommand(entry *history.Entry) string {
+	cmd := fmt.Sprintf("poxy %s", entry.Operation)
+	if len(entry.Packages) > 0 {
+		cmd += " " + strings.Join(entry.Packages, " ")
+	}
+	if entry.Source != "" {
+		cmd += " -s " + entry.Source
+	}
+	return cmd
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"testing"
+
+	"poxy/internal/history"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"pacman missing target", "error: target not found: firefx", "not_found"},
+		{"apt missing package", "E: Unable to locate package firefx", "not_found"},
+		{"dnf missing package", "No match for argument: firefx\nError: Unable to find a match: firefx", "not_found"},
+		{"yum missing package", "No package firefx available.", "not_found"},
+		{"poxy lookup", "package 'firefx' not found", "not_found"},
+		{"pacman unknown key", "error: key \"ABCD1234\" could not be looked up remotely\nerror: firefox: signature from \"Jane\" is unknown trust", "keyring"},
+		{"pacman corrupted package", "error: firefox: signature from \"Jane\" is invalid\nerror: failed to commit transaction (invalid or corrupted package (PGP signature))", "keyring"},
+		{"apt missing key", "W: GPG error: https://example.org stable InRelease: NO_PUBKEY 0123456789ABCDEF", "keyring"},
+		{"dnf missing key", "Public key for firefox-131.0-1.fc41.x86_64.rpm is not installed", "keyring"},
+		{"keyring file not found", "gpg: keyring file not found\nerror: GPG error while verifying", "keyring"},
+		{"pacman lock", "error: failed to init transaction (unable to lock database)", "database_locked"},
+		{"apt lock", "E: Could not get lock /var/lib/dpkg/lock-frontend", "database_locked"},
+		{"pacman conflict", "error: failed to prepare transaction (could not satisfy dependencies)", "dependency_conflict"},
+		{"apt unmet", "The following packages have unmet dependencies:", "dependency_conflict"},
+		{"permission", "error: you cannot perform this operation unless you are root.", "permission"},
+		{"network", "error: failed retrieving file 'core.db' from mirror : Could not resolve host", "network"},
+		{"disk", "error: Partition / too full: not enough free disk space", "disk_full"},
+		{"command not found", "exec: \"yay\": executable file not found in $PATH", "unknown"},
+		{"file not found", "open /etc/poxy/config.toml: file not found", "unknown"},
+		{"gpg-agent mention", "building gpgme failed: make exited with status 2", "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &history.Entry{Error: tt.text}
+			if got := classifyError(entry).name; got != tt.want {
+				t.Errorf("classifyError(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorPrefersParsedKind(t *testing.T) {
+	entry := &history.Entry{
+		Error:        "error: target not found: firefx",
+		ErrorDetails: &history.ErrorDetails{Kind: "database_locked"},
+	}
+	if got := classifyError(entry).name; got != "database_locked" {
+		t.Errorf("classifyError() = %s, want the parsed kind database_locked", got)
+	}
+}
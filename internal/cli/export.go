@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"poxy/internal/export"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportTarget string
+	exportFile   string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export installed packages to reinstall them elsewhere",
+	Long: `Export the explicitly installed packages of every available source as
+a bootstrap script or a manifest, to set up another machine the same way.
+Packages installed as dependencies are left out.
+
+  script  A shell script running 'poxy install' for each source
+  toml    A manifest of packages by source
+  json    The same manifest as JSON
+
+With --target, packages of the native manager and the AUR are renamed to
+their names in another distro's package manager using poxy's package
+mappings, e.g. pacman's "gvim" becomes apt's "vim-gtk3". Flatpaks, snaps
+and other cross-distro sources are kept as they are. Packages without a
+known name in the target are listed separately rather than guessed.
+
+The export is written to stdout unless --file is given.
+
+Examples:
+  poxy export > bootstrap.sh
+  poxy export --format toml --file packages.toml
+  poxy export --target apt > debian-bootstrap.sh
+  poxy export -s flatpak --format json`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", export.FormatScript, "export format (script, toml, json)")
+	exportCmd.Flags().StringVar(&exportTarget, "target", "", "translate package names to this native source, e.g. apt")
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "write the export to a file instead of stdout")
+	_ = exportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(export.Formats(), cobra.ShellCompDirectiveNoFileComp)) //nolint:errcheck
+	_ = exportCmd.RegisterFlagCompletionFunc("target", completeSources)                                                              //nolint:errcheck
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if !slices.Contains(export.Formats(), exportFormat) {
+		return fmt.Errorf("unknown format %q (valid: %s)", exportFormat, strings.Join(export.Formats(), ", "))
+	}
+	if exportTarget != "" {
+		mgr, ok := registry.Get(exportTarget)
+		if !ok || mgr.Type() != manager.TypeNative {
+			return fmt.Errorf("unknown target %q: expected a native package manager such as apt, dnf or pacman", exportTarget)
+		}
+	}
+
+	mgrs := getAvailableManagers()
+	if source != "" {
+		mgr, err := registry.GetManagerForSource(source)
+		if err != nil {
+			return err
+		}
+		mgrs = []manager.Manager{mgr}
+	}
+
+	var packages []export.Package
+	var distro []string
+	for _, mgr := range mgrs {
+		installed, err := mgr.ListInstalled(ctx, manager.ListOpts{})
+		if err != nil {
+			return fmt.Errorf("failed to list %s packages: %w (use --source to export the other sources)", mgr.Name(), err)
+		}
+		for _, pkg := range explicitPackages(ctx, mgr, installed, 0) {
+			packages = append(packages, export.Package{Source: mgr.Name(), Name: pkg.Name})
+		}
+		if mgr.Type() == manager.TypeNative || mgr.Type() == manager.TypeAUR {
+			distro = append(distro, mgr.Name())
+		}
+	}
+
+	manifest := export.New(packages)
+	if exportTarget != "" {
+		manifest.Translate(exportTarget, distro, packageMappings())
+	}
+
+	if exportFile == "" {
+		return manifest.Write(os.Stdout, exportFormat)
+	}
+	if err := manifest.WriteFile(exportFile, exportFormat); err != nil {
+		return err
+	}
+
+	ui.SuccessMsg("Exported %d packages to %s", len(manifest.Packages), exportFile)
+	if len(manifest.Unmapped) > 0 {
+		ui.WarningMsg("%d packages have no known %s name and were left out", len(manifest.Unmapped), exportTarget)
+	}
+	return nil
+}
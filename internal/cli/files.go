@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var ownsCmd = &cobra.Command{
+	Use:   "owns <path>",
+	Short: "Show which package owns a file",
+	Long: `Show which installed package owns a file.
+
+A bare command name is looked up in PATH, so 'poxy owns vim' works like
+'poxy owns /usr/bin/vim'. Every package manager that tracks file
+ownership is asked unless --source is given.
+
+Supported by pacman, APT (dpkg), DNF and Zypper (rpm).
+
+Examples:
+  poxy owns /usr/bin/vim     # Which package installed vim?
+  poxy owns ls               # Same, looking ls up in PATH
+  poxy owns -s apt /etc/hosts`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOwns,
+}
+
+var filesCmd = &cobra.Command{
+	Use:   "files <package>",
+	Short: "List the files installed by a package",
+	Long: `List the files installed by a package, one per line.
+
+Supported by pacman, APT (dpkg), DNF and Zypper (rpm).
+
+Examples:
+  poxy files vim             # Files of vim from the native manager
+  poxy files vim | grep bin  # Only its executables`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingle(completeInstalledPackages),
+	RunE:              runFiles,
+}
+
+func runOwns(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	path, err := ownedPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	var managers []manager.Manager
+	if source != "" {
+		mgr, err := getManager()
+		if err != nil {
+			return err
+		}
+		if _, ok := mgr.(manager.FileOwnershipChecker); !ok {
+			return fmt.Errorf("%s does not track file ownership", mgr.DisplayName())
+		}
+		managers = []manager.Manager{mgr}
+	} else {
+		managers = getAvailableManagers()
+	}
+
+	found, checked := false, 0
+	for _, mgr := range managers {
+		checker, ok := mgr.(manager.FileOwnershipChecker)
+		if !ok {
+			continue
+		}
+		checked++
+
+		owners, err := checker.OwnsFile(ctx, path)
+		if err != nil {
+			ui.WarningMsg("%s: %v", mgr.DisplayName(), err)
+			continue
+		}
+		for _, owner := range owners {
+			ui.SuccessMsg("%s is owned by %s (%s)", path, owner, mgr.Name())
+			found = true
+		}
+	}
+
+	if checked == 0 {
+		return fmt.Errorf("no available package manager tracks file ownership")
+	}
+	if !found {
+		return fmt.Errorf("no package owns %s", path)
+	}
+	return nil
+}
+
+// ownedPath returns the absolute path to look up. Bare command names are
+// looked up in PATH.
+func ownedPath(arg string) (string, error) {
+	path := arg
+	if !strings.ContainsRune(arg, filepath.Separator) {
+		if _, err := os.Stat(arg); err != nil {
+			if found, lookErr := exec.LookPath(arg); lookErr == nil {
+				path = found
+			}
+		}
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return "", fmt.Errorf("%s: no such file or directory", arg)
+	}
+	return path, nil
+}
+
+func runFiles(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	mgr, err := getManager()
+	if err != nil {
+		return err
+	}
+
+	checker, ok := mgr.(manager.FileOwnershipChecker)
+	if !ok {
+		return fmt.Errorf("%s cannot list package files", mgr.DisplayName())
+	}
+
+	pkg := resolvePackages(mgr, args)[0]
+	files, err := checker.ListFiles(ctx, pkg)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		fmt.Println(file)
+	}
+	return nil
+}
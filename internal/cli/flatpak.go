@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+	"poxy/pkg/manager/universal"
+
+	"github.com/spf13/cobra"
+)
+
+var flatpakCmd = &cobra.Command{
+	Use:   "flatpak",
+	Short: "Flatpak-specific tools",
+	Long: `Tools for managing parts of Flatpak that are not covered by the
+package-centric commands.
+
+Examples:
+  poxy flatpak runtimes             # List installed runtimes
+  poxy flatpak runtimes --clean     # Remove unused runtimes
+  poxy flatpak permissions org.mozilla.firefox  # Show an app's permissions`,
+}
+
+var flatpakRuntimesCmd = &cobra.Command{
+	Use:   "runtimes",
+	Short: "List installed Flatpak runtimes",
+	Long: `List installed Flatpak runtimes and extensions together with the
+applications that depend on each one.
+
+Runtimes marked end-of-life no longer receive updates and are highlighted.
+Runtimes no application depends on are marked unused and can be removed
+with --clean.
+
+Examples:
+  poxy flatpak runtimes             # List runtimes and their apps
+  poxy flatpak runtimes --clean     # Remove unused runtimes
+  poxy flatpak runtimes --clean -n  # Show what would be removed`,
+	Args: cobra.NoArgs,
+	RunE: runFlatpakRuntimes,
+}
+
+var flatpakPermissionsCmd = &cobra.Command{
+	Use:   "permissions <app>",
+	Short: "Show and override a Flatpak app's permissions",
+	Long: `Show what an installed Flatpak app may access outside its sandbox: the
+network, sockets such as X11 and Wayland, devices, filesystem locations,
+D-Bus names and environment variables. Permissions you overrode are listed
+separately, revoked ones starting with "!".
+
+--grant and --revoke override permissions for the current user with
+flatpak override; --reset removes your overrides. A permission is written
+as kind=value, where kind is one of share, socket, device, filesystem,
+feature, talk-name, own-name, system-talk-name, system-own-name and env.
+
+Examples:
+  poxy flatpak permissions org.mozilla.firefox
+  poxy flatpak permissions com.valvesoftware.Steam --grant filesystem=~/Games
+  poxy flatpak permissions org.example.App --revoke share=network
+  poxy flatpak permissions org.example.App --reset`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingle(completeInstalledPackages),
+	RunE:              runFlatpakPermissions,
+}
+
+var (
+	flatpakRuntimesClean bool
+	flatpakGrant         []string
+	flatpakRevoke        []string
+	flatpakReset         bool
+	flatpakPermsJSON     bool
+)
+
+func init() {
+	flatpakCmd.AddCommand(flatpakRuntimesCmd)
+	flatpakCmd.AddCommand(flatpakPermissionsCmd)
+
+	flatpakRuntimesCmd.Flags().BoolVar(&flatpakRuntimesClean, "clean", false, "remove runtimes no application uses")
+
+	flatpakPermissionsCmd.Flags().StringArrayVar(&flatpakGrant, "grant", nil, "grant a permission, as kind=value (repeatable)")
+	flatpakPermissionsCmd.Flags().StringArrayVar(&flatpakRevoke, "revoke", nil, "revoke a permission, as kind=value (repeatable)")
+	flatpakPermissionsCmd.Flags().BoolVar(&flatpakReset, "reset", false, "remove your overrides of the app")
+	flatpakPermissionsCmd.Flags().BoolVar(&flatpakPermsJSON, "json", false, "print the permissions as JSON")
+}
+
+// getFlatpak returns the registered Flatpak manager.
+func getFlatpak() (*universal.Flatpak, error) {
+	mgr, ok := registry.Get("flatpak")
+	if !ok || !mgr.IsAvailable() {
+		return nil, fmt.Errorf("flatpak is not available on this system")
+	}
+
+	fp, ok := mgr.(*universal.Flatpak)
+	if !ok {
+		return nil, fmt.Errorf("flatpak is not available on this system")
+	}
+	return fp, nil
+}
+
+func runFlatpakRuntimes(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	fp, err := getFlatpak()
+	if err != nil {
+		return err
+	}
+
+	runtimes, err := fp.ListRuntimes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list runtimes: %w", err)
+	}
+
+	if len(runtimes) == 0 {
+		ui.InfoMsg("No Flatpak runtimes installed")
+		return nil
+	}
+
+	var unused, eol int
+	ui.HeaderMsg("Flatpak Runtimes")
+	ui.Println("")
+
+	for _, rt := range runtimes {
+		ref := rt.ID + "//" + rt.Branch
+		size := rt.Size
+		if size == "" {
+			size = "-"
+		}
+
+		switch {
+		case rt.IsEOL():
+			eol++
+			ui.Println("  %s  %s  %s", ui.Red(ref), size, ui.Red("end-of-life"))
+			ui.MutedMsg("      %s", rt.EOL)
+		case rt.Unused:
+			ui.MutedMsg("  %s  %s  unused", ref, size)
+		default:
+			ui.Println("  %s  %s", ui.Cyan(ref), size)
+		}
+
+		if rt.Unused {
+			unused++
+		}
+		if len(rt.Apps) > 0 {
+			ui.MutedMsg("      used by: %s", strings.Join(rt.Apps, ", "))
+		}
+	}
+
+	ui.Println("")
+	ui.MutedMsg("%d runtimes, %d unused, %d end-of-life", len(runtimes), unused, eol)
+
+	if !flatpakRuntimesClean {
+		if unused > 0 {
+			ui.MutedMsg("Remove unused runtimes with: poxy flatpak runtimes --clean")
+		}
+		return nil
+	}
+
+	if unused == 0 {
+		ui.SuccessMsg("No unused runtimes to remove")
+		return nil
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Remove %d unused runtimes?", unused), true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	if err := fp.RemoveUnusedRuntimes(ctx, cfg.General.DryRun); err != nil {
+		return fmt.Errorf("failed to remove unused runtimes: %w", err)
+	}
+
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("Removed unused runtimes")
+	}
+
+	return nil
+}
+
+func runFlatpakPermissions(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	app := args[0]
+
+	fp, err := getFlatpak()
+	if err != nil {
+		return err
+	}
+
+	var overrides []manager.PermissionOverride
+	for _, spec := range flatpakGrant {
+		o, err := manager.ParsePermissionOverride("+" + spec)
+		if err != nil {
+			return err
+		}
+		overrides = append(overrides, o)
+	}
+	for _, spec := range flatpakRevoke {
+		o, err := manager.ParsePermissionOverride("-" + spec)
+		if err != nil {
+			return err
+		}
+		overrides = append(overrides, o)
+	}
+
+	if flatpakReset {
+		if err := fp.ResetPermissions(ctx, app, cfg.General.DryRun); err != nil {
+			return err
+		}
+		if !cfg.General.DryRun {
+			ui.SuccessMsg("Removed your overrides of %s", app)
+		}
+	}
+	if len(overrides) > 0 {
+		if err := fp.OverridePermissions(ctx, app, overrides, cfg.General.DryRun); err != nil {
+			return err
+		}
+		if !cfg.General.DryRun {
+			ui.SuccessMsg("Overrode %d permission(s) of %s", len(overrides), app)
+		}
+	}
+	if cfg.General.DryRun && (flatpakReset || len(overrides) > 0) {
+		return nil
+	}
+
+	perms, err := fp.Permissions(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	if flatpakPermsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(perms)
+	}
+
+	ui.HeaderMsg("Permissions of %s", app)
+	network := "no"
+	if perms.Network() {
+		network = "yes"
+	}
+	ui.Println("  %-12s %s", "Network", network)
+	printPermissionGroups(perms.Groups())
+	if perms.Overrides != nil {
+		ui.Println("")
+		ui.HeaderMsg("Your overrides")
+		printPermissionGroups(perms.Overrides.Groups())
+		ui.MutedMsg("Remove them with: poxy flatpak permissions %s --reset", app)
+	}
+	return nil
+}
+
+// printPermissionGroups prints permission groups, one per line.
+func printPermissionGroups(groups []manager.PermissionGroup) {
+	for _, g := range groups {
+		ui.Println("  %-12s %s", g.Label, strings.Join(g.Values, ", "))
+	}
+}
@@ -1,39 +1,192 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"poxy/internal/config"
 	"poxy/internal/history"
 	"poxy/internal/ui"
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
 
 	"github.com/spf13/cobra"
 )
 
-var historyLimit int
+var (
+	historyLimit     int
+	historyOp        string
+	historyPackage   string
+	historySince     string
+	historyUntil     string
+	historyFailed    bool
+	historySucceeded bool
+)
 
 var historyCmd = &cobra.Command{
 	Use:   "history",
 	Short: "Show operation history",
 	Long: `Display the history of package operations performed by poxy.
 
+Filters can be combined; --source limits the history to one package
+manager. Dates are YYYY-MM-DD or RFC 3339 timestamps, and --until includes
+the whole day.
+
+Examples:
+  poxy history                          # Show recent history
+  poxy history -l 20                    # Show last 20 operations
+  poxy history --package vim            # Operations on vim
+  poxy history --op upgrade --failed    # Failed upgrades
+  poxy history -s apt --since 2024-01-01`,
+	RunE: runHistory,
+}
+
+var historySearchCmd = &cobra.Command{
+	Use:   "search <term>",
+	Short: "Search the operation history",
+	Long: `Search the history for operations whose packages, source, operation or
+error contain a term. The filters of poxy history apply as well.
+
 Examples:
-  poxy history              # Show recent history
-  poxy history -l 20        # Show last 20 operations`,
+  poxy history search firefox           # Operations involving firefox
+  poxy history search locked --failed   # Failures mentioning a lock`,
+	Args: cobra.ExactArgs(1),
 	RunE: runHistory,
 }
 
+var historyShowCmd = &cobra.Command{
+	Use:   "show <operation-id>",
+	Short: "Show one recorded operation",
+	Long: `Show everything recorded about an operation: its packages, why poxy
+picked their source, the services it enabled, the snapshot taken before it,
+and for failures the error and the output of the package manager.
+
+Operations are referred to by their full ID, their short ID (e.g.
+2025-06-01-ab12), or its last four characters, which poxy prints at the end
+of every install, uninstall and upgrade. Four characters pick the most
+recent operation that has them.
+
+Examples:
+  poxy history show ab12                  # The operation poxy just printed
+  poxy history show 2025-06-01-ab12`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryShow,
+}
+
+var historyRedoCmd = &cobra.Command{
+	Use:   "redo <entry-id>",
+	Short: "Re-run a recorded operation",
+	Long: `Re-execute the operation of a history entry with the same package
+manager: install or remove the same packages, or repeat an update, upgrade
+or clean. Useful after reinstalling the system, or to retry an operation
+once a transient failure is fixed.
+
+Entry IDs are shown by poxy history; short IDs work too. Undo entries
+cannot be redone.
+
+Examples:
+  poxy history --failed                     # Find the failed operation
+  poxy history redo 20240114153045.123456   # Run it again`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryRedo,
+}
+
 func init() {
-	historyCmd.Flags().IntVarP(&historyLimit, "limit", "l", 10, "number of entries to show")
+	flags := historyCmd.PersistentFlags()
+	flags.IntVarP(&historyLimit, "limit", "l", 0, "number of entries to show (default from [limits] history)")
+	flags.StringVar(&historyOp, "op", "", "only show this operation (install, uninstall, update, upgrade, clean, undo)")
+	flags.StringVar(&historyPackage, "package", "", "only show operations on this package")
+	flags.StringVar(&historySince, "since", "", "only show operations on or after this date")
+	flags.StringVar(&historyUntil, "until", "", "only show operations on or before this date")
+	flags.BoolVar(&historyFailed, "failed", false, "only show failed operations")
+	flags.BoolVar(&historySucceeded, "succeeded", false, "only show successful operations")
+	historyCmd.MarkFlagsMutuallyExclusive("failed", "succeeded")
+
+	historyCmd.AddCommand(historySearchCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historyRedoCmd)
+}
+
+// historyQuery builds the history query from the filter flags and, for
+// poxy history search, the search term.
+func historyQuery(cmd *cobra.Command, args []string) (history.Query, error) {
+	query := history.Query{
+		Package: historyPackage,
+		Source:  source,
+		Limit:   limitFlag(cmd, historyLimit, cfg.Limits.History),
+	}
+	if len(args) > 0 {
+		query.Text = args[0]
+	}
+
+	if historyOp != "" {
+		op := history.Operation(strings.ToLower(historyOp))
+		switch op {
+		case history.OpInstall, history.OpUninstall, history.OpUpdate, history.OpUpgrade, history.OpClean, history.OpUndo:
+			query.Operation = op
+		default:
+			return query, fmt.Errorf("unknown operation %q", historyOp)
+		}
+	}
+
+	if historySince != "" {
+		since, err := parseSince(historySince)
+		if err != nil {
+			return query, err
+		}
+		query.Since = since
+	}
+	if historyUntil != "" {
+		until, err := parseAsOf(historyUntil)
+		if err != nil {
+			return query, err
+		}
+		query.Until = until
+	}
+
+	switch {
+	case historyFailed:
+		query.Status = history.StatusFailed
+	case historySucceeded:
+		query.Status = history.StatusSucceeded
+	}
+
+	return query, nil
+}
+
+// parseSince parses a date or timestamp. A bare date refers to the start of
+// that day.
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", value)
+	}
+	return t, nil
 }
 
 func runHistory(cmd *cobra.Command, args []string) error {
+	query, err := historyQuery(cmd, args)
+	if err != nil {
+		return err
+	}
+
 	store, err := history.Open()
+	if errors.Is(err, config.ErrDataDirReadOnly) {
+		ui.MutedMsg("No history entries found")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open history: %w", err)
 	}
 	defer store.Close()
 
-	entries, err := store.List(historyLimit)
+	entries, err := store.Query(query)
 	if err != nil {
 		return fmt.Errorf("failed to read history: %w", err)
 	}
@@ -66,17 +219,188 @@ func runHistory(cmd *cobra.Command, args []string) error {
 			reverseIndicator,
 		)
 
+		for _, r := range entry.Resolutions {
+			ui.MutedMsg("    %s", describeResolution(r))
+		}
+		if entry.RedoOf != "" {
+			ui.MutedMsg("    Redo of %s", entry.RedoOf)
+		}
+
 		if entry.Error != "" {
 			ui.MutedMsg("    Error: %s", entry.Error)
+			ui.MutedMsg("    ID:    %s (poxy explain-error %s)", entry.ShortID(), entry.ID)
+		} else {
+			ui.MutedMsg("    ID:    %s", entry.ShortID())
 		}
 	}
 
 	total, _ := store.Count() //nolint:errcheck
-	ui.MutedMsg("\nShowing %d of %d total entries", len(entries), total)
+	if query == (history.Query{Limit: query.Limit}) {
+		ui.MutedMsg("\nShowing %d of %d total entries", len(entries), total)
+	} else {
+		ui.MutedMsg("\nShowing %d matching of %d total entries", len(entries), total)
+	}
 
 	return nil
 }
 
+func runHistoryShow(cmd *cobra.Command, args []string) error {
+	store, err := history.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	entry, err := store.Get(args[0])
+	store.Close()
+	if err != nil {
+		return fmt.Errorf("operation not found: %s", args[0])
+	}
+
+	status := ui.Green("success")
+	if !entry.Success {
+		status = ui.Red("failed")
+	}
+
+	ui.HeaderMsg("Operation %s", entry.ShortID())
+	ui.Println("  ID:        %s", entry.ID)
+	ui.Println("  Time:      %s", entry.FormatTime())
+	ui.Println("  Operation: %s", entry.Operation)
+	ui.Println("  Source:    %s", entry.Source)
+	ui.Println("  Status:    %s", status)
+	if entry.RedoOf != "" {
+		ui.Println("  Redo of:   %s", entry.RedoOf)
+	}
+	if entry.Snapshot != "" {
+		ui.Println("  Snapshot:  %s (taken before)", entry.Snapshot)
+	}
+
+	if len(entry.Packages) > 0 {
+		ui.InfoMsg("Packages:")
+		for _, pkg := range entry.Packages {
+			ui.MutedMsg("  - %s", pkg)
+		}
+	}
+	if len(entry.Resolutions) > 0 {
+		ui.InfoMsg("Sources:")
+		for _, r := range entry.Resolutions {
+			ui.MutedMsg("  %s", describeResolution(r))
+		}
+	}
+	if len(entry.Services) > 0 {
+		ui.InfoMsg("Services enabled:")
+		for _, unit := range entry.Services {
+			ui.MutedMsg("  - %s", unit)
+		}
+	}
+
+	if entry.Error != "" {
+		ui.InfoMsg("Error:")
+		ui.MutedMsg("  %s", entry.Error)
+		if d := entry.ErrorDetails; d != nil && strings.TrimSpace(d.Output) != "" {
+			ui.InfoMsg("Output:")
+			for _, line := range strings.Split(strings.TrimRight(d.Output, "\n"), "\n") {
+				ui.MutedMsg("  %s", line)
+			}
+		}
+	}
+
+	ui.Println("")
+	code := entry.Code()
+	if entry.Error != "" {
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
+	}
+	if entry.Snapshot != "" {
+		ui.MutedMsg("Run 'poxy undo %s' to restore the packages from before it", code)
+	} else if entry.CanRollback() {
+		ui.MutedMsg("Run 'poxy rollback --id %s' to reverse it", code)
+	}
+	if entry.Replayable() {
+		ui.MutedMsg("Run 'poxy history redo %s' to run it again", code)
+	}
+	return nil
+}
+
+func runHistoryRedo(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Close the store before the redo is recorded, which opens it again
+	store, err := history.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	entry, err := store.Get(args[0])
+	store.Close()
+	if err != nil {
+		return fmt.Errorf("operation not found: %s", args[0])
+	}
+
+	if !entry.Replayable() {
+		return fmt.Errorf("%w: %s", history.ErrNotReplayable, entry.Summary())
+	}
+
+	mgr, ok := registry.Get(entry.Source)
+	if !ok {
+		return fmt.Errorf("package manager not available: %s", entry.Source)
+	}
+
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+
+	ui.HeaderMsg("Redoing: %s", entry.Summary())
+	for _, pkg := range entry.Packages {
+		ui.MutedMsg("  - %s", pkg)
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Run %s again with %s?", entry.Operation, mgr.DisplayName()), true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	switch entry.Operation {
+	case history.OpInstall:
+		capturePreOperationSnapshot(ctx, snapshot.TriggerInstall, entry.Packages)
+	case history.OpUninstall:
+		capturePreOperationSnapshot(ctx, snapshot.TriggerUninstall, entry.Packages)
+	case history.OpUpgrade:
+		capturePreOperationSnapshot(ctx, snapshot.TriggerUpgrade, entry.Packages)
+	}
+
+	redo := history.NewEntry(entry.Operation, entry.Source, entry.Packages)
+	redo.RedoOf = entry.ID
+	redo.Resolutions = entry.Resolutions
+
+	err = history.Replay(ctx, entry, mgr, history.ReplayOpts{
+		AutoConfirm: cfg.General.AutoConfirm,
+		DryRun:      cfg.General.DryRun,
+	})
+
+	if err != nil {
+		redo.MarkFailed(err)
+		ui.ErrorMsg("Redo failed: %v", err)
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", redo.ID)
+	} else {
+		redo.MarkSuccess()
+		ui.SuccessMsg("Redo of %s completed successfully", entry.ID)
+		switch entry.Operation {
+		case history.OpInstall:
+			recordResolutions(entry.Resolutions)
+			recordExplicit(entry.Source, entry.Packages)
+		case history.OpUninstall:
+			forgetResolutions(entry.Source, entry.Packages)
+			forgetInstallReasons(entry.Source, entry.Packages)
+		}
+	}
+
+	recordHistory(redo)
+
+	return err
+}
+
 // formatPackages formats a list of packages for display.
 func formatPackages(packages []string) string {
 	if len(packages) == 0 {
@@ -90,3 +414,15 @@ func formatPackages(packages []string) string {
 	}
 	return fmt.Sprintf("%s (+%d more)", packages[0], len(packages)-1)
 }
+
+// describeResolution explains in one line why a package came from its source.
+func describeResolution(r manager.Resolution) string {
+	text := fmt.Sprintf("%s: %s", r.Requested, r.Reason)
+	if r.Alias != "" {
+		text += fmt.Sprintf(", alias for '%s'", r.Alias)
+	}
+	if r.Mapping != "" && r.Mapping != r.Name {
+		text += fmt.Sprintf(", mapping '%s'", r.Mapping)
+	}
+	return text
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/internal/executor"
+	"poxy/internal/history"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+	"poxy/pkg/manager/detector"
+)
+
+// imageBased returns the kind of image-based system poxy runs on, or "".
+func imageBased() string {
+	if info := registry.SystemInfo(); info != nil {
+		return info.ImageBased
+	}
+	return ""
+}
+
+// appliesOnReboot reports whether changes made with mgr only take effect
+// after a reboot, as with rpm-ostree.
+func appliesOnReboot(mgr manager.Manager) bool {
+	applier, ok := mgr.(manager.DeferredApplier)
+	return ok && applier.AppliesOnReboot()
+}
+
+// rebootLabel returns the label of operations with mgr that apply after a
+// reboot, to append to the line announcing them.
+func rebootLabel(mgr manager.Manager) string {
+	if appliesOnReboot(mgr) {
+		return " (applies after a reboot)"
+	}
+	return ""
+}
+
+// announceReboot tells the user that a finished operation is staged until
+// the next reboot.
+func announceReboot(entry *history.Entry) {
+	if cfg.General.DryRun || !entry.Success {
+		return
+	}
+	switch entry.Operation {
+	case history.OpInstall, history.OpUninstall, history.OpUpgrade:
+	default:
+		return
+	}
+	if mgr, ok := registry.Get(entry.Source); ok {
+		warnReboot(mgr)
+	}
+}
+
+// warnReboot tells the user that the changes just made with mgr apply
+// after a reboot, if they do.
+func warnReboot(mgr manager.Manager) {
+	if appliesOnReboot(mgr) && !cfg.General.DryRun {
+		ui.WarningMsg("%s staged the changes in a new deployment; reboot to apply them (systemctl reboot)", mgr.DisplayName())
+	}
+}
+
+// ensureWritableRoot makes SteamOS's read-only root writable before pacman
+// or an AUR helper changes it, if the user agrees. SteamOS updates replace
+// the root, so the packages are lost again with the next update.
+func ensureWritableRoot(ctx context.Context, mgr manager.Manager) error {
+	info := registry.SystemInfo()
+	if info == nil || info.ImageBased != detector.ImageSteamOS || !info.ReadOnlyRoot {
+		return nil
+	}
+	if mgr.Name() != "pacman" && mgr.Type() != manager.TypeAUR {
+		return nil
+	}
+
+	ui.WarningMsg("SteamOS's root is read-only; %s can only change it once it is made writable", mgr.DisplayName())
+	ui.MutedMsg("The next SteamOS update replaces the root and removes these packages again; Flatpak apps are kept")
+	if cfg.General.DryRun {
+		ui.MutedMsg("Would run 'sudo steamos-readonly disable' first")
+		return nil
+	}
+
+	if !cfg.General.AutoConfirm {
+		confirmed, err := ui.Confirm("Make the root writable with 'steamos-readonly disable'?", false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	runner := executor.New(false, cfg.Output.Verbose)
+	if err := runner.RunSudo(ctx, "steamos-readonly", "disable"); err != nil {
+		return fmt.Errorf("failed to make the root writable: %w", err)
+	}
+	info.ReadOnlyRoot = false
+	ui.MutedMsg("Run 'sudo steamos-readonly enable' to make the root read-only again")
+	return nil
+}
+
+// describeDeployment returns the version of a deployment with the start
+// of its checksum, e.g. "40.20240502.0 (1a2b3c4d5e6f)".
+func describeDeployment(version, checksum string) string {
+	if len(checksum) > 12 {
+		checksum = checksum[:12]
+	}
+	if version == "" {
+		return checksum
+	}
+	return fmt.Sprintf("%s (%s)", version, checksum)
+}
+
+// notePendingDeployment tells the user that changes made with mgr join a
+// deployment already staged for the next boot, such as from an earlier
+// install that was not rebooted into yet.
+func notePendingDeployment(ctx context.Context, mgr manager.Manager) {
+	lister, ok := mgr.(manager.DeploymentLister)
+	if !ok {
+		return
+	}
+	deployments, err := lister.Deployments(ctx)
+	if err != nil {
+		return
+	}
+	if pending := manager.PendingDeployment(deployments); pending != nil {
+		ui.MutedMsg("Deployment %s is pending for the next boot; these changes are added to it", describeDeployment(pending.Version, pending.Checksum))
+	}
+}
+
+// prepareSystem readies the system for changes with mgr: it makes
+// SteamOS's root writable and notes deployments already pending.
+func prepareSystem(ctx context.Context, mgr manager.Manager) error {
+	if err := ensureWritableRoot(ctx, mgr); err != nil {
+		return err
+	}
+	notePendingDeployment(ctx, mgr)
+	return nil
+}
+
+// findFlatpakApp returns the name of the Flatpak app that is pkg, such as
+// org.mozilla.firefox for "firefox", on image-based systems, where apps
+// are best installed from Flatpak. It returns "" elsewhere, or if there is
+// no such app.
+func findFlatpakApp(ctx context.Context, probe *sourceProbe, pkg string) (manager.Manager, string) {
+	if imageBased() == "" {
+		return nil, ""
+	}
+	flatpak, ok := registry.Get("flatpak")
+	if !ok || !flatpak.IsAvailable() {
+		return nil, ""
+	}
+	if native := registry.Native(); native != nil && native.Name() == flatpak.Name() {
+		return nil, "" // Already checked first
+	}
+
+	mappings := packageMappings()
+	key := strings.ToLower(pkg)
+	var results []manager.Package
+	probe.run(ctx, flatpak, func(ctx context.Context) {
+		results, _ = flatpak.Search(ctx, pkg, manager.SearchOpts{Limit: 50}) //nolint:errcheck
+	})
+	for _, r := range results {
+		if strings.EqualFold(r.Name, pkg) || mappings.GroupKey(r.Source, r.Name) == key {
+			return flatpak, r.Name
+		}
+	}
+	return nil, ""
+}
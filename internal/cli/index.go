@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"poxy/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	indexBuildFull    bool
+	indexBuildRestart bool
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the search index",
+	Long: `Manage the search index smart search uses. The daemon keeps it up to
+date; build it by hand after adding a source, or to download the catalogs
+now.`,
+}
+
+var indexBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build the search index from every source",
+	Long: `Build the search index from every available source, showing how many
+packages each has. Catalogs are downloaded again once they are older than
+[daemon] catalog_interval, or always with --full.
+
+Packages are stored as they are listed, and searches use the old index
+until every source is done. A build that is interrupted, with Ctrl-C or
+otherwise, is resumed by the next one, which skips the sources already
+stored; --restart starts over instead.
+
+Examples:
+  poxy index build            # Refresh the index
+  poxy index build --full     # Download every catalog again`,
+	Args: cobra.NoArgs,
+	RunE: runIndexBuild,
+}
+
+func init() {
+	indexBuildCmd.Flags().BoolVar(&indexBuildFull, "full", false, "download every catalog again, however recent")
+	indexBuildCmd.Flags().BoolVar(&indexBuildRestart, "restart", false, "start over instead of resuming an interrupted build")
+
+	indexCmd.AddCommand(indexBuildCmd)
+}
+
+func runIndexBuild(cmd *cobra.Command, args []string) error {
+	if searchEngine == nil {
+		if rootDir != "" {
+			return fmt.Errorf("the search index is not used with --root")
+		}
+		return fmt.Errorf("smart search is disabled; set [general] smart_search to use the index")
+	}
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would build the search index from %d sources", len(registry.Available()))
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The index loaded at startup must not replace the new one
+	indexBuilder.WaitForLoad(10 * time.Second)
+
+	progress := &indexProgress{}
+	err := indexBuilder.BuildWith(ctx, IndexBuildOptions{
+		Full:     indexBuildFull,
+		Restart:  indexBuildRestart,
+		Progress: progress.report,
+	})
+	progress.stop()
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("index build interrupted; run it again to resume")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to build index: %w", err)
+	}
+
+	ui.SuccessMsg("Indexed %d packages from %d sources", searchEngine.IndexSize(), progress.sources)
+	return nil
+}
+
+// indexProgress shows the progress of an index build, a line per source
+// once it is done.
+type indexProgress struct {
+	sp      *ui.Spinner
+	sources int
+}
+
+func (p *indexProgress) report(progress IndexProgress) {
+	switch {
+	case progress.Resumed:
+		p.sources++
+		ui.SuccessMsg("%s: %d packages %s", progress.Source, progress.Packages, ui.Muted.Sprint("(from the interrupted build)"))
+	case progress.Done:
+		p.stop()
+		p.sources++
+		ui.SuccessMsg("%s: %d packages", progress.Source, progress.Packages)
+	default:
+		message := fmt.Sprintf("%s: listing packages", progress.Source)
+		if progress.Packages > 0 {
+			message = fmt.Sprintf("%s: %d packages stored", progress.Source, progress.Packages)
+		}
+		if p.sp == nil {
+			p.sp = ui.NewSpinner(message)
+			p.sp.Start()
+		} else {
+			p.sp.UpdateMessage(message)
+		}
+	}
+}
+
+func (p *indexProgress) stop() {
+	if p.sp != nil {
+		p.sp.Stop()
+		p.sp = nil
+	}
+}
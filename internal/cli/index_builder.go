@@ -91,11 +91,16 @@ func (b *IndexBuilder) BuildAsync(ctx context.Context) {
 
 // BuildSync rebuilds the index from live data synchronously.
 func (b *IndexBuilder) BuildSync(ctx context.Context) error {
+	return b.BuildWith(ctx, IndexBuildOptions{})
+}
+
+// BuildWith rebuilds the index from live data synchronously with opts.
+func (b *IndexBuilder) BuildWith(ctx context.Context, opts IndexBuildOptions) error {
 	b.mu.Lock()
 	b.loading = true
 	b.mu.Unlock()
 
-	err := b.engine.BuildIndex(ctx)
+	err := b.engine.BuildIndexWith(ctx, opts)
 
 	b.mu.Lock()
 	b.loading = false
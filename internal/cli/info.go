@@ -2,8 +2,13 @@ package cli
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"poxy/internal/ui"
+	"poxy/pkg/aur"
+	"poxy/pkg/manager"
+	"poxy/pkg/manager/native"
 
 	"github.com/spf13/cobra"
 )
@@ -15,14 +20,26 @@ var infoCmd = &cobra.Command{
 
 Examples:
   poxy info vim               # Show info from native manager
-  poxy info firefox -s flatpak # Show Flatpak info`,
-	Args: cobra.ExactArgs(1),
-	RunE: runInfo,
+  poxy info firefox -s flatpak # Show Flatpak info
+  poxy info --timeout 1m vim  # Allow a slow source more time
+  poxy info yay -s aur        # Votes, popularity and recent comments`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingle(completeCachedPackages),
+	RunE:              runInfo,
+}
+
+var (
+	infoTimeout  time.Duration
+	infoComments int
+)
+
+func init() {
+	infoCmd.Flags().DurationVar(&infoTimeout, "timeout", 0, "lookup timeout, e.g. 30s (default from [timeouts] info)")
+	infoCmd.Flags().IntVar(&infoComments, "comments", 3, "number of AUR comments to show")
 }
 
 func runInfo(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	pkg := resolvePackages(args)[0]
 
 	// Get package manager
 	mgr, err := getManager()
@@ -30,10 +47,16 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	pkg := resolvePackages(mgr, args)[0]
+
 	// Get package info
-	info, err := mgr.Info(ctx, pkg)
+	timeout := timeoutFlag(cmd, infoTimeout, cfg.Timeouts.Info)
+	infoCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	info, err := mgr.Info(infoCtx, pkg)
 	if err != nil {
-		return err
+		return timeoutError(infoCtx, err, "info", timeout)
 	}
 
 	// Display info
@@ -47,5 +70,124 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		ui.MutedMsg("Package is not installed")
 	}
 
+	if apt, ok := mgr.(*native.APT); ok {
+		printAptPolicy(ctx, apt, pkg)
+	}
+
+	if dnf, ok := mgr.(*native.DNF); ok {
+		printModuleStream(ctx, dnf, pkg)
+	}
+
+	if channel := info.Metadata[manager.MetaChannel]; channel != "" {
+		ui.InfoMsg("Tracking channel %s", channel)
+		ui.MutedMsg("  List the other channels with: poxy snap channel %s", pkg)
+	}
+
+	if mgr.Type() == manager.TypeAUR {
+		printAURDetails(infoCtx, pkg)
+	}
+
 	return nil
 }
+
+// printAptPolicy explains pins, holds and phasing that keep a package from upgrading.
+func printAptPolicy(ctx context.Context, apt *native.APT, pkg string) {
+	policy, err := apt.Policy(ctx, pkg)
+	if err != nil {
+		return
+	}
+
+	if policy.Installed != "" && policy.Candidate != "" && policy.Installed != policy.Candidate {
+		ui.InfoMsg("Upgrade available: %s -> %s", policy.Installed, policy.Candidate)
+		if phased := policy.CandidatePhased(); phased > 0 {
+			ui.WarningMsg("Phased update: rolled out to %d%% of systems so far", phased)
+			ui.MutedMsg("  Install it now with: poxy upgrade --phased %s", pkg)
+		}
+	}
+
+	if policy.Held {
+		ui.WarningMsg("Package is held (apt-mark hold); it will not be upgraded")
+		ui.MutedMsg("  Release the hold with: sudo apt-mark unhold %s", pkg)
+	}
+
+	if policy.Pin != "" {
+		ui.WarningMsg("Package is pinned to version %s by apt preferences", policy.Pin)
+		ui.MutedMsg("  See: apt-cache policy %s", pkg)
+	}
+}
+
+// printModuleStream shows which module stream provides a package, if any.
+func printModuleStream(ctx context.Context, dnf *native.DNF, pkg string) {
+	stream, err := dnf.StreamForPackage(ctx, pkg)
+	if err != nil || stream == nil {
+		return
+	}
+
+	enabled, _ := dnf.EnabledStreams(ctx) //nolint:errcheck
+	for _, s := range enabled {
+		if s.Name != stream.Name {
+			continue
+		}
+		if s.Stream == stream.Stream {
+			ui.InfoMsg("Provided by module stream %s (enabled)", stream)
+		} else {
+			ui.InfoMsg("Provided by module %s; stream %s is enabled", stream.Name, s.Stream)
+			ui.MutedMsg("  Switch streams with: poxy module enable %s", stream)
+		}
+		return
+	}
+
+	ui.InfoMsg("Provided by module stream %s", stream)
+}
+
+// aurCommentLines is the number of lines shown of each AUR comment.
+const aurCommentLines = 8
+
+// printAURDetails shows what helps judge whether an AUR package can be
+// trusted: its votes, popularity, maintainer, freshness and recent comments.
+func printAURDetails(ctx context.Context, name string) {
+	client := aur.NewClient()
+	pkg, err := client.GetPackage(ctx, name)
+	if err != nil {
+		return
+	}
+
+	ui.HeaderMsg("AUR")
+	ui.Println("  %s: %d", ui.Cyan("Votes"), pkg.NumVotes)
+	ui.Println("  %s: %.2f", ui.Cyan("Popularity"), pkg.Popularity)
+	if pkg.IsOrphan() {
+		ui.Println("  %s: %s", ui.Cyan("Maintainer"), ui.Yellow("none (orphaned)"))
+	} else {
+		ui.Println("  %s: %s", ui.Cyan("Maintainer"), pkg.Maintainer)
+	}
+	ui.Println("  %s: %s", ui.Cyan("Last updated"), pkg.LastModifiedTime().Format("2006-01-02"))
+	if flagged := pkg.OutOfDateTime(); flagged != nil {
+		ui.Println("  %s: %s", ui.Cyan("Out of date"), ui.Yellow("flagged "+flagged.Format("2006-01-02")))
+	}
+	ui.Println("  %s: %s", ui.Cyan("Page"), pkg.WebURL())
+
+	if infoComments <= 0 {
+		return
+	}
+	comments, err := client.Comments(ctx, pkg, infoComments)
+	if err != nil {
+		ui.MutedMsg("Could not load comments: %v", err)
+		return
+	}
+	if len(comments) == 0 {
+		return
+	}
+
+	ui.HeaderMsg("Recent comments")
+	for _, c := range comments {
+		ui.Println("  %s %s", ui.Bold(c.Author), ui.Cyan(c.Date))
+		lines := strings.Split(c.Text, "\n")
+		if len(lines) > aurCommentLines {
+			lines = append(lines[:aurCommentLines], "...")
+		}
+		for _, line := range lines {
+			ui.Println("    %s", line)
+		}
+		ui.Println("")
+	}
+}
@@ -2,14 +2,21 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"poxy/internal/history"
 	"poxy/internal/ui"
+	"poxy/pkg/database"
 	"poxy/pkg/manager"
 	"poxy/pkg/manager/native"
 	"poxy/pkg/snapshot"
+	"poxy/pkg/transaction"
 
 	"github.com/spf13/cobra"
 )
@@ -28,24 +35,52 @@ Examples:
   poxy install discord             # Auto-finds in AUR if not in repos
   poxy install firefox -s flatpak  # Explicitly install from Flatpak
   poxy install -y neovim           # Install without confirmation
-  poxy install code                # Uses alias if configured`,
-	Args: cobra.MinimumNArgs(1),
-	RunE: runInstall,
+  poxy install code                # Uses alias if configured
+  poxy install --rollback-on-failure vim discord  # All or nothing
+  poxy install --force some-aur-pkg  # Build despite a high PKGBUILD risk score
+  poxy install --sandbox-profile offline some-aur-pkg  # Build in a custom sandbox
+  poxy install --clean-chroot some-aur-pkg  # Build in a clean devtools chroot
+  poxy install code -s snap --channel=insiders  # Install a snap from another channel
+  poxy install --backend-arg='pacman:--overwrite=*' foo  # Pass an option to pacman`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeCachedPackages,
+	RunE:              runInstall,
+}
+
+var (
+	installForce   bool
+	installChannel string
+)
+
+func init() {
+	installCmd.Flags().BoolVar(&installForce, "force", false, "build AUR packages even if their PKGBUILD risk score is above [managers.aur] max_risk")
+	installCmd.Flags().StringVar(&installChannel, "channel", "", "install snaps from this channel, e.g. edge or insiders/stable (implies -s snap)")
+	addSandboxProfileFlag(installCmd)
+	addBackendArgFlag(installCmd)
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
-	// Resolve aliases
-	packages := resolvePackages(args)
+	if err := checkSandboxProfile(); err != nil {
+		return err
+	}
+	if err := checkBackendArgs(); err != nil {
+		return err
+	}
+
+	// Only snaps have channels
+	if installChannel != "" && source == "" {
+		source = "snap"
+	}
 
 	// If source is explicitly specified, use that manager directly
 	if source != "" {
-		return installFromSource(ctx, packages, source)
+		return installFromSource(ctx, args, source)
 	}
 
 	// Smart install: try native first, then search other sources
-	return smartInstall(ctx, packages)
+	return smartInstall(ctx, args)
 }
 
 // installFromSource installs packages from a specific source.
@@ -55,6 +90,15 @@ func installFromSource(ctx context.Context, packages []string, sourceName string
 		return err
 	}
 
+	if installChannel != "" && mgr.Name() != "snap" {
+		return fmt.Errorf("--channel only applies to snaps, not %s packages", mgr.DisplayName())
+	}
+
+	packages = resolvePackages(mgr, packages)
+	previewCommandConflicts(ctx, []manager.Manager{mgr}, map[string][]string{mgr.Name(): packages})
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
 	return doInstall(ctx, mgr, packages)
 }
 
@@ -67,48 +111,104 @@ func smartInstall(ctx context.Context, packages []string) error {
 
 	// Group packages by their best source
 	type packageSource struct {
-		pkg    string
-		mgr    manager.Manager
-		reason string
+		pkg        string
+		mgr        manager.Manager
+		resolution manager.Resolution
 	}
 
 	var toInstall []packageSource
 	var notFound []string
 
-	for _, pkg := range packages {
-		mgr, reason := findBestSource(ctx, pkg)
-		if mgr != nil {
-			toInstall = append(toInstall, packageSource{pkg: pkg, mgr: mgr, reason: reason})
-		} else {
+	resolver := packageResolver()
+	probe := &sourceProbe{timeout: cfg.Timeouts.Probe}
+	names := make([]string, len(packages))
+	for i, requested := range packages {
+		names[i] = resolver.Alias(requested)
+	}
+	prefetchInfo(ctx, probe, names)
+	for _, requested := range packages {
+		pkg := resolver.Alias(requested)
+		mgr, resolution := findBestSource(ctx, probe, resolver, pkg)
+		if mgr == nil || resolution.Score != manager.MatchExact {
+			// Maybe a virtual package like "java-runtime", whose providers
+			// beat packages that merely start with the name
+			providerMgr, providerResolution, satisfied, err := resolveProvider(ctx, pkg)
+			if err != nil {
+				return err
+			}
+			if satisfied {
+				continue
+			}
+			if providerMgr != nil {
+				mgr, resolution = providerMgr, providerResolution
+			}
+		}
+		if mgr == nil {
 			notFound = append(notFound, pkg)
+			continue
 		}
+
+		resolution.Requested = requested
+		if pkg != requested {
+			resolution.Alias = pkg
+		}
+		toInstall = append(toInstall, packageSource{pkg: resolution.Name, mgr: mgr, resolution: resolution})
+	}
+
+	// Report the sources that were skipped, since they might have had
+	// a better match
+	if len(probe.timedOut) > 0 {
+		ui.WarningMsg("Skipped %s: no answer within %s (raise [timeouts] probe or use --source)",
+			strings.Join(probe.timedOut, ", "), probe.timeout)
 	}
 
 	// Report not found packages
 	if len(notFound) > 0 {
 		ui.WarningMsg("Could not find the following packages in any source:")
 		for _, pkg := range notFound {
-			ui.MutedMsg("  - %s", pkg)
+			if suggestion := suggestPackage(pkg); suggestion != "" {
+				ui.MutedMsg("  - %s (did you mean %s?)", pkg, suggestion)
+			} else {
+				ui.MutedMsg("  - %s", pkg)
+			}
 		}
-		if len(toInstall) == 0 {
-			return fmt.Errorf("no packages found")
+		if len(toInstall) == 0 || ciMode || nonInteractive {
+			return fmt.Errorf("%w: %s", ErrPackageNotFound, strings.Join(notFound, ", "))
 		}
 	}
+	if len(toInstall) == 0 {
+		return nil
+	}
 
-	// Group by manager for efficient installation
+	// Group by manager for efficient installation, keeping the plan order
 	byManager := make(map[string][]string)
+	resolutions := make(map[string][]manager.Resolution)
 	managerMap := make(map[string]manager.Manager)
+	var order []string
 
 	for _, ps := range toInstall {
 		mgrName := ps.mgr.Name()
+		if _, ok := managerMap[mgrName]; !ok {
+			order = append(order, mgrName)
+		}
 		byManager[mgrName] = append(byManager[mgrName], ps.pkg)
+		resolutions[mgrName] = append(resolutions[mgrName], ps.resolution)
 		managerMap[mgrName] = ps.mgr
 	}
 
 	// Show installation plan
 	ui.InfoMsg("Installation plan:")
 	for _, ps := range toInstall {
-		ui.MutedMsg("  - %s from %s (%s)", ps.pkg, ps.mgr.DisplayName(), ps.reason)
+		ui.MutedMsg("  - %s from %s %s (%s)", ps.pkg, ps.mgr.DisplayName(), scopeLabel(ps.mgr), ps.resolution.Reason)
+	}
+
+	planned := make([]manager.Manager, len(order))
+	for i, mgrName := range order {
+		planned[i] = managerMap[mgrName]
+	}
+	previewCommandConflicts(ctx, planned, byManager)
+	if err := confirmPrivileges(planned...); err != nil {
+		return err
 	}
 
 	// Confirm if not auto-confirmed
@@ -127,152 +227,190 @@ func smartInstall(ctx context.Context, packages []string) error {
 	for _, ps := range toInstall {
 		allPackages = append(allPackages, ps.pkg)
 	}
-	capturePreOperationSnapshot(ctx, snapshot.TriggerInstall, allPackages)
+	before := capturePreOperationSnapshot(ctx, snapshot.TriggerInstall, allPackages)
 
-	// Install from each manager
-	var lastErr error
-	for mgrName, pkgs := range byManager {
+	// Install from each manager as one transaction
+	tx := transaction.New()
+	for _, mgrName := range order {
 		mgr := managerMap[mgrName]
-		if err := doInstallQuiet(ctx, mgr, pkgs); err != nil {
-			ui.ErrorMsg("Failed to install from %s: %v", mgr.DisplayName(), err)
-			lastErr = err
-		}
+		pkgs := byManager[mgrName]
+		reasons := resolutions[mgrName]
+		tx.Add(transaction.Step{
+			Manager:  mgr,
+			Packages: pkgs,
+			Run: func(ctx context.Context) error {
+				err := doInstallQuiet(ctx, mgr, pkgs, reasons)
+				if err != nil {
+					ui.ErrorMsg("Failed to install from %s: %v", mgr.DisplayName(), err)
+				}
+				return err
+			},
+		})
 	}
 
-	return lastErr
+	return runTransaction(ctx, tx, before)
 }
 
-// findBestSource finds the best source for a package.
-// Returns the manager and a reason string.
-func findBestSource(ctx context.Context, pkg string) (manager.Manager, string) {
-	pkgLower := strings.ToLower(pkg)
+// sourceProbe bounds the lookups in each source while smartInstall picks
+// sources, so one slow source cannot stall the whole plan, and remembers
+// the sources that timed out.
+type sourceProbe struct {
+	timeout time.Duration
 
-	// Check package mappings first for known packages
-	if searchEngine != nil {
-		if mappedMgr, mappedName := findMappedPackage(ctx, pkg); mappedMgr != nil {
-			if mappedName != pkg {
-				return mappedMgr, fmt.Sprintf("mapped to '%s' in %s", mappedName, mappedMgr.DisplayName())
-			}
-			return mappedMgr, fmt.Sprintf("known package in %s", mappedMgr.DisplayName())
-		}
+	mu       sync.Mutex
+	timedOut []string // Source names, in the order they timed out
+}
+
+// run calls lookup with a context bounded by the probe timeout. It returns
+// false if mgr timed out, now or for an earlier package; such sources are
+// not asked again.
+func (p *sourceProbe) run(ctx context.Context, mgr manager.Manager, lookup func(ctx context.Context)) bool {
+	p.mu.Lock()
+	skip := slices.Contains(p.timedOut, mgr.Name())
+	p.mu.Unlock()
+	if skip {
+		return false
 	}
 
-	// First, check if it's in the native repos
-	native := registry.Native()
-	if native != nil {
-		// Try exact match via Info first (faster and more accurate)
-		if info, err := native.Info(ctx, pkg); err == nil && info != nil {
-			return native, "in official repos"
-		}
+	probeCtx, cancel := withTimeout(ctx, p.timeout)
+	defer cancel()
+	lookup(probeCtx)
 
-		// Fall back to search
-		results, err := native.Search(ctx, pkg, manager.SearchOpts{Limit: 50})
-		if err == nil {
-			for _, r := range results {
-				if strings.ToLower(r.Name) == pkgLower {
-					return native, "in official repos"
-				}
-			}
+	if errors.Is(probeCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		p.mu.Lock()
+		p.timedOut = append(p.timedOut, mgr.Name())
+		p.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// runAll runs lookup for each of mgrs at once, each bounded as by run, and
+// reports which of them answered in time. lookup is given the index of the
+// manager in mgrs.
+func (p *sourceProbe) runAll(ctx context.Context, mgrs []manager.Manager, lookup func(ctx context.Context, i int)) []bool {
+	answered := make([]bool, len(mgrs))
+	var wg sync.WaitGroup
+	for i, mgr := range mgrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answered[i] = p.run(ctx, mgr, func(ctx context.Context) { lookup(ctx, i) })
+		}()
+	}
+	wg.Wait()
+	return answered
+}
+
+// prefetchInfo looks pkgs up ahead in the sources that batch lookups, such
+// as the AUR, so that findBestSource is answered from their cache instead
+// of sending a request per package.
+func prefetchInfo(ctx context.Context, probe *sourceProbe, pkgs []string) {
+	var prefetchers []manager.Manager
+	for _, mgr := range registry.Available() {
+		if _, ok := mgr.(manager.InfoPrefetcher); ok {
+			prefetchers = append(prefetchers, mgr)
 		}
 	}
+	probe.runAll(ctx, prefetchers, func(ctx context.Context, i int) {
+		_ = prefetchers[i].(manager.InfoPrefetcher).PrefetchInfo(ctx, pkgs) //nolint:errcheck // Info asks again
+	})
+}
 
-	// Search all available sources
-	available := registry.Available()
+// suggestPackage returns the indexed package name closest to a name that
+// was not found, or "" if there is none or the index is not loaded.
+func suggestPackage(name string) string {
+	if searchEngine == nil || !searchEngine.IsReady() {
+		return ""
+	}
+	return searchEngine.GetIndex().Suggest(name, database.SearchOptions{})
+}
 
-	// Priority order: native > aur > flatpak > snap > others
-	priority := map[string]int{
-		"pacman":  1,
-		"apt":     1,
-		"dnf":     1,
-		"brew":    1,
-		"aur":     2,
-		"flatpak": 3,
-		"snap":    4,
+// findBestSource finds the best source for a package.
+// Returns the manager and how it was chosen; the manager is nil if no
+// source has the package. Sources that time out are skipped. The caller
+// fills in the requested name.
+func findBestSource(ctx context.Context, probe *sourceProbe, resolver *database.Resolver, pkg string) (manager.Manager, manager.Resolution) {
+	resolved := func(mgr manager.Manager, name string, score int, reason string) (manager.Manager, manager.Resolution) {
+		return mgr, manager.Resolution{Source: mgr.Name(), Name: name, Score: score, Reason: reason}
 	}
 
-	type match struct {
-		mgr      manager.Manager
-		pkgName  string
-		priority int
-		score    int // 0 = exact, 1 = starts with, 2 = contains
+	// Check package mappings first for known packages
+	if mappedMgr, mappedName, canonical := findMappedPackage(ctx, probe, resolver, pkg); mappedMgr != nil {
+		reason := fmt.Sprintf("known package in %s", mappedMgr.DisplayName())
+		if mappedName != pkg {
+			reason = fmt.Sprintf("mapped to '%s' in %s", mappedName, mappedMgr.DisplayName())
+		}
+		mgr, resolution := resolved(mappedMgr, mappedName, manager.MatchExact, reason)
+		resolution.Mapping = canonical
+		return mgr, resolution
 	}
 
-	var matches []match
+	// Libraries, fonts and drivers are for the system, and would be of no
+	// use to other packages from a sandboxed source such as Flatpak or Snap
+	application := manager.NameKind(pkg) == manager.KindApplication
 
-	for _, mgr := range available {
-		if native != nil && mgr.Name() == native.Name() {
-			continue // Already checked native
+	// Image-based systems take apps from Flatpak, leaving the image alone
+	if application {
+		if flatpak, name := findFlatpakApp(ctx, probe, pkg); flatpak != nil {
+			return resolved(flatpak, name, manager.MatchExact, "Flatpak app, preferred on image-based systems")
 		}
+	}
 
-		// Try exact match via Info first (especially important for AUR)
-		if info, err := mgr.Info(ctx, pkg); err == nil && info != nil {
-			p := priority[mgr.Name()]
-			if p == 0 {
-				p = 10
-			}
-			matches = append(matches, match{
-				mgr:      mgr,
-				pkgName:  info.Name,
-				priority: p,
-				score:    0, // Exact match
-			})
+	// Ask the native repos and every other source at once, so a slow or hung
+	// source holds the others up no longer than the probe timeout
+	native := registry.Native()
+	var sources []manager.Manager
+	if native != nil {
+		sources = append(sources, native)
+	}
+	for _, mgr := range registry.Available() {
+		if native != nil && mgr.Name() == native.Name() {
 			continue
 		}
-
-		// Fall back to search with larger limit
-		results, err := mgr.Search(ctx, pkg, manager.SearchOpts{Limit: 100})
-		if err != nil {
+		if !application && mgr.Type() == manager.TypeUniversal {
 			continue
 		}
+		sources = append(sources, mgr)
+	}
 
-		for _, r := range results {
-			rNameLower := strings.ToLower(r.Name)
-			var score int = -1
-
-			// Exact match
-			if rNameLower == pkgLower {
-				score = 0
-			} else if strings.HasPrefix(rNameLower, pkgLower+"-") || strings.HasPrefix(rNameLower, pkgLower+"_") {
-				// Starts with package name followed by separator (e.g., "spotify-bin")
-				score = 1
-			} else if strings.HasPrefix(rNameLower, pkgLower) {
-				// Starts with package name
-				score = 2
-			}
+	found := make([][]sourceMatch, len(sources))
+	answered := probe.runAll(ctx, sources, func(ctx context.Context, i int) {
+		// Only an exact match counts in the native repos
+		found[i] = lookupSource(ctx, sources[i], pkg, sources[i] == native)
+	})
 
-			if score >= 0 {
-				p := priority[mgr.Name()]
-				if p == 0 {
-					p = 10
-				}
-				matches = append(matches, match{
-					mgr:      mgr,
-					pkgName:  r.Name,
-					priority: p,
-					score:    score,
-				})
-
-				// If we found an exact match, no need to continue searching this source
-				if score == 0 {
-					break
-				}
-			}
+	// A package in the official repos is preferred, unless the policy
+	// prefers sources of another scope
+	if native != nil && answered[0] && len(found[0]) > 0 && scopeRank(native) == 0 {
+		return resolved(native, found[0][0].pkgName, manager.MatchExact, "in official repos")
+	}
+
+	var matches []sourceMatch
+	for i := range sources {
+		if answered[i] {
+			matches = append(matches, found[i]...)
 		}
 	}
 
 	if len(matches) == 0 {
-		return nil, ""
+		return nil, manager.Resolution{}
 	}
 
 	// Find best match:
 	// 1. Prefer exact matches (score 0)
-	// 2. Then prefer by priority (lower is better)
-	// 3. Then prefer shorter names (usually the main package)
+	// 2. Then prefer sources of the scope [policy] install_scope prefers
+	// 3. Then prefer by priority (lower is better)
+	// 4. Then prefer shorter names (usually the main package)
 	best := matches[0]
 	for _, m := range matches[1:] {
 		// Better score always wins
 		if m.score < best.score {
 			best = m
+		} else if m.score == best.score && scopeRank(m.mgr) != scopeRank(best.mgr) {
+			if scopeRank(m.mgr) < scopeRank(best.mgr) {
+				best = m
+			}
 		} else if m.score == best.score {
 			// Same score: prefer lower priority (native > aur > flatpak)
 			if m.priority < best.priority {
@@ -286,69 +424,232 @@ func findBestSource(ctx context.Context, pkg string) (manager.Manager, string) {
 		}
 	}
 
+	if best.mgr == native {
+		return resolved(native, best.pkgName, best.score, "in official repos")
+	}
 	reason := fmt.Sprintf("found in %s", best.mgr.DisplayName())
 	if best.pkgName != pkg {
 		reason = fmt.Sprintf("'%s' in %s", best.pkgName, best.mgr.DisplayName())
 	}
-	return best.mgr, reason
+	return resolved(best.mgr, best.pkgName, best.score, reason)
 }
 
-// findMappedPackage checks if a package has a known mapping and finds it.
-func findMappedPackage(ctx context.Context, pkg string) (manager.Manager, string) {
-	mappings := searchEngine.GetMappings()
-	if mappings == nil {
-		return nil, ""
+// sourceMatch is a package of a source that matches a requested name.
+type sourceMatch struct {
+	mgr      manager.Manager
+	pkgName  string
+	priority int
+	score    int // manager.MatchExact, MatchSuffix or MatchPrefix
+}
+
+// lookupSource looks pkg up in mgr: Info for an exact match first
+// (especially important for AUR), then a search for names that start with
+// it. exactOnly leaves out names that only start with pkg.
+func lookupSource(ctx context.Context, mgr manager.Manager, pkg string, exactOnly bool) []sourceMatch {
+	priority := sourcePriority(mgr.Name())
+	if info, err := mgr.Info(ctx, pkg); err == nil && info != nil {
+		return []sourceMatch{{mgr: mgr, pkgName: info.Name, priority: priority, score: manager.MatchExact}}
 	}
 
-	// Check if this is a canonical name
-	mapping := mappings.GetByCanonical(pkg)
-	if mapping == nil {
-		// Maybe it's a source-specific name, try to find canonical
-		for _, mgr := range registry.Available() {
-			if m := mappings.GetBySourceName(mgr.Name(), pkg); m != nil {
-				mapping = m
+	limit := 100
+	if exactOnly {
+		limit = 50
+	}
+	results, _ := mgr.Search(ctx, pkg, manager.SearchOpts{Limit: limit}) //nolint:errcheck
+
+	pkgLower := strings.ToLower(pkg)
+	var matches []sourceMatch
+	for _, r := range results {
+		rNameLower := strings.ToLower(r.Name)
+		score := -1
+
+		if rNameLower == pkgLower {
+			score = manager.MatchExact
+		} else if exactOnly {
+			continue
+		} else if strings.HasPrefix(rNameLower, pkgLower+"-") || strings.HasPrefix(rNameLower, pkgLower+"_") {
+			// Starts with package name followed by separator (e.g., "spotify-bin")
+			score = manager.MatchSuffix
+		} else if strings.HasPrefix(rNameLower, pkgLower) {
+			score = manager.MatchPrefix
+		}
+
+		if score >= 0 {
+			matches = append(matches, sourceMatch{mgr: mgr, pkgName: r.Name, priority: priority, score: score})
+
+			// If we found an exact match, no need to continue searching this source
+			if score == manager.MatchExact {
 				break
 			}
 		}
 	}
+	return matches
+}
+
+// resolveProvider resolves the virtual package pkg to the provider the user
+// chooses. The manager is nil if nothing provides pkg; satisfied reports
+// that an installed package already does. The caller fills in the
+// requested name.
+func resolveProvider(ctx context.Context, pkg string) (manager.Manager, manager.Resolution, bool, error) {
+	provider, err := chooseProvider(ctx, pkg)
+	if err != nil || provider == nil {
+		return nil, manager.Resolution{}, false, err
+	}
+	if provider.Installed {
+		ui.InfoMsg("%s is already provided by the installed %s", pkg, provider.Name)
+		return nil, manager.Resolution{}, true, nil
+	}
+
+	mgr, err := registry.GetManagerForSource(provider.Source)
+	if err != nil {
+		return nil, manager.Resolution{}, false, nil
+	}
+	return mgr, manager.Resolution{
+		Source: mgr.Name(),
+		Name:   provider.Name,
+		Score:  manager.MatchProvides,
+		Reason: fmt.Sprintf("'%s' provides %s in %s", provider.Name, pkg, mgr.DisplayName()),
+	}, false, nil
+}
+
+// chooseProvider finds the packages that provide the virtual package pkg
+// and asks which one to install, or picks the first under auto-confirm.
+// Providers without installed conflicts come first, then by source
+// priority. An installed provider is returned as is, since pkg is already
+// satisfied; nil means nothing provides pkg.
+func chooseProvider(ctx context.Context, pkg string) (*manager.Package, error) {
+	providers := findProviders(ctx, registry.Available(), pkg)
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	for i := range providers {
+		if providers[i].Installed {
+			return &providers[i], nil
+		}
+	}
+
+	conflicts := make(map[string][]string)
+	for _, provider := range providers {
+		conflicts[provider.Source+":"+provider.Name] = installedConflicts(ctx, provider)
+	}
+	sort.SliceStable(providers, func(i, j int) bool {
+		ci := len(conflicts[providers[i].Source+":"+providers[i].Name]) > 0
+		cj := len(conflicts[providers[j].Source+":"+providers[j].Name]) > 0
+		if ci != cj {
+			return !ci
+		}
+		return sourcePriority(providers[i].Source) < sourcePriority(providers[j].Source)
+	})
+
+	ui.InfoMsg("%s is provided by:", pkg)
+	for _, provider := range providers {
+		note := ""
+		if c := conflicts[provider.Source+":"+provider.Name]; len(c) > 0 {
+			note = ui.Warning.Sprintf(" (conflicts with installed %s)", strings.Join(c, ", "))
+		}
+		ui.MutedMsg("  - %s %s [%s]%s", provider.Name, provider.Version, provider.Source, note)
+	}
+
+	if cfg.General.AutoConfirm || len(providers) == 1 {
+		return &providers[0], nil
+	}
+	return ui.SelectPackage(providers, fmt.Sprintf("Which package should provide %s?", pkg))
+}
+
+// installedConflicts returns the installed packages that provider
+// conflicts with, which installing it would remove.
+func installedConflicts(ctx context.Context, provider manager.Package) []string {
+	if len(provider.Conflicts) == 0 {
+		return nil
+	}
+	mgr, err := registry.GetManagerForSource(provider.Source)
+	if err != nil {
+		return nil
+	}
+
+	var installed []string
+	for _, conflict := range provider.Conflicts {
+		name := manager.RelationName(conflict)
+		if ok, _ := mgr.IsInstalled(ctx, name); ok { //nolint:errcheck
+			installed = append(installed, name)
+		}
+	}
+	return installed
+}
+
+// sourcePriorities rank sources when several have a package, lowest
+// first: native > aur > flatpak > snap > others.
+var sourcePriorities = map[string]int{
+	"pacman":     1,
+	"apt":        1,
+	"dnf":        1,
+	"brew":       1,
+	"rpm-ostree": 1,
+	"aur":        2,
+	"flatpak":    3,
+	"snap":       4,
+	"cargo":      5,
+	"npm":        5,
+	"gobin":      5,
+}
+
+// sourcePriority returns the rank of a source; unknown sources come last.
+func sourcePriority(source string) int {
+	if p, ok := sourcePriorities[source]; ok {
+		return p
+	}
+	return 10
+}
 
+// findMappedPackage checks if a package has a known mapping and finds it.
+// Returns the manager, the package name in that source and the canonical
+// name of the mapping.
+func findMappedPackage(ctx context.Context, probe *sourceProbe, resolver *database.Resolver, pkg string) (manager.Manager, string, string) {
+	mapping := resolver.Mapping(pkg)
 	if mapping == nil {
-		return nil, ""
+		return nil, "", ""
 	}
 
 	// Find the best available source for this mapping
-	// Priority: native > aur > flatpak > snap
+	// Priority: native > aur > flatpak > snap, with the sources of the
+	// scope [policy] install_scope prefers first
+	var candidates []manager.Manager
 	native := registry.Native()
-
-	// Try native first
 	if native != nil {
-		if mappedName, ok := mapping.Sources[native.Name()]; ok {
-			// Verify it exists
-			if info, err := native.Info(ctx, mappedName); err == nil && info != nil {
-				return native, mappedName
-			}
+		candidates = append(candidates, native)
+	}
+	for _, source := range []string{"aur", "flatpak", "snap", "cargo", "npm", "gobin", "brew", "winget"} {
+		if mgr, ok := registry.Get(source); ok && mgr != native {
+			candidates = append(candidates, mgr)
 		}
 	}
 
-	// Try other sources in priority order
-	priorities := []string{"aur", "flatpak", "snap", "brew", "winget"}
-	for _, source := range priorities {
-		if mappedName, ok := mapping.Sources[source]; ok {
-			if mgr, ok := registry.Get(source); ok {
-				// Verify it exists
-				if info, err := mgr.Info(ctx, mappedName); err == nil && info != nil {
-					return mgr, mappedName
-				}
+	for _, mgr := range preferScope(candidates) {
+		if mappedName, ok := mapping.Sources[mgr.Name()]; ok {
+			// Verify it exists
+			if probeInfo(ctx, probe, mgr, mappedName) {
+				return mgr, mappedName, mapping.Canonical
 			}
 		}
 	}
 
-	return nil, ""
+	return nil, "", ""
+}
+
+// probeInfo reports whether mgr has info for the package name, within the
+// probe timeout.
+func probeInfo(ctx context.Context, probe *sourceProbe, mgr manager.Manager, name string) bool {
+	found := false
+	probe.run(ctx, mgr, func(ctx context.Context) {
+		info, err := mgr.Info(ctx, name)
+		found = err == nil && info != nil
+	})
+	return found
 }
 
 // doInstall performs the installation with full UI feedback.
 func doInstall(ctx context.Context, mgr manager.Manager, packages []string) error {
-	ui.InfoMsg("Installing %d package(s) using %s", len(packages), mgr.DisplayName())
+	ui.InfoMsg("Installing %d package(s) using %s%s", len(packages), mgr.DisplayName(), rebootLabel(mgr))
 	for _, pkg := range packages {
 		ui.MutedMsg("  - %s", pkg)
 	}
@@ -364,37 +665,74 @@ func doInstall(ctx context.Context, mgr manager.Manager, packages []string) erro
 		}
 	}
 
-	return doInstallQuiet(ctx, mgr, packages)
+	if !rollbackOnFailure {
+		return doInstallQuiet(ctx, mgr, packages, nil)
+	}
+
+	before := capturePreOperationSnapshot(ctx, snapshot.TriggerInstall, packages)
+
+	tx := transaction.New()
+	tx.Add(transaction.Step{
+		Manager:  mgr,
+		Packages: packages,
+		Run: func(ctx context.Context) error {
+			return doInstallQuiet(ctx, mgr, packages, nil)
+		},
+	})
+
+	return runTransaction(ctx, tx, before)
 }
 
 // doInstallQuiet performs the installation without extra prompts.
-func doInstallQuiet(ctx context.Context, mgr manager.Manager, packages []string) error {
+// resolutions explain how poxy chose mgr for the packages, if it did.
+func doInstallQuiet(ctx context.Context, mgr manager.Manager, packages []string, resolutions []manager.Resolution) error {
 	// Create history entry
 	entry := history.NewEntry(history.OpInstall, mgr.Name(), packages)
+	entry.Resolutions = resolutions
 
 	// Build options - always set AutoConfirm since poxy already confirmed with user
 	opts := manager.InstallOpts{
-		AutoConfirm: true,
-		DryRun:      cfg.General.DryRun,
+		AutoConfirm:    true,
+		DryRun:         cfg.General.DryRun,
+		Force:          installForce,
+		SandboxProfile: sandboxProfile,
+		CleanChroot:    cleanChroot,
+		Channel:        installChannel,
+		ExtraArgs:      backendArgs(mgr.Name()),
 	}
 
+	if err := prepareSystem(ctx, mgr); err != nil {
+		return err
+	}
+
+	beginPlanOperation(ctx, history.OpInstall, mgr, packages)
+	defer endPlanOperation()
+
 	// Execute installation
 	err := mgr.Install(ctx, packages, opts)
 
-	// Check for pacman dependency conflicts and offer to help
+	// Check for pacman dependency conflicts or keyring problems and offer to help
 	if err != nil {
-		if handled, handledErr := handlePacmanConflict(ctx, mgr, packages, opts, err); handled {
+		handled, handledErr := handlePacmanConflict(ctx, mgr, packages, opts, err)
+		if !handled {
+			handled, handledErr = handlePacmanKeyring(ctx, err, func() error {
+				return mgr.Install(ctx, packages, opts)
+			})
+		}
+		if handled {
 			if handledErr == nil {
 				entry.MarkSuccess()
+				recordResolutions(resolutions)
+				recordExplicit(mgr.Name(), packages)
+				updateIndex(ctx, mgr, packages)
 				ui.SuccessMsg("Successfully installed %v from %s", packages, mgr.DisplayName())
+				entry.Services = offerServices(ctx, mgr, packages)
+				offerShellSetup(ctx, mgr)
 			} else {
 				entry.MarkFailed(handledErr)
+				ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
 			}
-			// Record in history (ignore errors)
-			if store, storeErr := history.Open(); storeErr == nil {
-				_ = store.Record(entry) //nolint:errcheck
-				_ = store.Close()       //nolint:errcheck
-			}
+			recordHistory(entry)
 			return handledErr
 		}
 	}
@@ -403,16 +741,18 @@ func doInstallQuiet(ctx context.Context, mgr manager.Manager, packages []string)
 	if err != nil {
 		entry.MarkFailed(err)
 		ui.ErrorMsg("Installation failed: %v", err)
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
 	} else {
 		entry.MarkSuccess()
+		recordResolutions(resolutions)
+		recordExplicit(mgr.Name(), packages)
+		updateIndex(ctx, mgr, packages)
 		ui.SuccessMsg("Successfully installed %v from %s", packages, mgr.DisplayName())
+		entry.Services = offerServices(ctx, mgr, packages)
+		offerShellSetup(ctx, mgr)
 	}
 
-	// Record in history (ignore errors)
-	if store, storeErr := history.Open(); storeErr == nil {
-		_ = store.Record(entry) //nolint:errcheck
-		_ = store.Close()       //nolint:errcheck
-	}
+	recordHistory(entry)
 
 	return err
 }
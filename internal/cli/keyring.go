@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager/native"
+
+	"github.com/spf13/cobra"
+)
+
+var keyringCmd = &cobra.Command{
+	Use:   "keyring",
+	Short: "Check and repair the pacman keyring",
+	Long: `Check and repair the pacman keyring on Arch-based systems.
+
+Signature errors such as "signature is unknown trust" or "invalid or
+corrupted package (PGP signature)" are usually caused by an outdated
+keyring package or a damaged trust database.
+
+Examples:
+  poxy keyring check     # Report keyring problems
+  poxy keyring repair    # Refresh the keyring package and populate keys`,
+}
+
+var keyringCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the pacman keyring for problems",
+	Args:  cobra.NoArgs,
+	RunE:  runKeyringCheck,
+}
+
+var keyringRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Refresh the keyring package and repopulate keys",
+	Args:  cobra.NoArgs,
+	RunE:  runKeyringRepair,
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringCheckCmd)
+	keyringCmd.AddCommand(keyringRepairCmd)
+}
+
+// getPacman returns the pacman manager if it is available.
+func getPacman() (*native.Pacman, error) {
+	mgr, ok := registry.Get("pacman")
+	if !ok || !mgr.IsAvailable() {
+		return nil, fmt.Errorf("pacman is not available on this system")
+	}
+
+	pacman, ok := mgr.(*native.Pacman)
+	if !ok {
+		return nil, fmt.Errorf("pacman is not available on this system")
+	}
+	return pacman, nil
+}
+
+func runKeyringCheck(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	pacman, err := getPacman()
+	if err != nil {
+		return err
+	}
+
+	status, err := pacman.KeyringStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check keyring: %w", err)
+	}
+
+	ui.HeaderMsg("Pacman Keyring")
+	ui.Println("")
+	ui.Println("  GnuPG home:     %s", status.GnupgDir)
+	for _, pkg := range status.Packages {
+		available := pkg.Available
+		if available == "" {
+			available = "unknown"
+		}
+		ui.Println("  %-15s %s (repository: %s)", pkg.Name+":", pkg.Installed, available)
+	}
+	ui.Println("")
+
+	problems := status.Problems()
+	if len(problems) == 0 {
+		ui.SuccessMsg("Keyring is healthy")
+		return nil
+	}
+
+	for _, problem := range problems {
+		ui.WarningMsg("%s", problem)
+	}
+	ui.MutedMsg("Run 'poxy keyring repair' to fix")
+	return nil
+}
+
+func runKeyringRepair(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	pacman, err := getPacman()
+	if err != nil {
+		return err
+	}
+	if err := confirmPrivileges(pacman); err != nil {
+		return err
+	}
+
+	return repairKeyring(ctx, pacman, true)
+}
+
+// repairKeyring shows the keyring problems and repair plan and runs the
+// repair, asking for confirmation first if prompt is set.
+func repairKeyring(ctx context.Context, pacman *native.Pacman, prompt bool) error {
+	status, err := pacman.KeyringStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check keyring: %w", err)
+	}
+
+	for _, problem := range status.Problems() {
+		ui.WarningMsg("%s", problem)
+	}
+
+	ui.InfoMsg("Repairing the pacman keyring:")
+	for _, step := range pacman.KeyringRepairPlan(status) {
+		ui.MutedMsg("  %s", step)
+	}
+
+	if prompt && !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		confirmed, err := ui.Confirm("Proceed?", true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	if err := pacman.RepairKeyring(ctx, status, cfg.General.DryRun); err != nil {
+		return fmt.Errorf("keyring repair failed: %w", err)
+	}
+
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("Keyring repaired")
+	}
+	return nil
+}
+
+// handlePacmanKeyring offers to repair the keyring after a signature error
+// and, if the repair succeeds, runs retry.
+func handlePacmanKeyring(ctx context.Context, err error, retry func() error) (bool, error) {
+	pacErr, ok := native.IsPacmanKeyringProblem(err)
+	if !ok {
+		return false, nil
+	}
+
+	ui.WarningMsg(native.FormatKeyringMessage(pacErr))
+
+	// If not interactive (auto-confirm mode), just return the error
+	if cfg.General.AutoConfirm {
+		return false, nil
+	}
+
+	pacman, pacmanErr := getPacman()
+	if pacmanErr != nil {
+		return false, nil
+	}
+
+	confirmed, confirmErr := ui.Confirm("Repair the keyring and retry?", true)
+	if confirmErr != nil || !confirmed {
+		return true, err // Return original error
+	}
+
+	if repairErr := repairKeyring(ctx, pacman, false); repairErr != nil {
+		ui.ErrorMsg("%v", repairErr)
+		return true, repairErr
+	}
+
+	ui.InfoMsg("Retrying...")
+	if retryErr := retry(); retryErr != nil {
+		ui.ErrorMsg("Retry failed: %v", retryErr)
+		return true, retryErr
+	}
+
+	return true, nil
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// limitFlag returns the --limit flag value if it was given on the command
+// line, otherwise the configured default.
+func limitFlag(cmd *cobra.Command, value, configured int) int {
+	if cmd.Flags().Changed("limit") {
+		return value
+	}
+	return configured
+}
+
+// timeoutFlag returns the --timeout flag value if it was given on the
+// command line, otherwise the configured default.
+func timeoutFlag(cmd *cobra.Command, value, configured time.Duration) time.Duration {
+	if cmd.Flags().Changed("timeout") {
+		return value
+	}
+	return configured
+}
+
+// withTimeout returns a context bounded by timeout. A zero or negative
+// timeout means no limit.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError replaces an error caused by an expired deadline with a message
+// explaining how to raise the timeout.
+func timeoutError(ctx context.Context, err error, what string, timeout time.Duration) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s timed out after %s (use --timeout or raise it under [timeouts] in the config)", what, timeout)
+}
@@ -2,16 +2,28 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"poxy/internal/config"
+	"poxy/internal/history"
 	"poxy/internal/ui"
+	"poxy/pkg/appstream"
+	"poxy/pkg/database"
 	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	listLimit   int
-	listPattern string
+	listLimit    int
+	listPattern  string
+	listAsOf     string
+	listExplicit bool
+	listKind     string
 )
 
 var listCmd = &cobra.Command{
@@ -24,18 +36,38 @@ Examples:
   poxy list                     # List all installed packages
   poxy list -s flatpak          # List installed Flatpaks
   poxy list -l 20               # List first 20 packages
-  poxy list -p vim              # List packages matching 'vim'`,
+  poxy list -p vim              # List packages matching 'vim'
+  poxy list --explicit          # Leave out packages installed as dependencies
+  poxy list --kind application  # Only applications, no libraries, fonts or drivers
+  poxy list --as-of 2024-11-01  # Show what was installed on a past date`,
 	RunE: runList,
 }
 
 func init() {
-	listCmd.Flags().IntVarP(&listLimit, "limit", "l", 0, "limit number of results")
+	listCmd.Flags().IntVarP(&listLimit, "limit", "l", 0, "limit number of results (default from [limits] list, 0 = all)")
 	listCmd.Flags().StringVarP(&listPattern, "pattern", "p", "", "filter by name pattern")
+	listCmd.Flags().StringVar(&listAsOf, "as-of", "", "reconstruct the package set at a past date (YYYY-MM-DD)")
+	listCmd.Flags().BoolVar(&listExplicit, "explicit", false, "only list packages installed explicitly, not as dependencies")
+	listCmd.Flags().StringVar(&listKind, "kind", "", "only list packages of a kind: application, library, font or driver")
+	listCmd.MarkFlagsMutuallyExclusive("as-of", "explicit")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	var kind manager.Kind
+	if listKind != "" {
+		k, err := manager.ParseKind(listKind)
+		if err != nil {
+			return err
+		}
+		kind = k
+	}
+
+	if listAsOf != "" {
+		return runListAsOf(ctx, kind)
+	}
+
 	// Get package manager
 	mgr, err := getManager()
 	if err != nil {
@@ -44,19 +76,250 @@ func runList(cmd *cobra.Command, args []string) error {
 
 	ui.InfoMsg("Listing installed packages from %s", mgr.DisplayName())
 
+	limit := limitFlag(cmd, listLimit, cfg.Limits.List)
 	opts := manager.ListOpts{
-		Limit:         listLimit,
+		Limit:         limit,
 		InstalledOnly: true,
 		Pattern:       listPattern,
 	}
 
+	if listExplicit || kind != "" {
+		// Filter before limiting, so the limit counts the packages listed
+		opts.Limit = 0
+	}
+
 	packages, err := mgr.ListInstalled(ctx, opts)
 	if err != nil {
 		return err
 	}
 
+	if listExplicit {
+		explicitLimit := limit
+		if kind != "" {
+			explicitLimit = 0
+		}
+		packages = explicitPackages(ctx, mgr, packages, explicitLimit)
+	}
+	if kind != "" {
+		packages = packagesOfKind(packages, kind, limit)
+	}
+
 	ui.PrintPackages(packages)
 	ui.MutedMsg("\nTotal: %d packages", len(packages))
 
+	if sm, ok := mgr.(manager.StreamManager); ok {
+		printEnabledStreams(ctx, sm)
+	}
+
 	return nil
 }
+
+// explicitPackages returns up to limit (0 = all) of packages that were not
+// installed as dependencies. Packages of sources that do not track install
+// reasons, and that poxy did not install, are kept.
+func explicitPackages(ctx context.Context, mgr manager.Manager, packages []manager.Package, limit int) []manager.Package {
+	var store *database.Store
+	if s, err := database.Open(); err == nil {
+		store = s
+		defer store.Close()
+	}
+	database.MarkInstallReasons(ctx, store, mgr, packages)
+
+	var explicit []manager.Package
+	for _, pkg := range packages {
+		if pkg.Reason == manager.ReasonDependency {
+			continue
+		}
+		explicit = append(explicit, pkg)
+		if limit > 0 && len(explicit) >= limit {
+			break
+		}
+	}
+	return explicit
+}
+
+// packagesOfKind returns up to limit (0 = all) of packages of the given
+// kind. Packages the system's AppStream catalogs describe are applications,
+// whatever their names suggest.
+func packagesOfKind(packages []manager.Package, kind manager.Kind, limit int) []manager.Package {
+	catalog, _ := appstream.LoadSystem() //nolint:errcheck
+
+	var matching []manager.Package
+	for _, pkg := range packages {
+		if catalog != nil {
+			catalog.Enrich(&pkg)
+		}
+		if pkg.Kind() != kind {
+			continue
+		}
+		matching = append(matching, pkg)
+		if limit > 0 && len(matching) >= limit {
+			break
+		}
+	}
+	return matching
+}
+
+// printEnabledStreams lists enabled module streams after the package list.
+func printEnabledStreams(ctx context.Context, sm manager.StreamManager) {
+	streams, err := sm.ListStreams(ctx)
+	if err != nil {
+		return
+	}
+
+	var enabled []string
+	for _, s := range streams {
+		if s.Enabled {
+			enabled = append(enabled, s.String())
+		}
+	}
+
+	if len(enabled) > 0 {
+		ui.MutedMsg("Enabled module streams: %s", strings.Join(enabled, ", "))
+	}
+}
+
+// runListAsOf reconstructs the package set at a past date from the nearest
+// snapshot plus history deltas, and shows how it differs from today.
+func runListAsOf(ctx context.Context, kind manager.Kind) error {
+	at, err := parseAsOf(listAsOf)
+	if err != nil {
+		return err
+	}
+
+	store, err := snapshot.OpenStore()
+	if errors.Is(err, config.ErrDataDirReadOnly) {
+		ui.InfoMsg("No snapshots available to reconstruct from")
+		ui.MutedMsg("%s is not writable, so snapshots cannot be created.", config.DataDir())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot store: %w", err)
+	}
+	defer store.Close()
+
+	base, err := store.Nearest(at)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	if base == nil {
+		ui.InfoMsg("No snapshots available to reconstruct from")
+		ui.MutedMsg("Create one with: poxy snapshot create")
+		return nil
+	}
+
+	// Collect the history between the snapshot and the target date
+	from, to := base.Timestamp, at
+	if from.After(to) {
+		from, to = to, from
+	}
+
+	var deltas []snapshot.Delta
+	if hstore, herr := history.Open(); herr == nil {
+		entries, _ := hstore.Range(from, to) //nolint:errcheck
+		_ = hstore.Close()                   //nolint:errcheck
+		deltas = historyDeltas(entries)
+	}
+
+	past := snapshot.Reconstruct(base, deltas, at)
+
+	ui.HeaderMsg("Packages as of %s", at.Format("2006-01-02 15:04"))
+	ui.MutedMsg("Reconstructed from snapshot %s (%s) and %d history entries",
+		base.ID, base.FormatTime(), len(deltas))
+	ui.Println("")
+
+	var packages []manager.Package
+	for _, pkg := range past.Packages {
+		if source != "" && pkg.Source != source {
+			continue
+		}
+		if listPattern != "" && !strings.Contains(strings.ToLower(pkg.Name), strings.ToLower(listPattern)) {
+			continue
+		}
+		p := manager.Package{
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			Source:    pkg.Source,
+			Installed: true,
+		}
+		if kind != "" && p.Kind() != kind {
+			continue
+		}
+		packages = append(packages, p)
+		if listLimit > 0 && len(packages) >= listLimit {
+			break
+		}
+	}
+
+	ui.PrintPackages(packages)
+	ui.MutedMsg("\nTotal: %d packages", len(packages))
+	ui.Println("")
+
+	// Compare against the current system state
+	current, err := snapshot.Capture(ctx, snapshot.TriggerManual, "current", getAvailableManagers())
+	if err != nil {
+		return err
+	}
+
+	diff := snapshot.Compare(past, current)
+	if source != "" {
+		var filtered []snapshot.Change
+		for _, c := range diff.Changes {
+			if c.Source == source {
+				filtered = append(filtered, c)
+			}
+		}
+		diff.Changes = filtered
+	}
+
+	ui.HeaderMsg("Changes since %s", at.Format("2006-01-02"))
+	ui.Println("")
+
+	if diff.IsEmpty() {
+		ui.SuccessMsg("No differences - the package set is unchanged")
+		return nil
+	}
+
+	printSnapshotDiff(diff)
+
+	return nil
+}
+
+// parseAsOf parses a date or timestamp. A bare date refers to the end of that day.
+func parseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", value)
+	}
+	return t.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// historyDeltas converts successful install/uninstall entries into snapshot deltas.
+func historyDeltas(entries []history.Entry) []snapshot.Delta {
+	var deltas []snapshot.Delta
+	for _, e := range entries {
+		if !e.Success || len(e.Packages) == 0 {
+			continue
+		}
+		switch e.Operation {
+		case history.OpInstall, history.OpUninstall:
+			deltas = append(deltas, snapshot.Delta{
+				Timestamp: e.Timestamp,
+				Added:     e.Operation == history.OpInstall,
+				Source:    e.Source,
+				Packages:  e.Packages,
+			})
+		}
+	}
+	return deltas
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/internal/log"
+	"poxy/internal/record"
+	"poxy/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsLimit  int
+	logsFollow bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the log of commands poxy ran",
+	Long: `Show the end of poxy's operation log, which records every command
+poxy ran through a package manager with how long it took and its exit
+status, the error output of the commands that failed, and each finished
+operation.
+
+The log is kept in ~/.local/state/poxy/poxy.log (or $XDG_STATE_HOME/poxy)
+and rotated at 5 MB, keeping three older files. It is not written in CI
+mode.
+
+Examples:
+  poxy logs            # Show the last 50 entries
+  poxy logs -l 200     # Show the last 200 entries
+  poxy logs --follow   # Keep showing entries as they are written`,
+	Args: cobra.NoArgs,
+	RunE: runLogs,
+}
+
+func init() {
+	logsCmd.Flags().IntVarP(&logsLimit, "limit", "l", 50, "number of entries to show")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "keep showing entries as they are written")
+}
+
+// openLog starts the operation log for this run. The log is a diagnostic
+// aid, so failing to open it is not an error.
+func openLog() {
+	path := config.LogPath()
+	if recording := os.Getenv(record.LogEnv); recording != "" {
+		// poxy record reads the log of the command it runs, even in CI
+		path = recording
+	} else if ciMode {
+		return
+	}
+	if err := log.Open(path); err != nil {
+		if verbose {
+			ui.WarningMsg("Could not open the log: %v", err)
+		}
+		return
+	}
+	log.Logger().Info("run", "args", strings.Join(os.Args[1:], " "))
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	path := config.LogPath()
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !logsFollow {
+		ui.InfoMsg("Nothing has been logged yet")
+		return nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read the log: %w", err)
+	}
+
+	fmt.Print(lastLines(string(content), logsLimit))
+	if !logsFollow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return followLog(ctx, path, int64(len(content)))
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "")
+}
+
+// followLog prints what is appended to the log at path from offset on,
+// until ctx is done. When the log is rotated it starts over on the new one.
+func followLog(ctx context.Context, path string, offset int64) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // Being rotated, or not created yet
+		}
+		if info.Size() < offset {
+			offset = 0 // Rotated
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err == nil {
+			n, _ := io.Copy(os.Stdout, f) //nolint:errcheck
+			offset += n
+		}
+		_ = f.Close() //nolint:errcheck
+	}
+}
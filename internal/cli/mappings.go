@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+	"poxy/pkg/database"
+
+	"github.com/spf13/cobra"
+)
+
+var mappingsSyncForce bool
+
+var mappingsCmd = &cobra.Command{
+	Use:   "mappings",
+	Short: "Manage the package mappings",
+	Long: `Manage the mappings between the names of a package in different sources,
+such as "firefox" in pacman and "org.mozilla.firefox" in Flatpak, which
+install, search and migrate use to find the same package everywhere.
+
+Mappings come from four places, later ones overriding earlier ones:
+
+  built-in   a small set shipped with poxy
+  synced     the curated dataset downloaded by 'poxy mappings sync'
+  local      JSON datasets in the mappings directory of the config directory
+  yours      mappings added with 'poxy mappings add', which extend the
+             mapping of the same name rather than replace it
+
+Examples:
+  poxy mappings sync                # Download the curated dataset
+  poxy mappings add vscode aur=visual-studio-code-bin flatpak=com.visualstudio.code
+  poxy mappings show vscode         # Show what vscode maps to
+  poxy mappings remove vscode aur   # Drop your AUR name for vscode`,
+}
+
+var mappingsAddCmd = &cobra.Command{
+	Use:   "add <name> <source=package>...",
+	Short: "Map a package name to its names in other sources",
+	Long: `Add your own cross-source mapping: name is the name you type, and each
+source=package gives the package's name in that source. Smart install and
+package name resolution use it from then on, in preference to the other
+mappings of name.
+
+Adding to an existing name adds or replaces only the sources given.
+
+Examples:
+  poxy mappings add vscode aur=visual-studio-code-bin flatpak=com.visualstudio.code
+  poxy mappings add ripgrep apt=ripgrep cargo=ripgrep`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runMappingsAdd,
+}
+
+var mappingsRemoveCmd = &cobra.Command{
+	Use:     "remove <name> [source...]",
+	Aliases: []string{"rm"},
+	Short:   "Remove mappings you added",
+	Long: `Remove the given sources from a mapping you added, or the whole mapping
+if no sources are given. Built-in and synced mappings cannot be removed,
+but a mapping you add overrides them.
+
+Examples:
+  poxy mappings remove vscode       # Forget your vscode mapping
+  poxy mappings remove vscode aur   # Only its AUR name`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeUserMappings,
+	RunE:              runMappingsRemove,
+}
+
+var mappingsShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a package's mapping, or the mappings you added",
+	Long: `Show the mapping poxy uses for a package name, canonical or as named in
+any source, with the sources you added marked. Without a name, list the
+mappings you added.
+
+Examples:
+  poxy mappings show                # Mappings you added
+  poxy mappings show firefox        # What firefox maps to
+  poxy mappings show org.gimp.GIMP  # Found by its Flatpak name`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeUserMappings,
+	RunE:              runMappingsShow,
+}
+
+var mappingsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download the curated package mapping dataset",
+	Long: `Download the curated package mapping dataset from the URL under
+[mappings] in the config, replacing the synced mappings.
+
+The dataset must be signed: its base64 ed25519 signature is read from the
+URL with ".sig" appended and checked against the configured public_key.
+A dataset with a lower serial than the one last synced is refused.
+The download is skipped if the dataset has not changed since the last
+sync (by ETag), unless --force is given.
+
+Examples:
+  poxy mappings sync                # Sync if the dataset changed
+  poxy mappings sync --force        # Download it again`,
+	Args: cobra.NoArgs,
+	RunE: runMappingsSync,
+}
+
+func init() {
+	mappingsSyncCmd.Flags().BoolVar(&mappingsSyncForce, "force", false, "download the dataset even if it has not changed")
+
+	mappingsCmd.AddCommand(mappingsSyncCmd)
+	mappingsCmd.AddCommand(mappingsAddCmd)
+	mappingsCmd.AddCommand(mappingsRemoveCmd)
+	mappingsCmd.AddCommand(mappingsShowCmd)
+}
+
+// openMappingStore opens the package database that keeps synced and user
+// mappings.
+func openMappingStore() (*database.Store, error) {
+	// The background index load holds the database
+	if indexBuilder != nil {
+		indexBuilder.WaitForLoad(5 * time.Second)
+	}
+	return database.Open()
+}
+
+func runMappingsSync(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if cfg.Mappings.URL == "" {
+		return fmt.Errorf("no mapping dataset configured: set url and public_key under [mappings] in %s", config.ConfigPath())
+	}
+	if cfg.Mappings.PublicKey == "" {
+		return fmt.Errorf("no public_key under [mappings] to verify %s with", cfg.Mappings.URL)
+	}
+	feed, err := database.NewMappingFeed(cfg.Mappings.URL, cfg.Mappings.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would sync the package mappings from %s", feed.URL)
+		return nil
+	}
+
+	store, err := openMappingStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	last, err := store.LastMappingSync()
+	if err != nil {
+		return fmt.Errorf("failed to read the last mapping sync: %w", err)
+	}
+	etag := last.ETag
+	if mappingsSyncForce {
+		etag = ""
+	}
+
+	ui.InfoMsg("Syncing package mappings from %s", feed.URL)
+	dataset, newETag, err := feed.Fetch(ctx, etag, last.Serial)
+	if errors.Is(err, database.ErrMappingsNotModified) {
+		if err := store.TouchMappingSync(last.ETag); err != nil {
+			return err
+		}
+		ui.SuccessMsg("Package mappings are up to date (%d synced)", last.Mappings)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync package mappings: %w", err)
+	}
+
+	if err := store.SaveSyncedMappings(dataset, newETag); err != nil {
+		return fmt.Errorf("failed to save package mappings: %w", err)
+	}
+	ui.SuccessMsg("Synced %d package mappings (serial %d)", len(dataset.Mappings), dataset.Serial)
+	ui.MutedMsg("Datasets in %s override them", config.MappingDir())
+	return nil
+}
+
+func runMappingsAdd(cmd *cobra.Command, args []string) error {
+	mapping := &database.Mapping{Canonical: args[0], Sources: make(map[string]string)}
+	for _, arg := range args[1:] {
+		src, name, ok := strings.Cut(arg, "=")
+		if !ok || src == "" || name == "" {
+			return fmt.Errorf("invalid mapping %q: expected source=package, e.g. flatpak=org.gimp.GIMP", arg)
+		}
+		mapping.Sources[src] = name
+	}
+	known := managerNames()
+	if err := database.CheckSources(mapping, known); err != nil {
+		return err
+	}
+
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would map %s to %s", mapping.Canonical, formatMappingSources(mapping.Sources))
+		return nil
+	}
+
+	store, err := openMappingStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	saved, err := store.AddUserMapping(mapping, known)
+	if err != nil {
+		return fmt.Errorf("failed to save the mapping: %w", err)
+	}
+	ui.SuccessMsg("Mapped %s to %s", saved.Canonical, formatMappingSources(saved.Sources))
+	return nil
+}
+
+func runMappingsRemove(cmd *cobra.Command, args []string) error {
+	canonical, sources := args[0], args[1:]
+
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would remove your mapping of %s", canonical)
+		return nil
+	}
+
+	store, err := openMappingStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	removed, err := store.RemoveUserMapping(canonical, sources...)
+	if err != nil {
+		return fmt.Errorf("failed to remove the mapping: %w", err)
+	}
+	if !removed {
+		for _, source := range sources {
+			if _, ok := registry.Get(source); !ok {
+				return fmt.Errorf("%w %q (known sources: %s)", database.ErrUnknownSource, source, strings.Join(managerNames(), ", "))
+			}
+		}
+		if len(sources) > 0 {
+			return fmt.Errorf("your mapping of %s has no %s", canonical, strings.Join(sources, " or "))
+		}
+		return fmt.Errorf("you have no mapping of %s; see 'poxy mappings show'", canonical)
+	}
+
+	if len(sources) > 0 {
+		ui.SuccessMsg("Removed %s from your mapping of %s", strings.Join(sources, ", "), canonical)
+	} else {
+		ui.SuccessMsg("Removed your mapping of %s", canonical)
+	}
+	return nil
+}
+
+func runMappingsShow(cmd *cobra.Command, args []string) error {
+	// Resolve the mapping before opening the database, which loading the
+	// mappings may need
+	var mapping *database.Mapping
+	if len(args) > 0 {
+		if mapping = packageResolver().Mapping(args[0]); mapping == nil {
+			return fmt.Errorf("no mapping for %s", args[0])
+		}
+	}
+
+	store, err := openMappingStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	user, err := store.UserMappings()
+	if err != nil {
+		return fmt.Errorf("failed to read your mappings: %w", err)
+	}
+	userSources := make(map[string]map[string]string, len(user))
+	for _, mapping := range user {
+		userSources[mapping.Canonical] = mapping.Sources
+	}
+
+	if mapping == nil {
+		if len(user) == 0 {
+			ui.InfoMsg("You have not added any mappings")
+			ui.MutedMsg("Add one with 'poxy mappings add <name> <source=package>...'")
+			return nil
+		}
+		width := 0
+		for _, mapping := range user {
+			width = max(width, len(mapping.Canonical))
+		}
+		ui.HeaderMsg("Your Mappings")
+		for _, mapping := range user {
+			ui.Println("  %-*s  %s", width, mapping.Canonical, formatMappingSources(mapping.Sources))
+		}
+		return nil
+	}
+
+	ui.HeaderMsg("%s", mapping.Canonical)
+	if mapping.Category != "" {
+		ui.MutedMsg("Category: %s", mapping.Category)
+	}
+	sources := make([]string, 0, len(mapping.Sources))
+	for src := range mapping.Sources {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+	for _, src := range sources {
+		note := ""
+		if name, ok := userSources[mapping.Canonical][src]; ok && name == mapping.Sources[src] {
+			note = ui.Muted.Sprint(" (yours)")
+		}
+		ui.Println("  %-10s %s%s", src, mapping.Sources[src], note)
+	}
+	return nil
+}
+
+// formatMappingSources formats sources as "source=package" pairs sorted by
+// source.
+func formatMappingSources(sources map[string]string) string {
+	pairs := make([]string, 0, len(sources))
+	for src, name := range sources {
+		pairs = append(pairs, src+"="+name)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// managerNames returns the sorted names of the registered package managers,
+// the sources a mapping can name.
+func managerNames() []string {
+	var names []string
+	for _, mgr := range registry.All() {
+		names = append(names, mgr.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeUserMappings completes the names of the mappings the user added.
+func completeUserMappings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	store, err := database.Open()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer store.Close()
+
+	user, _ := store.UserMappings() //nolint:errcheck
+	names := make([]string, 0, len(user))
+	for _, mapping := range user {
+		names = append(names, mapping.Canonical)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"poxy/internal/config"
+	"poxy/internal/migrate"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFrom string
+	migrateFile string
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --from <tool>",
+	Short: "Import settings and packages from another tool",
+	Long: `Import the state of another package tool into poxy, so you can switch
+without setting poxy up from scratch.
+
+  yay          Make yay the AUR helper and record the installed AUR
+               packages as coming from the AUR
+  topgrade     Map assume_yes, arch_package_manager and disabled steps
+               to poxy settings
+  brew-bundle  Record the Brewfile's formulae, casks, cargo and go
+               packages and offer to install the missing ones; formulae
+               from taps get an alias from their short name
+
+Poxy has no bundles of its own, so a Brewfile is imported as packages
+rather than kept as a file. Entries without a poxy equivalent (taps, Mac
+App Store apps, unknown topgrade keys) are listed and skipped.
+
+The plan is shown before anything changes. The config file is backed up to
+config.toml.bak before it is rewritten, and existing aliases are kept.
+
+To move packages to another distro instead, see 'poxy migrate plan'.
+
+Examples:
+  poxy migrate --from yay
+  poxy migrate --from topgrade --dry-run
+  poxy migrate --from brew-bundle --file ~/dotfiles/Brewfile`,
+	Args: cobra.NoArgs,
+	RunE: runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&migrateFrom, "from", "", "tool to migrate from (yay, topgrade, brew-bundle)")
+	migrateCmd.Flags().StringVar(&migrateFile, "file", "", "file to read instead of the tool's default location")
+	_ = migrateCmd.MarkFlagRequired("from")                                                                                        //nolint:errcheck
+	_ = migrateCmd.RegisterFlagCompletionFunc("from", cobra.FixedCompletions(migrate.Tools(), cobra.ShellCompDirectiveNoFileComp)) //nolint:errcheck
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	plan, err := buildMigratePlan(ctx)
+	if err != nil {
+		return err
+	}
+
+	installed, missing := splitInstalled(ctx, plan.Packages)
+	printMigratePlan(plan, installed, missing)
+
+	if plan.Empty() {
+		ui.InfoMsg("Nothing to migrate")
+		return nil
+	}
+	if cfg.General.DryRun {
+		ui.MutedMsg("Dry run - nothing was changed")
+		return nil
+	}
+
+	if !cfg.General.AutoConfirm {
+		confirmed, err := ui.Confirm("Apply this migration?", true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	if plan.ChangesConfig() {
+		path, err := writeMigratedConfig(plan)
+		if err != nil {
+			return err
+		}
+		ui.SuccessMsg("Updated %s", path)
+	}
+
+	if len(installed) > 0 {
+		recordResolutions(migrateResolutions(installed))
+		ui.SuccessMsg("Recorded %d installed packages", len(installed))
+	}
+
+	return installMissing(ctx, missing)
+}
+
+// buildMigratePlan reads the state of the tool given with --from.
+func buildMigratePlan(ctx context.Context) (*migrate.Plan, error) {
+	if !slices.Contains(migrate.Tools(), migrateFrom) {
+		return nil, fmt.Errorf("unknown tool %q (valid: %s)", migrateFrom, strings.Join(migrate.Tools(), ", "))
+	}
+
+	file := migrateFile
+	if file == "" {
+		file = migrate.DefaultFile(migrateFrom)
+	}
+
+	switch migrateFrom {
+	case migrate.FromYay:
+		mgr, err := registry.GetManagerForSource("aur")
+		if err != nil {
+			return nil, err
+		}
+		pkgs, err := mgr.ListInstalled(ctx, manager.ListOpts{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list AUR packages: %w", err)
+		}
+		names := make([]string, 0, len(pkgs))
+		for _, pkg := range pkgs {
+			names = append(names, pkg.Name)
+		}
+		return migrate.Yay(file, names)
+	case migrate.FromTopgrade:
+		if file == "" {
+			return nil, fmt.Errorf("no topgrade config found; pass it with --file")
+		}
+		return migrate.Topgrade(file)
+	default:
+		if file == "" {
+			return nil, fmt.Errorf("no Brewfile found; pass it with --file")
+		}
+		return migrate.BrewBundle(file)
+	}
+}
+
+// splitInstalled splits packages into those already installed from their
+// source and those that are not. Packages whose source is not available
+// count as missing.
+func splitInstalled(ctx context.Context, packages []migrate.Package) (installed, missing []migrate.Package) {
+	for _, pkg := range packages {
+		ok := false
+		if mgr, err := registry.GetManagerForSource(pkg.Source); err == nil {
+			ok, _ = mgr.IsInstalled(ctx, pkg.Name) //nolint:errcheck
+		}
+		if ok {
+			installed = append(installed, pkg)
+		} else {
+			missing = append(missing, pkg)
+		}
+	}
+	return installed, missing
+}
+
+func printMigratePlan(plan *migrate.Plan, installed, missing []migrate.Package) {
+	if plan.File != "" {
+		ui.HeaderMsg("Migrating from %s (%s)", plan.From, plan.File)
+	} else {
+		ui.HeaderMsg("Migrating from %s", plan.From)
+	}
+
+	if len(plan.Settings) > 0 {
+		ui.Println("")
+		ui.Println("Settings:")
+		for _, s := range plan.Settings {
+			ui.Println("  %s = %s", s.Key, s.Value)
+		}
+	}
+
+	if len(plan.Aliases) > 0 {
+		ui.Println("")
+		ui.Println("Aliases:")
+		for _, name := range plan.AliasNames() {
+			ui.Println("  %s -> %s", name, plan.Aliases[name])
+		}
+	}
+
+	if len(installed) > 0 {
+		ui.Println("")
+		ui.Println("Installed packages to record:")
+		for _, pkg := range installed {
+			ui.Println("  %s/%s %s", pkg.Source, pkg.Name, ui.Muted.Sprint("("+pkg.Reason+")"))
+		}
+	}
+
+	if len(missing) > 0 {
+		ui.Println("")
+		ui.Println("Packages to install:")
+		for _, pkg := range missing {
+			ui.Println("  %s/%s", pkg.Source, pkg.Name)
+		}
+	}
+
+	if len(plan.Skipped) > 0 {
+		ui.Println("")
+		ui.Println("Skipped:")
+		for _, s := range plan.Skipped {
+			ui.MutedMsg("  %s", s)
+		}
+	}
+	ui.Println("")
+}
+
+// writeMigratedConfig applies the plan to the config file, not to cfg,
+// which holds command line overrides. The previous file is kept as .bak.
+func writeMigratedConfig(plan *migrate.Plan) (string, error) {
+	path := cfgFile
+	if path == "" {
+		path = config.ConfigPath()
+	}
+
+	fileCfg, err := config.LoadFrom(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	plan.Apply(fileCfg)
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", data, 0o644); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	if err := fileCfg.SaveTo(path); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// migrateResolutions returns the install reasons recorded for packages.
+func migrateResolutions(packages []migrate.Package) []manager.Resolution {
+	resolutions := make([]manager.Resolution, 0, len(packages))
+	for _, pkg := range packages {
+		resolutions = append(resolutions, manager.Resolution{
+			Requested: pkg.Name,
+			Source:    pkg.Source,
+			Name:      pkg.Name,
+			Score:     manager.MatchExact,
+			Reason:    pkg.Reason,
+		})
+	}
+	return resolutions
+}
+
+// installMissing offers to install the packages a migration found missing,
+// grouped by source.
+func installMissing(ctx context.Context, missing []migrate.Package) error {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if !cfg.General.AutoConfirm {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Install the %d missing packages?", len(missing)), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			ui.MutedMsg("Install them later with 'poxy install -s <source> <package>'")
+			return nil
+		}
+	}
+
+	var bySource []string
+	groups := make(map[string][]migrate.Package)
+	for _, pkg := range missing {
+		if _, ok := groups[pkg.Source]; !ok {
+			bySource = append(bySource, pkg.Source)
+		}
+		groups[pkg.Source] = append(groups[pkg.Source], pkg)
+	}
+
+	var failed []string
+	for _, source := range bySource {
+		mgr, err := registry.GetManagerForSource(source)
+		if err != nil {
+			ui.WarningMsg("Skipping %d %s packages: %v", len(groups[source]), source, err)
+			failed = append(failed, source)
+			continue
+		}
+
+		names := make([]string, 0, len(groups[source]))
+		for _, pkg := range groups[source] {
+			names = append(names, pkg.Name)
+		}
+		if err := doInstallQuiet(ctx, mgr, names, migrateResolutions(groups[source])); err != nil {
+			failed = append(failed, source)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("some packages were not installed (%s)", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"poxy/internal/export"
+	"poxy/internal/migrate"
+	"poxy/internal/ui"
+	"poxy/pkg/database"
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migratePlanFrom     string
+	migratePlanTo       string
+	migratePlanSnapshot string
+	migratePlanManifest string
+	migratePlanMappings []string
+	migratePlanOutput   string
+	migratePlanFormat   string
+)
+
+var migratePlanCmd = &cobra.Command{
+	Use:   "plan --from <source> --to <source>",
+	Short: "Plan moving packages to another distro's package manager",
+	Long: `Translate the packages of one distro's package manager to another's, for
+moving to a new distro, and write the result as an installable plan.
+
+The packages are read from an export manifest (--manifest, written by
+'poxy export --format toml' or json), a snapshot (--snapshot), or the
+explicitly installed packages of this system. Each is translated:
+
+  mapping    poxy's package mappings name it in the target
+  same name  the target has a package of the same name
+  closest    the closest match in the target's search index; not
+             installed until checked
+  unmapped   nothing was found
+
+Name lookups need the target package manager, so run the plan on the new
+system to find more than the mappings know. Add mapping datasets with
+--mappings, or put them in the mappings directory of the config directory.
+
+Examples:
+  poxy export --format toml --file old.toml        # On the old system
+  poxy migrate plan --from pacman --to dnf --manifest old.toml -o plan.sh
+  poxy migrate plan --from apt --to pacman --snapshot 20240115-103000`,
+	Args: cobra.NoArgs,
+	RunE: runMigratePlan,
+}
+
+func init() {
+	migratePlanCmd.Flags().StringVar(&migratePlanFrom, "from", "", "package manager of the old distro, e.g. pacman")
+	migratePlanCmd.Flags().StringVar(&migratePlanTo, "to", "", "package manager of the new distro, e.g. dnf")
+	migratePlanCmd.Flags().StringVar(&migratePlanSnapshot, "snapshot", "", "read the packages from this snapshot")
+	migratePlanCmd.Flags().StringVar(&migratePlanManifest, "manifest", "", "read the packages from an export manifest")
+	migratePlanCmd.Flags().StringArrayVar(&migratePlanMappings, "mappings", nil, "extra mapping dataset (JSON), may be repeated")
+	migratePlanCmd.Flags().StringVarP(&migratePlanOutput, "output", "o", "", "write the installable plan to this file")
+	migratePlanCmd.Flags().StringVar(&migratePlanFormat, "format", export.FormatScript, "plan format (script, toml, json)")
+	migratePlanCmd.MarkFlagsMutuallyExclusive("snapshot", "manifest")
+	_ = migratePlanCmd.MarkFlagRequired("from")                                                                                           //nolint:errcheck
+	_ = migratePlanCmd.MarkFlagRequired("to")                                                                                             //nolint:errcheck
+	_ = migratePlanCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(export.Formats(), cobra.ShellCompDirectiveNoFileComp)) //nolint:errcheck
+
+	migrateCmd.AddCommand(migratePlanCmd)
+}
+
+func runMigratePlan(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if !slices.Contains(export.Formats(), migratePlanFormat) {
+		return fmt.Errorf("unknown format %q (valid: %s)", migratePlanFormat, strings.Join(export.Formats(), ", "))
+	}
+	for _, name := range []string{migratePlanFrom, migratePlanTo} {
+		if mgr, ok := registry.Get(name); !ok || mgr.Type() != manager.TypeNative {
+			return fmt.Errorf("unknown package manager %q: expected a native one such as apt, dnf or pacman", name)
+		}
+	}
+	if migratePlanFrom == migratePlanTo {
+		return fmt.Errorf("--from and --to are both %s", migratePlanFrom)
+	}
+
+	names, origin, err := migratePlanPackages(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		ui.InfoMsg("No %s packages in %s", migratePlanFrom, origin)
+		return nil
+	}
+
+	mappings := packageMappings()
+	for _, path := range migratePlanMappings {
+		dataset, err := database.ReadMappingFile(path)
+		if err != nil {
+			return err
+		}
+		mappings.AddBatch(dataset)
+	}
+
+	lookup := distroLookup(ctx, migratePlanTo)
+	if lookup == nil {
+		ui.WarningMsg("%s is not available here, so only the package mappings are used", migratePlanTo)
+	}
+
+	plan := migrate.PlanDistro(migratePlanFrom, migratePlanTo, names, mappings, lookup)
+	printDistroPlan(plan, origin)
+
+	if migratePlanOutput == "" {
+		ui.MutedMsg("Write the installable plan with --output <file>")
+		return nil
+	}
+	if err := plan.Manifest().WriteFile(migratePlanOutput, migratePlanFormat); err != nil {
+		return err
+	}
+	ui.SuccessMsg("Wrote the plan to %s", migratePlanOutput)
+	return nil
+}
+
+// migratePlanPackages returns the names of the --from packages to
+// translate, and where they were read from.
+func migratePlanPackages(ctx context.Context) ([]string, string, error) {
+	var names []string
+
+	switch {
+	case migratePlanManifest != "":
+		m, err := export.Read(migratePlanManifest)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, pkg := range m.Packages {
+			if pkg.Source == migratePlanFrom {
+				names = append(names, pkg.Name)
+			}
+		}
+		return names, migratePlanManifest, nil
+
+	case migratePlanSnapshot != "":
+		store, err := snapshot.OpenStore()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open snapshot store: %w", err)
+		}
+		defer store.Close()
+
+		snap, err := store.Get(migratePlanSnapshot)
+		if err != nil {
+			return nil, "", fmt.Errorf("snapshot not found: %s", migratePlanSnapshot)
+		}
+		for _, pkg := range snap.Packages {
+			if pkg.Source == migratePlanFrom {
+				names = append(names, pkg.Name)
+			}
+		}
+		return names, "snapshot " + snap.ID, nil
+
+	default:
+		mgr, err := registry.GetManagerForSource(migratePlanFrom)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w; read the packages from --manifest or --snapshot instead", err)
+		}
+		installed, err := mgr.ListInstalled(ctx, manager.ListOpts{})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list %s packages: %w", mgr.Name(), err)
+		}
+		for _, pkg := range explicitPackages(ctx, mgr, installed, 0) {
+			names = append(names, pkg.Name)
+		}
+		return names, "this system", nil
+	}
+}
+
+// distroLookup returns a lookup of packages in the target source, or nil if
+// it is not available here. A name is an exact match if the target has
+// info for it; otherwise the closest match in the search index is used.
+func distroLookup(ctx context.Context, to string) migrate.Lookup {
+	mgr, err := registry.GetManagerForSource(to)
+	if err != nil {
+		return nil
+	}
+
+	// The index loads in the background; it is only needed for closest
+	// matches, so do not wait long
+	indexed := indexBuilder != nil && indexBuilder.WaitForLoad(5*time.Second)
+
+	return func(name string) (string, bool) {
+		infoCtx, cancel := withTimeout(ctx, cfg.Timeouts.Info)
+		info, err := mgr.Info(infoCtx, name)
+		cancel()
+		if err == nil && info != nil {
+			return name, true
+		}
+
+		if !indexed {
+			return "", false
+		}
+		results := searchEngine.GetIndex().Search(name, database.SearchOptions{SourceFilter: to, Limit: 1})
+		if len(results) == 0 {
+			return "", false
+		}
+		return results[0].Package.Name, false
+	}
+}
+
+func printDistroPlan(plan *migrate.DistroPlan, origin string) {
+	ui.HeaderMsg("Moving %d %s packages from %s to %s", len(plan.Translations), plan.From, origin, plan.To)
+	ui.Println("")
+	ui.Println("  By mapping:    %d", plan.Count(migrate.MatchMapping))
+	ui.Println("  Same name:     %d", plan.Count(migrate.MatchSameName))
+	ui.Println("  Closest match: %d", plan.Count(migrate.MatchClosest))
+	ui.Println("  Unmapped:      %d", plan.Count(""))
+
+	if verbose {
+		printTranslations("Translated:", plan, migrate.MatchMapping, migrate.MatchSameName)
+	}
+	printTranslations("Check these closest matches:", plan, migrate.MatchClosest)
+	printTranslations("No equivalent found:", plan, "")
+	ui.Println("")
+}
+
+// printTranslations lists the translations found by one of matches.
+func printTranslations(title string, plan *migrate.DistroPlan, matches ...string) {
+	var lines []string
+	for _, t := range plan.Translations {
+		if !slices.Contains(matches, t.Match) {
+			continue
+		}
+		if t.To == "" {
+			lines = append(lines, t.Name)
+		} else {
+			lines = append(lines, fmt.Sprintf("%s -> %s", t.Name, t.To))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	ui.Println("")
+	ui.Println(title)
+	for _, line := range lines {
+		ui.Println("  %s", line)
+	}
+}
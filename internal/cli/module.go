@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var moduleCmd = &cobra.Command{
+	Use:   "module",
+	Short: "Manage module streams (DNF modularity)",
+	Long: `Manage module streams on Fedora/RHEL systems.
+
+Module streams provide several major versions of the same software,
+such as nodejs:18 and nodejs:20. Only one stream of a module can be
+enabled at a time; enabling another stream switches to it.
+
+Examples:
+  poxy module list                  # List available module streams
+  poxy module list --enabled        # List enabled streams only
+  poxy module enable nodejs:20      # Enable or switch to a stream
+  poxy module reset nodejs          # Reset a module to its default`,
+}
+
+var moduleListCmd = &cobra.Command{
+	Use:   "list [pattern]",
+	Short: "List module streams",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runModuleList,
+}
+
+var moduleEnableCmd = &cobra.Command{
+	Use:   "enable <name:stream>",
+	Short: "Enable or switch to a module stream",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runModuleEnable,
+}
+
+var moduleResetCmd = &cobra.Command{
+	Use:   "reset <name>",
+	Short: "Reset a module to its default state",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runModuleReset,
+}
+
+var moduleListEnabled bool
+
+func init() {
+	moduleCmd.AddCommand(moduleListCmd)
+	moduleCmd.AddCommand(moduleEnableCmd)
+	moduleCmd.AddCommand(moduleResetCmd)
+
+	moduleListCmd.Flags().BoolVar(&moduleListEnabled, "enabled", false, "show enabled streams only")
+}
+
+// getStreamManager returns the selected manager if it supports module streams.
+func getStreamManager() (manager.Manager, manager.StreamManager, error) {
+	mgr, err := getManager()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sm, ok := mgr.(manager.StreamManager)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s does not support module streams", mgr.DisplayName())
+	}
+	return mgr, sm, nil
+}
+
+// parseStreamSpec splits a name:stream specification.
+func parseStreamSpec(spec string) (name, stream string, err error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid module stream '%s' (expected name:stream, e.g. nodejs:20)", spec)
+	}
+	return parts[0], parts[1], nil
+}
+
+func runModuleList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	mgr, sm, err := getStreamManager()
+	if err != nil {
+		return err
+	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+
+	streams, err := sm.ListStreams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list module streams: %w", err)
+	}
+
+	pattern := ""
+	if len(args) > 0 {
+		pattern = strings.ToLower(args[0])
+	}
+
+	ui.HeaderMsg("Module Streams (%s)", mgr.DisplayName())
+	ui.Println("")
+
+	shown := 0
+	for _, s := range streams {
+		if moduleListEnabled && !s.Enabled {
+			continue
+		}
+		if pattern != "" && !strings.Contains(strings.ToLower(s.Name), pattern) {
+			continue
+		}
+
+		var flags []string
+		if s.Default {
+			flags = append(flags, "default")
+		}
+		if s.Enabled {
+			flags = append(flags, ui.Green("enabled"))
+		}
+		if s.Installed {
+			flags = append(flags, ui.Cyan("installed"))
+		}
+
+		ui.Println("  %-30s %s", ui.Bold(s.String()), strings.Join(flags, ", "))
+		if s.Summary != "" {
+			ui.MutedMsg("      %s", s.Summary)
+		}
+		shown++
+	}
+
+	if shown == 0 {
+		ui.MutedMsg("No module streams found")
+	}
+
+	return nil
+}
+
+func runModuleEnable(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	name, stream, err := parseStreamSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	mgr, sm, err := getStreamManager()
+	if err != nil {
+		return err
+	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+
+	streams, err := sm.ListStreams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list module streams: %w", err)
+	}
+
+	var current string
+	found := false
+	for _, s := range streams {
+		if s.Name != name {
+			continue
+		}
+		if s.Stream == stream {
+			found = true
+		}
+		if s.Enabled {
+			current = s.Stream
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("module stream '%s' not found", args[0])
+	}
+	if current == stream {
+		ui.SuccessMsg("Module stream %s is already enabled", args[0])
+		return nil
+	}
+
+	if current != "" {
+		ui.InfoMsg("Switching %s from stream %s to %s using %s", name, current, stream, mgr.DisplayName())
+		ui.MutedMsg("Installed packages from the module will be synced to the new stream.")
+	} else {
+		ui.InfoMsg("Enabling module stream %s using %s", args[0], mgr.DisplayName())
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		confirmed, err := ui.Confirm("Proceed?", true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	capturePreOperationSnapshot(ctx, snapshot.TriggerModule, []string{args[0]})
+
+	if err := sm.EnableStream(ctx, name, stream, cfg.General.DryRun); err != nil {
+		return fmt.Errorf("failed to enable module stream: %w", err)
+	}
+
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("Module stream %s enabled", args[0])
+	}
+	return nil
+}
+
+func runModuleReset(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	mgr, sm, err := getStreamManager()
+	if err != nil {
+		return err
+	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Reset module %s?", args[0]), true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	capturePreOperationSnapshot(ctx, snapshot.TriggerModule, []string{args[0]})
+
+	if err := sm.ResetStream(ctx, args[0], cfg.General.DryRun); err != nil {
+		return fmt.Errorf("failed to reset module: %w", err)
+	}
+
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("Module %s reset", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+
+	"poxy/internal/executor"
+	"poxy/internal/history"
+	"poxy/internal/ui"
+	"poxy/pkg/aur"
+)
+
+// nonInteractive is set by --non-interactive, and by --ci.
+var nonInteractive bool
+
+// Exit codes of non-interactive runs, so scripts can tell failures apart.
+// Without --non-interactive every failure exits with ExitFailure.
+const (
+	ExitFailure        = 1 // Any other failure
+	ExitNotFound       = 4 // A package was not found
+	ExitConflict       = 5 // Packages conflict, or their dependencies cannot be satisfied
+	ExitPartialFailure = 6 // Some packages were changed and others failed
+	ExitAborted        = 7 // A confirmation was needed, or was declined
+)
+
+// applyNonInteractive sets poxy up for scripts: prompts fail instead of
+// asking, output has no colors or animations and sudo fails rather than ask
+// for a password.
+func applyNonInteractive() {
+	cfg.Output.Color = false
+	cfg.Output.ReducedMotion = true
+	ui.NonInteractive = true
+
+	executor.SetNonInteractiveSudo(true)
+
+	// Keep debconf from asking questions, such as tzdata's
+	if os.Getenv("DEBIAN_FRONTEND") == "" {
+		_ = os.Setenv("DEBIAN_FRONTEND", "noninteractive") //nolint:errcheck
+	}
+}
+
+// partialFailureError marks the error of a transaction in which some steps
+// succeeded, keeping its message.
+type partialFailureError struct {
+	err error
+}
+
+func (e *partialFailureError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialFailureError) Unwrap() []error {
+	return []error{e.err, ErrPartialFailure}
+}
+
+// ExitCode returns the code poxy exits with after err.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if !nonInteractive {
+		return ExitFailure
+	}
+
+	switch {
+	case errors.Is(err, ErrAborted), errors.Is(err, ui.ErrNoPrompt):
+		return ExitAborted
+	case errors.Is(err, ErrPartialFailure):
+		return ExitPartialFailure
+	case errors.Is(err, ErrPackageNotFound), errors.Is(err, aur.ErrPackageNotFound):
+		return ExitNotFound
+	case errors.Is(err, exec.ErrNotFound):
+		return ExitFailure // A missing command, not a missing package
+	}
+
+	// Backend errors, by their parsed kind or their text
+	entry := &history.Entry{}
+	entry.MarkFailed(err)
+	switch classifyError(entry).name {
+	case "not_found":
+		return ExitNotFound
+	case "dependency_conflict":
+		return ExitConflict
+	}
+	return ExitFailure
+}
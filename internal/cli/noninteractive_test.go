@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"poxy/internal/ui"
+	"poxy/pkg/aur"
+)
+
+// backendError is a backend error carrying its parsed kind.
+type backendError struct {
+	kind string
+}
+
+func (e *backendError) Error() string              { return "backend failed" }
+func (e *backendError) ErrorKind() string          { return e.kind }
+func (e *backendError) ErrorOutput() string        { return "" }
+func (e *backendError) AffectedPackages() []string { return nil }
+func (e *backendError) ErrorSuggestion() string    { return "" }
+
+func TestExitCode(t *testing.T) {
+	defer func(saved bool) { nonInteractive = saved }(nonInteractive)
+	nonInteractive = true
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"aborted", ErrAborted, ExitAborted},
+		{"prompt needed", fmt.Errorf("remove vim: %w", ui.ErrNoPrompt), ExitAborted},
+		{"partial failure", &partialFailureError{err: errors.New("apt: exit status 100")}, ExitPartialFailure},
+		{"poxy lookup", fmt.Errorf("%w: firefx", ErrPackageNotFound), ExitNotFound},
+		{"AUR lookup", fmt.Errorf("firefx: %w", aur.ErrPackageNotFound), ExitNotFound},
+		{"missing command", &exec.Error{Name: "yay", Err: exec.ErrNotFound}, ExitFailure},
+		{"pacman missing target", errors.New("error: target not found: firefx"), ExitNotFound},
+		{"apt missing package", errors.New("E: Unable to locate package firefx"), ExitNotFound},
+		{"dnf missing package", errors.New("No match for argument: firefx"), ExitNotFound},
+		{"pacman conflict", errors.New("error: failed to prepare transaction (could not satisfy dependencies)"), ExitConflict},
+		{"apt unmet", errors.New("The following packages have unmet dependencies:"), ExitConflict},
+		{"parsed conflict", &backendError{kind: "dependency_conflict"}, ExitConflict},
+		{"parsed not found", &backendError{kind: "not_found"}, ExitNotFound},
+		{"keyring", errors.New("error: key \"ABCD1234\" could not be looked up remotely: signature is unknown trust"), ExitFailure},
+		{"file not found", errors.New("open /etc/poxy/config.toml: file not found"), ExitFailure},
+		{"lock", errors.New("error: failed to init transaction (unable to lock database)"), ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeInteractive(t *testing.T) {
+	defer func(saved bool) { nonInteractive = saved }(nonInteractive)
+	nonInteractive = false
+
+	if got := ExitCode(fmt.Errorf("%w: firefx", ErrPackageNotFound)); got != ExitFailure {
+		t.Errorf("ExitCode() without --non-interactive = %d, want %d", got, ExitFailure)
+	}
+}
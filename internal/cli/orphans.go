@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"poxy/internal/history"
+	"poxy/internal/ui"
+	"poxy/pkg/aur"
+	"poxy/pkg/manager"
+	"poxy/pkg/manager/native"
+	"poxy/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorOrphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "Report orphaned packages and other cruft",
+	Long: `Collect packages and runtimes that are probably no longer needed into
+one report, and pick which of them to remove:
+
+  - Orphaned dependencies (pacman -Qdt, apt autoremove, dnf --unneeded)
+  - DNF packages no enabled repository provides (dnf repoquery --extras)
+  - Foreign pacman packages that are not in the AUR either
+  - Flatpak runtimes no application uses
+
+Nothing is removed without being selected. Use --remove-all to remove
+every item after one confirmation.
+
+Examples:
+  poxy doctor orphans               # Report and pick items to remove
+  poxy doctor orphans --remove-all  # Remove everything reported
+  poxy doctor orphans -n --remove-all  # Show what would be removed`,
+	Args: cobra.NoArgs,
+	RunE: runDoctorOrphans,
+}
+
+var orphansRemoveAll bool
+
+func init() {
+	doctorOrphansCmd.Flags().BoolVar(&orphansRemoveAll, "remove-all", false, "remove every reported item")
+
+	doctorCmd.AddCommand(doctorOrphansCmd)
+}
+
+// cruftItem is a package or runtime the orphan report suggests removing.
+type cruftItem struct {
+	Source string
+	Name   string // Package name, or runtime ref for Flatpak
+	Reason string
+}
+
+// label returns the line shown for the item in the report.
+func (c cruftItem) label() string {
+	return fmt.Sprintf("%-8s %-40s %s", c.Source, c.Name, c.Reason)
+}
+
+func runDoctorOrphans(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	var items []cruftItem
+	err := ui.WithSpinner("Looking for orphans and cruft...", func() error {
+		items = collectCruft(ctx)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		ui.SuccessMsg("No orphaned packages or unused runtimes found")
+		return nil
+	}
+
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.label()
+	}
+
+	var selected []cruftItem
+	if orphansRemoveAll {
+		ui.HeaderMsg("Orphans and cruft")
+		for _, label := range labels {
+			ui.Println("  %s", label)
+		}
+		if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+			confirmed, err := ui.Confirm(fmt.Sprintf("Remove all %d items?", len(items)), false)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return ErrAborted
+			}
+		}
+		selected = items
+	} else {
+		chosen, err := ui.SelectMultiple(labels, ui.Bold("Orphans and cruft")+" - select items to remove:")
+		if err != nil {
+			return ErrAborted
+		}
+		index := make(map[string]cruftItem, len(items))
+		for i, label := range labels {
+			index[label] = items[i]
+		}
+		for _, label := range chosen {
+			selected = append(selected, index[label])
+		}
+	}
+
+	if len(selected) == 0 {
+		ui.InfoMsg("Nothing selected")
+		return nil
+	}
+
+	return removeCruft(ctx, selected)
+}
+
+// collectCruft gathers the report from every available source. Sources that
+// fail are reported as warnings and left out.
+func collectCruft(ctx context.Context) []cruftItem {
+	var items []cruftItem
+
+	for _, mgr := range getAvailableManagers() {
+		lister, ok := mgr.(manager.OrphanLister)
+		if !ok {
+			continue
+		}
+		orphans, err := lister.ListOrphans(ctx)
+		if err != nil {
+			ui.WarningMsg("%s: %v", mgr.DisplayName(), err)
+			continue
+		}
+		for _, name := range orphans {
+			items = append(items, cruftItem{Source: mgr.Name(), Name: name, Reason: "orphaned dependency"})
+		}
+	}
+
+	if mgr, ok := registry.Get("dnf"); ok && mgr.IsAvailable() {
+		if dnf, ok := mgr.(*native.DNF); ok {
+			extras, err := dnf.ListExtras(ctx)
+			if err != nil {
+				ui.WarningMsg("%s: %v", dnf.DisplayName(), err)
+			}
+			for _, name := range extras {
+				items = append(items, cruftItem{Source: dnf.Name(), Name: name, Reason: "not in any enabled repository"})
+			}
+		}
+	}
+
+	if pacman, err := getPacman(); err == nil {
+		gone, err := foreignWithoutAUR(ctx, pacman)
+		if err != nil {
+			ui.WarningMsg("Could not check foreign packages against the AUR: %v", err)
+		}
+		for _, name := range gone {
+			items = append(items, cruftItem{Source: pacman.Name(), Name: name, Reason: "not in the repositories or the AUR"})
+		}
+	}
+
+	if fp, err := getFlatpak(); err == nil {
+		runtimes, err := fp.ListRuntimes(ctx)
+		if err != nil {
+			ui.WarningMsg("%s: %v", fp.DisplayName(), err)
+		}
+		for _, rt := range runtimes {
+			if !rt.Unused {
+				continue
+			}
+			reason := "unused runtime"
+			if rt.IsEOL() {
+				reason = "unused end-of-life runtime"
+			}
+			items = append(items, cruftItem{Source: fp.Name(), Name: rt.Ref(), Reason: reason})
+		}
+	}
+
+	return items
+}
+
+// foreignWithoutAUR returns foreign pacman packages the AUR does not have,
+// usually because they were renamed or deleted.
+func foreignWithoutAUR(ctx context.Context, pacman *native.Pacman) ([]string, error) {
+	foreign, err := pacman.ListForeign(ctx)
+	if err != nil || len(foreign) == 0 {
+		return nil, err
+	}
+
+	found, err := aur.NewClient().InfoAll(ctx, foreign)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(found))
+	for _, pkg := range found {
+		known[pkg.Name] = true
+	}
+
+	var gone []string
+	for _, name := range foreign {
+		if !known[name] {
+			gone = append(gone, name)
+		}
+	}
+	return gone, nil
+}
+
+// removeCruft removes the selected items, one uninstall per source.
+func removeCruft(ctx context.Context, items []cruftItem) error {
+	bySource := make(map[string][]string)
+	var names []string
+	for _, item := range items {
+		bySource[item.Source] = append(bySource[item.Source], item.Name)
+		names = append(names, item.Name)
+	}
+	sources := make([]string, 0, len(bySource))
+	for src := range bySource {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	if err := confirmPrivileges(managersFor(sources)...); err != nil {
+		return err
+	}
+
+	capturePreOperationSnapshot(ctx, snapshot.TriggerUninstall, names)
+
+	var failed int
+	for _, src := range sources {
+		mgr, ok := registry.Get(src)
+		if !ok {
+			continue
+		}
+		packages := bySource[src]
+
+		entry := history.NewEntry(history.OpUninstall, mgr.Name(), packages)
+		err := mgr.Uninstall(ctx, packages, manager.UninstallOpts{
+			AutoConfirm: true, // Already selected and confirmed
+			DryRun:      cfg.General.DryRun,
+		})
+		if err != nil {
+			failed++
+			entry.MarkFailed(err)
+			ui.ErrorMsg("Failed to remove from %s: %v", mgr.DisplayName(), err)
+		} else {
+			entry.MarkSuccess()
+			ui.SuccessMsg("Removed %d item(s) from %s", len(packages), mgr.DisplayName())
+			forgetInstallReasons(mgr.Name(), packages)
+			updateIndex(ctx, mgr, packages)
+		}
+
+		recordHistory(entry)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("removal failed for %d source(s)", failed)
+	}
+	return nil
+}
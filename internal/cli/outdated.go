@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"poxy/internal/pin"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List packages with available upgrades",
+	Long: `List installed packages that have a newer version available.
+
+Upgrades that a normal upgrade would skip are shown with the reason,
+such as APT phased updates that are still rolling out, pinned or held
+packages (including those pinned with 'poxy pin'), and packages kept back
+because of dependency changes.
+
+Examples:
+  poxy outdated              # Check all package managers
+  poxy outdated -s apt       # Check only APT`,
+	Args: cobra.NoArgs,
+	RunE: runOutdated,
+}
+
+func runOutdated(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	var managers []manager.Manager
+	if source != "" {
+		mgr, err := getManager()
+		if err != nil {
+			return err
+		}
+		managers = []manager.Manager{mgr}
+	} else {
+		managers = getAvailableManagers()
+	}
+
+	var upgrades []manager.UpgradeCandidate
+	for _, mgr := range managers {
+		checker, ok := mgr.(manager.UpgradeChecker)
+		if !ok {
+			if source != "" {
+				ui.WarningMsg("%s does not support checking for upgrades", mgr.DisplayName())
+			}
+			continue
+		}
+
+		var found []manager.UpgradeCandidate
+		err := ui.WithSpinner("Checking "+mgr.DisplayName()+" for upgrades...", func() error {
+			var err error
+			found, err = checker.ListUpgradable(ctx)
+			return err
+		})
+		if err != nil {
+			continue
+		}
+		upgrades = append(upgrades, found...)
+	}
+
+	if pins, err := pin.Load(); err == nil {
+		pins.MarkHeld(upgrades)
+	}
+
+	ui.PrintUpgrades(upgrades)
+	if len(upgrades) == 0 {
+		return nil
+	}
+
+	held, phased := 0, 0
+	for _, u := range upgrades {
+		if u.Held {
+			held++
+		}
+		if strings.HasPrefix(u.HeldReason, "phased") {
+			phased++
+		}
+	}
+
+	ui.MutedMsg("\nTotal: %d upgrades, %d held back", len(upgrades), held)
+	if phased > 0 {
+		ui.MutedMsg("Phased updates roll out gradually; install them now with: poxy upgrade --phased")
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var pickLimit int
+
+var pickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Pick packages with fzf, rofi or another launcher",
+	Long: `Build launcher workflows on poxy without the TUI. 'pick search' and
+'pick list' print one package per line for a picker such as fzf, rofi or
+wofi, and 'pick install' and 'pick remove' read the chosen lines back on
+stdin.
+
+Lines are tab-separated and stable across releases:
+
+  name <TAB> source <TAB> version <TAB> installed <TAB> description
+
+The installed field is "installed" or empty. Only the name and source are
+read back, so pickers may show any fields; a line with just a name is
+installed from the best source, as 'poxy install' would.
+
+The selection is the confirmation: 'pick install' does not prompt, since
+stdin holds the selection. 'pick remove' needs --yes for the same reason.
+
+Examples:
+  poxy pick search browser | fzf -m | poxy pick install
+  poxy pick search editor | rofi -dmenu | poxy pick install
+  poxy pick list | fzf -m -d '\t' --with-nth 1,2 | poxy pick remove -y`,
+}
+
+var pickSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Print ranked search results as pick lines",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPickSearch,
+}
+
+var pickListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print installed packages as pick lines",
+	Args:  cobra.NoArgs,
+	RunE:  runPickList,
+}
+
+var pickInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the packages of pick lines read from stdin",
+	Args:  cobra.NoArgs,
+	RunE:  runPickInstall,
+}
+
+var pickRemoveCmd = &cobra.Command{
+	Use:     "remove",
+	Aliases: []string{"uninstall"},
+	Short:   "Remove the packages of pick lines read from stdin",
+	Args:    cobra.NoArgs,
+	RunE:    runPickRemove,
+}
+
+func init() {
+	pickSearchCmd.Flags().IntVarP(&pickLimit, "limit", "l", 0, "limit results (default from [limits] search)")
+	pickListCmd.Flags().IntVarP(&pickLimit, "limit", "l", 0, "limit results (default from [limits] list, 0 = all)")
+
+	pickCmd.AddCommand(pickSearchCmd)
+	pickCmd.AddCommand(pickListCmd)
+	pickCmd.AddCommand(pickInstallCmd)
+	pickCmd.AddCommand(pickRemoveCmd)
+}
+
+func runPickSearch(cmd *cobra.Command, args []string) error {
+	query := packageResolver().Alias(args[0])
+
+	searchLimit = limitFlag(cmd, pickLimit, cfg.Limits.Search)
+	searchTimeout = cfg.Timeouts.Search
+	smart := searchEngine != nil && cfg.General.SmartSearch
+
+	results, err := quietSearch(context.Background(), query, smart)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	for _, r := range results {
+		fmt.Fprintln(w, pickLine(r.Package))
+	}
+	return w.Flush()
+}
+
+func runPickList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	limit := limitFlag(cmd, pickLimit, cfg.Limits.List)
+
+	mgrs := getAvailableManagers()
+	if source != "" {
+		mgr, err := registry.GetManagerForSource(source)
+		if err != nil {
+			return err
+		}
+		mgrs = []manager.Manager{mgr}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	count := 0
+	for _, mgr := range mgrs {
+		packages, err := mgr.ListInstalled(ctx, manager.ListOpts{})
+		if err != nil {
+			continue
+		}
+		for _, pkg := range packages {
+			if limit > 0 && count >= limit {
+				return w.Flush()
+			}
+			pkg.Source = mgr.Name()
+			pkg.Installed = true
+			fmt.Fprintln(w, pickLine(pkg))
+			count++
+		}
+	}
+	return w.Flush()
+}
+
+func runPickInstall(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	sources, selected, err := readPickSelection(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		ui.InfoMsg("Nothing selected")
+		return nil
+	}
+
+	// The prompts would read the selection, which is already the answer
+	cfg.General.AutoConfirm = true
+
+	var failed []string
+	for _, src := range sources {
+		var err error
+		if src == "" {
+			err = smartInstall(ctx, selected[src])
+		} else {
+			err = installFromSource(ctx, selected[src], src)
+		}
+		if err != nil {
+			failed = append(failed, strings.Join(selected[src], ", "))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("some packages were not installed (%s)", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func runPickRemove(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	sources, selected, err := readPickSelection(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		ui.InfoMsg("Nothing selected")
+		return nil
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		return fmt.Errorf("pick remove cannot prompt for confirmation; pass --yes to remove the selected packages")
+	}
+
+	var failed []string
+	for _, src := range sources {
+		var mgr manager.Manager
+		var err error
+		if src == "" {
+			mgr, err = getManager()
+		} else {
+			mgr, err = registry.GetManagerForSource(src)
+		}
+		if err != nil {
+			ui.WarningMsg("Skipping %s: %v", strings.Join(selected[src], ", "), err)
+			failed = append(failed, strings.Join(selected[src], ", "))
+			continue
+		}
+
+		if err := doUninstall(ctx, mgr, resolvePackages(mgr, selected[src])); err != nil {
+			failed = append(failed, strings.Join(selected[src], ", "))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("some packages were not removed (%s)", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// pickLine formats pkg as a pick line: name, source, version, installed
+// and description, separated by tabs.
+func pickLine(pkg manager.Package) string {
+	installed := ""
+	if pkg.Installed {
+		installed = "installed"
+	}
+
+	// Keep every package on one line with five fields
+	description := strings.Join(strings.Fields(pkg.Description), " ")
+
+	return strings.Join([]string{pkg.Name, pkg.Source, pkg.Version, installed, description}, "\t")
+}
+
+// readPickSelection reads pick lines and returns the selected package names
+// by source, with the sources in the order first selected. Names selected
+// without a source are under "". Blank lines are ignored.
+func readPickSelection(r io.Reader) ([]string, map[string][]string, error) {
+	var sources []string
+	selected := make(map[string][]string)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		name := strings.TrimSpace(fields[0])
+		if name == "" {
+			continue
+		}
+		src := ""
+		if len(fields) > 1 {
+			src = strings.TrimSpace(fields[1])
+		}
+
+		if seen[src+"\x00"+name] {
+			continue
+		}
+		seen[src+"\x00"+name] = true
+
+		if _, ok := selected[src]; !ok {
+			sources = append(sources, src)
+		}
+		selected[src] = append(selected[src], name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read the selection: %w", err)
+	}
+
+	return sources, selected, nil
+}
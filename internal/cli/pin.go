@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/internal/pin"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pinReason   string
+	pinNoNative bool
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [packages...]",
+	Short: "Keep packages at their installed version",
+	Long: `Pin packages so 'poxy upgrade' leaves them at their installed version.
+
+Pinned packages are skipped by upgrades and marked as held in 'poxy
+outdated' and the TUI. Where the package manager has its own hold
+mechanism, the package is held there too, so upgrades run outside poxy
+respect the pin:
+
+  apt      apt-mark hold
+  dnf      dnf versionlock (needs python3-dnf-plugin-versionlock on DNF 4)
+  brew     brew pin
+  snap     snap refresh --hold (snapd 2.58 or later)
+  pacman   poxy prints the IgnorePkg line to add to /etc/pacman.conf
+
+Run without arguments to list pinned packages.
+
+Examples:
+  poxy pin                          # List pinned packages
+  poxy pin linux                    # Pin a package of the native manager
+  poxy pin -s flatpak org.gimp.GIMP # Pin a Flatpak app
+  poxy pin mesa --reason "GPU hang in 24.1"
+  poxy unpin linux                  # Allow upgrades again`,
+	ValidArgsFunction: completeInstalledPackages,
+	RunE:              runPin,
+}
+
+var unpinCmd = &cobra.Command{
+	Use:               "unpin <packages...>",
+	Short:             "Allow pinned packages to be upgraded again",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePinnedPackages,
+	RunE:              runUnpin,
+}
+
+func init() {
+	pinCmd.Flags().StringVar(&pinReason, "reason", "", "note why the package is pinned")
+	pinCmd.Flags().BoolVar(&pinNoNative, "no-native", false, "only record the pin in poxy, without holding the package in the package manager")
+}
+
+func runPin(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		return listPins()
+	}
+
+	mgr, err := getManager()
+	if err != nil {
+		return err
+	}
+
+	packages := resolvePackages(mgr, args)
+
+	installed, err := mgr.ListInstalled(ctx, manager.ListOpts{})
+	if err != nil {
+		return fmt.Errorf("failed to list installed packages: %w", err)
+	}
+	versions := make(map[string]string, len(installed))
+	for _, pkg := range installed {
+		versions[pkg.Name] = pkg.Version
+	}
+	for _, name := range packages {
+		if _, ok := versions[name]; !ok {
+			return fmt.Errorf("%s is not installed by %s", name, mgr.DisplayName())
+		}
+	}
+
+	native := false
+	if !pinNoNative {
+		if holder, ok := mgr.(manager.Holder); ok {
+			if err := confirmPrivileges(mgr); err != nil {
+				return err
+			}
+			if err := holder.Hold(ctx, packages, cfg.General.DryRun); err != nil {
+				ui.WarningMsg("Could not hold in %s: %v", mgr.DisplayName(), err)
+				ui.MutedMsg("The pin is still applied by poxy upgrade")
+			} else {
+				native = true
+			}
+		} else if advisor, ok := mgr.(manager.HoldAdvisor); ok {
+			ui.MutedMsg("%s", advisor.HoldAdvice(packages))
+		}
+	}
+
+	if cfg.General.DryRun {
+		ui.InfoMsg("Would pin %s", strings.Join(packages, ", "))
+		return nil
+	}
+
+	store, err := pin.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	for _, name := range packages {
+		p := pin.Pin{
+			Name:    name,
+			Source:  mgr.Name(),
+			Version: versions[name],
+			Reason:  pinReason,
+			Native:  native,
+		}
+		if err := store.Add(p); err != nil {
+			return fmt.Errorf("failed to pin %s: %w", name, err)
+		}
+		ui.SuccessMsg("Pinned %s %s (%s)", name, p.Version, mgr.Name())
+	}
+
+	return nil
+}
+
+func runUnpin(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	store, err := pin.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	pins, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	// Without --source, a name matches its pins in every source
+	sourceName := ""
+	if source != "" {
+		mgr, err := getManager()
+		if err != nil {
+			return err
+		}
+		sourceName = mgr.Name()
+	}
+
+	resolver := packageResolver()
+	for _, name := range args {
+		found := false
+		for _, p := range pins {
+			if p.Name != resolver.Resolve(p.Source, name) || (sourceName != "" && p.Source != sourceName) {
+				continue
+			}
+			found = true
+
+			if p.Native {
+				if err := unholdPin(ctx, p); err != nil {
+					return fmt.Errorf("failed to release the %s hold on %s: %w", p.Source, name, err)
+				}
+			}
+
+			if cfg.General.DryRun {
+				ui.InfoMsg("Would unpin %s (%s)", name, p.Source)
+				continue
+			}
+			if _, err := store.Remove(p.Source, p.Name); err != nil {
+				return fmt.Errorf("failed to unpin %s: %w", name, err)
+			}
+			ui.SuccessMsg("Unpinned %s (%s)", name, p.Source)
+		}
+
+		if !found {
+			ui.WarningMsg("%s is not pinned", name)
+		}
+	}
+
+	return nil
+}
+
+// unholdPin releases the native hold of a pin.
+func unholdPin(ctx context.Context, p pin.Pin) error {
+	mgr, ok := registry.Get(p.Source)
+	if !ok {
+		return fmt.Errorf("%s is not available", p.Source)
+	}
+	holder, ok := mgr.(manager.Holder)
+	if !ok {
+		return nil
+	}
+
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+	return holder.Unhold(ctx, []string{p.Name}, cfg.General.DryRun)
+}
+
+// listPins prints all pinned packages.
+func listPins() error {
+	store, err := pin.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	pins, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(pins) == 0 {
+		ui.InfoMsg("No pinned packages")
+		return nil
+	}
+
+	ui.HeaderMsg("Pinned packages")
+	for _, p := range pins {
+		line := fmt.Sprintf("  %-30s %-20s %-10s", p.Name, p.Version, p.Source)
+		if p.Native {
+			line += " " + ui.Cyan("held")
+		}
+		if p.Reason != "" {
+			line += "  " + p.Reason
+		}
+		ui.Println("%s", line)
+	}
+
+	return nil
+}
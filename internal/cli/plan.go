@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"poxy/internal/executor"
+	"poxy/internal/history"
+	"poxy/internal/plan"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// jsonOutput is set by --json
+	jsonOutput bool
+
+	// dryRunPlan collects what a dry run would do; nil outside dry runs
+	dryRunPlan *plan.Plan
+
+	// planStdout is where a JSON plan goes; everything else is sent to
+	// stderr to keep it parseable
+	planStdout *os.File
+)
+
+// planJSON reports whether plans are printed as JSON.
+func planJSON() bool {
+	return jsonOutput || ciMode
+}
+
+// plans reports whether cmd prints a plan when it is a dry run.
+func plans(cmd *cobra.Command) bool {
+	switch cmd {
+	case installCmd, uninstallCmd, upgradeCmd:
+		return true
+	}
+	return false
+}
+
+// startPlan starts collecting the plan of a dry run of cmd, in place of
+// printing each command that would run.
+func startPlan(cmd *cobra.Command) {
+	if !cfg.General.DryRun || !plans(cmd) {
+		return
+	}
+	dryRunPlan = plan.New()
+	executor.RecordDryRun(func(c executor.Command) {
+		dryRunPlan.Record(c.String())
+	})
+	if planJSON() {
+		planStdout = ui.StdoutToStderr()
+	}
+}
+
+// beginPlanOperation starts the planned operation of mgr on packages,
+// looking up the versions and sizes the operation would involve. All
+// packages are upgraded if none are given.
+func beginPlanOperation(ctx context.Context, op history.Operation, mgr manager.Manager, packages []string) {
+	if dryRunPlan == nil {
+		return
+	}
+
+	var planned []plan.Package
+	if op == history.OpUpgrade {
+		planned = plannedUpgrades(ctx, mgr, packages)
+	} else {
+		for _, name := range packages {
+			pkg := plan.Package{Name: name}
+			if info, err := mgr.Info(ctx, name); err == nil && info != nil {
+				pkg.Version = info.Version
+				pkg.Size = info.Size
+			}
+			planned = append(planned, pkg)
+		}
+	}
+
+	operation := dryRunPlan.Begin(string(op), mgr.Name(), planned)
+	operation.Reboot = appliesOnReboot(mgr)
+	if op == history.OpInstall {
+		operation.Scope = string(manager.InstallScope(mgr))
+	}
+}
+
+// plannedUpgrades returns the upgrades of packages, or of everything if
+// none are given, that mgr reports.
+func plannedUpgrades(ctx context.Context, mgr manager.Manager, packages []string) []plan.Package {
+	checker, ok := mgr.(manager.UpgradeChecker)
+	if !ok {
+		var planned []plan.Package
+		for _, name := range packages {
+			planned = append(planned, plan.Package{Name: name})
+		}
+		return planned
+	}
+
+	candidates, err := checker.ListUpgradable(ctx)
+	if err != nil {
+		return nil
+	}
+	wanted := make(map[string]bool, len(packages))
+	for _, name := range packages {
+		wanted[name] = true
+	}
+
+	var planned []plan.Package
+	for _, c := range candidates {
+		if c.Held || (len(packages) > 0 && !wanted[c.Name]) {
+			continue
+		}
+		planned = append(planned, plan.Package{
+			Name:           c.Name,
+			Version:        c.NewVersion,
+			CurrentVersion: c.CurrentVersion,
+		})
+	}
+	return planned
+}
+
+// endPlanOperation ends the planned operation begun last.
+func endPlanOperation() {
+	if dryRunPlan != nil {
+		dryRunPlan.End()
+	}
+}
+
+// printPlan prints the plan of a dry run, as JSON with --json.
+func printPlan() error {
+	if dryRunPlan == nil {
+		return nil
+	}
+	if planJSON() {
+		enc := json.NewEncoder(planStdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(dryRunPlan)
+	}
+	if dryRunPlan.Empty() {
+		return nil
+	}
+
+	ui.HeaderMsg("Plan (dry run)")
+	for _, op := range dryRunPlan.Operations {
+		title := fmt.Sprintf("%s with %s", op.Kind, op.Manager)
+		if op.Scope != "" {
+			title += " " + manager.Scope(op.Scope).Describe()
+		}
+		if op.Reboot {
+			title += " (applies after a reboot)"
+		}
+		ui.Println("\n%s", ui.Bold(title))
+
+		if len(op.Packages) > 0 {
+			table := ui.NewTable(nil)
+			table.AddRow([]string{"  " + ui.Bold("PACKAGE"), ui.Bold("VERSION"), ui.Bold("SIZE")})
+			for _, pkg := range op.Packages {
+				version := pkg.Version
+				if pkg.CurrentVersion != "" {
+					version = pkg.CurrentVersion + " -> " + pkg.Version
+				}
+				size := ""
+				if pkg.SizeBytes > 0 {
+					size = plan.FormatSize(pkg.SizeBytes)
+				}
+				table.AddRow([]string{"  " + pkg.Name, version, size})
+			}
+			table.Render()
+		}
+		for _, command := range op.Commands {
+			ui.MutedMsg("  $ %s", command)
+		}
+		if op.SizeBytes > 0 {
+			ui.MutedMsg("  Estimated size: %s", plan.FormatSize(op.SizeBytes))
+		}
+	}
+
+	if len(dryRunPlan.Commands) > 0 {
+		ui.Println("\n%s", ui.Bold("Other commands"))
+		for _, command := range dryRunPlan.Commands {
+			ui.MutedMsg("  $ %s", command)
+		}
+	}
+	if total := dryRunPlan.SizeBytes(); total > 0 && len(dryRunPlan.Operations) > 1 {
+		ui.Println("\nEstimated total size: %s", plan.FormatSize(total))
+	}
+	ui.MutedMsg("\nNothing was changed. Run without --dry-run to apply the plan, or add --json for a plan scripts can read.")
+	return nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+)
+
+// privilegeConsent records which managers the user has allowed to run via sudo.
+type privilegeConsent struct {
+	AcceptedAt time.Time `json:"accepted_at"`
+	Managers   []string  `json:"managers"`
+}
+
+// binaryProvider is implemented by managers that expose their command binary.
+type binaryProvider interface {
+	Binary() string
+}
+
+// confirmPrivileges shows a one-time summary of the commands mgrs, the
+// managers about to be run, may run with elevated privileges and records
+// the user's acceptance. It is shown again only for managers not accepted
+// yet. With --yes the summary is shown without a prompt and nothing is
+// recorded, so the next run without --yes still asks.
+func confirmPrivileges(mgrs ...manager.Manager) error {
+	if cfg == nil || !cfg.Policy.PromptPrivileges || cfg.General.DryRun {
+		return nil
+	}
+
+	consent := loadPrivilegeConsent()
+	accepted := make(map[string]bool)
+	for _, name := range consent.Managers {
+		accepted[name] = true
+	}
+
+	type privileged struct {
+		name    string
+		display string
+		binary  string
+	}
+
+	var pending []privileged
+	for _, mgr := range mgrs {
+		if mgr == nil || !mgr.NeedsSudo() || accepted[mgr.Name()] {
+			continue
+		}
+		accepted[mgr.Name()] = true // List each manager once
+
+		binary := mgr.Name()
+		if bp, ok := mgr.(binaryProvider); ok {
+			binary = bp.Binary()
+		}
+
+		pending = append(pending, privileged{
+			name:    mgr.Name(),
+			display: mgr.DisplayName(),
+			binary:  binary,
+		})
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ui.HeaderMsg("Privileged operations")
+	ui.Println("")
+	ui.Println("poxy is about to run the following commands with sudo:")
+	ui.Println("")
+	for _, p := range pending {
+		ui.Println("  %s  %s", ui.Cyan(p.display), ui.Bold("sudo "+p.binary))
+		ui.MutedMsg("      install, remove, upgrade, refresh databases, clean cache")
+	}
+	ui.Println("")
+	ui.MutedMsg("Restrict managers with [policy] allowed_managers in %s", config.ConfigPath())
+	ui.Println("")
+
+	if cfg.General.AutoConfirm {
+		return nil
+	}
+	confirmed, err := ui.Confirm("Allow these privileged operations?", false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return ErrAborted
+	}
+
+	for _, p := range pending {
+		consent.Managers = append(consent.Managers, p.name)
+	}
+	sort.Strings(consent.Managers)
+	consent.AcceptedAt = time.Now()
+
+	if err := savePrivilegeConsent(consent); err != nil && verbose {
+		ui.WarningMsg("Failed to record acceptance: %v", err)
+	}
+
+	return nil
+}
+
+// managersFor returns the available managers of sources, such as those a
+// plan changes, for confirmPrivileges.
+func managersFor(sources []string) []manager.Manager {
+	mgrs := make([]manager.Manager, 0, len(sources))
+	for _, source := range sources {
+		if mgr, ok := registry.Get(source); ok {
+			mgrs = append(mgrs, mgr)
+		}
+	}
+	return mgrs
+}
+
+// loadPrivilegeConsent reads the acceptance record, returning an empty record if none exists.
+func loadPrivilegeConsent() *privilegeConsent {
+	consent := &privilegeConsent{}
+
+	data, err := os.ReadFile(config.ConsentPath())
+	if err != nil {
+		return consent
+	}
+
+	_ = json.Unmarshal(data, consent) //nolint:errcheck
+	return consent
+}
+
+// savePrivilegeConsent writes the acceptance record to the data directory.
+func savePrivilegeConsent(consent *privilegeConsent) error {
+	if err := config.EnsureDataDir(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(consent, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.ConsentPath(), data, 0600)
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+)
+
+// renamedManager is a catalogManager under another name.
+type renamedManager struct {
+	*catalogManager
+	name string
+}
+
+func (m *renamedManager) Name() string { return m.name }
+
+// setupPrivileges registers a fake apt that needs sudo and returns it, with
+// no consent recorded and prompts failing with ui.ErrNoPrompt.
+func setupPrivileges(t *testing.T) manager.Manager {
+	t.Helper()
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	savedCfg, savedRegistry, savedNonInteractive := cfg, registry, ui.NonInteractive
+	t.Cleanup(func() {
+		cfg, registry, ui.NonInteractive = savedCfg, savedRegistry, savedNonInteractive
+	})
+	cfg = config.Default()
+	cfg.Policy.PromptPrivileges = true
+	ui.NonInteractive = true
+
+	apt := &catalogManager{sudo: true}
+	registry = manager.NewRegistry(cfg)
+	registry.Register(apt)
+	return apt
+}
+
+func TestConfirmPrivileges(t *testing.T) {
+	t.Run("prompts without consent", func(t *testing.T) {
+		apt := setupPrivileges(t)
+
+		if err := confirmPrivileges(apt); !errors.Is(err, ui.ErrNoPrompt) {
+			t.Errorf("confirmPrivileges() error = %v, want it to ask", err)
+		}
+	})
+
+	t.Run("only the managers being run", func(t *testing.T) {
+		setupPrivileges(t)
+		flatpak := &renamedManager{catalogManager: &catalogManager{}, name: "flatpak"}
+
+		if err := confirmPrivileges(flatpak); err != nil {
+			t.Errorf("confirmPrivileges() of a manager without sudo error = %v, want no prompt for apt", err)
+		}
+		if err := confirmPrivileges(); err != nil {
+			t.Errorf("confirmPrivileges() of no managers error = %v", err)
+		}
+	})
+
+	t.Run("--yes skips the prompt without recording consent", func(t *testing.T) {
+		apt := setupPrivileges(t)
+		cfg.General.AutoConfirm = true
+
+		if err := confirmPrivileges(apt); err != nil {
+			t.Fatalf("confirmPrivileges() with --yes error = %v", err)
+		}
+		if _, err := os.Stat(config.ConsentPath()); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("consent recorded with --yes: %v", err)
+		}
+
+		cfg.General.AutoConfirm = false
+		if err := confirmPrivileges(apt); !errors.Is(err, ui.ErrNoPrompt) {
+			t.Errorf("confirmPrivileges() after a run with --yes error = %v, want it to ask", err)
+		}
+	})
+
+	t.Run("recorded consent skips the prompt", func(t *testing.T) {
+		apt := setupPrivileges(t)
+		if err := savePrivilegeConsent(&privilegeConsent{Managers: []string{"apt"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := confirmPrivileges(apt); err != nil {
+			t.Errorf("confirmPrivileges() with consent recorded error = %v", err)
+		}
+
+		dnf := &renamedManager{catalogManager: &catalogManager{sudo: true}, name: "dnf"}
+		if err := confirmPrivileges(apt, dnf); !errors.Is(err, ui.ErrNoPrompt) {
+			t.Errorf("confirmPrivileges() with a manager not accepted yet error = %v, want it to ask", err)
+		}
+	})
+}
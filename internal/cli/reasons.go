@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"poxy/internal/ui"
+	"poxy/pkg/database"
+	"poxy/pkg/manager"
+)
+
+// recordExplicit records that packages of source were installed at the
+// user's request, for sources that do not track install reasons.
+func recordExplicit(source string, packages []string) {
+	if len(packages) == 0 || cfg.General.DryRun {
+		return
+	}
+	store, err := database.Open()
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	if err := store.SetInstallReason(source, manager.ReasonExplicit, packages...); err != nil && verbose {
+		ui.WarningMsg("Failed to record install reasons: %v", err)
+	}
+}
+
+// forgetInstallReasons drops the recorded install reasons of removed
+// packages.
+func forgetInstallReasons(source string, packages []string) {
+	if cfg.General.DryRun {
+		return
+	}
+	store, err := database.Open()
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	_ = store.ForgetInstallReasons(source, packages...) //nolint:errcheck
+}
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"poxy/internal/anomaly"
+	"poxy/internal/config"
+	"poxy/internal/history"
+	"poxy/internal/log"
+	"poxy/internal/record"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	recordOutput string
+	recordReplay string
+	recordSpeed  float64
+)
+
+// recordedVariables are the environment variables a recording keeps, for
+// how they change poxy's behavior and output.
+var recordedVariables = []string{
+	"LANG", "LC_ALL", "TERM", "NO_COLOR", "SHELL", "XDG_SESSION_TYPE",
+	"XDG_CURRENT_DESKTOP", "container", "WSL_DISTRO_NAME", "CI", "SUDO_USER",
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record <command> [args...]",
+	Short: "Record a command into a file for a bug report",
+	Long: `Run a poxy command as usual and record it into a single file to attach
+to a bug report, so it can be debugged without back-and-forth. The file
+holds:
+
+  - the command and its output, with timing
+  - the system: distribution, native manager, poxy and manager versions,
+    and locale and terminal variables
+  - the effective config
+  - the structured log the command wrote
+  - the operations it recorded, with their parsed errors
+  - package manager output poxy could not parse
+  - the error it ended with
+
+Tokens, passwords, authorization headers, passwords in URLs and private
+keys are removed, and the home directory, user name and host name replaced
+with placeholders. Read the file before sharing it all the same.
+
+Flags after the command belong to it. --replay plays a recording back with
+its original timing.
+
+Examples:
+  poxy record install htop                # Writes poxy-record-<time>.json
+  poxy record -o bug.json upgrade -s aur
+  poxy record --replay bug.json`,
+	RunE: runRecord,
+}
+
+func init() {
+	recordCmd.Flags().StringVarP(&recordOutput, "output", "o", "", "file to write the recording to (default: poxy-record-<time>.json)")
+	recordCmd.Flags().StringVar(&recordReplay, "replay", "", "play back a recording instead of recording")
+	recordCmd.Flags().Float64Var(&recordSpeed, "speed", 1, "with --replay, playback speed; 0 prints everything at once")
+	// Flags after the command belong to it
+	recordCmd.Flags().SetInterspersed(false)
+}
+
+func runRecord(cmd *cobra.Command, args []string) error {
+	if recordReplay != "" {
+		return replayRecording(recordReplay)
+	}
+	if len(args) == 0 {
+		return errors.New("no command to record; try 'poxy record install <package>'")
+	}
+	if args[0] == cmd.Name() {
+		return errors.New("a recording cannot record another")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the poxy binary: %w", err)
+	}
+	logFile, err := os.CreateTemp("", "poxy-record-*.log")
+	if err != nil {
+		return fmt.Errorf("failed to create the recording log: %w", err)
+	}
+	logFile.Close()
+	defer os.Remove(logFile.Name())
+
+	// Global flags given to record are the command's
+	childArgs := append(globalFlagArgs(cmd), args...)
+
+	transcript := record.NewTranscript()
+	child := exec.Command(exe, childArgs...)
+	child.Env = append(os.Environ(), record.LogEnv+"="+logFile.Name())
+	child.Stdin = os.Stdin
+	child.Stdout = transcript.Writer("stdout", os.Stdout)
+	child.Stderr = transcript.Writer("stderr", os.Stderr)
+
+	// Ctrl-C interrupts the command, and the recording is still written
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	started := time.Now()
+	runErr := child.Run()
+	finished := time.Now()
+
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return fmt.Errorf("failed to run poxy: %w", runErr)
+	}
+
+	chunks := transcript.Chunks()
+	bundle := &record.Bundle{
+		Version:     record.FormatVersion,
+		Args:        childArgs,
+		Started:     started,
+		Finished:    finished,
+		ExitCode:    log.ExitStatus(runErr),
+		Error:       finalError(chunks),
+		Environment: recordEnvironment(),
+		Log:         readLines(logFile.Name()),
+		Operations:  recordedOperations(started, finished),
+		Anomalies:   recordedAnomalies(started, finished),
+		Transcript:  chunks,
+	}
+	if data, err := cfg.Encode(config.FormatTOML); err == nil {
+		bundle.Config = string(data)
+	}
+	bundle.Sanitize(record.NewSanitizer())
+
+	path := recordOutput
+	if path == "" {
+		path = fmt.Sprintf("poxy-record-%s.json", started.Format("20060102-150405"))
+	}
+	if err := bundle.Write(path); err != nil {
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	ui.SuccessMsg("Recorded 'poxy %s' to %s", strings.Join(args, " "), path)
+	if bundle.ExitCode != 0 {
+		ui.MutedMsg("The command exited with status %d", bundle.ExitCode)
+	}
+	ui.MutedMsg("Secrets were removed; read the file before sharing it")
+	return nil
+}
+
+// globalFlagArgs returns the global flags set on cmd as arguments.
+func globalFlagArgs(cmd *cobra.Command) []string {
+	var args []string
+	cmd.InheritedFlags().Visit(func(f *pflag.Flag) {
+		args = append(args, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	return args
+}
+
+// finalError returns the error a recorded command ended with: the last
+// "Error:" line it printed.
+func finalError(chunks []record.Chunk) string {
+	var stderr strings.Builder
+	for _, chunk := range chunks {
+		if chunk.Stream == "stderr" {
+			stderr.WriteString(chunk.Text)
+		}
+	}
+	lines := strings.Split(stderr.String(), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if msg, ok := strings.CutPrefix(lines[i], "Error: "); ok {
+			return msg
+		}
+	}
+	return ""
+}
+
+// recordEnvironment summarizes the system for a recording.
+func recordEnvironment() record.Environment {
+	ctx, cancel := withTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	env := record.Environment{
+		PoxyVersion: fmt.Sprintf("%s (%s)", Version, Commit),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Managers:    registry.ManagerVersions(ctx),
+		Variables:   make(map[string]string),
+	}
+	if info := registry.SystemInfo(); info != nil {
+		env.Distro = info.PrettyName
+	}
+	if native := registry.Native(); native != nil {
+		env.Native = native.Name()
+	}
+	for _, name := range recordedVariables {
+		if value, ok := os.LookupEnv(name); ok {
+			env.Variables[name] = value
+		}
+	}
+	return env
+}
+
+// recordedOperations returns the history entries recorded between from
+// and to.
+func recordedOperations(from, to time.Time) []history.Entry {
+	store, err := history.Open()
+	if err != nil {
+		return nil
+	}
+	defer store.Close()
+
+	entries, _ := store.Range(from, to) //nolint:errcheck
+	return entries
+}
+
+// recordedAnomalies returns the parser anomalies logged between from and
+// to.
+func recordedAnomalies(from, to time.Time) []manager.ParserAnomaly {
+	all, err := anomaly.Open().Read()
+	if err != nil {
+		return nil
+	}
+
+	var anomalies []manager.ParserAnomaly
+	for _, a := range all {
+		if !a.Time.Before(from) && !a.Time.After(to) {
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies
+}
+
+// readLines returns the lines of the file at path, or nil if it cannot be
+// read.
+func readLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+// replayRecording plays the recording at path back.
+func replayRecording(path string) error {
+	bundle, err := record.Load(path)
+	if err != nil {
+		return err
+	}
+
+	env := bundle.Environment
+	ui.MutedMsg("Recorded %s: poxy %s", bundle.Started.Format("2006-01-02 15:04"), strings.Join(bundle.Args, " "))
+	ui.MutedMsg("poxy %s on %s (%s/%s), native manager %s", env.PoxyVersion, env.Distro, env.OS, env.Arch, env.Native)
+	fmt.Println()
+
+	if err := bundle.Replay(os.Stdout, os.Stderr, recordSpeed); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	ui.MutedMsg("Exited with status %d after %s", bundle.ExitCode, bundle.Duration().Round(time.Millisecond))
+	return nil
+}
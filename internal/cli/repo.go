@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Manage package repositories",
+	Long: `List, enable, disable and add the repositories package managers install
+from: pacman.conf repositories, APT sources, DNF repositories and Flatpak
+remotes.
+
+The commands act on the native package manager unless --source names
+another one.
+
+Examples:
+  poxy repo list                    # List the repositories of every manager
+  poxy repo disable multilib        # Disable a pacman repository
+  poxy repo enable fedora-cisco-openh264
+  poxy repo add copr:atim/starship  # Add a COPR project to DNF
+  poxy repo add ppa:fish-shell/release-3
+  poxy repo add -s flatpak flathub https://dl.flathub.org/repo/flathub.flatpakrepo`,
+}
+
+var repoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured repositories",
+	Long: `List the repositories of every package manager poxy can manage them
+for, or only those of the manager given with --source. Disabled
+repositories are listed too.
+
+Examples:
+  poxy repo list
+  poxy repo list -s flatpak
+  poxy repo list --json`,
+	Args: cobra.NoArgs,
+	RunE: runRepoList,
+}
+
+var repoEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable a repository",
+	Long: `Enable a configured repository of the native package manager, or of
+the manager given with --source.
+
+pacman repositories and APT sources are enabled by uncommenting them; DNF
+repositories with dnf config-manager; Flatpak remotes with flatpak
+remote-modify.
+
+Examples:
+  poxy repo enable multilib
+  poxy repo enable -s flatpak flathub-beta`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingle(completeRepos),
+	RunE:              runRepoEnable,
+}
+
+var repoDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable a repository",
+	Long: `Disable a repository, keeping its configuration so it can be enabled
+again. Packages installed from it stay installed but are no longer
+upgraded from it.
+
+Examples:
+  poxy repo disable multilib
+  poxy repo disable google-chrome`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingle(completeRepos),
+	RunE:              runRepoDisable,
+}
+
+var repoAddCmd = &cobra.Command{
+	Use:   "add [name] <url>",
+	Short: "Add a repository",
+	Long: `Add a repository to the native package manager, or to the manager
+given with --source. What the URL is depends on the manager:
+
+  pacman   a server, e.g. https://example.org/$repo/os/$arch (name required)
+  apt      ppa:owner/name, a source line with a name, written to
+           sources.list.d/<name>.list, or anything add-apt-repository accepts
+  dnf      copr:owner/project, a .repo file URL, or a baseurl (name
+           required with DNF 5)
+  flatpak  a .flatpakrepo URL (name required)
+
+copr: and ppa: repositories are added to DNF and APT without --source, and
+their signing keys are imported: COPR keys with rpm --import, PPA keys by
+add-apt-repository or, without it, from the Ubuntu keyserver by the
+fingerprint Launchpad lists. The package database is refreshed afterward.
+
+Examples:
+  poxy repo add copr:atim/starship
+  poxy repo add ppa:fish-shell/release-3
+  poxy repo add chaotic-aur 'https://cdn-mirror.chaotic.cx/$repo/$arch'
+  poxy repo add -s apt example "deb [signed-by=/etc/apt/keyrings/example.gpg] https://example.org/apt stable main"
+  poxy repo add https://download.docker.com/linux/fedora/docker-ce.repo
+  poxy repo add -s flatpak flathub https://dl.flathub.org/repo/flathub.flatpakrepo`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRepoAdd,
+}
+
+var repoListJSON bool
+
+func init() {
+	repoCmd.AddCommand(repoListCmd)
+	repoCmd.AddCommand(repoEnableCmd)
+	repoCmd.AddCommand(repoDisableCmd)
+	repoCmd.AddCommand(repoAddCmd)
+
+	repoListCmd.Flags().BoolVar(&repoListJSON, "json", false, "print the repositories as JSON")
+}
+
+// managerRepos are the repositories of one manager.
+type managerRepos struct {
+	Manager      string               `json:"manager"`
+	Repositories []manager.Repository `json:"repositories"`
+}
+
+// getRepoManager returns the manager the repo commands act on.
+func getRepoManager() (manager.Manager, manager.RepoManager, error) {
+	mgr, err := getManager()
+	if err != nil {
+		return nil, nil, err
+	}
+	rm, ok := mgr.(manager.RepoManager)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s does not support managing repositories", mgr.DisplayName())
+	}
+	return mgr, rm, nil
+}
+
+func runRepoList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	var managers []manager.Manager
+	if source != "" {
+		mgr, _, err := getRepoManager()
+		if err != nil {
+			return err
+		}
+		managers = []manager.Manager{mgr}
+	} else {
+		for _, mgr := range getAvailableManagers() {
+			if _, ok := mgr.(manager.RepoManager); ok {
+				managers = append(managers, mgr)
+			}
+		}
+	}
+	if len(managers) == 0 {
+		return fmt.Errorf("no available package manager supports managing repositories")
+	}
+
+	var results []managerRepos
+	for _, mgr := range managers {
+		repos, err := mgr.(manager.RepoManager).ListRepos(ctx)
+		if err != nil {
+			ui.WarningMsg("Could not list the repositories of %s: %v", mgr.DisplayName(), err)
+			continue
+		}
+		if repos == nil {
+			repos = []manager.Repository{}
+		}
+		results = append(results, managerRepos{Manager: mgr.Name(), Repositories: repos})
+	}
+
+	if repoListJSON {
+		if results == nil {
+			results = []managerRepos{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	for i, result := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		mgr, _ := registry.Get(result.Manager)
+		ui.HeaderMsg("%s repositories", mgr.DisplayName())
+		if len(result.Repositories) == 0 {
+			ui.MutedMsg("  none configured")
+			continue
+		}
+		for _, repo := range result.Repositories {
+			name := fmt.Sprintf("%-28s", repo.Name)
+			status := ui.Green("enabled ")
+			if repo.Enabled {
+				name = ui.Cyan(name)
+			} else {
+				status = ui.Muted.Sprint("disabled")
+			}
+			line := fmt.Sprintf("  %s %s", name, status)
+			if repo.URL != "" {
+				line += "  " + ui.Muted.Sprint(repo.URL)
+			}
+			ui.Println("%s", line)
+		}
+	}
+	return nil
+}
+
+func runRepoEnable(cmd *cobra.Command, args []string) error {
+	return setRepoEnabled(args[0], true)
+}
+
+func runRepoDisable(cmd *cobra.Command, args []string) error {
+	return setRepoEnabled(args[0], false)
+}
+
+// setRepoEnabled enables or disables a configured repository, doing
+// nothing if it already is.
+func setRepoEnabled(name string, enable bool) error {
+	ctx := context.Background()
+
+	mgr, rm, err := getRepoManager()
+	if err != nil {
+		return err
+	}
+
+	repos, err := rm.ListRepos(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories: %w", err)
+	}
+	var repo *manager.Repository
+	for i := range repos {
+		if repos[i].Name == name {
+			repo = &repos[i]
+			break
+		}
+	}
+	if repo == nil {
+		return fmt.Errorf("%s has no repository %s; list them with: poxy repo list -s %s", mgr.DisplayName(), name, mgr.Name())
+	}
+
+	state := "disabled"
+	if enable {
+		state = "enabled"
+	}
+	if repo.Enabled == enable {
+		ui.InfoMsg("%s is already %s", name, state)
+		return nil
+	}
+
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+	if enable {
+		err = rm.EnableRepo(ctx, name, cfg.General.DryRun)
+	} else {
+		err = rm.DisableRepo(ctx, name, cfg.General.DryRun)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to change %s: %w", name, err)
+	}
+
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("%s is now %s", name, state)
+		ui.MutedMsg("Refresh the package database with: poxy update")
+	}
+	return nil
+}
+
+func runRepoAdd(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	name, url := "", args[0]
+	if len(args) == 2 {
+		name, url = args[0], args[1]
+	}
+
+	mgr, rm, err := getRepoManagerFor(url)
+	if err != nil {
+		return err
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		ui.WarningMsg("Packages from this repository will be trusted like those of %s", mgr.DisplayName())
+		confirmed, err := ui.Confirm(fmt.Sprintf("Add %s to %s?", url, mgr.DisplayName()), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+	if err := rm.AddRepo(ctx, name, url, cfg.General.DryRun); err != nil {
+		return fmt.Errorf("failed to add repository: %w", err)
+	}
+
+	if cfg.General.DryRun {
+		return nil
+	}
+	ui.SuccessMsg("Added %s to %s", url, mgr.DisplayName())
+
+	ui.InfoMsg("Updating package database using %s", mgr.DisplayName())
+	if err := mgr.Update(ctx); err != nil {
+		return fmt.Errorf("added %s, but failed to update the package database: %w", url, err)
+	}
+	return nil
+}
+
+// repoShorthands are the repository shorthands poxy repo add takes and the
+// manager each is for.
+var repoShorthands = map[string]string{
+	"copr:": "dnf",
+	"ppa:":  "apt",
+}
+
+// getRepoManagerFor returns the manager to add the repository at url to:
+// the one its shorthand is for, such as dnf for copr:owner/project, or the
+// one getRepoManager returns.
+func getRepoManagerFor(url string) (manager.Manager, manager.RepoManager, error) {
+	for prefix, name := range repoShorthands {
+		if !strings.HasPrefix(url, prefix) {
+			continue
+		}
+		kind := strings.ToUpper(strings.TrimSuffix(prefix, ":"))
+		if source != "" && source != name {
+			return nil, nil, fmt.Errorf("%s repositories are added with %s, not %s", kind, name, source)
+		}
+		mgr, ok := registry.Get(name)
+		if !ok || !mgr.IsAvailable() {
+			return nil, nil, fmt.Errorf("%s repositories need %s, which is not available on this system", kind, name)
+		}
+		rm, ok := mgr.(manager.RepoManager)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s does not support managing repositories", mgr.DisplayName())
+		}
+		return mgr, rm, nil
+	}
+	return getRepoManager()
+}
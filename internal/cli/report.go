@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"poxy/internal/report"
+	"poxy/internal/ui"
+	"poxy/pkg/snapshot"
+)
+
+// startReport starts a report of operation if a report sink is configured
+// and poxy runs unattended (or report.always is set). It returns nil
+// otherwise, and for dry runs.
+func startReport(operation string) *report.Report {
+	if cfg == nil || cfg.General.DryRun || !report.ShouldReport(cfg.Report) {
+		return nil
+	}
+	return report.New(operation)
+}
+
+// sendReport finishes rep with the operation's outcome and delivers it. A
+// nil report is ignored, and delivery failures only warn.
+func sendReport(rep *report.Report, err error) {
+	if rep == nil {
+		return
+	}
+	rep.Finish(err)
+	if deliverErr := report.Deliver(context.Background(), rep, cfg.Report); deliverErr != nil {
+		ui.WarningMsg("Could not deliver report: %v", deliverErr)
+	}
+}
+
+// addChangesSection adds the package changes between before and the current
+// state to rep.
+func addChangesSection(ctx context.Context, rep *report.Report, before *snapshot.Snapshot) {
+	if before == nil {
+		rep.Add("Changes", "not available (snapshots are disabled or could not be captured)")
+		return
+	}
+
+	after, err := snapshot.Capture(ctx, snapshot.TriggerUpgrade, "after upgrade", getAvailableManagers())
+	if err != nil {
+		rep.Add("Changes", fmt.Sprintf("not available: %v", err))
+		return
+	}
+
+	diff := snapshot.Compare(before, after)
+	if diff.IsEmpty() {
+		rep.Add("Changes", "no packages changed")
+		return
+	}
+
+	lines := make([]string, 0, len(diff.Changes))
+	for _, c := range diff.Changes {
+		lines = append(lines, c.String())
+	}
+	rep.Add(fmt.Sprintf("Changes (%d)", len(lines)), lines...)
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"poxy/internal/config"
+	"poxy/internal/history"
+	"poxy/internal/ui"
+	"poxy/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var rescueCmd = &cobra.Command{
+	Use:   "rescue <root>",
+	Short: "Inspect a mounted system to repair it",
+	Long: `Inspect a system mounted from a live environment, such as one left
+unbootable by a broken upgrade, before repairing it with --root.
+
+With --root, poxy detects the distribution and package managers of the
+mounted system. Managers installed in the live environment use their own
+root option (pacman --root, dnf --installroot, apt -o Dir=); the others run
+chrooted into the target with its own binaries, which requires running
+poxy as root. History, snapshots and pins are read from and written to the
+data directory inside the target, so its own snapshots can be restored.
+
+The data directory is looked up at the same path inside the target. Set
+XDG_DATA_HOME to a path inside it to use another user's, for example
+/mnt/home/alice/.local/share.
+
+Examples:
+  poxy rescue /mnt                           # Inspect the system at /mnt
+  poxy --root /mnt undo --snapshot <id>      # Restore one of its snapshots
+  poxy --root /mnt upgrade                   # Finish an interrupted upgrade`,
+	Args: cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		rootDir = args[0]
+		return initializeApp()
+	},
+	RunE: runRescue,
+}
+
+func runRescue(cmd *cobra.Command, args []string) error {
+	ui.HeaderMsg("Rescue: %s", rootDir)
+	ui.Println("")
+
+	system := "unknown"
+	if info := registry.SystemInfo(); info != nil && info.PrettyName != "" {
+		system = info.PrettyName
+	}
+	ui.Println("  System:     %s", system)
+
+	native := registry.Native()
+	if native != nil {
+		ui.Println("  Native:     %s (%s)", native.Name(), rootMode(native.Name()))
+	} else {
+		ui.Println("  Native:     %s", ui.Warning.Sprint("none usable"))
+	}
+
+	var others []string
+	for _, mgr := range registry.Available() {
+		if native != nil && mgr.Name() == native.Name() {
+			continue
+		}
+		others = append(others, fmt.Sprintf("%s (%s)", mgr.Name(), rootMode(mgr.Name())))
+	}
+	sort.Strings(others)
+	if len(others) > 0 {
+		ui.Println("  Others:     %s", strings.Join(others, ", "))
+	}
+	ui.Println("  Data dir:   %s", config.DataDir())
+	ui.Println("")
+
+	printRescueState()
+
+	if native == nil {
+		ui.WarningMsg("No package manager of the target can be run from here")
+		ui.MutedMsg("Install the target's package manager in the live environment, or mount the target's /usr.")
+		return nil
+	}
+
+	ui.InfoMsg("Next steps:")
+	ui.Println("  poxy --root %s history                 # Review the last operations", rootDir)
+	ui.Println("  poxy --root %s undo --snapshot <id>    # Restore a snapshot", rootDir)
+	ui.Println("  poxy --root %s upgrade                 # Finish an interrupted upgrade", rootDir)
+	return nil
+}
+
+// rootMode describes how the named manager operates on the root.
+func rootMode(name string) string {
+	if registry.Chrooted(name) {
+		return "chrooted"
+	}
+	return "root option"
+}
+
+// printRescueState prints the target's last operation and snapshot. The
+// databases are only opened if they exist, so inspecting creates no files,
+// but opening converts a snapshot database of an older poxy to the current
+// storage format.
+func printRescueState() {
+	found := false
+
+	if _, err := os.Stat(config.HistoryPath()); err == nil {
+		if store, err := history.Open(); err == nil {
+			if entry, err := store.Last(); err == nil && entry != nil {
+				status := ui.Success.Sprint("succeeded")
+				if !entry.Success {
+					status = ui.Error.Sprint("failed")
+				}
+				ui.Println("  Last operation: %s, %s (%s)", entry.Summary(), status, entry.FormatTime())
+				found = true
+			}
+			store.Close()
+		}
+	}
+
+	if _, err := os.Stat(config.SnapshotPath()); err == nil {
+		if store, err := snapshot.OpenStore(); err == nil {
+			if snap, err := store.Latest(); err == nil && snap != nil {
+				ui.Println("  Last snapshot:  %s, %s (%s)", snap.ID, snap.Description, snap.FormatTime())
+				found = true
+			}
+			store.Close()
+		}
+	}
+
+	if !found {
+		ui.MutedMsg("No poxy history or snapshots in %s", config.DataDir())
+	}
+	ui.Println("")
+}
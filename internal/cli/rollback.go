@@ -23,7 +23,7 @@ Update, upgrade, and clean operations cannot be undone.
 
 Examples:
   poxy rollback             # Undo last reversible operation
-  poxy rollback --id=xyz    # Undo specific operation by ID`,
+  poxy rollback --id=ab12   # Undo specific operation by ID`,
 	RunE: runRollback,
 }
 
@@ -64,6 +64,9 @@ func runRollback(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("package manager not available: %s", entry.Source)
 	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
 
 	// Show what we're doing
 	ui.HeaderMsg("Rolling back: %s", entry.Summary())
@@ -71,6 +74,12 @@ func runRollback(cmd *cobra.Command, args []string) error {
 	for _, pkg := range entry.Packages {
 		ui.MutedMsg("  - %s", pkg)
 	}
+	if entry.ReverseOp == history.OpUninstall && len(entry.Services) > 0 {
+		ui.InfoMsg("Services to disable:")
+		for _, unit := range entry.Services {
+			ui.MutedMsg("  - %s", unit)
+		}
+	}
 
 	// Confirm
 	if !cfg.General.AutoConfirm {
@@ -93,6 +102,9 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		err = mgr.Install(ctx, entry.Packages, opts)
 
 	case history.OpUninstall:
+		if err := disableServices(ctx, entry.Services); err != nil {
+			ui.WarningMsg("%v", err)
+		}
 		opts := manager.UninstallOpts{
 			AutoConfirm: cfg.General.AutoConfirm,
 			DryRun:      cfg.General.DryRun,
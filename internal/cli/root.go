@@ -2,11 +2,23 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
 	"poxy/internal/config"
+	"poxy/internal/executor"
+	"poxy/internal/log"
 	"poxy/internal/ui"
+	"poxy/pkg/aur"
+	"poxy/pkg/database"
 	"poxy/pkg/manager"
+	"poxy/pkg/manager/language"
 	"poxy/pkg/manager/native"
 	"poxy/pkg/manager/universal"
+	"poxy/pkg/theme"
 
 	"github.com/spf13/cobra"
 )
@@ -19,12 +31,14 @@ var (
 	yes     bool
 	verbose bool
 	noColor bool
+	rootDir string
 
 	// Global state
 	cfg          *config.Config
 	registry     *manager.Registry
 	searchEngine *SearchEngine
 	indexBuilder *IndexBuilder
+	activeTheme  *theme.Theme // Theme from [output] theme; nil for the default
 )
 
 // Build metadata - set at build time via ldflags
@@ -42,7 +56,7 @@ macOS, and Windows. Use familiar commands regardless of your system's
 native package manager.
 
 Supported package managers:
-  Linux:    apt, dnf, pacman, zypper, xbps, apk, emerge, eopkg, nix, slackpkg, swupd
+  Linux:    apt, dnf, rpm-ostree, pacman, zypper, xbps, apk, emerge, eopkg, nix, slackpkg, swupd, opkg
   macOS:    brew
   Windows:  winget, chocolatey, scoop
   Universal: flatpak, snap, AUR helpers (yay, paru)
@@ -55,7 +69,20 @@ Examples:
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return initializeApp()
+		if cmd == demoCmd {
+			return nil // Initialized for the command it runs
+		}
+		if err := initializeApp(); err != nil {
+			return err
+		}
+		startPlan(cmd)
+		return nil
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		if cmd == demoCmd {
+			return nil
+		}
+		return printPlan()
 	},
 }
 
@@ -67,6 +94,11 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&yes, "yes", "y", false, "assume yes to all prompts")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output")
+	rootCmd.PersistentFlags().StringVar(&rootDir, "root", "", "operate on the system mounted at this path")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "with --dry-run, print the plan of an install, removal or upgrade as JSON")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "never prompt, and exit with a distinct code for each kind of failure")
+	rootCmd.PersistentFlags().BoolVar(&ciMode, "ci", false, "non-interactive preset for CI and containers: no prompts or color, JSON output, no history, fail fast")
+	_ = rootCmd.RegisterFlagCompletionFunc("source", completeSources) //nolint:errcheck
 
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
@@ -74,22 +106,50 @@ func init() {
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
 	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(pickCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(ownsCmd)
+	rootCmd.AddCommand(filesCmd)
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(autoremoveCmd)
 	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(explainErrorCmd)
 	rootCmd.AddCommand(rollbackCmd)
 	rootCmd.AddCommand(undoCmd)
 	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(assertCmd)
 	rootCmd.AddCommand(systemCmd)
+	rootCmd.AddCommand(flatpakCmd)
+	rootCmd.AddCommand(snapCmd)
+	rootCmd.AddCommand(aurCmd)
+	rootCmd.AddCommand(sourcesCmd)
+	rootCmd.AddCommand(repoCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(mappingsCmd)
+	rootCmd.AddCommand(moduleCmd)
+	rootCmd.AddCommand(keyringCmd)
 	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(rescueCmd)
+	rootCmd.AddCommand(configFilesCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.AddCommand(recordCmd)
 	rootCmd.AddCommand(selfUpdateCmd)
+	rootCmd.AddCommand(selfUninstallCmd)
 }
 
 // Execute runs the root command.
 func Execute() error {
+	defer log.Close() //nolint:errcheck
 	return rootCmd.Execute()
 }
 
@@ -97,9 +157,12 @@ func Execute() error {
 func initializeApp() error {
 	// Load configuration
 	var err error
-	if cfgFile != "" {
+	switch {
+	case demoMode:
+		cfg = config.Default() // Leave the user's settings out of the demo
+	case cfgFile != "":
 		cfg, err = config.LoadFrom(cfgFile)
-	} else {
+	default:
 		cfg, err = config.Load()
 	}
 	if err != nil {
@@ -119,13 +182,41 @@ func initializeApp() error {
 	if noColor {
 		cfg.Output.Color = false
 	}
+	if ciMode {
+		applyCIPreset()
+	}
+	if nonInteractive {
+		applyNonInteractive()
+	}
 
 	// Initialize UI
-	ui.Init(cfg.ShouldUseColor(), cfg.Output.Unicode)
+	ui.Init(cfg.ShouldUseColor(), cfg.Output.Unicode, cfg.Output.ReducedMotion)
+	if cfg.Output.Theme != "" && cfg.Output.Theme != theme.DefaultName {
+		t, err := theme.Resolve(cfg.Output.Theme, config.ThemeDir())
+		if err != nil {
+			ui.WarningMsg("Could not load theme %q: %v", cfg.Output.Theme, err)
+		} else {
+			activeTheme = t
+			ui.ApplyTheme(t)
+		}
+	}
+
+	configureSandbox()
+	checkInstallScopes()
+	recordParserAnomalies()
+	openLog()
 
 	// Initialize registry
 	registry = manager.NewRegistry(cfg)
+	if demoMode {
+		return simulateSystem()
+	}
 	registerManagers()
+	if rootDir != "" {
+		if err := applyRoot(); err != nil {
+			return err
+		}
+	}
 
 	// Detect system and available managers
 	if err := registry.Detect(); err != nil {
@@ -135,8 +226,9 @@ func initializeApp() error {
 		}
 	}
 
-	// Initialize search engine if smart search is enabled
-	if cfg.General.SmartSearch {
+	// Initialize search engine if smart search is enabled. The index
+	// describes the running system, so it is not used for another root.
+	if cfg.General.SmartSearch && rootDir == "" {
 		searchEngine = NewSearchEngine(registry)
 		indexBuilder = NewIndexBuilder(searchEngine)
 
@@ -147,11 +239,41 @@ func initializeApp() error {
 	return nil
 }
 
+// applyRoot points the registry and the data directory at the system
+// mounted at --root, leaving only the managers that can operate on it.
+func applyRoot() error {
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("invalid --root: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid --root: %s is not a directory", root)
+	}
+
+	rootDir = root
+	registry.SetRoot(root)
+	config.SetDataRoot(root)
+
+	if !executor.IsRoot() {
+		for _, mgr := range registry.All() {
+			if registry.Chrooted(mgr.Name()) {
+				ui.WarningMsg("%s runs chrooted into %s, which requires running poxy as root", mgr.Name(), root)
+			}
+		}
+	}
+	return nil
+}
+
 // registerManagers registers all available package managers.
 func registerManagers() {
 	// Native Linux managers
 	registry.Register(native.NewAPT(cfg.GetManagerConfig("apt").UseNala))
 	registry.Register(native.NewDNF())
+	registry.Register(native.NewRPMOSTree())
 	registry.Register(native.NewPacman())
 	registry.Register(native.NewZypper())
 	registry.Register(native.NewXBPS())
@@ -161,6 +283,7 @@ func registerManagers() {
 	registry.Register(native.NewNix())
 	registry.Register(native.NewSlackpkg())
 	registry.Register(native.NewSwupd())
+	registry.Register(native.NewOPKG())
 
 	// Homebrew (macOS + Linux)
 	registry.Register(native.NewBrew())
@@ -174,19 +297,53 @@ func registerManagers() {
 	registry.Register(universal.NewFlatpak(cfg.GetManagerConfig("flatpak").DefaultRemote))
 	registry.Register(universal.NewSnap(cfg.GetManagerConfig("snap").AllowClassic))
 
+	// Language ecosystem managers
+	registry.Register(language.NewCargo())
+	registry.Register(language.NewNPM(cfg.GetManagerConfig("npm").UsePnpm))
+	registry.Register(language.NewGoBin())
+
 	// AUR support - prefer native builder, fall back to helper (yay/paru)
 	aurConfig := cfg.GetManagerConfig("aur")
 	if aurConfig.UseNative {
+		if aurConfig.CacheTTL >= 0 {
+			cache := aur.NewCache(config.AURCacheDir(), aurConfig.CacheTTL)
+			cache.OnStale = func(age time.Duration, err error) {
+				ui.WarningMsg("The AUR could not be asked (%v); using answers cached %s ago", err, age.Round(time.Minute))
+			}
+			aur.SetDefaultCache(cache)
+		}
+
 		// Use poxy's native AUR builder
 		nativeAUR := universal.NewNativeAUR(aurConfig.ReviewPKGBUILD)
+		nativeAUR.SetMaxRisk(aurConfig.MaxRisk)
+		nativeAUR.SetSandboxProfile(aurConfig.SandboxProfile)
+		nativeAUR.SetOfflineBuild(aurConfig.OfflineBuild)
+		nativeAUR.SetCleanChroot(aurConfig.CleanChroot, aurConfig.ChrootDir)
+		var repo *aur.LocalRepo
+		if aurConfig.PublishRepo {
+			repo = aurRepo()
+		}
+		var prebuilt *aur.RemoteRepo
+		if aurConfig.RepoServer != "" {
+			prebuilt = aur.NewRemoteRepo(aurConfig.RepoServer, aurConfig.RepoName, aurConfig.RepoKey)
+		}
+		nativeAUR.SetRepo(repo, prebuilt)
+		nativeAUR.SetKeyserver(aurConfig.Keyserver)
+		if aurConfig.RequireApproval {
+			dir := aurConfig.ApprovalDir
+			if dir == "" {
+				dir = config.ApprovalDir()
+			}
+			nativeAUR.SetApprovals(aur.NewApprovalStore(dir, aurConfig.ApprovalKey))
+		}
 		if nativeAUR.IsAvailable() {
 			registry.Register(nativeAUR)
 		}
 	} else {
 		// Use AUR helper (yay, paru, etc.)
 		aurHelper := cfg.GetManagerConfig("pacman").AURHelper
-		if aur := universal.NewAUR(aurHelper); aur != nil {
-			registry.Register(aur)
+		if helper := universal.NewAUR(aurHelper); helper != nil {
+			registry.Register(helper)
 		}
 	}
 }
@@ -205,9 +362,43 @@ func getManager() (manager.Manager, error) {
 	return native, nil
 }
 
-// resolvePackages resolves aliases in package names.
-func resolvePackages(packages []string) []string {
-	return cfg.ResolveAliases(packages)
+// packageResolver returns the resolver for package names typed by the user.
+func packageResolver() *database.Resolver {
+	return database.NewResolver(cfg.Aliases, packageMappings())
+}
+
+var (
+	mappingsOnce   sync.Once
+	loadedMappings *database.MappingStore
+)
+
+// packageMappings returns the package mappings: the search engine's, or
+// without smart search, the mappings loaded on first use.
+func packageMappings() *database.MappingStore {
+	if searchEngine != nil {
+		return searchEngine.GetMappings()
+	}
+	mappingsOnce.Do(func() {
+		loadedMappings, _ = loadMappings() //nolint:errcheck
+	})
+	return loadedMappings
+}
+
+// loadMappings loads the package mappings, including those kept in the
+// package database if it can be opened.
+func loadMappings() (*database.MappingStore, error) {
+	store, err := database.Open()
+	if err != nil {
+		return database.LoadMappings(nil)
+	}
+	defer store.Close()
+	return database.LoadMappings(store)
+}
+
+// resolvePackages resolves aliases and cross-source mappings in package
+// names for mgr.
+func resolvePackages(mgr manager.Manager, packages []string) []string {
+	return packageResolver().ResolveAll(mgr.Name(), packages)
 }
 
 // Version command
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"poxy/internal/ui"
+	"poxy/pkg/sandbox"
+
+	"github.com/spf13/cobra"
+)
+
+// sandboxProfile and cleanChroot are the --sandbox-profile and
+// --clean-chroot of install and upgrade
+var (
+	sandboxProfile string
+	cleanChroot    bool
+)
+
+// addSandboxProfileFlag adds --sandbox-profile and --clean-chroot to a
+// command that builds AUR packages.
+func addSandboxProfileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sandboxProfile, "sandbox-profile", "", "sandbox profile AUR packages are built in (build, fetch, minimal or one from [sandbox.profiles])")
+	_ = cmd.RegisterFlagCompletionFunc("sandbox-profile", completeSandboxProfiles) //nolint:errcheck
+	cmd.Flags().BoolVar(&cleanChroot, "clean-chroot", false, "build AUR packages in a clean devtools chroot with only their declared dependencies")
+}
+
+// configureSandbox selects the sandbox backend and registers the custom
+// profiles from the config. Invalid settings are skipped with a warning.
+func configureSandbox() {
+	if err := sandbox.SetBackend(cfg.Sandbox.Backend); err != nil {
+		ui.WarningMsg("Ignoring %v", err)
+	}
+
+	for name, p := range cfg.Sandbox.Profiles {
+		err := sandbox.Define(name, sandbox.Custom{
+			Base:          p.Base,
+			BindReadOnly:  p.BindReadOnly,
+			BindReadWrite: p.BindReadWrite,
+			EnvPass:       p.EnvPass,
+			Env:           p.Env,
+			Network:       p.Network,
+		})
+		if err != nil {
+			ui.WarningMsg("Ignoring %v", err)
+		}
+	}
+}
+
+// checkSandboxProfile fails early if --sandbox-profile names no profile,
+// rather than when the first build starts.
+func checkSandboxProfile() error {
+	if sandboxProfile == "" {
+		return nil
+	}
+	_, err := sandbox.Lookup(sandboxProfile)
+	return err
+}
+
+// completeSandboxProfiles completes built-in and configured profile names.
+func completeSandboxProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !prepareCompletion(cmd) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return sandbox.Names(), cobra.ShellCompDirectiveNoFileComp
+}
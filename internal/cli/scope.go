@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"slices"
+	"sort"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+)
+
+// checkInstallScopes warns about install scope settings that are neither
+// "user" nor "system"; they are ignored.
+func checkInstallScopes() {
+	if _, err := manager.ParseScope(cfg.Policy.InstallScope); err != nil {
+		ui.WarningMsg("Ignoring [policy] install_scope: %v", err)
+	}
+	names := make([]string, 0, len(cfg.Managers))
+	for name := range cfg.Managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := manager.ParseScope(cfg.Managers[name].Scope); err != nil {
+			ui.WarningMsg("Ignoring [managers.%s] scope: %v", name, err)
+		}
+	}
+}
+
+// preferredScope returns the scope [policy] install_scope prefers sources
+// by, or "" for no preference.
+func preferredScope() manager.Scope {
+	scope, _ := manager.ParseScope(cfg.Policy.InstallScope) //nolint:errcheck // Reported at startup
+	return scope
+}
+
+// scopeRank ranks mgr by the preferred scope, lowest first: 0 if it
+// installs that way or there is no preference, 1 otherwise.
+func scopeRank(mgr manager.Manager) int {
+	if preferred := preferredScope(); preferred != "" && manager.InstallScope(mgr) != preferred {
+		return 1
+	}
+	return 0
+}
+
+// preferScope returns mgrs with those of the preferred scope first, keeping
+// their order otherwise.
+func preferScope(mgrs []manager.Manager) []manager.Manager {
+	sorted := slices.Clone(mgrs)
+	slices.SortStableFunc(sorted, func(a, b manager.Manager) int {
+		return scopeRank(a) - scopeRank(b)
+	})
+	return sorted
+}
+
+// scopeLabel returns how a plan describes who mgr installs for.
+func scopeLabel(mgr manager.Manager) string {
+	return manager.InstallScope(mgr).Describe()
+}
@@ -2,9 +2,16 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"poxy/internal/ui"
+	"poxy/pkg/database"
 	"poxy/pkg/manager"
 
 	"github.com/spf13/cobra"
@@ -12,8 +19,11 @@ import (
 
 var (
 	searchInstalled bool
+	searchAvailable bool
 	searchLimit     int
 	searchNative    bool
+	searchTimeout   time.Duration
+	searchOutput    string
 )
 
 var searchCmd = &cobra.Command{
@@ -22,7 +32,11 @@ var searchCmd = &cobra.Command{
 	Long: `Search for packages across all available package sources.
 
 By default, uses intelligent TF-IDF search for better relevance ranking.
-Results are sorted by relevance score, with exact matches first.
+Results are sorted by relevance score, with exact matches first. The same
+package found in several sources, such as firefox from pacman, Flatpak and
+Snap, is listed once with a badge for each source, and installing it asks
+which source to install from. Package mappings decide what is the same
+package, with names compared otherwise.
 
 Use --source to search a specific source only.
 Use --native to bypass TF-IDF and use native package manager search.
@@ -31,25 +45,54 @@ Examples:
   poxy search firefox           # Smart search across all sources
   poxy search vim -s apt        # Search only apt
   poxy search --installed vim   # Search installed packages only
+  poxy search --available-only vim  # Exclude installed packages
   poxy search -l 10 editor      # Limit to 10 results
-  poxy search --native firefox  # Use native search (no TF-IDF)`,
-	Args: cobra.ExactArgs(1),
-	RunE: runSearch,
+  poxy search --native firefox  # Use native search (no TF-IDF)
+  poxy search --timeout 2m vim  # Allow slow sources more time
+  poxy search -o json browser   # Machine-readable results for launchers`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingle(completeCachedPackages),
+	RunE:              runSearch,
 }
 
 func init() {
 	searchCmd.Flags().BoolVar(&searchInstalled, "installed", false, "search installed packages only")
-	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 0, "limit results (0 = default 50)")
+	searchCmd.Flags().BoolVar(&searchAvailable, "available-only", false, "exclude installed packages from results")
+	searchCmd.MarkFlagsMutuallyExclusive("installed", "available-only")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 0, "limit results (default from [limits] search)")
 	searchCmd.Flags().BoolVar(&searchNative, "native", false, "use native search instead of TF-IDF")
+	searchCmd.Flags().DurationVar(&searchTimeout, "timeout", 0, "search timeout, e.g. 30s (default from [timeouts] search)")
+	searchCmd.Flags().StringVarP(&searchOutput, "output", "o", "text", "output format: text or json")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	query := args[0]
+	query := packageResolver().Alias(args[0])
+
+	searchLimit = limitFlag(cmd, searchLimit, cfg.Limits.Search)
+	searchTimeout = timeoutFlag(cmd, searchTimeout, cfg.Timeouts.Search)
 
 	// Determine if we should use smart search
 	useSmartSearch := searchEngine != nil && !searchNative && cfg.General.SmartSearch
 
+	// Only the index corrects typos, and loading it is quicker than a
+	// live search
+	if useSmartSearch && indexBuilder != nil {
+		indexBuilder.WaitForLoad(2 * time.Second)
+	}
+
+	if ciMode && !cmd.Flags().Changed("output") {
+		searchOutput = "json"
+	}
+
+	switch searchOutput {
+	case "text":
+	case "json":
+		return searchJSON(ctx, query, useSmartSearch)
+	default:
+		return fmt.Errorf("invalid output format %q: expected text or json", searchOutput)
+	}
+
 	// If source specified, search only that source
 	if source != "" {
 		return searchSingleSource(ctx, query, source)
@@ -76,15 +119,18 @@ func searchSingleSource(ctx context.Context, query, sourceName string) error {
 	opts := manager.SearchOpts{
 		Limit:         searchLimit,
 		InstalledOnly: searchInstalled,
+		AvailableOnly: searchAvailable,
 	}
 
-	results, err := mgr.Search(ctx, query, opts)
+	searchCtx, cancel := withTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	results, err := manager.SearchScoped(searchCtx, mgr, query, opts)
 	if err != nil {
-		return err
+		return timeoutError(searchCtx, err, "search", searchTimeout)
 	}
 
-	printSearchResults(results)
-	return offerInstall(ctx, results)
+	return offerInstall(ctx, printSearchResults(results))
 }
 
 // searchSmart performs TF-IDF based intelligent search.
@@ -94,6 +140,7 @@ func searchSmart(ctx context.Context, query string) error {
 	opts := SearchOptions{
 		Limit:         searchLimit,
 		InstalledOnly: searchInstalled,
+		AvailableOnly: searchAvailable,
 		NativeFirst:   true,
 	}
 
@@ -101,27 +148,44 @@ func searchSmart(ctx context.Context, query string) error {
 		opts.Limit = 50
 	}
 
-	results, err := searchEngine.Search(ctx, query, opts)
-	if err != nil {
+	searchCtx, cancel := withTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	results, err := searchEngine.Search(searchCtx, query, opts)
+
+	// Sources that timed out would only time out again in a native search
+	var timedOut *manager.SourceTimeoutError
+	if err != nil && len(results) == 0 && !errors.As(err, &timedOut) {
 		ui.WarningMsg("Smart search error, falling back to native: %v", err)
 		return searchNativeAll(ctx, query)
 	}
+	if err != nil {
+		warnSearchErrors(searchCtx, err)
+	}
 
 	if len(results) == 0 {
 		ui.InfoMsg("No packages found matching '%s'", query)
+		ui.MutedMsg("Expected results? Check that your sources are reachable with 'poxy sources status'")
 		return nil
 	}
+	if correction := results[0].Correction; correction != "" {
+		ui.InfoMsg("No packages found matching '%s'; did you mean '%s'?", query, correction)
+		query = correction
+	}
 
 	// Print results with scores if verbose
-	printSmartResults(results)
+	groups := groupResults(packageMappings(), results)
+	printSmartResults(query, groups)
 
 	// Convert to manager.Package for install prompt
-	packages := make([]manager.Package, len(results))
-	for i, r := range results {
-		packages[i] = r.Package
+	choices := make([][]manager.Package, len(groups))
+	for i, group := range groups {
+		for _, r := range group {
+			choices[i] = append(choices[i], r.Package)
+		}
 	}
 
-	return offerInstall(ctx, packages)
+	return offerInstall(ctx, choices)
 }
 
 // searchNativeAll searches using native package managers.
@@ -131,71 +195,254 @@ func searchNativeAll(ctx context.Context, query string) error {
 	opts := manager.SearchOpts{
 		Limit:         searchLimit,
 		InstalledOnly: searchInstalled,
+		AvailableOnly: searchAvailable,
+	}
+
+	searchCtx, cancel := withTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	results, err := registry.SearchAll(searchCtx, query, opts)
+	if err != nil {
+		warnSearchErrors(searchCtx, err)
+	}
+
+	return offerInstall(ctx, printSearchResults(results))
+}
+
+// warnSearchErrors reports the sources a search across all of them got no
+// results from: those that took longer than [timeouts] probe, and those
+// that failed.
+func warnSearchErrors(ctx context.Context, err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var timedOut *manager.SourceTimeoutError
+		if errors.As(err, &timedOut) {
+			ui.WarningMsg("Partial results: %v (raise [timeouts] probe to wait longer)", timedOut)
+			continue
+		}
+		ui.WarningMsg("Some sources returned errors: %v", timeoutError(ctx, err, "search", searchTimeout))
 	}
+}
+
+// searchJSONResult is a search result as printed by --output json.
+type searchJSONResult struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Description string            `json:"description"`
+	Source      string            `json:"source"`
+	Badge       string            `json:"badge"` // Source with repository or remote, e.g. "pacman/extra"
+	Installed   bool              `json:"installed"`
+	Score       float64           `json:"score"` // 0 without smart search
+	MatchReason string            `json:"match_reason,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"`
+	Group       string            `json:"group"`                // Shared by the same package in other sources
+	Correction  string            `json:"correction,omitempty"` // Corrected query, if the query matched nothing as typed
+	Relation    string            `json:"relation,omitempty"`   // "provides" or "replaces" the query
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
 
-	results, err := registry.SearchAll(ctx, query, opts)
+// searchJSON searches like the text output does and prints the results as
+// a JSON array, for launchers and scripts. It prints nothing else and
+// never prompts.
+func searchJSON(ctx context.Context, query string, smart bool) error {
+	results, err := quietSearch(ctx, query, smart)
 	if err != nil {
-		ui.WarningMsg("Some sources returned errors: %v", err)
+		return err
 	}
 
-	printSearchResults(results)
-	return offerInstall(ctx, results)
+	mappings := packageMappings()
+	out := make([]searchJSONResult, 0, len(results))
+	for _, r := range results {
+		out = append(out, searchJSONResult{
+			Name:        r.Name,
+			Version:     r.Version,
+			Description: r.Description,
+			Source:      r.Source,
+			Badge:       r.Badge(),
+			Installed:   r.Installed,
+			Score:       r.Score,
+			MatchReason: r.MatchReason,
+			Canonical:   r.Canonical,
+			Group:       mappings.GroupKey(r.Source, r.Name),
+			Correction:  r.Correction,
+			Relation:    r.Relation(query),
+			Metadata:    r.Metadata,
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
 }
 
-// printSearchResults prints search results in standard format.
-func printSearchResults(results []manager.Package) {
+// quietSearch searches like the text output does, with the search flags,
+// without printing anything. Results are ranked if smart is set, and have
+// their canonical names.
+func quietSearch(ctx context.Context, query string, smart bool) ([]SearchResult, error) {
+	searchCtx, cancel := withTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	opts := manager.SearchOpts{
+		Limit:         searchLimit,
+		InstalledOnly: searchInstalled,
+		AvailableOnly: searchAvailable,
+	}
+
+	var results []SearchResult
+	var err error
+	switch {
+	case source != "":
+		mgr, mgrErr := registry.GetManagerForSource(source)
+		if mgrErr != nil {
+			return nil, mgrErr
+		}
+		var packages []manager.Package
+		packages, err = manager.SearchScoped(searchCtx, mgr, query, opts)
+		results = plainResults(packages)
+	case smart:
+		limit := searchLimit
+		if limit == 0 {
+			limit = 50
+		}
+		results, err = searchEngine.Search(searchCtx, query, SearchOptions{
+			Limit:         limit,
+			InstalledOnly: searchInstalled,
+			AvailableOnly: searchAvailable,
+			NativeFirst:   true,
+		})
+		if err == nil {
+			break
+		}
+		fallthrough
+	default:
+		var packages []manager.Package
+		packages, err = registry.SearchAll(searchCtx, query, opts)
+		results = plainResults(packages)
+	}
+
+	// Sources that failed are only an error if nothing was found
+	if err != nil && len(results) == 0 {
+		return nil, timeoutError(searchCtx, err, "search", searchTimeout)
+	}
+
+	return addCanonical(packageMappings(), results), nil
+}
+
+// plainResults wraps packages from a native search as unscored results.
+func plainResults(packages []manager.Package) []SearchResult {
+	results := make([]SearchResult, len(packages))
+	for i, pkg := range packages {
+		results[i] = SearchResult{Package: pkg}
+	}
+	return results
+}
+
+// printSearchResults prints search results in standard format and returns
+// them in the order numbered, each as the only choice of its number.
+func printSearchResults(results []manager.Package) [][]manager.Package {
 	if len(results) == 0 {
 		ui.InfoMsg("No packages found")
-		return
+		return nil
 	}
 
-	ui.PrintSearchResults(results)
+	ordered := ui.PrintSearchResults(results)
+	choices := make([][]manager.Package, len(ordered))
+	for i, pkg := range ordered {
+		choices[i] = []manager.Package{pkg}
+	}
+	return choices
 }
 
-// printSmartResults prints smart search results with relevance info.
-func printSmartResults(results []SearchResult) {
-	if len(results) == 0 {
+// groupResults groups ranked results that are the same package in
+// different sources, best result first.
+func groupResults(mappings *database.MappingStore, results []SearchResult) [][]SearchResult {
+	packages := make([]manager.Package, len(results))
+	for i, r := range results {
+		packages[i] = r.Package
+	}
+
+	var groups [][]SearchResult
+	for _, indexes := range mappings.GroupPackages(packages) {
+		group := make([]SearchResult, len(indexes))
+		for i, index := range indexes {
+			group[i] = results[index]
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// printSmartResults prints grouped smart search results with relevance
+// info. Each group is listed under its best result, with a badge for every
+// source it was found in; installed ones are green. Applications show
+// their AppStream name and categories. Providers of a virtual
+// package say which name they provide.
+func printSmartResults(query string, groups [][]SearchResult) {
+	if len(groups) == 0 {
 		ui.InfoMsg("No packages found")
 		return
 	}
 
-	ui.HeaderMsg("Search Results (%d)", len(results))
+	ui.HeaderMsg("Search Results (%d)", len(groups))
 	ui.Println("")
 
-	for i, r := range results {
-		// Format: [rank] name version [source] - description
+	for i, group := range groups {
+		// Format: [rank] name version [source]... - description
 		// In verbose mode, also show score and match reason
+		r := group[0]
 
 		rank := fmt.Sprintf("%2d.", i+1)
 
-		installed := ""
+		var badges []string
+		for _, alt := range group {
+			badge := alt.Badge()
+			if alt.Name != r.Name {
+				badge += " " + alt.Name
+			}
+			if alt.Installed {
+				badges = append(badges, ui.Green("["+badge+"]"))
+			} else {
+				badges = append(badges, ui.Cyan("["+badge+"]"))
+			}
+		}
+
+		tags := ""
+		if app := r.Metadata[manager.MetaAppName]; app != "" && !strings.EqualFold(app, r.Name) {
+			tags = ui.Muted.Sprintf(" %q", app)
+		}
 		if r.Installed {
-			installed = ui.Green(" [installed]")
+			tags += ui.Green(" [installed]")
+		}
+		if relation := r.Relation(query); relation != "" {
+			tags += ui.Muted.Sprintf(" (%s %s)", relation, query)
 		}
 
 		if verbose {
 			// Verbose output with score
-			ui.Println("%s %s %s [%s] (%.1f - %s)%s",
+			ui.Println("%s %s %s %s (%.1f - %s)%s",
 				rank,
 				ui.Bold(r.Name),
 				ui.Green(r.Version),
-				ui.Cyan(r.Source),
+				strings.Join(badges, " "),
 				r.Score,
 				r.MatchReason,
-				installed,
+				tags,
 			)
 		} else {
 			// Normal output
-			ui.Println("%s %s %s [%s]%s",
+			ui.Println("%s %s %s %s%s",
 				rank,
 				ui.Bold(r.Name),
 				ui.Green(r.Version),
-				ui.Cyan(r.Source),
-				installed,
+				strings.Join(badges, " "),
+				tags,
 			)
 		}
 
-		// Description (truncated)
+		// Description (truncated) and application categories
 		if r.Description != "" {
 			desc := r.Description
 			if len(desc) > 70 {
@@ -203,6 +450,9 @@ func printSmartResults(results []SearchResult) {
 			}
 			ui.MutedMsg("    %s", desc)
 		}
+		if categories := r.Metadata[manager.MetaCategories]; categories != "" {
+			ui.MutedMsg("    Categories: %s", strings.ReplaceAll(categories, ",", ", "))
+		}
 	}
 
 	// Show index stats if verbose
@@ -212,17 +462,109 @@ func printSmartResults(results []SearchResult) {
 	}
 }
 
-// offerInstall offers to install a selected package.
-func offerInstall(ctx context.Context, results []manager.Package) error {
-	if len(results) == 0 {
+// searchPrompt is the prompt offered after numbered search results.
+const searchPrompt = "Enter number to install, i<N> for info, q to quit"
+
+// searchAction is what to do with a search result chosen at the prompt.
+type searchAction int
+
+const (
+	searchQuit searchAction = iota
+	searchInstall
+	searchInfo
+)
+
+// offerInstall prompts for a numbered search result to install or show
+// info for, like yay does. Each number has the choices of sources for the
+// package, best first. Info can be shown for several results in turn;
+// choosing one to install ends the prompt. It is skipped without a terminal
+// and when search_prompt is off.
+func offerInstall(ctx context.Context, results [][]manager.Package) error {
+	if len(results) == 0 || !cfg.General.SearchPrompt || !ui.Interactive() {
 		return nil
 	}
 
-	pkg, err := ui.SelectPackage(results, "Select a package to install")
-	if err != nil || pkg == nil {
-		return nil
+	ui.Println("")
+	for {
+		answer, err := ui.Input(searchPrompt, "")
+		if err != nil {
+			return nil // Interrupted
+		}
+
+		action, index, err := parseSearchAction(answer, len(results))
+		if err != nil {
+			ui.WarningMsg("%v", err)
+			continue
+		}
+
+		switch action {
+		case searchQuit:
+			return nil
+		case searchInfo:
+			showResultInfo(ctx, results[index][0])
+		case searchInstall:
+			return installResult(ctx, results[index])
+		}
+	}
+}
+
+// parseSearchAction parses an answer to the search prompt: nothing or "q"
+// to quit, "N" to install result N and "iN" to show its info. It returns
+// the result's 0-based index.
+func parseSearchAction(answer string, count int) (searchAction, int, error) {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" || answer == "q" || answer == "quit" {
+		return searchQuit, 0, nil
+	}
+
+	action := searchInstall
+	if rest, ok := strings.CutPrefix(answer, "i"); ok {
+		action = searchInfo
+		answer = strings.TrimSpace(rest)
+	}
+
+	n, err := strconv.Atoi(answer)
+	if err != nil {
+		return searchQuit, 0, fmt.Errorf("expected a result number, i<N> or q")
+	}
+	if n < 1 || n > count {
+		return searchQuit, 0, fmt.Errorf("no result %d; choose 1-%d", n, count)
 	}
 
+	return action, n - 1, nil
+}
+
+// showResultInfo prints the info of a search result.
+func showResultInfo(ctx context.Context, pkg manager.Package) {
+	mgr, ok := registry.Get(pkg.Source)
+	if !ok {
+		ui.ErrorMsg("package manager not available: %s", pkg.Source)
+		return
+	}
+
+	timeout := cfg.Timeouts.Info
+	infoCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	info, err := mgr.Info(infoCtx, pkg.Name)
+	if err != nil {
+		ui.ErrorMsg("%v", timeoutError(infoCtx, err, "info", timeout))
+		return
+	}
+
+	ui.PrintPackageInfo(info)
+	ui.Println("")
+}
+
+// installResult installs a search result after confirmation, asking which
+// source to install from if it was found in several.
+func installResult(ctx context.Context, choices []manager.Package) error {
+	chosen, err := ui.SelectPackage(choices, "Install from which source?")
+	if err != nil {
+		return nil // Interrupted
+	}
+	pkg := *chosen
+
 	mgr, ok := registry.Get(pkg.Source)
 	if !ok {
 		return fmt.Errorf("package manager not available: %s", pkg.Source)
@@ -242,6 +584,11 @@ func runInstallPackage(ctx context.Context, mgr manager.Manager, pkg string) err
 	opts := manager.InstallOpts{
 		AutoConfirm: cfg.General.AutoConfirm,
 		DryRun:      cfg.General.DryRun,
+		ExtraArgs:   backendArgs(mgr.Name()),
+	}
+
+	if err := prepareSystem(ctx, mgr); err != nil {
+		return err
 	}
 
 	err := mgr.Install(ctx, []string{pkg}, opts)
@@ -251,5 +598,6 @@ func runInstallPackage(ctx context.Context, mgr manager.Manager, pkg string) err
 	}
 
 	ui.SuccessMsg("Successfully installed %s", pkg)
+	warnReboot(mgr)
 	return nil
 }
@@ -5,7 +5,10 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"poxy/internal/ui"
+	"poxy/pkg/appstream"
 	"poxy/pkg/database"
 	"poxy/pkg/manager"
 )
@@ -16,13 +19,13 @@ type SearchEngine struct {
 	mu sync.RWMutex
 
 	index    *database.Index
-	store    *database.Store
 	mappings *database.MappingStore
 	registry *manager.Registry
 
 	// State
 	indexReady bool
 	indexSize  int
+	catalogs   map[string]time.Time // When each source's catalog was downloaded
 }
 
 // SearchResult represents a search result with relevance information.
@@ -30,6 +33,8 @@ type SearchResult struct {
 	manager.Package
 	Score       float64 // Relevance score (higher is better)
 	MatchReason string  // Why this package matched
+	Canonical   string  // Canonical name from the package mappings, if mapped
+	Correction  string  // Corrected query the package matched, if the query had typos
 }
 
 // SearchOptions configures search behavior.
@@ -37,14 +42,20 @@ type SearchOptions struct {
 	Limit         int    // Maximum results (0 = default 50)
 	SourceFilter  string // Only search this source
 	InstalledOnly bool   // Only return installed packages
+	AvailableOnly bool   // Only return packages that are not installed
 	NativeFirst   bool   // Boost native packages in ranking
 }
 
 // NewSearchEngine creates a new search engine.
 func NewSearchEngine(registry *manager.Registry) *SearchEngine {
+	mappings, err := loadMappings()
+	if err != nil && verbose {
+		ui.WarningMsg("Some package mappings were not loaded: %v", err)
+	}
+
 	return &SearchEngine{
 		index:    database.NewIndex(),
-		mappings: database.NewMappingStore(),
+		mappings: mappings,
 		registry: registry,
 	}
 }
@@ -81,7 +92,8 @@ func (e *SearchEngine) Search(ctx context.Context, query string, opts SearchOpti
 			Limit:          opts.Limit * 2, // Get more for merging
 			SourceFilter:   opts.SourceFilter,
 			InstalledOnly:  opts.InstalledOnly,
-			BoostInstalled: true,
+			AvailableOnly:  opts.AvailableOnly,
+			BoostInstalled: !opts.AvailableOnly,
 			NativeSource:   nativeSource,
 		}
 
@@ -95,30 +107,50 @@ func (e *SearchEngine) Search(ctx context.Context, query string, opts SearchOpti
 					Package:     r.Package,
 					Score:       r.Score,
 					MatchReason: r.MatchReason,
+					Correction:  r.Correction,
 				})
 			}
 
-			// Optionally merge with live results for freshness
-			liveResults, _ := e.searchLive(ctx, query, opts) //nolint:errcheck
-			results = e.mergeResults(results, liveResults, opts.Limit)
+			// Optionally merge with live results for freshness; a
+			// query with typos finds nothing live
+			if indexResults[0].Correction == "" {
+				liveResults, _ := e.searchLive(ctx, query, opts) //nolint:errcheck
+				results = e.mergeResults(results, liveResults, opts.Limit)
+			} else if len(results) > opts.Limit {
+				results = results[:opts.Limit]
+			}
 
-			return results, nil
+			return addCanonical(e.mappings, results), nil
 		}
 	}
 
 	// Fallback to live search
-	return e.searchLive(ctx, query, opts)
+	results, err := e.searchLive(ctx, query, opts)
+	return addCanonical(e.mappings, results), err
 }
 
-// searchLive performs a live search across all managers.
+// addCanonical sets the canonical name of results that have a mapping.
+func addCanonical(mappings *database.MappingStore, results []SearchResult) []SearchResult {
+	for i := range results {
+		if mapping := mappings.GetBySourceName(results[i].Source, results[i].Name); mapping != nil {
+			results[i].Canonical = mapping.Canonical
+		}
+	}
+	return results
+}
+
+// searchLive performs a live search across all managers. Results some
+// sources did not contribute to come with the error saying why.
 func (e *SearchEngine) searchLive(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
 	mgrOpts := manager.SearchOpts{
 		Limit:         opts.Limit,
 		InstalledOnly: opts.InstalledOnly,
+		AvailableOnly: opts.AvailableOnly,
 	}
 
 	var packages []manager.Package
 	var err error
+	var mgrs []manager.Manager
 
 	if opts.SourceFilter != "" {
 		// Search specific source
@@ -126,16 +158,23 @@ func (e *SearchEngine) searchLive(ctx context.Context, query string, opts Search
 		if mgrErr != nil {
 			return nil, mgrErr
 		}
-		packages, err = mgr.Search(ctx, query, mgrOpts)
+		mgrs = []manager.Manager{mgr}
+		packages, err = manager.SearchScoped(ctx, mgr, query, mgrOpts)
 	} else {
 		// Search all sources
+		mgrs = e.registry.Available()
 		packages, err = e.registry.SearchAll(ctx, query, mgrOpts)
 	}
 
-	if err != nil {
+	// Sources that did not answer leave the results partial, not empty
+	if err != nil && len(packages) == 0 {
 		return nil, err
 	}
 
+	if !opts.InstalledOnly {
+		packages = appendProviders(packages, findProviders(ctx, mgrs, query))
+	}
+
 	// Convert to SearchResult with basic scoring
 	results := make([]SearchResult, 0, len(packages))
 	queryLower := strings.ToLower(query)
@@ -160,7 +199,7 @@ func (e *SearchEngine) searchLive(ctx context.Context, query string, opts Search
 		results = results[:opts.Limit]
 	}
 
-	return results, nil
+	return results, err
 }
 
 // calculateBasicScore calculates a simple relevance score.
@@ -174,6 +213,9 @@ func (e *SearchEngine) calculateBasicScore(pkg manager.Package, queryLower strin
 	} else if strings.HasPrefix(nameLower, queryLower) {
 		// Prefix match
 		score = 50.0
+	} else if pkg.Relation(queryLower) != "" {
+		// Provides or replaces the queried name
+		score = 40.0
 	} else if strings.Contains(nameLower, queryLower) {
 		// Contains in name
 		score = 25.0
@@ -205,6 +247,12 @@ func (e *SearchEngine) getMatchReason(pkg manager.Package, queryLower string) st
 	if strings.HasPrefix(nameLower, queryLower) {
 		return "Name prefix"
 	}
+	switch pkg.Relation(queryLower) {
+	case "provides":
+		return "Provides " + queryLower
+	case "replaces":
+		return "Replaces " + queryLower
+	}
 	if strings.Contains(nameLower, queryLower) {
 		return "Name contains"
 	}
@@ -214,6 +262,43 @@ func (e *SearchEngine) getMatchReason(pkg manager.Package, queryLower string) st
 	return "Keyword"
 }
 
+// findProviders returns the packages of mgrs that provide name. Queries
+// with several words cannot be package names and find nothing.
+func findProviders(ctx context.Context, mgrs []manager.Manager, name string) []manager.Package {
+	if strings.ContainsAny(name, " \t") {
+		return nil
+	}
+
+	var providers []manager.Package
+	for _, mgr := range mgrs {
+		finder, ok := mgr.(manager.ProviderFinder)
+		if !ok {
+			continue
+		}
+		found, err := finder.FindProviders(ctx, name)
+		if err != nil {
+			continue
+		}
+		providers = append(providers, found...)
+	}
+	return providers
+}
+
+// appendProviders adds the providers that are not already in packages.
+func appendProviders(packages, providers []manager.Package) []manager.Package {
+	seen := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		seen[pkg.Source+":"+pkg.Name] = true
+	}
+	for _, pkg := range providers {
+		if !seen[pkg.Source+":"+pkg.Name] {
+			seen[pkg.Source+":"+pkg.Name] = true
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
 // mergeResults merges TF-IDF results with live results, deduplicating.
 func (e *SearchEngine) mergeResults(indexed, live []SearchResult, limit int) []SearchResult {
 	// Build a set of already-seen packages
@@ -229,6 +314,20 @@ func (e *SearchEngine) mergeResults(indexed, live []SearchResult, limit int) []S
 		}
 	}
 
+	// Live results carry source metadata, such as AUR votes, that the
+	// cached packages may lack
+	liveMetadata := make(map[string]map[string]string)
+	for _, r := range live {
+		if len(r.Metadata) > 0 {
+			liveMetadata[r.Source+":"+r.Name] = r.Metadata
+		}
+	}
+	for i := range results {
+		if results[i].Metadata == nil {
+			results[i].Metadata = liveMetadata[results[i].Source+":"+results[i].Name]
+		}
+	}
+
 	// Add live results that aren't duplicates
 	for _, r := range live {
 		key := r.Source + ":" + r.Name
@@ -252,96 +351,449 @@ func (e *SearchEngine) mergeResults(indexed, live []SearchResult, limit int) []S
 	return results
 }
 
-// LoadIndex loads packages from the database store into the TF-IDF index.
+// LoadIndex loads the index saved by the last build, so it is ready without
+// being rebuilt, and then checks it against the package database. An index
+// that is missing, saved in another format or older than the cached
+// packages is rebuilt from them and saved again.
 func (e *SearchEngine) LoadIndex() error {
+	path, err := database.IndexPath()
+	if err != nil {
+		return err
+	}
+
+	built, err := e.index.LoadFile(path)
+	loaded := err == nil
+	if loaded {
+		e.markReady(e.index.Size())
+	}
+
 	store, err := database.Open()
 	if err != nil {
+		if loaded {
+			// The daemon may be writing the database; the saved index will do
+			return nil
+		}
 		return err
 	}
-	defer store.Close()
 
-	e.store = store
+	modified, err := store.PackagesModified()
+	if err != nil || (loaded && modified.Equal(built)) {
+		store.Close()
+		if loaded {
+			return nil
+		}
+		return err
+	}
 
-	// Load all cached packages
 	entries, err := store.GetAllPackages()
+	store.Close()
 	if err != nil {
 		return err
 	}
-
 	if len(entries) == 0 {
 		return nil
 	}
 
-	// Convert to Package slice
 	packages := make([]manager.Package, len(entries))
 	for i, entry := range entries {
 		packages[i] = entry.Package
 	}
+	e.index.Reset(packages)
+	e.markReady(len(packages))
 
-	// Add to index
-	e.index.AddBatch(packages)
-
-	// Load mappings
-	e.mappings.AddBatch(database.CommonMappings())
+	_ = e.index.SaveFile(path, modified) //nolint:errcheck
+	return nil
+}
 
+// markReady marks the index as ready for searches with size packages.
+func (e *SearchEngine) markReady(size int) {
 	e.mu.Lock()
 	e.indexReady = true
-	e.indexSize = len(packages)
+	e.indexSize = size
 	e.mu.Unlock()
+}
 
-	return nil
+// stageBatchSize is how many packages an index build stores at a time.
+// Progress is reported after each batch.
+const stageBatchSize = 2000
+
+// stagedBuildMaxAge is how long an interrupted index build is resumed;
+// an older one is started over, since its packages are out of date.
+const stagedBuildMaxAge = 24 * time.Hour
+
+// IndexBuildOptions configures BuildIndexWith.
+type IndexBuildOptions struct {
+	Full     bool                // Download every catalog again, however recent
+	Restart  bool                // Start over instead of resuming an interrupted build
+	Progress func(IndexProgress) // Called as each source is listed and stored
 }
 
-// BuildIndex fetches packages from all managers and builds the index.
+// IndexProgress reports how far an index build got with a source.
+type IndexProgress struct {
+	Source   string
+	Packages int  // Packages stored so far
+	Done     bool // All packages of the source are stored
+	Resumed  bool // The source was stored by an interrupted build
+}
+
+// BuildIndex refreshes the index from all available managers. Installed
+// packages are listed on every build, and packages that ship applications
+// get their AppStream name and categories. A source's catalog of available
+// packages is downloaded again once it is older than [daemon]
+// catalog_interval, and otherwise, or if the download fails, kept from the
+// last build.
 func (e *SearchEngine) BuildIndex(ctx context.Context) error {
+	return e.BuildIndexWith(ctx, IndexBuildOptions{})
+}
+
+// BuildIndexWith refreshes the index like BuildIndex. The packages of each
+// source are staged in the package database in batches as they are listed,
+// and only replace the cached packages, and the loaded and saved index,
+// once every source is done, so searches use the old index until then. A
+// build that is cancelled or interrupted is resumed by the next one, which
+// skips the sources already staged.
+func (e *SearchEngine) BuildIndexWith(ctx context.Context, opts IndexBuildOptions) error {
 	store, err := database.Open()
 	if err != nil {
 		return err
 	}
 	defer store.Close()
 
-	var allPackages []manager.Package
+	maxAge := cfg.Daemon.CatalogInterval
+	if opts.Full {
+		maxAge = 0
+	}
+	report := opts.Progress
+	if report == nil {
+		report = func(IndexProgress) {}
+	}
 
-	// Fetch from all available managers
-	for _, mgr := range e.registry.Available() {
-		// Get installed packages
-		installed, err := mgr.ListInstalled(ctx, manager.ListOpts{})
-		if err != nil {
+	build, err := stagedBuild(store, opts)
+	if err != nil {
+		return err
+	}
+
+	// Application names and categories, for the packages that ship apps
+	apps, err := appstream.LoadSystem()
+	if err != nil && verbose {
+		ui.WarningMsg("Some AppStream catalogs were not read: %v", err)
+	}
+
+	mgrs := e.registry.Available()
+	for _, mgr := range mgrs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if staged, ok := build.Done[mgr.Name()]; ok {
+			report(IndexProgress{Source: mgr.Name(), Packages: staged.Packages, Done: true, Resumed: true})
 			continue
 		}
-
-		for i := range installed {
-			installed[i].Installed = true
+		if err := stageSource(ctx, store, mgr, maxAge, apps, report); err != nil {
+			return err
 		}
-		allPackages = append(allPackages, installed...)
+	}
 
-		// For some managers, we could also search for popular packages
-		// This is optional and could be expensive
+	if err := store.CommitStaged(); err != nil {
+		return err
 	}
 
+	var allPackages []manager.Package
+	catalogs := make(map[string]time.Time)
+
+	for _, mgr := range mgrs {
+		err := forEachCached(store, mgr.Name(), func(entry database.PackageEntry) error {
+			allPackages = append(allPackages, entry.Package)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if updated, err := store.GetLastUpdate(mgr.Name()); err == nil && !updated.IsZero() {
+			catalogs[mgr.Name()] = updated
+		}
+	}
+
+	e.mu.Lock()
+	e.catalogs = catalogs
+	e.mu.Unlock()
+
 	if len(allPackages) == 0 {
 		return nil
 	}
 
-	// Store in database
-	if err := store.AddPackages(allPackages); err != nil {
+	e.index.Reset(allPackages)
+	e.markReady(len(allPackages))
+
+	// Save the index for the next LoadIndex
+	modified, err := store.PackagesModified()
+	if err != nil {
 		return err
 	}
+	path, err := database.IndexPath()
+	if err != nil {
+		return err
+	}
+	return e.index.SaveFile(path, modified)
+}
 
-	// Add to index
-	e.index.AddBatch(allPackages)
+// stagedBuild returns the interrupted index build to resume, or starts a
+// new one. A build is not resumed if it is too old, or if opts asks for
+// every catalog to be downloaded again and it reused some.
+func stagedBuild(store *database.Store, opts IndexBuildOptions) (*database.StagedBuild, error) {
+	build, err := store.StagedBuild()
+	if err != nil {
+		return nil, err
+	}
+	if build != nil && !opts.Restart && time.Since(build.Started) < stagedBuildMaxAge && (build.Full || !opts.Full) {
+		return build, nil
+	}
 
-	// Load mappings
-	e.mappings.AddBatch(database.CommonMappings())
+	if err := store.BeginStaging(opts.Full); err != nil {
+		return nil, err
+	}
+	return &database.StagedBuild{Started: time.Now(), Full: opts.Full, Done: map[string]database.StagedSource{}}, nil
+}
 
-	e.mu.Lock()
-	e.indexReady = true
-	e.indexSize = len(allPackages)
-	e.mu.Unlock()
+// stageSource stages the packages of mgr to index: its catalog with
+// relations if it has one, downloaded again if older than maxAge, and its
+// installed packages the catalog does not know, such as foreign packages
+// in pacman's database. A catalog kept from the last build is paged
+// through rather than loaded whole, and packages are staged in batches as
+// they are read. If nothing can be listed the cached packages are staged
+// again, so they are kept.
+func stageSource(ctx context.Context, store *database.Store, mgr manager.Manager, maxAge time.Duration, apps *appstream.Catalog, report func(IndexProgress)) error {
+	name := mgr.Name()
+	report(IndexProgress{Source: name})
+
+	// Anything left of an interrupted attempt is listed again
+	if err := store.ClearStaged(name); err != nil {
+		return err
+	}
 
+	installed, installedErr := mgr.ListInstalled(ctx, manager.ListOpts{})
+
+	var (
+		listed     []manager.Package
+		downloaded time.Time
+	)
+	lister, hasCatalog := mgr.(manager.CatalogLister)
+	if hasCatalog {
+		updated, _ := store.GetLastUpdate(name) //nolint:errcheck
+		if time.Since(updated) >= maxAge {
+			if pkgs, err := lister.ListCatalog(ctx); err == nil && len(pkgs) > 0 {
+				listed = pkgs
+				downloaded = time.Now()
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		// The listing was cut short; do not mistake it for the packages
+		return err
+	}
+
+	stager := &sourceStager{ctx: ctx, store: store, source: name, report: report}
+
+	// Without the installed packages, only a new catalog is worth keeping
+	if installedErr != nil && listed == nil {
+		if err := forEachCached(store, name, stager.add); err != nil {
+			return err
+		}
+		return stager.finish(time.Time{})
+	}
+
+	isInstalled := make(map[string]bool, len(installed))
+	for _, pkg := range installed {
+		isInstalled[pkg.Name] = true
+	}
+
+	known := make(map[string]bool)
+	stageCatalog := func(pkg manager.Package) error {
+		if installedErr == nil {
+			pkg.Installed = isInstalled[pkg.Name]
+		}
+		known[pkg.Name] = true
+		apps.Enrich(&pkg)
+		return stager.add(database.PackageEntry{Package: pkg, Catalog: true})
+	}
+
+	switch {
+	case listed != nil:
+		for _, pkg := range listed {
+			if err := stageCatalog(pkg); err != nil {
+				return err
+			}
+		}
+	case hasCatalog:
+		err := forEachCached(store, name, func(entry database.PackageEntry) error {
+			if !entry.Catalog {
+				return nil
+			}
+			return stageCatalog(entry.Package)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pkg := range installed {
+		if known[pkg.Name] {
+			continue
+		}
+		pkg.Installed = true
+		apps.Enrich(&pkg)
+		if err := stager.add(database.PackageEntry{Package: pkg}); err != nil {
+			return err
+		}
+	}
+	return stager.finish(downloaded)
+}
+
+// sourceStager stages the packages of a source in batches of
+// stageBatchSize, reporting progress after each.
+type sourceStager struct {
+	ctx    context.Context
+	store  *database.Store
+	source string
+	report func(IndexProgress)
+	batch  []database.PackageEntry
+	staged int
+}
+
+// add stages entry with the next batch.
+func (s *sourceStager) add(entry database.PackageEntry) error {
+	s.batch = append(s.batch, entry)
+	if len(s.batch) < stageBatchSize {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush stages the batch so far.
+func (s *sourceStager) flush() error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if len(s.batch) == 0 {
+		return nil
+	}
+	if err := s.store.StagePackages(s.source, s.batch); err != nil {
+		return err
+	}
+	s.staged += len(s.batch)
+	s.batch = s.batch[:0]
+	s.report(IndexProgress{Source: s.source, Packages: s.staged})
 	return nil
 }
 
+// finish stages the last batch and records the source as staged
+// completely, with the time its catalog was downloaded, if it was.
+func (s *sourceStager) finish(catalog time.Time) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if err := s.store.FinishStaged(s.source, database.StagedSource{Packages: s.staged, Catalog: catalog}); err != nil {
+		return err
+	}
+	s.report(IndexProgress{Source: s.source, Packages: s.staged, Done: true})
+	return nil
+}
+
+// forEachCached calls fn with each cached package of source, reading them
+// a page at a time.
+func forEachCached(store *database.Store, source string, fn func(database.PackageEntry) error) error {
+	after := ""
+	for {
+		page, err := store.PackagesPage(source, after, stageBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range page {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(page) < stageBatchSize {
+			return nil
+		}
+		after = page[len(page)-1].Name
+	}
+}
+
+// UpdatePackages applies an operation's changes to the packages of source
+// called names, given installed, the packages of source now installed. The
+// package database and the loaded index are updated, and the index saved
+// again, so searches show the change before the next BuildIndex.
+func (e *SearchEngine) UpdatePackages(source string, names []string, installed []manager.Package) error {
+	store, err := database.Open()
+	if err != nil {
+		return err
+	}
+	changed, deleted, err := store.UpdateInstalled(source, names, installed)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	modified, err := store.PackagesModified()
+	store.Close()
+	if err != nil {
+		return err
+	}
+
+	// An index that is not loaded is rebuilt from the database on the next
+	// LoadIndex, since it is now older than the cached packages
+	if !e.IsReady() || len(changed)+len(deleted) == 0 {
+		return nil
+	}
+
+	packages := make([]manager.Package, len(changed))
+	for i, entry := range changed {
+		packages[i] = entry.Package
+	}
+	e.index.AddBatch(packages)
+	for _, name := range deleted {
+		e.index.Remove(source, name)
+	}
+	e.markReady(e.index.Size())
+
+	path, err := database.IndexPath()
+	if err != nil {
+		return err
+	}
+	return e.index.SaveFile(path, modified)
+}
+
+// updateIndex updates the search index after an operation changed packages
+// of mgr. With no packages every installed package of mgr is checked, as
+// after a full upgrade.
+func updateIndex(ctx context.Context, mgr manager.Manager, packages []string) {
+	if searchEngine == nil || cfg.General.DryRun {
+		return
+	}
+	installed, err := mgr.ListInstalled(ctx, manager.ListOpts{})
+	if err != nil {
+		return
+	}
+	if len(packages) == 0 {
+		for _, pkg := range installed {
+			packages = append(packages, pkg.Name)
+		}
+	}
+
+	// Let the index load first, so the load does not replace the change
+	indexBuilder.WaitForLoad(5 * time.Second)
+	if err := searchEngine.UpdatePackages(mgr.Name(), packages, installed); err != nil && verbose {
+		ui.WarningMsg("Failed to update the search index: %v", err)
+	}
+}
+
+// CatalogTimes returns when the catalog of each source with one was last
+// downloaded, as of the last BuildIndex.
+func (e *SearchEngine) CatalogTimes() map[string]time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.catalogs
+}
+
 // GetMappings returns the package mapping store.
 func (e *SearchEngine) GetMappings() *database.MappingStore {
 	return e.mappings
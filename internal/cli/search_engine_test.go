@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/pkg/database"
+	"poxy/pkg/manager"
+)
+
+// catalogManager is a manager with a catalog of packages.
+type catalogManager struct {
+	catalog    []manager.Package
+	installed  []manager.Package
+	catalogErr error
+	listErr    error
+	sudo       bool
+	// changes records the installs and removals asked for
+	changes []string
+}
+
+func (m *catalogManager) Name() string                 { return "apt" }
+func (m *catalogManager) DisplayName() string          { return "APT" }
+func (m *catalogManager) Type() manager.ManagerType    { return manager.TypeNative }
+func (m *catalogManager) IsAvailable() bool            { return true }
+func (m *catalogManager) NeedsSudo() bool              { return m.sudo }
+func (m *catalogManager) Update(context.Context) error { return nil }
+func (m *catalogManager) Install(_ context.Context, pkgs []string, _ manager.InstallOpts) error {
+	m.changes = append(m.changes, "install "+strings.Join(pkgs, " "))
+	for _, name := range pkgs {
+		m.installed = append(m.installed, manager.Package{Name: name, Version: "1.0", Source: "apt", Installed: true})
+	}
+	return nil
+}
+func (m *catalogManager) Uninstall(_ context.Context, pkgs []string, _ manager.UninstallOpts) error {
+	m.changes = append(m.changes, "remove "+strings.Join(pkgs, " "))
+	m.installed = slices.DeleteFunc(m.installed, func(pkg manager.Package) bool {
+		return slices.Contains(pkgs, pkg.Name)
+	})
+	return nil
+}
+func (m *catalogManager) Upgrade(context.Context, manager.UpgradeOpts) error { return nil }
+func (m *catalogManager) Search(context.Context, string, manager.SearchOpts) ([]manager.Package, error) {
+	return nil, nil
+}
+func (m *catalogManager) Info(context.Context, string) (*manager.PackageInfo, error) {
+	return nil, nil
+}
+func (m *catalogManager) ListInstalled(context.Context, manager.ListOpts) ([]manager.Package, error) {
+	return m.installed, m.listErr
+}
+func (m *catalogManager) IsInstalled(context.Context, string) (bool, error) { return false, nil }
+func (m *catalogManager) Clean(context.Context, manager.CleanOpts) error    { return nil }
+func (m *catalogManager) Autoremove(context.Context) error                  { return nil }
+func (m *catalogManager) ListCatalog(context.Context) ([]manager.Package, error) {
+	return m.catalog, m.catalogErr
+}
+
+// testPackages returns count packages of apt at version.
+func testPackages(count int, version string) []manager.Package {
+	pkgs := make([]manager.Package, count)
+	for i := range pkgs {
+		pkgs[i] = manager.Package{Name: fmt.Sprintf("pkg%05d", i), Version: version, Source: "apt"}
+	}
+	return pkgs
+}
+
+// openStagingStore opens a package database in a temporary data directory
+// with an index build begun.
+func openStagingStore(t *testing.T) *database.Store {
+	t.Helper()
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := os.MkdirAll(config.DataDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := database.Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.BeginStaging(false); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// stagedPackages commits the build and returns the cached packages of apt
+// by name.
+func stagedPackages(t *testing.T, store *database.Store) map[string]database.PackageEntry {
+	t.Helper()
+
+	if err := store.CommitStaged(); err != nil {
+		t.Fatalf("CommitStaged() error = %v", err)
+	}
+	entries, err := store.GetPackagesBySource("apt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := make(map[string]database.PackageEntry, len(entries))
+	for _, entry := range entries {
+		packages[entry.Name] = entry
+	}
+	return packages
+}
+
+func TestStageSourceInBatches(t *testing.T) {
+	store := openStagingStore(t)
+	mgr := &catalogManager{
+		catalog:   testPackages(2*stageBatchSize+500, "1.0"),
+		installed: []manager.Package{{Name: "pkg00001", Version: "1.0"}, {Name: "local-tool", Version: "0.1"}},
+	}
+
+	var progress []int
+	report := func(p IndexProgress) {
+		if p.Packages > 0 && !p.Done {
+			progress = append(progress, p.Packages)
+		}
+	}
+	if err := stageSource(context.Background(), store, mgr, 0, nil, report); err != nil {
+		t.Fatalf("stageSource() error = %v", err)
+	}
+
+	total := 2*stageBatchSize + 501
+	if want := []int{stageBatchSize, 2 * stageBatchSize, total}; !slices.Equal(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	packages := stagedPackages(t, store)
+	if len(packages) != total {
+		t.Fatalf("staged %d packages, want %d", len(packages), total)
+	}
+	if entry := packages["pkg00001"]; !entry.Installed || !entry.Catalog {
+		t.Errorf("pkg00001 = %+v, want an installed catalog package", entry)
+	}
+	if entry := packages["local-tool"]; !entry.Installed || entry.Catalog {
+		t.Errorf("local-tool = %+v, want an installed package outside the catalog", entry)
+	}
+	if updated, err := store.GetLastUpdate("apt"); err != nil || updated.IsZero() {
+		t.Errorf("GetLastUpdate() = %v, %v, want the download time", updated, err)
+	}
+}
+
+func TestStageSourceKeepsCachedCatalog(t *testing.T) {
+	store := openStagingStore(t)
+
+	// A catalog downloaded an hour ago, larger than one page
+	mgr := &catalogManager{
+		catalog:   testPackages(stageBatchSize+10, "1.0"),
+		installed: []manager.Package{{Name: "pkg00003", Version: "1.0"}},
+	}
+	if err := stageSource(context.Background(), store, mgr, 0, nil, func(IndexProgress) {}); err != nil {
+		t.Fatal(err)
+	}
+	stagedPackages(t, store)
+	if err := store.SetLastUpdate("apt", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.BeginStaging(false); err != nil {
+		t.Fatal(err)
+	}
+	mgr.catalog = testPackages(3, "2.0")
+	mgr.installed = []manager.Package{{Name: "pkg00002", Version: "1.0"}}
+	if err := stageSource(context.Background(), store, mgr, 24*time.Hour, nil, func(IndexProgress) {}); err != nil {
+		t.Fatalf("stageSource() error = %v", err)
+	}
+
+	packages := stagedPackages(t, store)
+	if len(packages) != stageBatchSize+10 {
+		t.Fatalf("staged %d packages, want the %d cached", len(packages), stageBatchSize+10)
+	}
+	if entry := packages["pkg00002"]; entry.Version != "1.0" || !entry.Installed {
+		t.Errorf("pkg00002 = %+v, want the cached version, installed", entry)
+	}
+	if entry := packages["pkg00003"]; entry.Installed {
+		t.Errorf("pkg00003 = %+v, want it no longer installed", entry)
+	}
+}
+
+func TestStageSourceKeepsCachedWhenListingFails(t *testing.T) {
+	store := openStagingStore(t)
+
+	mgr := &catalogManager{catalog: testPackages(5, "1.0")}
+	if err := stageSource(context.Background(), store, mgr, 0, nil, func(IndexProgress) {}); err != nil {
+		t.Fatal(err)
+	}
+	stagedPackages(t, store)
+
+	if err := store.BeginStaging(false); err != nil {
+		t.Fatal(err)
+	}
+	mgr.catalogErr = errors.New("network unreachable")
+	mgr.listErr = errors.New("dpkg database locked")
+	if err := stageSource(context.Background(), store, mgr, 0, nil, func(IndexProgress) {}); err != nil {
+		t.Fatalf("stageSource() error = %v", err)
+	}
+	if packages := stagedPackages(t, store); len(packages) != 5 {
+		t.Errorf("staged %d packages, want the 5 cached", len(packages))
+	}
+}
+
+func TestStageSourceCancelled(t *testing.T) {
+	store := openStagingStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mgr := &catalogManager{catalog: testPackages(10, "1.0")}
+	if err := stageSource(ctx, store, mgr, 0, nil, func(IndexProgress) {}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("stageSource() error = %v, want context.Canceled", err)
+	}
+
+	build, err := store.StagedBuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := build.Done["apt"]; ok {
+		t.Error("a cancelled source was recorded as staged")
+	}
+}
+
+func TestLoadIndexRevalidates(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := os.MkdirAll(config.DataDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cache := func(pkgs ...manager.Package) {
+		t.Helper()
+		store, err := database.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		if err := store.AddPackages(pkgs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loadedSize := func() int {
+		t.Helper()
+		engine := NewSearchEngine(manager.NewRegistry(nil))
+		if err := engine.LoadIndex(); err != nil {
+			t.Fatalf("LoadIndex() error = %v", err)
+		}
+		return engine.IndexSize()
+	}
+
+	cache(testPackages(3, "1.0")...)
+	if size := loadedSize(); size != 3 {
+		t.Fatalf("LoadIndex() without a saved index loaded %d packages, want 3", size)
+	}
+	path, err := database.IndexPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("LoadIndex() did not save the index: %v", err)
+	}
+
+	// A saved index as new as the cached packages is used as it is
+	store, err := database.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified, err := store.PackagesModified()
+	store.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := database.NewIndex()
+	saved.Reset(testPackages(1, "1.0"))
+	if err := saved.SaveFile(path, modified); err != nil {
+		t.Fatal(err)
+	}
+	if size := loadedSize(); size != 1 {
+		t.Errorf("LoadIndex() of a current index loaded %d packages, want the 1 saved", size)
+	}
+
+	// Once the packages change it is rebuilt from them
+	time.Sleep(time.Millisecond)
+	cache(manager.Package{Name: "local-tool", Version: "0.1", Source: "apt"})
+	if size := loadedSize(); size != 4 {
+		t.Errorf("LoadIndex() of a stale index loaded %d packages, want 4 rebuilt", size)
+	}
+}
+
+func TestUpdatePackages(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := os.MkdirAll(config.DataDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := database.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.ReplaceSource("apt", []database.PackageEntry{
+		{Package: manager.Package{Name: "vim", Version: "1.0", Source: "apt", Description: "Vi improved", Installed: true}, Catalog: true},
+		{Package: manager.Package{Name: "local-tool", Version: "0.1", Source: "apt", Description: "A local tool", Installed: true}},
+	})
+	store.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewSearchEngine(manager.NewRegistry(nil))
+	if err := engine.LoadIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	installed := []manager.Package{
+		{Name: "vim", Version: "1.0", Source: "apt"},
+		{Name: "htop", Version: "3.0", Source: "apt", Description: "Interactive process viewer"},
+	}
+	if err := engine.UpdatePackages("apt", []string{"htop", "local-tool"}, installed); err != nil {
+		t.Fatalf("UpdatePackages() error = %v", err)
+	}
+
+	search := func(engine *SearchEngine, query string) []string {
+		var names []string
+		for _, result := range engine.GetIndex().Search(query, database.DefaultSearchOptions()) {
+			names = append(names, result.Name)
+		}
+		return names
+	}
+	if got := search(engine, "process viewer"); !slices.Contains(got, "htop") {
+		t.Errorf("search for the installed htop = %v", got)
+	}
+	if got := search(engine, "local tool"); slices.Contains(got, "local-tool") {
+		t.Errorf("search for the removed local-tool = %v", got)
+	}
+	if size := engine.IndexSize(); size != 2 {
+		t.Errorf("IndexSize() = %d, want 2", size)
+	}
+
+	// The saved index is current, so it is loaded as it is
+	reloaded := NewSearchEngine(manager.NewRegistry(nil))
+	if err := reloaded.LoadIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if got := search(reloaded, "process viewer"); !slices.Contains(got, "htop") {
+		t.Errorf("search of the reloaded index for htop = %v", got)
+	}
+}
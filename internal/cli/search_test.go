@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
+)
+
+// stalledManager is an apt whose searches wait for their context to end,
+// or fail with err if it is set, and counts them.
+type stalledManager struct {
+	*catalogManager
+	err      error
+	searches int
+}
+
+func (m *stalledManager) Search(ctx context.Context, _ string, _ manager.SearchOpts) ([]manager.Package, error) {
+	m.searches++
+	if m.err != nil {
+		return nil, m.err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSearchSmartFallback(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	savedCfg, savedRegistry, savedEngine := cfg, registry, searchEngine
+	t.Cleanup(func() { cfg, registry, searchEngine = savedCfg, savedRegistry, savedEngine })
+	cfg = config.Default()
+	cfg.Timeouts.Probe = 10 * time.Millisecond
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"timed out sources are not searched again", nil, 1},
+		{"failed sources are searched natively", errors.New("mirror unreachable"), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &stalledManager{catalogManager: &catalogManager{}, err: tt.err}
+			registry = manager.NewRegistry(cfg)
+			registry.Register(mgr)
+			searchEngine = NewSearchEngine(registry)
+
+			_ = searchSmart(context.Background(), "vim") //nolint:errcheck
+			if mgr.searches != tt.want {
+				t.Errorf("searched apt %d times, want %d", mgr.searches, tt.want)
+			}
+		})
+	}
+}
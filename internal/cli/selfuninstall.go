@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"poxy/internal/config"
+	"poxy/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var selfUninstallPurge bool
+
+var selfUninstallCmd = &cobra.Command{
+	Use:     "self-uninstall",
+	Aliases: []string{"uninstall-self"},
+	Short:   "Remove poxy and optionally its data",
+	Long: `Remove the poxy binary and, optionally, its data, cache and
+configuration directories.
+
+The directories are listed with their size and contents before anything
+is removed. They hold the history and snapshot databases, the package
+index, AUR build caches and the configuration file.
+
+Examples:
+  poxy self-uninstall              # Remove the binary, ask about data
+  poxy self-uninstall --purge      # Also remove data, cache and config
+  poxy self-uninstall --dry-run    # Show what would be removed`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUninstall,
+}
+
+func init() {
+	selfUninstallCmd.Flags().BoolVar(&selfUninstallPurge, "purge", false, "also remove data, cache and configuration directories")
+}
+
+// poxyDir is a directory poxy keeps state in.
+type poxyDir struct {
+	Label   string
+	Path    string
+	Size    int64
+	Files   int
+	Entries []string // Top-level entries
+}
+
+func runSelfUninstall(cmd *cobra.Command, args []string) error {
+	ui.HeaderMsg("Poxy Self-Uninstall")
+
+	updater := &SelfUpdater{}
+	if err := updater.detectCurrentBinary(); err != nil {
+		return fmt.Errorf("failed to detect current binary: %w", err)
+	}
+	binary := updater.currentBinary
+	needsSudo := needsSudoForPath(binary)
+
+	ui.Println("")
+	ui.InfoMsg("Binary:")
+	if needsSudo {
+		ui.MutedMsg("  %s (requires sudo)", binary)
+	} else {
+		ui.MutedMsg("  %s", binary)
+	}
+
+	dirs := collectPoxyDirs()
+	if len(dirs) > 0 {
+		ui.Println("")
+		ui.InfoMsg("Data, cache and configuration:")
+		for _, dir := range dirs {
+			ui.MutedMsg("  %-7s %s (%s in %d files)", dir.Label, dir.Path, formatBytes(dir.Size), dir.Files)
+			if len(dir.Entries) > 0 {
+				ui.MutedMsg("  %-7s %s", "", summarizeEntries(dir.Entries, 5))
+			}
+		}
+	}
+
+	if cfg.General.DryRun {
+		ui.Println("")
+		ui.InfoMsg("Dry run: would remove %s", binary)
+		for _, dir := range dirs {
+			if selfUninstallPurge {
+				ui.InfoMsg("Dry run: would remove %s", dir.Path)
+			}
+		}
+		if !selfUninstallPurge && len(dirs) > 0 {
+			ui.MutedMsg("Use --purge to also remove data, cache and configuration")
+		}
+		return nil
+	}
+
+	ui.Println("")
+	if !cfg.General.AutoConfirm {
+		confirmed, err := ui.Confirm("Uninstall poxy?", false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	removeData := selfUninstallPurge
+	if !removeData && len(dirs) > 0 && !cfg.General.AutoConfirm {
+		confirmed, err := ui.Confirm("Also remove data, cache and configuration?", false)
+		if err != nil {
+			return err
+		}
+		removeData = confirmed
+	}
+
+	if removeData {
+		for _, dir := range dirs {
+			if err := os.RemoveAll(dir.Path); err != nil {
+				ui.WarningMsg("Failed to remove %s: %v", dir.Path, err)
+				continue
+			}
+			ui.SuccessMsg("Removed %s", dir.Path)
+		}
+	}
+
+	if err := removeBinary(binary, needsSudo); err != nil {
+		return fmt.Errorf("failed to remove binary: %w", err)
+	}
+	ui.SuccessMsg("Removed %s", binary)
+
+	ui.Println("")
+	ui.SuccessMsg("poxy has been uninstalled")
+	if !removeData && len(dirs) > 0 {
+		ui.MutedMsg("Data, cache and configuration were kept")
+	}
+
+	return nil
+}
+
+// collectPoxyDirs returns the existing poxy directories with their usage.
+func collectPoxyDirs() []poxyDir {
+	candidates := []poxyDir{
+		{Label: "config", Path: config.ConfigDir()},
+		{Label: "data", Path: config.DataDir()},
+		{Label: "cache", Path: config.CacheDir()},
+	}
+
+	seen := make(map[string]bool)
+	var dirs []poxyDir
+	for _, dir := range candidates {
+		if seen[dir.Path] {
+			continue
+		}
+		seen[dir.Path] = true
+
+		if _, err := os.Stat(dir.Path); err != nil {
+			continue
+		}
+		dir.Size, dir.Files, dir.Entries = dirUsage(dir.Path)
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+// dirUsage returns the total size, file count and top-level entries of a path.
+func dirUsage(root string) (size int64, files int, entries []string) {
+	//nolint:errcheck // Unreadable entries are skipped
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != root && filepath.Dir(path) == root {
+			entries = append(entries, d.Name())
+		}
+		if d.Type().IsRegular() {
+			if info, infoErr := d.Info(); infoErr == nil {
+				size += info.Size()
+				files++
+			}
+		}
+		return nil
+	})
+
+	sort.Strings(entries)
+	return size, files, entries
+}
+
+// summarizeEntries joins up to max entry names, noting how many were left out.
+func summarizeEntries(entries []string, max int) string {
+	if len(entries) <= max {
+		return strings.Join(entries, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(entries[:max], ", "), len(entries)-max)
+}
+
+// formatBytes formats a byte count using binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// removeBinary deletes the poxy binary and any backup left by self-update.
+func removeBinary(path string, needsSudo bool) error {
+	backup := path + ".bak"
+
+	if needsSudo {
+		cmd := exec.Command("sudo", "rm", "-f", path, backup)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("sudo rm failed: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	os.Remove(backup) // Ignore error - backup usually does not exist
+	return nil
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"poxy/internal/executor"
+	"poxy/internal/history"
+	"poxy/internal/services"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+)
+
+// offerServices finds the systemd units the installed packages ship that
+// are not enabled and asks whether to enable and start them. It returns the
+// units it enabled. Nothing is enabled without an explicit answer, not even
+// with --yes.
+func offerServices(ctx context.Context, mgr manager.Manager, packages []string) []string {
+	if cfg.General.DryRun || !cfg.General.SuggestServices || !services.Available() {
+		return nil
+	}
+
+	checker, ok := mgr.(manager.FileOwnershipChecker)
+	if !ok && mgr.Type() == manager.TypeAUR {
+		// AUR packages are installed with pacman, which lists their files
+		checker, ok = registry.Native().(manager.FileOwnershipChecker)
+	}
+	if !ok {
+		return nil
+	}
+
+	var files []string
+	for _, pkg := range packages {
+		pkgFiles, err := checker.ListFiles(ctx, pkg)
+		if err != nil {
+			continue
+		}
+		files = append(files, pkgFiles...)
+	}
+
+	disabled := services.Disabled(ctx, services.Units(files))
+	if len(disabled) == 0 {
+		return nil
+	}
+
+	ui.InfoMsg("The installed packages ship services that are not enabled:")
+	for _, unit := range disabled {
+		ui.MutedMsg("  - %s", unit)
+	}
+
+	if cfg.General.AutoConfirm {
+		ui.MutedMsg("Enable them with: sudo %s", strings.Join(services.EnableArgs(disabled), " "))
+		return nil
+	}
+
+	selected := disabled
+	if len(disabled) == 1 {
+		confirmed, err := ui.Confirm("Enable and start "+disabled[0]+"?", false)
+		if err != nil || !confirmed {
+			return nil
+		}
+	} else {
+		var err error
+		selected, err = ui.SelectMultiple(disabled, "Enable and start which services? (leave empty for none)")
+		if err != nil || len(selected) == 0 {
+			return nil
+		}
+	}
+
+	args := services.EnableArgs(selected)
+	runner := executor.New(false, cfg.Output.Verbose)
+	if err := runner.RunSudo(ctx, args[0], args[1:]...); err != nil {
+		ui.WarningMsg("Failed to enable %s: %v", strings.Join(selected, ", "), err)
+		return nil
+	}
+
+	ui.SuccessMsg("Enabled and started %s", strings.Join(selected, ", "))
+	return selected
+}
+
+// servicesToDisable returns the units poxy enabled since from that are
+// still enabled, for undo to disable.
+func servicesToDisable(ctx context.Context, from time.Time) []string {
+	if !services.Available() {
+		return nil
+	}
+
+	store, err := history.Open()
+	if err != nil {
+		return nil
+	}
+	defer store.Close()
+
+	units, err := store.ServicesSince(from)
+	if err != nil {
+		return nil
+	}
+	return services.Enabled(ctx, units)
+}
+
+// disableServices stops and disables units poxy enabled after an install.
+func disableServices(ctx context.Context, units []string) error {
+	if len(units) == 0 {
+		return nil
+	}
+
+	args := services.DisableArgs(units)
+	runner := executor.New(cfg.General.DryRun, cfg.Output.Verbose)
+	if err := runner.RunSudo(ctx, args[0], args[1:]...); err != nil {
+		return fmt.Errorf("failed to disable %s: %w", strings.Join(units, ", "), err)
+	}
+	return nil
+}
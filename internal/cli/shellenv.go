@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"poxy/internal/shellenv"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+)
+
+// offerShellSetup checks whether the commands mgr just installed are on
+// the PATH and, if not, offers to add the lines that put them there to the
+// user's shell startup file, showing them first and backing the file up.
+// Nothing is changed without an explicit answer, not even with --yes.
+func offerShellSetup(ctx context.Context, mgr manager.Manager) {
+	if cfg.General.DryRun || !cfg.General.SuggestShellSetup || rootDir != "" {
+		return
+	}
+	provider, ok := mgr.(manager.BinDirProvider)
+	if !ok {
+		return
+	}
+	dir := provider.BinDir(ctx)
+	if dir == "" || shellenv.OnPath(dir, os.Getenv("PATH")) {
+		return
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return // Nothing was installed there
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+
+	shell := shellenv.Detect(os.Getenv("SHELL"))
+	rc := shellenv.RCFile(shell, home)
+	content, _ := os.ReadFile(rc) //nolint:errcheck
+	lines := shellenv.Missing(string(content), shellenv.Lines(shell, mgr.Name(), dir, home))
+	if len(lines) == 0 {
+		ui.InfoMsg("%s is set up in %s but not in this shell; open a new one, or run: source %s", dir, rc, rc)
+		return
+	}
+
+	ui.InfoMsg("%s installs commands into %s, which is not on your PATH", mgr.DisplayName(), dir)
+	ui.MutedMsg("These lines would be added to %s:", rc)
+	for _, line := range lines {
+		ui.Println("  %s", ui.Cyan(line))
+	}
+
+	if cfg.General.AutoConfirm {
+		ui.MutedMsg("Add them yourself to run the commands by name")
+		return
+	}
+	confirmed, err := ui.Confirm(fmt.Sprintf("Add them to %s?", rc), false)
+	if err != nil || !confirmed {
+		return
+	}
+
+	backup, err := shellenv.Append(rc, "commands installed with "+mgr.DisplayName(), lines)
+	if err != nil {
+		ui.WarningMsg("%v", err)
+		return
+	}
+	if backup != "" {
+		ui.SuccessMsg("Updated %s (backup at %s)", rc, backup)
+	} else {
+		ui.SuccessMsg("Created %s", rc)
+	}
+	ui.MutedMsg("Open a new shell, or run: source %s", rc)
+}
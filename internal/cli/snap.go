@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager/universal"
+
+	"github.com/spf13/cobra"
+)
+
+var snapCmd = &cobra.Command{
+	Use:   "snap",
+	Short: "Snap-specific tools",
+	Long: `Tools for managing parts of Snap that are not covered by the
+package-centric commands.
+
+Install from a channel with 'poxy install <snap> -s snap --channel=<channel>'.
+Pinning a snap with 'poxy pin -s snap' holds its refreshes in snapd.
+
+Examples:
+  poxy snap channel code            # List the channels of a snap
+  poxy snap channel code insiders   # Switch an installed snap to another channel`,
+}
+
+var snapChannelCmd = &cobra.Command{
+	Use:   "channel <snap> [channel]",
+	Short: "List a snap's channels or switch an installed snap to another",
+	Long: `Without a channel, list the open channels of a snap with the version
+each one has, marking the channel an installed snap tracks.
+
+With a channel, make the installed snap track it and refresh the snap to
+the channel's version. A channel is a risk level such as stable, candidate,
+beta or edge, a track such as insiders, or both: insiders/edge.
+
+Examples:
+  poxy snap channel code
+  poxy snap channel code insiders
+  poxy snap channel firefox latest/beta
+  poxy snap channel firefox --json`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeSingle(completeInstalledPackages),
+	RunE:              runSnapChannel,
+}
+
+var snapChannelJSON bool
+
+func init() {
+	snapCmd.AddCommand(snapChannelCmd)
+
+	snapChannelCmd.Flags().BoolVar(&snapChannelJSON, "json", false, "print the channels as JSON")
+}
+
+// getSnap returns the registered Snap manager.
+func getSnap() (*universal.Snap, error) {
+	mgr, ok := registry.Get("snap")
+	if !ok || !mgr.IsAvailable() {
+		return nil, fmt.Errorf("snap is not available on this system")
+	}
+
+	sn, ok := mgr.(*universal.Snap)
+	if !ok {
+		return nil, fmt.Errorf("snap is not available on this system")
+	}
+	return sn, nil
+}
+
+func runSnapChannel(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	name := args[0]
+
+	sn, err := getSnap()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 2 {
+		return switchSnapChannel(ctx, sn, name, args[1])
+	}
+
+	channels, err := sn.Channels(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if snapChannelJSON {
+		if channels.Channels == nil {
+			channels.Channels = []universal.SnapChannel{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(channels)
+	}
+
+	if len(channels.Channels) == 0 {
+		ui.InfoMsg("%s has no open channels", name)
+		return nil
+	}
+
+	ui.HeaderMsg("Channels of %s", name)
+	for _, ch := range channels.Channels {
+		channel := fmt.Sprintf("%-24s", ch.Name)
+		line := fmt.Sprintf("%-20s %s", ch.Version, ui.Muted.Sprint(ch.Released))
+		if ch.Name == channels.Tracking {
+			channel = ui.Cyan(channel)
+			line += "  " + ui.Green("tracking")
+		}
+		ui.Println("  %s %s", channel, line)
+	}
+	if channels.Tracking == "" {
+		ui.MutedMsg("Install from a channel with: poxy install %s -s snap --channel=<channel>", name)
+	} else {
+		ui.MutedMsg("Switch channels with: poxy snap channel %s <channel>", name)
+	}
+	return nil
+}
+
+// switchSnapChannel makes an installed snap track channel.
+func switchSnapChannel(ctx context.Context, sn *universal.Snap, name, channel string) error {
+	installed, err := sn.IsInstalled(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return fmt.Errorf("%s is not installed; install it with: poxy install %s -s snap --channel=%s", name, name, channel)
+	}
+
+	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Switch %s to the %s channel?", name, channel), true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrAborted
+		}
+	}
+
+	if err := confirmPrivileges(sn); err != nil {
+		return err
+	}
+	if err := sn.SwitchChannel(ctx, name, channel, cfg.General.DryRun); err != nil {
+		return fmt.Errorf("failed to switch %s to %s: %w", name, channel, err)
+	}
+
+	if !cfg.General.DryRun {
+		ui.SuccessMsg("%s now tracks %s", name, channel)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
+	"poxy/internal/config"
 	"poxy/internal/ui"
 	"poxy/pkg/snapshot"
 
@@ -54,19 +57,24 @@ var (
 )
 
 func init() {
-	snapshotListCmd.Flags().IntVarP(&snapshotListLimit, "limit", "l", 20, "maximum number of snapshots to list")
+	snapshotListCmd.Flags().IntVarP(&snapshotListLimit, "limit", "l", 0, "maximum number of snapshots to list (default from [limits] snapshot_list)")
 	snapshotListCmd.Flags().StringVarP(&snapshotListTrigger, "trigger", "t", "", "filter by trigger type")
 }
 
 func runSnapshotList(cmd *cobra.Command, args []string) error {
 	store, err := snapshot.OpenStore()
+	if errors.Is(err, config.ErrDataDirReadOnly) {
+		ui.InfoMsg("No snapshots available")
+		ui.MutedMsg("%s is not writable, so snapshots cannot be created.", config.DataDir())
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open snapshot store: %w", err)
 	}
 	defer store.Close()
 
 	trigger := snapshot.Trigger(snapshotListTrigger)
-	snapshots, err := store.List(snapshotListLimit, trigger)
+	snapshots, err := store.List(limitFlag(cmd, snapshotListLimit, cfg.Limits.SnapshotList), trigger)
 	if err != nil {
 		return fmt.Errorf("failed to list snapshots: %w", err)
 	}
@@ -125,9 +133,12 @@ Manual snapshots are not automatically pruned and must be deleted manually.`,
 	RunE: runSnapshotCreate,
 }
 
-func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+func runSnapshotCreate(cmd *cobra.Command, args []string) (err error) {
 	ctx := context.Background()
 
+	rep := startReport("snapshot create")
+	defer func() { sendReport(rep, err) }()
+
 	managers := getAvailableManagers()
 	if len(managers) == 0 {
 		return ErrNoManager
@@ -146,6 +157,7 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	ui.SuccessMsg("Created snapshot %s with %d packages", snap.ID, snap.PackageCount())
+	warnFailedSources(snap)
 
 	// Show breakdown by source
 	bySource := snap.PackagesBySource()
@@ -153,6 +165,15 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 		ui.MutedMsg("  %s: %d packages", source, len(pkgs))
 	}
 
+	if rep != nil {
+		rep.Add("Snapshot", fmt.Sprintf("%s (%s) with %d packages", snap.ID, description, snap.PackageCount()))
+		var missing []string
+		for _, source := range snap.FailedSourceNames() {
+			missing = append(missing, fmt.Sprintf("%s: %s", source, snap.FailedSources[source]))
+		}
+		rep.Add("Missing sources", missing...)
+	}
+
 	return nil
 }
 
@@ -185,11 +206,59 @@ func runSnapshotShow(cmd *cobra.Command, args []string) error {
 	ui.Println("  Packages:    %d total", snap.PackageCount())
 	ui.Println("")
 
+	if len(snap.ManagerVersions) > 0 {
+		ui.InfoMsg("Package managers")
+		names := make([]string, 0, len(snap.ManagerVersions))
+		for name := range snap.ManagerVersions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ui.MutedMsg("  %s %s", name, snap.ManagerVersions[name])
+		}
+		ui.Println("")
+	}
+
+	if len(snap.FailedSources) > 0 {
+		ui.WarningMsg("Sources that failed to capture")
+		for _, name := range snap.FailedSourceNames() {
+			ui.MutedMsg("  %s: %s", name, snap.FailedSources[name])
+		}
+		ui.Println("")
+	}
+
+	if len(snap.Deployments) > 0 {
+		ui.InfoMsg("Deployments")
+		for _, d := range snap.Deployments {
+			state := ""
+			switch {
+			case d.Booted:
+				state = " (booted)"
+			case d.Pending:
+				state = " (pending, for the next boot)"
+			}
+			ui.MutedMsg("  %s %s%s", d.Source, describeDeployment(d.Version, d.Checksum), state)
+		}
+		ui.Println("")
+	}
+
+	if len(snap.Modules) > 0 {
+		ui.InfoMsg("Module streams (%d enabled)", len(snap.Modules))
+		for _, mod := range snap.Modules {
+			ui.MutedMsg("  %s:%s [%s]", mod.Name, mod.Stream, mod.Source)
+		}
+		ui.Println("")
+	}
+
 	// Show packages by source
 	bySource := snap.PackagesBySource()
 	for source, pkgs := range bySource {
 		ui.InfoMsg("%s (%d packages)", source, len(pkgs))
 		for _, pkg := range pkgs {
+			if pkg.Resolution != nil {
+				ui.MutedMsg("  %s %s  (%s)", pkg.Name, ui.Green(pkg.Version), describeResolution(*pkg.Resolution))
+				continue
+			}
 			ui.MutedMsg("  %s %s", pkg.Name, ui.Green(pkg.Version))
 		}
 		ui.Println("")
@@ -232,11 +301,50 @@ func runSnapshotDiff(cmd *cobra.Command, args []string) error {
 	ui.HeaderMsg("Diff: %s -> %s", snap1.ID, snap2.ID)
 	ui.Println("")
 
+	printManagerVersionChanges(snapshot.CompareManagerVersions(snap1, snap2))
+	printDeploymentChanges(snapshot.CompareDeployments(snap1, snap2))
+
 	if diff.IsEmpty() {
 		ui.SuccessMsg("No differences - snapshots are identical")
 		return nil
 	}
 
+	printSnapshotDiff(diff)
+
+	return nil
+}
+
+// printManagerVersionChanges lists package managers whose backend version changed.
+func printManagerVersionChanges(changes []snapshot.ManagerChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	ui.InfoMsg("Package manager versions changed:")
+	for _, c := range changes {
+		ui.MutedMsg("  %s %s -> %s", c.Manager, c.FromVersion, c.ToVersion)
+	}
+	ui.Println("")
+}
+
+// printDeploymentChanges prints the image-based systems that booted another
+// deployment.
+func printDeploymentChanges(changes []snapshot.DeploymentChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	ui.InfoMsg("Booted deployment changed:")
+	for _, c := range changes {
+		ui.MutedMsg("  %s %s -> %s", c.Manager,
+			describeDeployment(c.From.Version, c.From.Checksum),
+			describeDeployment(c.To.Version, c.To.Checksum))
+	}
+	ui.Println("")
+}
+
+// printSnapshotDiff prints the changes in a diff grouped by change type.
+func printSnapshotDiff(diff *snapshot.Diff) {
 	ui.InfoMsg(diff.Summary())
 	ui.Println("")
 
@@ -277,8 +385,6 @@ func runSnapshotDiff(cmd *cobra.Command, args []string) error {
 		}
 		ui.Println("")
 	}
-
-	return nil
 }
 
 // snapshotDeleteCmd deletes a snapshot
@@ -345,7 +451,10 @@ func init() {
 	snapshotPruneCmd.Flags().IntVar(&pruneAutoKeep, "keep-auto", 20, "number of automatic snapshots to keep")
 }
 
-func runSnapshotPrune(cmd *cobra.Command, args []string) error {
+func runSnapshotPrune(cmd *cobra.Command, args []string) (err error) {
+	rep := startReport("snapshot prune")
+	defer func() { sendReport(rep, err) }()
+
 	store, err := snapshot.OpenStore()
 	if err != nil {
 		return fmt.Errorf("failed to open snapshot store: %w", err)
@@ -368,5 +477,9 @@ func runSnapshotPrune(cmd *cobra.Command, args []string) error {
 	afterCount, _ := store.Count() //nolint:errcheck
 	ui.MutedMsg("Remaining snapshots: %d", afterCount)
 
+	if rep != nil {
+		rep.Add("Snapshots", fmt.Sprintf("pruned %d, %d remaining", deleted, afterCount))
+	}
+
 	return nil
 }
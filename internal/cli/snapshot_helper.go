@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"poxy/internal/config"
 	"poxy/internal/ui"
 	"poxy/pkg/manager"
 	"poxy/pkg/snapshot"
@@ -16,6 +17,10 @@ func capturePreOperationSnapshot(ctx context.Context, trigger snapshot.Trigger,
 	if cfg != nil && !cfg.General.Snapshots {
 		return nil
 	}
+	if !config.DataDirWritable() {
+		warnDataDirReadOnly()
+		return nil
+	}
 
 	// Get all available managers
 	managers := getAvailableManagers()
@@ -45,10 +50,12 @@ func capturePreOperationSnapshot(ctx context.Context, trigger snapshot.Trigger,
 	if verbose {
 		ui.MutedMsg("Captured snapshot %s (%d packages)", snap.ID, snap.PackageCount())
 	}
+	warnFailedSources(snap)
 
 	// Store operation metadata in the snapshot
 	snap.Operation = string(trigger)
 	snap.Targets = targets
+	operationSnapshot = snap.ID
 
 	// Update the snapshot with metadata
 	store, err := snapshot.OpenStore()
@@ -60,6 +67,14 @@ func capturePreOperationSnapshot(ctx context.Context, trigger snapshot.Trigger,
 	return snap
 }
 
+// warnFailedSources warns that a snapshot is missing sources that failed to
+// capture, since restoring it cannot account for their packages.
+func warnFailedSources(snap *snapshot.Snapshot) {
+	for _, source := range snap.FailedSourceNames() {
+		ui.WarningMsg("Snapshot %s is missing %s packages: %s", snap.ID, source, snap.FailedSources[source])
+	}
+}
+
 // getAvailableManagers returns all currently available package managers.
 func getAvailableManagers() []manager.Manager {
 	if registry == nil {
@@ -68,3 +83,32 @@ func getAvailableManagers() []manager.Manager {
 
 	return registry.Available()
 }
+
+// recordResolutions stores why poxy chose the source of newly installed
+// packages, so later snapshots can show it.
+func recordResolutions(resolutions []manager.Resolution) {
+	if len(resolutions) == 0 || cfg.General.DryRun {
+		return
+	}
+	store, err := snapshot.OpenStore()
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	if err := store.RecordResolutions(resolutions); err != nil && verbose {
+		ui.WarningMsg("Failed to record install reasons: %v", err)
+	}
+}
+
+// forgetResolutions drops the recorded resolutions of removed packages.
+func forgetResolutions(source string, packages []string) {
+	if cfg.General.DryRun {
+		return
+	}
+	store, err := snapshot.OpenStore()
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	_ = store.ForgetResolutions(source, packages) //nolint:errcheck
+}
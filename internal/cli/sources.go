@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+
+	"github.com/spf13/cobra"
+)
+
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Inspect package sources",
+	Long: `Tools for inspecting the package sources poxy searches and installs
+from.
+
+Examples:
+  poxy sources status               # Probe every available source
+  poxy sources status flatpak snap  # Probe only Flatpak and Snap`,
+}
+
+var sourcesStatusCmd = &cobra.Command{
+	Use:   "status [source...]",
+	Short: "Check that package sources are reachable",
+	Long: `Probe each available package source to tell "nothing matched"
+apart from "the source is down or misconfigured".
+
+The probes are lightweight and change nothing: APT repository servers and
+pacman mirrors must answer, snapd must be running, the default Flatpak
+remote must be configured and reachable, and the AUR RPC interface must
+respond. Sources without a probe are reported as installed.
+
+Examples:
+  poxy sources status               # Probe every available source
+  poxy sources status aur           # Probe only the AUR
+  poxy sources status -s flatpak    # Same, using the source flag`,
+	RunE:              runSourcesStatus,
+	ValidArgsFunction: completeSources,
+}
+
+func init() {
+	sourcesCmd.AddCommand(sourcesStatusCmd)
+}
+
+func runSourcesStatus(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	names := args
+	if len(names) == 0 && source != "" {
+		names = []string{source}
+	}
+
+	var managers []manager.Manager
+	if len(names) == 0 {
+		managers = registry.Available()
+	} else {
+		for _, name := range names {
+			mgr, ok := registry.Get(name)
+			if !ok || !mgr.IsAvailable() {
+				return fmt.Errorf("source %q is not available on this system", name)
+			}
+			managers = append(managers, mgr)
+		}
+	}
+
+	ui.HeaderMsg("Source Status")
+
+	problems := 0
+	for _, result := range manager.ProbeSources(ctx, managers, manager.DefaultProbeTimeout) {
+		switch result.Status {
+		case manager.HealthOK:
+			ui.SuccessMsg("%-10s %s", result.Source, result.Summary)
+		case manager.HealthDegraded:
+			ui.WarningMsg("%-10s %s", result.Source, result.Summary)
+			problems++
+		default:
+			ui.ErrorMsg("%-10s %s", result.Source, result.Summary)
+			problems++
+		}
+		if result.Fix != "" {
+			ui.MutedMsg("  Fix: %s", result.Fix)
+		}
+	}
+
+	if problems > 0 {
+		ui.Println("")
+		ui.WarningMsg("%d source(s) have problems; searches may miss their packages", problems)
+	}
+	return nil
+}
@@ -1,23 +1,33 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"poxy/internal/ui"
+	"poxy/pkg/manager"
+	"poxy/pkg/manager/detector"
 
 	"github.com/spf13/cobra"
 )
 
 var systemCmd = &cobra.Command{
-	Use:   "system",
-	Short: "Show system information",
-	Long: `Display information about the detected system and available
-package managers.
+	Use:     "system",
+	Aliases: []string{"status"},
+	Short:   "Show system information",
+	Long: `Display information about the detected system, the available
+package managers and the version of each backend.
 
 Examples:
-  poxy system               # Show system info`,
+  poxy system               # Show system info
+  poxy status               # Same as poxy system`,
 	RunE: runSystem,
 }
 
 func runSystem(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
 	sysInfo := registry.SystemInfo()
 	if sysInfo == nil {
 		ui.WarningMsg("System information not available")
@@ -37,14 +47,76 @@ func runSystem(cmd *cobra.Command, args []string) error {
 		managerNames[i] = mgr.Name()
 	}
 
+	// Show what a derivative is based on, which decides its manager
+	distro := sysInfo.Distribution
+	if len(sysInfo.DistroFamily) > 0 {
+		distro += " (like " + strings.Join(sysInfo.DistroFamily, ", ") + ")"
+	}
+	switch {
+	case sysInfo.ImageBased != "":
+		distro += fmt.Sprintf(", image-based (%s)", sysInfo.ImageBased)
+	case sysInfo.ReadOnlyRoot:
+		distro += ", read-only root"
+	}
+
 	ui.PrintSystemInfo(
 		string(sysInfo.OS),
 		sysInfo.Arch,
-		sysInfo.Distribution,
+		distro,
 		sysInfo.PrettyName,
 		nativeManager,
+		registry.NativeReason(),
 		managerNames,
+		registry.ManagerVersions(ctx),
 	)
 
+	printDeployments(ctx)
+
+	switch sysInfo.ImageBased {
+	case detector.ImageOSTree:
+		ui.MutedMsg("\nThe system image is read-only: rpm-ostree stages package changes, which apply after a reboot.")
+		ui.MutedMsg("Apps are installed from Flatpak when it has them, leaving the image alone.")
+	case detector.ImageSteamOS:
+		ui.MutedMsg("\nThe root is read-only and replaced by SteamOS updates: apps are installed from Flatpak.")
+		ui.MutedMsg("pacman needs 'steamos-readonly disable' first, and its packages are lost on the next update.")
+	}
+
 	return nil
 }
+
+// printDeployments lists the deployments of an image-based system, marking
+// the booted one and one pending for the next boot.
+func printDeployments(ctx context.Context) {
+	lister, ok := registry.Native().(manager.DeploymentLister)
+	if !ok {
+		return
+	}
+	deployments, err := lister.Deployments(ctx)
+	if err != nil || len(deployments) == 0 {
+		return
+	}
+
+	ui.HeaderMsg("Deployments")
+	pending := manager.PendingDeployment(deployments)
+	for i, d := range deployments {
+		line := describeDeployment(d.Version, d.Checksum)
+		switch {
+		case d.Booted:
+			line = ui.Green("● "+line) + " (booted)"
+		case pending != nil && i == 0:
+			line = "  " + line + ui.Yellow(" (pending: reboot to apply)")
+		default:
+			line = "  " + line
+		}
+		if d.Pinned {
+			line += " [pinned]"
+		}
+		ui.Println("  %s", line)
+		if len(d.Layered) > 0 {
+			ui.MutedMsg("      Layered: %s", strings.Join(d.Layered, ", "))
+		}
+		if len(d.Removed) > 0 {
+			ui.MutedMsg("      Removed: %s", strings.Join(d.Removed, ", "))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"poxy/internal/ui"
+	"poxy/pkg/snapshot"
+	"poxy/pkg/transaction"
+)
+
+// rollbackOnFailure is shared by the install and upgrade commands. A
+// rollback removes the packages a failed upgrade added and reinstalls the
+// ones it removed, but cannot put back the versions it replaced.
+var rollbackOnFailure bool
+
+func init() {
+	installCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "stop at the first failure and roll back completed steps")
+	upgradeCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "remove packages added and reinstall packages removed if the upgrade fails")
+}
+
+// runTransaction executes tx from the state recorded in before (or a fresh
+// in-memory capture if before is nil). On failure it rolls back automatically
+// with --rollback-on-failure, or offers to when only some steps failed.
+// With --ci it stops at the first failure.
+func runTransaction(ctx context.Context, tx *transaction.Transaction, before *snapshot.Snapshot) error {
+	tx.StopOnFailure = rollbackOnFailure || ciMode
+
+	// A rollback is only possible with --rollback-on-failure or when several
+	// steps can partially succeed
+	if !cfg.General.DryRun && (rollbackOnFailure || len(tx.Steps()) > 1) {
+		if err := tx.Begin(ctx, before); err != nil && rollbackOnFailure {
+			ui.WarningMsg("%v; rollback will not be possible", err)
+		}
+	}
+
+	result := tx.Execute(ctx)
+	err := result.Err()
+	if err == nil || cfg.General.DryRun {
+		return err
+	}
+	if result.Partial() {
+		err = &partialFailureError{err: err}
+	}
+
+	for _, step := range result.Skipped {
+		ui.MutedMsg("Skipped %s from %s", strings.Join(step.Packages, " "), step.Manager.DisplayName())
+	}
+
+	if !rollbackOnFailure {
+		if cfg.General.AutoConfirm || !result.Partial() {
+			return err
+		}
+		confirmed, confirmErr := ui.Confirm("Some steps failed. Roll back the steps that succeeded?", false)
+		if confirmErr != nil || !confirmed {
+			return err
+		}
+	}
+
+	rollbackTransaction(ctx, tx)
+	return err
+}
+
+// rollbackTransaction restores the pre-transaction state and reports the outcome.
+func rollbackTransaction(ctx context.Context, tx *transaction.Transaction) {
+	plan, err := tx.RollbackPlan(ctx)
+	if err != nil {
+		ui.ErrorMsg("Rollback failed: %v", err)
+		return
+	}
+
+	if plan.IsEmpty() {
+		ui.InfoMsg("Nothing to roll back - no packages were changed")
+		return
+	}
+
+	ui.HeaderMsg("Rolling Back")
+	ui.MutedMsg(plan.Summary())
+	printRestorePlan(plan)
+
+	successful, err := tx.Rollback(ctx, plan)
+	if err != nil {
+		ui.ErrorMsg("Rollback incomplete after %d package(s): %v", successful, err)
+		ui.MutedMsg("Run 'poxy undo --plan' to review the remaining changes")
+		return
+	}
+
+	ui.SuccessMsg("Rolled back - processed %d package(s)", successful)
+}
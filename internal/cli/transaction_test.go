@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
+	"poxy/pkg/transaction"
+)
+
+// failingUpgrade returns a transaction whose single step, like an upgrade,
+// pulls in a new dependency and then fails.
+func failingUpgrade(mgr *catalogManager) *transaction.Transaction {
+	tx := transaction.New()
+	tx.Add(transaction.Step{
+		Manager: mgr,
+		Run: func(ctx context.Context) error {
+			if err := mgr.Install(ctx, []string{"libnew"}, manager.InstallOpts{}); err != nil {
+				return err
+			}
+			return errors.New("exit status 1")
+		},
+	})
+	return tx
+}
+
+func TestRunTransactionRollsBackOneStep(t *testing.T) {
+	savedCfg, savedRollback := cfg, rollbackOnFailure
+	t.Cleanup(func() { cfg, rollbackOnFailure = savedCfg, savedRollback })
+	cfg = config.Default()
+	cfg.General.AutoConfirm = true
+
+	installed := []manager.Package{{Name: "vim", Version: "1.0", Source: "apt", Installed: true}}
+
+	t.Run("without --rollback-on-failure", func(t *testing.T) {
+		rollbackOnFailure = false
+		mgr := &catalogManager{installed: slices.Clone(installed)}
+
+		if err := runTransaction(context.Background(), failingUpgrade(mgr), nil); err == nil {
+			t.Fatal("runTransaction() error = nil, want the step's failure")
+		}
+		if want := []string{"install libnew"}; !slices.Equal(mgr.changes, want) {
+			t.Errorf("changes = %v, want %v and no rollback", mgr.changes, want)
+		}
+	})
+
+	t.Run("with --rollback-on-failure", func(t *testing.T) {
+		rollbackOnFailure = true
+		mgr := &catalogManager{installed: slices.Clone(installed)}
+
+		if err := runTransaction(context.Background(), failingUpgrade(mgr), nil); err == nil {
+			t.Fatal("runTransaction() error = nil, want the step's failure")
+		}
+		if want := []string{"install libnew", "remove libnew"}; !slices.Equal(mgr.changes, want) {
+			t.Errorf("changes = %v, want %v", mgr.changes, want)
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"errors"
+	"strings"
+
 	"poxy/internal/history"
 	"poxy/internal/tui"
 	"poxy/internal/ui"
@@ -10,7 +13,7 @@ import (
 )
 
 var tuiCmd = &cobra.Command{
-	Use:   "tui",
+	Use:   "tui [view] [query...]",
 	Short: "Launch interactive terminal user interface",
 	Long: `Launch the interactive terminal user interface (TUI) for poxy.
 
@@ -18,25 +21,65 @@ The TUI provides a visual way to:
   - Browse installed packages
   - Search for new packages
   - View package details
-  - Install and remove packages
+  - Queue installs, removals and upgrades
   - View operation history
   - Check system information
+  - Merge leftover .pacnew and .rpmnew configuration files
 
 Navigation:
   - Use arrow keys or j/k to navigate
-  - Press 1-5 to switch tabs
+  - Press 1-7 to switch tabs (6 shows the operation queue, 7 leftover
+    .pacnew/.rpmnew files)
   - Press / to search
-  - Press i to install, r to remove
+  - Press i to queue an install, r to queue a removal
   - Press ? for help
-  - Press q to quit`,
-	RunE: runTUI,
+  - Press q to quit
+
+Pass a view name to open the TUI at that tab: packages, search, updates,
+history, system, queue or config. Any further arguments are a search query for
+the search view, or the initial filter for the other views.
+
+Examples:
+  poxy tui                     # Open at the installed packages
+  poxy tui search firefox      # Search for firefox
+  poxy tui updates             # Review available updates
+  poxy tui history op:remove   # Show removals in the history`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeTUIView,
+	RunE:              runTUI,
 }
 
 func init() {
 	rootCmd.AddCommand(tuiCmd)
 }
 
+// completeTUIView completes the view argument of the tui command.
+func completeTUIView(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return matchCompletions(tui.ViewNames(), args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
 func runTUI(cmd *cobra.Command, args []string) error {
+	if nonInteractive {
+		return errors.New("the TUI needs a terminal and cannot run with --non-interactive")
+	}
+
+	var opts tui.Options
+	if len(args) > 0 {
+		view, err := tui.ParseView(args[0])
+		if err != nil {
+			return err
+		}
+		opts.View = view
+		opts.Query = strings.Join(args[1:], " ")
+	}
+
+	if activeTheme != nil {
+		tui.ApplyTheme(activeTheme)
+	}
+
 	// Open history store
 	historyStore, err := history.Open()
 	if err != nil {
@@ -53,8 +96,11 @@ func runTUI(cmd *cobra.Command, args []string) error {
 	var searchIndex *database.Index
 	if searchEngine != nil {
 		searchIndex = searchEngine.GetIndex()
+		opts.RebuildIndex = searchEngine.BuildIndex
 	}
 
+	opts.Mappings = packageMappings()
+
 	// Launch TUI
-	return tui.Run(registry, cfg, historyStore, searchIndex)
+	return tui.Run(registry, cfg, historyStore, searchIndex, opts)
 }
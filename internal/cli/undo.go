@@ -2,7 +2,10 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"poxy/internal/history"
 	"poxy/internal/ui"
 	"poxy/pkg/snapshot"
 
@@ -12,49 +15,81 @@ import (
 var (
 	undoSnapshotID string
 	undoShowPlan   bool
+	undoForce      bool
 )
 
 var undoCmd = &cobra.Command{
-	Use:   "undo",
+	Use:   "undo [operation-id]",
 	Short: "Undo the last package operation",
 	Long: `Undo the last package operation by restoring the previous system state.
 
 Uses snapshots to determine what changed and reverses those changes.
-By default, undoes the most recent operation. Use --snapshot to restore
-to a specific snapshot.
+Services enabled after installing packages since then are stopped and
+disabled first. By default, undoes the most recent operation. Give an
+operation ID, as printed after every install, uninstall and upgrade, to
+restore the snapshot taken before that operation, or use --snapshot to
+restore to a specific snapshot.
+
+If the target snapshot failed to capture a package source, undo refuses
+to remove that source's packages, since the snapshot cannot say which of
+them were installed. Use --force to remove them anyway.
+
+The undo itself is recorded in the history with a snapshot taken before
+it, so it can be reviewed and undone like any other operation.
 
 Examples:
   poxy undo                          # Undo last operation
+  poxy undo ab12                     # Undo operation 2025-06-01-ab12 and everything after it
+  poxy undo --dry-run                # Show what would be undone without doing it
   poxy undo --snapshot=20240114-153045   # Restore to specific snapshot
-  poxy undo --plan                   # Show what would be undone without doing it`,
+  poxy undo --plan                   # Same as --dry-run`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runUndo,
 }
 
 func init() {
 	undoCmd.Flags().StringVar(&undoSnapshotID, "snapshot", "", "specific snapshot ID to restore to")
 	undoCmd.Flags().BoolVar(&undoShowPlan, "plan", false, "show what would be undone without executing")
+	undoCmd.Flags().BoolVar(&undoForce, "force", false, "remove packages of sources the snapshot failed to capture")
 }
 
 func runUndo(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	// The global --dry-run only shows the plan, like --plan
+	planOnly := undoShowPlan || cfg.General.DryRun
+
 	managers := getAvailableManagers()
 	if len(managers) == 0 {
 		return ErrNoManager
 	}
 
 	opts := snapshot.RestoreOpts{
-		DryRun:      cfg.General.DryRun || undoShowPlan,
+		DryRun:      planOnly,
 		AutoConfirm: cfg.General.AutoConfirm,
+		Force:       undoForce,
 	}
 
 	var plan *snapshot.RestorePlan
 	var err error
 
-	if undoSnapshotID != "" {
+	snapshotID := undoSnapshotID
+	if len(args) > 0 {
+		if snapshotID != "" {
+			return fmt.Errorf("give either an operation ID or --snapshot, not both")
+		}
+		entry, err := operationSnapshotOf(args[0])
+		if err != nil {
+			return err
+		}
+		ui.InfoMsg("Undoing operation %s: %s", entry.ShortID(), entry.Summary())
+		snapshotID = entry.Snapshot
+	}
+
+	if snapshotID != "" {
 		// Restore to specific snapshot
-		ui.InfoMsg("Restoring to snapshot %s", undoSnapshotID)
-		plan, err = snapshot.RestoreToSnapshot(ctx, undoSnapshotID, managers, opts)
+		ui.InfoMsg("Restoring to snapshot %s", snapshotID)
+		plan, err = snapshot.RestoreToSnapshot(ctx, snapshotID, managers, opts)
 	} else {
 		// Undo last operation
 		plan, err = snapshot.Undo(ctx, managers, opts)
@@ -64,7 +99,10 @@ func runUndo(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if plan.IsEmpty() {
+	// Services enabled after installs since the target snapshot
+	units := servicesToDisable(ctx, plan.Target.Timestamp)
+
+	if plan.IsEmpty() && len(units) == 0 {
 		ui.SuccessMsg("No changes needed - system already matches target state")
 		return nil
 	}
@@ -75,33 +113,25 @@ func runUndo(cmd *cobra.Command, args []string) error {
 	ui.MutedMsg(plan.Summary())
 	ui.Println("")
 
-	// Show packages to install
-	if len(plan.ToAdd) > 0 {
-		ui.InfoMsg("Packages to reinstall:")
-		for source, pkgs := range plan.ToAdd {
-			for _, pkg := range pkgs {
-				ui.MutedMsg("  + %s [%s]", pkg, source)
-			}
-		}
-	}
-
-	// Show packages to remove
-	if len(plan.ToRemove) > 0 {
-		ui.InfoMsg("Packages to remove:")
-		for source, pkgs := range plan.ToRemove {
-			for _, pkg := range pkgs {
-				ui.MutedMsg("  - %s [%s]", pkg, source)
-			}
+	if len(units) > 0 {
+		ui.InfoMsg("Services to disable:")
+		for _, unit := range units {
+			ui.MutedMsg("  - %s", unit)
 		}
 	}
+	printRestorePlan(plan)
 
 	// If just showing plan, stop here
-	if undoShowPlan || cfg.General.DryRun {
+	if planOnly {
 		ui.MutedMsg("")
 		ui.MutedMsg("(dry run - no changes made)")
 		return nil
 	}
 
+	if err := confirmPrivileges(managersFor(plan.Sources())...); err != nil {
+		return err
+	}
+
 	// Confirm
 	if !cfg.General.AutoConfirm {
 		confirmed, err := ui.Confirm("Proceed with undo?", false)
@@ -113,16 +143,111 @@ func runUndo(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Stop the services before their packages are removed
+	if err := disableServices(ctx, units); err != nil {
+		ui.WarningMsg("%v", err)
+	}
+
+	// Capture the state before the undo, so it can be undone in turn
+	pre := capturePreOperationSnapshot(ctx, snapshot.TriggerUndo, plan.Packages())
+
 	// Execute restore
 	executor := snapshot.NewExecutor(managers, opts)
-	successful, execErr := executor.Execute(ctx, plan)
+	results, execErr := executor.Execute(ctx, plan)
+
+	recordUndo(plan, pre, execErr)
 
 	if execErr != nil {
-		ui.WarningMsg("Some operations failed: %v", execErr)
-		ui.InfoMsg("Successfully processed %d package(s)", successful)
-		return execErr
+		ui.WarningMsg("Some operations did not complete:")
+		printFailedResults(results)
+		ui.InfoMsg("Successfully processed %d package(s)", results.Successful())
+		return fmt.Errorf("undo incomplete: %d of %d actions failed or were skipped", len(results.Failed()), len(results))
 	}
 
-	ui.SuccessMsg("Undo completed - processed %d package(s)", successful)
+	ui.SuccessMsg("Undo completed - processed %d package(s)", results.Successful())
 	return nil
 }
+
+// operationSnapshotOf returns the history entry of an operation, which must
+// have a snapshot from before it to undo.
+func operationSnapshotOf(id string) (*history.Entry, error) {
+	store, err := history.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer store.Close()
+
+	entry, err := store.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("operation not found: %s", id)
+	}
+	if entry.Snapshot == "" {
+		if entry.CanRollback() {
+			return nil, fmt.Errorf("operation %s has no snapshot to restore; try 'poxy rollback --id %s'", entry.ShortID(), entry.Code())
+		}
+		return nil, fmt.Errorf("operation %s has no snapshot to restore", entry.ShortID())
+	}
+	return entry, nil
+}
+
+// printFailedResults lists restore actions that failed or were skipped.
+func printFailedResults(results snapshot.Results) {
+	for _, res := range results.Failed() {
+		reason := res.Skipped
+		if res.Err != nil {
+			reason = res.Err.Error()
+		}
+		ui.MutedMsg("  %s %s [%s]: %s", res.Action, res.Package, res.Source, reason)
+	}
+}
+
+// recordUndo records an executed undo in the history, linked to the
+// snapshot taken before it.
+func recordUndo(plan *snapshot.RestorePlan, pre *snapshot.Snapshot, execErr error) {
+	entry := history.NewEntry(history.OpUndo, strings.Join(plan.Sources(), ","), plan.Packages())
+	if pre != nil {
+		entry.Snapshot = pre.ID
+	}
+	if execErr != nil {
+		entry.MarkFailed(execErr)
+	} else {
+		entry.MarkSuccess()
+	}
+
+	recordHistory(entry)
+}
+
+// printRestorePlan lists the module streams, installs and removals in a restore plan.
+func printRestorePlan(plan *snapshot.RestorePlan) {
+	if len(plan.Skipped) > 0 {
+		ui.WarningMsg("Skipping %s: installed packages could not be listed", strings.Join(plan.Skipped, ", "))
+	}
+
+	// Show module streams to enable
+	if len(plan.Streams) > 0 {
+		ui.InfoMsg("Module streams to enable:")
+		for _, mod := range plan.Streams {
+			ui.MutedMsg("  * %s:%s [%s]", mod.Name, mod.Stream, mod.Source)
+		}
+	}
+
+	// Show packages to install
+	if len(plan.ToAdd) > 0 {
+		ui.InfoMsg("Packages to reinstall:")
+		for source, pkgs := range plan.ToAdd {
+			for _, pkg := range pkgs {
+				ui.MutedMsg("  + %s [%s]", pkg, source)
+			}
+		}
+	}
+
+	// Show packages to remove
+	if len(plan.ToRemove) > 0 {
+		ui.InfoMsg("Packages to remove:")
+		for source, pkgs := range plan.ToRemove {
+			for _, pkg := range pkgs {
+				ui.MutedMsg("  - %s [%s]", pkg, source)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/internal/history"
+	"poxy/internal/ui"
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+)
+
+// setupUndo saves the snapshot taken before git was installed with apt and
+// a later manual one, and registers a fake apt with git installed. Prompts
+// fail, so an undo that asks for confirmation returns ui.ErrNoPrompt.
+func setupUndo(t *testing.T) *catalogManager {
+	t.Helper()
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(config.DataDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	savedCfg, savedRegistry, savedNonInteractive := cfg, registry, ui.NonInteractive
+	t.Cleanup(func() {
+		cfg, registry, ui.NonInteractive = savedCfg, savedRegistry, savedNonInteractive
+		undoShowPlan = false
+	})
+	cfg = config.Default()
+	ui.NonInteractive = true
+
+	store, err := snapshot.OpenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	taken := time.Now().Add(-time.Hour)
+	for _, saved := range []struct {
+		trigger snapshot.Trigger
+		names   []string
+	}{
+		{snapshot.TriggerInstall, []string{"vim"}},
+		{snapshot.TriggerManual, []string{"vim", "git"}},
+	} {
+		taken = taken.Add(time.Minute)
+		snap := &snapshot.Snapshot{
+			ID:        taken.Format("20060102-150405"),
+			Timestamp: taken,
+			Trigger:   saved.trigger,
+		}
+		for _, name := range saved.names {
+			snap.Packages = append(snap.Packages, snapshot.PackageState{Name: name, Version: "1.0", Source: "apt"})
+		}
+		if err := store.Save(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mgr := &catalogManager{installed: []manager.Package{
+		{Name: "vim", Version: "1.0", Source: "apt", Installed: true},
+		{Name: "git", Version: "1.0", Source: "apt", Installed: true},
+	}}
+	registry = manager.NewRegistry(cfg)
+	registry.Register(mgr)
+	return mgr
+}
+
+func TestUndoDryRun(t *testing.T) {
+	t.Run("--dry-run", func(t *testing.T) {
+		mgr := setupUndo(t)
+		cfg.General.DryRun = true
+
+		if err := runUndo(undoCmd, nil); err != nil {
+			t.Fatalf("runUndo() with --dry-run error = %v, want the plan shown without a prompt", err)
+		}
+		if len(mgr.changes) > 0 {
+			t.Errorf("runUndo() with --dry-run made changes: %v", mgr.changes)
+		}
+	})
+
+	t.Run("--plan", func(t *testing.T) {
+		mgr := setupUndo(t)
+		undoShowPlan = true
+
+		if err := runUndo(undoCmd, nil); err != nil {
+			t.Fatalf("runUndo() with --plan error = %v", err)
+		}
+		if len(mgr.changes) > 0 {
+			t.Errorf("runUndo() with --plan made changes: %v", mgr.changes)
+		}
+	})
+
+	t.Run("without either", func(t *testing.T) {
+		mgr := setupUndo(t)
+
+		if err := runUndo(undoCmd, nil); !errors.Is(err, ui.ErrNoPrompt) {
+			t.Errorf("runUndo() error = %v, want it to ask for confirmation", err)
+		}
+		if len(mgr.changes) > 0 {
+			t.Errorf("runUndo() made changes without confirmation: %v", mgr.changes)
+		}
+	})
+}
+
+func TestUndoCanBeUndone(t *testing.T) {
+	mgr := setupUndo(t)
+	cfg.General.AutoConfirm = true
+
+	if err := runUndo(undoCmd, nil); err != nil {
+		t.Fatalf("runUndo() error = %v", err)
+	}
+	if want := []string{"remove git"}; !slices.Equal(mgr.changes, want) {
+		t.Fatalf("runUndo() changes = %v, want %v, passing over the manual snapshot", mgr.changes, want)
+	}
+
+	store, err := history.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.Last()
+	store.Close()
+	if err != nil || entry == nil || entry.Operation != history.OpUndo {
+		t.Fatalf("last history entry = %+v, %v, want the undo", entry, err)
+	}
+	if _, err := operationSnapshotOf(entry.ID); err != nil {
+		t.Errorf("operationSnapshotOf() of the undo error = %v", err)
+	}
+
+	if err := runUndo(undoCmd, nil); err != nil {
+		t.Fatalf("runUndo() of the undo error = %v", err)
+	}
+	if want := []string{"remove git", "install git"}; !slices.Equal(mgr.changes, want) {
+		t.Errorf("runUndo() of the undo changes = %v, want %v", mgr.changes, want)
+	}
+}
@@ -2,6 +2,9 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"poxy/internal/history"
 	"poxy/internal/ui"
@@ -14,6 +17,7 @@ import (
 var (
 	uninstallPurge     bool
 	uninstallRecursive bool
+	uninstallUnusedOf  []string
 )
 
 var uninstallCmd = &cobra.Command{
@@ -27,14 +31,23 @@ Examples:
   poxy uninstall vim                # Remove package
   poxy uninstall -y firefox         # Remove without confirmation
   poxy uninstall --purge nginx      # Remove including config files
-  poxy uninstall -r package         # Remove with unused dependencies`,
-	Args: cobra.MinimumNArgs(1),
-	RunE: runUninstall,
+  poxy uninstall -r package         # Remove with unused dependencies
+  poxy uninstall --unused-deps-of gimp  # Pick which of gimp's own dependencies go too`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(uninstallUnusedOf) > 0 {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completeInstalledPackages,
+	RunE:              runUninstall,
 }
 
 func init() {
 	uninstallCmd.Flags().BoolVar(&uninstallPurge, "purge", false, "remove configuration files too")
 	uninstallCmd.Flags().BoolVarP(&uninstallRecursive, "recursive", "r", false, "remove unused dependencies")
+	uninstallCmd.Flags().StringArrayVar(&uninstallUnusedOf, "unused-deps-of", nil, "remove a package and pick which dependencies only it needs to remove too")
+	uninstallCmd.MarkFlagsMutuallyExclusive("recursive", "unused-deps-of")
 }
 
 func runUninstall(cmd *cobra.Command, args []string) error {
@@ -47,10 +60,57 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Resolve aliases
-	packages := resolvePackages(args)
+	packages := resolvePackages(mgr, args)
+	if len(uninstallUnusedOf) > 0 {
+		apps := resolvePackages(mgr, uninstallUnusedOf)
+		deps, err := selectUnusedDependencies(ctx, mgr, apps)
+		if err != nil {
+			return err
+		}
+		packages = append(append(packages, apps...), deps...)
+	}
+	return doUninstall(ctx, mgr, packages)
+}
+
+// selectUnusedDependencies finds the installed dependencies that only apps
+// need and asks which of them to remove along with apps. With -y all of
+// them are removed.
+func selectUnusedDependencies(ctx context.Context, mgr manager.Manager, apps []string) ([]string, error) {
+	lister, ok := mgr.(manager.UnusedDependencyLister)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot tell which dependencies only %s needs; use --recursive instead", mgr.DisplayName(), strings.Join(apps, ", "))
+	}
 
+	var deps []string
+	err := ui.WithSpinner("Finding dependencies nothing else needs...", func() error {
+		var err error
+		deps, err = lister.UnusedDependencies(ctx, apps)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(deps) == 0 {
+		ui.InfoMsg("No dependencies are needed only by %s", strings.Join(apps, ", "))
+		return nil, nil
+	}
+	sort.Strings(deps)
+
+	if cfg.General.AutoConfirm {
+		return deps, nil
+	}
+	selected, err := ui.SelectMultiple(deps, ui.Bold("Dependencies only "+strings.Join(apps, ", ")+" needs")+" - select the ones to remove:")
+	if err != nil {
+		return nil, ErrAborted
+	}
+	return selected, nil
+}
+
+// doUninstall removes packages with mgr after confirming, and records the
+// removal in the history.
+func doUninstall(ctx context.Context, mgr manager.Manager, packages []string) error {
 	// Show what we're doing
-	ui.InfoMsg("Removing %d package(s) using %s", len(packages), mgr.DisplayName())
+	ui.InfoMsg("Removing %d package(s) using %s%s", len(packages), mgr.DisplayName(), rebootLabel(mgr))
 	for _, pkg := range packages {
 		ui.MutedMsg("  - %s", pkg)
 	}
@@ -59,6 +119,11 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		ui.WarningMsg("Configuration files will also be removed")
 	}
 
+	warnDependents(ctx, mgr, packages)
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
+
 	// Confirm if not auto-confirmed
 	if !cfg.General.AutoConfirm && !cfg.General.DryRun {
 		confirmed, err := ui.Confirm("Proceed with removal?", false)
@@ -84,23 +149,47 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		Recursive:   uninstallRecursive,
 	}
 
+	if err := prepareSystem(ctx, mgr); err != nil {
+		return err
+	}
+
+	beginPlanOperation(ctx, history.OpUninstall, mgr, packages)
+	defer endPlanOperation()
+
 	// Execute removal
-	err = mgr.Uninstall(ctx, packages, opts)
+	err := mgr.Uninstall(ctx, packages, opts)
 
 	// Update history
 	if err != nil {
 		entry.MarkFailed(err)
 		ui.ErrorMsg("Removal failed: %v", err)
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
 	} else {
 		entry.MarkSuccess()
 		ui.SuccessMsg("Successfully removed %d package(s)", len(packages))
+		forgetResolutions(mgr.Name(), packages)
+		forgetInstallReasons(mgr.Name(), packages)
+		updateIndex(ctx, mgr, packages)
 	}
 
-	// Record in history (ignore errors)
-	if store, storeErr := history.Open(); storeErr == nil {
-		_ = store.Record(entry) //nolint:errcheck
-		_ = store.Close()       //nolint:errcheck
-	}
+	recordHistory(entry)
 
 	return err
 }
+
+// warnDependents lists installed packages that depend on the packages being
+// removed. The backend may refuse the removal or take them with it.
+func warnDependents(ctx context.Context, mgr manager.Manager, packages []string) {
+	dependents := manager.FindDependents(ctx, mgr, packages)
+	if len(dependents) == 0 {
+		return
+	}
+
+	ui.WarningMsg("Other installed packages depend on these packages:")
+	for _, pkg := range packages {
+		if deps, ok := dependents[pkg]; ok {
+			ui.MutedMsg("  %s is required by %s", pkg, strings.Join(deps, ", "))
+		}
+	}
+	ui.MutedMsg("They may stop working or be removed as well")
+}
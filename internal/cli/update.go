@@ -31,6 +31,9 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
 
 	ui.InfoMsg("Updating package database using %s", mgr.DisplayName())
 
@@ -44,16 +47,13 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		entry.MarkFailed(err)
 		ui.ErrorMsg("Update failed: %v", err)
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
 	} else {
 		entry.MarkSuccess()
 		ui.SuccessMsg("Package database updated successfully")
 	}
 
-	// Record in history (ignore errors)
-	if store, storeErr := history.Open(); storeErr == nil {
-		_ = store.Record(entry) //nolint:errcheck
-		_ = store.Close()       //nolint:errcheck
-	}
+	recordHistory(entry)
 
 	return err
 }
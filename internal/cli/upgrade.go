@@ -2,11 +2,18 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"poxy/internal/configfiles"
 	"poxy/internal/history"
+	"poxy/internal/pin"
+	"poxy/internal/report"
 	"poxy/internal/ui"
 	"poxy/pkg/manager"
 	"poxy/pkg/snapshot"
+	"poxy/pkg/transaction"
 
 	"github.com/spf13/cobra"
 )
@@ -21,29 +28,96 @@ If no packages are specified, all installed packages will be upgraded.
 Examples:
   poxy upgrade              # Upgrade all packages
   poxy upgrade vim git      # Upgrade specific packages
-  poxy upgrade -y           # Upgrade all without confirmation`,
-	RunE: runUpgrade,
+  poxy upgrade -y           # Upgrade all without confirmation
+  poxy upgrade --phased     # Include APT phased updates
+  poxy upgrade --rollback-on-failure  # Undo package changes if it fails
+  poxy upgrade -s aur --sandbox-profile offline  # Rebuild AUR packages in a custom sandbox
+  poxy upgrade --backend-arg=apt:--with-new-pkgs  # Pass an option to apt
+
+Packages pinned with 'poxy pin' are left at their installed version.
+
+With --rollback-on-failure, a failed upgrade removes the packages it added
+and reinstalls the ones it removed. Packages it upgraded stay at their new
+version: poxy cannot reinstall the versions it replaced, so take a
+filesystem snapshot first if you need to go back completely.
+
+The upgrade ends with an "Action required" checklist when it left new
+configuration files (.pacnew and the like) or, with pacman, when package
+scripts asked for manual steps such as regenerating the initramfs.`,
+	ValidArgsFunction: completeInstalledPackages,
+	RunE:              runUpgrade,
+}
+
+var upgradePhased bool
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradePhased, "phased", false, "include phased updates that are still rolling out (APT)")
+	addSandboxProfileFlag(upgradeCmd)
+	addBackendArgFlag(upgradeCmd)
 }
 
-func runUpgrade(cmd *cobra.Command, args []string) error {
+func runUpgrade(cmd *cobra.Command, args []string) (err error) {
 	ctx := context.Background()
 
+	rep := startReport("upgrade")
+	defer func() { sendReport(rep, err) }()
+
+	if err := checkSandboxProfile(); err != nil {
+		return err
+	}
+	if err := checkBackendArgs(); err != nil {
+		return err
+	}
+
 	// Get package manager
 	mgr, err := getManager()
 	if err != nil {
 		return err
 	}
+	if err := confirmPrivileges(mgr); err != nil {
+		return err
+	}
 
 	// Resolve aliases if specific packages given
-	packages := resolvePackages(args)
+	opts := manager.UpgradeOpts{
+		AutoConfirm:    cfg.General.AutoConfirm,
+		DryRun:         cfg.General.DryRun,
+		Packages:       resolvePackages(mgr, args),
+		IncludePhased:  upgradePhased,
+		SandboxProfile: sandboxProfile,
+		CleanChroot:    cleanChroot,
+		ExtraArgs:      backendArgs(mgr.Name()),
+	}
+
+	// Leave pinned packages at their installed version
+	if pins, err := pin.Load(); err != nil {
+		ui.WarningMsg("Could not read pinned packages: %v", err)
+	} else {
+		var skipped []string
+		opts, skipped, err = pins.Apply(ctx, mgr, opts)
+		if len(skipped) > 0 {
+			ui.MutedMsg("Skipping pinned: %s", strings.Join(skipped, ", "))
+			if rep != nil {
+				rep.Add("Skipped (pinned)", skipped...)
+			}
+		}
+		if errors.Is(err, pin.ErrAllPinned) {
+			ui.InfoMsg("Nothing to upgrade: all packages with updates are pinned")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	packages := opts.Packages
 
 	if len(packages) > 0 {
-		ui.InfoMsg("Upgrading %d package(s) using %s", len(packages), mgr.DisplayName())
+		ui.InfoMsg("Upgrading %d package(s) using %s%s", len(packages), mgr.DisplayName(), rebootLabel(mgr))
 		for _, pkg := range packages {
 			ui.MutedMsg("  - %s", pkg)
 		}
 	} else {
-		ui.InfoMsg("Upgrading all packages using %s", mgr.DisplayName())
+		ui.InfoMsg("Upgrading all packages using %s%s", mgr.DisplayName(), rebootLabel(mgr))
 	}
 
 	// Confirm if not auto-confirmed
@@ -58,35 +132,134 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 	}
 
 	// Capture pre-operation snapshot
-	capturePreOperationSnapshot(ctx, snapshot.TriggerUpgrade, packages)
+	before := capturePreOperationSnapshot(ctx, snapshot.TriggerUpgrade, packages)
+
+	// Note the leftover configuration files, to report only the new ones
+	var leftovers map[string]bool
+	if !opts.DryRun {
+		leftovers = scanLeftovers(ctx)
+	}
+
+	tx := transaction.New()
+	tx.Add(transaction.Step{
+		Manager:  mgr,
+		Packages: packages,
+		Run: func(ctx context.Context) error {
+			return doUpgrade(ctx, mgr, opts)
+		},
+	})
 
+	err = runTransaction(ctx, tx, before)
+	if rep != nil {
+		addChangesSection(ctx, rep, before)
+	}
+	if !opts.DryRun {
+		showActionsRequired(ctx, mgr, leftovers, rep)
+	}
+	return err
+}
+
+// scanLeftovers returns the paths of the leftover configuration files in
+// /etc, or nil if it cannot be read.
+func scanLeftovers(ctx context.Context) map[string]bool {
+	files, err := configfiles.Scan(ctx, configfiles.DefaultDir)
+	if err != nil {
+		return nil
+	}
+	paths := make(map[string]bool, len(files))
+	for _, f := range files {
+		paths[f.Path] = true
+	}
+	return paths
+}
+
+// showActionsRequired ends an upgrade with a checklist of what it left for
+// the user: configuration files left beside the live ones since before,
+// and the manual steps the manager reported.
+func showActionsRequired(ctx context.Context, mgr manager.Manager, before map[string]bool, rep *report.Report) {
+	var items []string
+	listed := make(map[string]bool)
+
+	if before != nil {
+		files, _ := configfiles.Scan(ctx, configfiles.DefaultDir)
+		for _, f := range files {
+			if !before[f.Path] {
+				items = append(items, fmt.Sprintf("Review %s (%s)", f.Path, f.Describe()))
+				listed[f.Path] = true
+			}
+		}
+	}
+
+	leftovers := len(items) > 0
+	if reporter, ok := mgr.(manager.NoticeReporter); ok {
+		for _, notice := range reporter.TakeNotices() {
+			switch {
+			case notice.Path != "":
+				if !listed[notice.Path] {
+					items = append(items, "Review "+notice.Path)
+					listed[notice.Path] = true
+					leftovers = true
+				}
+			case notice.Package != "":
+				items = append(items, fmt.Sprintf("%s: %s", notice.Package, notice.Text))
+			default:
+				items = append(items, notice.Text)
+			}
+		}
+	}
+
+	if len(items) == 0 {
+		return
+	}
+
+	ui.HeaderMsg("Action required")
+	for _, item := range items {
+		ui.Println("  [ ] %s", item)
+	}
+	if leftovers {
+		ui.MutedMsg("Run 'poxy config-files' to compare and merge the configuration files")
+	}
+	if rep != nil {
+		rep.Add("Action required", items...)
+	}
+}
+
+// doUpgrade runs the upgrade and records it in history.
+func doUpgrade(ctx context.Context, mgr manager.Manager, opts manager.UpgradeOpts) error {
 	// Create history entry
-	entry := history.NewEntry(history.OpUpgrade, mgr.Name(), packages)
+	entry := history.NewEntry(history.OpUpgrade, mgr.Name(), opts.Packages)
 
-	// Build options
-	opts := manager.UpgradeOpts{
-		AutoConfirm: cfg.General.AutoConfirm,
-		DryRun:      cfg.General.DryRun,
-		Packages:    packages,
+	if err := prepareSystem(ctx, mgr); err != nil {
+		return err
 	}
 
+	beginPlanOperation(ctx, history.OpUpgrade, mgr, opts.Packages)
+	defer endPlanOperation()
+
 	// Execute upgrade
-	err = mgr.Upgrade(ctx, opts)
+	err := mgr.Upgrade(ctx, opts)
+
+	// Offer to repair the pacman keyring on signature errors
+	if err != nil {
+		if handled, handledErr := handlePacmanKeyring(ctx, err, func() error {
+			return mgr.Upgrade(ctx, opts)
+		}); handled {
+			err = handledErr
+		}
+	}
 
 	// Update history
 	if err != nil {
 		entry.MarkFailed(err)
 		ui.ErrorMsg("Upgrade failed: %v", err)
+		ui.MutedMsg("Run 'poxy explain-error %s' for likely causes and fixes", entry.ID)
 	} else {
 		entry.MarkSuccess()
 		ui.SuccessMsg("Upgrade completed successfully")
+		updateIndex(ctx, mgr, opts.Packages)
 	}
 
-	// Record in history (ignore errors)
-	if store, storeErr := history.Open(); storeErr == nil {
-		_ = store.Record(entry) //nolint:errcheck
-		_ = store.Close()       //nolint:errcheck
-	}
+	recordHistory(entry)
 
 	return err
 }
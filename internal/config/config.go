@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -10,6 +11,13 @@ import (
 type Config struct {
 	General  GeneralConfig            `toml:"general"`
 	Output   OutputConfig             `toml:"output"`
+	Policy   PolicyConfig             `toml:"policy"`
+	Limits   LimitsConfig             `toml:"limits"`
+	Timeouts TimeoutsConfig           `toml:"timeouts"`
+	Daemon   DaemonConfig             `toml:"daemon"`
+	Report   ReportConfig             `toml:"report"`
+	Mappings MappingsConfig           `toml:"mappings"`
+	Sandbox  SandboxConfig            `toml:"sandbox"`
 	Managers map[string]ManagerConfig `toml:"managers"`
 	Aliases  map[string]string        `toml:"aliases"`
 }
@@ -17,7 +25,7 @@ type Config struct {
 // GeneralConfig contains general poxy settings.
 type GeneralConfig struct {
 	// SourcePriority defines the order in which package sources are searched/preferred.
-	// Valid values: "native", "flatpak", "snap", "aur", "brew"
+	// Valid values: "native", "flatpak", "snap", "cargo", "npm", "gobin", "aur", "brew"
 	SourcePriority []string `toml:"source_priority"`
 
 	// AutoConfirm skips confirmation prompts when true (like -y flag).
@@ -32,6 +40,19 @@ type GeneralConfig struct {
 	// SmartSearch enables TF-IDF based intelligent search with relevance ranking.
 	// When disabled, falls back to native package manager search.
 	SmartSearch bool `toml:"smart_search"`
+
+	// SuggestServices offers to enable and start the systemd services an
+	// installed package ships, such as docker or cups.
+	SuggestServices bool `toml:"suggest_services"`
+
+	// SuggestShellSetup offers to add the directory a manager installs
+	// commands into, such as ~/.cargo/bin, to the shell's PATH when it is
+	// not on it yet.
+	SuggestShellSetup bool `toml:"suggest_shell_setup"`
+
+	// SearchPrompt ends interactive searches with a prompt to install a
+	// result or show its info by number.
+	SearchPrompt bool `toml:"search_prompt"`
 }
 
 // OutputConfig contains output formatting settings.
@@ -44,6 +65,158 @@ type OutputConfig struct {
 
 	// Verbose enables detailed output.
 	Verbose bool `toml:"verbose"`
+
+	// ReducedMotion replaces animations such as spinners with static
+	// status text, for motion-sensitive users and slow terminals.
+	ReducedMotion bool `toml:"reduced_motion"`
+
+	// Theme selects the colors of the CLI and TUI: "default", the name of
+	// a theme file in ThemeDir() without ".toml", or a path to a theme file.
+	Theme string `toml:"theme"`
+}
+
+// PolicyConfig contains settings that restrict what poxy is allowed to do.
+type PolicyConfig struct {
+	// AllowedManagers restricts poxy to the listed package managers.
+	// An empty list allows every manager.
+	AllowedManagers []string `toml:"allowed_managers"`
+
+	// PromptPrivileges shows a one-time summary of the privileged
+	// commands of each manager before its first mutating operation and
+	// records acceptance.
+	PromptPrivileges bool `toml:"prompt_privileges"`
+
+	// InstallScope is "user" to prefer installs for the current user only,
+	// such as flatpak --user, Cargo or Homebrew, over system-wide ones, or
+	// "system" for the reverse. Sources that can do either install that
+	// way unless their own scope setting says otherwise. Empty leaves
+	// every source to its default.
+	InstallScope string `toml:"install_scope"`
+}
+
+// LimitsConfig contains default result limits. Each can be overridden with
+// the --limit flag of the corresponding command.
+type LimitsConfig struct {
+	// Search is the maximum number of search results.
+	Search int `toml:"search"`
+
+	// List is the maximum number of installed packages listed (0 = all).
+	List int `toml:"list"`
+
+	// History is the number of history entries shown.
+	History int `toml:"history"`
+
+	// SnapshotList is the maximum number of snapshots listed.
+	SnapshotList int `toml:"snapshot_list"`
+}
+
+// TimeoutsConfig contains command timeouts as duration strings such as "30s"
+// (0 = no timeout). Search and Info can be overridden with the --timeout
+// flag of the corresponding command.
+type TimeoutsConfig struct {
+	// Search bounds a search across all package sources.
+	Search time.Duration `toml:"search"`
+
+	// Info bounds a package information lookup.
+	Info time.Duration `toml:"info"`
+
+	// Probe bounds the lookups in each source while install picks a source
+	// for a package, and each source of a search across all of them;
+	// sources that take longer are skipped.
+	Probe time.Duration `toml:"probe"`
+}
+
+// DaemonConfig contains settings for the background index refresher.
+type DaemonConfig struct {
+	// Interval is how often the daemon rebuilds the package index and
+	// checks for updates, as a duration string such as "1h".
+	Interval time.Duration `toml:"interval"`
+
+	// CatalogInterval is how often the index downloads each source's
+	// catalog of available packages again; installed packages are listed
+	// on every refresh.
+	CatalogInterval time.Duration `toml:"catalog_interval"`
+
+	// Socket is the unix socket the daemon serves its status on
+	// (default: the runtime directory).
+	Socket string `toml:"socket"`
+}
+
+// SocketPath returns the daemon status socket, honoring the configured override.
+func (d DaemonConfig) SocketPath() string {
+	if d.Socket != "" {
+		return d.Socket
+	}
+	return SocketPath()
+}
+
+// ReportConfig contains where summaries of unattended operations, such as
+// upgrades and snapshot runs from cron or a systemd timer, are sent.
+type ReportConfig struct {
+	// File is appended a summary of each reported operation.
+	File string `toml:"file"`
+
+	// Command is run through sh with the summary on its stdin, such as
+	// "sendmail admin@example.com" or "curl -s -T- ntfy.sh/mytopic".
+	Command string `toml:"command"`
+
+	// Always reports interactive runs too. By default only runs without a
+	// terminal are reported.
+	Always bool `toml:"always"`
+}
+
+// Enabled returns true if reports have somewhere to go.
+func (r ReportConfig) Enabled() bool {
+	return r.File != "" || r.Command != ""
+}
+
+// MappingsConfig contains where `poxy mappings sync` downloads the curated
+// package mapping dataset from.
+type MappingsConfig struct {
+	// URL is the mapping dataset, a JSON object with a serial raised with
+	// every release and the array of mappings:
+	// {"serial": 42, "mappings": [...]}. Its ed25519 signature is read
+	// from URL + ".sig", base64 encoded.
+	URL string `toml:"url"`
+
+	// PublicKey is the base64 ed25519 key the dataset must be signed with.
+	PublicKey string `toml:"public_key"`
+}
+
+// SandboxConfig contains the sandbox backend and custom sandbox profiles
+// for AUR builds.
+type SandboxConfig struct {
+	// Backend is the tool builds are sandboxed with: "bubblewrap",
+	// "firejail", "systemd-run", "unshare" or "auto" (default) for the
+	// first one that works, in that order.
+	Backend string `toml:"backend"`
+
+	// Profiles are custom profiles by name, selected with --sandbox-profile
+	// or [managers.aur] sandbox_profile.
+	Profiles map[string]SandboxProfileConfig `toml:"profiles"`
+}
+
+// SandboxProfileConfig defines a sandbox profile on top of a built-in one.
+type SandboxProfileConfig struct {
+	// Base is the built-in profile extended: "build" (default), "fetch"
+	// or "minimal".
+	Base string `toml:"base"`
+
+	// BindReadOnly are extra paths mounted read-only.
+	BindReadOnly []string `toml:"bind_ro"`
+
+	// BindReadWrite are extra paths mounted read-write.
+	BindReadWrite []string `toml:"bind_rw"`
+
+	// EnvPass are extra environment variables passed into the sandbox.
+	EnvPass []string `toml:"env_pass"`
+
+	// Env are extra environment variables set in the sandbox.
+	Env map[string]string `toml:"env"`
+
+	// Network allows network access (default true, since makepkg
+	// downloads sources inside the sandbox).
+	Network *bool `toml:"network"`
 }
 
 // ManagerConfig contains per-manager settings.
@@ -54,6 +227,10 @@ type ManagerConfig struct {
 	// UseNala uses nala instead of apt if available. APT only.
 	UseNala bool `toml:"use_nala"`
 
+	// Scope is "user" or "system" to install for the current user only or
+	// for every user, overriding [policy] install_scope. Flatpak only.
+	Scope string `toml:"scope"`
+
 	// DefaultRemote specifies the default remote for Flatpak.
 	DefaultRemote string `toml:"default_remote"`
 
@@ -66,24 +243,123 @@ type ManagerConfig struct {
 	// ReviewPKGBUILD shows PKGBUILD review before building. AUR only.
 	ReviewPKGBUILD bool `toml:"review_pkgbuild"`
 
-	// UseSandbox runs AUR builds in a bubblewrap sandbox. AUR only.
+	// UseSandbox runs AUR builds in a sandbox. AUR only.
 	UseSandbox bool `toml:"use_sandbox"`
+
+	// SandboxProfile is the sandbox profile AUR builds run in: "build",
+	// "fetch", "minimal" or a profile from [sandbox.profiles] (default: the
+	// build profile with network access). Native AUR only.
+	SandboxProfile string `toml:"sandbox_profile"`
+
+	// OfflineBuild downloads sources first and then builds in a sandbox
+	// without network access, so prepare() and build() cannot fetch
+	// anything the checksums do not cover. Native AUR only.
+	OfflineBuild bool `toml:"offline_build"`
+
+	// CleanChroot builds in a fresh devtools chroot where only base-devel
+	// and the declared dependencies are installed, for builds that do not
+	// depend on what is installed on the host. Native AUR only.
+	CleanChroot bool `toml:"clean_chroot"`
+
+	// ChrootDir is where the clean chroot is kept (default: chroot/ in the
+	// AUR build cache). Native AUR only.
+	ChrootDir string `toml:"chroot_dir"`
+
+	// PublishRepo adds every package built to a local pacman repository
+	// that other machines can install from. Native AUR only.
+	PublishRepo bool `toml:"publish_repo"`
+
+	// RepoDir is the directory of that repository (default: aur-repo in
+	// the data directory).
+	RepoDir string `toml:"repo_dir"`
+
+	// RepoName is the name of the repository (default: poxy-aur).
+	RepoName string `toml:"repo_name"`
+
+	// RepoServer is the URL of another machine's repository, such as one
+	// served by poxy aur repo serve. Packages it has at the AUR's current
+	// version are installed from it instead of being built. Native AUR
+	// only.
+	RepoServer string `toml:"repo_server"`
+
+	// RepoKey is the GPG key packages of the published repository are
+	// signed with, and the key the packages of repo_server must be signed
+	// with; without it, repo_server is not used. Native AUR only.
+	RepoKey string `toml:"repo_key"`
+
+	// ExtraArgs are passed to the manager's tool on every install and
+	// upgrade, before the package names, e.g. ["--overwrite=*"] for pacman.
+	// --backend-arg adds more for one run.
+	ExtraArgs []string `toml:"extra_args"`
+
+	// RequireApproval only builds PKGBUILD revisions that were approved
+	// through review; approved revisions skip review. Native AUR only.
+	RequireApproval bool `toml:"require_approval"`
+
+	// ApprovalDir is where approval records are kept (default: the data
+	// directory). Point it at an admin-owned directory on shared machines.
+	ApprovalDir string `toml:"approval_dir"`
+
+	// ApprovalKey is a GPG key used to sign approvals; when set, unsigned
+	// or foreign-signed approvals are rejected. Native AUR only.
+	ApprovalKey string `toml:"approval_key"`
+
+	// CacheTTL is how long AUR RPC answers are reused before the AUR is
+	// asked again (default 10m; negative turns the cache off). Native AUR
+	// only.
+	CacheTTL time.Duration `toml:"cache_ttl"`
+
+	// Keyserver is where the unknown PGP keys of source signatures are
+	// looked up and imported from when a build fails on them (default
+	// hkps://keyserver.ubuntu.com). Native AUR only.
+	Keyserver string `toml:"keyserver"`
+
+	// MaxRisk refuses to build PKGBUILDs whose static analysis risk score
+	// (0-100) is above it, unless installed with --force. 0 disables the
+	// check. Native AUR only.
+	MaxRisk int `toml:"max_risk"`
+
+	// UsePnpm uses pnpm instead of npm for global packages if available. npm only.
+	UsePnpm bool `toml:"use_pnpm"`
 }
 
 // Default returns the default configuration.
 func Default() *Config {
 	return &Config{
 		General: GeneralConfig{
-			SourcePriority: []string{"native", "flatpak", "snap", "aur"},
-			AutoConfirm:    false,
-			DryRun:         false,
-			Snapshots:      true, // Enable snapshots by default
-			SmartSearch:    true, // Enable TF-IDF search by default
+			SourcePriority:    []string{"native", "flatpak", "snap", "aur"},
+			AutoConfirm:       false,
+			DryRun:            false,
+			Snapshots:         true, // Enable snapshots by default
+			SmartSearch:       true, // Enable TF-IDF search by default
+			SuggestServices:   true,
+			SuggestShellSetup: true,
+			SearchPrompt:      true,
 		},
 		Output: OutputConfig{
-			Color:   true,
-			Unicode: true,
-			Verbose: false,
+			Color:         true,
+			Unicode:       true,
+			Verbose:       false,
+			ReducedMotion: false,
+		},
+		Policy: PolicyConfig{
+			PromptPrivileges: true,
+		},
+		Limits: LimitsConfig{
+			Search:       50,
+			List:         0, // List everything by default
+			History:      10,
+			SnapshotList: 20,
+		},
+		Timeouts: TimeoutsConfig{
+			Probe: 15 * time.Second,
+		},
+		Daemon: DaemonConfig{
+			Interval:        time.Hour,
+			CatalogInterval: 24 * time.Hour,
+		},
+		Sandbox: SandboxConfig{
+			Backend: "auto",
 		},
 		Managers: map[string]ManagerConfig{
 			"pacman": {
@@ -102,33 +378,55 @@ func Default() *Config {
 				UseNative:      true, // Use native builder by default
 				ReviewPKGBUILD: true, // Show security review by default
 				UseSandbox:     true, // Use sandbox if available
+				MaxRisk:        60,   // Refuse the riskiest PKGBUILDs
 			},
 		},
 		Aliases: map[string]string{},
 	}
 }
 
-// Load loads the configuration from the default path.
-// If the config file doesn't exist, it returns the default configuration.
+// Load loads the configuration from the default path, then the fragments
+// in FragmentDir in name order, each overriding the settings it has.
+// Missing files leave the defaults.
 func Load() (*Config, error) {
-	return LoadFrom(ConfigPath())
+	fragments, err := Fragments(FragmentDir())
+	if err != nil {
+		return nil, err
+	}
+	return load(append([]string{ConfigPath()}, fragments...))
 }
 
-// LoadFrom loads the configuration from a specific path.
+// LoadFrom loads the configuration from a specific path, in the format its
+// extension names (TOML for others). Fragments are not loaded.
 // If the config file doesn't exist, it returns the default configuration.
 func LoadFrom(path string) (*Config, error) {
+	return load([]string{path})
+}
+
+// load merges the settings of the files in order onto the defaults.
+func load(paths []string) (*Config, error) {
 	cfg := Default()
 
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	tree := map[string]any{}
+	for _, path := range paths {
+		fileTree, err := readTree(path)
+		if err != nil {
+			return nil, err
+		}
+		mergeTree(tree, fileTree)
+	}
+	if len(tree) == 0 {
 		return cfg, nil
 	}
 
-	// Parse the config file
-	if _, err := toml.DecodeFile(path, cfg); err != nil {
+	// Decode the merged settings as TOML, whichever format they came in
+	data, err := encodeTree(tree, FormatTOML)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := toml.Decode(string(data), cfg); err != nil {
 		return nil, err
 	}
-
 	return cfg, nil
 }
 
@@ -137,20 +435,18 @@ func (c *Config) Save() error {
 	return c.SaveTo(ConfigPath())
 }
 
-// SaveTo writes the configuration to a specific path.
+// SaveTo writes the configuration to a specific path, in the format its
+// extension names (TOML for others).
 func (c *Config) SaveTo(path string) error {
 	if err := EnsureConfigDir(); err != nil {
 		return err
 	}
 
-	f, err := os.Create(path)
+	data, err := c.Encode(formatOf(path))
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	encoder := toml.NewEncoder(f)
-	return encoder.Encode(c)
+	return os.WriteFile(path, data, 0o644)
 }
 
 // ResolveAlias returns the actual package name for an alias, or the original name if no alias exists.
@@ -179,6 +475,29 @@ func (c *Config) GetManagerConfig(name string) ManagerConfig {
 	return ManagerConfig{}
 }
 
+// InstallScope returns the install scope configured for the named manager:
+// its own scope setting, or else the policy. Empty leaves the choice to
+// the manager.
+func (c *Config) InstallScope(name string) string {
+	if scope := c.GetManagerConfig(name).Scope; scope != "" {
+		return scope
+	}
+	return c.Policy.InstallScope
+}
+
+// IsManagerAllowed returns true if the policy permits using the named manager.
+func (c *Config) IsManagerAllowed(name string) bool {
+	if len(c.Policy.AllowedManagers) == 0 {
+		return true
+	}
+	for _, allowed := range c.Policy.AllowedManagers {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
 // ShouldUseColor returns true if colored output should be used.
 // Respects the NO_COLOR environment variable.
 func (c *Config) ShouldUseColor() bool {
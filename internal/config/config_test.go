@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefault(t *testing.T) {
@@ -28,6 +29,9 @@ func TestDefault(t *testing.T) {
 	if cfg.Output.Verbose {
 		t.Error("expected Verbose to be false by default")
 	}
+	if cfg.Output.ReducedMotion {
+		t.Error("expected ReducedMotion to be false by default")
+	}
 
 	// Check general settings
 	if cfg.General.AutoConfirm {
@@ -36,6 +40,13 @@ func TestDefault(t *testing.T) {
 	if cfg.General.DryRun {
 		t.Error("expected DryRun to be false by default")
 	}
+
+	if cfg.Daemon.Interval != time.Hour {
+		t.Errorf("expected daemon interval 1h, got %s", cfg.Daemon.Interval)
+	}
+	if cfg.Daemon.CatalogInterval != 24*time.Hour {
+		t.Errorf("expected catalog interval 24h, got %s", cfg.Daemon.CatalogInterval)
+	}
 }
 
 func TestResolveAlias(t *testing.T) {
@@ -109,6 +120,43 @@ func TestGetManagerConfig(t *testing.T) {
 	}
 }
 
+func TestIsManagerAllowed(t *testing.T) {
+	cfg := Default()
+
+	// Empty policy allows everything
+	if !cfg.IsManagerAllowed("snap") {
+		t.Error("expected all managers to be allowed by default")
+	}
+
+	cfg.Policy.AllowedManagers = []string{"apt", "flatpak"}
+	if !cfg.IsManagerAllowed("flatpak") {
+		t.Error("expected flatpak to be allowed")
+	}
+	if cfg.IsManagerAllowed("snap") {
+		t.Error("expected snap to be disallowed")
+	}
+}
+
+func TestInstallScope(t *testing.T) {
+	cfg := Default()
+	if scope := cfg.InstallScope("flatpak"); scope != "" {
+		t.Errorf("expected no scope by default, got %q", scope)
+	}
+
+	cfg.Policy.InstallScope = "user"
+	if scope := cfg.InstallScope("flatpak"); scope != "user" {
+		t.Errorf("expected the policy scope, got %q", scope)
+	}
+
+	// The source's own setting wins
+	flatpak := cfg.Managers["flatpak"]
+	flatpak.Scope = "system"
+	cfg.Managers["flatpak"] = flatpak
+	if scope := cfg.InstallScope("flatpak"); scope != "system" {
+		t.Errorf("expected flatpak's own scope, got %q", scope)
+	}
+}
+
 func TestShouldUseColor(t *testing.T) {
 	cfg := &Config{
 		Output: OutputConfig{Color: true},
@@ -176,3 +224,119 @@ func TestLoadNonExistentConfig(t *testing.T) {
 		t.Error("expected default Color to be true")
 	}
 }
+
+func TestLoadLimitsAndTimeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+
+	content := `[limits]
+search = 200
+snapshot_list = 5
+
+[timeouts]
+info = "45s"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+
+	if cfg.Limits.Search != 200 {
+		t.Errorf("expected search limit 200, got %d", cfg.Limits.Search)
+	}
+	if cfg.Limits.SnapshotList != 5 {
+		t.Errorf("expected snapshot list limit 5, got %d", cfg.Limits.SnapshotList)
+	}
+
+	// Unset values keep their defaults
+	if cfg.Limits.History != 10 {
+		t.Errorf("expected default history limit 10, got %d", cfg.Limits.History)
+	}
+
+	if cfg.Timeouts.Info != 45*time.Second {
+		t.Errorf("expected info timeout 45s, got %s", cfg.Timeouts.Info)
+	}
+	if cfg.Timeouts.Search != 0 {
+		t.Errorf("expected no search timeout, got %s", cfg.Timeouts.Search)
+	}
+	if cfg.Timeouts.Probe != 15*time.Second {
+		t.Errorf("expected default probe timeout 15s, got %s", cfg.Timeouts.Probe)
+	}
+}
+
+func TestLoadSandboxProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+
+	content := `[managers.aur]
+sandbox_profile = "offline"
+
+[sandbox.profiles.offline]
+base = "build"
+bind_ro = ["/opt/sdk"]
+env_pass = ["JAVA_HOME"]
+network = false
+
+[sandbox.profiles.rust.env]
+CARGO_HOME = "/tmp/cargo"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+
+	if got := cfg.GetManagerConfig("aur").SandboxProfile; got != "offline" {
+		t.Errorf("expected aur sandbox profile offline, got %q", got)
+	}
+
+	offline, ok := cfg.Sandbox.Profiles["offline"]
+	if !ok {
+		t.Fatal("expected an offline sandbox profile")
+	}
+	if len(offline.BindReadOnly) != 1 || offline.BindReadOnly[0] != "/opt/sdk" {
+		t.Errorf("unexpected read-only binds: %v", offline.BindReadOnly)
+	}
+	if offline.Network == nil || *offline.Network {
+		t.Errorf("expected network to be disabled, got %v", offline.Network)
+	}
+
+	rust := cfg.Sandbox.Profiles["rust"]
+	if rust.Network != nil {
+		t.Errorf("expected network to be unset, got %v", *rust.Network)
+	}
+	if rust.Env["CARGO_HOME"] != "/tmp/cargo" {
+		t.Errorf("expected CARGO_HOME to be set, got %v", rust.Env)
+	}
+}
+
+func TestLoadExtraArgs(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+
+	content := `[managers.pacman]
+extra_args = ["--overwrite=*", "--needed"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+
+	got := cfg.GetManagerConfig("pacman").ExtraArgs
+	if len(got) != 2 || got[0] != "--overwrite=*" || got[1] != "--needed" {
+		t.Errorf("pacman extra_args = %v", got)
+	}
+	if got := cfg.GetManagerConfig("apt").ExtraArgs; len(got) != 0 {
+		t.Errorf("apt extra_args = %v, want none", got)
+	}
+}
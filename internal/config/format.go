@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a config file format.
+type Format string
+
+const (
+	FormatTOML Format = "toml"
+	FormatYAML Format = "yaml"
+)
+
+// Formats lists the supported formats, in the order config files are
+// looked for.
+var Formats = []Format{FormatTOML, FormatYAML}
+
+// ParseFormat parses a format name, accepting "yml" for YAML.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "toml":
+		return FormatTOML, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("unknown config format %q (want toml or yaml)", s)
+}
+
+// FormatOf returns the format of a config file from its extension.
+func FormatOf(path string) (Format, error) {
+	format, err := ParseFormat(filepath.Ext(path))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return format, nil
+}
+
+// Ext returns the file extension of the format, with the dot.
+func (f Format) Ext() string {
+	return "." + string(f)
+}
+
+// Convert converts the contents of a config file from one format to
+// another. Only the settings the file has are converted, not the defaults
+// of the rest; comments are lost.
+func Convert(data []byte, from, to Format) ([]byte, error) {
+	tree, err := decodeTree(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return encodeTree(tree, to)
+}
+
+// Encode returns the whole configuration in the format.
+func (c *Config) Encode(format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, err
+	}
+	if format == FormatTOML {
+		return buf.Bytes(), nil
+	}
+	return Convert(buf.Bytes(), FormatTOML, format)
+}
+
+// Fragments returns the config fragments in dir, sorted by name: files of
+// a supported format, such as 10-company.toml. Hidden files are skipped.
+func Fragments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if _, err := ParseFormat(filepath.Ext(name)); err == nil {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// formatOf returns the format of a config file, taking files of other
+// extensions, such as a --config of poxyrc, for TOML.
+func formatOf(path string) Format {
+	if format, err := FormatOf(path); err == nil {
+		return format
+	}
+	return FormatTOML
+}
+
+// readTree reads a config file into a tree of settings. A missing file is
+// an empty tree.
+func readTree(path string) (map[string]any, error) {
+	format := formatOf(path)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tree, err := decodeTree(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// decodeTree parses a config file into a tree of settings.
+func decodeTree(data []byte, format Format) (map[string]any, error) {
+	tree := map[string]any{}
+	switch format {
+	case FormatTOML:
+		if _, err := toml.Decode(string(data), &tree); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+		if tree == nil {
+			tree = map[string]any{} // An empty document
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+	return tree, nil
+}
+
+// encodeTree writes a tree of settings in the format.
+func encodeTree(tree map[string]any, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatTOML:
+		if err := toml.NewEncoder(&buf).Encode(tree); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(tree); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeTree merges src into dst: tables are merged key by key, and any
+// other setting in src replaces the one in dst.
+func mergeTree(dst, src map[string]any) {
+	for key, value := range src {
+		if table, ok := value.(map[string]any); ok {
+			if existing, ok := dst[key].(map[string]any); ok {
+				mergeTree(existing, table)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLoadYAML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `general:
+  source_priority: [native, flatpak]
+timeouts:
+  probe: 5s
+managers:
+  aur:
+    max_risk: 40
+aliases:
+  vim: neovim
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	if len(cfg.General.SourcePriority) != 2 || cfg.General.SourcePriority[1] != "flatpak" {
+		t.Errorf("expected source priority [native flatpak], got %v", cfg.General.SourcePriority)
+	}
+	if cfg.Timeouts.Probe != 5*time.Second {
+		t.Errorf("expected probe timeout 5s, got %s", cfg.Timeouts.Probe)
+	}
+	if got := cfg.GetManagerConfig("aur").MaxRisk; got != 40 {
+		t.Errorf("expected aur max_risk 40, got %d", got)
+	}
+	if cfg.ResolveAlias("vim") != "neovim" {
+		t.Error("expected the vim alias")
+	}
+
+	// Unset values keep their defaults
+	if cfg.Limits.Search != 50 {
+		t.Errorf("expected default search limit 50, got %d", cfg.Limits.Search)
+	}
+}
+
+func TestLoadFragments(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG not used on this platform")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	files := map[string]string{
+		"config.toml":            "[output]\ncolor = false\n\n[limits]\nsearch = 20\nhistory = 5\n",
+		"conf.d/10-company.yaml": "policy:\n  allowed_managers: [apt, flatpak]\nlimits:\n  search: 30\n",
+		"conf.d/20-team.toml":    "[limits]\nsearch = 40\n",
+		"conf.d/notes.txt":       "not a fragment",
+		"conf.d/.hidden.toml":    "[limits]\nsearch = 99\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(ConfigDir(), name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Output.Color {
+		t.Error("expected color off from the config file")
+	}
+	if cfg.Limits.Search != 40 {
+		t.Errorf("expected the last fragment's search limit 40, got %d", cfg.Limits.Search)
+	}
+	if cfg.Limits.History != 5 {
+		t.Errorf("expected the config file's history limit 5 to stay, got %d", cfg.Limits.History)
+	}
+	if !cfg.IsManagerAllowed("apt") || cfg.IsManagerAllowed("snap") {
+		t.Errorf("expected the company fragment's allowed managers, got %v", cfg.Policy.AllowedManagers)
+	}
+}
+
+func TestConfigPathYAML(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG not used on this platform")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if filepath.Base(ConfigPath()) != "config.toml" {
+		t.Errorf("ConfigPath() = %s, want config.toml when there is none", ConfigPath())
+	}
+	if err := EnsureConfigDir(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ConfigDir(), "config.yml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(ConfigPath()) != "config.yml" {
+		t.Errorf("ConfigPath() = %s, want the existing config.yml", ConfigPath())
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tomlData := []byte("[general]\nauto_confirm = true\n\n[timeouts]\nprobe = \"20s\"\n")
+
+	yamlData, err := Convert(tomlData, FormatTOML, FormatYAML)
+	if err != nil {
+		t.Fatalf("Convert() to YAML error: %v", err)
+	}
+	back, err := Convert(yamlData, FormatYAML, FormatTOML)
+	if err != nil {
+		t.Fatalf("Convert() to TOML error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, back, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	if !cfg.General.AutoConfirm || cfg.Timeouts.Probe != 20*time.Second {
+		t.Errorf("settings lost in conversion:\n%s", back)
+	}
+}
+
+func TestSaveToYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := Default()
+	cfg.Aliases["test"] = "test-package"
+	if err := cfg.SaveTo(path); err != nil {
+		t.Fatalf("SaveTo() error: %v", err)
+	}
+
+	loaded, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	if loaded.ResolveAlias("test") != "test-package" || loaded.Timeouts.Probe != cfg.Timeouts.Probe {
+		t.Error("loaded config differs from the saved one")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]Format{"toml": FormatTOML, ".yml": FormatYAML, "YAML": FormatYAML} {
+		if got, err := ParseFormat(input); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %s, %v; want %s", input, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("dhall"); err == nil {
+		t.Error("ParseFormat() accepted an unsupported format")
+	}
+}
@@ -1,16 +1,37 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
+// ErrDataDirReadOnly is returned when a database has to be created but the
+// data directory is not writable, as on read-only homes or under sudo with
+// another user's home.
+var ErrDataDirReadOnly = errors.New("data directory is not writable")
+
 const (
 	appName      = "poxy"
-	configFile   = "config.toml"
+	configName   = "config"
+	configFile   = configName + ".toml"
+	fragmentDir  = "conf.d"
 	historyFile  = "history.db"
 	snapshotFile = "snapshots.db"
+	pinFile      = "pins.db"
+	consentFile  = "privileges.json"
+	approvalDir  = "aur-approvals"
+	socketFile   = "daemon.sock"
+	themeDir     = "themes"
+	mappingDir   = "mappings"
+	anomalyFile  = "parser-anomalies.jsonl"
+	aurWatchFile = "aur-maintainers.json"
+	logFile      = "poxy.log"
+	aurCacheDir  = "aur-rpc"
+	aurRepoDir   = "aur-repo"
 )
 
 // ConfigDir returns the platform-specific configuration directory for poxy.
@@ -31,8 +52,32 @@ func ConfigDir() string {
 	}
 }
 
-// DataDir returns the platform-specific data directory for poxy.
+// dataRoot is the root of the system whose data directory is used, when
+// operating on a system other than the running one.
+var dataRoot string
+
+// SetDataRoot makes DataDir return the data directory inside the system
+// mounted at root, so history, snapshots and pins are those of that system.
+// An empty root restores the running system's.
+func SetDataRoot(root string) {
+	dataRoot = root
+}
+
+// DataDir returns the platform-specific data directory for poxy, inside
+// the root set with SetDataRoot if any.
 func DataDir() string {
+	dir := hostDataDir()
+	if dataRoot == "" {
+		return dir
+	}
+	if rel, err := filepath.Rel(dataRoot, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+		return dir // XDG_DATA_HOME already points into the root
+	}
+	return filepath.Join(dataRoot, dir)
+}
+
+// hostDataDir returns the data directory of the running system.
+func hostDataDir() string {
 	switch runtime.GOOS {
 	case "darwin":
 		home, _ := os.UserHomeDir() //nolint:errcheck
@@ -49,9 +94,73 @@ func DataDir() string {
 	}
 }
 
-// ConfigPath returns the full path to the config file.
+// CacheDir returns the platform-specific cache directory for poxy.
+func CacheDir() string {
+	switch runtime.GOOS {
+	case "darwin":
+		home, _ := os.UserHomeDir() //nolint:errcheck
+		return filepath.Join(home, "Library", "Caches", appName)
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), appName, "cache")
+	default: // linux and others
+		// Respect XDG_CACHE_HOME if set
+		if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+			return filepath.Join(xdg, appName)
+		}
+		home, _ := os.UserHomeDir() //nolint:errcheck
+		return filepath.Join(home, ".cache", appName)
+	}
+}
+
+// StateDir returns the platform-specific directory for poxy's logs.
+func StateDir() string {
+	switch runtime.GOOS {
+	case "darwin":
+		home, _ := os.UserHomeDir() //nolint:errcheck
+		return filepath.Join(home, "Library", "Logs", appName)
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), appName, "logs")
+	default: // linux and others
+		// Respect XDG_STATE_HOME if set
+		if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+			return filepath.Join(xdg, appName)
+		}
+		home, _ := os.UserHomeDir() //nolint:errcheck
+		return filepath.Join(home, ".local", "state", appName)
+	}
+}
+
+// LogPath returns the full path to the operation log.
+func LogPath() string {
+	return filepath.Join(StateDir(), logFile)
+}
+
+// ConfigPath returns the full path to the config file: config.toml, or
+// config.yaml or config.yml if only that exists.
 func ConfigPath() string {
-	return filepath.Join(ConfigDir(), configFile)
+	dir := ConfigDir()
+	for _, name := range []string{configFile, configName + ".yaml", configName + ".yml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, configFile)
+}
+
+// FragmentDir returns the directory of config fragments, which override
+// the config file; see Load.
+func FragmentDir() string {
+	return filepath.Join(ConfigDir(), fragmentDir)
+}
+
+// ThemeDir returns the directory searched for theme files by name.
+func ThemeDir() string {
+	return filepath.Join(ConfigDir(), themeDir)
+}
+
+// MappingDir returns the directory searched for package mapping datasets.
+func MappingDir() string {
+	return filepath.Join(ConfigDir(), mappingDir)
 }
 
 // HistoryPath returns the full path to the history database.
@@ -64,6 +173,55 @@ func SnapshotPath() string {
 	return filepath.Join(DataDir(), snapshotFile)
 }
 
+// PinPath returns the full path to the package pin database.
+func PinPath() string {
+	return filepath.Join(DataDir(), pinFile)
+}
+
+// AnomalyPath returns the full path to the parser anomaly log.
+func AnomalyPath() string {
+	return filepath.Join(DataDir(), anomalyFile)
+}
+
+// AURWatchPath returns the full path to the record of AUR package
+// maintainers.
+func AURWatchPath() string {
+	return filepath.Join(DataDir(), aurWatchFile)
+}
+
+// AURCacheDir returns the directory AUR RPC responses are cached in.
+func AURCacheDir() string {
+	return filepath.Join(WritableCacheDir(), aurCacheDir)
+}
+
+// AURRepoDir returns the default directory of the repository built AUR
+// packages are published to.
+func AURRepoDir() string {
+	return filepath.Join(DataDir(), aurRepoDir)
+}
+
+// ConsentPath returns the full path to the privileged-operations acceptance record.
+func ConsentPath() string {
+	return filepath.Join(DataDir(), consentFile)
+}
+
+// ApprovalDir returns the default directory for AUR PKGBUILD approval records.
+func ApprovalDir() string {
+	return filepath.Join(DataDir(), approvalDir)
+}
+
+// SocketPath returns the default path of the daemon status socket. It lives
+// in XDG_RUNTIME_DIR when set, so it is private to the user and cleared on
+// logout, and in the data directory otherwise.
+func SocketPath() string {
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		if xdg := os.Getenv("XDG_RUNTIME_DIR"); xdg != "" {
+			return filepath.Join(xdg, appName, socketFile)
+		}
+	}
+	return filepath.Join(DataDir(), socketFile)
+}
+
 // EnsureConfigDir creates the config directory if it doesn't exist.
 func EnsureConfigDir() error {
 	return os.MkdirAll(ConfigDir(), 0755)
@@ -73,3 +231,38 @@ func EnsureConfigDir() error {
 func EnsureDataDir() error {
 	return os.MkdirAll(DataDir(), 0755)
 }
+
+// DataDirWritable reports whether files can be created in the data
+// directory, creating it if needed.
+func DataDirWritable() bool {
+	return dirWritable(DataDir())
+}
+
+// WritableCacheDir returns the cache directory, or a per-user directory
+// under the system temp directory if the cache directory is not writable.
+func WritableCacheDir() string {
+	if dirWritable(CacheDir()) {
+		return CacheDir()
+	}
+	return TempCacheDir()
+}
+
+// TempCacheDir returns the per-user cache directory used in place of
+// unwritable data and cache directories. Its contents do not persist.
+func TempCacheDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d", appName, os.Getuid()))
+}
+
+// dirWritable reports whether dir exists or can be created, and accepts
+// new files.
+func dirWritable(dir string) bool {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false
+	}
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return os.Remove(f.Name()) == nil
+}
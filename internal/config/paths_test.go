@@ -51,6 +51,63 @@ func TestDataDir(t *testing.T) {
 	}
 }
 
+func TestCacheDir(t *testing.T) {
+	dir := CacheDir()
+
+	if !strings.Contains(dir, "poxy") {
+		t.Errorf("CacheDir() should contain 'poxy': %s", dir)
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+	if got := CacheDir(); got != filepath.Join("/tmp/xdg-cache", "poxy") {
+		t.Errorf("CacheDir() with XDG_CACHE_HOME = %s", got)
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	if filepath.Base(LogPath()) != "poxy.log" {
+		t.Errorf("LogPath() = %s, want a poxy.log", LogPath())
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	home, _ := os.UserHomeDir() //nolint:errcheck
+	t.Setenv("XDG_STATE_HOME", "")
+	if got := StateDir(); got != filepath.Join(home, ".local", "state", "poxy") {
+		t.Errorf("StateDir() = %s, want ~/.local/state/poxy", got)
+	}
+	t.Setenv("XDG_STATE_HOME", "/tmp/xdg-state")
+	if got := LogPath(); got != filepath.Join("/tmp/xdg-state", "poxy", "poxy.log") {
+		t.Errorf("LogPath() with XDG_STATE_HOME = %s", got)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	if !strings.HasSuffix(SocketPath(), "daemon.sock") {
+		t.Errorf("SocketPath() should end with 'daemon.sock': %s", SocketPath())
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := SocketPath(); got != filepath.Join("/run/user/1000", "poxy", "daemon.sock") {
+		t.Errorf("SocketPath() with XDG_RUNTIME_DIR = %s", got)
+	}
+
+	d := DaemonConfig{Socket: "/tmp/custom.sock"}
+	if got := d.SocketPath(); got != "/tmp/custom.sock" {
+		t.Errorf("DaemonConfig.SocketPath() = %s, want override", got)
+	}
+}
+
 func TestConfigPath(t *testing.T) {
 	path := ConfigPath()
 
@@ -152,3 +209,60 @@ func TestXDGOverride(t *testing.T) {
 	}
 	os.Setenv("XDG_DATA_HOME", originalData)
 }
+
+func TestDataDirWritable(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if !DataDirWritable() {
+		t.Error("DataDirWritable() = false for a temp directory")
+	}
+
+	// A file in place of the data directory's parent cannot be created
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_DATA_HOME", blocker)
+	if DataDirWritable() {
+		t.Error("DataDirWritable() = true below a regular file")
+	}
+
+	t.Setenv("XDG_CACHE_HOME", blocker)
+	if got := WritableCacheDir(); got != TempCacheDir() {
+		t.Errorf("WritableCacheDir() = %s, want the temp cache dir", got)
+	}
+}
+
+func TestSetDataRoot(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", "/home/user/.local/share")
+	SetDataRoot(root)
+	defer SetDataRoot("")
+
+	want := filepath.Join(root, "home/user/.local/share/poxy")
+	if got := DataDir(); got != want {
+		t.Errorf("DataDir() = %s, want %s", got, want)
+	}
+	if got := HistoryPath(); !strings.HasPrefix(got, root) {
+		t.Errorf("HistoryPath() = %s, want a path inside the root", got)
+	}
+
+	// Data directories already inside the root are kept
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "home/user/.local/share"))
+	if got := DataDir(); got != want {
+		t.Errorf("DataDir() = %s, want %s", got, want)
+	}
+
+	SetDataRoot("")
+	t.Setenv("XDG_DATA_HOME", "/home/user/.local/share")
+	if got := DataDir(); got != "/home/user/.local/share/poxy" {
+		t.Errorf("DataDir() = %s after clearing the root", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Package configfiles finds the configuration files package managers leave
+// next to a modified config when a package ships a new version of it
+// (.pacnew, .rpmnew, .dpkg-dist) or removes it (.pacsave, .rpmsave,
+// .dpkg-old), and resolves them.
+package configfiles
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultDir is where package managers install configuration files.
+const DefaultDir = "/etc"
+
+// Kind tells which side of a leftover pair is the user's version.
+type Kind int
+
+const (
+	// KindNew is a new default from the package; the live file is the
+	// user's version.
+	KindNew Kind = iota
+	// KindSaved is the user's version saved aside; the live file, if any,
+	// is the package's default.
+	KindSaved
+)
+
+// suffixes maps each leftover suffix to its kind
+var suffixes = map[string]Kind{
+	".pacnew":    KindNew,
+	".rpmnew":    KindNew,
+	".dpkg-dist": KindNew,
+	".dpkg-new":  KindNew,
+	".pacsave":   KindSaved,
+	".rpmsave":   KindSaved,
+	".dpkg-old":  KindSaved,
+}
+
+// File is a leftover configuration file.
+type File struct {
+	Path   string // The leftover, e.g. /etc/pacman.conf.pacnew
+	Target string // The live file it belongs to, e.g. /etc/pacman.conf
+	Suffix string
+	Kind   Kind
+}
+
+// Parse returns the leftover described by path, if its name has a
+// leftover suffix.
+func Parse(path string) (File, bool) {
+	for suffix, kind := range suffixes {
+		target, found := strings.CutSuffix(path, suffix)
+		if found && target != "" && !os.IsPathSeparator(target[len(target)-1]) {
+			return File{Path: path, Target: target, Suffix: suffix, Kind: kind}, true
+		}
+	}
+	return File{}, false
+}
+
+// Describe explains the leftover in a few words.
+func (f File) Describe() string {
+	if f.Kind == KindNew {
+		return "new default from the package"
+	}
+	if !f.TargetExists() {
+		return "your version, saved when the package was removed"
+	}
+	return "your version, saved when the package replaced it"
+}
+
+// TargetExists reports whether the live file exists.
+func (f File) TargetExists() bool {
+	_, err := os.Lstat(f.Target)
+	return err == nil
+}
+
+// Scan walks dir for leftover files, sorted by path. Directories that
+// cannot be read are skipped.
+func Scan(ctx context.Context, dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if f, ok := Parse(path); ok {
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Diff returns a unified diff from the live file to the leftover, or ""
+// if they are identical. A missing live file diffs as empty.
+func (f File) Diff(ctx context.Context) (string, error) {
+	target := f.Target
+	if !f.TargetExists() {
+		target = os.DevNull
+	}
+
+	cmd := exec.CommandContext(ctx, "diff", "-u", "--label", f.Target, "--label", f.Path, target, f.Path)
+	out, err := cmd.Output()
+
+	// diff exits with 1 when the files differ
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return string(out), nil
+	}
+	if err != nil {
+		if exitErr != nil && len(exitErr.Stderr) > 0 {
+			return "", errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ReplaceArgs returns the command that makes the leftover the live file.
+func (f File) ReplaceArgs() []string {
+	return []string{"mv", "-f", f.Path, f.Target}
+}
+
+// KeepArgs returns the command that keeps the live file and deletes the
+// leftover.
+func (f File) KeepArgs() []string {
+	return []string{"rm", "-f", f.Path}
+}
+
+// MergeArgs returns the command that opens the live file and the leftover
+// in a merge tool: $DIFFPROG, as used by pacdiff, or vim -d.
+func (f File) MergeArgs() []string {
+	tool := strings.Fields(os.Getenv("DIFFPROG"))
+	if len(tool) == 0 {
+		tool = []string{"vim", "-d"}
+	}
+	return append(tool, f.Target, f.Path)
+}
@@ -0,0 +1,114 @@
+package configfiles
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path   string
+		target string
+		kind   Kind
+		ok     bool
+	}{
+		{"/etc/pacman.conf.pacnew", "/etc/pacman.conf", KindNew, true},
+		{"/etc/ssh/sshd_config.rpmsave", "/etc/ssh/sshd_config", KindSaved, true},
+		{"/etc/default/grub.dpkg-dist", "/etc/default/grub", KindNew, true},
+		{"/etc/pacman.conf", "", KindNew, false},
+		{"/etc/.pacnew", "", KindNew, false},
+	}
+
+	for _, tt := range tests {
+		f, ok := Parse(tt.path)
+		if ok != tt.ok {
+			t.Errorf("Parse(%q) ok = %v, want %v", tt.path, ok, tt.ok)
+			continue
+		}
+		if ok && (f.Target != tt.target || f.Kind != tt.kind) {
+			t.Errorf("Parse(%q) = %+v, want target %q kind %v", tt.path, f, tt.target, tt.kind)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"pacman.conf", "pacman.conf.pacnew", "ssh/sshd_config.pacsave", "hosts"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Scan(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Scan() found %d files, want 2: %+v", len(files), files)
+	}
+	if !files[0].TargetExists() || files[0].Kind != KindNew {
+		t.Errorf("pacnew = %+v, want a new default with an existing target", files[0])
+	}
+	if files[1].TargetExists() || files[1].Kind != KindSaved {
+		t.Errorf("pacsave = %+v, want a saved file without a target", files[1])
+	}
+
+	if _, err := Scan(context.Background(), filepath.Join(dir, "missing")); err == nil {
+		t.Error("Scan() of a missing directory error = nil, want error")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app.conf")
+	leftover := target + ".rpmnew"
+	if err := os.WriteFile(target, []byte("a=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(leftover, []byte("a=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := Parse(leftover)
+	diff, err := f.Diff(context.Background())
+	if err != nil || diff != "" {
+		t.Errorf("Diff() of identical files = %q, %v; want no diff", diff, err)
+	}
+
+	if err := os.WriteFile(leftover, []byte("a=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = f.Diff(context.Background())
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if !strings.Contains(diff, "-a=1") || !strings.Contains(diff, "+a=2") {
+		t.Errorf("Diff() = %q, want the changed line", diff)
+	}
+}
+
+func TestMergeArgs(t *testing.T) {
+	f := File{Path: "/etc/x.pacnew", Target: "/etc/x"}
+
+	t.Setenv("DIFFPROG", "meld --newtab")
+	if got := strings.Join(f.MergeArgs(), " "); got != "meld --newtab /etc/x /etc/x.pacnew" {
+		t.Errorf("MergeArgs() = %q", got)
+	}
+
+	t.Setenv("DIFFPROG", "")
+	if got := strings.Join(f.MergeArgs(), " "); got != "vim -d /etc/x /etc/x.pacnew" {
+		t.Errorf("MergeArgs() without DIFFPROG = %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// dialTimeout keeps clients such as shell prompts from blocking when
+	// the daemon is not running.
+	dialTimeout = 200 * time.Millisecond
+
+	// ioTimeout bounds a whole request once connected.
+	ioTimeout = 2 * time.Second
+)
+
+// ErrNotRunning is returned when no daemon is listening on the socket.
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Query returns the status of the daemon listening on socket.
+func Query(socket string) (*Status, error) {
+	return Send(socket, CmdStatus)
+}
+
+// Send sends a command to the daemon and returns its status.
+func Send(socket, cmd string) (*Status, error) {
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout)) //nolint:errcheck
+
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return nil, fmt.Errorf("failed to send %s to daemon: %w", cmd, err)
+	}
+
+	var status Status
+	if err := json.NewDecoder(conn).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to read daemon status: %w", err)
+	}
+
+	return &status, nil
+}
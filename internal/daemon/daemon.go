@@ -0,0 +1,235 @@
+// Package daemon keeps poxy's package index and update counts fresh in the
+// background and serves their status over a unix socket, so the TUI and
+// shell prompts can read them without querying package managers.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commands understood by the daemon socket. Each connection sends one
+// command line and receives the daemon status as JSON.
+const (
+	CmdStatus  = "status"
+	CmdRefresh = "refresh"
+	CmdStop    = "stop"
+)
+
+// ErrAlreadyRunning is returned when another daemon is serving the socket.
+var ErrAlreadyRunning = errors.New("daemon is already running")
+
+// Status is the daemon state reported over the socket.
+type Status struct {
+	PID             int            `json:"pid"`
+	StartedAt       time.Time      `json:"started_at"`
+	Interval        string         `json:"interval"`
+	Refreshing      bool           `json:"refreshing"`
+	LastRefresh     time.Time      `json:"last_refresh"`
+	NextRefresh     time.Time      `json:"next_refresh"`
+	IndexSize       int            `json:"index_size"`
+	Updates         int            `json:"updates"`
+	UpdatesBySource map[string]int `json:"updates_by_source,omitempty"`
+	LastError       string         `json:"last_error,omitempty"`
+
+	// Catalogs is when each source's catalog of available packages was
+	// last downloaded
+	Catalogs map[string]time.Time `json:"catalogs,omitempty"`
+
+	// AURAlerts is the number of installed AUR packages flagged for a
+	// maintainer change
+	AURAlerts int `json:"aur_alerts,omitempty"`
+}
+
+// Result is what a refresh produced.
+type Result struct {
+	IndexSize       int
+	UpdatesBySource map[string]int
+	Catalogs        map[string]time.Time
+	AURAlerts       int
+}
+
+// RefreshFunc rebuilds the index and counts available updates.
+type RefreshFunc func(ctx context.Context) (Result, error)
+
+// Daemon periodically runs a refresh and serves its status.
+type Daemon struct {
+	socket   string
+	interval time.Duration
+	refresh  RefreshFunc
+
+	mu      sync.Mutex
+	status  Status
+	trigger chan struct{}
+	stop    context.CancelFunc
+}
+
+// New creates a daemon that refreshes every interval and listens on socket.
+func New(socket string, interval time.Duration, refresh RefreshFunc) *Daemon {
+	return &Daemon{
+		socket:   socket,
+		interval: interval,
+		refresh:  refresh,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Status returns a copy of the current status.
+func (d *Daemon) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := d.status
+	s.UpdatesBySource = make(map[string]int, len(d.status.UpdatesBySource))
+	for k, v := range d.status.UpdatesBySource {
+		s.UpdatesBySource[k] = v
+	}
+	return s
+}
+
+// Run refreshes immediately and then on every interval until ctx is
+// cancelled or a stop command is received.
+func (d *Daemon) Run(ctx context.Context) error {
+	ln, err := d.listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(d.socket)
+
+	ctx, d.stop = context.WithCancel(ctx)
+	defer d.stop()
+
+	d.mu.Lock()
+	d.status = Status{
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+		Interval:  d.interval.String(),
+	}
+	d.mu.Unlock()
+
+	go d.serve(ln)
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		case <-d.trigger:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+
+		d.runRefresh(ctx)
+		timer.Reset(d.interval)
+	}
+}
+
+// runRefresh performs one refresh and records its outcome.
+func (d *Daemon) runRefresh(ctx context.Context) {
+	d.mu.Lock()
+	d.status.Refreshing = true
+	d.mu.Unlock()
+
+	result, err := d.refresh(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.status.Refreshing = false
+	d.status.LastRefresh = time.Now()
+	d.status.NextRefresh = d.status.LastRefresh.Add(d.interval)
+	if err != nil {
+		d.status.LastError = err.Error()
+		return
+	}
+
+	d.status.LastError = ""
+	d.status.IndexSize = result.IndexSize
+	d.status.UpdatesBySource = result.UpdatesBySource
+	d.status.Catalogs = result.Catalogs
+	d.status.AURAlerts = result.AURAlerts
+	d.status.Updates = 0
+	for _, n := range result.UpdatesBySource {
+		d.status.Updates += n
+	}
+}
+
+// listen opens the socket, replacing a stale one left by a crashed daemon.
+func (d *Daemon) listen() (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(d.socket), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(d.socket); err == nil {
+		if conn, err := net.DialTimeout("unix", d.socket, dialTimeout); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w on %s", ErrAlreadyRunning, d.socket)
+		}
+		os.Remove(d.socket)
+	}
+
+	ln, err := net.Listen("unix", d.socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", d.socket, err)
+	}
+	if err := os.Chmod(d.socket, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to secure socket: %w", err)
+	}
+
+	return ln, nil
+}
+
+// serve answers socket connections until the listener is closed.
+func (d *Daemon) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+// handle answers a single command.
+func (d *Daemon) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout)) //nolint:errcheck
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return
+	}
+
+	switch strings.TrimSpace(line) {
+	case CmdRefresh:
+		select {
+		case d.trigger <- struct{}{}:
+		default: // A refresh is already pending
+		}
+	case CmdStop:
+		defer d.stop()
+	}
+
+	_ = json.NewEncoder(conn).Encode(d.Status()) //nolint:errcheck
+}
@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDaemonStatusAndRefresh(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+
+	var refreshes atomic.Int32
+	d := New(socket, time.Hour, func(context.Context) (Result, error) {
+		refreshes.Add(1)
+		return Result{IndexSize: 42, UpdatesBySource: map[string]int{"apt": 3, "flatpak": 1}, AURAlerts: 2}, nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- d.Run(context.Background()) }()
+
+	status := waitFor(t, socket, func(s *Status) bool { return !s.LastRefresh.IsZero() })
+	if status.Updates != 4 || status.IndexSize != 42 {
+		t.Errorf("status = %+v, want 4 updates and 42 indexed", status)
+	}
+	if status.UpdatesBySource["apt"] != 3 {
+		t.Errorf("apt updates = %d, want 3", status.UpdatesBySource["apt"])
+	}
+	if status.AURAlerts != 2 {
+		t.Errorf("AUR alerts = %d, want 2", status.AURAlerts)
+	}
+
+	if _, err := Send(socket, CmdRefresh); err != nil {
+		t.Fatalf("Send(refresh) error = %v", err)
+	}
+	waitFor(t, socket, func(*Status) bool { return refreshes.Load() >= 2 })
+
+	if err := New(socket, time.Hour, nil).Run(context.Background()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Run() error = %v, want ErrAlreadyRunning", err)
+	}
+
+	if _, err := Send(socket, CmdStop); err != nil {
+		t.Fatalf("Send(stop) error = %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+
+	if _, err := Query(socket); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Query() after stop error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestDaemonRefreshError(t *testing.T) {
+	d := New("", time.Hour, func(context.Context) (Result, error) {
+		return Result{}, errors.New("boom")
+	})
+	d.runRefresh(context.Background())
+
+	if s := d.Status(); s.LastError != "boom" || s.LastRefresh.IsZero() {
+		t.Errorf("status = %+v, want error recorded", s)
+	}
+}
+
+// waitFor polls the daemon until cond holds.
+func waitFor(t *testing.T, socket string, cond func(*Status) bool) *Status {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s, err := Query(socket); err == nil && cond(s) {
+			return s
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for daemon")
+	return nil
+}
@@ -0,0 +1,44 @@
+package doctor
+
+import (
+	"context"
+
+	"poxy/pkg/manager/native"
+)
+
+func init() {
+	Register(Check{
+		Name:        "apt-broken",
+		Description: "No half-installed packages or broken dependencies",
+		Managers:    []string{"apt"},
+		Run:         checkAptBroken,
+	})
+}
+
+func checkAptBroken(ctx context.Context, env *Env) Result {
+	mgr, ok := env.Available("apt")
+	if !ok {
+		return Skip("APT is not available")
+	}
+	apt, ok := mgr.(*native.APT)
+	if !ok {
+		return Skip("APT is not available")
+	}
+
+	audit, fixes, err := apt.BrokenPackages(ctx)
+	if err != nil {
+		return Skip("%v", err)
+	}
+
+	switch {
+	case len(audit) > 0:
+		return Fail("%d package(s) are not fully installed", len(audit)).
+			WithDetails(audit...).
+			WithFix("sudo dpkg --configure -a && sudo apt-get install -f")
+	case len(fixes) > 0:
+		return Fail("Broken dependencies; apt will refuse to install or upgrade").
+			WithDetails(fixes...).
+			WithFix("sudo apt-get install -f")
+	}
+	return OK("No broken packages")
+}
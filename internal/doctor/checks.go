@@ -0,0 +1,172 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"poxy/internal/configfiles"
+)
+
+const (
+	// staleAfter is how old the newest package database may get
+	staleAfter = 7 * 24 * time.Hour
+
+	// lowDiskSpace and criticalDiskSpace are the free space thresholds for
+	// the package cache filesystem
+	lowDiskSpace      = 2 << 30   // 2 GiB
+	criticalDiskSpace = 512 << 20 // 512 MiB
+)
+
+func init() {
+	Register(Check{
+		Name:        "stale-databases",
+		Description: "Package databases were refreshed recently",
+		Managers:    []string{"pacman", "apt", "dnf", "zypper"},
+		Run:         checkStaleDatabases,
+	})
+	Register(Check{
+		Name:        "disk-space",
+		Description: "Enough free space for the package cache in /var/cache",
+		Run:         checkDiskSpace,
+	})
+	Register(Check{
+		Name:        "config-leftovers",
+		Description: "No unmerged .pacnew, .pacsave, .rpmnew or .dpkg-dist files in /etc",
+		Managers:    []string{"pacman", "apt", "dnf", "zypper"},
+		Run:         checkConfigLeftovers,
+	})
+}
+
+// databaseGlobs are the files each manager rewrites when it refreshes its
+// package databases
+var databaseGlobs = map[string][]string{
+	"pacman": {"/var/lib/pacman/sync/*.db"},
+	"apt":    {"/var/lib/apt/lists/*Release"},
+	"dnf":    {"/var/cache/dnf/*/repodata/repomd.xml", "/var/cache/libdnf5/*/repodata/repomd.xml"},
+	"zypper": {"/var/cache/zypp/raw/*/repodata/repomd.xml"},
+}
+
+func checkStaleDatabases(ctx context.Context, env *Env) Result {
+	var stale, checked []string
+	for _, name := range sortedKeys(databaseGlobs) {
+		if _, ok := env.Available(name); !ok {
+			continue
+		}
+		newest, found := newestModTime(env, databaseGlobs[name])
+		if !found {
+			continue
+		}
+		checked = append(checked, name)
+		if age := env.Now.Sub(newest); age > staleAfter {
+			stale = append(stale, fmt.Sprintf("%s: last refreshed %s ago", name, formatAge(age)))
+		}
+	}
+
+	switch {
+	case len(checked) == 0:
+		return Skip("No package databases found")
+	case len(stale) > 0:
+		fix := "poxy update"
+		if _, ok := env.Available("pacman"); ok {
+			// A plain refresh on Arch sets up a partial upgrade
+			fix = "poxy upgrade"
+		}
+		return Warn("Package databases are more than %d days old", int(staleAfter.Hours()/24)).
+			WithDetails(stale...).
+			WithFix(fix)
+	}
+	return OK("Package databases are up to date")
+}
+
+// newestModTime returns the newest modification time of the files matching
+// any of the globs.
+func newestModTime(env *Env, globs []string) (time.Time, bool) {
+	var newest time.Time
+	found := false
+	for _, glob := range globs {
+		matches, _ := filepath.Glob(env.Path(glob)) //nolint:errcheck
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			found = true
+			if info.ModTime().After(newest) {
+				newest = info.ModTime()
+			}
+		}
+	}
+	return newest, found
+}
+
+// formatAge formats a duration in whole days, or hours below a day.
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
+
+func checkDiskSpace(ctx context.Context, env *Env) Result {
+	path := env.Path("/var/cache")
+	free, err := freeSpace(path)
+	if err != nil {
+		return Skip("Could not check free space: %v", err)
+	}
+
+	switch {
+	case free < criticalDiskSpace:
+		return Fail("Only %s free in %s; downloads will fail", formatBytes(free), path).
+			WithFix("poxy clean --all")
+	case free < lowDiskSpace:
+		return Warn("Only %s free in %s", formatBytes(free), path).
+			WithFix("poxy clean")
+	}
+	return OK("%s free in %s", formatBytes(free), path)
+}
+
+// formatBytes formats a size in binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func checkConfigLeftovers(ctx context.Context, env *Env) Result {
+	files, err := configfiles.Scan(ctx, env.Path(configfiles.DefaultDir))
+	if err != nil {
+		return Skip("%v", err)
+	}
+	if len(files) == 0 {
+		return OK("No unmerged configuration files")
+	}
+
+	leftovers := make([]string, len(files))
+	for i, f := range files {
+		leftovers[i] = f.Path
+	}
+	return Warn("%d configuration file(s) waiting to be merged", len(files)).
+		WithDetails(leftovers...).
+		WithFix("poxy config-files resolve")
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package doctor
+
+import "errors"
+
+// freeSpace is not implemented on Windows, where /var/cache does not exist.
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.New("not supported on Windows")
+}
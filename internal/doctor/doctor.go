@@ -0,0 +1,175 @@
+// Package doctor runs system health checks for poxy doctor. Checks register
+// themselves with Register and each reports a status, the details behind
+// it and a suggested fix.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"poxy/internal/anomaly"
+	"poxy/pkg/manager"
+)
+
+// checkTimeout bounds a single check
+const checkTimeout = 30 * time.Second
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	StatusOK      Status = iota // Nothing to fix
+	StatusWarning               // Worth a look, but nothing is broken yet
+	StatusError                 // Package operations are likely to fail
+	StatusSkipped               // The check could not run
+)
+
+// Result is what a check found.
+type Result struct {
+	Status  Status
+	Summary string
+	Details []string // Affected packages, files and the like
+	Fix     string   // Suggested command or action
+}
+
+// OK returns a passing result.
+func OK(format string, args ...interface{}) Result {
+	return Result{Status: StatusOK, Summary: fmt.Sprintf(format, args...)}
+}
+
+// Warn returns a warning result.
+func Warn(format string, args ...interface{}) Result {
+	return Result{Status: StatusWarning, Summary: fmt.Sprintf(format, args...)}
+}
+
+// Fail returns an error result.
+func Fail(format string, args ...interface{}) Result {
+	return Result{Status: StatusError, Summary: fmt.Sprintf(format, args...)}
+}
+
+// Skip returns a result for a check that could not run.
+func Skip(format string, args ...interface{}) Result {
+	return Result{Status: StatusSkipped, Summary: fmt.Sprintf(format, args...)}
+}
+
+// WithDetails returns the result with details attached.
+func (r Result) WithDetails(details ...string) Result {
+	r.Details = append(r.Details, details...)
+	return r
+}
+
+// WithFix returns the result with a suggested fix.
+func (r Result) WithFix(fix string) Result {
+	r.Fix = fix
+	return r
+}
+
+// Env is the system a check inspects.
+type Env struct {
+	Registry  *manager.Registry
+	Root      string // Filesystem root, "/" on a live system
+	Now       time.Time
+	Anomalies *anomaly.Log // Parser anomalies recorded by poxy, nil if none
+}
+
+// NewEnv returns the environment of the running system.
+func NewEnv(registry *manager.Registry) *Env {
+	return &Env{Registry: registry, Root: "/", Now: time.Now(), Anomalies: anomaly.Open()}
+}
+
+// Path returns an absolute system path inside the environment's root.
+func (e *Env) Path(path string) string {
+	return filepath.Join(e.Root, path)
+}
+
+// Available returns the manager if it is registered and available.
+func (e *Env) Available(name string) (manager.Manager, bool) {
+	if e.Registry == nil {
+		return nil, false
+	}
+	mgr, ok := e.Registry.Get(name)
+	if !ok || !mgr.IsAvailable() {
+		return nil, false
+	}
+	return mgr, true
+}
+
+// Check is a single health check.
+type Check struct {
+	Name        string // Short identifier, e.g. "stale-databases"
+	Description string
+
+	// Managers limits the check to systems where one of these package
+	// managers is available. Empty means every system.
+	Managers []string
+
+	Run func(ctx context.Context, env *Env) Result
+}
+
+// applies reports whether the check is relevant to the environment.
+func (c Check) applies(env *Env) bool {
+	if len(c.Managers) == 0 {
+		return true
+	}
+	for _, name := range c.Managers {
+		if _, ok := env.Available(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var checks = map[string]Check{}
+
+// Register adds a check. Registering a name twice replaces the first check.
+func Register(c Check) {
+	checks[c.Name] = c
+}
+
+// Checks returns all registered checks sorted by name.
+func Checks() []Check {
+	list := make([]Check, 0, len(checks))
+	for _, c := range checks {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Report pairs a check with its result.
+type Report struct {
+	Check  Check
+	Result Result
+}
+
+// Run runs the named checks, or every check relevant to the environment
+// when names is empty. Named checks run even when they do not apply.
+func Run(ctx context.Context, env *Env, names []string) ([]Report, error) {
+	var selected []Check
+	if len(names) == 0 {
+		for _, c := range Checks() {
+			if c.applies(env) {
+				selected = append(selected, c)
+			}
+		}
+	} else {
+		for _, name := range names {
+			c, ok := checks[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown check: %s", name)
+			}
+			selected = append(selected, c)
+		}
+	}
+
+	reports := make([]Report, 0, len(selected))
+	for _, c := range selected {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		reports = append(reports, Report{Check: c, Result: c.Run(checkCtx, env)})
+		cancel()
+	}
+	return reports, nil
+}
@@ -0,0 +1,230 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"poxy/internal/anomaly"
+	"poxy/pkg/manager"
+	"poxy/pkg/sandbox"
+)
+
+// writeFile creates a file below root, including its directories.
+func writeFile(t *testing.T, root, path, content string) string {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return full
+}
+
+func TestRunUnknownCheck(t *testing.T) {
+	if _, err := Run(context.Background(), &Env{Root: t.TempDir()}, []string{"no-such-check"}); err == nil {
+		t.Error("Run() error = nil, want error for an unknown check")
+	}
+}
+
+func TestRunSelectsApplicableChecks(t *testing.T) {
+	// Without a registry only checks that need no package manager apply
+	reports, err := Run(context.Background(), &Env{Root: t.TempDir(), Now: time.Now()}, nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, r := range reports {
+		if len(r.Check.Managers) > 0 {
+			t.Errorf("check %s ran without any of %v", r.Check.Name, r.Check.Managers)
+		}
+	}
+}
+
+func TestPartialUpgrades(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []string
+	}{
+		{
+			name: "full upgrade",
+			log: `[2024-05-01T10:00:00+0200] [PACMAN] synchronizing package lists
+[2024-05-01T10:00:02+0200] [PACMAN] starting full system upgrade
+[2024-05-01T10:00:30+0200] [ALPM] upgraded glibc (2.39-1 -> 2.39-2)
+[2024-05-02T09:00:00+0200] [ALPM] installed htop (3.3.0-1)`,
+		},
+		{
+			name: "install after refresh",
+			log: `[2024-05-01T10:00:00+0200] [PACMAN] synchronizing package lists
+[2024-05-01T10:00:02+0200] [PACMAN] starting full system upgrade
+[2024-05-03T11:00:00+0200] [PACMAN] synchronizing package lists
+[2024-05-03T11:00:10+0200] [ALPM] installed htop (3.3.0-1)
+[2024-05-03T11:00:11+0200] [ALPM] upgraded ncurses (6.4-1 -> 6.5-1)`,
+			want: []string{"htop", "ncurses"},
+		},
+		{
+			name: "fixed by a later full upgrade",
+			log: `[2024-05-03T11:00:00+0200] [PACMAN] synchronizing package lists
+[2024-05-03T11:00:10+0200] [ALPM] installed htop (3.3.0-1)
+[2024-05-04T08:00:00+0200] [PACMAN] synchronizing package lists
+[2024-05-04T08:00:02+0200] [PACMAN] starting full system upgrade`,
+		},
+		{
+			name: "refresh only",
+			log:  `[2024-05-03T11:00:00+0200] [PACMAN] synchronizing package lists`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := partialUpgrades(strings.NewReader(tt.log))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("partialUpgrades() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPacmanLock(t *testing.T) {
+	root := t.TempDir()
+	env := &Env{Root: root}
+	ctx := context.Background()
+
+	if r := checkPacmanLock(ctx, env); r.Status != StatusOK {
+		t.Errorf("without lock: status = %v, want OK", r.Status)
+	}
+
+	writeFile(t, root, pacmanLock, "")
+	r := checkPacmanLock(ctx, env)
+	if r.Status != StatusError || r.Fix == "" {
+		t.Errorf("stale lock: status = %v, fix = %q; want an error with a fix", r.Status, r.Fix)
+	}
+
+	writeFile(t, root, "/proc/4242/comm", "pacman\n")
+	if r := checkPacmanLock(ctx, env); r.Status != StatusOK {
+		t.Errorf("lock held by pacman: status = %v, want OK", r.Status)
+	}
+}
+
+func TestNewestModTime(t *testing.T) {
+	root := t.TempDir()
+	env := &Env{Root: root}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	for path, mtime := range map[string]time.Time{
+		"/var/lib/pacman/sync/core.db":  old,
+		"/var/lib/pacman/sync/extra.db": recent,
+	} {
+		full := writeFile(t, root, path, "")
+		if err := os.Chtimes(full, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newest, found := newestModTime(env, databaseGlobs["pacman"])
+	if !found || newest.Sub(recent).Abs() > time.Second {
+		t.Errorf("newestModTime() = %v, %v; want %v", newest, found, recent)
+	}
+
+	if _, found := newestModTime(env, databaseGlobs["apt"]); found {
+		t.Error("newestModTime() found apt databases in an empty root")
+	}
+}
+
+func TestCheckConfigLeftovers(t *testing.T) {
+	root := t.TempDir()
+	env := &Env{Root: root}
+	ctx := context.Background()
+
+	writeFile(t, root, "/etc/pacman.conf", "")
+	if r := checkConfigLeftovers(ctx, env); r.Status != StatusOK {
+		t.Errorf("clean /etc: status = %v, want OK", r.Status)
+	}
+
+	writeFile(t, root, "/etc/pacman.conf.pacnew", "")
+	writeFile(t, root, "/etc/ssh/sshd_config.dpkg-dist", "")
+	r := checkConfigLeftovers(ctx, env)
+	if r.Status != StatusWarning || len(r.Details) != 2 {
+		t.Errorf("leftovers: status = %v, details = %v; want a warning listing 2 files", r.Status, r.Details)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:        "512 B",
+		2048:       "2.0 KiB",
+		3 << 30:    "3.0 GiB",
+		1536 << 20: "1.5 GiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+// fakeBackend is a sandbox backend that is never run.
+type fakeBackend string
+
+func (b fakeBackend) Name() string    { return string(b) }
+func (b fakeBackend) Available() bool { return true }
+func (b fakeBackend) Command(ctx context.Context, profile *sandbox.Profile, workdir, cmd string, args []string) *exec.Cmd {
+	return nil
+}
+
+func TestSandboxResult(t *testing.T) {
+	tests := []struct {
+		backend sandbox.Backend
+		err     error
+		want    Status
+	}{
+		{fakeBackend("bubblewrap"), nil, StatusOK},
+		{fakeBackend("firejail"), nil, StatusOK},
+		{fakeBackend("unshare"), nil, StatusWarning},
+		{nil, sandbox.ErrNoBackend, StatusWarning},
+	}
+
+	for _, tt := range tests {
+		if got := sandboxResult(tt.backend, tt.err); got.Status != tt.want {
+			t.Errorf("sandboxResult(%v, %v) = %v, want status %v", tt.backend, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCheckParserAnomalies(t *testing.T) {
+	log := anomaly.OpenPath(filepath.Join(t.TempDir(), "anomalies.jsonl"))
+	env := &Env{Root: t.TempDir(), Now: time.Now(), Anomalies: log}
+	ctx := context.Background()
+
+	if r := checkParserAnomalies(ctx, env); r.Status != StatusOK {
+		t.Errorf("empty log: %+v, want OK", r)
+	}
+
+	err := log.Record(manager.ParserAnomaly{
+		Time:    time.Now(),
+		Source:  "dnf",
+		Command: "dnf list installed",
+		Sample:  "Installed packages\nbash.x86_64 5.2.26-3.fc40 @fedora",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkParserAnomalies(ctx, env)
+	if r.Status != StatusWarning || len(r.Details) != 1 {
+		t.Fatalf("result = %+v, want a warning with one detail", r)
+	}
+	if !strings.Contains(r.Details[0], "dnf list installed") || !strings.HasSuffix(r.Details[0], ": Installed packages") {
+		t.Errorf("detail = %q", r.Details[0])
+	}
+
+	if r := checkParserAnomalies(ctx, &Env{Now: time.Now()}); r.Status != StatusSkipped {
+		t.Errorf("without a log: %+v, want skipped", r)
+	}
+}
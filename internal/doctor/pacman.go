@@ -0,0 +1,132 @@
+package doctor
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"poxy/pkg/manager/native"
+)
+
+const (
+	pacmanLog  = "/var/log/pacman.log"
+	pacmanLock = "/var/lib/pacman/db.lck"
+)
+
+func init() {
+	Register(Check{
+		Name:        "partial-upgrade",
+		Description: "No packages installed after a database refresh without a full upgrade",
+		Managers:    []string{"pacman"},
+		Run:         checkPartialUpgrade,
+	})
+	Register(Check{
+		Name:        "pacman-lock",
+		Description: "No stale pacman database lock",
+		Managers:    []string{"pacman"},
+		Run:         checkPacmanLock,
+	})
+	Register(Check{
+		Name:        "pacman-keyring",
+		Description: "The pacman keyring is initialized and up to date",
+		Managers:    []string{"pacman"},
+		Run:         checkPacmanKeyring,
+	})
+}
+
+func checkPartialUpgrade(ctx context.Context, env *Env) Result {
+	f, err := os.Open(env.Path(pacmanLog))
+	if err != nil {
+		return Skip("Could not read %s: %v", pacmanLog, err)
+	}
+	defer f.Close()
+
+	changed := partialUpgrades(f)
+	if len(changed) == 0 {
+		return OK("No partial upgrade since the last database refresh")
+	}
+	return Fail("Packages were installed after a database refresh without a full upgrade").
+		WithDetails(changed...).
+		WithFix("poxy upgrade")
+}
+
+// partialUpgrades reads a pacman log and returns the packages installed or
+// upgraded since the last database refresh that was not followed by a full
+// system upgrade. Those packages may be built against newer libraries than
+// the rest of the system has.
+func partialUpgrades(log io.Reader) []string {
+	var changed []string
+	pending := false
+
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.Contains(line, "[PACMAN] synchronizing package lists"):
+			pending = true
+			changed = nil
+		case strings.Contains(line, "[PACMAN] starting full system upgrade"):
+			pending = false
+			changed = nil
+		case pending:
+			for _, action := range []string{"[ALPM] installed ", "[ALPM] upgraded "} {
+				if _, rest, ok := strings.Cut(line, action); ok {
+					if name, _, _ := strings.Cut(rest, " "); name != "" {
+						changed = append(changed, name)
+					}
+				}
+			}
+		}
+	}
+	return changed
+}
+
+func checkPacmanLock(ctx context.Context, env *Env) Result {
+	lock := env.Path(pacmanLock)
+	if _, err := os.Stat(lock); err != nil {
+		return OK("No pacman database lock")
+	}
+	if processRunning(env, "pacman") {
+		return OK("The pacman database is locked by a running pacman")
+	}
+	return Fail("%s exists but pacman is not running", lock).
+		WithFix("sudo rm " + lock)
+}
+
+// processRunning reports whether a process with the given command name is
+// running, according to /proc.
+func processRunning(env *Env, command string) bool {
+	comms, _ := filepath.Glob(env.Path("/proc/[0-9]*/comm")) //nolint:errcheck
+	for _, path := range comms {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.TrimSpace(string(data)) == command {
+			return true
+		}
+	}
+	return false
+}
+
+func checkPacmanKeyring(ctx context.Context, env *Env) Result {
+	mgr, ok := env.Available("pacman")
+	if !ok {
+		return Skip("pacman is not available")
+	}
+	pacman, ok := mgr.(*native.Pacman)
+	if !ok {
+		return Skip("pacman is not available")
+	}
+
+	status, err := pacman.KeyringStatus(ctx)
+	if err != nil {
+		return Skip("Keyring check failed: %v", err)
+	}
+	if problems := status.Problems(); len(problems) > 0 {
+		return Fail("The pacman keyring has problems").
+			WithDetails(problems...).
+			WithFix("poxy keyring repair")
+	}
+	return OK("The pacman keyring is healthy")
+}
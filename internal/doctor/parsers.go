@@ -0,0 +1,56 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"poxy/internal/anomaly"
+)
+
+// anomalyWindow is how far back parser anomalies are reported
+const anomalyWindow = 30 * 24 * time.Hour
+
+func init() {
+	Register(Check{
+		Name:        "parser-anomalies",
+		Description: "Package manager output was parsed as expected",
+		Run:         checkParserAnomalies,
+	})
+}
+
+func checkParserAnomalies(ctx context.Context, env *Env) Result {
+	if env.Anomalies == nil {
+		return Skip("Parser anomalies are not recorded")
+	}
+	anomalies, err := env.Anomalies.Read()
+	if err != nil {
+		return Skip("%v", err)
+	}
+
+	counts := anomaly.Summarize(anomalies, env.Now.Add(-anomalyWindow))
+	if len(counts) == 0 {
+		return OK("No unparsable package manager output in the last 30 days")
+	}
+
+	total := 0
+	var details []string
+	for _, c := range counts {
+		total += c.Count
+		details = append(details, fmt.Sprintf("%s: %s, %d time(s), last %s: %s",
+			c.Source, c.Command, c.Count, c.Last.Time.Format("2006-01-02"), firstLine(c.Last.Sample)))
+	}
+	return Warn("%d unparsable output(s) from package managers in the last 30 days; results may be missing", total).
+		WithDetails(details...).
+		WithFix(fmt.Sprintf("Their output format may have changed: report it with the samples in %s", env.Anomalies.Path()))
+}
+
+// firstLine returns the first line of s, shortened for a detail line.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	if len(line) > 80 {
+		line = line[:77] + "..."
+	}
+	return line
+}
@@ -0,0 +1,38 @@
+package doctor
+
+import (
+	"context"
+
+	"poxy/pkg/sandbox"
+)
+
+func init() {
+	Register(Check{
+		Name:        "aur-sandbox",
+		Description: "AUR builds can run in a sandbox",
+		Managers:    []string{"aur"},
+		Run:         checkAURSandbox,
+	})
+}
+
+func checkAURSandbox(ctx context.Context, env *Env) Result {
+	backend, err := sandbox.SelectBackend()
+	return sandboxResult(backend, err)
+}
+
+// sandboxResult reports on the sandbox backend AUR builds would use.
+func sandboxResult(backend sandbox.Backend, err error) Result {
+	if err != nil {
+		return Warn("AUR builds run unsandboxed: %v", err).
+			WithFix("Install bubblewrap (pacman -S bubblewrap)")
+	}
+
+	switch backend.Name() {
+	case "bubblewrap":
+		return OK("AUR builds run in bubblewrap")
+	case "unshare":
+		return Warn("AUR builds run with unshare, which does not restrict the filesystem").
+			WithFix("Install bubblewrap (pacman -S bubblewrap)")
+	}
+	return OK("AUR builds run in %s (bubblewrap is not available)", backend.Name())
+}
@@ -9,12 +9,56 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"poxy/internal/log"
 )
 
+// nonInteractiveSudo makes sudo fail instead of asking for a password.
+var nonInteractiveSudo bool
+
+// SetNonInteractiveSudo makes sudo fail when it would ask for a password,
+// for runs without anyone to type it, such as in CI.
+func SetNonInteractiveSudo(nonInteractive bool) {
+	nonInteractiveSudo = nonInteractive
+}
+
+// dryRunRecorder receives the commands executors in dry-run mode would run.
+var dryRunRecorder func(Command)
+
+// Command is a command an executor in dry-run mode would have run.
+type Command struct {
+	Name string
+	Args []string
+	Sudo bool // Run with sudo
+}
+
+// String returns the command line.
+func (c Command) String() string {
+	line := strings.Join(append([]string{c.Name}, c.Args...), " ")
+	if c.Sudo {
+		line = "sudo " + line
+	}
+	return line
+}
+
+// RecordDryRun passes the commands executors in dry-run mode would run to
+// record instead of printing them, to build a plan of an operation. A nil
+// record prints them again.
+func RecordDryRun(record func(Command)) {
+	dryRunRecorder = record
+}
+
 // Executor handles command execution with optional sudo elevation.
 type Executor struct {
 	dryRun  bool
 	verbose bool
+
+	// commandArgs are inserted before the arguments of the named commands
+	commandArgs map[string][]string
+
+	// chroot, if set, is the root commands run chrooted into
+	chroot string
 }
 
 // New creates a new Executor with the given options.
@@ -35,8 +79,51 @@ func (e *Executor) SetVerbose(verbose bool) {
 	e.verbose = verbose
 }
 
+// SetCommandArgs sets arguments inserted before the arguments of every run
+// of the command name, such as a backend's alternate root option. Calling
+// it without args removes them.
+func (e *Executor) SetCommandArgs(name string, args ...string) {
+	if len(args) == 0 {
+		delete(e.commandArgs, name)
+		return
+	}
+	if e.commandArgs == nil {
+		e.commandArgs = make(map[string][]string)
+	}
+	e.commandArgs[name] = args
+}
+
+// SetChroot makes commands run chrooted into root, using the target's own
+// binaries. An empty root runs them on the host again. Chrooting requires
+// root privileges, also for commands that are not run with sudo.
+func (e *Executor) SetChroot(root string) {
+	e.chroot = root
+}
+
+// command returns the command line that runs name with args: the arguments
+// set for name in front and, if chrooted, wrapped in the chroot tool.
+func (e *Executor) command(name string, args []string) (string, []string) {
+	if extra, ok := e.commandArgs[name]; ok {
+		args = append(append([]string{}, extra...), args...)
+	}
+	if e.chroot == "" {
+		return name, args
+	}
+	return chrootTool(), append([]string{e.chroot, name}, args...)
+}
+
+// chrootTool returns arch-chroot, which mounts /proc, /sys and /dev in the
+// target as package scripts expect, if installed, and chroot otherwise.
+func chrootTool() string {
+	if _, err := exec.LookPath("arch-chroot"); err == nil {
+		return "arch-chroot"
+	}
+	return "chroot"
+}
+
 // Run executes a command without sudo.
 func (e *Executor) Run(ctx context.Context, name string, args ...string) error {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRun(name, args)
 		return nil
@@ -51,24 +138,20 @@ func (e *Executor) Run(ctx context.Context, name string, args ...string) error {
 		fmt.Printf("Executing: %s %s\n", name, strings.Join(args, " "))
 	}
 
-	return cmd.Run()
+	return run(cmd, Command{Name: name, Args: args})
 }
 
 // RunSudo executes a command with sudo if not already root.
 func (e *Executor) RunSudo(ctx context.Context, name string, args ...string) error {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRunSudo(name, args)
 		return nil
 	}
 
-	var cmd *exec.Cmd
-	if isRoot() {
-		cmd = exec.CommandContext(ctx, name, args...)
-	} else if hasSudo() {
-		sudoArgs := append([]string{name}, args...)
-		cmd = exec.CommandContext(ctx, "sudo", sudoArgs...)
-	} else {
-		return fmt.Errorf("this operation requires root privileges, but sudo is not available")
+	cmd, err := SudoCommand(ctx, name, args...)
+	if err != nil {
+		return err
 	}
 
 	cmd.Stdin = os.Stdin
@@ -83,26 +166,37 @@ func (e *Executor) RunSudo(ctx context.Context, name string, args ...string) err
 		}
 	}
 
-	return cmd.Run()
+	return run(cmd, Command{Name: name, Args: args, Sudo: !isRoot()})
+}
+
+// SudoCommand returns a command that runs name with sudo, or directly when
+// already running as root. The caller connects its input and output.
+func SudoCommand(ctx context.Context, name string, args ...string) (*exec.Cmd, error) {
+	if isRoot() {
+		return exec.CommandContext(ctx, name, args...), nil
+	}
+	if !hasSudo() {
+		return nil, fmt.Errorf("this operation requires root privileges, but sudo is not available")
+	}
+	if nonInteractiveSudo {
+		return exec.CommandContext(ctx, "sudo", append([]string{"-n", name}, args...)...), nil
+	}
+	return exec.CommandContext(ctx, "sudo", append([]string{name}, args...)...), nil
 }
 
 // RunSudoWithStderr executes a command with sudo while capturing stderr.
 // It streams both stdout and stderr to the terminal while also capturing stderr
 // for error analysis. Returns the captured stderr and any error.
 func (e *Executor) RunSudoWithStderr(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRunSudo(name, args)
 		return "", nil
 	}
 
-	var cmd *exec.Cmd
-	if isRoot() {
-		cmd = exec.CommandContext(ctx, name, args...)
-	} else if hasSudo() {
-		sudoArgs := append([]string{name}, args...)
-		cmd = exec.CommandContext(ctx, "sudo", sudoArgs...)
-	} else {
-		return "", fmt.Errorf("this operation requires root privileges, but sudo is not available")
+	cmd, err := SudoCommand(ctx, name, args...)
+	if err != nil {
+		return "", err
 	}
 
 	cmd.Stdin = os.Stdin
@@ -120,12 +214,13 @@ func (e *Executor) RunSudoWithStderr(ctx context.Context, name string, args ...s
 		}
 	}
 
-	err := cmd.Run()
+	err = run(cmd, Command{Name: name, Args: args, Sudo: !isRoot()})
 	return stderrBuf.String(), err
 }
 
 // Output runs a command and returns its stdout.
 func (e *Executor) Output(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRun(name, args)
 		return "", nil
@@ -140,12 +235,13 @@ func (e *Executor) Output(ctx context.Context, name string, args ...string) (str
 		fmt.Printf("Executing: %s %s\n", name, strings.Join(args, " "))
 	}
 
-	err := cmd.Run()
+	err := run(cmd, Command{Name: name, Args: args})
 	return stdout.String(), err
 }
 
 // OutputQuiet runs a command and returns its stdout, suppressing stderr.
 func (e *Executor) OutputQuiet(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRun(name, args)
 		return "", nil
@@ -160,25 +256,21 @@ func (e *Executor) OutputQuiet(ctx context.Context, name string, args ...string)
 		fmt.Printf("Executing: %s %s\n", name, strings.Join(args, " "))
 	}
 
-	err := cmd.Run()
+	err := run(cmd, Command{Name: name, Args: args})
 	return stdout.String(), err
 }
 
 // OutputSudo runs a command with sudo and returns its stdout.
 func (e *Executor) OutputSudo(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRunSudo(name, args)
 		return "", nil
 	}
 
-	var cmd *exec.Cmd
-	if isRoot() {
-		cmd = exec.CommandContext(ctx, name, args...)
-	} else if hasSudo() {
-		sudoArgs := append([]string{name}, args...)
-		cmd = exec.CommandContext(ctx, "sudo", sudoArgs...)
-	} else {
-		return "", fmt.Errorf("this operation requires root privileges, but sudo is not available")
+	cmd, err := SudoCommand(ctx, name, args...)
+	if err != nil {
+		return "", err
 	}
 
 	var stdout bytes.Buffer
@@ -193,12 +285,13 @@ func (e *Executor) OutputSudo(ctx context.Context, name string, args ...string)
 		}
 	}
 
-	err := cmd.Run()
+	err = run(cmd, Command{Name: name, Args: args, Sudo: !isRoot()})
 	return stdout.String(), err
 }
 
 // OutputCombined runs a command and returns both stdout and stderr combined.
 func (e *Executor) OutputCombined(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRun(name, args)
 		return "", nil
@@ -213,12 +306,13 @@ func (e *Executor) OutputCombined(ctx context.Context, name string, args ...stri
 		fmt.Printf("Executing: %s %s\n", name, strings.Join(args, " "))
 	}
 
-	err := cmd.Run()
+	err := run(cmd, Command{Name: name, Args: args})
 	return combined.String(), err
 }
 
 // RunInteractive runs a command that requires user interaction.
 func (e *Executor) RunInteractive(ctx context.Context, name string, args ...string) error {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRun(name, args)
 		return nil
@@ -229,11 +323,12 @@ func (e *Executor) RunInteractive(ctx context.Context, name string, args ...stri
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	return cmd.Run()
+	return run(cmd, Command{Name: name, Args: args})
 }
 
 // RunWithOutput runs a command and streams output while also capturing it.
 func (e *Executor) RunWithOutput(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = e.command(name, args)
 	if e.dryRun {
 		e.printDryRun(name, args)
 		return "", nil
@@ -246,15 +341,42 @@ func (e *Executor) RunWithOutput(ctx context.Context, name string, args ...strin
 	cmd.Stdout = io.MultiWriter(os.Stdout, &buf)
 	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	err := run(cmd, Command{Name: name, Args: args})
 	return buf.String(), err
 }
 
+// run runs cmd, which runs the command c, and logs it with its duration
+// and exit status. Error output that is not shown on a terminal is kept for
+// the log, in case the command fails.
+func run(cmd *exec.Cmd, c Command) error {
+	var output bytes.Buffer
+	if _, onTerminal := cmd.Stderr.(*os.File); !onTerminal {
+		if cmd.Stderr != nil {
+			cmd.Stderr = io.MultiWriter(cmd.Stderr, &output)
+		} else {
+			cmd.Stderr = &output
+		}
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	log.Command(c.String(), time.Since(start), err, output.String())
+	return err
+}
+
 func (e *Executor) printDryRun(name string, args []string) {
+	if dryRunRecorder != nil {
+		dryRunRecorder(Command{Name: name, Args: args})
+		return
+	}
 	fmt.Printf("[dry-run] Would execute: %s %s\n", name, strings.Join(args, " "))
 }
 
 func (e *Executor) printDryRunSudo(name string, args []string) {
+	if dryRunRecorder != nil {
+		dryRunRecorder(Command{Name: name, Args: args, Sudo: !isRoot()})
+		return
+	}
 	if isRoot() {
 		fmt.Printf("[dry-run] Would execute (as root): %s %s\n", name, strings.Join(args, " "))
 	} else {
@@ -42,6 +42,55 @@ func TestOutput(t *testing.T) {
 	}
 }
 
+func TestSetCommandArgs(t *testing.T) {
+	exec := New(false, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exec.SetCommandArgs("echo", "--root", "/mnt")
+	output, err := exec.Output(ctx, "echo", "hello")
+	if err != nil {
+		t.Fatalf("Output() error: %v", err)
+	}
+	if strings.TrimSpace(output) != "--root /mnt hello" {
+		t.Errorf("Output() = %q, want the command args first", output)
+	}
+
+	// Other commands are unaffected
+	output, err = exec.Output(ctx, "printf", "%s", "hello")
+	if err != nil {
+		t.Fatalf("Output() error: %v", err)
+	}
+	if output != "hello" {
+		t.Errorf("Output() = %q, want hello", output)
+	}
+
+	exec.SetCommandArgs("echo")
+	output, _ = exec.Output(ctx, "echo", "hello")
+	if strings.TrimSpace(output) != "hello" {
+		t.Errorf("Output() = %q after removing the command args", output)
+	}
+}
+
+func TestSetChroot(t *testing.T) {
+	exec := New(false, false)
+	exec.SetCommandArgs("pacman", "--noconfirm")
+	exec.SetChroot("/mnt")
+
+	name, args := exec.command("pacman", []string{"-Syu"})
+	if name != "chroot" && name != "arch-chroot" {
+		t.Errorf("command() name = %q, want a chroot tool", name)
+	}
+	if got := strings.Join(args, " "); got != "/mnt pacman --noconfirm -Syu" {
+		t.Errorf("command() args = %q", got)
+	}
+
+	exec.SetChroot("")
+	if name, _ := exec.command("pacman", nil); name != "pacman" {
+		t.Errorf("command() name = %q after leaving the chroot", name)
+	}
+}
+
 func TestOutputDryRun(t *testing.T) {
 	exec := New(true, false) // dry-run mode
 	ctx := context.Background()
@@ -92,6 +141,28 @@ func TestRunDryRun(t *testing.T) {
 	}
 }
 
+func TestRecordDryRun(t *testing.T) {
+	var recorded []Command
+	RecordDryRun(func(c Command) { recorded = append(recorded, c) })
+	defer RecordDryRun(nil)
+
+	exec := New(true, false)
+	exec.SetCommandArgs("apt-get", "-o", "RootDir=/mnt")
+	if err := exec.Run(context.Background(), "apt-get", "install", "htop"); err != nil {
+		t.Fatalf("Run() in dry-run mode error: %v", err)
+	}
+
+	if len(recorded) != 1 {
+		t.Fatalf("recorded %d commands, want 1", len(recorded))
+	}
+	if got := recorded[0].String(); got != "apt-get -o RootDir=/mnt install htop" {
+		t.Errorf("recorded %q", got)
+	}
+	if got := (Command{Name: "dnf", Args: []string{"remove", "vim"}, Sudo: true}).String(); got != "sudo dnf remove vim" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
 func TestOutputCombined(t *testing.T) {
 	exec := New(false, false)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -118,3 +189,19 @@ func TestContextCancellation(t *testing.T) {
 		t.Error("Output() should error with canceled context")
 	}
 }
+
+func TestSudoCommandNonInteractive(t *testing.T) {
+	if isRoot() || !hasSudo() {
+		t.Skip("needs a non-root user with sudo")
+	}
+	SetNonInteractiveSudo(true)
+	defer SetNonInteractiveSudo(false)
+
+	cmd, err := SudoCommand(context.Background(), "true")
+	if err != nil {
+		t.Fatalf("SudoCommand() error: %v", err)
+	}
+	if got := strings.Join(cmd.Args, " "); got != "sudo -n true" {
+		t.Errorf("SudoCommand() args = %q, want %q", got, "sudo -n true")
+	}
+}
@@ -0,0 +1,233 @@
+// Package export writes the explicitly installed packages of a machine as a
+// bootstrap script or a manifest, so they can be reinstalled on another
+// machine, translated to another distro's package names if needed.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"poxy/pkg/database"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Export formats.
+const (
+	FormatScript = "script"
+	FormatTOML   = "toml"
+	FormatJSON   = "json"
+)
+
+// Formats returns the export formats.
+func Formats() []string {
+	return []string{FormatScript, FormatTOML, FormatJSON}
+}
+
+// Package is an exported package.
+type Package struct {
+	Source string `json:"source" toml:"source"`
+	Name   string `json:"name" toml:"name"`
+	// From is the package it was translated from, as "source:name"
+	From string `json:"from,omitempty" toml:"from,omitempty"`
+	// Suggested is the closest package found in the target for an
+	// unmapped package, to be checked by hand
+	Suggested string `json:"suggested,omitempty" toml:"suggested,omitempty"`
+}
+
+// Manifest is the set of packages to reinstall.
+type Manifest struct {
+	Generated time.Time `json:"generated" toml:"generated"`
+	Host      string    `json:"host" toml:"host"`
+	Target    string    `json:"target,omitempty" toml:"target,omitempty"` // Source names were translated to
+	Packages  []Package `json:"packages" toml:"packages"`
+	// Unmapped packages have no known name in Target
+	Unmapped []Package `json:"unmapped,omitempty" toml:"unmapped,omitempty"`
+}
+
+// New returns a manifest of packages, sorted by source and name.
+func New(packages []Package) *Manifest {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	m := &Manifest{
+		Generated: time.Now(),
+		Host:      host,
+		Packages:  packages,
+	}
+	sortPackages(m.Packages)
+	return m
+}
+
+// Translate renames the packages of the distro-specific sources in from
+// (the native manager and the AUR) to their names in the target source,
+// using the package mappings. Packages of other sources, such as Flatpak,
+// are installed the same way on any distro and are kept. Packages without
+// a name in target are moved to Unmapped.
+func (m *Manifest) Translate(target string, from []string, mappings *database.MappingStore) {
+	distro := make(map[string]bool, len(from))
+	for _, source := range from {
+		distro[source] = true
+	}
+
+	seen := make(map[string]bool)
+	var packages, unmapped []Package
+	for _, pkg := range m.Packages {
+		if distro[pkg.Source] && pkg.Source != target {
+			name := ""
+			if mapping := mappings.GetBySourceName(pkg.Source, pkg.Name); mapping != nil {
+				name = mapping.Sources[target]
+			}
+			if name == "" {
+				unmapped = append(unmapped, pkg)
+				continue
+			}
+			pkg = Package{Source: target, Name: name, From: pkg.Source + ":" + pkg.Name}
+		}
+
+		// Several packages can map to the same target package
+		key := pkg.Source + ":" + pkg.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		packages = append(packages, pkg)
+	}
+
+	sortPackages(packages)
+	m.Target = target
+	m.Packages = packages
+	m.Unmapped = unmapped
+}
+
+// Read reads a manifest written in the toml or json format. The format is
+// told by the file extension, and by the content for other extensions.
+func Read(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	switch {
+	case strings.HasSuffix(path, ".json") || strings.HasPrefix(strings.TrimSpace(string(data)), "{"):
+		err = json.Unmarshal(data, &m)
+	case strings.HasPrefix(string(data), "#!"):
+		return nil, fmt.Errorf("%s is an export script; export with --format toml or json to read it back", path)
+	default:
+		_, err = toml.Decode(string(data), &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Write writes the manifest to w in format.
+func (m *Manifest) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatScript:
+		return m.writeScript(w)
+	case FormatTOML:
+		return toml.NewEncoder(w).Encode(m)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	default:
+		return fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(Formats(), ", "))
+	}
+}
+
+// WriteFile writes the manifest to path in format. Scripts are made
+// executable.
+func (m *Manifest) WriteFile(path, format string) error {
+	mode := os.FileMode(0644)
+	if format == FormatScript {
+		mode = 0755
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if err := m.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeScript writes a shell script that installs the packages with poxy,
+// one command per source. Unmapped packages are listed in comments.
+func (m *Manifest) writeScript(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Generated by poxy on %s at %s.\n", m.Host, m.Generated.Format("2006-01-02 15:04"))
+	b.WriteString("# Installs the packages listed below; requires poxy.\n")
+	b.WriteString("set -e\n")
+
+	for _, group := range groupBySource(m.Packages) {
+		fmt.Fprintf(&b, "\n# %s (%d)\n", group[0].Source, len(group))
+		fmt.Fprintf(&b, "poxy install -y -s %s", shellQuote(group[0].Source))
+		for _, pkg := range group {
+			b.WriteString(" \\\n  " + shellQuote(pkg.Name))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.Unmapped) > 0 {
+		fmt.Fprintf(&b, "\n# No %s name is known for these packages; install them by hand:\n", m.Target)
+		for _, pkg := range m.Unmapped {
+			if pkg.Suggested != "" {
+				fmt.Fprintf(&b, "#   %s (%s), perhaps %s\n", pkg.Name, pkg.Source, pkg.Suggested)
+			} else {
+				fmt.Fprintf(&b, "#   %s (%s)\n", pkg.Name, pkg.Source)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// groupBySource splits sorted packages into runs of the same source.
+func groupBySource(packages []Package) [][]Package {
+	var groups [][]Package
+	for i, pkg := range packages {
+		if i == 0 || pkg.Source != packages[i-1].Source {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], pkg)
+	}
+	return groups
+}
+
+func sortPackages(packages []Package) {
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Source != packages[j].Source {
+			return packages[i].Source < packages[j].Source
+		}
+		return packages[i].Name < packages[j].Name
+	})
+}
+
+// shellQuote quotes s for sh if it contains anything but characters common
+// in package names.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._+-:@/=", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
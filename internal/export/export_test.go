@@ -0,0 +1,169 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"poxy/pkg/database"
+
+	"github.com/BurntSushi/toml"
+)
+
+func testManifest() *Manifest {
+	m := New([]Package{
+		{Source: "pacman", Name: "vim"},
+		{Source: "flatpak", Name: "org.mozilla.firefox"},
+		{Source: "pacman", Name: "base-devel"},
+		{Source: "aur", Name: "yay-bin"},
+		{Source: "pacman", Name: "gvim"},
+	})
+	m.Host = "laptop"
+	return m
+}
+
+func testMappings() *database.MappingStore {
+	mappings := database.NewMappingStore()
+	mappings.AddBatch([]*database.Mapping{
+		{Canonical: "vim", Sources: map[string]string{"pacman": "vim", "apt": "vim"}},
+		{Canonical: "vim-gtk", Sources: map[string]string{"pacman": "gvim", "apt": "vim-gtk3"}},
+		{Canonical: "build-tools", Sources: map[string]string{"pacman": "base-devel", "dnf": "gcc"}},
+	})
+	return mappings
+}
+
+func names(packages []Package) []string {
+	var out []string
+	for _, pkg := range packages {
+		out = append(out, pkg.Source+":"+pkg.Name)
+	}
+	return out
+}
+
+func TestNewSorts(t *testing.T) {
+	want := []string{"aur:yay-bin", "flatpak:org.mozilla.firefox", "pacman:base-devel", "pacman:gvim", "pacman:vim"}
+	if got := names(testManifest().Packages); !slices.Equal(got, want) {
+		t.Errorf("packages = %v, want %v", got, want)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	m := testManifest()
+	m.Translate("apt", []string{"pacman", "aur"}, testMappings())
+
+	want := []string{"apt:vim", "apt:vim-gtk3", "flatpak:org.mozilla.firefox"}
+	if got := names(m.Packages); !slices.Equal(got, want) {
+		t.Errorf("packages = %v, want %v", got, want)
+	}
+	if m.Packages[1].From != "pacman:gvim" {
+		t.Errorf("vim-gtk3 from = %q, want pacman:gvim", m.Packages[1].From)
+	}
+	if got := names(m.Unmapped); !slices.Equal(got, []string{"aur:yay-bin", "pacman:base-devel"}) {
+		t.Errorf("unmapped = %v", got)
+	}
+}
+
+func TestWriteScript(t *testing.T) {
+	m := testManifest()
+	m.Translate("apt", []string{"pacman", "aur"}, testMappings())
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf, FormatScript); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	script := buf.String()
+
+	for _, want := range []string{
+		"#!/bin/sh\n",
+		"poxy install -y -s apt \\\n  vim \\\n  vim-gtk3\n",
+		"poxy install -y -s flatpak \\\n  org.mozilla.firefox\n",
+		"#   base-devel (pacman)\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestWriteManifests(t *testing.T) {
+	m := testManifest()
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf, FormatJSON); err != nil {
+		t.Fatalf("Write(json) error = %v", err)
+	}
+	var fromJSON Manifest
+	if err := json.Unmarshal(buf.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if !slices.Equal(names(fromJSON.Packages), names(m.Packages)) || fromJSON.Host != "laptop" {
+		t.Errorf("json round trip = %+v", fromJSON)
+	}
+
+	buf.Reset()
+	if err := m.Write(&buf, FormatTOML); err != nil {
+		t.Fatalf("Write(toml) error = %v", err)
+	}
+	var fromTOML Manifest
+	if _, err := toml.Decode(buf.String(), &fromTOML); err != nil {
+		t.Fatalf("toml: %v", err)
+	}
+	if !slices.Equal(names(fromTOML.Packages), names(m.Packages)) {
+		t.Errorf("toml round trip = %+v", fromTOML)
+	}
+
+	if err := m.Write(&buf, "yaml"); err == nil {
+		t.Error("Write(yaml) should fail")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"vim":                      "vim",
+		"golang.org/x/tools/gopls": "golang.org/x/tools/gopls",
+		"python3.12-dev":           "python3.12-dev",
+		"it's":                     `'it'\''s'`,
+		"a b":                      "'a b'",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	m := testManifest()
+	dir := t.TempDir()
+
+	for _, format := range []string{FormatJSON, FormatTOML} {
+		path := filepath.Join(dir, "packages."+format)
+		var buf bytes.Buffer
+		if err := m.Write(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		read, err := Read(path)
+		if err != nil {
+			t.Fatalf("Read(%s) error = %v", format, err)
+		}
+		if !slices.Equal(names(read.Packages), names(m.Packages)) {
+			t.Errorf("Read(%s) packages = %v", format, names(read.Packages))
+		}
+	}
+
+	script := filepath.Join(dir, "bootstrap.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(script); err == nil || !strings.Contains(err.Error(), "export script") {
+		t.Errorf("Read(script) error = %v", err)
+	}
+}
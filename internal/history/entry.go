@@ -2,7 +2,12 @@
 package history
 
 import (
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"time"
+
+	"poxy/pkg/manager"
 )
 
 // Operation represents the type of package operation.
@@ -14,6 +19,7 @@ const (
 	OpUpdate    Operation = "update"
 	OpUpgrade   Operation = "upgrade"
 	OpClean     Operation = "clean"
+	OpUndo      Operation = "undo"
 )
 
 // Entry represents a single operation in the history.
@@ -26,16 +32,50 @@ type Entry struct {
 	Success   bool      `json:"success"`
 	Error     string    `json:"error,omitempty"`
 
+	// Structured failure information for post-mortem analysis
+	ErrorDetails *ErrorDetails `json:"error_details,omitempty"`
+
+	// Why each package was installed from Source, when poxy picked it
+	Resolutions []manager.Resolution `json:"resolutions,omitempty"`
+
+	// Systemd units the user chose to enable after the install, so undo
+	// and rollback can disable them again
+	Services []string `json:"services,omitempty"`
+
+	// ID of the entry this operation replayed, for poxy history redo
+	RedoOf string `json:"redo_of,omitempty"`
+
+	// ID of the snapshot taken before the operation, for poxy undo <id>
+	Snapshot string `json:"snapshot,omitempty"`
+
 	// Rollback support
 	Reversible bool      `json:"reversible"`
 	ReverseOp  Operation `json:"reverse_op,omitempty"`
 }
 
+// ErrorDetails holds structured information about a failed operation.
+type ErrorDetails struct {
+	Kind       string   `json:"kind"`                 // Parsed error category, e.g. "database_locked"
+	Output     string   `json:"output,omitempty"`     // Raw backend output
+	Packages   []string `json:"packages,omitempty"`   // Packages named in the error
+	Suggestion string   `json:"suggestion,omitempty"` // Backend-specific remedy
+}
+
+// DetailedError is implemented by backend errors that carry parsed output.
+type DetailedError interface {
+	error
+	ErrorKind() string
+	ErrorOutput() string
+	AffectedPackages() []string
+	ErrorSuggestion() string
+}
+
 // NewEntry creates a new history entry.
 func NewEntry(op Operation, source string, packages []string) *Entry {
+	now := time.Now()
 	return &Entry{
-		ID:         generateID(),
-		Timestamp:  time.Now(),
+		ID:         generateID(now),
+		Timestamp:  now,
 		Operation:  op,
 		Source:     source,
 		Packages:   packages,
@@ -51,16 +91,31 @@ func (e *Entry) MarkSuccess() {
 }
 
 // MarkFailed marks the entry as failed with an error message.
+// Structured backend errors are preserved in ErrorDetails.
 func (e *Entry) MarkFailed(err error) {
 	e.Success = false
-	if err != nil {
-		e.Error = err.Error()
+	if err == nil {
+		return
+	}
+
+	e.Error = err.Error()
+
+	var detailed DetailedError
+	if errors.As(err, &detailed) {
+		e.ErrorDetails = &ErrorDetails{
+			Kind:       detailed.ErrorKind(),
+			Output:     detailed.ErrorOutput(),
+			Packages:   detailed.AffectedPackages(),
+			Suggestion: detailed.ErrorSuggestion(),
+		}
 	}
 }
 
-// generateID generates a unique ID for the entry.
-func generateID() string {
-	return time.Now().Format("20060102150405.000000")
+// generateID returns the ID of an entry recorded at t. IDs are derived
+// from the timestamp the entry is stored under, so they stay stable and
+// Store.Record can keep them unique.
+func generateID(t time.Time) string {
+	return t.Format("20060102150405.000000")
 }
 
 // isReversible returns whether an operation can be reversed.
@@ -68,7 +123,7 @@ func isReversible(op Operation) bool {
 	switch op {
 	case OpInstall, OpUninstall:
 		return true
-	case OpUpdate, OpUpgrade, OpClean:
+	case OpUpdate, OpUpgrade, OpClean, OpUndo:
 		return false
 	}
 	return false
@@ -90,6 +145,25 @@ func (e *Entry) CanRollback() bool {
 	return e.Reversible && e.Success && len(e.Packages) > 0
 }
 
+// Code returns a four character handle derived from the ID, e.g. "ab12".
+// Codes are short enough to type but not unique; Store.Get refuses one that
+// more than one entry has.
+func (e *Entry) Code() string {
+	h := fnv.New32a()
+	h.Write([]byte(e.ID))
+	return fmt.Sprintf("%04x", h.Sum32()&0xffff)
+}
+
+// ShortID returns the date and code of the entry, e.g. "2025-06-01-ab12".
+func (e *Entry) ShortID() string {
+	return e.Timestamp.Format("2006-01-02") + "-" + e.Code()
+}
+
+// Matches reports whether ref is the ID, short ID or code of the entry.
+func (e *Entry) Matches(ref string) bool {
+	return ref == e.ID || ref == e.ShortID() || ref == e.Code()
+}
+
 // FormatTime returns a human-readable timestamp.
 func (e *Entry) FormatTime() string {
 	return e.Timestamp.Format("2006-01-02 15:04:05")
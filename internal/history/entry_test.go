@@ -1,6 +1,7 @@
 package history
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -15,6 +16,7 @@ func TestOperation(t *testing.T) {
 		{OpUpdate, "update"},
 		{OpUpgrade, "upgrade"},
 		{OpClean, "clean"},
+		{OpUndo, "undo"},
 	}
 
 	for _, tt := range tests {
@@ -80,6 +82,28 @@ func TestEntryMarkFailed(t *testing.T) {
 	}
 }
 
+func TestEntryMarkFailedDetails(t *testing.T) {
+	entry := NewEntry(OpInstall, "pacman", []string{"vim"})
+	entry.MarkFailed(fmt.Errorf("install failed: %w", &testDetailedError{}))
+
+	if entry.ErrorDetails == nil {
+		t.Fatal("MarkFailed() should capture details from a DetailedError")
+	}
+	if entry.ErrorDetails.Kind != "database_locked" {
+		t.Errorf("expected kind 'database_locked', got '%s'", entry.ErrorDetails.Kind)
+	}
+	if entry.ErrorDetails.Suggestion == "" {
+		t.Error("expected suggestion to be captured")
+	}
+
+	// Plain errors carry no details
+	plain := NewEntry(OpInstall, "apt", []string{"vim"})
+	plain.MarkFailed(&testError{msg: "exit status 1"})
+	if plain.ErrorDetails != nil {
+		t.Error("plain errors should not produce ErrorDetails")
+	}
+}
+
 type testError struct {
 	msg string
 }
@@ -88,6 +112,14 @@ func (e *testError) Error() string {
 	return e.msg
 }
 
+type testDetailedError struct{}
+
+func (e *testDetailedError) Error() string              { return "exit status 1" }
+func (e *testDetailedError) ErrorKind() string          { return "database_locked" }
+func (e *testDetailedError) ErrorOutput() string        { return "unable to lock database" }
+func (e *testDetailedError) AffectedPackages() []string { return nil }
+func (e *testDetailedError) ErrorSuggestion() string    { return "wait for the other process" }
+
 func TestIsReversible(t *testing.T) {
 	tests := []struct {
 		op       Operation
@@ -98,6 +130,7 @@ func TestIsReversible(t *testing.T) {
 		{OpUpdate, false},
 		{OpUpgrade, false},
 		{OpClean, false},
+		{OpUndo, false},
 	}
 
 	for _, tt := range tests {
@@ -197,9 +230,9 @@ func TestSummary(t *testing.T) {
 }
 
 func TestGenerateID(t *testing.T) {
-	id1 := generateID()
-	time.Sleep(1 * time.Millisecond)
-	id2 := generateID()
+	now := time.Now()
+	id1 := generateID(now)
+	id2 := generateID(now.Add(time.Millisecond))
 
 	if id1 == "" {
 		t.Error("generateID() should not return empty string")
@@ -207,4 +240,29 @@ func TestGenerateID(t *testing.T) {
 	if id1 == id2 {
 		t.Error("generateID() should return unique IDs")
 	}
+	if generateID(now) != id1 {
+		t.Error("generateID() should be stable for a timestamp")
+	}
+}
+
+func TestShortID(t *testing.T) {
+	entry := NewEntry(OpInstall, "apt", []string{"vim"})
+	entry.Timestamp = time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	entry.ID = generateID(entry.Timestamp)
+
+	code := entry.Code()
+	if len(code) != 4 {
+		t.Errorf("Code() = %q, want four characters", code)
+	}
+	if got := entry.ShortID(); got != "2025-06-01-"+code {
+		t.Errorf("ShortID() = %q, want 2025-06-01-%s", got, code)
+	}
+	for _, ref := range []string{entry.ID, entry.ShortID(), code} {
+		if !entry.Matches(ref) {
+			t.Errorf("Matches(%q) = false", ref)
+		}
+	}
+	if entry.Matches("2025-06-02-" + code) {
+		t.Error("Matches() accepted the code with another date")
+	}
 }
@@ -0,0 +1,125 @@
+package history
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Status selects entries by outcome.
+type Status string
+
+const (
+	StatusAny       Status = ""
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Query selects history entries. Zero fields match every entry; set fields
+// must all match.
+type Query struct {
+	Operation Operation // Operation type
+	Package   string    // Package name, matched exactly and case-insensitively
+	Source    string    // Package manager, case-insensitive
+	Since     time.Time // Entries recorded at or after
+	Until     time.Time // Entries recorded at or before
+	Status    Status
+
+	// Text matches entries with it, case-insensitively, in a package name,
+	// the source, the operation, the time, the error or a requested
+	// package name
+	Text string
+
+	// Limit caps the number of entries returned; 0 means no limit
+	Limit int
+}
+
+// Matches reports whether an entry satisfies the query.
+func (q Query) Matches(e *Entry) bool {
+	if q.Operation != "" && e.Operation != q.Operation {
+		return false
+	}
+	if q.Source != "" && !strings.EqualFold(e.Source, q.Source) {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Timestamp.After(q.Until) {
+		return false
+	}
+	switch q.Status {
+	case StatusSucceeded:
+		if !e.Success {
+			return false
+		}
+	case StatusFailed:
+		if e.Success {
+			return false
+		}
+	}
+	if q.Package != "" && !hasPackage(e, q.Package) {
+		return false
+	}
+	if q.Text != "" && !containsText(e, q.Text) {
+		return false
+	}
+	return true
+}
+
+// hasPackage reports whether name is one of the entry's packages.
+func hasPackage(e *Entry, name string) bool {
+	for _, pkg := range e.Packages {
+		if strings.EqualFold(pkg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsText reports whether text appears in one of the entry's fields.
+func containsText(e *Entry, text string) bool {
+	fields := append([]string{string(e.Operation), e.Source, e.FormatTime(), e.Error}, e.Packages...)
+	for _, r := range e.Resolutions {
+		fields = append(fields, r.Requested)
+	}
+
+	text = strings.ToLower(text)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query returns the entries matching q, newest first.
+func (s *Store) Query(q Query) ([]Entry, error) {
+	var entries []Entry
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketHistory))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+
+		// Start from the end (most recent) and go backwards
+		for k, v := cursor.Last(); k != nil && (q.Limit <= 0 || len(entries) < q.Limit); k, v = cursor.Prev() {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				continue // Skip malformed entries
+			}
+			if q.Matches(&entry) {
+				entries = append(entries, entry)
+			}
+		}
+
+		return nil
+	})
+
+	return entries, err
+}
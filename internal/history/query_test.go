@@ -0,0 +1,79 @@
+package history
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"poxy/pkg/manager"
+)
+
+func TestQuery(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	record := func(days int, op Operation, source string, packages []string, err error) {
+		entry := NewEntry(op, source, packages)
+		entry.Timestamp = base.AddDate(0, 0, days)
+		if err != nil {
+			entry.MarkFailed(err)
+		} else {
+			entry.MarkSuccess()
+		}
+		if err := store.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	record(0, OpInstall, "apt", []string{"vim"}, nil)
+	record(1, OpInstall, "flatpak", []string{"org.mozilla.firefox"}, nil)
+	record(2, OpUpgrade, "apt", nil, errors.New("dpkg was interrupted"))
+	record(3, OpUninstall, "apt", []string{"vim", "vim-runtime"}, nil)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  int
+	}{
+		{"all", Query{}, 4},
+		{"limit", Query{Limit: 2}, 2},
+		{"operation", Query{Operation: OpInstall}, 2},
+		{"source", Query{Source: "APT"}, 3},
+		{"package", Query{Package: "vim"}, 2},
+		{"package is exact", Query{Package: "vim-r"}, 0},
+		{"text in package", Query{Text: "firefox"}, 1},
+		{"text in error", Query{Text: "interrupted"}, 1},
+		{"failed", Query{Status: StatusFailed}, 1},
+		{"succeeded", Query{Status: StatusSucceeded}, 3},
+		{"since", Query{Since: base.AddDate(0, 0, 2)}, 2},
+		{"until", Query{Until: base.AddDate(0, 0, 1)}, 2},
+		{"combined", Query{Source: "apt", Package: "vim", Operation: OpUninstall}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := store.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query() error: %v", err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("Query() returned %d entries, want %d", len(entries), tt.want)
+			}
+		})
+	}
+
+	entries, _ := store.Query(Query{Source: "apt"}) //nolint:errcheck
+	if len(entries) > 1 && entries[0].Timestamp.Before(entries[1].Timestamp) {
+		t.Error("Query() should return entries newest first")
+	}
+}
+
+func TestQueryMatchesResolutions(t *testing.T) {
+	entry := NewEntry(OpInstall, "pacman", []string{"neovim"})
+	entry.Resolutions = []manager.Resolution{{Requested: "nvim", Name: "neovim", Source: "pacman"}}
+
+	if !(Query{Text: "nvim"}).Matches(entry) {
+		t.Error("Matches() should find text in the requested package names")
+	}
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ErrNotReplayable is returned by Replay for entries that cannot be
+// re-executed as recorded.
+var ErrNotReplayable = errors.New("operation cannot be replayed")
+
+// ReplayOpts contains options for replaying an entry.
+type ReplayOpts struct {
+	AutoConfirm bool // Automatically confirm prompts
+	DryRun      bool // Show what would happen without executing
+}
+
+// Replayable returns true if Replay can re-execute the entry. Undo entries
+// restore a snapshot rather than run one operation, and entries spanning
+// several sources cannot be replayed with one manager.
+func (e *Entry) Replayable() bool {
+	if e.Source == "" || strings.Contains(e.Source, ",") {
+		return false
+	}
+
+	switch e.Operation {
+	case OpInstall, OpUninstall:
+		return len(e.Packages) > 0
+	case OpUpdate, OpUpgrade, OpClean:
+		return true
+	}
+	return false
+}
+
+// Replay re-executes the operation recorded in e with mgr, the manager
+// named by e.Source. Installs and removals apply to the same package set,
+// and upgrades to the same packages, or to all packages if none were named.
+func Replay(ctx context.Context, e *Entry, mgr manager.Manager, opts ReplayOpts) error {
+	if !e.Replayable() {
+		return fmt.Errorf("%w: %s", ErrNotReplayable, e.Operation)
+	}
+	if mgr.Name() != e.Source {
+		return fmt.Errorf("entry %s was recorded with %s, not %s", e.ID, e.Source, mgr.Name())
+	}
+
+	switch e.Operation {
+	case OpInstall:
+		return mgr.Install(ctx, e.Packages, manager.InstallOpts{
+			AutoConfirm: opts.AutoConfirm,
+			DryRun:      opts.DryRun,
+		})
+	case OpUninstall:
+		return mgr.Uninstall(ctx, e.Packages, manager.UninstallOpts{
+			AutoConfirm: opts.AutoConfirm,
+			DryRun:      opts.DryRun,
+		})
+	case OpUpgrade:
+		return mgr.Upgrade(ctx, manager.UpgradeOpts{
+			AutoConfirm: opts.AutoConfirm,
+			DryRun:      opts.DryRun,
+			Packages:    e.Packages,
+		})
+	case OpUpdate:
+		if opts.DryRun {
+			return nil
+		}
+		return mgr.Update(ctx)
+	default: // OpClean
+		return mgr.Clean(ctx, manager.CleanOpts{DryRun: opts.DryRun})
+	}
+}
@@ -0,0 +1,87 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+// replayManager records the operations Replay runs.
+type replayManager struct {
+	manager.Manager
+	calls []string
+}
+
+func (m *replayManager) Name() string { return "apt" }
+
+func (m *replayManager) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	m.calls = append(m.calls, "install "+packages[0])
+	return nil
+}
+
+func (m *replayManager) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	m.calls = append(m.calls, "uninstall "+packages[0])
+	return nil
+}
+
+func (m *replayManager) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	m.calls = append(m.calls, "upgrade")
+	return nil
+}
+
+func (m *replayManager) Update(ctx context.Context) error {
+	m.calls = append(m.calls, "update")
+	return nil
+}
+
+func (m *replayManager) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	m.calls = append(m.calls, "clean")
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	mgr := &replayManager{}
+	ctx := context.Background()
+
+	for _, e := range []*Entry{
+		NewEntry(OpInstall, "apt", []string{"vim"}),
+		NewEntry(OpUninstall, "apt", []string{"nano"}),
+		NewEntry(OpUpgrade, "apt", nil),
+		NewEntry(OpUpdate, "apt", nil),
+		NewEntry(OpClean, "apt", nil),
+	} {
+		if err := Replay(ctx, e, mgr, ReplayOpts{}); err != nil {
+			t.Errorf("Replay(%s) error: %v", e.Operation, err)
+		}
+	}
+
+	want := []string{"install vim", "uninstall nano", "upgrade", "update", "clean"}
+	if !slices.Equal(mgr.calls, want) {
+		t.Errorf("Replay() ran %v, want %v", mgr.calls, want)
+	}
+}
+
+func TestReplayRefuses(t *testing.T) {
+	mgr := &replayManager{}
+	ctx := context.Background()
+
+	for _, e := range []*Entry{
+		NewEntry(OpUndo, "apt", []string{"vim"}),
+		NewEntry(OpInstall, "apt", nil),
+		NewEntry(OpInstall, "apt,flatpak", []string{"vim"}),
+	} {
+		if err := Replay(ctx, e, mgr, ReplayOpts{}); !errors.Is(err, ErrNotReplayable) {
+			t.Errorf("Replay(%s %v [%s]) error = %v, want ErrNotReplayable", e.Operation, e.Packages, e.Source, err)
+		}
+	}
+
+	if err := Replay(ctx, NewEntry(OpInstall, "dnf", []string{"vim"}), mgr, ReplayOpts{}); err == nil {
+		t.Error("Replay() should refuse an entry recorded with another manager")
+	}
+	if len(mgr.calls) > 0 {
+		t.Errorf("Replay() ran %v for refused entries", mgr.calls)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"poxy/internal/config"
@@ -23,15 +26,35 @@ type Store struct {
 	db *bbolt.DB
 }
 
-// Open opens or creates the history database at the default location.
+// Open opens or creates the history database at the default location. If
+// the data directory is not writable, an existing database is opened
+// read-only, so history can still be shown but not recorded.
 func Open() (*Store, error) {
-	if err := config.EnsureDataDir(); err != nil {
-		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	if !config.DataDirWritable() {
+		return OpenReadOnly(config.HistoryPath())
 	}
 
 	return OpenPath(config.HistoryPath())
 }
 
+// OpenReadOnly opens an existing history database without write access.
+// It fails with config.ErrDataDirReadOnly if the database does not exist.
+func OpenReadOnly(dbPath string) (*Store, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("%w: %s", config.ErrDataDirReadOnly, filepath.Dir(dbPath))
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
+		Timeout:  1 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
 // OpenPath opens or creates the history database at the specified path.
 func OpenPath(dbPath string) (*Store, error) {
 	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
@@ -67,7 +90,9 @@ func (s *Store) Close() error {
 	return nil
 }
 
-// Record saves a new history entry.
+// Record saves a new history entry. An entry created in the same
+// microsecond as one already recorded is moved to the next free
+// microsecond, so no entry is overwritten and every ID stays unique.
 func (s *Store) Record(entry *Entry) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(bucketHistory))
@@ -75,13 +100,19 @@ func (s *Store) Record(entry *Entry) error {
 			return fmt.Errorf("history bucket not found")
 		}
 
+		// Use timestamp as key for chronological ordering
+		key := []byte(entry.Timestamp.Format(time.RFC3339Nano))
+		for bucket.Get(key) != nil || hasID(bucket, entry.ID) {
+			entry.Timestamp = entry.Timestamp.Add(time.Microsecond)
+			entry.ID = generateID(entry.Timestamp)
+			key = []byte(entry.Timestamp.Format(time.RFC3339Nano))
+		}
+
 		data, err := json.Marshal(entry)
 		if err != nil {
 			return fmt.Errorf("failed to marshal entry: %w", err)
 		}
 
-		// Use timestamp as key for chronological ordering
-		key := []byte(entry.Timestamp.Format(time.RFC3339Nano))
 		if err := bucket.Put(key, data); err != nil {
 			return fmt.Errorf("failed to save entry: %w", err)
 		}
@@ -96,8 +127,29 @@ func (s *Store) Record(entry *Entry) error {
 	})
 }
 
+// hasID reports whether an entry in bucket has the given ID.
+func hasID(bucket *bbolt.Bucket, id string) bool {
+	found := false
+	_ = bucket.ForEach(func(_, v []byte) error { //nolint:errcheck
+		var e struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(v, &e) == nil && e.ID == id {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
 // List returns the most recent history entries.
 func (s *Store) List(limit int) ([]Entry, error) {
+	return s.Query(Query{Limit: limit})
+}
+
+// Range returns entries recorded within [from, to], oldest first.
+// A zero 'to' means no upper bound.
+func (s *Store) Range(from, to time.Time) ([]Entry, error) {
 	var entries []Entry
 
 	err := s.db.View(func(tx *bbolt.Tx) error {
@@ -107,13 +159,17 @@ func (s *Store) List(limit int) ([]Entry, error) {
 		}
 
 		cursor := bucket.Cursor()
-
-		// Start from the end (most recent) and go backwards
-		for k, v := cursor.Last(); k != nil && (limit <= 0 || len(entries) < limit); k, v = cursor.Prev() {
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
 			var entry Entry
 			if err := json.Unmarshal(v, &entry); err != nil {
 				continue // Skip malformed entries
 			}
+			if entry.Timestamp.Before(from) {
+				continue
+			}
+			if !to.IsZero() && entry.Timestamp.After(to) {
+				continue
+			}
 			entries = append(entries, entry)
 		}
 
@@ -123,9 +179,39 @@ func (s *Store) List(limit int) ([]Entry, error) {
 	return entries, err
 }
 
-// Get retrieves a specific entry by ID.
+// ServicesSince returns the systemd units enabled by successful operations
+// recorded since from, oldest first and without duplicates.
+func (s *Store) ServicesSince(from time.Time) ([]string, error) {
+	entries, err := s.Range(from, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var units []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !e.Success {
+			continue
+		}
+		for _, unit := range e.Services {
+			if !seen[unit] {
+				seen[unit] = true
+				units = append(units, unit)
+			}
+		}
+	}
+	return units, nil
+}
+
+// ErrAmbiguous is returned by Get for a short ID or code more than one
+// entry has.
+var ErrAmbiguous = errors.New("ambiguous history reference")
+
+// Get retrieves a specific entry by ID, short ID or code. Codes are not
+// unique: if more than one entry has the short ID or code, Get returns an
+// error wrapping ErrAmbiguous that lists their IDs.
 func (s *Store) Get(id string) (*Entry, error) {
-	var entry *Entry
+	var matches []*Entry
 
 	err := s.db.View(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(bucketHistory))
@@ -135,22 +221,38 @@ func (s *Store) Get(id string) (*Entry, error) {
 
 		cursor := bucket.Cursor()
 
-		// Search for entry with matching ID
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		// Search newest first for every entry matching the reference
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
 			var e Entry
 			if err := json.Unmarshal(v, &e); err != nil {
 				continue
 			}
 			if e.ID == id {
-				entry = &e
+				matches = []*Entry{&e}
 				return nil
 			}
+			if e.Matches(id) {
+				matches = append(matches, &e)
+			}
 		}
-
-		return fmt.Errorf("entry not found: %s", id)
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return entry, err
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("entry not found: %s", id)
+	case 1:
+		return matches[0], nil
+	}
+
+	ids := make([]string, len(matches))
+	for i, e := range matches {
+		ids[i] = e.ID
+	}
+	return nil, fmt.Errorf("%w: %s matches %d entries (%s); use the full ID", ErrAmbiguous, id, len(matches), strings.Join(ids, ", "))
 }
 
 // Last returns the most recent entry.
@@ -196,6 +298,22 @@ func (s *Store) LastReversible() (*Entry, error) {
 	return nil, fmt.Errorf("no reversible operations found")
 }
 
+// LastFailed returns the most recent failed entry.
+func (s *Store) LastFailed() (*Entry, error) {
+	entries, err := s.List(50) // Check recent entries
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if !e.Success {
+			return &e, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no failed operations found")
+}
+
 // Count returns the total number of entries.
 func (s *Store) Count() (int, error) {
 	var count int
@@ -1,9 +1,14 @@
 package history
 
 import (
+	"errors"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
 )
 
 func setupTestStore(t *testing.T) (*Store, func()) {
@@ -114,6 +119,14 @@ func TestGet(t *testing.T) {
 		t.Errorf("Get() returned wrong entry: %s != %s", retrieved.ID, entry.ID)
 	}
 
+	// Get by short ID and code
+	for _, ref := range []string{entry.ShortID(), entry.Code()} {
+		retrieved, err := store.Get(ref)
+		if err != nil || retrieved.ID != entry.ID {
+			t.Errorf("Get(%q) = %v, %v, want entry %s", ref, retrieved, err, entry.ID)
+		}
+	}
+
 	// Get non-existent
 	_, err = store.Get("nonexistent")
 	if err == nil {
@@ -121,6 +134,121 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestRecordKeepsIDsUnique(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	first := NewEntry(OpInstall, "apt", []string{"vim"})
+	second := NewEntry(OpInstall, "apt", []string{"git"})
+	second.Timestamp = first.Timestamp
+	second.ID = first.ID
+
+	if err := store.Record(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Record(second); err != nil {
+		t.Fatal(err)
+	}
+
+	if second.ID == first.ID {
+		t.Fatalf("Record() kept the duplicate ID %s", first.ID)
+	}
+	if count, _ := store.Count(); count != 2 { //nolint:errcheck
+		t.Errorf("Count() = %d, want 2", count)
+	}
+	got, err := store.Get(first.ID)
+	if err != nil || got.Packages[0] != "vim" {
+		t.Errorf("Get(%s) = %v, %v, want the first entry", first.ID, got, err)
+	}
+}
+
+func TestGetAmbiguousCode(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	// Find two entries of the same day whose codes collide
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	seen := make(map[string]*Entry)
+	var first, second *Entry
+	for i := 0; second == nil; i++ {
+		entry := NewEntry(OpInstall, "apt", []string{"vim"})
+		entry.Timestamp = base.Add(time.Duration(i) * time.Microsecond)
+		entry.ID = generateID(entry.Timestamp)
+		if other, ok := seen[entry.Code()]; ok {
+			first, second = other, entry
+		}
+		seen[entry.Code()] = entry
+	}
+	for _, entry := range []*Entry{first, second} {
+		if err := store.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, ref := range []string{first.Code(), first.ShortID()} {
+		if _, err := store.Get(ref); !errors.Is(err, ErrAmbiguous) {
+			t.Errorf("Get(%q) error = %v, want ErrAmbiguous", ref, err)
+		}
+	}
+	for _, entry := range []*Entry{first, second} {
+		got, err := store.Get(entry.ID)
+		if err != nil || got.ID != entry.ID {
+			t.Errorf("Get(%s) = %v, %v, want that entry", entry.ID, got, err)
+		}
+	}
+}
+
+func TestServicesSince(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	now := time.Now()
+	for _, e := range []Entry{
+		{ID: "old", Timestamp: now.Add(-48 * time.Hour), Services: []string{"cups.service"}, Success: true},
+		{ID: "docker", Timestamp: now.Add(-2 * time.Hour), Services: []string{"docker.socket", "docker.service"}, Success: true},
+		{ID: "failed", Timestamp: now.Add(-90 * time.Minute), Services: []string{"sshd.service"}, Success: false},
+		{ID: "again", Timestamp: now.Add(-time.Hour), Services: []string{"docker.service"}, Success: true},
+	} {
+		e.Operation = OpInstall
+		store.Record(&e)
+	}
+
+	units, err := store.ServicesSince(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("ServicesSince() error: %v", err)
+	}
+	want := []string{"docker.socket", "docker.service"}
+	if !slices.Equal(units, want) {
+		t.Errorf("ServicesSince() = %v, want %v", units, want)
+	}
+}
+
+func TestRecordResolutions(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	entry := NewEntry(OpInstall, "aur", []string{"spotify-bin"})
+	entry.Resolutions = []manager.Resolution{{
+		Requested: "spotify",
+		Source:    "aur",
+		Name:      "spotify-bin",
+		Score:     manager.MatchSuffix,
+		Reason:    "'spotify-bin' in AUR",
+	}}
+	entry.MarkSuccess()
+	if err := store.Record(entry); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+
+	retrieved, err := store.Get(entry.ID)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if len(retrieved.Resolutions) != 1 || retrieved.Resolutions[0] != entry.Resolutions[0] {
+		t.Errorf("Resolutions = %+v, want %+v", retrieved.Resolutions, entry.Resolutions)
+	}
+}
+
 func TestLast(t *testing.T) {
 	store, cleanup := setupTestStore(t)
 	defer cleanup()
@@ -178,6 +306,32 @@ func TestLastReversible(t *testing.T) {
 	}
 }
 
+func TestLastFailed(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	if _, err := store.LastFailed(); err == nil {
+		t.Error("LastFailed() should error on empty store")
+	}
+
+	failed := NewEntry(OpInstall, "apt", []string{"broken"})
+	failed.MarkFailed(&testError{msg: "exit status 100"})
+	store.Record(failed)
+	time.Sleep(1 * time.Millisecond)
+
+	ok := NewEntry(OpInstall, "apt", []string{"vim"})
+	ok.MarkSuccess()
+	store.Record(ok)
+
+	entry, err := store.LastFailed()
+	if err != nil {
+		t.Fatalf("LastFailed() error: %v", err)
+	}
+	if entry.ID != failed.ID {
+		t.Errorf("expected entry %s, got %s", failed.ID, entry.ID)
+	}
+}
+
 func TestCount(t *testing.T) {
 	store, cleanup := setupTestStore(t)
 	defer cleanup()
@@ -268,6 +422,42 @@ func TestPrune(t *testing.T) {
 	}
 }
 
+func TestRange(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, 24 * time.Hour} {
+		store.Record(&Entry{
+			ID:        "entry-" + string(rune('a'+i)),
+			Timestamp: now.Add(-age),
+			Operation: OpInstall,
+			Source:    "apt",
+			Packages:  []string{"pkg"},
+			Success:   true,
+		})
+	}
+
+	entries, err := store.Range(now.Add(-60*time.Hour), now.Add(-12*time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// Should be oldest first
+	if entries[0].ID != "entry-b" || entries[1].ID != "entry-c" {
+		t.Errorf("unexpected order: %s, %s", entries[0].ID, entries[1].ID)
+	}
+
+	// Zero upper bound is open-ended
+	entries, _ = store.Range(now.Add(-30*time.Hour), time.Time{})
+	if len(entries) != 1 {
+		t.Errorf("expected 1 entry with open upper bound, got %d", len(entries))
+	}
+}
+
 func TestClose(t *testing.T) {
 	store, cleanup := setupTestStore(t)
 	defer cleanup()
@@ -281,3 +471,35 @@ func TestClose(t *testing.T) {
 	_ = store.Close()
 	// May or may not error depending on implementation
 }
+
+func TestOpenReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "history.db")
+
+	if _, err := OpenReadOnly(dbPath); !errors.Is(err, config.ErrDataDirReadOnly) {
+		t.Fatalf("OpenReadOnly() of a missing database error = %v, want ErrDataDirReadOnly", err)
+	}
+
+	store, err := OpenPath(dbPath)
+	if err != nil {
+		t.Fatalf("OpenPath() error: %v", err)
+	}
+	entry := NewEntry(OpInstall, "apt", []string{"vim"})
+	if err := store.Record(entry); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	store.Close()
+
+	store, err = OpenReadOnly(dbPath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error: %v", err)
+	}
+	defer store.Close()
+
+	entries, err := store.List(10)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("List() = %d entries, %v; want the recorded entry", len(entries), err)
+	}
+	if err := store.Record(NewEntry(OpInstall, "apt", []string{"git"})); err == nil {
+		t.Error("Record() on a read-only store error = nil, want error")
+	}
+}
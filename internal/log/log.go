@@ -0,0 +1,212 @@
+// Package log writes a structured log of what poxy does, such as each
+// command it runs with how long it took and how it exited, to a file that
+// is rotated as it grows. It helps trace failures whose output was not
+// visible, as inside the TUI.
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxSize is the size at which the log file is rotated.
+	MaxSize = 5 << 20
+
+	// Backups is the number of rotated files kept, as poxy.log.1 (the
+	// newest) to poxy.log.3.
+	Backups = 3
+
+	// maxOutput is how much of a failed command's error output is logged,
+	// from its end.
+	maxOutput = 2048
+)
+
+var (
+	mu     sync.Mutex
+	logger = slog.New(slog.DiscardHandler)
+	file   *rotatingFile
+)
+
+// Open starts logging to the file at path, creating its directory. Until
+// it is called, nothing is logged.
+func Open(path string) error {
+	f, err := openRotating(path, MaxSize, Backups)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		_ = file.Close() //nolint:errcheck
+	}
+	file = f
+	logger = slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return nil
+}
+
+// Close stops logging and closes the log file.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	logger = slog.New(slog.DiscardHandler)
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	return err
+}
+
+// Logger returns the logger writing to the log file, which discards
+// everything while no file is open.
+func Logger() *slog.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return logger
+}
+
+// Command logs a command that ran: its command line, how long it took, its
+// exit status and, if it failed, the end of its error output.
+func Command(line string, duration time.Duration, err error, output string) {
+	attrs := []slog.Attr{
+		slog.String("cmd", line),
+		slog.Duration("duration", duration.Round(time.Millisecond)),
+		slog.Int("exit", ExitStatus(err)),
+	}
+	if err == nil {
+		Logger().LogAttrs(context.Background(), slog.LevelInfo, "command", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.String("error", err.Error()))
+	if output = strings.TrimSpace(output); output != "" {
+		if len(output) > maxOutput {
+			output = "..." + output[len(output)-maxOutput:]
+		}
+		attrs = append(attrs, slog.String("output", output))
+	}
+	Logger().LogAttrs(context.Background(), slog.LevelError, "command", attrs...)
+}
+
+// Operation logs a finished poxy operation, such as an install: the
+// source it used, the packages it affected and, if it failed, why.
+func Operation(op, source string, packages []string, errMsg string) {
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.String("source", source),
+		slog.String("packages", strings.Join(packages, " ")),
+	}
+	if errMsg == "" {
+		Logger().LogAttrs(context.Background(), slog.LevelInfo, "operation", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("error", errMsg))
+	Logger().LogAttrs(context.Background(), slog.LevelError, "operation", attrs...)
+}
+
+// ExitStatus returns the exit status of a command that ended with err: 0
+// for nil, the exit code of a command that ran, or -1 if it did not run or
+// was killed.
+func ExitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// rotatingFile is a log file that is renamed to a backup once it reaches
+// its maximum size, keeping a number of backups.
+type rotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// openRotating opens the log file at path for appending.
+func openRotating(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the log directory: %w", err)
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close() //nolint:errcheck
+		return fmt.Errorf("failed to open the log: %w", err)
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would take it past its
+// maximum size.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups along, dropping the oldest, makes the current
+// file the newest backup and starts a new one.
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		_ = os.Rename(backupPath(r.path, i), backupPath(r.path, i+1)) //nolint:errcheck
+	}
+	if r.backups > 0 {
+		_ = os.Rename(r.path, backupPath(r.path, 1)) //nolint:errcheck
+	} else {
+		_ = os.Remove(r.path) //nolint:errcheck
+	}
+	return r.open()
+}
+
+// Close closes the file.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// backupPath returns the path of the nth backup of the log at path.
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "poxy.log")
+	r, err := openRotating(path, 10, 2)
+	if err != nil {
+		t.Fatalf("openRotating() error: %v", err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:                "fourth\n",
+		backupPath(path, 1): "third\n",
+		backupPath(path, 2): "second\n",
+	}
+	for file, content := range want {
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, content)
+		}
+	}
+	if _, err := os.Stat(backupPath(path, 3)); err == nil {
+		t.Error("more backups kept than asked for")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "poxy.log")
+	if err := Open(path); err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+
+	Command("apt-get install htop", 1500*time.Millisecond, nil, "")
+	failed := exec.Command("sh", "-c", "echo broken >&2; exit 100")
+	output, runErr := failed.CombinedOutput()
+	Command("sh -c false", time.Second, runErr, strings.Repeat("x", 3*maxOutput)+string(output))
+	if err := Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), content)
+	}
+	for _, want := range []string{"level=INFO", `cmd="apt-get install htop"`, "duration=1.5s", "exit=0"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("success entry is missing %s: %s", want, lines[0])
+		}
+	}
+	for _, want := range []string{"level=ERROR", "exit=100", "broken"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("failure entry is missing %s: %s", want, lines[1])
+		}
+	}
+	if len(lines[1]) > 2*maxOutput+200 {
+		t.Errorf("failure entry logs %d bytes, the output should be cut", len(lines[1]))
+	}
+
+	// Closed, nothing more is written
+	Command("true", 0, nil, "")
+	after, _ := os.ReadFile(path) //nolint:errcheck
+	if len(after) != len(content) {
+		t.Error("logged after Close()")
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{exitErr, 3},
+		{errors.New("executable file not found"), -1},
+	}
+	for _, tt := range tests {
+		if got := ExitStatus(tt.err); got != tt.want {
+			t.Errorf("ExitStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
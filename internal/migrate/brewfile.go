@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BrewBundle reads a Brewfile. Formulae and casks become brew packages and
+// cargo and go entries cargo and gobin packages; formulae from a tap
+// ("user/repo/name") also get an alias from their short name. Taps, Mac App
+// Store apps and editor extensions have no poxy equivalent and are skipped.
+func BrewBundle(path string) (*Plan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	plan, err := ParseBrewfile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	plan.File = path
+	return plan, nil
+}
+
+// ParseBrewfile parses the entries of a Brewfile.
+func ParseBrewfile(r io.Reader) (*Plan, error) {
+	plan := newPlan(FromBrewBundle, "")
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		name, _, ok := unquote(rest)
+		if !ok {
+			plan.skip("line %d: %s (not understood)", lineNo, line)
+			continue
+		}
+
+		switch keyword {
+		case "brew", "cask":
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			reason := "imported from Brewfile"
+			if keyword == "cask" {
+				reason = "imported from Brewfile (cask)"
+			}
+			plan.Packages = append(plan.Packages, Package{Source: "brew", Name: name, Reason: reason})
+
+			if parts := strings.Split(name, "/"); len(parts) == 3 {
+				plan.Aliases[parts[2]] = name
+			}
+		case "cargo", "go":
+			source := "cargo"
+			if keyword == "go" {
+				source = "gobin"
+			}
+			plan.Packages = append(plan.Packages, Package{Source: source, Name: name, Reason: "imported from Brewfile"})
+		case "tap":
+			plan.skip("tap %s (brew taps it when installing its formulae)", name)
+		case "mas":
+			plan.skip("mas %s (Mac App Store apps are not supported)", name)
+		case "vscode", "whalebrew":
+			plan.skip("%s %s (not supported)", keyword, name)
+		default:
+			plan.skip("line %d: %s (unknown entry type)", lineNo, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"strings"
+
+	"poxy/internal/export"
+	"poxy/pkg/database"
+)
+
+// How a package was translated to the target distro, most certain first.
+const (
+	MatchMapping  = "mapping"   // The package mappings name it in the target
+	MatchSameName = "same name" // The target has a package of the same name
+	MatchClosest  = "closest"   // Closest search result in the target, to be checked
+)
+
+// Lookup finds the package called name in the target source. It returns
+// the name of the package found, or "" if there is none, and whether it is
+// an exact match rather than the closest one.
+type Lookup func(name string) (found string, exact bool)
+
+// Translation is a package translated to the target source.
+type Translation struct {
+	Name  string // Name in the source distro
+	To    string // Name in the target, "" if nothing was found
+	Match string // How To was found
+}
+
+// DistroPlan translates the packages of one distro's package manager to
+// another's, for moving to a new distro.
+type DistroPlan struct {
+	From         string
+	To           string
+	Translations []Translation
+}
+
+// PlanDistro translates packages of the from source to the to source. The
+// package mappings are tried first, then lookup, which may be nil if the
+// target cannot be searched here.
+func PlanDistro(from, to string, names []string, mappings *database.MappingStore, lookup Lookup) *DistroPlan {
+	plan := &DistroPlan{From: from, To: to}
+
+	for _, name := range names {
+		t := Translation{Name: name}
+
+		if mapping := mappings.GetBySourceName(from, name); mapping != nil && mapping.Sources[to] != "" {
+			t.To = mapping.Sources[to]
+			t.Match = MatchMapping
+		} else if lookup != nil {
+			if found, exact := lookup(name); found != "" {
+				t.To = found
+				t.Match = MatchClosest
+				if exact || strings.EqualFold(found, name) {
+					t.Match = MatchSameName
+				}
+			}
+		}
+
+		plan.Translations = append(plan.Translations, t)
+	}
+	return plan
+}
+
+// Count returns the number of translations found by match; "" counts the
+// packages without one.
+func (p *DistroPlan) Count(match string) int {
+	n := 0
+	for _, t := range p.Translations {
+		if t.Match == match {
+			n++
+		}
+	}
+	return n
+}
+
+// Manifest returns the installable part of the plan: packages found by
+// mapping or by name. Closest matches are not installed unchecked; they
+// are listed as unmapped with the match as a suggestion.
+func (p *DistroPlan) Manifest() *export.Manifest {
+	seen := make(map[string]bool)
+	var packages, unmapped []export.Package
+
+	for _, t := range p.Translations {
+		switch t.Match {
+		case MatchMapping, MatchSameName:
+			if seen[t.To] {
+				continue
+			}
+			seen[t.To] = true
+			packages = append(packages, export.Package{Source: p.To, Name: t.To, From: p.From + ":" + t.Name})
+		default:
+			unmapped = append(unmapped, export.Package{Source: p.From, Name: t.Name, Suggested: t.To})
+		}
+	}
+
+	m := export.New(packages)
+	m.Target = p.To
+	m.Unmapped = unmapped
+	return m
+}
@@ -0,0 +1,159 @@
+// Package migrate imports the state of other package meta-tools (yay,
+// topgrade, Homebrew bundles) into poxy's config and database, so users can
+// switch without setting poxy up from scratch.
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"poxy/internal/config"
+)
+
+// Tools poxy can migrate from.
+const (
+	FromYay        = "yay"
+	FromTopgrade   = "topgrade"
+	FromBrewBundle = "brew-bundle"
+)
+
+// Tools returns the tools poxy can migrate from.
+func Tools() []string {
+	return []string{FromYay, FromTopgrade, FromBrewBundle}
+}
+
+// Setting is a config value a migration sets.
+type Setting struct {
+	Key   string // Config key, e.g. "general.auto_confirm"
+	Value string // Value as shown to the user
+	Apply func(*config.Config)
+}
+
+// Package is a package the other tool manages.
+type Package struct {
+	Source string // Poxy source that manages it, e.g. "aur" or "brew"
+	Name   string
+	Reason string // Why it is imported, recorded as its install reason
+}
+
+// Plan is what a migration changes.
+type Plan struct {
+	From     string
+	File     string // File the state was read from
+	Settings []Setting
+	Aliases  map[string]string
+	Packages []Package
+	Skipped  []string // Entries without a poxy equivalent, with the reason
+}
+
+func newPlan(from, file string) *Plan {
+	return &Plan{From: from, File: file, Aliases: make(map[string]string)}
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *Plan) Empty() bool {
+	return len(p.Settings) == 0 && len(p.Aliases) == 0 && len(p.Packages) == 0
+}
+
+// ChangesConfig reports whether the plan writes the config file.
+func (p *Plan) ChangesConfig() bool {
+	return len(p.Settings) > 0 || len(p.Aliases) > 0
+}
+
+// Apply sets the plan's settings and aliases in cfg. Existing aliases are
+// kept.
+func (p *Plan) Apply(cfg *config.Config) {
+	for _, s := range p.Settings {
+		s.Apply(cfg)
+	}
+	if len(p.Aliases) == 0 {
+		return
+	}
+	if cfg.Aliases == nil {
+		cfg.Aliases = make(map[string]string)
+	}
+	for name, target := range p.Aliases {
+		if _, ok := cfg.Aliases[name]; !ok {
+			cfg.Aliases[name] = target
+		}
+	}
+}
+
+// AliasNames returns the names of the plan's aliases, sorted.
+func (p *Plan) AliasNames() []string {
+	names := make([]string, 0, len(p.Aliases))
+	for name := range p.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Plan) skip(format string, args ...any) {
+	p.Skipped = append(p.Skipped, fmt.Sprintf(format, args...))
+}
+
+// setAURHelper returns the setting that makes helper the AUR helper.
+func setAURHelper(helper string) Setting {
+	return Setting{
+		Key:   "managers.pacman.aur_helper",
+		Value: fmt.Sprintf("%q", helper),
+		Apply: func(cfg *config.Config) {
+			if cfg.Managers == nil {
+				cfg.Managers = make(map[string]config.ManagerConfig)
+			}
+			mc := cfg.Managers["pacman"]
+			mc.AURHelper = helper
+			cfg.Managers["pacman"] = mc
+		},
+	}
+}
+
+// DefaultFile returns the file the tool keeps its state in, or "" if none
+// of the usual locations exists.
+func DefaultFile(from string) string {
+	home, _ := os.UserHomeDir() //nolint:errcheck
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	var candidates []string
+	switch from {
+	case FromYay:
+		candidates = []string{filepath.Join(configHome, "yay", "config.json")}
+	case FromTopgrade:
+		candidates = []string{
+			filepath.Join(configHome, "topgrade.toml"),
+			filepath.Join(configHome, "topgrade", "topgrade.toml"),
+		}
+	case FromBrewBundle:
+		if env := os.Getenv("HOMEBREW_BUNDLE_FILE"); env != "" {
+			candidates = append(candidates, env)
+		}
+		candidates = append(candidates, "Brewfile", filepath.Join(home, ".Brewfile"))
+	}
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// unquote returns the first quoted string at the start of s and the rest.
+func unquote(s string) (string, string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return "", s, false
+	}
+	end := strings.IndexByte(s[1:], s[0])
+	if end < 0 {
+		return "", s, false
+	}
+	return s[1 : end+1], s[end+2:], true
+}
@@ -0,0 +1,186 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"poxy/internal/config"
+	"poxy/pkg/database"
+)
+
+func TestParseBrewfile(t *testing.T) {
+	brewfile := `# Essentials
+tap "homebrew/bundle"
+brew "git"
+brew 'ripgrep', args: ["with-pcre2"]
+brew "user/tools/fancy", restart_service: true
+cask "firefox"
+mas "Xcode", id: 497799835
+vscode "golang.go"
+cargo "bat"
+go "golang.org/x/tools/gopls"
+brew "git"
+`
+	plan, err := ParseBrewfile(strings.NewReader(brewfile))
+	if err != nil {
+		t.Fatalf("ParseBrewfile() error = %v", err)
+	}
+
+	var got []string
+	for _, pkg := range plan.Packages {
+		got = append(got, pkg.Source+"/"+pkg.Name)
+	}
+	want := []string{"brew/git", "brew/ripgrep", "brew/user/tools/fancy", "brew/firefox", "cargo/bat", "gobin/golang.org/x/tools/gopls"}
+	if !slices.Equal(got, want) {
+		t.Errorf("packages = %v, want %v", got, want)
+	}
+
+	if plan.Aliases["fancy"] != "user/tools/fancy" || len(plan.Aliases) != 1 {
+		t.Errorf("aliases = %v, want fancy = user/tools/fancy", plan.Aliases)
+	}
+	if len(plan.Skipped) != 3 {
+		t.Errorf("skipped = %v, want tap, mas and vscode", plan.Skipped)
+	}
+}
+
+func TestParseTopgrade(t *testing.T) {
+	data := []byte(`
+[misc]
+assume_yes = true
+disable = ["flatpak", "node", "tmux"]
+cleanup = true
+
+[linux]
+arch_package_manager = "paru"
+`)
+	plan, err := ParseTopgrade(data)
+	if err != nil {
+		t.Fatalf("ParseTopgrade() error = %v", err)
+	}
+
+	cfg := config.Default()
+	plan.Apply(cfg)
+
+	if !cfg.General.AutoConfirm {
+		t.Error("auto_confirm not set")
+	}
+	if got := cfg.Managers["pacman"].AURHelper; got != "paru" {
+		t.Errorf("aur_helper = %q, want paru", got)
+	}
+	if want := []string{"native", "snap", "aur"}; !slices.Equal(cfg.General.SourcePriority, want) {
+		t.Errorf("source_priority = %v, want %v", cfg.General.SourcePriority, want)
+	}
+
+	// tmux is not a poxy source and cleanup has no equivalent
+	if len(plan.Skipped) != 2 {
+		t.Errorf("skipped = %v, want 2 entries", plan.Skipped)
+	}
+}
+
+func TestParseTopgradeLegacy(t *testing.T) {
+	plan, err := ParseTopgrade([]byte("assume_yes = true\n"))
+	if err != nil {
+		t.Fatalf("ParseTopgrade() error = %v", err)
+	}
+	if len(plan.Settings) != 1 || plan.Settings[0].Key != "general.auto_confirm" {
+		t.Errorf("settings = %v, want general.auto_confirm", plan.Settings)
+	}
+}
+
+func TestYay(t *testing.T) {
+	dir := t.TempDir()
+	buildDir := filepath.Join(dir, "build")
+	if err := os.MkdirAll(filepath.Join(buildDir, "visual-studio-code-bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"buildDir": "`+buildDir+`", "sudoloop": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Yay(configPath, []string{"visual-studio-code-bin", "local-tool"})
+	if err != nil {
+		t.Fatalf("Yay() error = %v", err)
+	}
+
+	if len(plan.Packages) != 2 {
+		t.Fatalf("packages = %v, want 2", plan.Packages)
+	}
+	if plan.Packages[0].Reason != "built with yay" {
+		t.Errorf("cached package reason = %q", plan.Packages[0].Reason)
+	}
+	if plan.Packages[1].Reason == "built with yay" {
+		t.Errorf("uncached package reason = %q", plan.Packages[1].Reason)
+	}
+
+	cfg := config.Default()
+	cfg.Managers["pacman"] = config.ManagerConfig{AURHelper: "paru"}
+	plan.Apply(cfg)
+	if got := cfg.Managers["pacman"].AURHelper; got != "yay" {
+		t.Errorf("aur_helper = %q, want yay", got)
+	}
+}
+
+func TestApplyKeepsAliases(t *testing.T) {
+	plan := newPlan(FromBrewBundle, "")
+	plan.Aliases["fancy"] = "user/tools/fancy"
+	plan.Aliases["new"] = "user/tools/new"
+
+	cfg := config.Default()
+	cfg.Aliases["fancy"] = "mine"
+	plan.Apply(cfg)
+
+	if cfg.Aliases["fancy"] != "mine" || cfg.Aliases["new"] != "user/tools/new" {
+		t.Errorf("aliases = %v", cfg.Aliases)
+	}
+}
+
+func TestPlanDistro(t *testing.T) {
+	mappings := database.NewMappingStore()
+	mappings.Add(&database.Mapping{Canonical: "vim-gtk", Sources: map[string]string{"pacman": "gvim", "dnf": "vim-X11"}})
+
+	available := map[string]string{"git": "git", "python": "python3", "vim-x11": "vim-X11"}
+	lookup := func(name string) (string, bool) {
+		found, ok := available[name]
+		return found, ok && found == name
+	}
+
+	plan := PlanDistro("pacman", "dnf", []string{"gvim", "git", "python", "base-devel", "vim-x11"}, mappings, lookup)
+
+	var got []string
+	for _, tr := range plan.Translations {
+		got = append(got, tr.Name+">"+tr.To+"("+tr.Match+")")
+	}
+	want := []string{
+		"gvim>vim-X11(mapping)",
+		"git>git(same name)",
+		"python>python3(closest)",
+		"base-devel>()",
+		"vim-x11>vim-X11(same name)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("translations = %v, want %v", got, want)
+	}
+	if plan.Count(MatchMapping) != 1 || plan.Count("") != 1 {
+		t.Errorf("counts: mapping %d, none %d", plan.Count(MatchMapping), plan.Count(""))
+	}
+
+	m := plan.Manifest()
+	var installed []string
+	for _, pkg := range m.Packages {
+		installed = append(installed, pkg.Source+"/"+pkg.Name)
+	}
+	if !slices.Equal(installed, []string{"dnf/git", "dnf/vim-X11"}) {
+		t.Errorf("manifest packages = %v", installed)
+	}
+	if len(m.Unmapped) != 2 || m.Unmapped[0].Suggested != "python3" || m.Target != "dnf" {
+		t.Errorf("manifest unmapped = %+v", m.Unmapped)
+	}
+
+	if plan := PlanDistro("pacman", "dnf", []string{"git"}, mappings, nil); plan.Count("") != 1 {
+		t.Error("without a lookup, unmapped packages should stay unmapped")
+	}
+}
@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"poxy/internal/config"
+)
+
+// topgradeSteps maps topgrade steps to the poxy sources they upgrade.
+var topgradeSteps = map[string]string{
+	"flatpak": "flatpak",
+	"snap":    "snap",
+	"cargo":   "cargo",
+	"node":    "npm",
+	"go":      "gobin",
+	"brew":    "brew",
+}
+
+// topgradeAURHelpers are the arch_package_manager values poxy can use as
+// its AUR helper.
+var topgradeAURHelpers = []string{"yay", "paru"}
+
+// Topgrade reads a topgrade.toml.
+func Topgrade(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := ParseTopgrade(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	plan.File = path
+	return plan, nil
+}
+
+// ParseTopgrade maps a topgrade config to poxy settings. assume_yes turns on
+// auto_confirm, a yay or paru arch_package_manager becomes the AUR helper and
+// disabled steps that upgrade a poxy source drop it from source_priority.
+// Other keys are skipped.
+func ParseTopgrade(data []byte) (*Plan, error) {
+	var raw map[string]any
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	// Before topgrade 10 the [misc] keys were at the top level
+	doc := map[string]map[string]any{"misc": {}}
+	for key, value := range raw {
+		if table, ok := value.(map[string]any); ok {
+			for k, v := range table {
+				if doc[key] == nil {
+					doc[key] = make(map[string]any)
+				}
+				doc[key][k] = v
+			}
+		} else {
+			doc["misc"][key] = value
+		}
+	}
+
+	plan := newPlan(FromTopgrade, "")
+	for _, section := range sortedKeys(doc) {
+		for _, key := range sortedKeys(doc[section]) {
+			value := doc[section][key]
+			switch section + "." + key {
+			case "misc.assume_yes":
+				if yes, ok := value.(bool); ok && yes {
+					plan.Settings = append(plan.Settings, Setting{
+						Key:   "general.auto_confirm",
+						Value: "true",
+						Apply: func(cfg *config.Config) { cfg.General.AutoConfirm = true },
+					})
+				}
+			case "misc.disable":
+				if s, ok := disabledSources(plan, value); ok {
+					plan.Settings = append(plan.Settings, s)
+				}
+			case "linux.arch_package_manager":
+				helper, _ := value.(string)
+				if slices.Contains(topgradeAURHelpers, helper) {
+					plan.Settings = append(plan.Settings, setAURHelper(helper))
+				} else {
+					plan.skip("linux.arch_package_manager = %q (poxy supports yay and paru)", helper)
+				}
+			default:
+				plan.skip("%s.%s (no poxy equivalent)", section, key)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// disabledSources returns the setting that drops the sources of disabled
+// topgrade steps from source_priority, if any step upgrades a poxy source.
+func disabledSources(plan *Plan, value any) (Setting, bool) {
+	steps, _ := value.([]any)
+
+	var sources []string
+	for _, v := range steps {
+		step, _ := v.(string)
+		if source, ok := topgradeSteps[step]; ok {
+			sources = append(sources, source)
+		} else {
+			plan.skip("misc.disable %s (not a poxy source)", step)
+		}
+	}
+	if len(sources) == 0 {
+		return Setting{}, false
+	}
+
+	return Setting{
+		Key:   "general.source_priority",
+		Value: "without " + strings.Join(sources, ", "),
+		Apply: func(cfg *config.Config) {
+			cfg.General.SourcePriority = slices.DeleteFunc(cfg.General.SourcePriority, func(s string) bool {
+				return slices.Contains(sources, s)
+			})
+		},
+	}, true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
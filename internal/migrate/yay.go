@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// yayConfig is the part of yay's config.json poxy reads.
+type yayConfig struct {
+	BuildDir string `json:"buildDir"`
+}
+
+// Yay makes yay the AUR helper and imports the foreign (AUR) packages
+// installed, so poxy knows they come from the AUR. Packages yay still has
+// a build directory for are recorded as built with yay. configPath may be
+// "" if yay has no config file.
+func Yay(configPath string, foreign []string) (*Plan, error) {
+	plan := newPlan(FromYay, configPath)
+	plan.Settings = append(plan.Settings, setAURHelper("yay"))
+
+	buildDir, err := yayBuildDir(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range foreign {
+		reason := "foreign package, imported from yay"
+		if info, err := os.Stat(filepath.Join(buildDir, name)); err == nil && info.IsDir() {
+			reason = "built with yay"
+		}
+		plan.Packages = append(plan.Packages, Package{Source: "aur", Name: name, Reason: reason})
+	}
+	return plan, nil
+}
+
+// yayBuildDir returns the directory yay clones and builds packages in.
+func yayBuildDir(configPath string) (string, error) {
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return "", err
+		}
+		var c yayConfig
+		if err := json.Unmarshal(data, &c); err != nil {
+			return "", err
+		}
+		if c.BuildDir != "" {
+			return c.BuildDir, nil
+		}
+	}
+
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "yay"), nil
+	}
+	home, _ := os.UserHomeDir() //nolint:errcheck
+	return filepath.Join(home, ".cache", "yay"), nil
+}
@@ -0,0 +1,160 @@
+// Package pathconflict finds the commands an install would add that clash
+// with each other or with commands already on the PATH, and tells which
+// one a shell would run. It explains the "I installed it but the old
+// version still runs" surprise before it happens.
+package pathconflict
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// Command is a command a planned package would install.
+type Command struct {
+	Path    string // Where it would be installed, e.g. /snap/bin/foo
+	Package string
+	Source  string // Manager name, e.g. "snap"
+}
+
+// Name returns the name the command is run by.
+func (c Command) Name() string {
+	return filepath.Base(c.Path)
+}
+
+// Kind is the kind of a clash.
+type Kind string
+
+const (
+	// Shadowed means a command already on the PATH comes first, so the
+	// new one does not run by its name.
+	Shadowed Kind = "shadowed"
+	// TakesOver means the new command comes first on the PATH and runs
+	// in place of one already installed.
+	TakesOver Kind = "takes_over"
+	// Conflict means several sources of the plan install the command.
+	Conflict Kind = "conflict"
+)
+
+// Finding is a command that clashes.
+type Finding struct {
+	Kind    Kind
+	Name    string    // Command name
+	Planned []Command // The planned commands by that name
+	Winner  string    // Path of the command a shell would run
+	Others  []string  // Paths of the installed commands by that name that do not run
+}
+
+// Check finds the planned commands that clash with each other or with the
+// commands in the search path dirs, as returned by SearchPath. exists
+// reports whether an executable is at a path; nil checks the file system.
+// Commands planned outside the search path are left out, since they are
+// not run by name anyway.
+func Check(planned []Command, dirs []string, exists func(path string) bool) []Finding {
+	if exists == nil {
+		exists = isExecutable
+	}
+	rank := func(path string) int {
+		return slices.Index(dirs, filepath.Dir(path))
+	}
+
+	byName := make(map[string][]Command)
+	var names []string
+	for _, cmd := range planned {
+		if rank(cmd.Path) < 0 {
+			continue
+		}
+		name := cmd.Name()
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		if !slices.ContainsFunc(byName[name], func(c Command) bool { return c.Path == cmd.Path && c.Source == cmd.Source }) {
+			byName[name] = append(byName[name], cmd)
+		}
+	}
+	sort.Strings(names)
+
+	var findings []Finding
+	for _, name := range names {
+		cmds := byName[name]
+
+		// The first directory with the command is what a shell runs
+		winner := ""
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name)
+			if exists(path) || slices.ContainsFunc(cmds, func(c Command) bool { return c.Path == path }) {
+				winner = path
+				break
+			}
+		}
+
+		var others []string
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name)
+			if path == winner || slices.ContainsFunc(cmds, func(c Command) bool { return c.Path == path }) {
+				continue
+			}
+			if exists(path) {
+				others = append(others, path)
+			}
+		}
+
+		finding := Finding{Name: name, Planned: cmds, Winner: winner, Others: others}
+		plannedWins := slices.ContainsFunc(cmds, func(c Command) bool { return c.Path == winner })
+		switch {
+		case len(sources(cmds)) > 1:
+			finding.Kind = Conflict
+		case !plannedWins:
+			finding.Kind = Shadowed
+		case len(others) > 0:
+			finding.Kind = TakesOver
+		default:
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// SearchPath returns the directories of a PATH value in search order, with
+// symbolic links resolved and repeats dropped, so /bin and /usr/bin count
+// once on merged-/usr systems.
+func SearchPath(pathEnv string) []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+		dir = ResolveDir(dir)
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// ResolveDir returns dir with symbolic links resolved, or cleaned if it
+// cannot be resolved.
+func ResolveDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return filepath.Clean(dir)
+}
+
+// sources returns the distinct sources of cmds.
+func sources(cmds []Command) []string {
+	var names []string
+	for _, cmd := range cmds {
+		if !slices.Contains(names, cmd.Source) {
+			names = append(names, cmd.Source)
+		}
+	}
+	return names
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
@@ -0,0 +1,59 @@
+package pathconflict
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dirs := []string{"/home/u/.cargo/bin", "/usr/local/bin", "/usr/bin", "/snap/bin"}
+	installed := []string{"/usr/bin/rg", "/usr/local/bin/fd", "/usr/bin/fd", "/usr/bin/htop"}
+	exists := func(path string) bool { return slices.Contains(installed, path) }
+
+	planned := []Command{
+		{Path: "/snap/bin/rg", Package: "ripgrep", Source: "snap"},           // Hidden by /usr/bin/rg
+		{Path: "/home/u/.cargo/bin/fd", Package: "fd-find", Source: "cargo"}, // Hides both fd
+		{Path: "/usr/bin/htop", Package: "htop", Source: "apt"},              // Replaces itself
+		{Path: "/usr/bin/jq", Package: "jq", Source: "apt"},
+		{Path: "/snap/bin/jq", Package: "jq", Source: "snap"},         // Twice in the plan
+		{Path: "/opt/tool/bin/tool", Package: "tool", Source: "brew"}, // Not on PATH
+	}
+
+	findings := Check(planned, dirs, exists)
+	if len(findings) != 3 {
+		t.Fatalf("Check() found %d clashes, want 3: %+v", len(findings), findings)
+	}
+
+	fd, jq, rg := findings[0], findings[1], findings[2]
+	if fd.Kind != TakesOver || fd.Winner != "/home/u/.cargo/bin/fd" ||
+		!slices.Equal(fd.Others, []string{"/usr/local/bin/fd", "/usr/bin/fd"}) {
+		t.Errorf("fd = %+v, want the cargo fd taking over both", fd)
+	}
+	if jq.Kind != Conflict || jq.Winner != "/usr/bin/jq" || len(jq.Planned) != 2 {
+		t.Errorf("jq = %+v, want a conflict the APT jq wins", jq)
+	}
+	if rg.Kind != Shadowed || rg.Winner != "/usr/bin/rg" || rg.Planned[0].Source != "snap" {
+		t.Errorf("rg = %+v, want the snap rg shadowed by /usr/bin/rg", rg)
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	dir := t.TempDir()
+	usrBin := filepath.Join(dir, "usr", "bin")
+	if err := os.MkdirAll(usrBin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "bin")
+	if err := os.Symlink(filepath.Join("usr", "bin"), bin); err != nil {
+		t.Fatal(err)
+	}
+	realUsrBin, _ := filepath.EvalSymlinks(usrBin) //nolint:errcheck
+
+	got := SearchPath(usrBin + string(os.PathListSeparator) + bin + string(os.PathListSeparator) + string(os.PathListSeparator) + "/nonexistent/")
+	want := []string{realUsrBin, "/nonexistent"}
+	if !slices.Equal(got, want) {
+		t.Errorf("SearchPath() = %v, want %v", got, want)
+	}
+}
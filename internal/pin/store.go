@@ -0,0 +1,208 @@
+// Package pin records packages that poxy keeps at their installed version.
+package pin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
+
+	"go.etcd.io/bbolt"
+)
+
+const bucketPins = "pins"
+
+// Pin is a package excluded from upgrades.
+type Pin struct {
+	Name     string    `json:"name"`
+	Source   string    `json:"source"`
+	Version  string    `json:"version,omitempty"` // Installed version when pinned
+	Reason   string    `json:"reason,omitempty"`
+	Native   bool      `json:"native"` // Also held by the package manager itself
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// Key returns the key of the pin, "source/name".
+func (p Pin) Key() string {
+	return key(p.Source, p.Name)
+}
+
+func key(source, name string) string {
+	return source + "/" + name
+}
+
+// Store manages package pins using BoltDB.
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open opens or creates the pin database at the default location. If the
+// data directory is not writable, an existing database is opened read-only,
+// so pins are still honored.
+func Open() (*Store, error) {
+	if !config.DataDirWritable() {
+		return OpenReadOnly(config.PinPath())
+	}
+
+	return OpenPath(config.PinPath())
+}
+
+// OpenReadOnly opens an existing pin database without write access. It
+// fails with config.ErrDataDirReadOnly if the database does not exist.
+func OpenReadOnly(dbPath string) (*Store, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("%w: %s", config.ErrDataDirReadOnly, filepath.Dir(dbPath))
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
+		Timeout:  1 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pin database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// OpenPath opens or creates the pin database at the specified path.
+func OpenPath(dbPath string) (*Store, error) {
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
+		Timeout: 1 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pin database: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucketPins))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize buckets: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// Add saves a pin, replacing any existing pin of the same package.
+func (s *Store) Add(p Pin) error {
+	if p.PinnedAt.IsZero() {
+		p.PinnedAt = time.Now()
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal pin: %w", err)
+		}
+		return tx.Bucket([]byte(bucketPins)).Put([]byte(p.Key()), data)
+	})
+}
+
+// Remove deletes the pin of a package. It reports whether the package was pinned.
+func (s *Store) Remove(source, name string) (bool, error) {
+	var found bool
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketPins))
+		k := []byte(key(source, name))
+		if bucket.Get(k) == nil {
+			return nil
+		}
+		found = true
+		return bucket.Delete(k)
+	})
+
+	return found, err
+}
+
+// List returns all pins sorted by source and name.
+func (s *Store) List() ([]Pin, error) {
+	var pins []Pin
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(bucketPins)).ForEach(func(_, v []byte) error {
+			var p Pin
+			if err := json.Unmarshal(v, &p); err != nil {
+				return nil // Skip malformed entries
+			}
+			pins = append(pins, p)
+			return nil
+		})
+	})
+
+	return pins, err
+}
+
+// Set is a lookup of pins by key.
+type Set map[string]Pin
+
+// Load reads all pins from the default database.
+func Load() (Set, error) {
+	store, err := Open()
+	if errors.Is(err, config.ErrDataDirReadOnly) {
+		return Set{}, nil // No pin database, so nothing is pinned
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	pins, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(Set, len(pins))
+	for _, p := range pins {
+		set[p.Key()] = p
+	}
+	return set, nil
+}
+
+// Has reports whether a package is pinned.
+func (s Set) Has(source, name string) bool {
+	_, ok := s[key(source, name)]
+	return ok
+}
+
+// Names returns the sorted names of the packages pinned for a source.
+func (s Set) Names(source string) []string {
+	var names []string
+	for _, p := range s {
+		if p.Source == source {
+			names = append(names, p.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarkHeld marks pinned upgrade candidates as held.
+func (s Set) MarkHeld(candidates []manager.UpgradeCandidate) {
+	for i, c := range candidates {
+		if p, ok := s[key(c.Source, c.Name)]; ok {
+			candidates[i].Held = true
+			candidates[i].HeldReason = "pinned (poxy pin)"
+			if p.Reason != "" {
+				candidates[i].HeldReason = "pinned: " + p.Reason
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package pin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+func setupTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := OpenPath(filepath.Join(t.TempDir(), "test_pins.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestAddListRemove(t *testing.T) {
+	store := setupTestStore(t)
+
+	for _, p := range []Pin{
+		{Name: "vim", Source: "pacman", Version: "9.1"},
+		{Name: "firefox", Source: "flatpak", Reason: "extension breaks"},
+	} {
+		if err := store.Add(p); err != nil {
+			t.Fatalf("Add(%s) error = %v", p.Name, err)
+		}
+	}
+
+	pins, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(pins) != 2 || pins[0].Key() != "flatpak/firefox" || pins[1].Key() != "pacman/vim" {
+		t.Fatalf("List() = %+v, want flatpak/firefox and pacman/vim", pins)
+	}
+	if pins[1].PinnedAt.IsZero() {
+		t.Error("Add() should set PinnedAt")
+	}
+
+	found, err := store.Remove("pacman", "vim")
+	if err != nil || !found {
+		t.Fatalf("Remove() = %v, %v, want true", found, err)
+	}
+	if found, _ := store.Remove("pacman", "vim"); found {
+		t.Error("Remove() of an unpinned package should report false")
+	}
+
+	pins, _ = store.List()
+	if len(pins) != 1 {
+		t.Errorf("List() after Remove = %+v, want 1 pin", pins)
+	}
+}
+
+func TestSet(t *testing.T) {
+	set := Set{
+		"apt/vim":   {Name: "vim", Source: "apt"},
+		"apt/curl":  {Name: "curl", Source: "apt", Reason: "regression in 8.5"},
+		"brew/node": {Name: "node", Source: "brew"},
+	}
+
+	if !set.Has("apt", "vim") || set.Has("brew", "vim") {
+		t.Error("Has() should match source and name")
+	}
+	if got := set.Names("apt"); len(got) != 2 || got[0] != "curl" || got[1] != "vim" {
+		t.Errorf("Names(apt) = %v, want [curl vim]", got)
+	}
+
+	candidates := []manager.UpgradeCandidate{
+		{Name: "vim", Source: "apt"},
+		{Name: "curl", Source: "apt"},
+		{Name: "git", Source: "apt"},
+	}
+	set.MarkHeld(candidates)
+
+	if !candidates[0].Held || candidates[0].HeldReason != "pinned (poxy pin)" {
+		t.Errorf("vim = %+v, want held by pin", candidates[0])
+	}
+	if candidates[1].HeldReason != "pinned: regression in 8.5" {
+		t.Errorf("curl reason = %q", candidates[1].HeldReason)
+	}
+	if candidates[2].Held {
+		t.Error("unpinned package should not be held")
+	}
+}
@@ -0,0 +1,81 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ErrAllPinned is returned by Apply when every requested upgrade is pinned.
+var ErrAllPinned = errors.New("all packages to upgrade are pinned")
+
+// Apply adjusts upgrade options so pinned packages of mgr stay at their
+// installed version, returning the pinned packages left out.
+//
+// Pinned packages are dropped from an explicit package list. A full upgrade
+// becomes an upgrade of every upgradable package that is not pinned when the
+// manager can list upgrades, and otherwise uses UpgradeOpts.Exclude. Pins
+// held natively need neither, since the manager skips them itself.
+func (s Set) Apply(ctx context.Context, mgr manager.Manager, opts manager.UpgradeOpts) (manager.UpgradeOpts, []string, error) {
+	if len(opts.Packages) > 0 {
+		var keep, skipped []string
+		for _, name := range opts.Packages {
+			if s.Has(mgr.Name(), name) {
+				skipped = append(skipped, name)
+			} else {
+				keep = append(keep, name)
+			}
+		}
+		if len(keep) == 0 {
+			return opts, skipped, ErrAllPinned
+		}
+		opts.Packages = keep
+		return opts, skipped, nil
+	}
+
+	pinned := s.Names(mgr.Name())
+	if len(pinned) == 0 || s.allNative(mgr.Name()) {
+		return opts, pinned, nil
+	}
+
+	if checker, ok := mgr.(manager.UpgradeChecker); ok {
+		candidates, err := checker.ListUpgradable(ctx)
+		if err != nil {
+			return opts, nil, fmt.Errorf("failed to list upgrades: %w", err)
+		}
+
+		var skipped []string
+		for _, c := range candidates {
+			if s.Has(mgr.Name(), c.Name) {
+				skipped = append(skipped, c.Name)
+			} else {
+				opts.Packages = append(opts.Packages, c.Name)
+			}
+		}
+		if len(opts.Packages) == 0 {
+			return opts, skipped, ErrAllPinned
+		}
+		return opts, skipped, nil
+	}
+
+	if excluder, ok := mgr.(manager.UpgradeExcluder); ok && excluder.ExcludesUpgrades() {
+		opts.Exclude = append(opts.Exclude, pinned...)
+		return opts, pinned, nil
+	}
+
+	return opts, nil, fmt.Errorf("%s cannot leave pinned packages (%s) out of a full upgrade; upgrade packages by name or unpin them",
+		mgr.DisplayName(), strings.Join(pinned, ", "))
+}
+
+// allNative reports whether every pin of the source is held natively.
+func (s Set) allNative(source string) bool {
+	for _, p := range s {
+		if p.Source == source && !p.Native {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+// Package plan describes what installs, removals and upgrades would do,
+// as worked out by a dry run, so scripts can check it before poxy runs
+// them for real.
+package plan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Plan is the operations of a dry run.
+type Plan struct {
+	Operations []*Operation `json:"operations"`
+	// Commands run outside any operation, such as database refreshes
+	Commands []string `json:"commands,omitempty"`
+	// Warnings about the plan, such as commands it installs that others
+	// on the PATH would hide
+	Warnings []string `json:"warnings,omitempty"`
+
+	mu      sync.Mutex
+	current *Operation
+}
+
+// Operation is an install, removal or upgrade with one manager.
+type Operation struct {
+	Kind    string `json:"operation"` // "install", "uninstall" or "upgrade"
+	Manager string `json:"manager"`
+	// Scope is "user" if the packages are for the current user only, or
+	// "system" if they are for every user
+	Scope    string    `json:"scope,omitempty"`
+	Packages []Package `json:"packages"`
+	Commands []string  `json:"commands"`
+	// SizeBytes estimates the size of the packages, from the download or
+	// installed sizes their manager reports
+	SizeBytes int64 `json:"estimated_size_bytes,omitempty"`
+	// Reboot is set if the changes only apply after a reboot
+	Reboot bool `json:"requires_reboot,omitempty"`
+}
+
+// Package is a package an operation affects.
+type Package struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`         // Version it would have
+	CurrentVersion string `json:"current_version,omitempty"` // Version it has, for upgrades
+	Size           string `json:"size,omitempty"`            // As the manager reports it
+	SizeBytes      int64  `json:"size_bytes,omitempty"`
+}
+
+// New creates an empty plan.
+func New() *Plan {
+	return &Plan{Operations: []*Operation{}}
+}
+
+// Begin starts an operation; the commands recorded until End belong to it.
+func (p *Plan) Begin(kind, manager string, packages []Package) *Operation {
+	if packages == nil {
+		packages = []Package{}
+	}
+	op := &Operation{Kind: kind, Manager: manager, Packages: packages, Commands: []string{}}
+	for i := range op.Packages {
+		pkg := &op.Packages[i]
+		if pkg.SizeBytes == 0 {
+			pkg.SizeBytes = ParseSize(pkg.Size)
+		}
+		op.SizeBytes += pkg.SizeBytes
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Operations = append(p.Operations, op)
+	p.current = op
+	return op
+}
+
+// End ends the current operation.
+func (p *Plan) End() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = nil
+}
+
+// Record adds a command that would run to the current operation.
+func (p *Plan) Record(command string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil {
+		p.current.Commands = append(p.current.Commands, command)
+	} else {
+		p.Commands = append(p.Commands, command)
+	}
+}
+
+// Warn adds a warning about the plan.
+func (p *Plan) Warn(warning string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Warnings = append(p.Warnings, warning)
+}
+
+// Empty reports whether the dry run would have done nothing.
+func (p *Plan) Empty() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.Operations) == 0 && len(p.Commands) == 0
+}
+
+// SizeBytes returns the estimated size of all operations.
+func (p *Plan) SizeBytes() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var total int64
+	for _, op := range p.Operations {
+		total += op.SizeBytes
+	}
+	return total
+}
+
+// sizeUnits are the multipliers of the units managers report sizes in,
+// by their lowercased first letter.
+var sizeUnits = map[byte]float64{
+	'b': 1,
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+	't': 1 << 40,
+}
+
+// ParseSize parses a size as managers report it, such as "1.5 MiB",
+// "2,048 kB", "340k" or "12 M", to bytes. Decimal and binary units are
+// both taken as binary, which is close enough for an estimate. It returns
+// 0 for sizes it does not understand.
+func ParseSize(s string) int64 {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+		end++
+	}
+	n, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0
+	}
+
+	unit := strings.ToLower(strings.TrimSpace(s[end:]))
+	if unit == "" {
+		return int64(n)
+	}
+	mult, ok := sizeUnits[unit[0]]
+	if !ok {
+		return 0
+	}
+	return int64(n * mult)
+}
+
+// FormatSize formats bytes for display, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,86 @@
+package plan
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	p := New()
+	if !p.Empty() {
+		t.Error("a new plan should be empty")
+	}
+
+	p.Record("sudo apt-get update")
+	op := p.Begin("install", "apt", []Package{
+		{Name: "htop", Version: "3.2.2-2", Size: "152 kB"},
+		{Name: "vim", Size: "1.5 MiB"},
+	})
+	p.Record("sudo apt-get install -y htop vim")
+	p.End()
+	p.Record("apt-mark showmanual")
+
+	if len(op.Commands) != 1 || op.Commands[0] != "sudo apt-get install -y htop vim" {
+		t.Errorf("operation commands = %v", op.Commands)
+	}
+	if len(p.Commands) != 2 {
+		t.Errorf("commands outside operations = %v", p.Commands)
+	}
+	if want := int64(152<<10 + 1.5*(1<<20)); op.SizeBytes != want || p.SizeBytes() != want {
+		t.Errorf("SizeBytes = %d, want %d", op.SizeBytes, want)
+	}
+
+	p.Warn("htop from Snap will not run by name")
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	for _, want := range []string{`"operation":"install"`, `"manager":"apt"`, `"name":"htop"`, `"estimated_size_bytes":`, `"warnings":["htop`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON missing %s: %s", want, data)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"512", 512},
+		{"340k", 340 << 10},
+		{"2,048 kB", 2048 << 10},
+		{"1.5 MiB", 3 << 19},
+		{"12 M", 12 << 20},
+		{"1 GB", 1 << 30},
+		{"", 0},
+		{"unknown", 0},
+		{"3 parsecs", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParseSize(tt.size); got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KiB"},
+		{3 << 19, "1.5 MiB"},
+		{1 << 30, "1.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package record captures a poxy command, with what is needed to debug it,
+// in a single bundle that users can attach to a bug report, and plays the
+// bundles back. Bundles are cleaned of secrets before they are written.
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"poxy/internal/history"
+	"poxy/pkg/manager"
+)
+
+// FormatVersion is the version of the bundle format.
+const FormatVersion = 1
+
+// LogEnv is the environment variable that tells a recorded poxy process
+// where to write its structured log.
+const LogEnv = "POXY_RECORD_LOG"
+
+// maxPause is the longest pause Replay keeps between two chunks of output.
+const maxPause = 2 * time.Second
+
+// Bundle is a recorded command.
+type Bundle struct {
+	Version  int       `json:"version"`
+	Args     []string  `json:"args"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	ExitCode int       `json:"exit_code"`
+	// Error is the error the command ended with, if it failed
+	Error string `json:"error,omitempty"`
+
+	Environment Environment `json:"environment"`
+	// Config is the effective configuration, as TOML
+	Config string `json:"config,omitempty"`
+	// Log holds the structured log lines the command wrote
+	Log []string `json:"log,omitempty"`
+	// Operations are the history entries the command recorded, with
+	// their parsed errors
+	Operations []history.Entry `json:"operations,omitempty"`
+	// Anomalies is package manager output the parsers could not read
+	Anomalies  []manager.ParserAnomaly `json:"anomalies,omitempty"`
+	Transcript []Chunk                 `json:"transcript"`
+}
+
+// Environment summarizes the system a bundle was recorded on.
+type Environment struct {
+	PoxyVersion string            `json:"poxy_version"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	Distro      string            `json:"distro,omitempty"`
+	Native      string            `json:"native,omitempty"` // Native package manager
+	Managers    map[string]string `json:"managers,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
+}
+
+// Chunk is output the command wrote, at Offset from its start.
+type Chunk struct {
+	Offset time.Duration `json:"offset"`
+	Stream string        `json:"stream"` // "stdout" or "stderr"
+	Text   string        `json:"text"`
+}
+
+// Duration returns how long the command ran.
+func (b *Bundle) Duration() time.Duration {
+	return b.Finished.Sub(b.Started)
+}
+
+// Write saves the bundle to path, readable by the user only until they
+// decide to share it.
+func (b *Bundle) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Load reads the bundle at path.
+func Load(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s is not a poxy recording: %w", path, err)
+	}
+	if b.Version > FormatVersion {
+		return nil, fmt.Errorf("%s was recorded by a newer poxy (format %d)", path, b.Version)
+	}
+	return &b, nil
+}
+
+// Replay writes the transcript to stdout and stderr with its original
+// timing, shortening long pauses such as prompts the user thought about.
+// speed above 1 plays faster; 0 writes everything at once.
+func (b *Bundle) Replay(stdout, stderr io.Writer, speed float64) error {
+	var last time.Duration
+	for _, chunk := range b.Transcript {
+		if speed > 0 {
+			pause := time.Duration(float64(chunk.Offset-last) / speed)
+			time.Sleep(min(pause, maxPause))
+		}
+		last = chunk.Offset
+
+		w := stdout
+		if chunk.Stream == "stderr" {
+			w = stderr
+		}
+		if _, err := io.WriteString(w, chunk.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transcript records the output of a command as timed chunks.
+type Transcript struct {
+	mu     sync.Mutex
+	start  time.Time
+	chunks []Chunk
+}
+
+// NewTranscript starts a transcript now.
+func NewTranscript() *Transcript {
+	return &Transcript{start: time.Now()}
+}
+
+// Writer returns a writer that passes output of stream through to w and
+// records it.
+func (t *Transcript) Writer(stream string, w io.Writer) io.Writer {
+	return &transcriptWriter{t: t, stream: stream, w: w}
+}
+
+// Chunks returns the output recorded so far.
+func (t *Transcript) Chunks() []Chunk {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Chunk(nil), t.chunks...)
+}
+
+type transcriptWriter struct {
+	t      *Transcript
+	stream string
+	w      io.Writer
+}
+
+func (tw *transcriptWriter) Write(p []byte) (int, error) {
+	tw.t.mu.Lock()
+	tw.t.chunks = append(tw.t.chunks, Chunk{
+		Offset: time.Since(tw.t.start),
+		Stream: tw.stream,
+		Text:   string(p),
+	})
+	tw.t.mu.Unlock()
+	return tw.w.Write(p)
+}
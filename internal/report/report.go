@@ -0,0 +1,179 @@
+// Package report writes human-readable summaries of unattended operations,
+// so headless machines report what changed.
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"poxy/internal/config"
+
+	"github.com/mattn/go-isatty"
+)
+
+// commandTimeout bounds a report command, so a hung mailer cannot hold up
+// the operation that was reported.
+const commandTimeout = time.Minute
+
+// Report summarizes one operation.
+type Report struct {
+	Operation string // Command that ran, e.g. "upgrade"
+	Host      string
+	Started   time.Time
+	Finished  time.Time
+	Err       error
+	Sections  []Section
+}
+
+// Section is a titled list of report lines, such as the packages upgraded.
+type Section struct {
+	Title string
+	Lines []string
+}
+
+// New starts a report of operation on this host.
+func New(operation string) *Report {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return &Report{
+		Operation: operation,
+		Host:      host,
+		Started:   time.Now(),
+	}
+}
+
+// Add adds a section. Sections without lines are left out.
+func (r *Report) Add(title string, lines ...string) {
+	if len(lines) == 0 {
+		return
+	}
+	r.Sections = append(r.Sections, Section{Title: title, Lines: lines})
+}
+
+// Finish records the outcome of the operation.
+func (r *Report) Finish(err error) {
+	r.Finished = time.Now()
+	r.Err = err
+}
+
+// Status returns "succeeded" or "failed".
+func (r *Report) Status() string {
+	if r.Err != nil {
+		return "failed"
+	}
+	return "succeeded"
+}
+
+// Subject returns a one-line summary, used as the mail subject.
+func (r *Report) Subject() string {
+	return fmt.Sprintf("[poxy] %s on %s %s", r.Operation, r.Host, r.Status())
+}
+
+// String renders the report as a mail message: a Subject header, a blank
+// line and the body.
+func (r *Report) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Subject: %s\n\n", r.Subject())
+	fmt.Fprintf(&b, "poxy %s on %s\n\n", r.Operation, r.Host)
+	fmt.Fprintf(&b, "Started:  %s\n", r.Started.Format("2006-01-02 15:04:05"))
+	if !r.Finished.IsZero() {
+		fmt.Fprintf(&b, "Finished: %s (took %s)\n", r.Finished.Format("2006-01-02 15:04:05"),
+			r.Finished.Sub(r.Started).Round(time.Second))
+	}
+	fmt.Fprintf(&b, "Result:   %s\n", r.Status())
+	if r.Err != nil {
+		fmt.Fprintf(&b, "Error:    %v\n", r.Err)
+	}
+
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "\n%s:\n", section.Title)
+		for _, line := range section.Lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+
+	return b.String()
+}
+
+// Unattended returns true if poxy runs without a terminal, as it does from
+// cron or a systemd timer.
+func Unattended() bool {
+	return !isatty.IsTerminal(os.Stdin.Fd())
+}
+
+// ShouldReport returns true if operations of this run are reported with
+// cfg.
+func ShouldReport(cfg config.ReportConfig) bool {
+	return cfg.Enabled() && (cfg.Always || Unattended())
+}
+
+// Deliver appends the report to the configured file and pipes it to the
+// configured command. Both are attempted; the first error is returned.
+func Deliver(ctx context.Context, r *Report, cfg config.ReportConfig) error {
+	text := r.String()
+	var firstErr error
+
+	if cfg.File != "" {
+		if err := appendFile(cfg.File, text); err != nil {
+			firstErr = fmt.Errorf("failed to write report to %s: %w", cfg.File, err)
+		}
+	}
+
+	if cfg.Command != "" {
+		if err := runCommand(ctx, cfg.Command, r, text); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("report command failed: %w", err)
+		}
+	}
+
+	return firstErr
+}
+
+// appendFile appends a report to path, separated from the previous one.
+func appendFile(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runCommand runs command through sh with the report on its stdin. The
+// subject and status are also passed in POXY_REPORT_SUBJECT and
+// POXY_REPORT_STATUS, for commands that take them as arguments.
+func runCommand(ctx context.Context, command string, r *Report, text string) error {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"POXY_REPORT_SUBJECT="+r.Subject(),
+		"POXY_REPORT_STATUS="+r.Status(),
+	)
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"poxy/internal/config"
+)
+
+func TestReportString(t *testing.T) {
+	r := New("upgrade")
+	r.Host = "router"
+	r.Add("Upgraded (1)", "^ vim (9.0 -> 9.1) [apt]")
+	r.Add("Empty")
+	r.Finish(nil)
+
+	text := r.String()
+	for _, want := range []string{
+		"Subject: [poxy] upgrade on router succeeded\n\n",
+		"Result:   succeeded",
+		"Upgraded (1):\n  ^ vim (9.0 -> 9.1) [apt]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("String() missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Empty") {
+		t.Error("String() should leave out sections without lines")
+	}
+
+	r.Finish(errors.New("dpkg was interrupted"))
+	if r.Status() != "failed" || !strings.Contains(r.String(), "Error:    dpkg was interrupted") {
+		t.Errorf("failed report = %q", r.String())
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "logs", "poxy.log")
+	piped := filepath.Join(dir, "piped")
+
+	r := New("snapshot prune")
+	r.Finish(nil)
+
+	cfg := config.ReportConfig{
+		File:    file,
+		Command: `cat > "` + piped + `"; echo "$POXY_REPORT_STATUS" >> "` + piped + `"`,
+	}
+	if err := Deliver(context.Background(), r, cfg); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+	if err := Deliver(context.Background(), r, cfg); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+
+	written, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(written), "Subject:") != 2 {
+		t.Errorf("report file should hold both reports:\n%s", written)
+	}
+
+	output, err := os.ReadFile(piped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(output), "Subject: [poxy] snapshot prune") || !strings.HasSuffix(string(output), "succeeded\n") {
+		t.Errorf("command input = %q", output)
+	}
+}
+
+func TestDeliverCommandError(t *testing.T) {
+	r := New("upgrade")
+	err := Deliver(context.Background(), r, config.ReportConfig{Command: "echo no mailer >&2; exit 1"})
+	if err == nil || !strings.Contains(err.Error(), "no mailer") {
+		t.Errorf("Deliver() error = %v, want the command's stderr", err)
+	}
+}
+
+func TestShouldReport(t *testing.T) {
+	if ShouldReport(config.ReportConfig{Always: true}) {
+		t.Error("ShouldReport() = true without a file or command")
+	}
+	if !ShouldReport(config.ReportConfig{File: "/tmp/report", Always: true}) {
+		t.Error("ShouldReport() = false with always set")
+	}
+}
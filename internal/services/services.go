@@ -0,0 +1,110 @@
+// Package services finds the systemd units a package ships and enables or
+// disables them, so poxy can offer to start services after an install and
+// stop them again on undo.
+package services
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// unitDirs are the directories packages install system units into.
+var unitDirs = []string{
+	"/usr/lib/systemd/system/",
+	"/lib/systemd/system/",
+	"/etc/systemd/system/",
+}
+
+// unitSuffixes are the unit types worth offering to enable. Mounts,
+// targets and slices are pulled in by other units.
+var unitSuffixes = []string{".service", ".socket", ".timer", ".path"}
+
+// Available reports whether the system is running systemd.
+func Available() bool {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+	_, err := os.Stat("/run/systemd/system")
+	return err == nil
+}
+
+// Units returns the names of the system units among files, sorted and
+// without duplicates. Template units (foo@.service) and drop-ins are left
+// out, since they cannot be enabled as they are.
+func Units(files []string) []string {
+	seen := make(map[string]bool)
+	var units []string
+	for _, file := range files {
+		dir, name := filepath.Split(file)
+		if !isUnitDir(dir) || strings.Contains(name, "@.") || !hasUnitSuffix(name) {
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			units = append(units, name)
+		}
+	}
+	sort.Strings(units)
+	return units
+}
+
+// isUnitDir reports whether dir is one of unitDirs.
+func isUnitDir(dir string) bool {
+	for _, d := range unitDirs {
+		if dir == d {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUnitSuffix reports whether name is a unit type worth enabling.
+func hasUnitSuffix(name string) bool {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Disabled returns the units that can be enabled but are not. Units that
+// are already enabled, static (no [Install] section) or masked are left
+// out.
+func Disabled(ctx context.Context, units []string) []string {
+	var disabled []string
+	for _, unit := range units {
+		// is-enabled exits non-zero for disabled units, so only the
+		// output matters
+		out, _ := exec.CommandContext(ctx, "systemctl", "is-enabled", unit).Output() //nolint:errcheck
+		if strings.TrimSpace(string(out)) == "disabled" {
+			disabled = append(disabled, unit)
+		}
+	}
+	return disabled
+}
+
+// Enabled returns the units that are enabled.
+func Enabled(ctx context.Context, units []string) []string {
+	var enabled []string
+	for _, unit := range units {
+		if exec.CommandContext(ctx, "systemctl", "is-enabled", "--quiet", unit).Run() == nil {
+			enabled = append(enabled, unit)
+		}
+	}
+	return enabled
+}
+
+// EnableArgs returns the command that enables and starts units.
+func EnableArgs(units []string) []string {
+	return append([]string{"systemctl", "enable", "--now"}, units...)
+}
+
+// DisableArgs returns the command that stops and disables units.
+func DisableArgs(units []string) []string {
+	return append([]string{"systemctl", "disable", "--now"}, units...)
+}
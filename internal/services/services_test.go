@@ -0,0 +1,39 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUnits(t *testing.T) {
+	files := []string{
+		"/usr/bin/dockerd",
+		"/usr/lib/systemd/system/",
+		"/usr/lib/systemd/system/docker.service",
+		"/usr/lib/systemd/system/docker.socket",
+		"/usr/lib/systemd/system/getty@.service",
+		"/usr/lib/systemd/system/docker.service.d/override.conf",
+		"/usr/lib/systemd/system/docker.mount",
+		"/usr/lib/systemd/user/pipewire.service",
+		"/lib/systemd/system/ssh.service",
+		"/lib/systemd/system/fstrim.timer",
+		"/usr/share/doc/docker/example.service",
+		"/usr/lib/systemd/system/docker.service", // Listed twice, e.g. by merged /usr
+	}
+
+	want := []string{"docker.service", "docker.socket", "fstrim.timer", "ssh.service"}
+	if got := Units(files); !slices.Equal(got, want) {
+		t.Errorf("Units() = %v, want %v", got, want)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	units := []string{"docker.socket", "cups.service"}
+
+	if got, want := EnableArgs(units), []string{"systemctl", "enable", "--now", "docker.socket", "cups.service"}; !slices.Equal(got, want) {
+		t.Errorf("EnableArgs() = %v, want %v", got, want)
+	}
+	if got, want := DisableArgs(units), []string{"systemctl", "disable", "--now", "docker.socket", "cups.service"}; !slices.Equal(got, want) {
+		t.Errorf("DisableArgs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+// Package shellenv finds directories installed commands land in that the
+// shell does not search, and adds the lines that put them on the PATH to
+// the user's shell startup file, so commands work right after an install.
+package shellenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Shell is a shell whose startup file poxy can set up.
+type Shell string
+
+// Shells poxy knows the startup files of. Other shells are treated as sh.
+const (
+	Bash Shell = "bash"
+	Zsh  Shell = "zsh"
+	Fish Shell = "fish"
+	Sh   Shell = "sh"
+)
+
+// Detect returns the shell at path, such as $SHELL.
+func Detect(path string) Shell {
+	switch name := Shell(filepath.Base(path)); name {
+	case Bash, Zsh, Fish:
+		return name
+	}
+	return Sh
+}
+
+// RCFile returns the startup file of shell in home that interactive shells
+// read.
+func RCFile(shell Shell, home string) string {
+	switch shell {
+	case Bash:
+		return filepath.Join(home, ".bashrc")
+	case Zsh:
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc")
+		}
+		return filepath.Join(home, ".zshrc")
+	case Fish:
+		return filepath.Join(home, ".config", "fish", "config.fish")
+	}
+	return filepath.Join(home, ".profile")
+}
+
+// OnPath reports whether dir is one of the directories of path, a list
+// such as $PATH.
+func OnPath(dir, path string) bool {
+	dir = filepath.Clean(dir)
+	for _, entry := range filepath.SplitList(path) {
+		if entry != "" && filepath.Clean(entry) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// Lines returns the lines that put dir, the bin directory of manager, on
+// the PATH of shell. Homebrew's shellenv is used for Homebrew, since it
+// sets up more than the PATH. Paths under home are written relative to
+// $HOME.
+func Lines(shell Shell, manager, dir, home string) []string {
+	if manager == "brew" {
+		brew := filepath.Join(dir, "brew")
+		if shell == Fish {
+			return []string{fmt.Sprintf("%s shellenv | source", brew)}
+		}
+		return []string{fmt.Sprintf(`eval "$(%s shellenv)"`, brew)}
+	}
+
+	if home != "" && strings.HasPrefix(dir, home+string(filepath.Separator)) {
+		dir = "$HOME" + dir[len(home):]
+	}
+	if shell == Fish {
+		return []string{fmt.Sprintf("fish_add_path %s", dir)}
+	}
+	return []string{fmt.Sprintf(`export PATH="%s:$PATH"`, dir)}
+}
+
+// Missing returns the lines content does not have yet.
+func Missing(content string, lines []string) []string {
+	present := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	var missing []string
+	for _, line := range lines {
+		if !present[strings.TrimSpace(line)] {
+			missing = append(missing, line)
+		}
+	}
+	return missing
+}
+
+// Append adds lines to the startup file rc after a comment saying why. An
+// existing file is first copied to a backup next to it, whose path is
+// returned; a missing file is created.
+func Append(rc, why string, lines []string) (string, error) {
+	content, err := os.ReadFile(rc)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", rc, err)
+	}
+
+	var backup string
+	mode := os.FileMode(0o644)
+	if err == nil {
+		if info, statErr := os.Stat(rc); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		backup = fmt.Sprintf("%s.poxy-%s.bak", rc, time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(backup, content, mode); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", rc, err)
+		}
+	}
+
+	var b strings.Builder
+	b.Write(content)
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\n# Added by poxy: %s\n", why)
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rc), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(rc), err)
+	}
+	if err := os.WriteFile(rc, []byte(b.String()), mode); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", rc, err)
+	}
+	return backup, nil
+}
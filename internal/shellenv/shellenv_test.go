@@ -0,0 +1,103 @@
+package shellenv
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := map[string]Shell{
+		"/bin/bash":           Bash,
+		"/usr/bin/zsh":        Zsh,
+		"/usr/local/bin/fish": Fish,
+		"/bin/dash":           Sh,
+		"":                    Sh,
+	}
+
+	for path, want := range tests {
+		if got := Detect(path); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestOnPath(t *testing.T) {
+	path := strings.Join([]string{"/usr/local/bin", "/usr/bin", "/home/me/.cargo/bin/"}, string(os.PathListSeparator))
+
+	if !OnPath("/home/me/.cargo/bin", path) {
+		t.Error("OnPath() should ignore trailing slashes")
+	}
+	if OnPath("/home/me/go/bin", path) {
+		t.Error("OnPath() found a directory that is not on the path")
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		shell   Shell
+		manager string
+		dir     string
+		want    string
+	}{
+		{Bash, "cargo", "/home/me/.cargo/bin", `export PATH="$HOME/.cargo/bin:$PATH"`},
+		{Fish, "gobin", "/home/me/go/bin", "fish_add_path $HOME/go/bin"},
+		{Zsh, "snap", "/snap/bin", `export PATH="/snap/bin:$PATH"`},
+		{Bash, "brew", "/home/linuxbrew/.linuxbrew/bin", `eval "$(/home/linuxbrew/.linuxbrew/bin/brew shellenv)"`},
+		{Fish, "brew", "/opt/homebrew/bin", "/opt/homebrew/bin/brew shellenv | source"},
+	}
+
+	for _, tt := range tests {
+		if got := Lines(tt.shell, tt.manager, tt.dir, "/home/me"); !slices.Equal(got, []string{tt.want}) {
+			t.Errorf("Lines(%s, %s) = %v, want %q", tt.shell, tt.manager, got, tt.want)
+		}
+	}
+}
+
+func TestMissing(t *testing.T) {
+	content := "alias ll='ls -l'\n  export PATH=\"$HOME/.cargo/bin:$PATH\"\n"
+	lines := []string{`export PATH="$HOME/.cargo/bin:$PATH"`, `export PATH="$HOME/go/bin:$PATH"`}
+
+	want := []string{`export PATH="$HOME/go/bin:$PATH"`}
+	if got := Missing(content, lines); !slices.Equal(got, want) {
+		t.Errorf("Missing() = %v, want %v", got, want)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	dir := t.TempDir()
+	rc := filepath.Join(dir, ".bashrc")
+	if err := os.WriteFile(rc, []byte("alias ll='ls -l'"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := Append(rc, "commands installed with cargo", []string{`export PATH="$HOME/.cargo/bin:$PATH"`})
+	if err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	saved, err := os.ReadFile(backup)
+	if err != nil || string(saved) != "alias ll='ls -l'" {
+		t.Errorf("backup = %q, %v; want the original file", saved, err)
+	}
+	content, _ := os.ReadFile(rc) //nolint:errcheck
+	want := "alias ll='ls -l'\n\n# Added by poxy: commands installed with cargo\nexport PATH=\"$HOME/.cargo/bin:$PATH\"\n"
+	if string(content) != want {
+		t.Errorf("rc file = %q, want %q", content, want)
+	}
+	if info, err := os.Stat(rc); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Append() should keep the file mode, got %v", info.Mode())
+	}
+
+	// A missing file is created, with nothing to back up
+	fishRC := filepath.Join(dir, ".config", "fish", "config.fish")
+	backup, err = Append(fishRC, "commands installed with Go", []string{"fish_add_path $HOME/go/bin"})
+	if err != nil || backup != "" {
+		t.Fatalf("Append() = %q, %v; want a new file without backup", backup, err)
+	}
+	if _, err := os.Stat(fishRC); err != nil {
+		t.Errorf("Append() did not create %s: %v", fishRC, err)
+	}
+}
@@ -11,8 +11,11 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"poxy/internal/aurwatch"
 	"poxy/internal/config"
+	"poxy/internal/daemon"
 	"poxy/internal/history"
+	"poxy/internal/pin"
 	"poxy/pkg/database"
 	"poxy/pkg/manager"
 )
@@ -30,6 +33,7 @@ type (
 	}
 
 	historyLoadedMsg struct {
+		filter  string
 		entries []history.Entry
 		err     error
 	}
@@ -39,6 +43,23 @@ type (
 		message string
 		err     error
 	}
+
+	managerVersionsMsg struct {
+		versions map[string]string
+	}
+
+	pinsLoadedMsg struct {
+		pins pin.Set
+	}
+
+	dependentsMsg struct {
+		pkg        manager.Package
+		dependents []string
+	}
+
+	daemonStatusMsg struct {
+		status *daemon.Status
+	}
 )
 
 // App wraps the Model with bubbletea components
@@ -46,10 +67,15 @@ type App struct {
 	*Model
 	spinner   spinner.Model
 	textInput textinput.Model
+
+	filterBefore string // Filter to restore if filter input is cancelled
 }
 
+// searchLimit caps the number of search results shown
+const searchLimit = 100
+
 // NewApp creates a new TUI application
-func NewApp(registry *manager.Registry, cfg *config.Config, historyStore *history.Store, searchIndex *database.Index) *App {
+func NewApp(registry *manager.Registry, cfg *config.Config, historyStore *history.Store, searchIndex *database.Index, opts Options) *App {
 	// Initialize spinner
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
@@ -62,7 +88,7 @@ func NewApp(registry *manager.Registry, cfg *config.Config, historyStore *histor
 	ti.Width = 40
 
 	return &App{
-		Model:     NewModel(registry, cfg, historyStore, searchIndex),
+		Model:     NewModel(registry, cfg, historyStore, searchIndex, opts),
 		spinner:   sp,
 		textInput: ti,
 	}
@@ -70,11 +96,40 @@ func NewApp(registry *manager.Registry, cfg *config.Config, historyStore *histor
 
 // Init implements tea.Model
 func (a *App) Init() tea.Cmd {
-	return tea.Batch(
-		a.spinner.Tick,
+	cmds := []tea.Cmd{
 		a.loadPackages(),
 		a.loadHistory(),
-	)
+		a.loadManagerVersions(),
+		a.loadDaemonStatus(),
+		a.loadPins(),
+	}
+	switch {
+	case a.activeView == ViewSearch && a.searchQuery != "":
+		cmds = append(cmds, a.searchPackages(a.searchQuery))
+	case a.activeView == ViewUpdates:
+		cmds = append(cmds, a.loadUpgrades())
+	case a.activeView == ViewConfigFiles:
+		cmds = append(cmds, a.loadConfigFiles())
+	case a.activeView == ViewSystem:
+		cmds = append(cmds, a.probeSources())
+	}
+	if !a.reducedMotion() {
+		cmds = append(cmds, a.spinner.Tick)
+	}
+	return tea.Batch(cmds...)
+}
+
+// reducedMotion reports whether animations are disabled in the config.
+func (a *App) reducedMotion() bool {
+	return a.config != nil && a.config.Output.ReducedMotion
+}
+
+// activityIndicator returns the spinner, or a static marker in reduced-motion mode.
+func (a *App) activityIndicator() string {
+	if a.reducedMotion() {
+		return a.spinner.Style.Render("*")
+	}
+	return a.spinner.View()
 }
 
 // Update implements tea.Model
@@ -91,26 +146,38 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if a.showConfirm {
 			switch msg.String() {
 			case "y", "Y", "enter":
-				a.ConfirmYes()
+				return a, a.ConfirmYes()
 			case "n", "N", "esc", "q":
 				a.ConfirmNo()
 			}
 			return a, nil
 		}
 
+		if a.paletteOpen {
+			cmds = append(cmds, a.handlePaletteKey(msg))
+			break
+		}
+
 		// Handle input mode
 		if a.inputMode {
 			switch msg.String() {
 			case "enter":
-				a.FinishInput()
-				return a, nil
+				return a, a.FinishInput()
 			case "esc":
+				if a.inputPrompt == filterPrompt {
+					a.SetFilterText(a.filterBefore)
+				}
 				a.CancelInput()
-				return a, nil
+				return a, a.refreshHistory()
 			default:
 				var cmd tea.Cmd
 				a.textInput, cmd = a.textInput.Update(msg)
 				a.inputValue = a.textInput.Value()
+				// Filters apply as you type
+				if a.inputPrompt == filterPrompt {
+					a.SetFilterText(a.inputValue)
+					cmds = append(cmds, a.details.Request(a.VisiblePackages()), a.refreshHistory())
+				}
 				cmds = append(cmds, cmd)
 				return a, tea.Batch(cmds...)
 			}
@@ -118,6 +185,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Global keybindings
 		switch {
+		case key.Matches(msg, a.keys.Palette):
+			a.openPalette()
+
 		case key.Matches(msg, a.keys.Quit):
 			a.quitting = true
 			return a, tea.Quit
@@ -143,6 +213,10 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.SetTab(3)
 		case key.Matches(msg, a.keys.Tab5):
 			a.SetTab(4)
+		case key.Matches(msg, a.keys.Tab6):
+			a.SetTab(5)
+		case key.Matches(msg, a.keys.Tab7):
+			a.SetTab(6)
 
 		case key.Matches(msg, a.keys.Left):
 			a.PrevTab()
@@ -171,8 +245,20 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Actions
 		case key.Matches(msg, a.keys.Enter):
-			if a.activeView == ViewPackages || a.activeView == ViewSearch {
+			switch a.activeView {
+			case ViewPackages, ViewSearch:
 				a.ShowDetails()
+				cmds = append(cmds, a.loadAURDetails(), a.loadAppStream(), a.loadPermissions())
+			case ViewUpdates:
+				a.queueSelectedUpgrades()
+			case ViewConfigFiles:
+				cmds = append(cmds, a.showConfigDiff())
+			}
+
+		case key.Matches(msg, a.keys.Select):
+			if a.activeView == ViewUpdates {
+				a.ToggleUpgradeSelection()
+				a.MoveCursor(1)
 			}
 
 		case key.Matches(msg, a.keys.Search):
@@ -182,49 +268,159 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.startFilter()
 
 		case key.Matches(msg, a.keys.Install):
-			if pkg := a.SelectedPackage(); pkg != nil && !pkg.Installed {
-				a.ShowConfirm(fmt.Sprintf("Install %s?", pkg.Name), func() {
-					cmds = append(cmds, a.installPackage(pkg.Name, pkg.Source))
-				})
-			}
+			a.confirmInstall()
+
+		case key.Matches(msg, a.keys.SwitchSource):
+			a.switchSource()
 
 		case key.Matches(msg, a.keys.Uninstall):
-			if pkg := a.SelectedPackage(); pkg != nil && pkg.Installed {
-				a.ShowConfirm(fmt.Sprintf("Remove %s?", pkg.Name), func() {
-					cmds = append(cmds, a.uninstallPackage(pkg.Name, pkg.Source))
-				})
+			cmds = append(cmds, a.confirmRemove())
+
+		case key.Matches(msg, a.keys.Permissions):
+			if a.activeView == ViewDetails {
+				a.startPermissionOverride()
 			}
 
 		case key.Matches(msg, a.keys.Update):
-			a.ShowConfirm("Update package databases?", func() {
-				cmds = append(cmds, a.updateDatabases())
+			a.ShowConfirm("Update package databases?", func() tea.Cmd {
+				return a.updateDatabases()
 			})
+
+		// Queue
+		case key.Matches(msg, a.keys.UpgradeSource):
+			if a.activeView == ViewUpdates {
+				a.queueAllUpgrades()
+			} else {
+				a.confirmSourceUpgrade()
+			}
+
+		case key.Matches(msg, a.keys.CancelItem):
+			if a.activeView == ViewQueue {
+				if item := a.SelectedQueueItem(); item != nil {
+					switch {
+					case a.queue.Cancel(item.ID):
+						a.SetSuccess(fmt.Sprintf("Cancelled #%d", item.ID))
+					case item.Status == QueueRunning:
+						a.SetError(fmt.Sprintf("#%d is running and cannot be cancelled", item.ID))
+					default:
+						a.SetError(fmt.Sprintf("#%d has already finished", item.ID))
+					}
+				}
+			}
+
+		case key.Matches(msg, a.keys.ClearQueue):
+			if a.activeView == ViewQueue {
+				a.queue.ClearFinished()
+				a.GoToTop()
+			}
+
+		// Config files
+		case key.Matches(msg, a.keys.Merge):
+			cmds = append(cmds, a.mergeConfigFile())
+		case key.Matches(msg, a.keys.Replace):
+			a.confirmConfigReplace()
+		case key.Matches(msg, a.keys.Keep):
+			a.confirmConfigKeep()
 		}
 
+	case upgradesLoadedMsg:
+		a.upgradesLoading = false
+		a.upgradesLoaded = true
+		a.upgrades = msg.upgrades
+		a.upgradesFailed = msg.failed
+		a.aurAlerts = aurwatch.ByPackage(msg.alerts)
+		a.pins.MarkHeld(a.upgrades)
+
+	case configFilesLoadedMsg:
+		a.configLoading = false
+		a.configLoaded = true
+		a.configFiles = msg.files
+		a.configErr = msg.err
+
+	case configDiffMsg:
+		a.SetLoading(false, "")
+		if msg.err != nil {
+			a.SetError(msg.err.Error())
+		} else {
+			a.configDiff = msg.diff
+			a.configDiffFile = &msg.file
+			a.cursors[ViewConfigDiff] = 0
+			a.activeView = ViewConfigDiff
+		}
+
+	case configActionMsg:
+		cmds = append(cmds, a.handleConfigAction(msg))
+
+	case aurDetailsMsg:
+		a.aurDetails = &msg
+
+	case permissionsMsg:
+		a.permissions = &msg
+
+	case permissionsChangedMsg:
+		cmds = append(cmds, a.handlePermissionsChanged(msg))
+
+	case appstreamLoadedMsg:
+		a.appstreamLoading = false
+		a.appstream = msg.catalog
+
 	case packagesLoadedMsg:
 		a.SetLoading(false, "")
 		if msg.err != nil {
 			a.SetError(msg.err.Error())
 		} else {
 			a.installedPkgs = msg.packages
+			// Install dates and sizes may have changed
+			a.details.Reset()
 		}
 
 	case searchResultsMsg:
 		a.SetLoading(false, "")
-		if msg.err != nil {
+		if msg.err == nil || len(msg.results) > 0 {
+			a.searchResults, a.searchGroups = groupSearchResults(a.resolver.Mappings(), msg.results)
+		}
+		switch {
+		case msg.err != nil && len(msg.results) > 0:
+			// Some sources did not answer in time
+			a.SetError("Partial results: " + msg.err.Error())
+		case msg.err != nil:
 			a.SetError(msg.err.Error())
-		} else {
-			a.searchResults = msg.results
-			if len(msg.results) == 0 {
-				a.SetError("No packages found")
-			}
+		case len(msg.results) == 0:
+			a.SetError("No packages found")
 		}
 
 	case historyLoadedMsg:
-		if msg.err == nil {
+		// Results for a filter since changed are dropped
+		if msg.err == nil && msg.filter == a.historyFilter {
 			a.historyEntries = msg.entries
 		}
 
+	case managerVersionsMsg:
+		a.managerVersions = msg.versions
+
+	case sourceHealthMsg:
+		a.sourcesLoading = false
+		a.sourcesLoaded = true
+		a.sourceHealth = msg.health
+
+	case pinsLoadedMsg:
+		a.pins = msg.pins
+		a.pins.MarkHeld(a.upgrades)
+
+	case daemonStatusMsg:
+		a.daemonStatus = msg.status
+
+	case dependentsMsg:
+		a.SetLoading(false, "")
+		pkg := msg.pkg
+		var detail string
+		if len(msg.dependents) > 0 {
+			detail = "Required by: " + summarizeNames(msg.dependents, 6)
+		}
+		a.ShowConfirmWithDetail(fmt.Sprintf("Queue removal of %s?", pkg.Name), detail, func() tea.Cmd {
+			return a.enqueue(history.OpUninstall, pkg.Source, []string{pkg.Name})
+		})
+
 	case operationCompleteMsg:
 		a.SetLoading(false, "")
 		if msg.err != nil {
@@ -233,6 +429,23 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.SetSuccess(msg.message)
 			// Reload packages after successful operation
 			cmds = append(cmds, a.loadPackages())
+			if a.upgradesLoaded && !a.upgradesLoading {
+				cmds = append(cmds, a.loadUpgrades())
+			}
+		}
+
+	case queueItemDoneMsg:
+		a.queue.Finish(msg.id, msg.err)
+		if msg.err != nil {
+			a.SetError(fmt.Sprintf("#%d failed: %v", msg.id, msg.err))
+		} else {
+			a.SetSuccess(fmt.Sprintf("#%d completed", msg.id))
+		}
+		cmds = append(cmds, a.loadPackages(), a.loadHistory(), a.processQueue())
+		if a.upgradesLoaded && !a.upgradesLoading {
+			if pending, running := a.queue.Counts(); pending+running == 0 {
+				cmds = append(cmds, a.loadUpgrades())
+			}
 		}
 
 	case spinner.TickMsg:
@@ -241,6 +454,26 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
+	cmds = append(cmds, a.refreshHistory())
+
+	// Check for upgrades the first time the Updates tab is opened
+	if a.activeView == ViewUpdates && !a.upgradesLoaded && !a.upgradesLoading {
+		cmds = append(cmds, a.loadUpgrades())
+	}
+	// Scan for leftover config files the first time the Config tab is opened
+	if a.activeView == ViewConfigFiles && !a.configLoaded && !a.configLoading {
+		cmds = append(cmds, a.loadConfigFiles())
+	}
+	// Probe the sources the first time the System tab is opened
+	if a.activeView == ViewSystem && !a.sourcesLoaded && !a.sourcesLoading {
+		cmds = append(cmds, a.probeSources())
+	}
+
+	// Load details for whatever is on screen now
+	if a.ready {
+		cmds = append(cmds, a.details.Request(a.VisiblePackages()))
+	}
+
 	return a, tea.Batch(cmds...)
 }
 
@@ -275,6 +508,11 @@ func (a *App) View() string {
 		return a.renderWithDialog(b.String())
 	}
 
+	// Overlay: Command palette
+	if a.paletteOpen {
+		return a.renderPalette()
+	}
+
 	return b.String()
 }
 
@@ -284,8 +522,10 @@ func (a *App) renderHeader() string {
 
 	// Right side: loading indicator or status
 	var right string
-	if a.loading {
-		right = a.spinner.View() + " " + a.loadingMsg
+	if pending, running := a.queue.Counts(); running > 0 {
+		right = a.activityIndicator() + fmt.Sprintf(" Queue: %d running, %d pending", running, pending)
+	} else if a.loading {
+		right = a.activityIndicator() + " " + a.loadingMsg
 	} else if a.errorMsg != "" {
 		right = a.styles.Error.Render(a.errorMsg)
 	} else if a.successMsg != "" {
@@ -309,7 +549,13 @@ func (a *App) renderTabs() string {
 		if i == a.activeTab {
 			style = a.styles.TabActive
 		}
-		tabs = append(tabs, style.Render(fmt.Sprintf("[%d] %s", i+1, tab.Name)))
+		name := tab.Name
+		if tab.View == ViewUpdates {
+			if n, ok := a.updateCount(); ok && n > 0 {
+				name = fmt.Sprintf("%s (%d)", name, n)
+			}
+		}
+		tabs = append(tabs, style.Render(fmt.Sprintf("[%d] %s", i+1, name)))
 	}
 
 	tabBar := strings.Join(tabs, " ")
@@ -336,6 +582,12 @@ func (a *App) renderContent() string {
 		content = a.renderHistoryView()
 	case ViewSystem:
 		content = a.renderSystemView()
+	case ViewQueue:
+		content = a.renderQueueView()
+	case ViewConfigFiles:
+		content = a.renderConfigFilesView()
+	case ViewConfigDiff:
+		content = a.renderConfigDiffView()
 	case ViewDetails:
 		content = a.renderDetailsView()
 	case ViewHelp:
@@ -357,8 +609,8 @@ func (a *App) renderPackageList(packages []manager.Package, title string) string
 
 	// Title with count
 	titleStr := fmt.Sprintf("%s (%d)", title, len(filtered))
-	if a.filterText != "" {
-		titleStr += fmt.Sprintf(" - Filter: %s", a.filterText)
+	if a.FilterText() != "" {
+		titleStr += fmt.Sprintf(" - Filter: %s", a.FilterText())
 	}
 	b.WriteString(a.styles.Title.Render(titleStr))
 	b.WriteString("\n\n")
@@ -415,8 +667,28 @@ func (a *App) renderPackageLine(pkg manager.Package, selected bool) string {
 	// Version
 	version := a.styles.PackageVersion.Render(pkg.Version)
 
-	// Source badge
+	if a.pins.Has(pkg.Source, pkg.Name) {
+		version += " " + a.styles.Warning.Render("pinned")
+	}
+
+	// Install reason, where the source tracks it or poxy recorded it
+	switch pkg.Reason {
+	case manager.ReasonExplicit:
+		version += " " + a.styles.Info.Render("explicit")
+	case manager.ReasonDependency:
+		version += " " + a.styles.ListItemDim.Render("dep")
+	}
+
+	// Source badge, and the other sources of a search result
 	source := SourceBadge(pkg.Source)
+	if others := a.renderAlternateSources(pkg); others != "" {
+		source += " " + others
+	}
+
+	// Details filled in by the background prefetch
+	if meta := packageMeta(pkg, a.details.Get(pkg)); meta != "" {
+		source += " " + a.styles.Description.Render(meta)
+	}
 
 	// Description (truncated)
 	maxDescWidth := a.width - lipgloss.Width(cursor) - lipgloss.Width(name) - lipgloss.Width(version) - lipgloss.Width(source) - 10
@@ -429,6 +701,28 @@ func (a *App) renderPackageLine(pkg manager.Package, selected bool) string {
 	return fmt.Sprintf("%s%-25s %s %s %s", cursor, name, version, source, descStyle)
 }
 
+// packageMeta summarizes the size, repository and install date of a package
+// for its list row
+func packageMeta(pkg manager.Package, info *manager.PackageInfo) string {
+	var parts []string
+	size := pkg.Size
+	if info != nil {
+		if info.Size != "" {
+			size = info.Size
+		}
+		if info.Repository != "" {
+			parts = append(parts, info.Repository)
+		}
+	}
+	if size != "" {
+		parts = append([]string{size}, parts...)
+	}
+	if info != nil && !info.InstallDate.IsZero() {
+		parts = append(parts, info.InstallDate.Format("2006-01-02"))
+	}
+	return strings.Join(parts, " · ")
+}
+
 // renderSearchView renders the search view
 func (a *App) renderSearchView() string {
 	var b strings.Builder
@@ -439,7 +733,11 @@ func (a *App) renderSearchView() string {
 		b.WriteString(a.textInput.View())
 		b.WriteString("\n\n")
 	} else if a.searchQuery != "" {
-		b.WriteString(a.styles.Title.Render(fmt.Sprintf("Search results for '%s'", a.searchQuery)))
+		titleStr := fmt.Sprintf("Search results for '%s'", a.searchQuery)
+		if a.FilterText() != "" {
+			titleStr += fmt.Sprintf(" - Filter: %s", a.FilterText())
+		}
+		b.WriteString(a.styles.Title.Render(titleStr))
 		b.WriteString("\n\n")
 	} else {
 		b.WriteString(a.styles.Title.Render("Search Packages"))
@@ -449,9 +747,9 @@ func (a *App) renderSearchView() string {
 	}
 
 	if len(a.searchResults) > 0 {
-		b.WriteString(a.renderPackageListContent(a.searchResults))
+		b.WriteString(a.renderPackageListContent(a.ListItems()))
 	} else if a.searchQuery != "" && !a.loading {
-		b.WriteString(a.styles.Description.Render("No results found"))
+		b.WriteString(a.styles.Description.Render("No results found. Check the System tab if a source may be down."))
 	}
 
 	return b.String()
@@ -481,37 +779,32 @@ func (a *App) renderPackageListContent(packages []manager.Package) string {
 	return b.String()
 }
 
-// renderUpdatesView renders the updates view
-func (a *App) renderUpdatesView() string {
-	var b strings.Builder
-
-	b.WriteString(a.styles.Title.Render("Available Updates"))
-	b.WriteString("\n\n")
-	b.WriteString(a.styles.Description.Render("Press 'u' to check for updates"))
-	b.WriteString("\n\n")
-
-	// TODO: Implement update checking
-	b.WriteString(a.styles.Info.Render("Update checking not yet implemented"))
-
-	return b.String()
-}
-
 // renderHistoryView renders the history view
 func (a *App) renderHistoryView() string {
 	var b strings.Builder
 
-	b.WriteString(a.styles.Title.Render("Operation History"))
+	entries := a.HistoryItems()
+
+	titleStr := fmt.Sprintf("Operation History (%d)", len(entries))
+	if a.FilterText() != "" {
+		titleStr += fmt.Sprintf(" - Filter: %s", a.FilterText())
+	}
+	b.WriteString(a.styles.Title.Render(titleStr))
 	b.WriteString("\n\n")
 
-	if len(a.historyEntries) == 0 {
+	if len(entries) == 0 {
 		b.WriteString(a.styles.Description.Render("No history entries"))
 		return b.String()
 	}
 
-	for i, entry := range a.historyEntries {
-		if i >= a.VisibleHeight() {
-			break
-		}
+	start := a.Scroll()
+	end := start + a.VisibleHeight()
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	for i := start; i < end; i++ {
+		entry := entries[i]
 
 		// Format: [time] operation packages (status)
 		status := a.styles.Success.Render("OK")
@@ -526,7 +819,12 @@ func (a *App) renderHistoryView() string {
 			pkgs = pkgs[:37] + "..."
 		}
 
-		line := fmt.Sprintf("  %s  %-10s  %-40s  %s", timestamp, op, pkgs, status)
+		cursor := "  "
+		if i == a.Cursor() {
+			cursor = a.styles.ListItemSelected.Render("> ")
+		}
+
+		line := fmt.Sprintf("%s%s  %-10s  %-40s  %s", cursor, timestamp, op, pkgs, status)
 		b.WriteString(line)
 		b.WriteString("\n")
 	}
@@ -568,14 +866,16 @@ func (a *App) renderSystemView() string {
 	b.WriteString(a.styles.Subtitle.Render("Available Sources"))
 	b.WriteString("\n")
 	for _, mgr := range a.registry.Available() {
-		status := a.styles.Success.Render("OK")
 		sudo := ""
 		if mgr.NeedsSudo() {
 			sudo = " (sudo)"
 		}
-		b.WriteString(fmt.Sprintf("  %-12s %s%s\n", mgr.Name(), status, sudo))
+		version := a.managerVersions[mgr.Name()]
+		b.WriteString(fmt.Sprintf("  %-12s %-10s %s%s\n", mgr.Name(), version, a.renderSourceStatus(mgr.Name()), sudo))
 	}
 
+	b.WriteString(a.renderSourceProblems())
+
 	return b.String()
 }
 
@@ -611,16 +911,50 @@ func (a *App) renderDetailsView() string {
 	b.WriteString(a.styles.Subtitle.Render("Status: "))
 	if pkg.Installed {
 		b.WriteString(a.styles.Success.Render("Installed"))
+		switch pkg.Reason {
+		case manager.ReasonExplicit:
+			b.WriteString(a.styles.Description.Render(" (explicitly)"))
+		case manager.ReasonDependency:
+			b.WriteString(a.styles.Description.Render(" (as a dependency)"))
+		}
 	} else {
 		b.WriteString(a.styles.Info.Render("Not installed"))
 	}
 	b.WriteString("\n\n")
 
+	// Details, once the background prefetch has loaded them
+	if info := a.details.Get(*pkg); info != nil {
+		fields := []struct{ label, value string }{
+			{"Repository", info.Repository},
+			{"Size", info.Size},
+			{"License", info.License},
+			{"URL", info.URL},
+		}
+		if !info.InstallDate.IsZero() {
+			fields = append(fields, struct{ label, value string }{"Installed", info.InstallDate.Format("2006-01-02 15:04")})
+		}
+		for _, f := range fields {
+			if f.value == "" {
+				continue
+			}
+			b.WriteString(a.styles.Subtitle.Render(fmt.Sprintf("%-11s", f.label+":")))
+			b.WriteString(" " + f.value + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(a.renderAppDetails(pkg))
+	b.WriteString(a.renderAURDetails(pkg))
+	b.WriteString(a.renderPermissions(pkg))
+
 	// Actions
 	b.WriteString(a.styles.Subtitle.Render("Actions"))
 	b.WriteString("\n")
 	if pkg.Installed {
 		b.WriteString("  [r] Remove package\n")
+		if a.permissionManager(pkg) != nil {
+			b.WriteString("  [p] Override permissions\n")
+		}
 	} else {
 		b.WriteString("  [i] Install package\n")
 	}
@@ -646,7 +980,7 @@ func (a *App) renderHelpView() string {
 				{"j/k or Up/Down", "Move cursor"},
 				{"g/G", "Go to top/bottom"},
 				{"PgUp/PgDn", "Page up/down"},
-				{"1-5", "Switch tabs"},
+				{"1-7", "Switch tabs"},
 				{"Left/Right", "Previous/next tab"},
 			},
 		},
@@ -655,15 +989,37 @@ func (a *App) renderHelpView() string {
 			keys: []struct{ key, desc string }{
 				{"Enter", "View details"},
 				{"/", "Search packages"},
-				{"f", "Filter list"},
-				{"i", "Install package"},
-				{"r", "Remove package"},
+				{"f", "Filter list as you type (op:, source:, kind:, since:, until:)"},
+				{"i", "Queue install"},
+				{"s", "Install a search result from another source it was found in"},
+				{"r", "Queue removal"},
+				{"U", "Queue upgrade of the package's source"},
 				{"u", "Update databases"},
+				{"p", "Override the permissions of a Flatpak app (details view)"},
+			},
+		},
+		{
+			title: "Queue",
+			keys: []struct{ key, desc string }{
+				{"6", "Show queue"},
+				{"x", "Cancel selected item"},
+				{"c", "Clear finished items"},
+			},
+		},
+		{
+			title: "Config Files",
+			keys: []struct{ key, desc string }{
+				{"7", "Show .pacnew/.pacsave/.rpmnew files"},
+				{"Enter", "Show diff against the live file"},
+				{"m", "Merge with $DIFFPROG (default: vim -d)"},
+				{"R", "Replace the live file"},
+				{"K", "Keep the live file, delete the leftover"},
 			},
 		},
 		{
 			title: "General",
 			keys: []struct{ key, desc string }{
+				{"Ctrl+P or :", "Command palette"},
 				{"?", "Toggle help"},
 				{"Esc/b", "Go back"},
 				{"q", "Quit"},
@@ -693,20 +1049,34 @@ func (a *App) renderFooter() string {
 
 	switch a.activeView {
 	case ViewPackages, ViewSearch:
-		hints = []string{"i:install", "r:remove", "/:search", "Enter:details"}
+		hints = []string{"i:install", "r:remove", "U:upgrade source", "/:search", "Enter:details"}
 	case ViewDetails:
-		if a.selectedPkg != nil && a.selectedPkg.Installed {
+		if a.permissionManager(a.selectedPkg) != nil {
+			hints = []string{"r:remove", "p:permissions", "b:back"}
+		} else if a.selectedPkg != nil && a.selectedPkg.Installed {
 			hints = []string{"r:remove", "b:back"}
 		} else {
 			hints = []string{"i:install", "b:back"}
 		}
+	case ViewUpdates:
+		hints = []string{"space:select", "Enter:upgrade selected", "U:upgrade all", "f:filter"}
 	case ViewHistory:
-		hints = []string{"Enter:details", "b:back"}
+		hints = []string{"f:filter (op:, since:, until:)", "b:back"}
+	case ViewQueue:
+		hints = []string{"x:cancel", "c:clear finished"}
+	case ViewConfigFiles:
+		hints = []string{"Enter:diff", "m:merge", "R:replace", "K:keep current", "f:filter"}
+	case ViewConfigDiff:
+		hints = []string{"m:merge", "R:replace", "K:keep current", "b:back"}
 	default:
 		hints = []string{"?:help", "q:quit"}
 	}
 
-	hints = append(hints, "?:help", "q:quit")
+	hints = append(hints, "ctrl+p:commands", "?:help", "q:quit")
+
+	if a.inputMode && a.inputPrompt == filterPrompt {
+		hints = []string{filterPrompt + a.textInput.View(), "Enter:apply", "Esc:cancel"}
+	}
 
 	footer := strings.Join(hints, "  ")
 	return lipgloss.NewStyle().
@@ -719,8 +1089,13 @@ func (a *App) renderFooter() string {
 
 // renderWithDialog renders content with a dialog overlay
 func (a *App) renderWithDialog(_ string) string {
+	body := a.styles.DialogTitle.Render(a.confirmTitle) + "\n\n"
+	if a.confirmDetail != "" {
+		body += a.styles.Warning.Render(a.confirmDetail) + "\n\n"
+	}
+
 	dialog := a.styles.Dialog.Render(
-		a.styles.DialogTitle.Render(a.confirmTitle) + "\n\n" +
+		body +
 			a.styles.DialogButton.Render("[Y]es") + " " +
 			lipgloss.NewStyle().Foreground(ColorMuted).Render("[N]o"),
 	)
@@ -730,28 +1105,62 @@ func (a *App) renderWithDialog(_ string) string {
 		lipgloss.WithWhitespaceForeground(ColorBg))
 }
 
+// confirmInstall asks to queue an install of the selected package
+func (a *App) confirmInstall() {
+	if pkg := a.SelectedPackage(); pkg != nil && !pkg.Installed {
+		a.ShowConfirm(fmt.Sprintf("Queue install of %s?", pkg.Name), func() tea.Cmd {
+			return a.enqueue(history.OpInstall, pkg.Source, []string{pkg.Name})
+		})
+	}
+}
+
+// confirmRemove asks to queue removal of the selected package, once its
+// dependents are known
+func (a *App) confirmRemove() tea.Cmd {
+	if pkg := a.SelectedPackage(); pkg != nil && pkg.Installed {
+		return a.checkDependents(*pkg)
+	}
+	return nil
+}
+
+// confirmSourceUpgrade asks to queue an upgrade of every package from the
+// source of the selected package
+func (a *App) confirmSourceUpgrade() {
+	if pkg := a.SelectedPackage(); pkg != nil && pkg.Source != "" {
+		src := pkg.Source
+		a.ShowConfirm(fmt.Sprintf("Queue upgrade of all %s packages?", src), func() tea.Cmd {
+			return a.enqueue(history.OpUpgrade, src, nil)
+		})
+	}
+}
+
 // startSearch initiates search input
 func (a *App) startSearch() {
 	a.textInput.SetValue("")
 	a.textInput.Focus()
-	a.StartInput("Search: ", func(query string) {
-		if query != "" {
-			a.searchQuery = query
-			a.SetLoading(true, "Searching...")
-			// Search will be triggered via command
+	a.StartInput("Search: ", func(query string) tea.Cmd {
+		if query == "" {
+			return nil
 		}
+		a.searchQuery = query
+		return a.searchPackages(a.resolver.Alias(query))
 	})
 }
 
-// startFilter initiates filter input
+// filterPrompt is the input prompt of the live list filter
+const filterPrompt = "Filter: "
+
+// startFilter initiates filter input. The filter is applied as it is typed
+// and restored if the input is cancelled.
 func (a *App) startFilter() {
-	a.textInput.SetValue(a.filterText)
+	a.filterBefore = a.FilterText()
+	a.textInput.SetValue(a.filterBefore)
 	a.textInput.Focus()
-	a.StartInput("Filter: ", func(filter string) {
-		a.filterText = filter
-		a.SetCursor(0)
-		a.SetScroll(0)
+	a.StartInput(filterPrompt, func(filter string) tea.Cmd {
+		a.SetFilterText(filter)
+		return nil
 	})
+	a.inputValue = a.filterBefore
 }
 
 // Async commands
@@ -763,11 +1172,18 @@ func (a *App) loadPackages() tea.Cmd {
 		ctx := context.Background()
 		var allPkgs []manager.Package
 
+		// Install reasons recorded by poxy, for sources that do not track them
+		store, err := database.Open()
+		if err == nil {
+			defer store.Close()
+		}
+
 		for _, mgr := range a.registry.Available() {
 			pkgs, err := mgr.ListInstalled(ctx, manager.ListOpts{})
 			if err != nil {
 				continue
 			}
+			database.MarkInstallReasons(ctx, store, mgr, pkgs)
 			allPkgs = append(allPkgs, pkgs...)
 		}
 
@@ -775,51 +1191,122 @@ func (a *App) loadPackages() tea.Cmd {
 	}
 }
 
-func (a *App) loadHistory() tea.Cmd {
+// searchPackages searches the search index, falling back to querying the
+// package managers when the index is empty
+func (a *App) searchPackages(query string) tea.Cmd {
+	a.SetLoading(true, "Searching...")
+	a.searchResults = nil
+	a.searchGroups = nil
+	a.cursors[ViewSearch] = 0
+	a.scrolls[ViewSearch] = 0
+
 	return func() tea.Msg {
-		if a.historyStore == nil {
-			return historyLoadedMsg{}
+		if a.searchIndex != nil && a.searchIndex.Size() > 0 {
+			var results []manager.Package
+			for _, r := range a.searchIndex.Search(query, database.SearchOptions{Limit: searchLimit}) {
+				results = append(results, r.Package)
+			}
+			return searchResultsMsg{results: results}
 		}
 
-		entries, err := a.historyStore.List(50)
-		return historyLoadedMsg{entries: entries, err: err}
+		results, err := a.registry.SearchAll(context.Background(), query, manager.SearchOpts{Limit: searchLimit})
+		return searchResultsMsg{results: results, err: err}
 	}
 }
 
-func (a *App) installPackage(name, source string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+// historyLoadLimit is the number of history entries loaded.
+const historyLoadLimit = 50
 
-		mgr, ok := a.registry.Get(source)
-		if !ok {
-			return operationCompleteMsg{err: fmt.Errorf("unknown source: %s", source)}
+// loadHistory loads the most recent history entries matching the history
+// filter.
+func (a *App) loadHistory() tea.Cmd {
+	filter := a.filters[ViewHistory]
+	a.historyFilter = filter
+	return func() tea.Msg {
+		if a.historyStore == nil {
+			return historyLoadedMsg{filter: filter}
 		}
 
-		err := mgr.Install(ctx, []string{name}, manager.InstallOpts{AutoConfirm: true})
-		if err != nil {
-			return operationCompleteMsg{err: err}
-		}
+		entries, err := a.historyStore.Query(ParseFilter(filter).historyQuery(historyLoadLimit))
+		return historyLoadedMsg{filter: filter, entries: entries, err: err}
+	}
+}
 
-		return operationCompleteMsg{success: true, message: fmt.Sprintf("Installed %s", name)}
+// refreshHistory reloads the history if its filter changed since it was
+// loaded.
+func (a *App) refreshHistory() tea.Cmd {
+	if a.filters[ViewHistory] == a.historyFilter {
+		return nil
 	}
+	return a.loadHistory()
 }
 
-func (a *App) uninstallPackage(name, source string) tea.Cmd {
+func (a *App) loadManagerVersions() tea.Cmd {
 	return func() tea.Msg {
-		ctx := context.Background()
+		return managerVersionsMsg{versions: a.registry.ManagerVersions(context.Background())}
+	}
+}
 
-		mgr, ok := a.registry.Get(source)
-		if !ok {
-			return operationCompleteMsg{err: fmt.Errorf("unknown source: %s", source)}
-		}
+// loadPins reads the packages pinned with poxy pin
+func (a *App) loadPins() tea.Cmd {
+	return func() tea.Msg {
+		pins, _ := pin.Load() //nolint:errcheck
+		return pinsLoadedMsg{pins: pins}
+	}
+}
+
+// loadDaemonStatus reads cached update counts from the background daemon,
+// if one is running
+func (a *App) loadDaemonStatus() tea.Cmd {
+	socket := config.SocketPath()
+	if a.config != nil {
+		socket = a.config.Daemon.SocketPath()
+	}
+	return func() tea.Msg {
+		status, _ := daemon.Query(socket) //nolint:errcheck
+		return daemonStatusMsg{status: status}
+	}
+}
 
-		err := mgr.Uninstall(ctx, []string{name}, manager.UninstallOpts{AutoConfirm: true})
-		if err != nil {
-			return operationCompleteMsg{err: err}
+// updateCount returns the number of pending updates, preferring a completed
+// check over the daemon's cached count
+func (a *App) updateCount() (int, bool) {
+	if a.upgradesLoaded {
+		n := 0
+		for _, c := range a.upgrades {
+			if !c.Held {
+				n++
+			}
 		}
+		return n, true
+	}
+	if a.daemonStatus != nil && !a.daemonStatus.LastRefresh.IsZero() {
+		return a.daemonStatus.Updates, true
+	}
+	return 0, false
+}
+
+// checkDependents looks up the installed packages that depend on pkg before
+// asking to remove it
+func (a *App) checkDependents(pkg manager.Package) tea.Cmd {
+	mgr, ok := a.registry.Get(pkg.Source)
+	if !ok {
+		return func() tea.Msg { return dependentsMsg{pkg: pkg} }
+	}
+
+	a.SetLoading(true, "Checking dependents...")
+	return func() tea.Msg {
+		dependents := manager.FindDependents(context.Background(), mgr, []string{pkg.Name})
+		return dependentsMsg{pkg: pkg, dependents: dependents[pkg.Name]}
+	}
+}
 
-		return operationCompleteMsg{success: true, message: fmt.Sprintf("Removed %s", name)}
+// summarizeNames joins up to max names, noting how many were left out
+func summarizeNames(names []string, max int) string {
+	if len(names) <= max {
+		return strings.Join(names, ", ")
 	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:max], ", "), len(names)-max)
 }
 
 func (a *App) updateDatabases() tea.Cmd {
@@ -837,8 +1324,8 @@ func (a *App) updateDatabases() tea.Cmd {
 }
 
 // Run starts the TUI application
-func Run(registry *manager.Registry, cfg *config.Config, historyStore *history.Store, searchIndex *database.Index) error {
-	app := NewApp(registry, cfg, historyStore, searchIndex)
+func Run(registry *manager.Registry, cfg *config.Config, historyStore *history.Store, searchIndex *database.Index, opts Options) error {
+	app := NewApp(registry, cfg, historyStore, searchIndex, opts)
 	p := tea.NewProgram(app, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
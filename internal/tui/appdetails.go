@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/pkg/appstream"
+	"poxy/pkg/manager"
+)
+
+// appDetailScreenshots is the number of screenshot URLs shown in the
+// details view
+const appDetailScreenshots = 3
+
+// appstreamLoadedMsg carries the AppStream catalogs of the system
+type appstreamLoadedMsg struct {
+	catalog *appstream.Catalog
+}
+
+// loadAppStream reads the AppStream catalogs the first time the details
+// view is opened
+func (a *App) loadAppStream() tea.Cmd {
+	if a.appstream != nil || a.appstreamLoading {
+		return nil
+	}
+	a.appstreamLoading = true
+	return func() tea.Msg {
+		// Unreadable catalogs are skipped
+		catalog, _ := appstream.LoadSystem() //nolint:errcheck
+		return appstreamLoadedMsg{catalog: catalog}
+	}
+}
+
+// renderAppDetails renders the application section of the details view,
+// for packages an AppStream catalog describes
+func (a *App) renderAppDetails(pkg *manager.Package) string {
+	comp := a.appstream.Lookup(pkg.Source, pkg.Name)
+	if comp == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(a.styles.Subtitle.Render("Application"))
+	b.WriteString("\n")
+
+	fields := []struct{ label, value string }{
+		{"Name", comp.Name},
+		{"Summary", comp.Summary},
+		{"Categories", strings.Join(comp.Categories, ", ")},
+		{"License", comp.License},
+		{"Homepage", comp.Homepage},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		b.WriteString(a.styles.Subtitle.Render(fmt.Sprintf("  %-11s", f.label+":")))
+		b.WriteString(" " + f.value + "\n")
+	}
+
+	for i, url := range comp.Screenshots {
+		if i == appDetailScreenshots {
+			b.WriteString(a.styles.Description.Render(fmt.Sprintf("  ... %d more screenshots", len(comp.Screenshots)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(a.styles.Subtitle.Render(fmt.Sprintf("  %-11s", "Screenshot:")))
+		b.WriteString(" " + url + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
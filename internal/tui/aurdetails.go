@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/pkg/aur"
+	"poxy/pkg/manager"
+)
+
+const (
+	// aurDetailComments is the number of AUR comments shown in the details view
+	aurDetailComments = 3
+
+	// aurDetailCommentLines is the number of lines shown of each comment
+	aurDetailCommentLines = 3
+)
+
+// aurDetailsMsg carries the AUR votes, popularity and comments of a package
+type aurDetailsMsg struct {
+	name     string
+	pkg      *aur.Package
+	comments []aur.Comment
+	err      error
+}
+
+// loadAURDetails fetches AUR details for the package in the details view,
+// if it comes from the AUR
+func (a *App) loadAURDetails() tea.Cmd {
+	pkg := a.selectedPkg
+	if pkg == nil || pkg.Source != "aur" {
+		return nil
+	}
+	if a.aurDetails != nil && a.aurDetails.name == pkg.Name && a.aurDetails.err == nil {
+		return nil
+	}
+
+	name := pkg.Name
+	a.aurDetails = &aurDetailsMsg{name: name}
+	return func() tea.Msg {
+		ctx := context.Background()
+		client := aur.NewClient()
+		found, err := client.GetPackage(ctx, name)
+		if err != nil {
+			return aurDetailsMsg{name: name, err: err}
+		}
+		// Comments are a nice-to-have; show the votes even if the page fails
+		comments, _ := client.Comments(ctx, found, aurDetailComments) //nolint:errcheck
+		return aurDetailsMsg{name: name, pkg: found, comments: comments}
+	}
+}
+
+// renderAURDetails renders the AUR section of the details view
+func (a *App) renderAURDetails(pkg *manager.Package) string {
+	d := a.aurDetails
+	if pkg.Source != "aur" || d == nil || d.name != pkg.Name {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(a.styles.Subtitle.Render("AUR"))
+	b.WriteString("\n")
+
+	switch {
+	case d.err != nil:
+		b.WriteString(a.styles.Description.Render("  Not found in the AUR"))
+		b.WriteString("\n\n")
+		return b.String()
+	case d.pkg == nil:
+		b.WriteString("  " + a.activityIndicator() + " " + a.styles.Description.Render("Loading votes and comments..."))
+		b.WriteString("\n\n")
+		return b.String()
+	}
+
+	info := d.pkg
+	maintainer := info.Maintainer
+	if info.IsOrphan() {
+		maintainer = a.styles.Warning.Render("none (orphaned)")
+	}
+	b.WriteString(fmt.Sprintf("  %d votes · popularity %.2f · maintainer %s\n", info.NumVotes, info.Popularity, maintainer))
+	b.WriteString(fmt.Sprintf("  Last updated %s", info.LastModifiedTime().Format("2006-01-02")))
+	if flagged := info.OutOfDateTime(); flagged != nil {
+		b.WriteString(" · " + a.styles.Warning.Render("flagged out of date "+flagged.Format("2006-01-02")))
+	}
+	b.WriteString("\n\n")
+
+	for _, c := range d.comments {
+		b.WriteString("  " + a.styles.Subtitle.Render(c.Author) + " " + a.styles.Description.Render(c.Date) + "\n")
+		lines := strings.Split(c.Text, "\n")
+		if len(lines) > aurDetailCommentLines {
+			lines = append(lines[:aurDetailCommentLines], "...")
+		}
+		for _, line := range lines {
+			b.WriteString("    " + line + "\n")
+		}
+	}
+	if len(d.comments) > 0 {
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
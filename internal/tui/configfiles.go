@@ -0,0 +1,245 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"poxy/internal/configfiles"
+	"poxy/internal/executor"
+)
+
+// configFilesLoadedMsg carries the leftover configuration files found
+type configFilesLoadedMsg struct {
+	files []configfiles.File
+	err   error
+}
+
+// configDiffMsg carries the diff of a leftover against its live file
+type configDiffMsg struct {
+	file configfiles.File
+	diff string
+	err  error
+}
+
+// configActionMsg reports a finished merge, replace or keep
+type configActionMsg struct {
+	file    configfiles.File
+	merged  bool
+	message string
+	err     error
+}
+
+// loadConfigFiles scans for leftover configuration files
+func (a *App) loadConfigFiles() tea.Cmd {
+	a.configLoading = true
+	return func() tea.Msg {
+		files, err := configfiles.Scan(context.Background(), configfiles.DefaultDir)
+		return configFilesLoadedMsg{files: files, err: err}
+	}
+}
+
+// ConfigFileItems returns the leftovers matching the config files filter
+func (m *Model) ConfigFileItems() []configfiles.File {
+	filter := ParseFilter(m.filters[ViewConfigFiles])
+	return filterList(m.configFiles, filter, func(f configfiles.File) filterRecord {
+		return filterRecord{fields: []string{f.Path, f.Describe()}}
+	})
+}
+
+// SelectedConfigFile returns the leftover the config actions apply to: the
+// one whose diff is shown, or the one under the cursor
+func (m *Model) SelectedConfigFile() *configfiles.File {
+	if m.activeView == ViewConfigDiff {
+		return m.configDiffFile
+	}
+	if m.activeView != ViewConfigFiles {
+		return nil
+	}
+	items := m.ConfigFileItems()
+	cursor := m.Cursor()
+	if cursor >= 0 && cursor < len(items) {
+		return &items[cursor]
+	}
+	return nil
+}
+
+// configDiffLines returns the lines of the diff shown in the diff view
+func (m *Model) configDiffLines() []string {
+	if m.configDiff == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(m.configDiff, "\n"), "\n")
+}
+
+// showConfigDiff loads the diff of the selected leftover and shows it
+func (a *App) showConfigDiff() tea.Cmd {
+	f := a.SelectedConfigFile()
+	if f == nil {
+		return nil
+	}
+	file := *f
+	a.SetLoading(true, "Comparing "+file.Path+"...")
+	return func() tea.Msg {
+		diff, err := file.Diff(context.Background())
+		return configDiffMsg{file: file, diff: diff, err: err}
+	}
+}
+
+// mergeConfigFile opens the selected leftover and its live file in the
+// merge tool
+func (a *App) mergeConfigFile() tea.Cmd {
+	f := a.SelectedConfigFile()
+	if f == nil {
+		return nil
+	}
+	return a.runConfigFileCommand(*f, f.MergeArgs(), true, fmt.Sprintf("Merged %s", f.Path))
+}
+
+// confirmConfigReplace asks before making the selected leftover the live file
+func (a *App) confirmConfigReplace() {
+	f := a.SelectedConfigFile()
+	if f == nil {
+		return
+	}
+	file := *f
+	a.ShowConfirmWithDetail(fmt.Sprintf("Replace %s?", file.Target), "with "+file.Path, func() tea.Cmd {
+		return a.runConfigFileCommand(file, file.ReplaceArgs(), false, fmt.Sprintf("Replaced %s", file.Target))
+	})
+}
+
+// confirmConfigKeep asks before deleting the selected leftover
+func (a *App) confirmConfigKeep() {
+	f := a.SelectedConfigFile()
+	if f == nil {
+		return
+	}
+	file := *f
+	a.ShowConfirmWithDetail(fmt.Sprintf("Delete %s?", file.Path), "Keeps "+file.Target+" as it is", func() tea.Cmd {
+		return a.runConfigFileCommand(file, file.KeepArgs(), false, fmt.Sprintf("Deleted %s", file.Path))
+	})
+}
+
+// runConfigFileCommand runs args with root privileges, handing the terminal
+// over so sudo can ask for a password and merge tools can run
+func (a *App) runConfigFileCommand(f configfiles.File, args []string, merge bool, message string) tea.Cmd {
+	cmd, err := executor.SudoCommand(context.Background(), args[0], args[1:]...)
+	if err != nil {
+		return func() tea.Msg { return configActionMsg{file: f, err: err} }
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			err = fmt.Errorf("%s: %w", strings.Join(args, " "), err)
+		}
+		return configActionMsg{file: f, merged: merge, message: message, err: err}
+	})
+}
+
+// handleConfigAction updates the view after a merge, replace or keep
+func (a *App) handleConfigAction(msg configActionMsg) tea.Cmd {
+	if msg.err != nil {
+		a.SetError(msg.err.Error())
+		return nil
+	}
+	a.SetSuccess(msg.message)
+
+	if msg.merged {
+		// The leftover is usually not needed once merged
+		file := msg.file
+		a.ShowConfirm(fmt.Sprintf("Merged. Delete %s?", file.Path), func() tea.Cmd {
+			return a.runConfigFileCommand(file, file.KeepArgs(), false, fmt.Sprintf("Deleted %s", file.Path))
+		})
+	} else if a.activeView == ViewConfigDiff {
+		a.activeView = ViewConfigFiles
+	}
+	return a.loadConfigFiles()
+}
+
+// renderConfigFilesView renders the list of leftover configuration files
+func (a *App) renderConfigFilesView() string {
+	var b strings.Builder
+
+	files := a.ConfigFileItems()
+
+	titleStr := fmt.Sprintf("Configuration Files (%d)", len(files))
+	if a.FilterText() != "" {
+		titleStr += fmt.Sprintf(" - Filter: %s", a.FilterText())
+	}
+	b.WriteString(a.styles.Title.Render(titleStr))
+	b.WriteString("\n\n")
+
+	switch {
+	case a.configLoading && len(a.configFiles) == 0:
+		b.WriteString(a.activityIndicator() + " " + a.styles.Description.Render("Scanning "+configfiles.DefaultDir+"..."))
+		return b.String()
+	case a.configErr != nil:
+		b.WriteString(a.styles.Error.Render(a.configErr.Error()))
+		return b.String()
+	case len(files) == 0:
+		b.WriteString(a.styles.Description.Render("No .pacnew, .pacsave or .rpmnew files to merge"))
+		return b.String()
+	}
+
+	start := a.Scroll()
+	end := min(start+a.VisibleHeight(), len(files))
+
+	for i := start; i < end; i++ {
+		f := files[i]
+
+		cursor := "  "
+		if i == a.Cursor() {
+			cursor = a.styles.ListItemSelected.Render("> ")
+		}
+
+		path := lipgloss.NewStyle().Foreground(ColorText).Render(fmt.Sprintf("%-50s", f.Path))
+		b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, path, a.styles.Description.Render(f.Describe())))
+	}
+
+	return b.String()
+}
+
+// renderConfigDiffView renders the diff of a leftover against its live file,
+// starting at the cursor line
+func (a *App) renderConfigDiffView() string {
+	var b strings.Builder
+
+	f := a.configDiffFile
+	if f == nil {
+		return ""
+	}
+
+	b.WriteString(a.styles.Title.Render(f.Path))
+	b.WriteString("\n")
+	b.WriteString(a.styles.Description.Render(f.Describe()))
+	b.WriteString("\n\n")
+
+	lines := a.configDiffLines()
+	if len(lines) == 0 {
+		b.WriteString(a.styles.Description.Render("Identical to " + f.Target))
+		return b.String()
+	}
+
+	height := a.VisibleHeight() - 2
+	start := max(min(a.Cursor(), len(lines)-height), 0)
+	end := min(start+height, len(lines))
+
+	for _, line := range lines[start:end] {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			line = a.styles.Subtitle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = a.styles.Info.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = a.styles.Success.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = a.styles.Error.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
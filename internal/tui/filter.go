@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"poxy/internal/history"
+	"poxy/pkg/manager"
+)
+
+// Filter is a parsed list filter shared by the filterable views. The filter
+// text is split into space-separated terms that must all match:
+//
+//	vim                text in any field (name, description, source, date)
+//	op:install         operation type
+//	source:apt         package source
+//	kind:application   packages of a kind (application, library, font, driver)
+//	pkg:vim            history entries for exactly this package
+//	status:failed      failed history entries (or status:ok)
+//	since:2024-01-15   entries on or after a date
+//	until:2024-02-01   entries on or before a date
+type Filter struct {
+	terms  []string
+	op     string
+	source string
+	kind   manager.Kind
+	pkg    string
+	status string // "ok" or "failed"
+	since  time.Time
+	until  time.Time // Exclusive: the day after the until: date
+}
+
+// filterRecord is the filterable view of a list entry.
+type filterRecord struct {
+	fields   []string
+	op       string
+	source   string
+	kind     manager.Kind
+	packages []string
+	status   string
+	time     time.Time
+}
+
+// ParseFilter parses filter text. Date terms that do not parse as
+// YYYY-MM-DD are matched as plain text.
+func ParseFilter(text string) Filter {
+	var f Filter
+
+	for _, term := range strings.Fields(text) {
+		key, value, found := strings.Cut(term, ":")
+		if !found || value == "" {
+			f.terms = append(f.terms, term)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "op":
+			f.op = strings.ToLower(value)
+		case "source":
+			f.source = strings.ToLower(value)
+		case "kind":
+			if kind, err := manager.ParseKind(value); err == nil {
+				f.kind = kind
+			} else {
+				f.terms = append(f.terms, term)
+			}
+		case "pkg":
+			f.pkg = strings.ToLower(value)
+		case "status":
+			switch strings.ToLower(value) {
+			case "ok", "success", "succeeded":
+				f.status = "ok"
+			case "failed", "fail", "error":
+				f.status = "failed"
+			default:
+				f.terms = append(f.terms, term)
+			}
+		case "since":
+			if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+				f.since = t
+			} else {
+				f.terms = append(f.terms, term)
+			}
+		case "until":
+			if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+				f.until = t.AddDate(0, 0, 1)
+			} else {
+				f.terms = append(f.terms, term)
+			}
+		default:
+			f.terms = append(f.terms, term)
+		}
+	}
+
+	return f
+}
+
+// IsEmpty returns true if the filter matches everything.
+func (f Filter) IsEmpty() bool {
+	return len(f.terms) == 0 && f.op == "" && f.source == "" && f.kind == "" && f.pkg == "" && f.status == "" &&
+		f.since.IsZero() && f.until.IsZero()
+}
+
+// matches reports whether a record satisfies every term of the filter.
+func (f Filter) matches(r filterRecord) bool {
+	if f.op != "" && !strings.EqualFold(r.op, f.op) {
+		return false
+	}
+	if f.source != "" && !strings.EqualFold(r.source, f.source) {
+		return false
+	}
+	if f.kind != "" && r.kind != f.kind {
+		return false
+	}
+	if f.pkg != "" && !slices.ContainsFunc(r.packages, func(p string) bool { return strings.EqualFold(p, f.pkg) }) {
+		return false
+	}
+	if f.status != "" && r.status != f.status {
+		return false
+	}
+	if !f.since.IsZero() && (r.time.IsZero() || r.time.Before(f.since)) {
+		return false
+	}
+	if !f.until.IsZero() && (r.time.IsZero() || !r.time.Before(f.until)) {
+		return false
+	}
+
+	for _, term := range f.terms {
+		found := false
+		for _, field := range r.fields {
+			if containsIgnoreCase(field, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// historyQuery returns the history store query for the filter, so the
+// whole history is searched rather than the entries loaded. Only the first
+// text term is passed to the store; the others narrow its results.
+func (f Filter) historyQuery(limit int) history.Query {
+	query := history.Query{
+		Operation: history.Operation(f.op),
+		Source:    f.source,
+		Package:   f.pkg,
+		Since:     f.since,
+		Limit:     limit,
+	}
+	if !f.until.IsZero() {
+		query.Until = f.until.Add(-time.Nanosecond)
+	}
+	switch f.status {
+	case "ok":
+		query.Status = history.StatusSucceeded
+	case "failed":
+		query.Status = history.StatusFailed
+	}
+	if len(f.terms) > 0 {
+		query.Text = f.terms[0]
+	}
+	return query
+}
+
+// filterList returns the items matching f, using record to describe each item.
+func filterList[T any](items []T, f Filter, record func(T) filterRecord) []T {
+	if f.IsEmpty() {
+		return items
+	}
+
+	var filtered []T
+	for _, item := range items {
+		if f.matches(record(item)) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// withSource returns filter text limited to a package source, replacing any
+// source: term already in it. An empty source removes the limit.
+func withSource(text, source string) string {
+	return withTerm(text, "source", source)
+}
+
+// withKind returns filter text limited to a package kind, replacing any
+// kind: term already in it. An empty kind removes the limit.
+func withKind(text string, kind manager.Kind) string {
+	return withTerm(text, "kind", string(kind))
+}
+
+// withTerm returns filter text with the key:value term, replacing any
+// term with that key already in it. An empty value removes the term.
+func withTerm(text, key, value string) string {
+	var terms []string
+	for _, term := range strings.Fields(text) {
+		if k, _, found := strings.Cut(term, ":"); found && strings.EqualFold(k, key) {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	if value != "" {
+		terms = append(terms, key+":"+value)
+	}
+	return strings.Join(terms, " ")
+}
@@ -22,21 +22,39 @@ type KeyMap struct {
 	Tab3 key.Binding
 	Tab4 key.Binding
 	Tab5 key.Binding
+	Tab6 key.Binding
+	Tab7 key.Binding
 
 	// Actions
-	Enter  key.Binding
-	Search key.Binding
-	Filter key.Binding
-	Cancel key.Binding
-	Quit   key.Binding
-	Help   key.Binding
-	Back   key.Binding
+	Enter   key.Binding
+	Search  key.Binding
+	Filter  key.Binding
+	Cancel  key.Binding
+	Quit    key.Binding
+	Help    key.Binding
+	Back    key.Binding
+	Palette key.Binding
 
 	// Package actions
-	Install   key.Binding
-	Uninstall key.Binding
-	Update    key.Binding
-	Info      key.Binding
+	Install      key.Binding
+	Uninstall    key.Binding
+	Update       key.Binding
+	Info         key.Binding
+	SwitchSource key.Binding
+	Permissions  key.Binding
+
+	// Updates actions
+	Select key.Binding
+
+	// Queue actions
+	UpgradeSource key.Binding
+	CancelItem    key.Binding
+	ClearQueue    key.Binding
+
+	// Config file actions
+	Merge   key.Binding
+	Replace key.Binding
+	Keep    key.Binding
 
 	// Vim-style
 	VimUp   key.Binding
@@ -103,6 +121,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("5"),
 			key.WithHelp("5", "system"),
 		),
+		Tab6: key.NewBinding(
+			key.WithKeys("6"),
+			key.WithHelp("6", "queue"),
+		),
+		Tab7: key.NewBinding(
+			key.WithKeys("7"),
+			key.WithHelp("7", "config files"),
+		),
 
 		// Actions
 		Enter: key.NewBinding(
@@ -133,6 +159,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("backspace", "b"),
 			key.WithHelp("b", "back"),
 		),
+		Palette: key.NewBinding(
+			key.WithKeys("ctrl+p", ":"),
+			key.WithHelp("ctrl+p", "command palette"),
+		),
 
 		// Package actions
 		Install: key.NewBinding(
@@ -151,6 +181,48 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("enter", "o"),
 			key.WithHelp("o", "info"),
 		),
+		SwitchSource: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "switch source"),
+		),
+		Permissions: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "override permissions"),
+		),
+
+		// Updates actions
+		Select: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "select"),
+		),
+
+		// Queue actions
+		UpgradeSource: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "queue source upgrade"),
+		),
+		CancelItem: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "cancel pending item"),
+		),
+		ClearQueue: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "clear finished"),
+		),
+
+		// Config file actions
+		Merge: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "merge config"),
+		),
+		Replace: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "replace config"),
+		),
+		Keep: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "keep current config"),
+		),
 
 		// Vim-style
 		VimUp: key.NewBinding(
@@ -175,7 +247,7 @@ func DefaultKeyMap() KeyMap {
 // ShortHelp returns a condensed help view
 func (k KeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
-		k.Up, k.Down, k.Enter, k.Search, k.Install, k.Uninstall, k.Quit, k.Help,
+		k.Up, k.Down, k.Enter, k.Search, k.Install, k.Uninstall, k.Palette, k.Quit, k.Help,
 	}
 }
 
@@ -183,10 +255,12 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right, k.PageUp, k.PageDown},
-		{k.Tab1, k.Tab2, k.Tab3, k.Tab4, k.Tab5},
+		{k.Tab1, k.Tab2, k.Tab3, k.Tab4, k.Tab5, k.Tab6, k.Tab7},
 		{k.Enter, k.Search, k.Filter, k.Back, k.Cancel},
-		{k.Install, k.Uninstall, k.Update, k.Info},
+		{k.Install, k.Uninstall, k.Update, k.Info, k.SwitchSource, k.Permissions, k.Select},
+		{k.UpgradeSource, k.CancelItem, k.ClearQueue},
+		{k.Merge, k.Replace, k.Keep},
 		{k.VimUp, k.VimDown, k.VimTop, k.VimBot},
-		{k.Help, k.Quit},
+		{k.Palette, k.Help, k.Quit},
 	}
 }
@@ -1,10 +1,21 @@
 package tui
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/internal/aurwatch"
 	"poxy/internal/config"
+	"poxy/internal/configfiles"
+	"poxy/internal/daemon"
 	"poxy/internal/history"
+	"poxy/internal/pin"
+	"poxy/pkg/appstream"
 	"poxy/pkg/database"
 	"poxy/pkg/manager"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // View represents different views in the TUI
@@ -16,7 +27,10 @@ const (
 	ViewUpdates
 	ViewHistory
 	ViewSystem
+	ViewQueue
+	ViewConfigFiles
 	ViewDetails
+	ViewConfigDiff
 	ViewHelp
 )
 
@@ -34,7 +48,51 @@ func DefaultTabs() []Tab {
 		{Name: "Updates", View: ViewUpdates},
 		{Name: "History", View: ViewHistory},
 		{Name: "System", View: ViewSystem},
+		{Name: "Queue", View: ViewQueue},
+		{Name: "Config", View: ViewConfigFiles},
+	}
+}
+
+// viewNames maps the names accepted by ParseView to tab views
+var viewNames = map[string]View{
+	"packages": ViewPackages,
+	"search":   ViewSearch,
+	"updates":  ViewUpdates,
+	"history":  ViewHistory,
+	"system":   ViewSystem,
+	"queue":    ViewQueue,
+	"config":   ViewConfigFiles,
+}
+
+// ViewNames returns the names of the views the TUI can be opened at
+func ViewNames() []string {
+	var names []string
+	for _, tab := range DefaultTabs() {
+		names = append(names, strings.ToLower(tab.Name))
+	}
+	return names
+}
+
+// ParseView returns the tab view with the given name
+func ParseView(name string) (View, error) {
+	view, ok := viewNames[strings.ToLower(name)]
+	if !ok {
+		return ViewPackages, fmt.Errorf("unknown view %q (valid views: %s)", name, strings.Join(ViewNames(), ", "))
 	}
+	return view, nil
+}
+
+// Options controls how the TUI starts
+type Options struct {
+	View  View   // Tab to open at
+	Query string // Search query for the search view, filter text for other views
+
+	// RebuildIndex rebuilds the search index, if one is in use
+	RebuildIndex func(ctx context.Context) error
+
+	// Mappings translate package names between sources, as for the CLI;
+	// without them the common mappings are used
+	Mappings *database.MappingStore
 }
 
 // Model holds the application state
@@ -58,22 +116,64 @@ type Model struct {
 	config         *config.Config
 	historyStore   *history.Store
 	searchIndex    *database.Index
+	resolver       *database.Resolver
 	installedPkgs  []manager.Package
 	searchResults  []manager.Package
+	searchGroups   map[string][]manager.Package // Search result -> the results of every source it was found in
 	historyEntries []history.Entry
+	historyFilter  string // History filter the entries were loaded with
 	selectedPkg    *manager.Package
+	queue          *Queue
+
+	managerVersions map[string]string // Backend versions, loaded asynchronously
+	pins            pin.Set           // Packages pinned with poxy pin
+	details         *prefetcher       // Package details loaded for the rows on screen
+
+	// Updates view
+	upgrades         []manager.UpgradeCandidate
+	upgradesLoaded   bool
+	upgradesLoading  bool
+	upgradesFailed   []string                  // Managers whose upgrade check failed
+	selectedUpgrades map[string]bool           // Keyed by source/name
+	aurAlerts        map[string]aurwatch.Alert // AUR packages that changed hands, by name
+	daemonStatus     *daemon.Status            // Cached counts from the background daemon
+
+	// Config files view
+	configFiles    []configfiles.File
+	configLoaded   bool
+	configLoading  bool
+	configErr      error
+	configDiff     string
+	configDiffFile *configfiles.File // Leftover shown in the diff view
+
+	// AUR votes and comments of the package in the details view
+	aurDetails *aurDetailsMsg
+
+	// Sandbox permissions of the package in the details view
+	permissions *permissionsMsg
+
+	// AppStream catalogs, read when the details view is first opened
+	appstream        *appstream.Catalog
+	appstreamLoading bool
+
+	// Source health shown in the System view
+	sourceHealth   []manager.SourceHealth
+	sourcesLoaded  bool
+	sourcesLoading bool
 
 	// UI state
 	loading      bool
 	loadingMsg   string
 	errorMsg     string
 	successMsg   string
-	filterText   string
 	searchQuery  string
 	inputMode    bool
 	inputPrompt  string
 	inputValue   string
-	inputHandler func(string)
+	inputHandler func(string) tea.Cmd
+
+	// Filter text for each view
+	filters map[View]string
 
 	// Cursor positions for each view
 	cursors map[View]int
@@ -85,27 +185,61 @@ type Model struct {
 	styles *Styles
 	keys   KeyMap
 
+	// Command palette
+	paletteOpen   bool
+	paletteQuery  string
+	paletteCursor int
+	rebuildIndex  func(ctx context.Context) error
+
 	// Confirmation dialog
 	showConfirm   bool
 	confirmTitle  string
-	confirmAction func()
+	confirmDetail string
+	confirmAction func() tea.Cmd
 }
 
 // NewModel creates a new TUI model
-func NewModel(registry *manager.Registry, cfg *config.Config, historyStore *history.Store, searchIndex *database.Index) *Model {
-	return &Model{
-		tabs:         DefaultTabs(),
-		activeTab:    0,
-		activeView:   ViewPackages,
-		registry:     registry,
-		config:       cfg,
-		historyStore: historyStore,
-		searchIndex:  searchIndex,
-		cursors:      make(map[View]int),
-		scrolls:      make(map[View]int),
-		styles:       DefaultStyles(),
-		keys:         DefaultKeyMap(),
+func NewModel(registry *manager.Registry, cfg *config.Config, historyStore *history.Store, searchIndex *database.Index, opts Options) *Model {
+	m := &Model{
+		tabs:             DefaultTabs(),
+		activeTab:        0,
+		activeView:       ViewPackages,
+		registry:         registry,
+		config:           cfg,
+		historyStore:     historyStore,
+		searchIndex:      searchIndex,
+		resolver:         database.NewResolver(aliases(cfg), opts.Mappings),
+		queue:            NewQueue(),
+		details:          newPrefetcher(registry),
+		filters:          make(map[View]string),
+		selectedUpgrades: make(map[string]bool),
+		cursors:          make(map[View]int),
+		scrolls:          make(map[View]int),
+		styles:           DefaultStyles(),
+		keys:             DefaultKeyMap(),
+		rebuildIndex:     opts.RebuildIndex,
+	}
+
+	for i, tab := range m.tabs {
+		if tab.View == opts.View {
+			m.SetTab(i)
+		}
+	}
+	if opts.View == ViewSearch {
+		m.searchQuery = opts.Query
+	} else if opts.Query != "" {
+		m.filters[opts.View] = opts.Query
 	}
+
+	return m
+}
+
+// aliases returns the configured package aliases
+func aliases(cfg *config.Config) map[string]string {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Aliases
 }
 
 // SetSize sets the terminal size
@@ -142,6 +276,18 @@ func (m *Model) SetScroll(offset int) {
 	m.scrolls[m.activeView] = offset
 }
 
+// FilterText returns the filter text for the current view
+func (m *Model) FilterText() string {
+	return m.filters[m.activeView]
+}
+
+// SetFilterText sets the filter for the current view and resets the cursor
+func (m *Model) SetFilterText(text string) {
+	m.filters[m.activeView] = text
+	m.SetCursor(0)
+	m.SetScroll(0)
+}
+
 // VisibleHeight returns the height available for list content
 func (m *Model) VisibleHeight() int {
 	// Account for header (2), tabs (1), footer (2), padding (2)
@@ -154,29 +300,78 @@ func (m *Model) ListItems() []manager.Package {
 	case ViewPackages:
 		return m.filterPackages(m.installedPkgs)
 	case ViewSearch:
-		return m.searchResults
-	case ViewUpdates:
-		// TODO: Filter for upgradable packages
-		return nil
+		return m.filterPackages(m.searchResults)
 	default:
 		return nil
 	}
 }
 
+// VisiblePackages returns the packages on screen in the current view
+func (m *Model) VisiblePackages() []manager.Package {
+	if m.activeView == ViewDetails {
+		if m.selectedPkg == nil {
+			return nil
+		}
+		return []manager.Package{*m.selectedPkg}
+	}
+
+	items := m.ListItems()
+	start := min(m.Scroll(), len(items))
+	end := min(start+m.VisibleHeight(), len(items))
+	return items[start:end]
+}
+
 // filterPackages filters packages by the current filter text
 func (m *Model) filterPackages(pkgs []manager.Package) []manager.Package {
-	if m.filterText == "" {
-		return pkgs
+	return filterList(pkgs, ParseFilter(m.FilterText()), func(pkg manager.Package) filterRecord {
+		return filterRecord{fields: []string{pkg.Name, pkg.Description, pkg.Source}, source: pkg.Source, kind: pkg.Kind()}
+	})
+}
+
+// UpgradeItems returns the upgrade candidates matching the updates filter
+func (m *Model) UpgradeItems() []manager.UpgradeCandidate {
+	filter := ParseFilter(m.filters[ViewUpdates])
+	return filterList(m.upgrades, filter, func(c manager.UpgradeCandidate) filterRecord {
+		return filterRecord{fields: []string{c.Name, c.Source, c.CurrentVersion, c.NewVersion, c.HeldReason}, source: c.Source}
+	})
+}
+
+// SelectedUpgrade returns the upgrade candidate under the cursor
+func (m *Model) SelectedUpgrade() *manager.UpgradeCandidate {
+	items := m.UpgradeItems()
+	cursor := m.Cursor()
+	if cursor >= 0 && cursor < len(items) {
+		return &items[cursor]
 	}
+	return nil
+}
 
-	var filtered []manager.Package
-	for _, pkg := range pkgs {
-		if containsIgnoreCase(pkg.Name, m.filterText) ||
-			containsIgnoreCase(pkg.Description, m.filterText) {
-			filtered = append(filtered, pkg)
+// ToggleUpgradeSelection selects or deselects the upgrade under the cursor
+func (m *Model) ToggleUpgradeSelection() {
+	if c := m.SelectedUpgrade(); c != nil {
+		k := upgradeKey(*c)
+		if m.selectedUpgrades[k] {
+			delete(m.selectedUpgrades, k)
+		} else {
+			m.selectedUpgrades[k] = true
 		}
 	}
-	return filtered
+}
+
+// HistoryItems returns the history entries matching the history filter
+func (m *Model) HistoryItems() []history.Entry {
+	filter := ParseFilter(m.filters[ViewHistory])
+	return filterList(m.historyEntries, filter, func(e history.Entry) filterRecord {
+		fields := append([]string{string(e.Operation), e.Source, e.FormatTime(), e.Error}, e.Packages...)
+		for _, r := range e.Resolutions {
+			fields = append(fields, r.Requested)
+		}
+		status := "ok"
+		if !e.Success {
+			status = "failed"
+		}
+		return filterRecord{fields: fields, op: string(e.Operation), source: e.Source, packages: e.Packages, status: status, time: e.Timestamp}
+	})
 }
 
 // containsIgnoreCase checks if s contains substr (case insensitive)
@@ -220,10 +415,37 @@ func (m *Model) SelectedPackage() *manager.Package {
 	return nil
 }
 
+// listLen returns the number of navigable rows in the current view
+func (m *Model) listLen() int {
+	switch m.activeView {
+	case ViewQueue:
+		return m.queue.Len()
+	case ViewHistory:
+		return len(m.HistoryItems())
+	case ViewUpdates:
+		return len(m.UpgradeItems())
+	case ViewConfigFiles:
+		return len(m.ConfigFileItems())
+	case ViewConfigDiff:
+		return len(m.configDiffLines())
+	}
+	return len(m.ListItems())
+}
+
+// SelectedQueueItem returns the queue item under the cursor
+func (m *Model) SelectedQueueItem() *QueueItem {
+	items := m.queue.Items()
+	cursor := m.Cursor()
+	if cursor >= 0 && cursor < len(items) {
+		return &items[cursor]
+	}
+	return nil
+}
+
 // MoveCursor moves the cursor by delta, clamping to valid range
 func (m *Model) MoveCursor(delta int) {
-	items := m.ListItems()
-	if len(items) == 0 {
+	count := m.listLen()
+	if count == 0 {
 		return
 	}
 
@@ -231,8 +453,8 @@ func (m *Model) MoveCursor(delta int) {
 	if newPos < 0 {
 		newPos = 0
 	}
-	if newPos >= len(items) {
-		newPos = len(items) - 1
+	if newPos >= count {
+		newPos = count - 1
 	}
 	m.SetCursor(newPos)
 
@@ -255,15 +477,15 @@ func (m *Model) GoToTop() {
 
 // GoToBottom moves cursor to the bottom
 func (m *Model) GoToBottom() {
-	items := m.ListItems()
-	if len(items) == 0 {
+	count := m.listLen()
+	if count == 0 {
 		return
 	}
-	m.SetCursor(len(items) - 1)
+	m.SetCursor(count - 1)
 
 	visibleHeight := m.VisibleHeight()
-	if len(items) > visibleHeight {
-		m.SetScroll(len(items) - visibleHeight)
+	if count > visibleHeight {
+		m.SetScroll(count - visibleHeight)
 	}
 }
 
@@ -301,6 +523,10 @@ func (m *Model) ShowDetails() {
 
 // GoBack returns to the previous view
 func (m *Model) GoBack() {
+	if m.activeView == ViewConfigDiff {
+		m.activeView = ViewConfigFiles
+		return
+	}
 	if m.activeView == ViewDetails || m.activeView == ViewHelp {
 		m.activeView = m.prevView
 	}
@@ -331,22 +557,25 @@ func (m *Model) ClearMessages() {
 }
 
 // StartInput starts input mode
-func (m *Model) StartInput(prompt string, handler func(string)) {
+func (m *Model) StartInput(prompt string, handler func(string) tea.Cmd) {
 	m.inputMode = true
 	m.inputPrompt = prompt
 	m.inputValue = ""
 	m.inputHandler = handler
 }
 
-// FinishInput finishes input mode and calls the handler
-func (m *Model) FinishInput() {
-	if m.inputHandler != nil {
-		m.inputHandler(m.inputValue)
-	}
+// FinishInput finishes input mode and returns the command from the handler
+func (m *Model) FinishInput() tea.Cmd {
+	handler, value := m.inputHandler, m.inputValue
 	m.inputMode = false
 	m.inputPrompt = ""
 	m.inputValue = ""
 	m.inputHandler = nil
+
+	if handler != nil {
+		return handler(value)
+	}
+	return nil
 }
 
 // CancelInput cancels input mode
@@ -358,25 +587,32 @@ func (m *Model) CancelInput() {
 }
 
 // ShowConfirm shows a confirmation dialog
-func (m *Model) ShowConfirm(title string, action func()) {
+func (m *Model) ShowConfirm(title string, action func() tea.Cmd) {
+	m.ShowConfirmWithDetail(title, "", action)
+}
+
+// ShowConfirmWithDetail shows a confirmation dialog with a warning below the title
+func (m *Model) ShowConfirmWithDetail(title, detail string, action func() tea.Cmd) {
 	m.showConfirm = true
 	m.confirmTitle = title
+	m.confirmDetail = detail
 	m.confirmAction = action
 }
 
-// ConfirmYes executes the confirmation action
-func (m *Model) ConfirmYes() {
+// ConfirmYes executes the confirmation action and returns its command
+func (m *Model) ConfirmYes() tea.Cmd {
+	var cmd tea.Cmd
 	if m.confirmAction != nil {
-		m.confirmAction()
+		cmd = m.confirmAction()
 	}
-	m.showConfirm = false
-	m.confirmTitle = ""
-	m.confirmAction = nil
+	m.ConfirmNo()
+	return cmd
 }
 
 // ConfirmNo cancels the confirmation
 func (m *Model) ConfirmNo() {
 	m.showConfirm = false
 	m.confirmTitle = ""
+	m.confirmDetail = ""
 	m.confirmAction = nil
 }
@@ -0,0 +1,353 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+)
+
+// paletteHeight is the number of actions shown in the command palette
+const paletteHeight = 12
+
+// paletteAction is an entry of the command palette
+type paletteAction struct {
+	title string
+	key   string // Keybinding with the same effect, if any
+	run   func() tea.Cmd
+}
+
+// openPalette shows the command palette
+func (a *App) openPalette() {
+	a.paletteOpen = true
+	a.paletteQuery = ""
+	a.paletteCursor = 0
+}
+
+// closePalette hides the command palette
+func (a *App) closePalette() {
+	a.paletteOpen = false
+}
+
+// handlePaletteKey handles a key press while the palette is open
+func (a *App) handlePaletteKey(msg tea.KeyMsg) tea.Cmd {
+	matches := a.paletteMatches()
+
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		a.closePalette()
+	case tea.KeyEnter:
+		a.closePalette()
+		if a.paletteCursor < len(matches) {
+			return matches[a.paletteCursor].run()
+		}
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+		if a.paletteCursor > 0 {
+			a.paletteCursor--
+		}
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		if a.paletteCursor < len(matches)-1 {
+			a.paletteCursor++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(a.paletteQuery); len(r) > 0 {
+			a.paletteQuery = string(r[:len(r)-1])
+			a.paletteCursor = 0
+		}
+	case tea.KeyCtrlU:
+		a.paletteQuery = ""
+		a.paletteCursor = 0
+	case tea.KeySpace:
+		a.paletteQuery += " "
+		a.paletteCursor = 0
+	case tea.KeyRunes:
+		a.paletteQuery += string(msg.Runes)
+		a.paletteCursor = 0
+	}
+	return nil
+}
+
+// paletteMatches returns the actions matching the palette query, best
+// match first
+func (a *App) paletteMatches() []paletteAction {
+	actions := a.paletteActions()
+	if strings.TrimSpace(a.paletteQuery) == "" {
+		return actions
+	}
+
+	type scored struct {
+		action paletteAction
+		score  int
+	}
+	var matches []scored
+	for _, action := range actions {
+		if score, ok := fuzzyScore(a.paletteQuery, action.title); ok {
+			matches = append(matches, scored{action, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result := make([]paletteAction, len(matches))
+	for i, m := range matches {
+		result[i] = m.action
+	}
+	return result
+}
+
+// fuzzyScore matches the characters of query, in order, against text,
+// ignoring case and spaces in the query. Consecutive matches and matches at
+// the start of a word score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 5
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	// Prefer shorter titles among equal matches
+	return score*100 - len(t), true
+}
+
+// paletteActions returns the actions available in the current state
+func (a *App) paletteActions() []paletteAction {
+	var actions []paletteAction
+	add := func(title, key string, run func() tea.Cmd) {
+		actions = append(actions, paletteAction{title: title, key: key, run: run})
+	}
+	do := func(f func()) func() tea.Cmd {
+		return func() tea.Cmd { f(); return nil }
+	}
+
+	pkg := a.SelectedPackage()
+	if pkg != nil {
+		add("Open details of "+pkg.Name, "enter", do(a.ShowDetails))
+		if pkg.Installed {
+			add("Remove "+pkg.Name, "r", a.confirmRemove)
+		} else {
+			add("Install "+pkg.Name, "i", do(a.confirmInstall))
+		}
+		if pkg.Source != "" {
+			add(fmt.Sprintf("Upgrade all %s packages", pkg.Source), "U", do(a.confirmSourceUpgrade))
+		}
+		if a.activeView == ViewDetails && a.permissionManager(pkg) != nil {
+			add("Override permissions of "+pkg.Name, "p", do(a.startPermissionOverride))
+		}
+		if a.activeView == ViewSearch && len(a.searchGroups[resultKey(*pkg)]) > 1 {
+			add(fmt.Sprintf("Install %s from another source", pkg.Name), "s", do(a.switchSource))
+		}
+	}
+
+	add("Search packages", "/", do(func() {
+		a.setView(ViewSearch)
+		a.startSearch()
+	}))
+
+	if f := a.SelectedConfigFile(); f != nil {
+		if a.activeView == ViewConfigFiles {
+			add("Show diff of "+f.Path, "enter", a.showConfigDiff)
+		}
+		add("Merge "+f.Path, "m", a.mergeConfigFile)
+		add(fmt.Sprintf("Replace %s with the %s file", f.Target, f.Suffix), "R", do(a.confirmConfigReplace))
+		add(fmt.Sprintf("Keep %s, delete the %s file", f.Target, f.Suffix), "K", do(a.confirmConfigKeep))
+	}
+
+	switch a.activeView {
+	case ViewPackages, ViewSearch, ViewUpdates, ViewHistory, ViewConfigFiles:
+		add("Filter list", "f", do(a.startFilter))
+		if a.FilterText() != "" {
+			add("Clear filter", "", do(func() { a.SetFilterText("") }))
+		}
+	}
+
+	switch a.activeView {
+	case ViewPackages, ViewSearch, ViewUpdates, ViewHistory:
+		for _, mgr := range a.registry.Available() {
+			source := mgr.Name()
+			add(fmt.Sprintf("Show only %s packages", source), "", do(func() {
+				a.SetFilterText(withSource(a.FilterText(), source))
+			}))
+		}
+		if ParseFilter(a.FilterText()).source != "" {
+			add("Show all sources", "", do(func() {
+				a.SetFilterText(withSource(a.FilterText(), ""))
+			}))
+		}
+	}
+
+	if a.activeView == ViewPackages || a.activeView == ViewSearch {
+		for _, kind := range manager.Kinds {
+			add(fmt.Sprintf("Show only %s packages", kind), "", do(func() {
+				a.SetFilterText(withKind(a.FilterText(), kind))
+			}))
+		}
+		if ParseFilter(a.FilterText()).kind != "" {
+			add("Show all kinds of packages", "", do(func() {
+				a.SetFilterText(withKind(a.FilterText(), ""))
+			}))
+		}
+	}
+
+	if a.activeView == ViewUpdates {
+		add("Upgrade selected packages", "enter", do(a.queueSelectedUpgrades))
+		add("Upgrade all packages", "U", do(a.queueAllUpgrades))
+	}
+	add("Check for updates", "", func() tea.Cmd {
+		a.setView(ViewUpdates)
+		if a.upgradesLoading {
+			return nil
+		}
+		return a.loadUpgrades()
+	})
+	add("Update package databases", "u", do(func() {
+		a.ShowConfirm("Update package databases?", a.updateDatabases)
+	}))
+	add("Scan for .pacnew/.rpmnew files", "", func() tea.Cmd {
+		a.setView(ViewConfigFiles)
+		if a.configLoading {
+			return nil
+		}
+		return a.loadConfigFiles()
+	})
+	add("Check source status", "", func() tea.Cmd {
+		a.setView(ViewSystem)
+		if a.sourcesLoading {
+			return nil
+		}
+		return a.probeSources()
+	})
+	add("Create snapshot", "", a.createSnapshot)
+	if a.rebuildIndex != nil {
+		add("Rebuild search index", "", a.rebuildSearchIndex)
+	}
+
+	if a.activeView == ViewQueue {
+		add("Clear finished queue items", "c", do(func() {
+			a.queue.ClearFinished()
+			a.GoToTop()
+		}))
+	}
+
+	for i, tab := range a.tabs {
+		index := i
+		add("Go to "+tab.Name, fmt.Sprint(i+1), do(func() { a.SetTab(index) }))
+	}
+
+	add("Show keyboard shortcuts", "?", do(func() {
+		if a.activeView != ViewHelp {
+			a.prevView = a.activeView
+			a.activeView = ViewHelp
+		}
+	}))
+	add("Quit", "q", func() tea.Cmd {
+		a.quitting = true
+		return tea.Quit
+	})
+
+	return actions
+}
+
+// setView switches to the tab showing view
+func (a *App) setView(view View) {
+	for i, tab := range a.tabs {
+		if tab.View == view {
+			a.SetTab(i)
+			return
+		}
+	}
+}
+
+// createSnapshot captures a manual snapshot of all available sources
+func (a *App) createSnapshot() tea.Cmd {
+	a.SetLoading(true, "Creating snapshot...")
+	managers := a.registry.Available()
+	return func() tea.Msg {
+		snap, err := snapshot.CaptureAndSave(context.Background(), snapshot.TriggerManual, "created from the TUI", managers)
+		if err != nil {
+			return operationCompleteMsg{err: fmt.Errorf("snapshot failed: %w", err)}
+		}
+		return operationCompleteMsg{success: true, message: fmt.Sprintf("Snapshot %s created (%d packages)", snap.ID, snap.PackageCount())}
+	}
+}
+
+// rebuildSearchIndex rebuilds the search index from the package managers
+func (a *App) rebuildSearchIndex() tea.Cmd {
+	a.SetLoading(true, "Rebuilding search index...")
+	rebuild := a.rebuildIndex
+	return func() tea.Msg {
+		if err := rebuild(context.Background()); err != nil {
+			return operationCompleteMsg{err: fmt.Errorf("index rebuild failed: %w", err)}
+		}
+		return operationCompleteMsg{success: true, message: "Search index rebuilt"}
+	}
+}
+
+// renderPalette renders the command palette over the screen
+func (a *App) renderPalette() string {
+	var b strings.Builder
+
+	b.WriteString(a.styles.InputPrompt.Render("> "))
+	b.WriteString(a.paletteQuery)
+	b.WriteString(a.styles.InputCursor.Render("_"))
+	b.WriteString("\n\n")
+
+	matches := a.paletteMatches()
+	if len(matches) == 0 {
+		b.WriteString(a.styles.Description.Render("No matching actions"))
+	}
+
+	// Keep the cursor in the visible window
+	start := 0
+	if a.paletteCursor >= paletteHeight {
+		start = a.paletteCursor - paletteHeight + 1
+	}
+	end := min(start+paletteHeight, len(matches))
+
+	width := 50
+	for i := start; i < end; i++ {
+		action := matches[i]
+		cursor := "  "
+		title := action.title
+		if i == a.paletteCursor {
+			cursor = a.styles.InputPrompt.Render("> ")
+			title = a.styles.PackageName.Render(title)
+		}
+		hint := ""
+		if action.key != "" {
+			hint = a.styles.HelpKey.Render(action.key)
+		}
+		padding := max(width-lipgloss.Width(title)-lipgloss.Width(hint), 1)
+		b.WriteString(cursor + title + strings.Repeat(" ", padding) + hint + "\n")
+	}
+	if len(matches) > end {
+		b.WriteString(a.styles.Description.Render(fmt.Sprintf("  ... %d more", len(matches)-end)))
+	}
+
+	box := a.styles.Dialog.Width(width + 6).Render(
+		a.styles.DialogTitle.Render("Command Palette") + "\n" + strings.TrimRight(b.String(), "\n"),
+	)
+	return lipgloss.Place(a.width, a.height, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(ColorBg))
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/pkg/manager"
+)
+
+// permissionsPrompt is the input prompt of permission overrides
+const permissionsPrompt = "Override (+kind=value, -kind=value or reset): "
+
+// permissionsMsg carries the sandbox permissions of a package
+type permissionsMsg struct {
+	name  string
+	perms *manager.Permissions
+	err   error
+}
+
+// permissionsChangedMsg reports the result of overriding permissions
+type permissionsChangedMsg struct {
+	name    string
+	message string
+	err     error
+}
+
+// permissionManager returns the manager of pkg if it can show and override
+// the permissions of installed packages
+func (a *App) permissionManager(pkg *manager.Package) manager.PermissionManager {
+	if pkg == nil || !pkg.Installed {
+		return nil
+	}
+	mgr, ok := a.registry.Get(pkg.Source)
+	if !ok {
+		return nil
+	}
+	pm, _ := mgr.(manager.PermissionManager)
+	return pm
+}
+
+// loadPermissions reads the permissions of the package in the details view,
+// if its source sandboxes apps
+func (a *App) loadPermissions() tea.Cmd {
+	pkg := a.selectedPkg
+	pm := a.permissionManager(pkg)
+	if pm == nil {
+		return nil
+	}
+	if a.permissions != nil && a.permissions.name == pkg.Name && a.permissions.err == nil {
+		return nil
+	}
+
+	name := pkg.Name
+	a.permissions = &permissionsMsg{name: name}
+	return func() tea.Msg {
+		perms, err := pm.Permissions(context.Background(), name)
+		return permissionsMsg{name: name, perms: perms, err: err}
+	}
+}
+
+// startPermissionOverride asks for overrides of the permissions of the
+// package in the details view
+func (a *App) startPermissionOverride() {
+	pkg := a.selectedPkg
+	pm := a.permissionManager(pkg)
+	if pm == nil {
+		return
+	}
+
+	name := pkg.Name
+	a.textInput.SetValue("")
+	a.textInput.Focus()
+	a.StartInput(permissionsPrompt, func(input string) tea.Cmd {
+		fields := strings.Fields(input)
+		if len(fields) == 0 {
+			return nil
+		}
+
+		if len(fields) == 1 && fields[0] == "reset" {
+			return func() tea.Msg {
+				err := pm.ResetPermissions(context.Background(), name, false)
+				return permissionsChangedMsg{name: name, message: "Reset the permission overrides of " + name, err: err}
+			}
+		}
+
+		overrides := make([]manager.PermissionOverride, 0, len(fields))
+		for _, field := range fields {
+			o, err := manager.ParsePermissionOverride(field)
+			if err != nil {
+				a.SetError(err.Error())
+				return nil
+			}
+			overrides = append(overrides, o)
+		}
+		return func() tea.Msg {
+			err := pm.OverridePermissions(context.Background(), name, overrides, false)
+			return permissionsChangedMsg{name: name, message: fmt.Sprintf("Applied %d override(s) to %s", len(overrides), name), err: err}
+		}
+	})
+}
+
+// handlePermissionsChanged reports an override and reloads the permissions
+func (a *App) handlePermissionsChanged(msg permissionsChangedMsg) tea.Cmd {
+	if msg.err != nil {
+		a.SetError(msg.err.Error())
+		return nil
+	}
+	a.SetSuccess(msg.message)
+	if a.permissions != nil && a.permissions.name == msg.name {
+		a.permissions = nil
+	}
+	return a.loadPermissions()
+}
+
+// renderPermissions renders the Permissions section of the details view
+func (a *App) renderPermissions(pkg *manager.Package) string {
+	if a.permissionManager(pkg) == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(a.styles.Subtitle.Render("Permissions"))
+	b.WriteString("\n")
+
+	if a.inputMode && a.inputPrompt == permissionsPrompt {
+		b.WriteString("  " + a.styles.InputPrompt.Render(permissionsPrompt))
+		b.WriteString(a.textInput.View())
+		b.WriteString("\n")
+		b.WriteString(a.styles.Description.Render("  e.g. -share=network +filesystem=~/Games:ro +device=dri"))
+		b.WriteString("\n\n")
+	}
+
+	d := a.permissions
+	switch {
+	case d == nil || d.name != pkg.Name:
+		return b.String()
+	case d.err != nil:
+		b.WriteString(a.styles.Description.Render("  " + d.err.Error()))
+		b.WriteString("\n\n")
+		return b.String()
+	case d.perms == nil:
+		b.WriteString("  " + a.activityIndicator() + " " + a.styles.Description.Render("Loading permissions..."))
+		b.WriteString("\n\n")
+		return b.String()
+	}
+
+	perms := d.perms
+	network := a.styles.Success.Render("no")
+	if perms.Network() {
+		network = a.styles.Warning.Render("yes")
+	}
+	b.WriteString(fmt.Sprintf("  %-13s %s\n", "Network:", network))
+	for _, g := range perms.Groups() {
+		b.WriteString(fmt.Sprintf("  %-13s %s\n", g.Label+":", strings.Join(g.Values, ", ")))
+	}
+	if perms.Empty() {
+		b.WriteString(a.styles.Description.Render("  No access outside the sandbox"))
+		b.WriteString("\n")
+	}
+
+	if perms.Overrides != nil {
+		b.WriteString("\n  " + a.styles.Subtitle.Render("Your overrides") + "\n")
+		for _, g := range perms.Overrides.Groups() {
+			b.WriteString(fmt.Sprintf("  %-13s %s\n", g.Label+":", strings.Join(g.Values, ", ")))
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
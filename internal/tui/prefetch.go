@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/pkg/manager"
+)
+
+const (
+	// prefetchWorkers caps the number of Info lookups running at once
+	prefetchWorkers = 3
+
+	// prefetchInterval is the minimum time between starting two lookups, so
+	// scrolling through a long list does not flood the package managers
+	prefetchInterval = 50 * time.Millisecond
+
+	// prefetchTimeout bounds a single Info lookup
+	prefetchTimeout = 10 * time.Second
+)
+
+// infoLoadedMsg reports that a prefetched Info lookup finished
+type infoLoadedMsg struct {
+	key string
+}
+
+// packageKey identifies a package across sources
+func packageKey(pkg manager.Package) string {
+	return pkg.Source + "/" + pkg.Name
+}
+
+// prefetcher loads package details for the rows on screen in the background.
+// Lookups run on a small worker pool and are cached for the session; rows
+// that scroll out of view before their turn are dropped from the queue.
+type prefetcher struct {
+	registry *manager.Registry
+	slots    chan struct{}
+	ticker   *time.Ticker
+
+	mu      sync.Mutex
+	cache   map[string]*manager.PackageInfo // nil value: lookup failed
+	pending map[string]bool
+	visible map[string]bool
+}
+
+// newPrefetcher creates a prefetcher that looks packages up in registry
+func newPrefetcher(registry *manager.Registry) *prefetcher {
+	return &prefetcher{
+		registry: registry,
+		slots:    make(chan struct{}, prefetchWorkers),
+		ticker:   time.NewTicker(prefetchInterval),
+		cache:    make(map[string]*manager.PackageInfo),
+		pending:  make(map[string]bool),
+		visible:  make(map[string]bool),
+	}
+}
+
+// Get returns the cached details of pkg, or nil if they are not loaded
+func (p *prefetcher) Get(pkg manager.Package) *manager.PackageInfo {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cache[packageKey(pkg)]
+}
+
+// Reset drops all cached details, e.g. after packages were installed or
+// removed
+func (p *prefetcher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = make(map[string]*manager.PackageInfo)
+}
+
+// Request marks pkgs as the rows on screen and returns a command that loads
+// the details of each one not cached or already being loaded.
+func (p *prefetcher) Request(pkgs []manager.Package) tea.Cmd {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.visible = make(map[string]bool, len(pkgs))
+	var cmds []tea.Cmd
+	for _, pkg := range pkgs {
+		key := packageKey(pkg)
+		p.visible[key] = true
+		if _, ok := p.cache[key]; ok || p.pending[key] {
+			continue
+		}
+		p.pending[key] = true
+		cmds = append(cmds, p.fetch(pkg))
+	}
+	return tea.Batch(cmds...)
+}
+
+// fetch returns a command that waits for a worker slot and loads pkg
+func (p *prefetcher) fetch(pkg manager.Package) tea.Cmd {
+	key := packageKey(pkg)
+	return func() tea.Msg {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+		<-p.ticker.C
+
+		p.mu.Lock()
+		wanted := p.visible[key]
+		if !wanted {
+			delete(p.pending, key)
+		}
+		p.mu.Unlock()
+		if !wanted {
+			return nil
+		}
+
+		var info *manager.PackageInfo
+		if mgr, ok := p.registry.Get(pkg.Source); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), prefetchTimeout)
+			info, _ = mgr.Info(ctx, pkg.Name) //nolint:errcheck
+			cancel()
+		}
+
+		p.mu.Lock()
+		delete(p.pending, key)
+		p.cache[key] = info
+		p.mu.Unlock()
+
+		return infoLoadedMsg{key: key}
+	}
+}
@@ -0,0 +1,334 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/internal/history"
+	"poxy/internal/pin"
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+)
+
+// QueueStatus represents the state of a queued operation
+type QueueStatus int
+
+const (
+	QueuePending QueueStatus = iota
+	QueueRunning
+	QueueDone
+	QueueFailed
+	QueueCancelled
+)
+
+// String returns a short label for the status
+func (s QueueStatus) String() string {
+	switch s {
+	case QueuePending:
+		return "pending"
+	case QueueRunning:
+		return "running"
+	case QueueDone:
+		return "done"
+	case QueueFailed:
+		return "failed"
+	case QueueCancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// QueueItem is a single operation waiting in (or processed by) the queue
+type QueueItem struct {
+	ID       int
+	Op       history.Operation
+	Source   string
+	Packages []string
+	Status   QueueStatus
+	Err      error
+}
+
+// Label returns a human-readable description of the item
+func (q *QueueItem) Label() string {
+	if len(q.Packages) == 0 {
+		return fmt.Sprintf("%s all [%s]", q.Op, q.Source)
+	}
+	return fmt.Sprintf("%s %s [%s]", q.Op, strings.Join(q.Packages, ", "), q.Source)
+}
+
+// Queue holds operations that are executed one at a time
+type Queue struct {
+	items  []*QueueItem
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewQueue creates an empty operation queue
+func NewQueue() *Queue {
+	return &Queue{nextID: 1}
+}
+
+// Add appends a pending operation to the queue
+func (q *Queue) Add(op history.Operation, source string, packages []string) *QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item := &QueueItem{
+		ID:       q.nextID,
+		Op:       op,
+		Source:   source,
+		Packages: packages,
+		Status:   QueuePending,
+	}
+	q.nextID++
+	q.items = append(q.items, item)
+	return item
+}
+
+// Items returns a copy of the queued items in insertion order
+func (q *Queue) Items() []QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := make([]QueueItem, len(q.items))
+	for i, item := range q.items {
+		items[i] = *item
+	}
+	return items
+}
+
+// Len returns the number of items in the queue
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Counts returns the number of pending and running items
+func (q *Queue) Counts() (pending, running int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		switch item.Status {
+		case QueuePending:
+			pending++
+		case QueueRunning:
+			running++
+		}
+	}
+	return pending, running
+}
+
+// Start marks the next pending item as running and returns it.
+// Returns nil if an item is already running or nothing is pending.
+func (q *Queue) Start() *QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.Status == QueueRunning {
+			return nil
+		}
+	}
+
+	for _, item := range q.items {
+		if item.Status == QueuePending {
+			item.Status = QueueRunning
+			return item
+		}
+	}
+
+	return nil
+}
+
+// Finish records the result of a running item
+func (q *Queue) Finish(id int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.ID != id {
+			continue
+		}
+		if err != nil {
+			item.Status = QueueFailed
+			item.Err = err
+		} else {
+			item.Status = QueueDone
+		}
+		return
+	}
+}
+
+// Cancel cancels a pending item. Returns false if the item is running or
+// has already finished: a package manager stopped halfway can leave its
+// database locked or packages half installed, so a running item is let
+// finish.
+func (q *Queue) Cancel(id int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.ID == id && item.Status == QueuePending {
+			item.Status = QueueCancelled
+			return true
+		}
+	}
+	return false
+}
+
+// ClearFinished removes completed, failed and cancelled items
+func (q *Queue) ClearFinished() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var kept []*QueueItem
+	for _, item := range q.items {
+		if item.Status == QueuePending || item.Status == QueueRunning {
+			kept = append(kept, item)
+		}
+	}
+	q.items = kept
+}
+
+// queueItemDoneMsg is sent when a queued operation finishes
+type queueItemDoneMsg struct {
+	id  int
+	err error
+}
+
+// enqueue adds an operation and starts the queue if it is idle. Packages to
+// install or remove are resolved to their names in source, as the CLI does.
+func (a *App) enqueue(op history.Operation, source string, packages []string) tea.Cmd {
+	if op == history.OpInstall || op == history.OpUninstall {
+		packages = a.resolver.ResolveAll(source, packages)
+	}
+	item := a.queue.Add(op, source, packages)
+	a.SetSuccess(fmt.Sprintf("Queued: %s", item.Label()))
+	return a.processQueue()
+}
+
+// processQueue starts the next pending operation if nothing is running
+func (a *App) processQueue() tea.Cmd {
+	item := a.queue.Start()
+	if item == nil {
+		return nil
+	}
+
+	op := *item
+	return func() tea.Msg {
+		return queueItemDoneMsg{id: op.ID, err: a.runQueueItem(context.Background(), op)}
+	}
+}
+
+// runQueueItem executes a queued operation through the snapshot/history pipeline
+func (a *App) runQueueItem(ctx context.Context, item QueueItem) error {
+	mgr, ok := a.registry.Get(item.Source)
+	if !ok {
+		return fmt.Errorf("unknown source: %s", item.Source)
+	}
+
+	// Capture a pre-operation snapshot
+	if a.config == nil || a.config.General.Snapshots {
+		trigger := snapshot.TriggerInstall
+		switch item.Op {
+		case history.OpUninstall:
+			trigger = snapshot.TriggerUninstall
+		case history.OpUpgrade:
+			trigger = snapshot.TriggerUpgrade
+		}
+		_, _ = snapshot.CaptureAndSave(ctx, trigger, "before "+item.Label(), a.registry.Available()) //nolint:errcheck
+	}
+
+	// extra_args under [managers.<name>]
+	var extraArgs []string
+	if a.config != nil {
+		extraArgs = a.config.GetManagerConfig(item.Source).ExtraArgs
+	}
+
+	var err error
+	switch item.Op {
+	case history.OpInstall:
+		err = mgr.Install(ctx, item.Packages, manager.InstallOpts{AutoConfirm: true, ExtraArgs: extraArgs})
+	case history.OpUninstall:
+		err = mgr.Uninstall(ctx, item.Packages, manager.UninstallOpts{AutoConfirm: true})
+	case history.OpUpgrade:
+		opts := manager.UpgradeOpts{AutoConfirm: true, Packages: item.Packages, ExtraArgs: extraArgs}
+		if pins, pinErr := pin.Load(); pinErr == nil {
+			opts, _, err = pins.Apply(ctx, mgr, opts)
+		}
+		if err == nil {
+			err = mgr.Upgrade(ctx, opts)
+		}
+	default:
+		err = fmt.Errorf("unsupported operation: %s", item.Op)
+	}
+
+	// Record in history
+	if a.historyStore != nil {
+		entry := history.NewEntry(item.Op, mgr.Name(), item.Packages)
+		if err != nil {
+			entry.MarkFailed(err)
+		} else {
+			entry.MarkSuccess()
+		}
+		_ = a.historyStore.Record(entry) //nolint:errcheck
+	}
+
+	return err
+}
+
+// renderQueueView renders the operation queue pane
+func (a *App) renderQueueView() string {
+	var b strings.Builder
+
+	pending, running := a.queue.Counts()
+	b.WriteString(a.styles.Title.Render(fmt.Sprintf("Operation Queue (%d running, %d pending)", running, pending)))
+	b.WriteString("\n\n")
+
+	items := a.queue.Items()
+	if len(items) == 0 {
+		b.WriteString(a.styles.Description.Render("No queued operations. Press i/r on a package to queue one."))
+		return b.String()
+	}
+
+	start := min(a.Scroll(), len(items))
+	end := min(start+a.VisibleHeight(), len(items))
+
+	for i := start; i < end; i++ {
+		item := items[i]
+
+		prefix := "  "
+		if i == a.Cursor() {
+			prefix = a.styles.ListItemSelected.Render("> ")
+		}
+
+		var status string
+		switch item.Status {
+		case QueuePending:
+			status = a.styles.Info.Render(item.Status.String())
+		case QueueRunning:
+			status = a.activityIndicator() + " " + a.styles.Warning.Render(item.Status.String())
+		case QueueDone:
+			status = a.styles.Success.Render(item.Status.String())
+		case QueueFailed:
+			status = a.styles.Error.Render(item.Status.String())
+		case QueueCancelled:
+			status = a.styles.Description.Render(item.Status.String())
+		}
+
+		line := fmt.Sprintf("%s#%-3d %-50s %s", prefix, item.ID, item.Label(), status)
+		if item.Err != nil && item.Status == QueueFailed {
+			line += " " + a.styles.Error.Render(item.Err.Error())
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
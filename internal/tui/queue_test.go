@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/internal/config"
+	"poxy/internal/history"
+	"poxy/pkg/database"
+	"poxy/pkg/manager"
+)
+
+// newQueueApp returns an app showing the queue with count pending items,
+// sized to show three rows of it.
+func newQueueApp(count int) *App {
+	app := NewApp(manager.NewRegistry(nil), nil, nil, nil, Options{View: ViewQueue})
+	app.SetSize(100, 10)
+	for i := 0; i < count; i++ {
+		app.queue.Add(history.OpInstall, "pacman", []string{fmt.Sprintf("pkg%d", i+1)})
+	}
+	return app
+}
+
+// itemStatus returns the status of the queue item with id.
+func itemStatus(q *Queue, id int) QueueStatus {
+	for _, item := range q.Items() {
+		if item.ID == id {
+			return item.Status
+		}
+	}
+	return -1
+}
+
+func TestQueueLifecycle(t *testing.T) {
+	q := NewQueue()
+	for _, pkg := range []string{"vim", "git", "htop"} {
+		q.Add(history.OpInstall, "pacman", []string{pkg})
+	}
+
+	first := q.Start()
+	if first == nil || first.ID != 1 || first.Status != QueueRunning {
+		t.Fatalf("Start() = %+v, want #1 running", first)
+	}
+	if next := q.Start(); next != nil {
+		t.Errorf("Start() while #1 runs = %+v, want nil", next)
+	}
+
+	if q.Cancel(1) {
+		t.Error("Cancel() cancelled the running item")
+	}
+	if !q.Cancel(2) {
+		t.Error("Cancel() of a pending item failed")
+	}
+	if pending, running := q.Counts(); pending != 1 || running != 1 {
+		t.Errorf("Counts() = %d pending, %d running, want 1 and 1", pending, running)
+	}
+
+	q.Finish(1, nil)
+	if status := itemStatus(q, 1); status != QueueDone {
+		t.Errorf("#1 = %s, want done", status)
+	}
+	if q.Cancel(1) {
+		t.Error("Cancel() of a finished item succeeded")
+	}
+
+	third := q.Start()
+	if third == nil || third.ID != 3 {
+		t.Fatalf("Start() = %+v, want #3, skipping the cancelled #2", third)
+	}
+	q.Finish(3, errors.New("target not found: htop"))
+	items := q.Items()
+	if items[2].Status != QueueFailed || items[2].Err == nil {
+		t.Errorf("#3 = %+v, want failed with the error", items[2])
+	}
+	if q.Start() != nil {
+		t.Error("Start() found an item with nothing pending")
+	}
+
+	q.Add(history.OpUninstall, "pacman", []string{"nano"})
+	q.ClearFinished()
+	if items := q.Items(); len(items) != 1 || items[0].ID != 4 {
+		t.Errorf("Items() after ClearFinished() = %+v, want only the pending #4", items)
+	}
+}
+
+func TestRenderQueueViewScrolls(t *testing.T) {
+	app := newQueueApp(8)
+	app.GoToBottom()
+
+	view := app.renderQueueView()
+	for id := 1; id <= 8; id++ {
+		shown := strings.Contains(view, fmt.Sprintf("#%-3d", id))
+		if want := id >= 6; shown != want {
+			t.Errorf("#%d shown = %v, want %v in:\n%s", id, shown, want, view)
+		}
+	}
+	if !strings.Contains(view, "> #8") {
+		t.Errorf("the cursor is not on #8 in:\n%s", view)
+	}
+
+	app.GoToTop()
+	view = app.renderQueueView()
+	if !strings.Contains(view, "> #1") || strings.Contains(view, "#4 ") {
+		t.Errorf("after GoToTop() the view is:\n%s", view)
+	}
+}
+
+func TestCancelKey(t *testing.T) {
+	app := newQueueApp(2)
+	app.queue.Start()
+	cancel := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}
+
+	app.Update(cancel)
+	if status := itemStatus(app.queue, 1); status != QueueRunning {
+		t.Errorf("#1 = %s after cancelling it while running, want running", status)
+	}
+	if !strings.Contains(app.errorMsg, "running") {
+		t.Errorf("error = %q, want it to say the item is running", app.errorMsg)
+	}
+
+	app.MoveCursor(1)
+	app.Update(cancel)
+	if status := itemStatus(app.queue, 2); status != QueueCancelled {
+		t.Errorf("#2 = %s after cancelling it, want cancelled", status)
+	}
+}
+
+func TestEnqueueResolvesNames(t *testing.T) {
+	cfg := config.Default()
+	cfg.Aliases = map[string]string{"code": "vscode"}
+	mappings := database.NewMappingStore()
+	mappings.Add(&database.Mapping{Canonical: "vscode", Sources: map[string]string{"apt": "code", "flatpak": "com.visualstudio.code"}})
+	app := NewApp(manager.NewRegistry(nil), cfg, nil, nil, Options{View: ViewQueue, Mappings: mappings})
+
+	tests := []struct {
+		op     history.Operation
+		source string
+		pkg    string
+		want   string
+	}{
+		{history.OpInstall, "flatpak", "vscode", "com.visualstudio.code"},
+		{history.OpInstall, "flatpak", "code", "com.visualstudio.code"},
+		{history.OpUninstall, "apt", "vscode", "code"},
+		{history.OpInstall, "apt", "htop", "htop"},
+	}
+
+	for _, tt := range tests {
+		app.enqueue(tt.op, tt.source, []string{tt.pkg})
+		items := app.queue.Items()
+		if got := items[len(items)-1].Packages; len(got) != 1 || got[0] != tt.want {
+			t.Errorf("enqueue(%s, %s, %s) queued %v, want %s", tt.op, tt.source, tt.pkg, got, tt.want)
+		}
+	}
+}
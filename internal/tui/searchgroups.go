@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"poxy/pkg/database"
+	"poxy/pkg/manager"
+)
+
+// resultKey identifies a search result among its alternatives
+func resultKey(pkg manager.Package) string {
+	return pkg.Source + "/" + pkg.Name
+}
+
+// groupSearchResults lists a package found in several sources once, as its
+// best ranked result, and returns the results of every source for each
+// listed package, best first
+func groupSearchResults(mappings *database.MappingStore, results []manager.Package) ([]manager.Package, map[string][]manager.Package) {
+	var listed []manager.Package
+	groups := make(map[string][]manager.Package)
+
+	for _, indexes := range mappings.GroupPackages(results) {
+		group := make([]manager.Package, len(indexes))
+		for i, index := range indexes {
+			group[i] = results[index]
+		}
+		listed = append(listed, group[0])
+		groups[resultKey(group[0])] = group
+	}
+	return listed, groups
+}
+
+// switchSource replaces the selected search result with the same package
+// from the next source it was found in, so installing it uses that source
+func (a *App) switchSource() {
+	pkg := a.SelectedPackage()
+	if a.activeView != ViewSearch || pkg == nil {
+		return
+	}
+
+	key := resultKey(*pkg)
+	group := a.searchGroups[key]
+	if len(group) < 2 {
+		a.SetError(fmt.Sprintf("%s was only found in %s", pkg.Name, pkg.Source))
+		return
+	}
+
+	next := group[0]
+	for i, alt := range group {
+		if resultKey(alt) == key {
+			next = group[(i+1)%len(group)]
+			break
+		}
+	}
+
+	for i := range a.searchResults {
+		if resultKey(a.searchResults[i]) == key {
+			a.searchResults[i] = next
+		}
+	}
+	delete(a.searchGroups, key)
+	a.searchGroups[resultKey(next)] = group
+	a.SetSuccess(fmt.Sprintf("%s will be installed from %s", next.Name, next.Badge()))
+}
+
+// renderAlternateSources renders the other sources a search result was
+// found in
+func (a *App) renderAlternateSources(pkg manager.Package) string {
+	if a.activeView != ViewSearch {
+		return ""
+	}
+
+	var others []string
+	for _, alt := range a.searchGroups[resultKey(pkg)] {
+		if alt.Source != pkg.Source {
+			others = append(others, alt.Source)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	return a.styles.ListItemDim.Render("+" + strings.Join(others, " +"))
+}
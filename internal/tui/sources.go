@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"poxy/pkg/manager"
+)
+
+// sourceHealthMsg carries the result of probing the available sources
+type sourceHealthMsg struct {
+	health []manager.SourceHealth
+}
+
+// probeSources checks that each available source is reachable
+func (a *App) probeSources() tea.Cmd {
+	a.sourcesLoading = true
+	managers := a.registry.Available()
+	return func() tea.Msg {
+		return sourceHealthMsg{health: manager.ProbeSources(context.Background(), managers, manager.DefaultProbeTimeout)}
+	}
+}
+
+// sourceHealthOf returns the probe result of a source, if it has been probed
+func (a *App) sourceHealthOf(name string) (manager.SourceHealth, bool) {
+	for _, h := range a.sourceHealth {
+		if h.Source == name {
+			return h, true
+		}
+	}
+	return manager.SourceHealth{}, false
+}
+
+// renderSourceStatus renders the status column of a source in the System view
+func (a *App) renderSourceStatus(name string) string {
+	h, ok := a.sourceHealthOf(name)
+	switch {
+	case !ok && a.sourcesLoading:
+		return a.activityIndicator()
+	case !ok:
+		return a.styles.Description.Render("-")
+	case h.Status == manager.HealthOK:
+		return a.styles.Success.Render("OK")
+	case h.Status == manager.HealthDegraded:
+		return a.styles.Warning.Render("Degraded")
+	}
+	return a.styles.Error.Render("Down")
+}
+
+// renderSourceProblems explains the sources that are degraded or down
+func (a *App) renderSourceProblems() string {
+	var b strings.Builder
+	for _, h := range a.sourceHealth {
+		if h.Status == manager.HealthOK {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n")
+			b.WriteString(a.styles.Subtitle.Render("Source Problems"))
+			b.WriteString("\n")
+		}
+		b.WriteString("  " + h.Source + ": " + h.Summary + "\n")
+		if h.Fix != "" {
+			b.WriteString("    " + a.styles.Description.Render("Fix: "+h.Fix) + "\n")
+		}
+	}
+	return b.String()
+}
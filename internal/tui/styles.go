@@ -3,31 +3,50 @@ package tui
 
 import (
 	"github.com/charmbracelet/lipgloss"
+
+	"poxy/pkg/theme"
 )
 
-// Color palette - matches existing CLI colors
+// Color palette, set from the active theme
 var (
-	ColorPrimary   = lipgloss.Color("#7C3AED") // Purple
-	ColorSecondary = lipgloss.Color("#06B6D4") // Cyan
-	ColorSuccess   = lipgloss.Color("#10B981") // Green
-	ColorWarning   = lipgloss.Color("#F59E0B") // Yellow
-	ColorError     = lipgloss.Color("#EF4444") // Red
-	ColorMuted     = lipgloss.Color("#6B7280") // Gray
-	ColorText      = lipgloss.Color("#F3F4F6") // Light gray
-	ColorBg        = lipgloss.Color("#1F2937") // Dark gray
-	ColorBgAlt     = lipgloss.Color("#374151") // Slightly lighter
+	ColorPrimary   lipgloss.Color
+	ColorSecondary lipgloss.Color
+	ColorSuccess   lipgloss.Color
+	ColorWarning   lipgloss.Color
+	ColorError     lipgloss.Color
+	ColorMuted     lipgloss.Color
+	ColorText      lipgloss.Color
+	ColorBg        lipgloss.Color
+	ColorBgAlt     lipgloss.Color
+	ColorBadgeText lipgloss.Color
 )
 
-// Source colors for different package managers
-var SourceColors = map[string]lipgloss.Color{
-	"pacman":  lipgloss.Color("#1793D1"), // Arch blue
-	"apt":     lipgloss.Color("#A80030"), // Debian red
-	"dnf":     lipgloss.Color("#294172"), // Fedora blue
-	"brew":    lipgloss.Color("#FBB040"), // Homebrew yellow
-	"flatpak": lipgloss.Color("#4A90D9"), // Flatpak blue
-	"snap":    lipgloss.Color("#E95420"), // Ubuntu orange
-	"aur":     lipgloss.Color("#1793D1"), // Arch blue
-	"winget":  lipgloss.Color("#0078D4"), // Windows blue
+// activeTheme supplies the source badge colors
+var activeTheme *theme.Theme
+
+func init() {
+	ApplyTheme(theme.Default())
+}
+
+// ApplyTheme sets the TUI palette from a theme. Call it before creating the
+// app, since styles are built from the palette.
+func ApplyTheme(t *theme.Theme) {
+	activeTheme = t
+	ColorPrimary = lipgloss.Color(t.Color(theme.Accent))
+	ColorSecondary = lipgloss.Color(t.Color(theme.Info))
+	ColorSuccess = lipgloss.Color(t.Color(theme.Success))
+	ColorWarning = lipgloss.Color(t.Color(theme.Warning))
+	ColorError = lipgloss.Color(t.Color(theme.Error))
+	ColorMuted = lipgloss.Color(t.Color(theme.Muted))
+	ColorText = lipgloss.Color(t.Color(theme.Text))
+	ColorBg = lipgloss.Color(t.Color(theme.Background))
+	ColorBgAlt = lipgloss.Color(t.Color(theme.Surface))
+	ColorBadgeText = lipgloss.Color(t.Color(theme.BadgeText))
+}
+
+// SourceColor returns the badge color of a package source
+func SourceColor(source string) lipgloss.Color {
+	return lipgloss.Color(activeTheme.Badge(source))
 }
 
 // Styles contains all the lipgloss styles used in the TUI
@@ -252,19 +271,15 @@ func DefaultStyles() *Styles {
 
 // SourceStyle returns a style for the given package source
 func SourceStyle(source string) lipgloss.Style {
-	color, ok := SourceColors[source]
-	if !ok {
-		color = ColorMuted
-	}
 	return lipgloss.NewStyle().
-		Foreground(color).
+		Foreground(SourceColor(source)).
 		Bold(true)
 }
 
 // Badge creates a badge-style label
 func Badge(text string, color lipgloss.Color) string {
 	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
+		Foreground(ColorBadgeText).
 		Background(color).
 		Padding(0, 1).
 		Render(text)
@@ -272,9 +287,5 @@ func Badge(text string, color lipgloss.Color) string {
 
 // SourceBadge creates a badge for a package source
 func SourceBadge(source string) string {
-	color, ok := SourceColors[source]
-	if !ok {
-		color = ColorMuted
-	}
-	return Badge(source, color)
+	return Badge(source, SourceColor(source))
 }
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"poxy/internal/aurwatch"
+	"poxy/internal/history"
+	"poxy/pkg/manager"
+)
+
+// upgradesLoadedMsg carries the upgrade candidates of all managers
+type upgradesLoadedMsg struct {
+	upgrades []manager.UpgradeCandidate
+	failed   []string // Managers whose check failed
+	alerts   []aurwatch.Alert
+}
+
+// upgradeKey identifies an upgrade candidate for selection
+func upgradeKey(c manager.UpgradeCandidate) string {
+	return c.Source + "/" + c.Name
+}
+
+// loadUpgrades checks every manager that supports it for upgrades in parallel
+func (a *App) loadUpgrades() tea.Cmd {
+	a.upgradesLoading = true
+	managers := a.registry.Available()
+
+	return func() tea.Msg {
+		var (
+			mu     sync.Mutex
+			wg     sync.WaitGroup
+			msg    upgradesLoadedMsg
+			ctx    = context.Background()
+			failed []string
+		)
+
+		for _, mgr := range managers {
+			checker, ok := mgr.(manager.UpgradeChecker)
+			if !ok {
+				continue
+			}
+
+			wg.Add(1)
+			go func(mgr manager.Manager, checker manager.UpgradeChecker) {
+				defer wg.Done()
+				found, err := checker.ListUpgradable(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failed = append(failed, mgr.DisplayName())
+					return
+				}
+				msg.upgrades = append(msg.upgrades, found...)
+			}(mgr, checker)
+		}
+		wg.Wait()
+
+		sort.Slice(msg.upgrades, func(i, j int) bool {
+			if msg.upgrades[i].Source != msg.upgrades[j].Source {
+				return msg.upgrades[i].Source < msg.upgrades[j].Source
+			}
+			return msg.upgrades[i].Name < msg.upgrades[j].Name
+		})
+		sort.Strings(failed)
+		msg.failed = failed
+
+		// Recorded by poxy audit and the daemon; checking needs the network
+		if watch, err := aurwatch.Load(); err == nil {
+			msg.alerts = watch.Alerts(time.Now(), aurwatch.AdoptionWindow)
+		}
+		return msg
+	}
+}
+
+// queueSelectedUpgrades queues upgrades of the selected packages, or of the
+// package under the cursor if nothing is selected
+func (a *App) queueSelectedUpgrades() {
+	var targets []manager.UpgradeCandidate
+	for _, c := range a.upgrades {
+		if a.selectedUpgrades[upgradeKey(c)] {
+			targets = append(targets, c)
+		}
+	}
+	if len(targets) == 0 {
+		if c := a.SelectedUpgrade(); c != nil {
+			targets = append(targets, *c)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	// One queue item per source, in source order
+	bySource := make(map[string][]string)
+	var sources []string
+	for _, c := range targets {
+		if _, ok := bySource[c.Source]; !ok {
+			sources = append(sources, c.Source)
+		}
+		bySource[c.Source] = append(bySource[c.Source], c.Name)
+	}
+
+	title := fmt.Sprintf("Queue upgrade of %s?", targets[0].Name)
+	if len(targets) > 1 {
+		title = fmt.Sprintf("Queue upgrade of %d packages?", len(targets))
+	}
+
+	a.ShowConfirm(title, func() tea.Cmd {
+		var cmds []tea.Cmd
+		for _, src := range sources {
+			cmds = append(cmds, a.enqueue(history.OpUpgrade, src, bySource[src]))
+		}
+		a.selectedUpgrades = make(map[string]bool)
+		return tea.Batch(cmds...)
+	})
+}
+
+// queueAllUpgrades queues a full upgrade of every source with pending upgrades
+func (a *App) queueAllUpgrades() {
+	var sources []string
+	seen := make(map[string]bool)
+	count := 0
+	for _, c := range a.upgrades {
+		if c.Held {
+			continue
+		}
+		count++
+		if !seen[c.Source] {
+			seen[c.Source] = true
+			sources = append(sources, c.Source)
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+
+	title := fmt.Sprintf("Queue upgrade of all %d packages from %s?", count, strings.Join(sources, ", "))
+	a.ShowConfirm(title, func() tea.Cmd {
+		var cmds []tea.Cmd
+		for _, src := range sources {
+			cmds = append(cmds, a.enqueue(history.OpUpgrade, src, nil))
+		}
+		return tea.Batch(cmds...)
+	})
+}
+
+// upgradeStatus returns the queue status of the latest upgrade covering c
+func (a *App) upgradeStatus(c manager.UpgradeCandidate) string {
+	items := a.queue.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if item.Op != history.OpUpgrade || item.Source != c.Source {
+			continue
+		}
+		if len(item.Packages) > 0 && !containsString(item.Packages, c.Name) {
+			continue
+		}
+
+		switch item.Status {
+		case QueuePending:
+			return a.styles.Info.Render("queued")
+		case QueueRunning:
+			return a.activityIndicator() + " " + a.styles.Warning.Render("upgrading")
+		case QueueDone:
+			return a.styles.Success.Render("upgraded")
+		case QueueFailed:
+			return a.styles.Error.Render("failed")
+		}
+		return ""
+	}
+
+	if alert, ok := a.aurAlerts[c.Name]; ok && c.Source == "aur" {
+		return a.styles.Warning.Render(alert.Kind)
+	}
+	if c.Held {
+		reason := "held"
+		if c.HeldReason != "" {
+			reason += ": " + c.HeldReason
+		}
+		return a.styles.Description.Render(reason)
+	}
+	return ""
+}
+
+// renderUpdatesView renders the updates view
+func (a *App) renderUpdatesView() string {
+	var b strings.Builder
+
+	upgrades := a.UpgradeItems()
+
+	titleStr := fmt.Sprintf("Available Updates (%d)", len(upgrades))
+	if a.FilterText() != "" {
+		titleStr += fmt.Sprintf(" - Filter: %s", a.FilterText())
+	}
+	b.WriteString(a.styles.Title.Render(titleStr))
+	b.WriteString("\n\n")
+
+	if a.upgradesLoading && len(a.upgrades) == 0 {
+		b.WriteString(a.activityIndicator() + " " + a.styles.Description.Render("Checking for updates..."))
+		return b.String()
+	}
+	if len(a.upgradesFailed) > 0 {
+		b.WriteString(a.styles.Warning.Render("Could not check: " + strings.Join(a.upgradesFailed, ", ")))
+		b.WriteString("\n\n")
+	}
+	if len(a.aurAlerts) > 0 {
+		names := make([]string, 0, len(a.aurAlerts))
+		for name := range a.aurAlerts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString(a.styles.Warning.Render("AUR packages that changed hands: " + strings.Join(names, ", ")))
+		b.WriteString("\n")
+		b.WriteString(a.styles.Description.Render("Read their PKGBUILDs before upgrading; see poxy audit"))
+		b.WriteString("\n\n")
+	}
+	if len(upgrades) == 0 {
+		b.WriteString(a.styles.Description.Render("All packages are up to date"))
+		return b.String()
+	}
+
+	start := a.Scroll()
+	end := start + a.VisibleHeight()
+	if end > len(upgrades) {
+		end = len(upgrades)
+	}
+
+	for i := start; i < end; i++ {
+		c := upgrades[i]
+
+		cursor := "  "
+		if i == a.Cursor() {
+			cursor = a.styles.ListItemSelected.Render("> ")
+		}
+		mark := "[ ]"
+		if a.selectedUpgrades[upgradeKey(c)] {
+			mark = a.styles.Success.Render("[x]")
+		}
+
+		name := lipgloss.NewStyle().Foreground(ColorText).Render(fmt.Sprintf("%-30s", c.Name))
+		versions := fmt.Sprintf("%-20s -> %-20s", c.CurrentVersion, a.styles.PackageVersion.Render(c.NewVersion))
+
+		b.WriteString(fmt.Sprintf("%s%s %s %s %s %s\n", cursor, mark, name, versions, SourceBadge(c.Source), a.upgradeStatus(c)))
+	}
+
+	return b.String()
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/fatih/color"
+
+	"poxy/pkg/theme"
 )
 
 var (
@@ -23,6 +25,13 @@ var (
 	PackageSource  = color.New(color.FgCyan)
 	Installed      = color.New(color.FgGreen)
 	NotInstalled   = color.New(color.FgHiBlack)
+
+	// Colors behind Green, Red, Yellow, Cyan and Magenta
+	green   = color.New(color.FgGreen)
+	red     = color.New(color.FgRed)
+	yellow  = color.New(color.FgYellow)
+	cyan    = color.New(color.FgCyan)
+	magenta = color.New(color.FgMagenta)
 )
 
 // UseColors represents whether colors should be used.
@@ -31,6 +40,9 @@ var UseColors = true
 // UseUnicode represents whether unicode symbols should be used.
 var UseUnicode = true
 
+// ReducedMotion represents whether animations should be replaced with static text.
+var ReducedMotion = false
+
 // Symbols for status indicators
 var (
 	SymbolSuccess = "✓"
@@ -42,9 +54,10 @@ var (
 )
 
 // Init initializes the UI settings based on configuration.
-func Init(useColors, useUnicode bool) {
+func Init(useColors, useUnicode, reducedMotion bool) {
 	UseColors = useColors
 	UseUnicode = useUnicode
+	ReducedMotion = reducedMotion
 
 	if !useColors || os.Getenv("NO_COLOR") != "" {
 		color.NoColor = true
@@ -60,6 +73,42 @@ func Init(useColors, useUnicode bool) {
 	}
 }
 
+// ApplyTheme replaces the terminal's ANSI colors with the colors of a theme.
+// Without a theme, output keeps the terminal's own palette.
+func ApplyTheme(t *theme.Theme) {
+	Success = themeColor(t, theme.Success, color.Bold)
+	Error = themeColor(t, theme.Error, color.Bold)
+	Warning = themeColor(t, theme.Warning, color.Bold)
+	Info = themeColor(t, theme.Info)
+	Header = themeColor(t, theme.Accent, color.Bold)
+	Muted = themeColor(t, theme.Muted)
+
+	PackageName = themeColor(t, theme.Text, color.Bold)
+	PackageVersion = themeColor(t, theme.Success)
+	PackageSource = themeColor(t, theme.Info)
+	Installed = themeColor(t, theme.Success)
+	NotInstalled = themeColor(t, theme.Muted)
+
+	green = themeColor(t, theme.Success)
+	red = themeColor(t, theme.Error)
+	yellow = themeColor(t, theme.Warning)
+	cyan = themeColor(t, theme.Info)
+	magenta = themeColor(t, theme.Accent)
+}
+
+// themeColor returns a color printing in the color of a theme token.
+func themeColor(t *theme.Theme, token string, attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	value := t.Color(token)
+	if r, g, b, ok := theme.RGB(value); ok {
+		return c.AddRGB(int(r), int(g), int(b))
+	}
+	if n, ok := theme.ANSI(value); ok {
+		return c.Add(38, 5, color.Attribute(n))
+	}
+	return c
+}
+
 // SuccessMsg prints a success message.
 func SuccessMsg(format string, args ...interface{}) {
 	Success.Printf(SymbolSuccess+" "+format+"\n", args...)
@@ -90,6 +139,16 @@ func MutedMsg(format string, args ...interface{}) {
 	Muted.Printf(format+"\n", args...)
 }
 
+// StdoutToStderr sends everything printed from now on, messages and the
+// output of backends alike, to stderr. It returns the original stdout, for
+// commands that reserve it for machine-readable output.
+func StdoutToStderr() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	return stdout
+}
+
 // Println prints a plain line with formatting.
 func Println(format string, args ...interface{}) {
 	fmt.Printf(format+"\n", args...)
@@ -102,25 +161,25 @@ func Bold(s string) string {
 
 // Green returns a green string.
 func Green(s string) string {
-	return color.GreenString(s)
+	return green.Sprint(s)
 }
 
 // Red returns a red string.
 func Red(s string) string {
-	return color.RedString(s)
+	return red.Sprint(s)
 }
 
 // Yellow returns a yellow string.
 func Yellow(s string) string {
-	return color.YellowString(s)
+	return yellow.Sprint(s)
 }
 
 // Cyan returns a cyan string.
 func Cyan(s string) string {
-	return color.CyanString(s)
+	return cyan.Sprint(s)
 }
 
 // Magenta returns a magenta string.
 func Magenta(s string) string {
-	return color.MagentaString(s)
+	return magenta.Sprint(s)
 }
@@ -1,16 +1,36 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"poxy/pkg/manager"
 
 	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
 )
 
+// NonInteractive represents whether prompts fail instead of asking, for
+// scripts that cannot answer them.
+var NonInteractive = false
+
+// ErrNoPrompt is returned by prompts in non-interactive mode.
+var ErrNoPrompt = errors.New("cannot ask for confirmation in non-interactive mode; pass --yes to proceed")
+
+// Interactive returns true if stdin is a terminal, so prompts can be
+// answered.
+func Interactive() bool {
+	return !NonInteractive && isatty.IsTerminal(os.Stdin.Fd())
+}
+
 // Confirm prompts the user for yes/no confirmation.
 func Confirm(prompt string, defaultYes bool) (bool, error) {
+	if NonInteractive {
+		return false, ErrNoPrompt
+	}
+
 	label := prompt
 	if defaultYes {
 		label += " [Y/n]"
@@ -53,6 +73,9 @@ func SelectPackage(packages []manager.Package, prompt string) (*manager.Package,
 	if len(packages) == 1 {
 		return &packages[0], nil
 	}
+	if NonInteractive {
+		return nil, ErrNoPrompt
+	}
 
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}",
@@ -99,6 +122,9 @@ func SelectSource(sources []string, prompt string) (string, error) {
 	if len(sources) == 1 {
 		return sources[0], nil
 	}
+	if NonInteractive {
+		return "", ErrNoPrompt
+	}
 
 	p := promptui.Select{
 		Label: prompt,
@@ -116,6 +142,10 @@ func SelectSource(sources []string, prompt string) (string, error) {
 
 // Input prompts the user for text input.
 func Input(prompt string, defaultValue string) (string, error) {
+	if NonInteractive {
+		return defaultValue, ErrNoPrompt
+	}
+
 	p := promptui.Prompt{
 		Label:   prompt,
 		Default: defaultValue,
@@ -136,6 +166,9 @@ func SelectMultiple(items []string, prompt string) ([]string, error) {
 	if len(items) == 0 {
 		return nil, fmt.Errorf("no items to select from")
 	}
+	if NonInteractive {
+		return nil, ErrNoPrompt
+	}
 
 	fmt.Println(prompt)
 	fmt.Println("Enter numbers separated by spaces (e.g., '1 3 5'), or 'all' for all items:")
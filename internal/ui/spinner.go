@@ -7,12 +7,18 @@ import (
 )
 
 // Spinner wraps the spinner library for consistent styling.
+// In reduced-motion mode it prints static status lines instead.
 type Spinner struct {
-	s *spinner.Spinner
+	s       *spinner.Spinner
+	message string
 }
 
 // NewSpinner creates a new spinner with the given message.
 func NewSpinner(message string) *Spinner {
+	if ReducedMotion {
+		return &Spinner{message: message}
+	}
+
 	charSet := spinner.CharSets[14] // ⣾⣽⣻⢿⡿⣟⣯⣷
 	if !UseUnicode {
 		charSet = spinner.CharSets[0] // |/-\
@@ -25,33 +31,49 @@ func NewSpinner(message string) *Spinner {
 		_ = s.Color("cyan") //nolint:errcheck
 	}
 
-	return &Spinner{s: s}
+	return &Spinner{s: s, message: message}
 }
 
 // Start starts the spinner.
 func (sp *Spinner) Start() {
+	if sp.s == nil {
+		Info.Printf(SymbolPending+" %s\n", sp.message)
+		return
+	}
 	sp.s.Start()
 }
 
 // Stop stops the spinner.
 func (sp *Spinner) Stop() {
-	sp.s.Stop()
+	if sp.s != nil {
+		sp.s.Stop()
+	}
 }
 
 // Success stops the spinner with a success message.
 func (sp *Spinner) Success(message string) {
-	sp.s.Stop()
+	sp.Stop()
 	SuccessMsg(message)
 }
 
 // Error stops the spinner with an error message.
 func (sp *Spinner) Error(message string) {
-	sp.s.Stop()
+	sp.Stop()
 	ErrorMsg(message)
 }
 
 // UpdateMessage updates the spinner message.
+// In reduced-motion mode a changed message is printed on a new line.
 func (sp *Spinner) UpdateMessage(message string) {
+	if message == sp.message {
+		return
+	}
+	sp.message = message
+
+	if sp.s == nil {
+		Info.Printf(SymbolPending+" %s\n", message)
+		return
+	}
 	sp.s.Suffix = " " + message
 }
 
@@ -99,6 +99,34 @@ func PrintPackages(packages []manager.Package) {
 	w.Flush()
 }
 
+// PrintUpgrades prints available upgrades in a formatted table.
+// Held-back upgrades are shown with the reason they are not applied.
+func PrintUpgrades(upgrades []manager.UpgradeCandidate) {
+	if len(upgrades) == 0 {
+		MutedMsg("All packages are up to date")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, Bold("SOURCE")+"\t"+Bold("NAME")+"\t"+Bold("CURRENT")+"\t"+Bold("NEW")+"\t"+Bold("NOTE"))
+
+	for _, u := range upgrades {
+		source := PackageSource.Sprint("[" + u.Source + "]")
+		name := PackageName.Sprint(u.Name)
+		newVersion := PackageVersion.Sprint(u.NewVersion)
+
+		note := ""
+		if u.Held {
+			note = Yellow(u.HeldReason)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", source, name, u.CurrentVersion, newVersion, note)
+	}
+
+	w.Flush()
+}
+
 // PrintPackageInfo prints detailed package information.
 func PrintPackageInfo(info *manager.PackageInfo) {
 	if info == nil {
@@ -150,28 +178,35 @@ func printField(label, value string) {
 	fmt.Printf("  %s: %s\n", Cyan(label), value)
 }
 
-// PrintSearchResults prints search results grouped by source.
-func PrintSearchResults(packages []manager.Package) {
+// PrintSearchResults prints numbered search results grouped by source, in
+// the order the sources first appear. It returns the packages in the order
+// printed, so a result's number indexes it.
+func PrintSearchResults(packages []manager.Package) []manager.Package {
 	if len(packages) == 0 {
 		MutedMsg("No packages found")
-		return
+		return nil
 	}
 
 	// Group by source
+	var sources []string
 	grouped := make(map[string][]manager.Package)
 	for _, pkg := range packages {
+		if _, ok := grouped[pkg.Source]; !ok {
+			sources = append(sources, pkg.Source)
+		}
 		grouped[pkg.Source] = append(grouped[pkg.Source], pkg)
 	}
 
-	totalCount := len(packages)
-	sourceCount := len(grouped)
-
-	HeaderMsg("Found %d results across %d sources", totalCount, sourceCount)
+	HeaderMsg("Found %d results across %d sources", len(packages), len(sources))
 
-	for source, pkgs := range grouped {
+	ordered := make([]manager.Package, 0, len(packages))
+	for _, source := range sources {
+		pkgs := grouped[source]
 		fmt.Printf("\n%s (%d):\n", PackageSource.Sprint("["+source+"]"), len(pkgs))
 
 		for _, pkg := range pkgs {
+			ordered = append(ordered, pkg)
+
 			name := PackageName.Sprint(pkg.Name)
 			version := ""
 			if pkg.Version != "" {
@@ -183,21 +218,24 @@ func PrintSearchResults(packages []manager.Package) {
 				installedMark = " " + Installed.Sprint("[installed]")
 			}
 
-			fmt.Printf("  %s%s%s\n", name, version, installedMark)
+			fmt.Printf("  %2d. %s%s%s\n", len(ordered), name, version, installedMark)
 
 			if pkg.Description != "" {
 				desc := pkg.Description
 				if len(desc) > 70 {
 					desc = desc[:67] + "..."
 				}
-				MutedMsg("    %s", desc)
+				MutedMsg("      %s", desc)
 			}
 		}
 	}
+
+	return ordered
 }
 
-// PrintSystemInfo prints system information.
-func PrintSystemInfo(osName, arch, distro, prettyName, nativeManager string, availableManagers []string) {
+// PrintSystemInfo prints system information. nativeReason says why
+// nativeManager was chosen.
+func PrintSystemInfo(osName, arch, distro, prettyName, nativeManager, nativeReason string, availableManagers []string, managerVersions map[string]string) {
 	HeaderMsg("System Information")
 
 	printField("Operating System", prettyName)
@@ -209,9 +247,21 @@ func PrintSystemInfo(osName, arch, distro, prettyName, nativeManager string, ava
 
 	if nativeManager != "" {
 		printField("Native Package Manager", nativeManager)
+		if nativeReason != "" {
+			printField("Chosen Because", nativeReason)
+		}
 	}
 
 	if len(availableManagers) > 0 {
 		printField("Available Managers", strings.Join(availableManagers, ", "))
 	}
+
+	if len(managerVersions) > 0 {
+		HeaderMsg("Manager Versions")
+		for _, name := range availableManagers {
+			if version, ok := managerVersions[name]; ok {
+				printField(name, version)
+			}
+		}
+	}
 }
@@ -0,0 +1,225 @@
+// Package appstream reads AppStream catalogs, the metadata distributions
+// and Flathub publish for applications: human-friendly names, categories,
+// licenses and screenshots. Catalogs are read from the XML files of Arch,
+// Fedora and Flatpak remotes and from the DEP-11 YAML files of Debian and
+// Ubuntu.
+package appstream
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// Component is an application described by a catalog.
+type Component struct {
+	ID          string   // AppStream ID, e.g. "org.mozilla.firefox"
+	Package     string   // Distribution package it ships in, if any
+	Flatpak     string   // Flatpak app ID it ships as, if any
+	Name        string   // e.g. "Firefox"
+	Summary     string   // One line description
+	License     string   // SPDX expression of the project license
+	Homepage    string   // Project homepage URL
+	Categories  []string // freedesktop.org categories, e.g. "WebBrowser"
+	Screenshots []string // Full size screenshot URLs, the default first
+}
+
+// applicationTypes are the component types read from catalogs. Addons,
+// fonts, codecs and the like describe parts of other packages.
+var applicationTypes = map[string]bool{
+	"desktop-application": true,
+	"desktop":             true, // The type before AppStream 0.10
+	"console-application": true,
+	"web-application":     true,
+}
+
+// SystemPaths are the glob patterns of the catalogs distributions and
+// Flatpak install.
+var SystemPaths = []string{
+	"/usr/share/swcatalog/xml/*.xml*",
+	"/usr/share/app-info/xmls/*.xml*",
+	"/var/cache/swcatalog/xml/*.xml*",
+	"/var/cache/app-info/xmls/*.xml*",
+	"/var/lib/swcatalog/yaml/*.yml*",
+	"/var/lib/app-info/yaml/*.yml*",
+	"/var/lib/flatpak/appstream/*/*/active/appstream.xml*",
+}
+
+// userPaths are the glob patterns of catalogs under the home directory.
+var userPaths = []string{
+	".local/share/flatpak/appstream/*/*/active/appstream.xml*",
+}
+
+// Catalog indexes components by the package and Flatpak app they ship in.
+type Catalog struct {
+	byPackage map[string]*Component
+	byApp     map[string]*Component // Lowercased AppStream or Flatpak app ID
+}
+
+// NewCatalog creates a catalog of components.
+func NewCatalog(components []Component) *Catalog {
+	c := &Catalog{
+		byPackage: make(map[string]*Component),
+		byApp:     make(map[string]*Component),
+	}
+	c.Add(components)
+	return c
+}
+
+// Add adds components to the catalog. A later component for the same
+// package or app replaces an earlier one.
+func (c *Catalog) Add(components []Component) {
+	for i := range components {
+		comp := &components[i]
+		if comp.Package != "" {
+			c.byPackage[comp.Package] = comp
+		}
+		if comp.ID != "" {
+			c.byApp[strings.ToLower(comp.ID)] = comp
+		}
+		if comp.Flatpak != "" {
+			c.byApp[strings.ToLower(comp.Flatpak)] = comp
+		}
+	}
+}
+
+// Len returns the number of packages and apps with a component.
+func (c *Catalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.byPackage) + len(c.byApp)
+}
+
+// Lookup returns the component of a package of source, or nil if no
+// catalog describes it. Flatpak packages are looked up by app ID, others
+// by package name.
+func (c *Catalog) Lookup(source, name string) *Component {
+	if c == nil {
+		return nil
+	}
+	if source == "flatpak" {
+		return c.byApp[strings.ToLower(name)]
+	}
+	return c.byPackage[name]
+}
+
+// Enrich records the application name and categories of pkg in its
+// metadata, if a catalog describes it, and reports whether it did.
+func (c *Catalog) Enrich(pkg *manager.Package) bool {
+	comp := c.Lookup(pkg.Source, pkg.Name)
+	if comp == nil || comp.Name == "" {
+		return false
+	}
+
+	metadata := make(map[string]string, len(pkg.Metadata)+2)
+	for k, v := range pkg.Metadata {
+		metadata[k] = v
+	}
+	metadata[manager.MetaAppName] = comp.Name
+	if len(comp.Categories) > 0 {
+		metadata[manager.MetaCategories] = strings.Join(comp.Categories, ",")
+	}
+	pkg.Metadata = metadata
+	return true
+}
+
+// LoadSystem reads every catalog at SystemPaths and under the home
+// directory. Catalogs that cannot be read are skipped and reported in the
+// error, with the others still loaded.
+func LoadSystem() (*Catalog, error) {
+	patterns := SystemPaths
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, pattern := range userPaths {
+			patterns = append(patterns, filepath.Join(home, pattern))
+		}
+	}
+	return Load(patterns...)
+}
+
+// Load reads the catalogs matching the glob patterns. A catalog reached by
+// several paths, such as through a symlink, is read once.
+func Load(patterns ...string) (*Catalog, error) {
+	catalog := NewCatalog(nil)
+	seen := make(map[string]bool)
+	var errs []error
+
+	for _, pattern := range patterns {
+		paths, _ := filepath.Glob(pattern) //nolint:errcheck // Only fails on malformed patterns
+		for _, path := range paths {
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil || seen[real] {
+				continue
+			}
+			seen[real] = true
+
+			components, err := ReadFile(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			catalog.Add(components)
+		}
+	}
+	return catalog, errors.Join(errs...)
+}
+
+// ReadFile reads the components of a catalog file: XML, or DEP-11 YAML if
+// its name ends in .yml or .yaml, gzip-compressed if it ends in .gz.
+func ReadFile(path string) ([]Component, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	name := path
+	if base, ok := strings.CutSuffix(name, ".gz"); ok {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r, name = gz, base
+	}
+
+	var components []Component
+	switch filepath.Ext(name) {
+	case ".yml", ".yaml":
+		components, err = ParseYAML(r)
+	default:
+		components, err = ParseXML(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return components, nil
+}
+
+// mediaURL resolves a media path of a catalog against its base URL.
+func mediaURL(base, path string) string {
+	if path == "" || base == "" || strings.Contains(path, "://") {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// flatpakApp returns the app ID of a Flatpak ref, e.g. org.mozilla.firefox
+// for "app/org.mozilla.firefox/x86_64/stable".
+func flatpakApp(ref string) string {
+	parts := strings.Split(strings.TrimSpace(ref), "/")
+	if len(parts) >= 2 && parts[0] == "app" {
+		return parts[1]
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package appstream
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+const sampleXML = `<?xml version="1.0" encoding="UTF-8"?>
+<components version="0.14" origin="flathub" media_baseurl="https://dl.flathub.org/media">
+  <component type="desktop-application">
+    <id>org.mozilla.firefox</id>
+    <name xml:lang="de">Feuerfuchs</name>
+    <name>Firefox</name>
+    <summary>Fast, Private &amp; Safe Web Browser</summary>
+    <project_license>MPL-2.0</project_license>
+    <pkgname>firefox</pkgname>
+    <bundle type="flatpak">app/org.mozilla.firefox/x86_64/stable</bundle>
+    <url type="homepage">https://www.mozilla.org</url>
+    <categories>
+      <category>Network</category>
+      <category>WebBrowser</category>
+    </categories>
+    <screenshots>
+      <screenshot>
+        <image type="thumbnail">org/mozilla/2-small.png</image>
+        <image type="source">org/mozilla/2.png</image>
+      </screenshot>
+      <screenshot type="default">
+        <image type="source">https://example.org/1.png</image>
+      </screenshot>
+    </screenshots>
+  </component>
+  <component type="addon">
+    <id>org.mozilla.firefox.addon</id>
+    <name>Addon</name>
+    <pkgname>firefox-addon</pkgname>
+  </component>
+</components>`
+
+const sampleYAML = `---
+File: DEP-11
+Version: '0.16'
+Origin: debian-bookworm-main
+MediaBaseUrl: https://appstream.debian.org/media/bookworm
+---
+Type: desktop-application
+ID: org.gnome.gedit.desktop
+Package: gedit
+Name:
+  de: Texteditor
+  C: Text Editor
+Summary:
+  C: Edit text files
+ProjectLicense: GPL-2.0+
+Url:
+  homepage: https://wiki.gnome.org/Apps/Gedit
+Categories:
+- Utility
+- TextEditor
+Screenshots:
+- source-image:
+    url: gedit/second.png
+- default: true
+  source-image:
+    url: gedit/first.png
+---
+Type: font
+ID: fonts-dejavu
+Package: fonts-dejavu-core
+Name:
+  C: DejaVu
+`
+
+func TestParseXML(t *testing.T) {
+	components, err := ParseXML(strings.NewReader(sampleXML))
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+	want := []Component{{
+		ID:         "org.mozilla.firefox",
+		Package:    "firefox",
+		Flatpak:    "org.mozilla.firefox",
+		Name:       "Firefox",
+		Summary:    "Fast, Private & Safe Web Browser",
+		License:    "MPL-2.0",
+		Homepage:   "https://www.mozilla.org",
+		Categories: []string{"Network", "WebBrowser"},
+		Screenshots: []string{
+			"https://example.org/1.png",
+			"https://dl.flathub.org/media/org/mozilla/2.png",
+		},
+	}}
+	if !reflect.DeepEqual(components, want) {
+		t.Errorf("ParseXML() = %+v, want %+v", components, want)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	components, err := ParseYAML(strings.NewReader(sampleYAML))
+	if err != nil {
+		t.Fatalf("ParseYAML() error = %v", err)
+	}
+	want := []Component{{
+		ID:         "org.gnome.gedit",
+		Package:    "gedit",
+		Name:       "Text Editor",
+		Summary:    "Edit text files",
+		License:    "GPL-2.0+",
+		Homepage:   "https://wiki.gnome.org/Apps/Gedit",
+		Categories: []string{"Utility", "TextEditor"},
+		Screenshots: []string{
+			"https://appstream.debian.org/media/bookworm/gedit/first.png",
+			"https://appstream.debian.org/media/bookworm/gedit/second.png",
+		},
+	}}
+	if !reflect.DeepEqual(components, want) {
+		t.Errorf("ParseYAML() = %+v, want %+v", components, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(dir, "extra.xml.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(sampleXML))
+	gz.Close()
+	f.Close()
+	if err := os.WriteFile(filepath.Join(dir, "main.yml"), []byte(sampleYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The same catalog through a symlink is read once
+	if err := os.Symlink(filepath.Join(dir, "main.yml"), filepath.Join(dir, "link.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := Load(filepath.Join(dir, "*.xml*"), filepath.Join(dir, "*.yml*"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, tc := range []struct{ source, name, want string }{
+		{"pacman", "firefox", "Firefox"},
+		{"flatpak", "org.mozilla.firefox", "Firefox"},
+		{"flatpak", "org.mozilla.Firefox", "Firefox"},
+		{"apt", "gedit", "Text Editor"},
+	} {
+		comp := catalog.Lookup(tc.source, tc.name)
+		if comp == nil || comp.Name != tc.want {
+			t.Errorf("Lookup(%s, %s) = %+v, want %s", tc.source, tc.name, comp, tc.want)
+		}
+	}
+	if comp := catalog.Lookup("apt", "fonts-dejavu-core"); comp != nil {
+		t.Errorf("Lookup() of a font = %+v, want nil", comp)
+	}
+
+	pkg := manager.Package{Name: "gedit", Source: "apt", Metadata: map[string]string{manager.MetaRepository: "main"}}
+	if !catalog.Enrich(&pkg) {
+		t.Fatal("Enrich() = false")
+	}
+	if pkg.Metadata[manager.MetaAppName] != "Text Editor" || pkg.Metadata[manager.MetaCategories] != "Utility,TextEditor" || pkg.Metadata[manager.MetaRepository] != "main" {
+		t.Errorf("Enrich() metadata = %v", pkg.Metadata)
+	}
+
+	var empty *Catalog
+	if empty.Lookup("apt", "gedit") != nil {
+		t.Error("Lookup() on a nil catalog should return nil")
+	}
+}
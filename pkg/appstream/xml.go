@@ -0,0 +1,132 @@
+package appstream
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+type xmlText struct {
+	Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlTyped struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlScreenshot struct {
+	Type   string     `xml:"type,attr"`
+	Images []xmlTyped `xml:"image"`
+}
+
+type xmlComponent struct {
+	Type        string          `xml:"type,attr"`
+	ID          string          `xml:"id"`
+	PkgName     []string        `xml:"pkgname"`
+	Names       []xmlText       `xml:"name"`
+	Summaries   []xmlText       `xml:"summary"`
+	License     string          `xml:"project_license"`
+	Bundles     []xmlTyped      `xml:"bundle"`
+	URLs        []xmlTyped      `xml:"url"`
+	Categories  []string        `xml:"categories>category"`
+	Screenshots []xmlScreenshot `xml:"screenshots>screenshot"`
+}
+
+// ParseXML reads the application components of an AppStream XML catalog.
+// Components are decoded one at a time, so large catalogs such as
+// Flathub's are not held in memory as a whole.
+func ParseXML(r io.Reader) ([]Component, error) {
+	dec := xml.NewDecoder(r)
+	var components []Component
+	mediaBase := ""
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return components, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "components":
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "media_baseurl" {
+					mediaBase = attr.Value
+				}
+			}
+		case "component":
+			var xc xmlComponent
+			if err := dec.DecodeElement(&xc, &start); err != nil {
+				return nil, err
+			}
+			if comp, ok := xc.component(mediaBase); ok {
+				components = append(components, comp)
+			}
+		}
+	}
+}
+
+// component converts a decoded component, if it is an application.
+func (xc xmlComponent) component(mediaBase string) (Component, bool) {
+	if !applicationTypes[xc.Type] {
+		return Component{}, false
+	}
+
+	comp := Component{
+		ID:         strings.TrimSuffix(strings.TrimSpace(xc.ID), ".desktop"),
+		Name:       untranslated(xc.Names),
+		Summary:    untranslated(xc.Summaries),
+		License:    strings.TrimSpace(xc.License),
+		Categories: xc.Categories,
+	}
+	if len(xc.PkgName) > 0 {
+		comp.Package = strings.TrimSpace(xc.PkgName[0])
+	}
+	for _, bundle := range xc.Bundles {
+		if bundle.Type == "flatpak" {
+			comp.Flatpak = flatpakApp(bundle.Value)
+		}
+	}
+	for _, url := range xc.URLs {
+		if url.Type == "homepage" {
+			comp.Homepage = strings.TrimSpace(url.Value)
+		}
+	}
+
+	for _, shot := range xc.Screenshots {
+		for _, image := range shot.Images {
+			if image.Type != "source" {
+				continue
+			}
+			url := mediaURL(mediaBase, strings.TrimSpace(image.Value))
+			if shot.Type == "default" {
+				comp.Screenshots = append([]string{url}, comp.Screenshots...)
+			} else {
+				comp.Screenshots = append(comp.Screenshots, url)
+			}
+		}
+	}
+	return comp, true
+}
+
+// untranslated returns the text without a language, or the first text.
+func untranslated(texts []xmlText) string {
+	for _, t := range texts {
+		if t.Lang == "" {
+			return strings.TrimSpace(t.Value)
+		}
+	}
+	if len(texts) > 0 {
+		return strings.TrimSpace(texts[0].Value)
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package appstream
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlImage struct {
+	URL string `yaml:"url"`
+}
+
+type yamlScreenshot struct {
+	Default     bool      `yaml:"default"`
+	SourceImage yamlImage `yaml:"source-image"`
+}
+
+type yamlBundle struct {
+	Type string `yaml:"type"`
+	ID   string `yaml:"id"`
+}
+
+// yamlDocument is a document of a DEP-11 catalog: the header, which has
+// File and MediaBaseUrl, or a component.
+type yamlDocument struct {
+	File         string            `yaml:"File"`
+	MediaBaseURL string            `yaml:"MediaBaseUrl"`
+	Type         string            `yaml:"Type"`
+	ID           string            `yaml:"ID"`
+	Package      string            `yaml:"Package"`
+	Name         map[string]string `yaml:"Name"`
+	Summary      map[string]string `yaml:"Summary"`
+	License      string            `yaml:"ProjectLicense"`
+	URL          map[string]string `yaml:"Url"`
+	Categories   []string          `yaml:"Categories"`
+	Bundles      []yamlBundle      `yaml:"Bundles"`
+	Screenshots  []yamlScreenshot  `yaml:"Screenshots"`
+}
+
+// ParseYAML reads the application components of a DEP-11 catalog, the
+// YAML form of AppStream Debian and Ubuntu ship.
+func ParseYAML(r io.Reader) ([]Component, error) {
+	dec := yaml.NewDecoder(r)
+	var components []Component
+	mediaBase := ""
+
+	for {
+		var doc yamlDocument
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return components, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if doc.File != "" {
+			mediaBase = doc.MediaBaseURL
+			continue
+		}
+		if !applicationTypes[doc.Type] {
+			continue
+		}
+
+		comp := Component{
+			ID:         strings.TrimSuffix(doc.ID, ".desktop"),
+			Package:    doc.Package,
+			Name:       doc.Name["C"],
+			Summary:    doc.Summary["C"],
+			License:    doc.License,
+			Homepage:   doc.URL["homepage"],
+			Categories: doc.Categories,
+		}
+		for _, bundle := range doc.Bundles {
+			if bundle.Type == "flatpak" {
+				comp.Flatpak = flatpakApp(bundle.ID)
+			}
+		}
+		for _, shot := range doc.Screenshots {
+			url := mediaURL(mediaBase, shot.SourceImage.URL)
+			if url == "" {
+				continue
+			}
+			if shot.Default {
+				comp.Screenshots = append([]string{url}, comp.Screenshots...)
+			} else {
+				comp.Screenshots = append(comp.Screenshots, url)
+			}
+		}
+		components = append(components, comp)
+	}
+}
@@ -0,0 +1,256 @@
+package aur
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity is how much risk a PKGBUILD finding carries.
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// String returns the severity name.
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// weight is how much a finding of the severity adds to the risk score.
+func (s Severity) weight() int {
+	switch s {
+	case SeverityLow:
+		return 5
+	case SeverityMedium:
+		return 15
+	case SeverityHigh:
+		return 30
+	case SeverityCritical:
+		return 50
+	}
+	return 0
+}
+
+// MaxRiskScore is the highest risk score a PKGBUILD can get.
+const MaxRiskScore = 100
+
+// Finding categories
+const (
+	CategoryCommand  = "command"  // Dangerous shell command
+	CategoryNetwork  = "network"  // Downloads while building
+	CategoryBinary   = "binary"   // Prebuilt binaries in the sources
+	CategoryChecksum = "checksum" // Sources that are not verified
+	CategorySource   = "source"   // Sources fetched insecurely
+	CategorySystemd  = "systemd"  // Services installed or started unusually
+)
+
+// Finding is one risk found in a PKGBUILD.
+type Finding struct {
+	Severity Severity
+	Category string
+	Line     int    // Line in the PKGBUILD, 0 for findings about arrays
+	Detail   string // The offending line or source
+	Reason   string
+}
+
+// Analysis is the result of statically analyzing a PKGBUILD.
+type Analysis struct {
+	Findings []Finding // Most severe first
+	Score    int       // 0 (no findings) to MaxRiskScore
+}
+
+// Level summarizes the score as a severity.
+func (a *Analysis) Level() Severity {
+	switch {
+	case a.Score >= 75:
+		return SeverityCritical
+	case a.Score >= 50:
+		return SeverityHigh
+	case a.Score >= 25:
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// Summary returns a one-line description of the analysis.
+func (a *Analysis) Summary() string {
+	if len(a.Findings) == 0 {
+		return "No obvious security issues detected"
+	}
+	return fmt.Sprintf("Risk score %d/%d (%s), %d finding(s)", a.Score, MaxRiskScore, a.Level(), len(a.Findings))
+}
+
+var (
+	buildFunctionRe = regexp.MustCompile(`^\s*(prepare|build|check|package(_[\w-]+)?)\s*\(\)`)
+	networkRe       = regexp.MustCompile(`\b(curl|wget|aria2c)\s|\bgit\s+(clone|fetch|pull)\b`)
+	fetchDepsRe     = regexp.MustCompile(`\b(npm|pnpm|yarn)\s+(install|i|ci|add)\b|\bpip3?\s+install\b|\bgo\s+get\b`)
+	systemdUnitRe   = regexp.MustCompile(`\.(service|socket|timer|path|mount)\b`)
+	installCmdRe    = regexp.MustCompile(`\b(install|cp|mv|ln)\s`)
+	systemctlRe     = regexp.MustCompile(`\bsystemctl\s+(enable|start|restart|daemon-reload)\b`)
+)
+
+// binaryExtensions are source file types that ship prebuilt code.
+var binaryExtensions = []string{
+	".deb", ".rpm", ".appimage", ".exe", ".msi", ".jar", ".bin", ".run", ".so", ".dll", ".snap",
+	".pkg.tar.zst", ".pkg.tar.xz",
+}
+
+// vcsPrefixes mark sources from version control, whose checksums are
+// always SKIP.
+var vcsPrefixes = []string{"git+", "svn+", "hg+", "bzr+", "fossil+"}
+
+// Analyze scores the risk of building a PKGBUILD: dangerous commands,
+// downloads at build time, prebuilt binaries and unverified sources, and
+// systemd units installed outside /usr/lib/systemd.
+func Analyze(p *PKGBUILD) *Analysis {
+	analysis := &Analysis{}
+
+	flagged := make(map[int]bool)
+	for _, cmd := range p.DangerousCommands {
+		analysis.add(Finding{Severity: cmd.Severity, Category: CategoryCommand, Line: cmd.Line, Detail: cmd.Command, Reason: cmd.Reason})
+		flagged[cmd.Line] = true
+	}
+
+	analysis.scanLines(p.RawContent, flagged)
+	analysis.scanSources(p)
+
+	sort.SliceStable(analysis.Findings, func(i, j int) bool {
+		return analysis.Findings[i].Severity > analysis.Findings[j].Severity
+	})
+	return analysis
+}
+
+// add records a finding and adds its weight to the score.
+func (a *Analysis) add(f Finding) {
+	a.Findings = append(a.Findings, f)
+	a.Score = min(a.Score+f.Severity.weight(), MaxRiskScore)
+}
+
+// scanLines looks for risky commands inside the build functions. Lines
+// already flagged as dangerous commands are skipped.
+func (a *Analysis) scanLines(content string, flagged map[int]bool) {
+	function := "" // The build function the line is in
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || flagged[i+1] {
+			continue
+		}
+		if m := buildFunctionRe.FindStringSubmatch(line); m != nil {
+			function = m[1]
+			continue
+		}
+		if strings.HasPrefix(line, "}") {
+			function = ""
+			continue
+		}
+		if function == "" {
+			continue
+		}
+		packaging := strings.HasPrefix(function, "package")
+
+		finding := Finding{Line: i + 1, Detail: trimmed}
+		switch {
+		case networkRe.MatchString(line):
+			finding.Severity, finding.Category, finding.Reason = SeverityHigh, CategoryNetwork, "Downloads while building, bypassing source checksums"
+		case fetchDepsRe.MatchString(line):
+			finding.Severity, finding.Category, finding.Reason = SeverityMedium, CategoryNetwork, "Fetches unpinned dependencies while building"
+		case systemctlRe.MatchString(line):
+			finding.Severity, finding.Category, finding.Reason = SeverityHigh, CategorySystemd, "Changes running services while building"
+		case packaging && systemdUnitRe.MatchString(line) && installCmdRe.MatchString(line) && !strings.Contains(line, "usr/lib/systemd/"):
+			finding.Severity, finding.Category, finding.Reason = SeverityHigh, CategorySystemd, "Installs a systemd unit outside /usr/lib/systemd"
+		default:
+			continue
+		}
+		a.add(finding)
+	}
+}
+
+// scanSources looks for prebuilt binaries, insecure downloads and skipped
+// checksums in the source arrays, including those of single architectures.
+func (a *Analysis) scanSources(p *PKGBUILD) {
+	for _, set := range p.SourceSets() {
+		a.scanSourceSet(set)
+	}
+}
+
+// scanSourceSet scans one source array against its checksum arrays.
+func (a *Analysis) scanSourceSet(set SourceSet) {
+	for i, src := range set.Source {
+		location := src
+		if idx := strings.Index(src, "::"); idx != -1 {
+			location = src[idx+2:]
+		}
+		remote := strings.Contains(location, "://") && !hasAnyPrefix(location, vcsPrefixes)
+
+		if remote {
+			if ext := binaryExtension(location); ext != "" {
+				a.add(Finding{Severity: SeverityMedium, Category: CategoryBinary, Detail: src, Reason: "Ships a prebuilt " + ext + " instead of building from source"})
+			}
+			if skippedChecksum(set, i) {
+				a.add(Finding{Severity: SeverityMedium, Category: CategoryChecksum, Detail: src, Reason: "Checksum is SKIP, so the download is not verified"})
+			}
+		}
+		if strings.HasPrefix(strings.TrimPrefix(location, "git+"), "http://") || strings.HasPrefix(location, "ftp://") {
+			a.add(Finding{Severity: SeverityLow, Category: CategorySource, Detail: src, Reason: "Downloaded without encryption"})
+		}
+	}
+}
+
+// binaryExtension returns the prebuilt binary extension of a source, if any.
+func binaryExtension(location string) string {
+	name := location
+	if u, err := url.Parse(location); err == nil && u.Path != "" {
+		name = u.Path
+	}
+	name = strings.ToLower(path.Base(name))
+	for _, ext := range binaryExtensions {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// skippedChecksum reports whether the checksum of the i-th source is SKIP
+// in every checksum array of set that has one.
+func skippedChecksum(set SourceSet, i int) bool {
+	found := false
+	for _, sums := range [][]string{set.SHA256Sums, set.SHA512Sums, set.B2Sums, set.MD5Sums} {
+		if i >= len(sums) {
+			continue
+		}
+		if sums[i] != "SKIP" {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// hasAnyPrefix reports whether s starts with one of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
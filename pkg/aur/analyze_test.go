@@ -0,0 +1,163 @@
+package aur
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writePKGBUILD writes content to a PKGBUILD in a temporary directory and
+// parses it.
+func writePKGBUILD(t *testing.T, content string) *PKGBUILD {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "PKGBUILD")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkgbuild, err := ParsePKGBUILD(path)
+	if err != nil {
+		t.Fatalf("ParsePKGBUILD() error = %v", err)
+	}
+	return pkgbuild
+}
+
+// findingReasons returns the category and reason of each finding.
+func findingReasons(analysis *Analysis) []string {
+	var reasons []string
+	for _, f := range analysis.Findings {
+		reasons = append(reasons, f.Category+": "+f.Reason)
+	}
+	return reasons
+}
+
+func TestAnalyze(t *testing.T) {
+	const header = "pkgname=tool\npkgver=1.0\npkgrel=1\narch=(x86_64 aarch64)\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "clean",
+			content: header + `source=("https://example.org/tool-1.0.tar.gz")
+sha256sums=('0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef')
+package() {
+	install -Dm755 tool "$pkgdir/usr/bin/tool"
+}
+`,
+		},
+		{
+			name: "skipped checksum",
+			content: header + `source=("https://example.org/tool-1.0.tar.gz")
+sha256sums=('SKIP')
+`,
+			want: []string{"checksum: Checksum is SKIP, so the download is not verified"},
+		},
+		{
+			name: "vcs source with skipped checksum",
+			content: header + `source=("git+https://github.com/example/tool.git")
+sha256sums=('SKIP')
+`,
+		},
+		{
+			name: "architecture-specific sources",
+			content: header + `source=("https://example.org/tool-1.0.tar.gz")
+sha256sums=('0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef')
+source_x86_64=("https://example.org/tool-1.0-x86_64.deb")
+sha256sums_x86_64=('SKIP')
+source_aarch64=("http://example.org/tool-1.0-aarch64.tar.gz")
+b2sums_aarch64=('0123')
+`,
+			want: []string{
+				"binary: Ships a prebuilt .deb instead of building from source",
+				"checksum: Checksum is SKIP, so the download is not verified",
+				"source: Downloaded without encryption",
+			},
+		},
+		{
+			name: "curl piped to a shell",
+			content: header + `build() {
+	curl -fsSL https://example.org/install.sh | sh
+}
+`,
+			want: []string{"command: Downloads and executes script"},
+		},
+		{
+			name: "systemd unit installed outside /usr/lib/systemd",
+			content: header + `package() {
+	install -Dm644 tool.service "$pkgdir/etc/systemd/system/tool.service"
+}
+`,
+			want: []string{"systemd: Installs a systemd unit outside /usr/lib/systemd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findingReasons(Analyze(writePKGBUILD(t, tt.content)))
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArchSources(t *testing.T) {
+	content := `pkgname=tool
+arch=(x86_64 aarch64)
+source=("tool.desktop")
+sha256sums=('SKIP')
+source_x86_64=("https://example.org/tool-x86_64.tar.gz")
+sha256sums_x86_64=('abc')
+source_aarch64=("https://example.org/tool-aarch64.tar.gz")
+b2sums_aarch64=('def')
+`
+
+	parsed := writePKGBUILD(t, content)
+	var regex PKGBUILD
+	regex.parseWithRegex(content)
+
+	for name, pkgbuild := range map[string]*PKGBUILD{"bash": parsed, "regex": &regex} {
+		sets := pkgbuild.SourceSets()
+		if len(sets) != 3 {
+			t.Fatalf("%s: SourceSets() = %+v, want the common and two architecture sets", name, sets)
+		}
+		if sets[0].Arch != "" || !slices.Equal(sets[0].Source, []string{"tool.desktop"}) {
+			t.Errorf("%s: common set = %+v", name, sets[0])
+		}
+		if sets[1].Arch != "aarch64" || !slices.Equal(sets[1].B2Sums, []string{"def"}) {
+			t.Errorf("%s: aarch64 set = %+v", name, sets[1])
+		}
+		if sets[2].Arch != "x86_64" || !slices.Equal(sets[2].Source, []string{"https://example.org/tool-x86_64.tar.gz"}) ||
+			!slices.Equal(sets[2].SHA256Sums, []string{"abc"}) {
+			t.Errorf("%s: x86_64 set = %+v", name, sets[2])
+		}
+	}
+}
+
+func TestParseInstallScriptAnalysis(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		risky   bool
+	}{
+		{"harmless", "post_install() {\n\techo 'Run tool --setup to configure'\n}\n", false},
+		{"downloads and runs a script", "post_install() {\n\tcurl -s https://example.org/setup.sh | bash\n}\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := ParseInstallScript("tool.install", tt.content)
+			if !slices.Equal(script.Hooks, []string{"post_install"}) {
+				t.Errorf("Hooks = %v, want post_install", script.Hooks)
+			}
+			if risky := len(script.Analysis.Findings) > 0; risky != tt.risky {
+				t.Errorf("findings = %q, want risky %v", findingReasons(script.Analysis), tt.risky)
+			}
+		})
+	}
+}
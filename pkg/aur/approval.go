@@ -0,0 +1,187 @@
+package aur
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrApprovalRequired is returned when audit mode is on and a PKGBUILD
+	// has no approval record and cannot be reviewed interactively
+	ErrApprovalRequired = errors.New("PKGBUILD has not been approved")
+
+	// ErrApprovalSignature is returned when an approval record's signature
+	// is missing or does not verify against the approval key
+	ErrApprovalSignature = errors.New("approval signature is missing or invalid")
+)
+
+// Approval records that a specific revision of a PKGBUILD was reviewed.
+type Approval struct {
+	PackageBase  string    `json:"package_base"`
+	Commit       string    `json:"commit"`        // AUR git commit that was reviewed
+	PKGBUILDHash string    `json:"pkgbuild_hash"` // SHA-256 of the reviewed PKGBUILD
+	ApprovedBy   string    `json:"approved_by"`
+	ApprovedAt   time.Time `json:"approved_at"`
+	SigningKey   string    `json:"signing_key,omitempty"`
+}
+
+// Matches returns true if the approval covers the given revision.
+func (a *Approval) Matches(commit, hash string) bool {
+	return a.Commit == commit && a.PKGBUILDHash == hash
+}
+
+// ApprovalStore keeps one approval record per package base. Only the most
+// recently approved revision is kept, so any change requires a new review.
+// When a signing key is set, records are signed with gpg and unsigned or
+// badly signed records are rejected.
+type ApprovalStore struct {
+	dir        string
+	signingKey string
+}
+
+// NewApprovalStore creates an approval store in dir. signingKey is an
+// optional GPG key ID or fingerprint used to sign and verify records.
+func NewApprovalStore(dir, signingKey string) *ApprovalStore {
+	return &ApprovalStore{dir: dir, signingKey: signingKey}
+}
+
+// Dir returns the directory holding the approval records.
+func (s *ApprovalStore) Dir() string {
+	return s.dir
+}
+
+// Lookup returns the approval record for a package base, or nil if there is
+// none. The record's signature is verified when a signing key is set.
+func (s *ApprovalStore) Lookup(ctx context.Context, pkgbase string) (*Approval, error) {
+	path := s.recordPath(pkgbase)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval: %w", err)
+	}
+
+	if s.signingKey != "" {
+		if err := verifySignature(ctx, path, s.signingKey); err != nil {
+			return nil, fmt.Errorf("%w for %s: %v", ErrApprovalSignature, pkgbase, err)
+		}
+	}
+
+	var approval Approval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return nil, fmt.Errorf("failed to parse approval for %s: %w", pkgbase, err)
+	}
+
+	return &approval, nil
+}
+
+// Record stores an approval, replacing any earlier one for the package base,
+// and signs it when a signing key is set.
+func (s *ApprovalStore) Record(ctx context.Context, approval *Approval) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create approval directory: %w", err)
+	}
+
+	if approval.ApprovedBy == "" {
+		approval.ApprovedBy = currentUser()
+	}
+	if approval.ApprovedAt.IsZero() {
+		approval.ApprovedAt = time.Now()
+	}
+	approval.SigningKey = s.signingKey
+
+	data, err := json.MarshalIndent(approval, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approval: %w", err)
+	}
+
+	path := s.recordPath(approval.PackageBase)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write approval: %w", err)
+	}
+
+	if s.signingKey == "" {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--yes", "--armor",
+		"--local-user", s.signingKey, "--detach-sign", "--output", path+".asc", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path) // An unsigned record would be rejected anyway
+		return fmt.Errorf("failed to sign approval with key %s: %w", s.signingKey, err)
+	}
+
+	return nil
+}
+
+// recordPath returns the approval file for a package base.
+func (s *ApprovalStore) recordPath(pkgbase string) string {
+	return filepath.Join(s.dir, filepath.Base(pkgbase)+".json")
+}
+
+// HashPKGBUILD returns the SHA-256 of a PKGBUILD's content.
+func HashPKGBUILD(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifySignature checks the detached signature of path and that it was made
+// by key, which may be a key ID or fingerprint.
+func verifySignature(ctx context.Context, path, key string) error {
+	return verifyDetached(ctx, path+".asc", path, key)
+}
+
+// verifyDetached checks the detached signature sig of path, armored or
+// not, and that it was made by key.
+func verifyDetached(ctx context.Context, sig, path, key string) error {
+	if _, err := os.Stat(sig); err != nil {
+		return fmt.Errorf("no signature")
+	}
+
+	var status bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--status-fd", "1", "--verify", sig, path)
+	cmd.Stdout = &status
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gpg --verify failed: %w", err)
+	}
+
+	want := strings.ToUpper(strings.TrimPrefix(strings.ReplaceAll(key, " ", ""), "0x"))
+	for _, line := range strings.Split(status.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "[GNUPG:]" || fields[1] != "VALIDSIG" {
+			continue
+		}
+		// VALIDSIG <signing key fpr> ... <primary key fpr>
+		for _, fpr := range []string{fields[2], fields[len(fields)-1]} {
+			if strings.HasSuffix(strings.ToUpper(fpr), want) {
+				return nil
+			}
+		}
+		return fmt.Errorf("signed by %s, expected %s", fields[2], key)
+	}
+
+	return fmt.Errorf("no valid signature")
+}
+
+// currentUser returns the name of the user recording an approval.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
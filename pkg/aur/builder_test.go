@@ -0,0 +1,35 @@
+package aur
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMakepkgOfflineNeedsSandbox(t *testing.T) {
+	// A makepkg that would leave a package behind if it ever ran
+	dir := t.TempDir()
+	script := "#!/bin/sh\ntouch tool-1.0-1-any.pkg.tar.zst\n"
+	if err := os.WriteFile(filepath.Join(dir, "makepkg"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	b := NewBuilder(t.TempDir())
+	opts := DefaultBuildOptions()
+	opts.OfflineBuild = true
+	opts.SandboxProfile = "no-such-profile"
+	b.SetOptions(opts)
+	pkgDir := t.TempDir()
+
+	_, err := b.runMakepkg(context.Background(), pkgDir, &PKGBUILD{})
+	if !errors.Is(err, ErrBuildFailed) || !strings.Contains(err.Error(), "offline builds need a sandbox") {
+		t.Errorf("runMakepkg() error = %v, want it to refuse building without a sandbox", err)
+	}
+	if pkgs, _ := b.findBuiltPackages(pkgDir); len(pkgs) > 0 {
+		t.Errorf("makepkg ran without a sandbox and built %v", pkgs)
+	}
+}
@@ -0,0 +1,148 @@
+package aur
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached RPC responses are used before the AUR
+// is asked again.
+const DefaultCacheTTL = 10 * time.Minute
+
+// MaxStaleAge is how old an expired response can be and still be used when
+// the AUR cannot be reached; older ones are too likely to be wrong.
+const MaxStaleAge = 7 * 24 * time.Hour
+
+// defaultCache is the cache clients made with NewClient use; nil for none.
+var defaultCache *Cache
+
+// SetDefaultCache makes clients created with NewClient afterwards use
+// cache, or no cache if it is nil.
+func SetDefaultCache(cache *Cache) {
+	defaultCache = cache
+}
+
+// Cache keeps RPC responses on disk for a while, so that resolving an
+// install and then building it, or checking a hundred packages for upgrades
+// twice in a row, does not ask the RPC interface again. Each response is a
+// file of its own, replaced atomically, so several poxy processes can share
+// the cache. Expired responses up to MaxStaleAge old are still used when
+// the AUR cannot be reached or rate limits requests.
+type Cache struct {
+	dir string
+	ttl time.Duration
+
+	// OnStale, if set, is called when expired responses are used because
+	// the AUR could not be asked, with the age of the oldest one and the
+	// error asking it
+	OnStale func(age time.Duration, err error)
+}
+
+// cacheEntry is a cached response. Found is false for packages the AUR
+// does not have, so those are not asked for again either.
+type cacheEntry struct {
+	Fetched  time.Time `json:"fetched"`
+	Found    bool      `json:"found"`
+	Packages []Package `json:"packages,omitempty"`
+}
+
+// NewCache creates a cache in dir whose responses are fresh for ttl.
+func NewCache(dir string, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// Dir returns the directory the cache is kept in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Clear removes every cached response.
+func (c *Cache) Clear() error {
+	entries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// get returns the response cached under key and whether it is still
+// fresh. ok is false if nothing is cached.
+func (c *Cache) get(key string) (entry cacheEntry, fresh, ok bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return cacheEntry{}, false, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false, false
+	}
+	return entry, time.Since(entry.Fetched) < c.ttl, true
+}
+
+// stale returns the response cached under key if it is at most
+// MaxStaleAge old, for when the AUR cannot be asked.
+func (c *Cache) stale(key string) (cacheEntry, bool) {
+	entry, _, ok := c.get(key)
+	if !ok || time.Since(entry.Fetched) > MaxStaleAge {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// usedStale reports that expired responses, the oldest fetched at fetched,
+// were used because asking the AUR failed with err.
+func (c *Cache) usedStale(fetched time.Time, err error) {
+	if c.OnStale != nil {
+		c.OnStale(time.Since(fetched), err)
+	}
+}
+
+// put caches a response under key. Failures only cost a later request, so
+// they are ignored.
+func (c *Cache) put(key string, packages []Package, found bool) {
+	data, err := json.Marshal(cacheEntry{Fetched: time.Now(), Found: found, Packages: packages})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), c.path(key)) != nil {
+		_ = os.Remove(tmp.Name()) //nolint:errcheck
+	}
+}
+
+// path returns the file a key is cached in; keys are hashed, since search
+// queries can hold any character.
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func infoKey(name string) string    { return "info:" + name }
+func searchKey(query string) string { return "search:" + query }
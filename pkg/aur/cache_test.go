@@ -0,0 +1,182 @@
+package aur
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAUR serves the RPC interface with the packages it has, failing every
+// request while down is set. It counts the requests it is sent.
+type fakeAUR struct {
+	packages map[string]Package
+	down     atomic.Bool
+	requests atomic.Int32
+}
+
+func (f *fakeAUR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	if f.down.Load() {
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	resp := Response{Version: 5, Type: "multiinfo"}
+	for _, name := range r.URL.Query()["arg[]"] {
+		if pkg, ok := f.packages[name]; ok {
+			resp.Results = append(resp.Results, pkg)
+		}
+	}
+	if r.URL.Path == "/search/yay" {
+		resp.Type = "search"
+		resp.Results = []Package{f.packages["yay"]}
+	}
+	resp.ResultCount = len(resp.Results)
+	_ = json.NewEncoder(w).Encode(resp) //nolint:errcheck
+}
+
+// newCachedClient returns a client of a fake AUR with the packages yay and
+// paru, caching its answers for ttl.
+func newCachedClient(t *testing.T, ttl time.Duration) (*Client, *fakeAUR) {
+	t.Helper()
+
+	aur := &fakeAUR{packages: map[string]Package{
+		"yay":  {Name: "yay", PackageBase: "yay", Version: "12.4.2-1"},
+		"paru": {Name: "paru", PackageBase: "paru", Version: "2.0.4-1"},
+	}}
+	server := httptest.NewServer(aur)
+	t.Cleanup(server.Close)
+
+	client := NewClientWithOptions(server.URL, 0)
+	client.SetCache(NewCache(t.TempDir(), ttl))
+	return client, aur
+}
+
+// backdate makes the response cached under key look fetched age ago.
+func backdate(t *testing.T, cache *Cache, key string, age time.Duration) {
+	t.Helper()
+
+	data, err := os.ReadFile(cache.path(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Fetched = time.Now().Add(-age)
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache.path(key), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheHit(t *testing.T) {
+	ctx := context.Background()
+	client, aur := newCachedClient(t, time.Hour)
+
+	for range 2 {
+		packages, err := client.InfoAll(ctx, []string{"yay", "paru", "missing"})
+		if err != nil {
+			t.Fatalf("InfoAll() error = %v", err)
+		}
+		if len(packages) != 2 {
+			t.Errorf("InfoAll() = %+v, want yay and paru", packages)
+		}
+	}
+	for range 2 {
+		if _, err := client.Search(ctx, "yay"); err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+	}
+	if n := aur.requests.Load(); n != 2 {
+		t.Errorf("the AUR was asked %d times, want once for info and once for the search", n)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	client, aur := newCachedClient(t, time.Hour)
+
+	if _, err := client.InfoAll(ctx, []string{"yay", "paru"}); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, client.Cache(), infoKey("paru"), 2*time.Hour)
+
+	aur.requests.Store(0)
+	aur.packages["paru"] = Package{Name: "paru", PackageBase: "paru", Version: "2.1.0-1"}
+	packages, err := client.InfoAll(ctx, []string{"yay", "paru"})
+	if err != nil {
+		t.Fatalf("InfoAll() error = %v", err)
+	}
+	if n := aur.requests.Load(); n != 1 {
+		t.Errorf("the AUR was asked %d times, want once for the expired paru", n)
+	}
+	for _, pkg := range packages {
+		if pkg.Name == "paru" && pkg.Version != "2.1.0-1" {
+			t.Errorf("paru = %s, want the version asked again", pkg.Version)
+		}
+	}
+}
+
+func TestCacheFallback(t *testing.T) {
+	ctx := context.Background()
+	client, aur := newCachedClient(t, time.Hour)
+
+	var warned []time.Duration
+	client.Cache().OnStale = func(age time.Duration, err error) {
+		if err == nil {
+			t.Error("OnStale() called without the error")
+		}
+		warned = append(warned, age)
+	}
+
+	if _, err := client.InfoAll(ctx, []string{"yay", "paru"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Search(ctx, "yay"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{infoKey("yay"), infoKey("paru"), searchKey("search/yay")} {
+		backdate(t, client.Cache(), key, 3*24*time.Hour)
+	}
+	aur.down.Store(true)
+
+	t.Run("expired answers are used", func(t *testing.T) {
+		warned = nil
+		packages, err := client.InfoAll(ctx, []string{"yay", "paru"})
+		if err != nil || len(packages) != 2 {
+			t.Errorf("InfoAll() = %+v, %v, want the cached answers", packages, err)
+		}
+		if results, err := client.Search(ctx, "yay"); err != nil || len(results) != 1 {
+			t.Errorf("Search() = %+v, %v, want the cached answer", results, err)
+		}
+		if len(warned) != 2 || warned[0] < 3*24*time.Hour {
+			t.Errorf("warnings = %v, want two about answers three days old", warned)
+		}
+	})
+
+	t.Run("answers too old are not", func(t *testing.T) {
+		backdate(t, client.Cache(), infoKey("paru"), MaxStaleAge+time.Hour)
+		backdate(t, client.Cache(), searchKey("search/yay"), MaxStaleAge+time.Hour)
+		if _, err := client.InfoAll(ctx, []string{"yay", "paru"}); err == nil {
+			t.Error("InfoAll() used an answer older than MaxStaleAge")
+		}
+		if _, err := client.Search(ctx, "yay"); err == nil {
+			t.Error("Search() used an answer older than MaxStaleAge")
+		}
+	})
+
+	t.Run("nothing cached", func(t *testing.T) {
+		if _, err := client.InfoAll(ctx, []string{"pikaur"}); err == nil {
+			t.Error("InfoAll() of a package never asked about succeeded")
+		}
+	})
+}
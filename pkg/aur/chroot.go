@@ -0,0 +1,177 @@
+package aur
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// chrootTools are the devtools commands clean chroot builds need.
+var chrootTools = []string{"mkarchroot", "arch-nspawn", "makechrootpkg"}
+
+// ChrootAvailable returns true if devtools, which clean chroot builds use,
+// is installed.
+func ChrootAvailable() bool {
+	for _, tool := range chrootTools {
+		if _, err := exec.LookPath(tool); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// chrootDir returns the directory holding the clean chroot: the root
+// chroot in root/ and the build copies next to it.
+func (b *Builder) chrootDir() string {
+	if b.options.ChrootDir != "" {
+		return b.options.ChrootDir
+	}
+	return filepath.Join(b.CacheDir(), "chroot")
+}
+
+// prepareChroot creates the root chroot with base-devel, or brings an
+// existing one up to date. It is done once per builder.
+func (b *Builder) prepareChroot(ctx context.Context) error {
+	if b.chrootReady {
+		return nil
+	}
+
+	root := filepath.Join(b.chrootDir(), "root")
+	var cmd *exec.Cmd
+	if _, err := os.Stat(filepath.Join(root, ".arch-chroot")); err == nil {
+		b.progress("chroot", "Updating the clean chroot...")
+		cmd = exec.CommandContext(ctx, "arch-nspawn", root, "pacman", "-Syu", "--noconfirm")
+	} else {
+		b.progress("chroot", "Creating the clean chroot in "+root+"...")
+		if err := os.MkdirAll(b.chrootDir(), 0o755); err != nil {
+			return fmt.Errorf("%w: failed to create chroot directory: %v", ErrBuildFailed, err)
+		}
+		cmd = exec.CommandContext(ctx, "mkarchroot", root, "base-devel")
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: failed to prepare the clean chroot: %v", ErrBuildFailed, err)
+	}
+	b.chrootReady = true
+	return nil
+}
+
+// runMakechrootpkg builds in a fresh copy of the clean chroot, where only
+// base-devel and the package's declared dependencies are installed, so the
+// result does not depend on what happens to be installed on the host.
+// Dependencies that are not in the repositories are installed into the
+// copy from packages built earlier.
+func (b *Builder) runMakechrootpkg(ctx context.Context, pkgDir string, pkgbuild *PKGBUILD, args []string) ([]string, error) {
+	if !ChrootAvailable() {
+		return nil, fmt.Errorf("%w: clean chroot builds need devtools (install it with: pacman -S devtools)", ErrBuildFailed)
+	}
+	if err := b.prepareChroot(ctx); err != nil {
+		return nil, err
+	}
+
+	local, err := b.localDependencies(ctx, pkgbuild)
+	if err != nil {
+		return nil, err
+	}
+
+	// -c builds in a fresh copy of the root chroot
+	chrootArgs := []string{"-c", "-r", b.chrootDir()}
+	for _, pkg := range local {
+		chrootArgs = append(chrootArgs, "-I", pkg)
+	}
+	chrootArgs = append(chrootArgs, "--")
+	chrootArgs = append(chrootArgs, args...)
+
+	b.progress("build", "Building in a clean chroot...")
+	output := newBuildOutput()
+	cmd := exec.CommandContext(ctx, "makechrootpkg", chrootArgs...)
+	cmd.Dir = pkgDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = output.stdout
+	cmd.Stderr = output.stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, output.failed(err, b.keyserver())
+	}
+
+	return b.findBuiltPackages(pkgDir)
+}
+
+// localDependencies returns the package files of the dependencies of
+// pkgbuild that the repositories do not have, such as other AUR packages.
+// The clean chroot cannot install them with pacman, so they must have been
+// built by an earlier build.
+func (b *Builder) localDependencies(ctx context.Context, pkgbuild *PKGBUILD) ([]string, error) {
+	deps := append(pkgbuild.AllDependencies(), pkgbuild.AllBuildDependencies()...)
+
+	var files, missing []string
+	for _, dep := range deps {
+		name := depName(dep)
+		// -Sp prints what would be downloaded, including for providers
+		if exec.CommandContext(ctx, "pacman", "-Sp", "--print-format", "%n", name).Run() == nil {
+			continue
+		}
+		if file := b.findCachedPackage(name); file != "" {
+			files = append(files, file)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s not in the repositories and not built yet; build them first",
+			ErrMissingDependencies, strings.Join(missing, ", "))
+	}
+	return files, nil
+}
+
+// findCachedPackage returns the most recently built package file named
+// name in the build cache, or "" if there is none.
+func (b *Builder) findCachedPackage(name string) string {
+	matches, err := filepath.Glob(filepath.Join(b.CacheDir(), "*", name+"-*.pkg.tar*"))
+	if err != nil {
+		return ""
+	}
+
+	var newest string
+	var newestTime int64
+	for _, match := range matches {
+		if packageFileName(filepath.Base(match)) != name {
+			continue
+		}
+		info, err := os.Stat(match)
+		if err != nil || strings.HasSuffix(match, ".sig") {
+			continue
+		}
+		if t := info.ModTime().UnixNano(); newest == "" || t > newestTime {
+			newest, newestTime = match, t
+		}
+	}
+	return newest
+}
+
+// packageFileName returns the package name of a package file name such as
+// "foo-bar-1.2-1-x86_64.pkg.tar.zst": everything before the last three
+// dash-separated fields.
+func packageFileName(file string) string {
+	fields := strings.Split(file, "-")
+	if len(fields) < 4 {
+		return ""
+	}
+	return strings.Join(fields[:len(fields)-3], "-")
+}
+
+// depName returns the package name of a dependency, without its version
+// constraint.
+func depName(dep string) string {
+	if i := strings.IndexAny(dep, "<>="); i >= 0 {
+		return dep[:i]
+	}
+	return dep
+}
@@ -0,0 +1,213 @@
+package aur
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPackageFileName(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"foo-1.2-1-x86_64.pkg.tar.zst", "foo"},
+		{"foo-bar-1.2-1-x86_64.pkg.tar.zst", "foo-bar"},
+		{"foo-bar-1:1.2-3-any.pkg.tar.xz", "foo-bar"},
+		{"foo-1.2-1-x86_64.pkg.tar.zst.sig", "foo"},
+		{"foo-1.2", ""},
+	}
+
+	for _, tt := range tests {
+		if got := packageFileName(tt.file); got != tt.want {
+			t.Errorf("packageFileName(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestDepName(t *testing.T) {
+	tests := []struct {
+		dep  string
+		want string
+	}{
+		{"glibc", "glibc"},
+		{"openssl>=3", "openssl"},
+		{"python<3.13", "python"},
+		{"libfoo=1.2-1", "libfoo"},
+	}
+
+	for _, tt := range tests {
+		if got := depName(tt.dep); got != tt.want {
+			t.Errorf("depName(%q) = %q, want %q", tt.dep, got, tt.want)
+		}
+	}
+}
+
+// writePackageFile creates an empty package file in the build cache of b,
+// modified at modTime.
+func writePackageFile(t *testing.T, b *Builder, dir, file string, modTime time.Time) string {
+	t.Helper()
+	path := filepath.Join(b.CacheDir(), dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindCachedPackage(t *testing.T) {
+	b := NewBuilder(t.TempDir())
+	built := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	writePackageFile(t, b, "foo", "foo-1.0-1-x86_64.pkg.tar.zst", built)
+	newest := writePackageFile(t, b, "foo", "foo-1.1-1-x86_64.pkg.tar.zst", built.Add(time.Hour))
+	writePackageFile(t, b, "foo", "foo-1.1-1-x86_64.pkg.tar.zst.sig", built.Add(2*time.Hour))
+	bar := writePackageFile(t, b, "foo", "foo-bar-1.0-1-x86_64.pkg.tar.zst", built.Add(3*time.Hour))
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"foo", newest},
+		{"foo-bar", bar},
+		{"baz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := b.findCachedPackage(tt.name); got != tt.want {
+			t.Errorf("findCachedPackage(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// stubDevtools puts devtools and a pacman that finds only glibc in the sync
+// databases on PATH. Each logs its name and arguments, and makechrootpkg
+// leaves a package in the build directory. It returns the log's path.
+func stubDevtools(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+
+	scripts := map[string]string{
+		"mkarchroot":    "",
+		"arch-nspawn":   "",
+		"makechrootpkg": "touch foo-1.0-1-x86_64.pkg.tar.zst\n",
+		"pacman":        "[ \"$4\" = glibc ]\n",
+	}
+	for name, body := range scripts {
+		script := "#!/bin/sh\necho \"${0##*/} $*\" >> " + log + "\n" + body
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+// chrootCommands returns the devtools commands logged by the stubs.
+func chrootCommands(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "pacman ") {
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
+
+func TestRunMakechrootpkg(t *testing.T) {
+	ctx := context.Background()
+	built := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("creates the chroot and installs AUR dependencies into it", func(t *testing.T) {
+		log := stubDevtools(t)
+		b := NewBuilder(t.TempDir())
+		libfoo := writePackageFile(t, b, "libfoo", "libfoo-2.0-1-x86_64.pkg.tar.zst", built)
+		pkgDir := filepath.Join(b.CacheDir(), "foo")
+		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		pkgbuild := &PKGBUILD{Depends: []string{"glibc", "libfoo>=2"}}
+		pkgs, err := b.runMakechrootpkg(ctx, pkgDir, pkgbuild, []string{"-f"})
+		if err != nil {
+			t.Fatalf("runMakechrootpkg() error = %v", err)
+		}
+		if len(pkgs) != 1 || filepath.Base(pkgs[0]) != "foo-1.0-1-x86_64.pkg.tar.zst" {
+			t.Errorf("runMakechrootpkg() = %v, want the built package", pkgs)
+		}
+
+		chroot := filepath.Join(b.CacheDir(), "chroot")
+		want := []string{
+			"mkarchroot " + filepath.Join(chroot, "root") + " base-devel",
+			"makechrootpkg -c -r " + chroot + " -I " + libfoo + " -- -f",
+		}
+		if got := chrootCommands(t, log); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("commands = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("updates an existing chroot once", func(t *testing.T) {
+		log := stubDevtools(t)
+		b := NewBuilder(t.TempDir())
+		b.SetOptions(BuildOptions{ChrootDir: t.TempDir()})
+		root := filepath.Join(b.chrootDir(), "root")
+		if err := os.MkdirAll(root, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, ".arch-chroot"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		pkgDir := filepath.Join(b.CacheDir(), "foo")
+		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		for range 2 {
+			if _, err := b.runMakechrootpkg(ctx, pkgDir, &PKGBUILD{}, []string{"-f"}); err != nil {
+				t.Fatalf("runMakechrootpkg() error = %v", err)
+			}
+		}
+
+		build := "makechrootpkg -c -r " + b.chrootDir() + " -- -f"
+		want := []string{"arch-nspawn " + root + " pacman -Syu --noconfirm", build, build}
+		if got := chrootCommands(t, log); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("commands = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("dependencies that were not built yet", func(t *testing.T) {
+		stubDevtools(t)
+		b := NewBuilder(t.TempDir())
+
+		pkgbuild := &PKGBUILD{Depends: []string{"glibc"}, MakeDepends: []string{"libbar"}}
+		_, err := b.runMakechrootpkg(ctx, t.TempDir(), pkgbuild, []string{"-f"})
+		if !errors.Is(err, ErrMissingDependencies) || !strings.Contains(err.Error(), "libbar") {
+			t.Errorf("runMakechrootpkg() error = %v, want libbar missing", err)
+		}
+	})
+
+	t.Run("without devtools", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		b := NewBuilder(t.TempDir())
+
+		_, err := b.runMakechrootpkg(ctx, t.TempDir(), &PKGBUILD{}, []string{"-f"})
+		if !errors.Is(err, ErrBuildFailed) || !strings.Contains(err.Error(), "devtools") {
+			t.Errorf("runMakechrootpkg() error = %v, want devtools missing", err)
+		}
+	})
+}
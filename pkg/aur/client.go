@@ -18,12 +18,17 @@ const (
 
 	// DefaultTimeout is the default HTTP timeout
 	DefaultTimeout = 30 * time.Second
+
+	// InfoBatchSize is the number of packages InfoAll looks up per request,
+	// keeping request URLs well under server limits
+	InfoBatchSize = 100
 )
 
 // Client is an AUR RPC API client.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	cache      *Cache // nil to always ask the AUR
 }
 
 // Package represents an AUR package from the RPC API.
@@ -68,13 +73,15 @@ type Response struct {
 	Error       string    `json:"error,omitempty"`
 }
 
-// NewClient creates a new AUR client with default settings.
+// NewClient creates a new AUR client with default settings, using the
+// cache set with SetDefaultCache.
 func NewClient() *Client {
 	return &Client{
 		baseURL: DefaultBaseURL,
 		httpClient: &http.Client{
 			Timeout: DefaultTimeout,
 		},
+		cache: defaultCache,
 	}
 }
 
@@ -95,6 +102,17 @@ func NewClientWithOptions(baseURL string, timeout time.Duration) *Client {
 	}
 }
 
+// SetCache makes the client answer Info and searches from cache while its
+// responses are fresh, or always ask the AUR if cache is nil.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// Cache returns the cache of the client, or nil if it has none.
+func (c *Client) Cache() *Cache {
+	return c.cache
+}
+
 // Search searches for packages matching the query.
 func (c *Client) Search(ctx context.Context, query string) ([]Package, error) {
 	return c.searchBy(ctx, "search", query)
@@ -115,23 +133,93 @@ func (c *Client) SearchByMaintainer(ctx context.Context, maintainer string) ([]P
 	return c.searchBy(ctx, "search", maintainer+"&by=maintainer")
 }
 
+// SearchByProvides searches for packages that provide a virtual package,
+// such as "java-runtime".
+func (c *Client) SearchByProvides(ctx context.Context, name string) ([]Package, error) {
+	return c.searchBy(ctx, "search", name+"&by=provides")
+}
+
 func (c *Client) searchBy(ctx context.Context, searchType, query string) ([]Package, error) {
-	endpoint := fmt.Sprintf("%s/%s/%s", c.baseURL, searchType, url.PathEscape(query))
+	key := searchKey(searchType + "/" + query)
+	if c.cache != nil {
+		if entry, fresh, ok := c.cache.get(key); ok && fresh {
+			return entry.Packages, nil
+		}
+	}
 
+	endpoint := fmt.Sprintf("%s/%s/%s", c.baseURL, searchType, url.PathEscape(query))
 	resp, err := c.doRequest(ctx, endpoint)
 	if err != nil {
-		return nil, err
+		if c.cache == nil || ctx.Err() != nil {
+			return nil, err
+		}
+		stale, ok := c.cache.stale(key)
+		if !ok {
+			return nil, err
+		}
+		c.cache.usedStale(stale.Fetched, err)
+		return stale.Packages, nil // Better an old answer than none
 	}
 
+	if c.cache != nil {
+		c.cache.put(key, resp.Results, true)
+	}
 	return resp.Results, nil
 }
 
-// Info retrieves detailed information about one or more packages.
+// Info retrieves detailed information about one or more packages, in
+// batches of InfoBatchSize. Packages not in the AUR are left out of the
+// result.
 func (c *Client) Info(ctx context.Context, names ...string) ([]Package, error) {
-	if len(names) == 0 {
-		return nil, nil
+	return c.InfoAll(ctx, names)
+}
+
+// Ping checks that the RPC interface answers. Whether the queried package
+// exists does not matter. It never answers from cache.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.doRequest(ctx, c.baseURL+"/info?arg[]=poxy")
+	return err
+}
+
+// InfoAll retrieves information about any number of packages. Packages
+// with a fresh cached answer are not asked for; the rest are looked up in
+// batches of InfoBatchSize. Packages not in the AUR are left out of the
+// result.
+func (c *Client) InfoAll(ctx context.Context, names []string) ([]Package, error) {
+	var packages []Package
+	missing := names
+	if c.cache != nil {
+		missing = nil
+		for _, name := range names {
+			entry, fresh, ok := c.cache.get(infoKey(name))
+			if !ok || !fresh {
+				missing = append(missing, name)
+			} else if entry.Found {
+				packages = append(packages, entry.Packages...)
+			}
+		}
 	}
 
+	for start := 0; start < len(missing); start += InfoBatchSize {
+		batch := missing[start:min(start+InfoBatchSize, len(missing))]
+		found, err := c.fetchInfo(ctx, batch)
+		if err != nil {
+			stale, fetched, ok := c.staleInfo(batch)
+			if !ok || ctx.Err() != nil {
+				return nil, err
+			}
+			c.cache.usedStale(fetched, err)
+			found = stale // Better old answers than none
+		} else if c.cache != nil {
+			c.storeInfo(batch, found)
+		}
+		packages = append(packages, found...)
+	}
+	return packages, nil
+}
+
+// fetchInfo asks the AUR about names in one request.
+func (c *Client) fetchInfo(ctx context.Context, names []string) ([]Package, error) {
 	// Build query string with multiple arg[] parameters
 	params := make([]string, len(names))
 	for i, name := range names {
@@ -148,6 +236,48 @@ func (c *Client) Info(ctx context.Context, names ...string) ([]Package, error) {
 	return resp.Results, nil
 }
 
+// storeInfo caches the answer about each of names, including that the AUR
+// does not have the ones missing from found.
+func (c *Client) storeInfo(names []string, found []Package) {
+	byName := make(map[string]Package, len(found))
+	for _, pkg := range found {
+		byName[pkg.Name] = pkg
+	}
+	for _, name := range names {
+		if pkg, ok := byName[name]; ok {
+			c.cache.put(infoKey(name), []Package{pkg}, true)
+		} else {
+			c.cache.put(infoKey(name), nil, false)
+		}
+	}
+}
+
+// staleInfo returns the cached answers about names, expired but at most
+// MaxStaleAge old, if every one of them has one, and when the oldest was
+// fetched.
+func (c *Client) staleInfo(names []string) ([]Package, time.Time, bool) {
+	if c.cache == nil {
+		return nil, time.Time{}, false
+	}
+	var (
+		packages []Package
+		oldest   time.Time
+	)
+	for _, name := range names {
+		entry, ok := c.cache.stale(infoKey(name))
+		if !ok {
+			return nil, time.Time{}, false
+		}
+		if oldest.IsZero() || entry.Fetched.Before(oldest) {
+			oldest = entry.Fetched
+		}
+		if entry.Found {
+			packages = append(packages, entry.Packages...)
+		}
+	}
+	return packages, oldest, true
+}
+
 // GetPackage retrieves detailed information about a single package.
 func (c *Client) GetPackage(ctx context.Context, name string) (*Package, error) {
 	packages, err := c.Info(ctx, name)
@@ -0,0 +1,108 @@
+package aur
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Comment is a user comment from a package's AUR web page.
+type Comment struct {
+	Author string
+	Date   string // As shown on the page, e.g. "2024-03-10 12:00 (UTC)"
+	Text   string
+}
+
+var (
+	commentHeaderRe  = regexp.MustCompile(`(?s)<h4 id="comment-(\d+)"[^>]*>(.*?)</h4>`)
+	commentContentRe = regexp.MustCompile(`(?s)<div id="comment-(\d+)-content"[^>]*>(.*?)</div>\s*</div>`)
+	commentAuthorRe  = regexp.MustCompile(`(?s)<a [^>]*>(.*?)</a>`)
+	commentDateRe    = regexp.MustCompile(`(?s)class="date"[^>]*>(.*?)</a>`)
+	htmlLineBreakRe  = regexp.MustCompile(`(?i)<br\s*/?>\s*`)
+	htmlBlockEndRe   = regexp.MustCompile(`(?i)</(p|pre|li)>`)
+	htmlTagRe        = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLinesRe     = regexp.MustCompile(`\n{3,}`)
+)
+
+// WebURL returns the package's page on the AUR website.
+func (p *Package) WebURL() string {
+	return fmt.Sprintf("https://aur.archlinux.org/packages/%s", url.PathEscape(p.Name))
+}
+
+// Comments returns up to limit comments from the package's AUR web page,
+// pinned comments first and then the newest. The RPC API has no comments, so
+// they are scraped from the page.
+func (c *Client) Comments(ctx context.Context, pkg *Package, limit int) ([]Comment, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pkg.WebURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AUR web error (status %d)", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return parseComments(string(body), limit), nil
+}
+
+// parseComments extracts up to limit comments from an AUR package page.
+func parseComments(page string, limit int) []Comment {
+	contents := make(map[string]string)
+	for _, m := range commentContentRe.FindAllStringSubmatch(page, -1) {
+		contents[m[1]] = m[2]
+	}
+
+	var comments []Comment
+	for _, m := range commentHeaderRe.FindAllStringSubmatch(page, -1) {
+		if limit > 0 && len(comments) >= limit {
+			break
+		}
+
+		content, ok := contents[m[1]]
+		if !ok {
+			continue // Deleted comments have no content
+		}
+
+		comment := Comment{Text: htmlText(content)}
+		if a := commentAuthorRe.FindStringSubmatch(m[2]); a != nil {
+			comment.Author = htmlText(a[1])
+		}
+		if d := commentDateRe.FindStringSubmatch(m[2]); d != nil {
+			comment.Date = htmlText(d[1])
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments
+}
+
+// htmlText converts an HTML fragment to plain text, keeping paragraph breaks.
+func htmlText(fragment string) string {
+	text := htmlLineBreakRe.ReplaceAllString(fragment, "\n")
+	text = htmlBlockEndRe.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(htmlTagRe.ReplaceAllString(text, ""))
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(text, "\n\n"))
+}
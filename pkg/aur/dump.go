@@ -0,0 +1,65 @@
+package aur
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// dumpPath is the metadata dump of every AUR package, relative to the
+	// AUR root
+	dumpPath = "/packages-meta-v1.json.gz"
+
+	// DumpTimeout bounds downloading the metadata dump, which is several
+	// megabytes
+	DumpTimeout = 5 * time.Minute
+)
+
+// Dump downloads the metadata of every AUR package: name, version,
+// description, votes and popularity, but not dependencies or relations.
+// The AUR regenerates the dump every few minutes; fetch it sparingly.
+func (c *Client) Dump(ctx context.Context) ([]Package, error) {
+	root := strings.TrimSuffix(strings.TrimSuffix(c.baseURL, "/v5"), "/rpc")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, root+dumpPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+
+	client := &http.Client{Timeout: DumpTimeout, Transport: c.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AUR metadata dump: status %d", resp.StatusCode)
+	}
+
+	// Served as a gzip file, unless a proxy or the transport already
+	// decompressed it
+	body := bufio.NewReader(resp.Body)
+	var r io.Reader = body
+	if magic, err := body.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("AUR metadata dump: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var packages []Package
+	if err := json.NewDecoder(r).Decode(&packages); err != nil {
+		return nil, fmt.Errorf("failed to parse the AUR metadata dump: %w", err)
+	}
+	return packages, nil
+}
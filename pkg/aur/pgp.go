@@ -0,0 +1,275 @@
+package aur
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultKeyserver is the keyserver PGP keys are looked up on and imported
+// from.
+const DefaultKeyserver = "hkps://keyserver.ubuntu.com"
+
+// UnknownKeysError is returned when a build fails because source signatures
+// are made with keys that are not in the user's keyring.
+type UnknownKeysError struct {
+	Keys      []string
+	Keyserver string // Where the keys can be imported from
+	Err       error
+}
+
+func (e *UnknownKeysError) Error() string {
+	return fmt.Sprintf("%v: unknown public keys %s (import them with: %s)", e.Err,
+		strings.Join(e.Keys, ", "), ImportCommand(e.Keyserver, e.Keys))
+}
+
+func (e *UnknownKeysError) Unwrap() error {
+	return e.Err
+}
+
+// KeyInfo describes a PGP key as a keyserver knows it.
+type KeyInfo struct {
+	ID          string // The key ID makepkg reported
+	Fingerprint string // Empty if the keyserver does not have the key
+	Bits        int
+	Created     time.Time
+	UserIDs     []string
+	Revoked     bool
+	Expired     bool
+	// Listed is set if the PKGBUILD lists the key in validpgpkeys
+	Listed bool
+	// Err is set if the keyserver could not be asked about the key
+	Err error
+}
+
+// Found returns true if the keyserver has the key.
+func (k *KeyInfo) Found() bool {
+	return k.Fingerprint != ""
+}
+
+// unknownKeyPattern matches makepkg's report of a signature it cannot
+// check, e.g. "foo-1.0.tar.gz ... FAILED (unknown public key 1234ABCD5678EF90)".
+var unknownKeyPattern = regexp.MustCompile(`unknown public key ([0-9A-Fa-f]{8,40})`)
+
+// UnknownKeys returns the IDs of the keys makepkg reported as unknown in
+// output, without duplicates.
+func UnknownKeys(output string) []string {
+	var keys []string
+	for _, m := range unknownKeyPattern.FindAllStringSubmatch(output, -1) {
+		key := strings.ToUpper(m[1])
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// keyWatcher passes makepkg's output through to w, recording the unknown
+// keys it reports.
+type keyWatcher struct {
+	w    io.Writer
+	line []byte
+	keys []string
+}
+
+func (k *keyWatcher) Write(p []byte) (int, error) {
+	k.line = append(k.line, p...)
+	for {
+		i := slices.Index(k.line, '\n')
+		if i < 0 {
+			break
+		}
+		k.scan(string(k.line[:i]))
+		k.line = k.line[i+1:]
+	}
+	// Progress bars redraw with \r and never end the line
+	if len(k.line) > 4096 {
+		k.line = k.line[len(k.line)-4096:]
+	}
+	return k.w.Write(p)
+}
+
+func (k *keyWatcher) scan(line string) {
+	for _, key := range UnknownKeys(line) {
+		if !slices.Contains(k.keys, key) {
+			k.keys = append(k.keys, key)
+		}
+	}
+}
+
+// Keys returns the unknown keys reported so far.
+func (k *keyWatcher) Keys() []string {
+	k.scan(string(k.line))
+	return k.keys
+}
+
+// buildOutput passes makepkg's output through, watching it for signatures
+// made with unknown keys.
+type buildOutput struct {
+	stdout *keyWatcher
+	stderr *keyWatcher
+}
+
+func newBuildOutput() *buildOutput {
+	return &buildOutput{
+		stdout: &keyWatcher{w: os.Stdout},
+		stderr: &keyWatcher{w: os.Stderr},
+	}
+}
+
+// failed returns the error for a failed makepkg run: an *UnknownKeysError
+// if it reported signatures made with unknown keys.
+func (o *buildOutput) failed(err error, keyserver string) error {
+	err = fmt.Errorf("%w: %v", ErrBuildFailed, err)
+
+	keys := o.stdout.Keys()
+	for _, key := range o.stderr.Keys() {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		return &UnknownKeysError{Keys: keys, Keyserver: keyserver, Err: err}
+	}
+	return err
+}
+
+// keyserverURL returns the HTTP URL of an hkp:// or hkps:// keyserver.
+func keyserverURL(keyserver string) (string, error) {
+	u, err := url.Parse(keyserver)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid keyserver %q", keyserver)
+	}
+	switch u.Scheme {
+	case "hkps":
+		u.Scheme = "https"
+	case "hkp":
+		u.Scheme = "http"
+		if u.Port() == "" {
+			u.Host += ":11371"
+		}
+	case "https", "http":
+	default:
+		return "", fmt.Errorf("unsupported keyserver scheme %q", u.Scheme)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// LookupKey asks keyserver about the key with id. A key the keyserver does
+// not have is returned without a fingerprint.
+func (c *Client) LookupKey(ctx context.Context, keyserver, id string) (*KeyInfo, error) {
+	base, err := keyserverURL(keyserver)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("op", "index")
+	params.Set("options", "mr")
+	params.Set("search", "0x"+id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/pks/lookup?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &KeyInfo{ID: id}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("keyserver error (status %d)", resp.StatusCode)
+	}
+
+	return parseKeyIndex(resp.Body, id), nil
+}
+
+// parseKeyIndex reads the first key of a machine-readable HKP index:
+//
+//	info:1:1
+//	pub:<fingerprint or key id>:<algorithm>:<bits>:<created>:<expires>:<flags>
+//	uid:<escaped user id>:<created>:<expires>:<flags>
+func parseKeyIndex(r io.Reader, id string) *KeyInfo {
+	info := &KeyInfo{ID: id}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		switch fields[0] {
+		case "pub":
+			if info.Fingerprint != "" {
+				return info // Only the first key
+			}
+			if len(fields) < 2 {
+				continue
+			}
+			info.Fingerprint = strings.ToUpper(fields[1])
+			if len(fields) > 3 {
+				info.Bits, _ = strconv.Atoi(fields[3]) //nolint:errcheck
+			}
+			if len(fields) > 4 {
+				info.Created = unixField(fields[4])
+			}
+			if len(fields) > 5 {
+				if expires := unixField(fields[5]); !expires.IsZero() && expires.Before(time.Now()) {
+					info.Expired = true
+				}
+			}
+			if len(fields) > 6 {
+				info.Revoked = strings.Contains(fields[6], "r")
+			}
+		case "uid":
+			if info.Fingerprint == "" || len(fields) < 2 {
+				continue
+			}
+			if uid, err := url.PathUnescape(fields[1]); err == nil {
+				info.UserIDs = append(info.UserIDs, uid)
+			}
+		}
+	}
+	return info
+}
+
+// unixField parses a Unix time field of an HKP index; empty is zero.
+func unixField(s string) time.Time {
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// ImportKeys imports keys from keyserver into the user's keyring, the one
+// makepkg checks signatures with.
+func ImportKeys(ctx context.Context, keyserver string, keys []string) error {
+	args := append([]string{"--keyserver", keyserver, "--recv-keys"}, keys...)
+	cmd := exec.CommandContext(ctx, "gpg", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to import keys %s: %w", strings.Join(keys, ", "), err)
+	}
+	return nil
+}
+
+// ImportCommand returns the command that imports keys from keyserver, for
+// users to run themselves.
+func ImportCommand(keyserver string, keys []string) string {
+	return fmt.Sprintf("gpg --keyserver %s --recv-keys %s", keyserver, strings.Join(keys, " "))
+}
@@ -0,0 +1,102 @@
+package aur
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	output := `==> Verifying source file signatures with gpg...
+    tool-1.0.tar.gz ... FAILED (unknown public key 1234abcd5678ef90)
+    tool-1.0.patch ... FAILED (unknown public key 1234ABCD5678EF90)
+    tool-extra.tar.gz ... FAILED (unknown public key 0A1B2C3D4E5F60718293A4B5C6D7E8F901234567)
+==> ERROR: One or more PGP signatures could not be verified!`
+
+	want := []string{"1234ABCD5678EF90", "0A1B2C3D4E5F60718293A4B5C6D7E8F901234567"}
+	if got := UnknownKeys(output); !slices.Equal(got, want) {
+		t.Errorf("UnknownKeys() = %v, want %v", got, want)
+	}
+	if got := UnknownKeys("==> Validating source files with sha256sums...\n    tool-1.0.tar.gz ... Passed"); got != nil {
+		t.Errorf("UnknownKeys() without failures = %v", got)
+	}
+}
+
+func TestParseKeyIndex(t *testing.T) {
+	expired := strconv.FormatInt(time.Now().Add(-24*time.Hour).Unix(), 10)
+	index := strings.Join([]string{
+		"info:1:2",
+		"pub:0a1b2c3d4e5f60718293a4b5c6d7e8f901234567:1:4096:1577836800::",
+		"uid:Jane%20Doe%20%3Cjane@example.org%3E:1577836800::",
+		"uid:Jane%20Doe%20%3Cjane@work.example%3E:1577836800::",
+		"pub:FFFF0000FFFF0000FFFF0000FFFF000012345678:22:256:1600000000:" + expired + ":r",
+		"uid:Mallory:1600000000::",
+	}, "\n")
+
+	info := parseKeyIndex(strings.NewReader(index), "01234567")
+	if info.ID != "01234567" || info.Fingerprint != "0A1B2C3D4E5F60718293A4B5C6D7E8F901234567" || info.Bits != 4096 {
+		t.Errorf("parseKeyIndex() = %+v", info)
+	}
+	if !info.Created.Equal(time.Unix(1577836800, 0)) {
+		t.Errorf("Created = %v", info.Created)
+	}
+	if want := []string{"Jane Doe <jane@example.org>", "Jane Doe <jane@work.example>"}; !slices.Equal(info.UserIDs, want) {
+		t.Errorf("UserIDs = %q, want only the first key's %q", info.UserIDs, want)
+	}
+	if info.Revoked || info.Expired {
+		t.Errorf("the first key is reported revoked %v, expired %v", info.Revoked, info.Expired)
+	}
+
+	revoked := "info:1:1\npub:FFFF0000FFFF0000FFFF0000FFFF000012345678:22:256:1600000000:" + expired + ":r\n"
+	if info := parseKeyIndex(strings.NewReader(revoked), "12345678"); !info.Revoked || !info.Expired {
+		t.Errorf("parseKeyIndex() of a revoked, expired key = %+v", info)
+	}
+
+	if info := parseKeyIndex(strings.NewReader("info:1:0\n"), "12345678"); info.Found() {
+		t.Errorf("parseKeyIndex() of an empty index found %+v", info)
+	}
+}
+
+func TestKeyserverURL(t *testing.T) {
+	tests := []struct {
+		keyserver string
+		want      string
+		wantErr   bool
+	}{
+		{"hkps://keyserver.ubuntu.com", "https://keyserver.ubuntu.com", false},
+		{"hkps://keys.openpgp.org/", "https://keys.openpgp.org", false},
+		{"hkp://pgp.mit.edu", "http://pgp.mit.edu:11371", false},
+		{"hkp://pgp.mit.edu:80", "http://pgp.mit.edu:80", false},
+		{"https://keyserver.example.org", "https://keyserver.example.org", false},
+		{"ldap://keyserver.example.org", "", true},
+		{"keyserver.ubuntu.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keyserver, func(t *testing.T) {
+			got, err := keyserverURL(tt.keyserver)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("keyserverURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("keyserverURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListedKey(t *testing.T) {
+	pkgbuild := &PKGBUILD{ValidPGPKeys: []string{"0a1b2c3d4e5f6071 8293a4b5c6d7e8f901234567"}}
+
+	const fingerprint = "0A1B2C3D4E5F60718293A4B5C6D7E8F901234567"
+	for _, id := range []string{fingerprint, "C6D7E8F901234567", "0x01234567"} {
+		if got := pkgbuild.ListedKey(id); got != fingerprint {
+			t.Errorf("ListedKey(%s) = %q, want %s", id, got, fingerprint)
+		}
+	}
+	if got := pkgbuild.ListedKey("FFFF0000FFFF0000"); got != "" {
+		t.Errorf("ListedKey() of an unlisted key = %q", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -41,12 +42,19 @@ type PKGBUILD struct {
 	Source    []string
 	NoExtract []string
 
+	// Fingerprints of the keys source signatures may be made with
+	ValidPGPKeys []string
+
 	// Checksums
 	MD5Sums    []string
 	SHA256Sums []string
 	SHA512Sums []string
 	B2Sums     []string
 
+	// Sources and checksums of single architectures, from source_<arch>,
+	// sha256sums_<arch> and the like, by architecture
+	ArchSources map[string]*SourceSet
+
 	// Options
 	Options []string
 	Backup  []string
@@ -65,11 +73,26 @@ type PKGBUILD struct {
 	DangerousCommands []DangerousCommand
 }
 
+// SourceSet is a source array with its checksum arrays.
+type SourceSet struct {
+	Arch       string // "" for the sources of every architecture
+	Source     []string
+	MD5Sums    []string
+	SHA256Sums []string
+	SHA512Sums []string
+	B2Sums     []string
+}
+
+// archArrays are the arrays that can be given per architecture, as
+// <name>_<arch>
+var archArrays = []string{"source", "md5sums", "sha256sums", "sha512sums", "b2sums"}
+
 // DangerousCommand represents a potentially dangerous command in PKGBUILD.
 type DangerousCommand struct {
-	Line    int
-	Command string
-	Reason  string
+	Line     int
+	Command  string
+	Reason   string
+	Severity Severity
 }
 
 // ParsePKGBUILD parses a PKGBUILD file.
@@ -124,6 +147,7 @@ echo "provides=${provides[@]}"
 echo "replaces=${replaces[@]}"
 echo "source=${source[@]}"
 echo "noextract=${noextract[@]}"
+echo "validpgpkeys=${validpgpkeys[@]}"
 echo "options=${options[@]}"
 echo "backup=${backup[@]}"
 echo "install=$install"
@@ -131,6 +155,14 @@ echo "md5sums=${md5sums[@]}"
 echo "sha256sums=${sha256sums[@]}"
 echo "sha512sums=${sha512sums[@]}"
 echo "b2sums=${b2sums[@]}"
+for a in "${arch[@]}"; do
+	for v in source md5sums sha256sums sha512sums b2sums; do
+		n="${v}_${a}"
+		declare -p "$n" &>/dev/null || continue
+		n="$n[@]"
+		echo "${v}_${a}=${!n}"
+	done
+done
 `
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*60)
@@ -192,6 +224,8 @@ echo "b2sums=${b2sums[@]}"
 			p.Source = splitBashArray(value)
 		case "noextract":
 			p.NoExtract = splitBashArray(value)
+		case "validpgpkeys":
+			p.ValidPGPKeys = splitBashArray(value)
 		case "options":
 			p.Options = splitBashArray(value)
 		case "backup":
@@ -206,6 +240,10 @@ echo "b2sums=${b2sums[@]}"
 			p.SHA512Sums = splitBashArray(value)
 		case "b2sums":
 			p.B2Sums = splitBashArray(value)
+		default:
+			if name, arch, ok := strings.Cut(key, "_"); ok {
+				p.setArchArray(name, arch, splitBashArray(value))
+			}
 		}
 	}
 
@@ -257,6 +295,16 @@ func (p *PKGBUILD) parseWithRegex(content string) {
 		"makedepends":  regexp.MustCompile(`(?m)^makedepends=\(([^)]+)\)`),
 		"checkdepends": regexp.MustCompile(`(?m)^checkdepends=\(([^)]+)\)`),
 		"source":       regexp.MustCompile(`(?m)^source=\(([^)]+)\)`),
+		"md5sums":      regexp.MustCompile(`(?m)^md5sums=\(([^)]+)\)`),
+		"sha256sums":   regexp.MustCompile(`(?m)^sha256sums=\(([^)]+)\)`),
+		"sha512sums":   regexp.MustCompile(`(?m)^sha512sums=\(([^)]+)\)`),
+		"b2sums":       regexp.MustCompile(`(?m)^b2sums=\(([^)]+)\)`),
+		"validpgpkeys": regexp.MustCompile(`(?m)^validpgpkeys=\(([^)]+)\)`),
+	}
+
+	archArrayPattern := regexp.MustCompile(`(?m)^(` + strings.Join(archArrays, "|") + `)_(\w+)=\(([^)]+)\)`)
+	for _, match := range archArrayPattern.FindAllStringSubmatch(content, -1) {
+		p.setArchArray(match[1], match[2], parseArrayContent(match[3]))
 	}
 
 	for key, pattern := range arrayPatterns {
@@ -275,11 +323,70 @@ func (p *PKGBUILD) parseWithRegex(content string) {
 				p.CheckDepends = values
 			case "source":
 				p.Source = values
+			case "md5sums":
+				p.MD5Sums = values
+			case "sha256sums":
+				p.SHA256Sums = values
+			case "sha512sums":
+				p.SHA512Sums = values
+			case "b2sums":
+				p.B2Sums = values
+			case "validpgpkeys":
+				p.ValidPGPKeys = values
 			}
 		}
 	}
 }
 
+// setArchArray sets the array name, one of archArrays, of arch.
+func (p *PKGBUILD) setArchArray(name, arch string, values []string) {
+	if p.ArchSources == nil {
+		p.ArchSources = make(map[string]*SourceSet)
+	}
+	set, ok := p.ArchSources[arch]
+	if !ok {
+		set = &SourceSet{Arch: arch}
+	}
+
+	switch name {
+	case "source":
+		set.Source = values
+	case "md5sums":
+		set.MD5Sums = values
+	case "sha256sums":
+		set.SHA256Sums = values
+	case "sha512sums":
+		set.SHA512Sums = values
+	case "b2sums":
+		set.B2Sums = values
+	default:
+		return
+	}
+	p.ArchSources[arch] = set
+}
+
+// SourceSets returns the sources of every architecture followed by those
+// of single architectures, sorted by architecture.
+func (p *PKGBUILD) SourceSets() []SourceSet {
+	sets := []SourceSet{{
+		Source:     p.Source,
+		MD5Sums:    p.MD5Sums,
+		SHA256Sums: p.SHA256Sums,
+		SHA512Sums: p.SHA512Sums,
+		B2Sums:     p.B2Sums,
+	}}
+
+	arches := make([]string, 0, len(p.ArchSources))
+	for arch := range p.ArchSources {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	for _, arch := range arches {
+		sets = append(sets, *p.ArchSources[arch])
+	}
+	return sets
+}
+
 // detectFunctions checks which build functions are defined.
 func (p *PKGBUILD) detectFunctions(content string) {
 	p.HasPrepare = regexp.MustCompile(`(?m)^prepare\s*\(\)`).MatchString(content)
@@ -289,29 +396,32 @@ func (p *PKGBUILD) detectFunctions(content string) {
 		regexp.MustCompile(`(?m)^package_\w+\s*\(\)`).MatchString(content)
 }
 
+// dangerousPatterns are commands that have no place in a PKGBUILD, with how
+// much risk each carries.
+var dangerousPatterns = []struct {
+	pattern  *regexp.Regexp
+	reason   string
+	severity Severity
+}{
+	{regexp.MustCompile(`curl\s+[^|]*\|\s*(ba)?sh`), "Downloads and executes script", SeverityCritical},
+	{regexp.MustCompile(`wget\s+[^|]*\|\s*(ba)?sh`), "Downloads and executes script", SeverityCritical},
+	{regexp.MustCompile(`rm\s+-rf\s+/[^$]`), "Recursive deletion from root", SeverityCritical},
+	{regexp.MustCompile(`chmod\s+777`), "World-writable permissions", SeverityMedium},
+	{regexp.MustCompile(`eval\s+`), "Dynamic code execution", SeverityMedium},
+	{regexp.MustCompile(`\$\([^)]*curl[^)]*\)`), "Command substitution with curl", SeverityHigh},
+	{regexp.MustCompile(`\$\([^)]*wget[^)]*\)`), "Command substitution with wget", SeverityHigh},
+	{regexp.MustCompile(`sudo\s+`), "Explicit sudo usage", SeverityHigh},
+	{regexp.MustCompile(`/etc/passwd`), "Accesses passwd file", SeverityHigh},
+	{regexp.MustCompile(`/etc/shadow`), "Accesses shadow file", SeverityCritical},
+	{regexp.MustCompile(`\.ssh/`), "Accesses SSH directory", SeverityHigh},
+	{regexp.MustCompile(`nc\s+-[el]`), "Netcat listener", SeverityCritical},
+	{regexp.MustCompile(`ncat\s+-[el]`), "Ncat listener", SeverityCritical},
+	{regexp.MustCompile(`python.*-c.*socket`), "Python socket code", SeverityHigh},
+	{regexp.MustCompile(`base64\s+-d`), "Base64 decoding (obfuscation)", SeverityHigh},
+}
+
 // scanForDangerousCommands looks for potentially dangerous commands.
 func (p *PKGBUILD) scanForDangerousCommands(content string) {
-	dangerousPatterns := []struct {
-		pattern *regexp.Regexp
-		reason  string
-	}{
-		{regexp.MustCompile(`curl\s+[^|]*\|\s*(ba)?sh`), "Downloads and executes script"},
-		{regexp.MustCompile(`wget\s+[^|]*\|\s*(ba)?sh`), "Downloads and executes script"},
-		{regexp.MustCompile(`rm\s+-rf\s+/[^$]`), "Recursive deletion from root"},
-		{regexp.MustCompile(`chmod\s+777`), "World-writable permissions"},
-		{regexp.MustCompile(`eval\s+`), "Dynamic code execution"},
-		{regexp.MustCompile(`\$\([^)]*curl[^)]*\)`), "Command substitution with curl"},
-		{regexp.MustCompile(`\$\([^)]*wget[^)]*\)`), "Command substitution with wget"},
-		{regexp.MustCompile(`sudo\s+`), "Explicit sudo usage"},
-		{regexp.MustCompile(`/etc/passwd`), "Accesses passwd file"},
-		{regexp.MustCompile(`/etc/shadow`), "Accesses shadow file"},
-		{regexp.MustCompile(`\.ssh/`), "Accesses SSH directory"},
-		{regexp.MustCompile(`nc\s+-[el]`), "Netcat listener"},
-		{regexp.MustCompile(`ncat\s+-[el]`), "Ncat listener"},
-		{regexp.MustCompile(`python.*-c.*socket`), "Python socket code"},
-		{regexp.MustCompile(`base64\s+-d`), "Base64 decoding (obfuscation)"},
-	}
-
 	lines := strings.Split(content, "\n")
 	for lineNum, line := range lines {
 		// Skip comments
@@ -323,9 +433,10 @@ func (p *PKGBUILD) scanForDangerousCommands(content string) {
 		for _, dp := range dangerousPatterns {
 			if dp.pattern.MatchString(line) {
 				p.DangerousCommands = append(p.DangerousCommands, DangerousCommand{
-					Line:    lineNum + 1,
-					Command: strings.TrimSpace(line),
-					Reason:  dp.reason,
+					Line:     lineNum + 1,
+					Command:  strings.TrimSpace(line),
+					Reason:   dp.reason,
+					Severity: dp.severity,
 				})
 			}
 		}
@@ -393,6 +504,19 @@ func (p *PKGBUILD) HasDangerousCommands() bool {
 	return len(p.DangerousCommands) > 0
 }
 
+// ListedKey returns the fingerprint in the PKGBUILD's validpgpkeys of the
+// key with id, a key ID or fingerprint, or "" if it is not listed.
+func (p *PKGBUILD) ListedKey(id string) string {
+	id = strings.ToUpper(strings.TrimPrefix(id, "0x"))
+	for _, key := range p.ValidPGPKeys {
+		key = strings.ToUpper(strings.ReplaceAll(key, " ", ""))
+		if strings.HasSuffix(key, id) {
+			return key
+		}
+	}
+	return ""
+}
+
 // SourceURLs returns the URLs from the source array.
 func (p *PKGBUILD) SourceURLs() []string {
 	var urls []string
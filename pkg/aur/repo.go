@@ -0,0 +1,443 @@
+package aur
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRepoName is the name of the repository built packages are
+// published to.
+const DefaultRepoName = "poxy-aur"
+
+// RepoPackage is a package in a repository database.
+type RepoPackage struct {
+	Name      string    `json:"name"`
+	Base      string    `json:"base"`
+	Version   string    `json:"version"`
+	Arch      string    `json:"arch"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	BuildDate time.Time `json:"build_date"`
+}
+
+// LocalRepo is a pacman repository of built AUR packages. Every version
+// added is kept in its directory; the database lists the latest.
+type LocalRepo struct {
+	dir        string
+	name       string
+	signingKey string
+}
+
+// NewLocalRepo returns the repository name in dir. Empty name uses
+// DefaultRepoName. signingKey is the GPG key packages are signed with as
+// they are added, if makepkg did not sign them; other machines only
+// install signed packages.
+func NewLocalRepo(dir, name, signingKey string) *LocalRepo {
+	if name == "" {
+		name = DefaultRepoName
+	}
+	return &LocalRepo{dir: dir, name: name, signingKey: signingKey}
+}
+
+// SigningKey returns the key packages are signed with, if any.
+func (r *LocalRepo) SigningKey() string {
+	return r.signingKey
+}
+
+// Dir returns the directory of the repository.
+func (r *LocalRepo) Dir() string {
+	return r.dir
+}
+
+// Name returns the name of the repository.
+func (r *LocalRepo) Name() string {
+	return r.name
+}
+
+// DBPath returns the path of the repository database.
+func (r *LocalRepo) DBPath() string {
+	return filepath.Join(r.dir, r.name+".db.tar.gz")
+}
+
+// Add copies the package files into the repository, with their signatures
+// if any, signs those without one when a signing key is set, and adds them
+// to the database with repo-add.
+func (r *LocalRepo) Add(ctx context.Context, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if _, err := exec.LookPath("repo-add"); err != nil {
+		return fmt.Errorf("repo-add not found: %w", err)
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create repository directory: %w", err)
+	}
+
+	var added []string
+	for _, file := range files {
+		dest := filepath.Join(r.dir, filepath.Base(file))
+		if err := copyFile(file, dest); err != nil {
+			return fmt.Errorf("failed to copy %s into the repository: %w", filepath.Base(file), err)
+		}
+		if _, err := os.Stat(file + ".sig"); err == nil {
+			if err := copyFile(file+".sig", dest+".sig"); err != nil {
+				return fmt.Errorf("failed to copy the signature of %s: %w", filepath.Base(file), err)
+			}
+		} else if r.signingKey != "" {
+			if err := signPackage(ctx, dest, r.signingKey); err != nil {
+				return err
+			}
+		}
+		added = append(added, dest)
+	}
+
+	args := append([]string{"--quiet", r.DBPath()}, added...)
+	out, err := exec.CommandContext(ctx, "repo-add", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("repo-add failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Packages returns the packages the database lists, by name. A repository
+// nothing was added to yet is empty.
+func (r *LocalRepo) Packages() ([]RepoPackage, error) {
+	f, err := os.Open(r.DBPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseRepoDB(f)
+}
+
+// Files returns the package files kept in the repository, including older
+// versions the database no longer lists.
+func (r *LocalRepo) Files() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(r.dir, "*.pkg.tar*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, match := range matches {
+		if !strings.HasSuffix(match, ".sig") {
+			files = append(files, match)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Handler serves the repository over HTTP, for pacman and the RemoteRepo
+// of other machines.
+func (r *LocalRepo) Handler() http.Handler {
+	return http.FileServer(http.Dir(r.dir))
+}
+
+// PacmanConf returns the pacman.conf section that makes pacman use the
+// repository served at server. pacman requires the packages to be signed
+// by a key its keyring trusts, such as one added with pacman-key
+// --lsign-key.
+func (r *LocalRepo) PacmanConf(server string) string {
+	return fmt.Sprintf("[%s]\nSigLevel = Required DatabaseOptional\nServer = %s\n", r.name, strings.TrimSuffix(server, "/"))
+}
+
+// signPackage writes the detached binary signature pacman expects,
+// file.sig, made with key.
+func signPackage(ctx context.Context, file, key string) error {
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--yes", "--local-user", key,
+		"--detach-sign", "--output", file+".sig", file)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to sign %s with key %s: %w", filepath.Base(file), key, err)
+	}
+	return nil
+}
+
+// RemoteRepo is a repository of built AUR packages served by another
+// machine, such as with poxy aur repo serve.
+type RemoteRepo struct {
+	url        string
+	name       string
+	key        string
+	httpClient *http.Client
+}
+
+// NewRemoteRepo returns the repository name served at url. Empty name uses
+// DefaultRepoName. Packages are only downloaded if signed by key, a GPG
+// key ID or fingerprint in the user's keyring.
+func NewRemoteRepo(url, name, key string) *RemoteRepo {
+	if name == "" {
+		name = DefaultRepoName
+	}
+	return &RemoteRepo{
+		url:        strings.TrimSuffix(url, "/"),
+		name:       name,
+		key:        key,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// URL returns where the repository is served.
+func (r *RemoteRepo) URL() string {
+	return r.url
+}
+
+// Packages downloads the database and returns the packages it lists.
+func (r *RemoteRepo) Packages(ctx context.Context) ([]RepoPackage, error) {
+	resp, err := r.get(ctx, r.name+".db.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return parseRepoDB(resp.Body)
+}
+
+// Download saves the file of pkg into dir and returns its path, after
+// checking it against the checksum in the database and its detached
+// signature against the repository's key. The database comes from the same
+// server, so only the signature vouches for the package; packages without
+// a checksum or signature, or without a key to check them with, are
+// refused.
+func (r *RemoteRepo) Download(ctx context.Context, pkg RepoPackage, dir string) (string, error) {
+	if r.key == "" {
+		return "", fmt.Errorf("no repo_key is set to verify the packages of %s with", r.url)
+	}
+	if pkg.SHA256 == "" {
+		return "", fmt.Errorf("the repository has no checksum for %s", pkg.Filename)
+	}
+
+	resp, err := r.get(ctx, pkg.Filename)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filepath.Base(pkg.Filename))
+	f, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", pkg.Filename, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != pkg.SHA256 {
+		return "", fmt.Errorf("%s does not match its checksum in the repository", pkg.Filename)
+	}
+
+	sig, err := r.downloadSignature(ctx, pkg, dir)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(sig)
+	if err := verifyDetached(ctx, sig, f.Name(), r.key); err != nil {
+		return "", fmt.Errorf("the signature of %s does not verify against %s: %w", pkg.Filename, r.key, err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// downloadSignature saves the detached signature of pkg into a temporary
+// file in dir and returns its path.
+func (r *RemoteRepo) downloadSignature(ctx context.Context, pkg RepoPackage, dir string) (string, error) {
+	resp, err := r.get(ctx, pkg.Filename+".sig")
+	if err != nil {
+		return "", fmt.Errorf("%s is not signed: %w", pkg.Filename, err)
+	}
+	defer resp.Body.Close()
+
+	f, err := os.CreateTemp(dir, ".download-*.sig")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, io.LimitReader(resp.Body, 1<<16))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to download the signature of %s: %w", pkg.Filename, err)
+	}
+	return f.Name(), nil
+}
+
+// get requests a file of the repository.
+func (r *RemoteRepo) get(ctx context.Context, file string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+"/"+file, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("repository error for %s (status %d)", file, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// FindPrebuilt returns the packages of base at version built for arch, or
+// for any architecture, in pkgs, or nil if the repository does not have
+// that version for arch.
+func FindPrebuilt(pkgs []RepoPackage, base, version, arch string) []RepoPackage {
+	var found []RepoPackage
+	for _, pkg := range pkgs {
+		pkgBase := pkg.Base
+		if pkgBase == "" {
+			pkgBase = pkg.Name
+		}
+		if pkg.Arch != arch && pkg.Arch != "any" {
+			continue
+		}
+		if pkgBase == base && pkg.Version == version {
+			found = append(found, pkg)
+		}
+	}
+	return found
+}
+
+// HostArch returns the pacman architecture of this machine, such as x86_64.
+func HostArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i686"
+	case "arm":
+		return "armv7h"
+	}
+	return runtime.GOARCH
+}
+
+// parseRepoDB reads the packages of a gzipped repository database, where
+// each package has a desc file of sections such as:
+//
+//	%NAME%
+//	foo
+//
+//	%VERSION%
+//	1.0-1
+func parseRepoDB(r io.Reader) ([]RepoPackage, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository database: %w", err)
+	}
+	defer gz.Close()
+
+	var pkgs []RepoPackage
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository database: %w", err)
+		}
+		if filepath.Base(hdr.Name) != "desc" {
+			continue
+		}
+		if pkg := parseDesc(tr); pkg.Name != "" {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs, nil
+}
+
+// parseDesc reads the desc file of a package in a repository database.
+func parseDesc(r io.Reader) RepoPackage {
+	var pkg RepoPackage
+	var section string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			section = ""
+			continue
+		}
+		if strings.HasPrefix(line, "%") && strings.HasSuffix(line, "%") {
+			section = line
+			continue
+		}
+		switch section {
+		case "%NAME%":
+			pkg.Name = line
+		case "%BASE%":
+			pkg.Base = line
+		case "%VERSION%":
+			pkg.Version = line
+		case "%ARCH%":
+			pkg.Arch = line
+		case "%FILENAME%":
+			pkg.Filename = line
+		case "%CSIZE%":
+			pkg.Size, _ = strconv.ParseInt(line, 10, 64) //nolint:errcheck
+		case "%SHA256SUM%":
+			pkg.SHA256 = line
+		case "%BUILDDATE%":
+			pkg.BuildDate = unixField(line)
+		}
+	}
+	return pkg
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
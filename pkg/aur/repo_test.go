@@ -0,0 +1,176 @@
+package aur
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPackageFile = "foo-1.0-1-x86_64.pkg.tar.zst"
+
+// serveRepo serves files by name, answering 404 for others.
+func serveRepo(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data) //nolint:errcheck
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRemoteRepoDownloadChecksum(t *testing.T) {
+	content := []byte("package contents")
+	server := serveRepo(t, map[string][]byte{testPackageFile: content})
+	repo := NewRemoteRepo(server.URL, "", "0123456789ABCDEF")
+
+	tests := []struct {
+		name   string
+		sha256 string
+		want   string
+	}{
+		{name: "missing hash", sha256: "", want: "no checksum"},
+		{name: "hash mismatch", sha256: sha256Hex([]byte("something else")), want: "does not match its checksum"},
+		{name: "unsigned", sha256: sha256Hex(content), want: "is not signed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pkg := RepoPackage{Name: "foo", Filename: testPackageFile, SHA256: tt.sha256}
+
+			path, err := repo.Download(context.Background(), pkg, dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Download() = %q, %v; want an error containing %q", path, err, tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(dir, testPackageFile)); !os.IsNotExist(err) {
+				t.Error("a refused package should not be left in the directory")
+			}
+		})
+	}
+}
+
+func TestRemoteRepoDownloadWithoutKey(t *testing.T) {
+	content := []byte("package contents")
+	server := serveRepo(t, map[string][]byte{testPackageFile: content, testPackageFile + ".sig": []byte("sig")})
+	repo := NewRemoteRepo(server.URL, "", "")
+
+	pkg := RepoPackage{Name: "foo", Filename: testPackageFile, SHA256: sha256Hex(content)}
+	if _, err := repo.Download(context.Background(), pkg, t.TempDir()); err == nil || !strings.Contains(err.Error(), "repo_key") {
+		t.Errorf("Download() without a key = %v, want a repo_key error", err)
+	}
+}
+
+func TestPacmanConf(t *testing.T) {
+	conf := NewLocalRepo(t.TempDir(), "", "").PacmanConf("http://buildbox:8080/")
+
+	want := "[poxy-aur]\nSigLevel = Required DatabaseOptional\nServer = http://buildbox:8080\n"
+	if conf != want {
+		t.Errorf("PacmanConf() = %q, want %q", conf, want)
+	}
+	if strings.Contains(conf, "TrustAll") {
+		t.Error("PacmanConf() should not trust unsigned packages")
+	}
+}
+
+func TestRemoteRepoPackages(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	desc := "%FILENAME%\n" + testPackageFile + "\n\n%NAME%\nfoo\n\n%BASE%\nfoo\n\n%VERSION%\n1.0-1\n\n%ARCH%\nx86_64\n\n%SHA256SUM%\nabc\n"
+	tw.WriteHeader(&tar.Header{Name: "foo-1.0-1/desc", Mode: 0o644, Size: int64(len(desc))}) //nolint:errcheck
+	tw.Write([]byte(desc))                                                                   //nolint:errcheck
+	tw.Close()
+	gz.Close()
+
+	server := serveRepo(t, map[string][]byte{DefaultRepoName + ".db.tar.gz": buf.Bytes()})
+	pkgs, err := NewRemoteRepo(server.URL, "", "key").Packages(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Filename != testPackageFile || pkgs[0].SHA256 != "abc" {
+		t.Fatalf("unexpected packages: %+v", pkgs)
+	}
+
+	if found := FindPrebuilt(pkgs, "foo", "1.0-1", "x86_64"); len(found) != 1 {
+		t.Errorf("FindPrebuilt() = %+v", found)
+	}
+	if found := FindPrebuilt(pkgs, "foo", "1.0-2", "x86_64"); found != nil {
+		t.Errorf("FindPrebuilt() of another version = %+v", found)
+	}
+}
+
+func TestFindPrebuiltArch(t *testing.T) {
+	pkgs := []RepoPackage{
+		{Name: "foo", Base: "foo", Version: "1.0-1", Arch: "x86_64", Filename: "foo-1.0-1-x86_64.pkg.tar.zst"},
+		{Name: "foo-docs", Base: "foo", Version: "1.0-1", Arch: "any", Filename: "foo-docs-1.0-1-any.pkg.tar.zst"},
+		{Name: "bar", Base: "bar", Version: "2.0-1", Arch: "aarch64", Filename: "bar-2.0-1-aarch64.pkg.tar.zst"},
+	}
+
+	tests := []struct {
+		name    string
+		base    string
+		version string
+		arch    string
+		want    []string
+	}{
+		{"host architecture and any", "foo", "1.0-1", "x86_64", []string{"foo", "foo-docs"}},
+		{"only any on another host", "foo", "1.0-1", "aarch64", []string{"foo-docs"}},
+		{"built for another host", "bar", "2.0-1", "x86_64", nil},
+		{"built for this host", "bar", "2.0-1", "aarch64", []string{"bar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, pkg := range FindPrebuilt(pkgs, tt.base, tt.version, tt.arch) {
+				names = append(names, pkg.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("FindPrebuilt(%s, %s, %s) = %v, want %v", tt.base, tt.version, tt.arch, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitAURDeps(t *testing.T) {
+	// A pacman that finds only glibc and openssl in the sync databases
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncase \"$2\" in glibc|openssl) exit 0 ;; esac\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "pacman"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client, _ := newCachedClient(t, time.Hour)
+	b := NewBuilder(t.TempDir())
+	b.client = client
+
+	repoDeps, aurDeps := b.splitAURDeps(context.Background(), []string{"glibc", "yay>=12", "openssl>=3", "no-such-dep"})
+	if want := []string{"glibc", "openssl>=3", "no-such-dep"}; !slices.Equal(repoDeps, want) {
+		t.Errorf("repository dependencies = %v, want %v", repoDeps, want)
+	}
+	if want := []string{"yay"}; !slices.Equal(aurDeps, want) {
+		t.Errorf("AUR dependencies = %v, want %v", aurDeps, want)
+	}
+}
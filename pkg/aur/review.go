@@ -45,7 +45,7 @@ func (r *Reviewer) SetColors(enabled bool) {
 func (r *Reviewer) Review(pkg *Package, pkgbuild *PKGBUILD) bool {
 	for {
 		r.displayReview(pkg, pkgbuild)
-		result := r.promptAction()
+		result := r.promptAction("[v]iew full PKGBUILD")
 
 		switch result {
 		case ReviewAccept:
@@ -64,7 +64,6 @@ func (r *Reviewer) displayReview(pkg *Package, pkgbuild *PKGBUILD) {
 	labelColor := color.New(color.FgWhite, color.Bold)
 	valueColor := color.New(color.FgWhite)
 	warnColor := color.New(color.FgYellow, color.Bold)
-	errorColor := color.New(color.FgRed, color.Bold)
 	successColor := color.New(color.FgGreen)
 
 	fmt.Println()
@@ -135,12 +134,13 @@ func (r *Reviewer) displayReview(pkg *Package, pkgbuild *PKGBUILD) {
 	successColor.Printf("  %s\n", strings.Join(funcs, ", "))
 	fmt.Println()
 
-	// Security warnings
-	if pkgbuild.HasDangerousCommands() {
-		errorColor.Print("SECURITY WARNINGS:\n")
-		for _, cmd := range pkgbuild.DangerousCommands {
-			errorColor.Printf("  Line %d: %s\n", cmd.Line, cmd.Reason)
-			valueColor.Printf("    %s\n", truncate(cmd.Command, 70))
+	// Security analysis
+	analysis := Analyze(pkgbuild)
+	if len(analysis.Findings) > 0 {
+		severityColor(analysis.Level()).Printf("SECURITY ANALYSIS: %s\n", analysis.Summary())
+		for _, f := range analysis.Findings {
+			severityColor(f.Severity).Printf("  [%s] %s%s\n", f.Severity, findingLocation(f), f.Reason)
+			valueColor.Printf("    %s\n", truncate(f.Detail, 70))
 		}
 		fmt.Println()
 	} else {
@@ -149,6 +149,25 @@ func (r *Reviewer) displayReview(pkg *Package, pkgbuild *PKGBUILD) {
 	}
 }
 
+// severityColor returns the color findings of a severity are shown in.
+func severityColor(s Severity) *color.Color {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return color.New(color.FgRed, color.Bold)
+	case SeverityMedium:
+		return color.New(color.FgYellow, color.Bold)
+	}
+	return color.New(color.FgWhite)
+}
+
+// findingLocation returns the "Line N: " prefix of a finding on a line.
+func findingLocation(f Finding) string {
+	if f.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Line %d: ", f.Line)
+}
+
 // displayFullPKGBUILD shows the full PKGBUILD content.
 func (r *Reviewer) displayFullPKGBUILD(pkgbuild *PKGBUILD) {
 	titleColor := color.New(color.FgCyan, color.Bold)
@@ -169,13 +188,144 @@ func (r *Reviewer) displayFullPKGBUILD(pkgbuild *PKGBUILD) {
 	_, _ = r.reader.ReadString('\n') //nolint:errcheck
 }
 
-// promptAction prompts the user for their decision.
-func (r *Reviewer) promptAction() ReviewResult {
+// ReviewScriptlets displays the install scriptlets of a package and prompts
+// for user action. Returns true if the user accepts, false if they reject.
+func (r *Reviewer) ReviewScriptlets(pkg *Package, scripts []*InstallScript) bool {
+	for {
+		r.displayScriptlets(pkg, scripts)
+		result := r.promptAction("[v]iew scriptlets")
+
+		switch result {
+		case ReviewAccept:
+			return true
+		case ReviewReject:
+			return false
+		case ReviewViewFull:
+			r.displayFullScriptlets(scripts)
+		}
+	}
+}
+
+// displayScriptlets shows the hooks and findings of install scriptlets.
+func (r *Reviewer) displayScriptlets(pkg *Package, scripts []*InstallScript) {
+	titleColor := color.New(color.FgCyan, color.Bold)
+	labelColor := color.New(color.FgWhite, color.Bold)
+	valueColor := color.New(color.FgWhite)
+	warnColor := color.New(color.FgYellow, color.Bold)
+	successColor := color.New(color.FgGreen)
+
+	fmt.Println()
+	titleColor.Printf("=== Install Scriptlets: %s ===\n", pkg.PackageBase)
+	fmt.Println()
+	warnColor.Print("Pacman runs these hooks as root on the host, outside the build sandbox.\n")
+	fmt.Println()
+
+	for _, script := range scripts {
+		labelColor.Printf("%s:\n", script.Name)
+		if len(script.Hooks) > 0 {
+			valueColor.Printf("  Hooks:       %s\n", strings.Join(script.Hooks, ", "))
+		} else {
+			valueColor.Print("  Hooks:       none\n")
+		}
+
+		if len(script.Analysis.Findings) > 0 {
+			severityColor(script.Analysis.Level()).Printf("  %s\n", script.Analysis.Summary())
+			for _, f := range script.Analysis.Findings {
+				severityColor(f.Severity).Printf("  [%s] %s%s\n", f.Severity, findingLocation(f), f.Reason)
+				valueColor.Printf("    %s\n", truncate(f.Detail, 70))
+			}
+		} else {
+			successColor.Print("  No obvious security issues detected.\n")
+		}
+		fmt.Println()
+	}
+}
+
+// displayFullScriptlets shows the full content of install scriptlets.
+func (r *Reviewer) displayFullScriptlets(scripts []*InstallScript) {
+	titleColor := color.New(color.FgCyan, color.Bold)
+	lineColor := color.New(color.FgYellow)
+
+	for _, script := range scripts {
+		fmt.Println()
+		titleColor.Printf("=== %s ===\n", script.Name)
+		fmt.Println()
+
+		lines := strings.Split(script.Content, "\n")
+		for i, line := range lines {
+			lineColor.Printf("%4d | ", i+1)
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	fmt.Print("Press Enter to continue...")
+	_, _ = r.reader.ReadString('\n') //nolint:errcheck
+}
+
+// ReviewKeys displays the unknown PGP keys a build of pkg reported, as the
+// keyserver knows them, and asks whether to import them. Returns true if
+// the user accepts.
+func (r *Reviewer) ReviewKeys(pkg *Package, keys []*KeyInfo) bool {
+	titleColor := color.New(color.FgCyan, color.Bold)
+	labelColor := color.New(color.FgWhite, color.Bold)
+	valueColor := color.New(color.FgWhite)
+	warnColor := color.New(color.FgYellow, color.Bold)
+	successColor := color.New(color.FgGreen)
+	promptColor := color.New(color.FgGreen, color.Bold)
+
+	fmt.Println()
+	titleColor.Printf("=== Unknown PGP Keys: %s ===\n", pkg.PackageBase)
+	fmt.Println()
+	valueColor.Print("The sources are signed with keys that are not in your keyring.\n")
+	fmt.Println()
+
+	for _, key := range keys {
+		labelColor.Printf("%s:\n", key.ID)
+		switch {
+		case key.Err != nil:
+			warnColor.Printf("  Could not look the key up: %v\n", key.Err)
+		case !key.Found():
+			warnColor.Print("  WARNING: The keyserver does not have this key\n")
+		default:
+			valueColor.Printf("  Fingerprint: %s\n", key.Fingerprint)
+			for _, uid := range key.UserIDs {
+				valueColor.Printf("  User ID:     %s\n", uid)
+			}
+			if !key.Created.IsZero() {
+				valueColor.Printf("  Created:     %s\n", key.Created.Format("2006-01-02"))
+			}
+			if key.Revoked {
+				warnColor.Print("  WARNING: The key is revoked\n")
+			}
+			if key.Expired {
+				warnColor.Print("  WARNING: The key is expired\n")
+			}
+		}
+		if key.Listed {
+			successColor.Print("  Listed in the PKGBUILD's validpgpkeys\n")
+		} else {
+			warnColor.Print("  WARNING: Not listed in the PKGBUILD's validpgpkeys\n")
+		}
+		fmt.Println()
+	}
+
+	promptColor.Print("Import the listed keys with gpg --recv-keys and build again? ")
+	valueColor.Print("[y/N]: ")
+
+	input, _ := r.reader.ReadString('\n') //nolint:errcheck
+	input = strings.ToLower(strings.TrimSpace(input))
+	return input == "y" || input == "yes"
+}
+
+// promptAction prompts the user for their decision. view is the label of
+// the option that shows the full content.
+func (r *Reviewer) promptAction(view string) ReviewResult {
 	promptColor := color.New(color.FgGreen, color.Bold)
 	optionColor := color.New(color.FgWhite)
 
 	promptColor.Print("Action: ")
-	optionColor.Print("[a]ccept and build, [v]iew full PKGBUILD, [r]eject and abort: ")
+	optionColor.Printf("[a]ccept and build, %s, [r]eject and abort: ", view)
 
 	input, _ := r.reader.ReadString('\n') //nolint:errcheck
 	input = strings.ToLower(strings.TrimSpace(input))
@@ -189,7 +339,7 @@ func (r *Reviewer) promptAction() ReviewResult {
 		return ReviewReject
 	default:
 		fmt.Println("Invalid option. Please try again.")
-		return r.promptAction()
+		return r.promptAction(view)
 	}
 }
 
@@ -215,34 +365,29 @@ func CreateReviewCallback(enabled bool) func(*Package, *PKGBUILD) bool {
 
 // FormatSecuritySummary returns a one-line security summary.
 func FormatSecuritySummary(pkgbuild *PKGBUILD) string {
-	if pkgbuild.HasDangerousCommands() {
-		return fmt.Sprintf("WARNING: %d potentially dangerous command(s) detected",
-			len(pkgbuild.DangerousCommands))
-	}
-	return "No obvious security issues detected"
+	return Analyze(pkgbuild).Summary()
 }
 
 // PrintSecurityReport prints a detailed security report.
 func PrintSecurityReport(pkgbuild *PKGBUILD) {
 	titleColor := color.New(color.FgCyan, color.Bold)
-	errorColor := color.New(color.FgRed, color.Bold)
-	warnColor := color.New(color.FgYellow)
+	detailColor := color.New(color.FgWhite)
 	successColor := color.New(color.FgGreen)
 
 	titleColor.Printf("\nSecurity Report: %s\n", pkgbuild.Name())
 	fmt.Println(strings.Repeat("-", 50))
 
-	if !pkgbuild.HasDangerousCommands() {
-		successColor.Println("No potentially dangerous commands detected.")
+	analysis := Analyze(pkgbuild)
+	if len(analysis.Findings) == 0 {
+		successColor.Println("No obvious security issues detected.")
 		fmt.Println()
 		return
 	}
 
-	errorColor.Printf("Found %d potentially dangerous command(s):\n\n",
-		len(pkgbuild.DangerousCommands))
+	severityColor(analysis.Level()).Printf("%s:\n\n", analysis.Summary())
 
-	for i, cmd := range pkgbuild.DangerousCommands {
-		errorColor.Printf("%d. Line %d: %s\n", i+1, cmd.Line, cmd.Reason)
-		warnColor.Printf("   Command: %s\n\n", truncate(cmd.Command, 60))
+	for i, f := range analysis.Findings {
+		severityColor(f.Severity).Printf("%d. [%s] %s%s\n", i+1, f.Severity, findingLocation(f), f.Reason)
+		detailColor.Printf("   %s\n\n", truncate(f.Detail, 60))
 	}
 }
@@ -0,0 +1,118 @@
+package aur
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CategoryScriptlet marks findings in an install scriptlet.
+const CategoryScriptlet = "scriptlet"
+
+// InstallScript is a .install file. Pacman runs its hooks as root on the
+// host, outside any build sandbox, when the package is installed, upgraded
+// or removed.
+type InstallScript struct {
+	Name     string   // File name, e.g. "docker.install"
+	Content  string   // Raw content
+	Hooks    []string // Hooks it defines, e.g. "post_install"
+	Analysis *Analysis
+}
+
+var (
+	scriptHookRe      = regexp.MustCompile(`^\s*(?:function\s+)?((?:pre|post)_(?:install|upgrade|remove))\s*\(\)`)
+	scriptInstallRe   = regexp.MustCompile(`(?m)^\s*install=["']?([^"'\s]+)`)
+	scriptSystemctlRe = regexp.MustCompile(`\bsystemctl\s+(enable|start|restart)\b`)
+)
+
+// InstallScripts returns the install scriptlets of a PKGBUILD: the files
+// named by install= (globally or in a package function of a split package)
+// and any other .install file next to it.
+func InstallScripts(pkgbuild *PKGBUILD) ([]*InstallScript, error) {
+	dir := filepath.Dir(pkgbuild.Path)
+
+	names := make(map[string]bool)
+	if pkgbuild.Install != "" {
+		names[filepath.Base(pkgbuild.Install)] = true
+	}
+	for _, m := range scriptInstallRe.FindAllStringSubmatch(pkgbuild.RawContent, -1) {
+		name := strings.NewReplacer("${pkgname}", pkgbuild.Name(), "$pkgname", pkgbuild.Name(),
+			"${pkgbase}", pkgbuild.PkgBase, "$pkgbase", pkgbuild.PkgBase).Replace(m[1])
+		names[filepath.Base(name)] = true
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.install"))
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		names[filepath.Base(match)] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var scripts []*InstallScript
+	for _, name := range sorted {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			// Names with unexpanded variables do not exist as such
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, ParseInstallScript(name, string(content)))
+	}
+	return scripts, nil
+}
+
+// ParseInstallScript finds the hooks of an install scriptlet and analyzes
+// them with the PKGBUILD's dangerous command patterns. Downloads weigh more
+// than in a build function, since hooks run as root; starting services is
+// common in hooks and weighs less.
+func ParseInstallScript(name, content string) *InstallScript {
+	script := &InstallScript{Name: name, Content: content, Analysis: &Analysis{}}
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if m := scriptHookRe.FindStringSubmatch(line); m != nil {
+			script.Hooks = append(script.Hooks, m[1])
+			continue
+		}
+
+		severity, reason, ok := scriptletRisk(line)
+		if !ok {
+			continue
+		}
+		script.Analysis.add(Finding{Severity: severity, Category: CategoryScriptlet, Line: i + 1, Detail: trimmed, Reason: reason})
+	}
+
+	sort.SliceStable(script.Analysis.Findings, func(i, j int) bool {
+		return script.Analysis.Findings[i].Severity > script.Analysis.Findings[j].Severity
+	})
+	return script
+}
+
+// scriptletRisk returns the risk of a scriptlet line, if it has one.
+func scriptletRisk(line string) (Severity, string, bool) {
+	for _, dp := range dangerousPatterns {
+		if dp.pattern.MatchString(line) {
+			return dp.severity, dp.reason, true
+		}
+	}
+	switch {
+	case networkRe.MatchString(line):
+		return SeverityCritical, "Downloads while installing, as root", true
+	case scriptSystemctlRe.MatchString(line):
+		return SeverityMedium, "Starts or enables services while installing", true
+	}
+	return 0, "", false
+}
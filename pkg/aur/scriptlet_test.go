@@ -0,0 +1,192 @@
+package aur
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseInstallScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantHooks []string
+		want      []string
+	}{
+		{
+			name: "clean",
+			content: `post_install() {
+	echo "Run tool --init to get started"
+}
+
+function post_upgrade() {
+	post_install
+}
+`,
+			wantHooks: []string{"post_install", "post_upgrade"},
+		},
+		{
+			name: "starts a service",
+			content: `post_install() {
+	systemctl enable --now tool.service
+}
+`,
+			wantHooks: []string{"post_install"},
+			want:      []string{"scriptlet: Starts or enables services while installing"},
+		},
+		{
+			name: "downloads as root, most severe first",
+			content: `pre_remove() {
+	chmod 777 /opt/tool
+}
+post_install() {
+	curl -o /opt/tool/data https://example.org/data
+}
+`,
+			wantHooks: []string{"pre_remove", "post_install"},
+			want: []string{
+				"scriptlet: Downloads while installing, as root",
+				"scriptlet: World-writable permissions",
+			},
+		},
+		{
+			name: "dangerous commands and comments",
+			content: `post_install() {
+	# rm -rf /usr is only mentioned here
+	curl https://example.org/setup.sh | sh
+}
+`,
+			wantHooks: []string{"post_install"},
+			want:      []string{"scriptlet: Downloads and executes script"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := ParseInstallScript("tool.install", tt.content)
+			if !slices.Equal(script.Hooks, tt.wantHooks) {
+				t.Errorf("hooks = %v, want %v", script.Hooks, tt.wantHooks)
+			}
+			if got := findingReasons(script.Analysis); !slices.Equal(got, tt.want) {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallScripts(t *testing.T) {
+	const header = "pkgbase=tool\npkgname=(tool tool-docs)\npkgver=1.0\npkgrel=1\narch=(any)\n"
+
+	tests := []struct {
+		name    string
+		content string
+		files   []string
+		want    []string
+	}{
+		{
+			name:    "none",
+			content: header,
+		},
+		{
+			name:    "global install",
+			content: header + "install=tool.install\n",
+			files:   []string{"tool.install"},
+			want:    []string{"tool.install"},
+		},
+		{
+			name:    "in a package function, with variables",
+			content: header + "package_tool() {\n\tinstall=\"${pkgbase}-setup.install\"\n}\n",
+			files:   []string{"tool-setup.install"},
+			want:    []string{"tool-setup.install"},
+		},
+		{
+			name:    "unreferenced files next to the PKGBUILD",
+			content: header + "install=tool.install\n",
+			files:   []string{"tool.install", "extra.install"},
+			want:    []string{"extra.install", "tool.install"},
+		},
+		{
+			name:    "referenced but missing",
+			content: header + "install=tool.install\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgbuild := writePKGBUILD(t, tt.content)
+			for _, file := range tt.files {
+				path := filepath.Join(filepath.Dir(pkgbuild.Path), file)
+				if err := os.WriteFile(path, []byte("post_install() {\n\ttrue\n}\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			scripts, err := InstallScripts(pkgbuild)
+			if err != nil {
+				t.Fatalf("InstallScripts() error = %v", err)
+			}
+			var names []string
+			for _, script := range scripts {
+				names = append(names, script.Name)
+				if !slices.Equal(script.Hooks, []string{"post_install"}) {
+					t.Errorf("%s hooks = %v, want post_install", script.Name, script.Hooks)
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("InstallScripts() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewScriptlets(t *testing.T) {
+	pkg := &Package{Name: "tool", PackageBase: "tool"}
+	withScript := writePKGBUILD(t, "pkgname=tool\npkgver=1.0\npkgrel=1\ninstall=tool.install\n")
+	path := filepath.Join(filepath.Dir(withScript.Path), "tool.install")
+	if err := os.WriteFile(path, []byte("post_install() {\n\tsystemctl enable tool\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withoutScript := writePKGBUILD(t, "pkgname=tool\npkgver=1.0\npkgrel=1\n")
+
+	tests := []struct {
+		name       string
+		pkgbuild   *PKGBUILD
+		accept     bool
+		wantShown  bool
+		wantErr    bool
+		noCallback bool
+	}{
+		{name: "accepted", pkgbuild: withScript, accept: true, wantShown: true},
+		{name: "rejected", pkgbuild: withScript, wantShown: true, wantErr: true},
+		{name: "no scriptlets", pkgbuild: withoutScript},
+		{name: "review disabled", pkgbuild: withScript, noCallback: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shown []*InstallScript
+			opts := DefaultBuildOptions()
+			if !tt.noCallback {
+				opts.OnScriptletReview = func(_ *Package, scripts []*InstallScript) bool {
+					shown = scripts
+					return tt.accept
+				}
+			}
+			b := NewBuilder(t.TempDir())
+			b.SetOptions(opts)
+
+			err := b.reviewScriptlets(pkg, tt.pkgbuild)
+			if (err != nil) != tt.wantErr || (err != nil && !strings.Contains(err.Error(), "aborted")) {
+				t.Errorf("reviewScriptlets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (shown != nil) != tt.wantShown {
+				t.Errorf("scriptlets shown = %v, want shown %v", shown, tt.wantShown)
+			}
+			if shown != nil && (len(shown) != 1 || len(shown[0].Analysis.Findings) != 1) {
+				t.Errorf("scriptlets shown = %+v, want tool.install with its finding", shown)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// boostFuzzy scales the scores of results found by correcting the query,
+// so they rank below results matching the query as typed.
+const boostFuzzy = 0.5
+
+// maxEdits returns the number of typos tolerated in a term: none in short
+// terms, where any edit makes another word, and more in longer ones.
+func maxEdits(term string) int {
+	switch n := len(term); {
+	case n < 4:
+		return 0
+	case n < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// levenshtein returns the edit distance between a and b, or limit+1 if it
+// is more than limit.
+func levenshtein(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return min(prev[len(rb)], limit+1)
+}
+
+// fuzzyCandidate returns true if candidate is worth measuring against term:
+// it starts with the same letter, since typos rarely hit the first one,
+// and its length is within limit of term's. This skips the distance of
+// almost every indexed term.
+func fuzzyCandidate(term, candidate string, limit int) bool {
+	if candidate == "" || candidate[0] != term[0] {
+		return false
+	}
+	return abs(utf8.RuneCountInString(candidate)-utf8.RuneCountInString(term)) <= limit
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// correctQuery replaces the query terms that match no indexed term, even
+// as a prefix, with the closest indexed term within maxEdits that starts
+// with the same letter. It returns
+// the corrected terms and whether any term was corrected.
+func (idx *Index) correctQuery(queryTerms []string) ([]string, bool) {
+	corrected := make([]string, len(queryTerms))
+	changed := false
+
+	for i, term := range queryTerms {
+		corrected[i] = term
+		limit := maxEdits(term)
+		if limit == 0 {
+			continue
+		}
+
+		best, bestDist, bestDF := "", limit+1, 0
+		known := false
+		for indexedTerm, postings := range idx.invertedIndex {
+			if strings.HasPrefix(indexedTerm, term) {
+				known = true
+				break
+			}
+			if strings.HasPrefix(indexedTerm, "__") || !fuzzyCandidate(term, indexedTerm, limit) {
+				continue
+			}
+			d := levenshtein(term, indexedTerm, limit)
+			if d > limit {
+				continue
+			}
+			// Prefer the closest term, then the most common one
+			if d < bestDist || d == bestDist && (len(postings) > bestDF || len(postings) == bestDF && indexedTerm < best) {
+				best, bestDist, bestDF = indexedTerm, d, len(postings)
+			}
+		}
+		if !known && best != "" {
+			corrected[i] = best
+			changed = true
+		}
+	}
+
+	return corrected, changed
+}
+
+// Suggest returns the name of the indexed package closest to name, for a
+// "did you mean" hint when name is not found, or "" if no package name
+// starting with the same letter is within a few typos of it. Packages excluded by the source, installed and
+// available filters of opts are not suggested.
+func (idx *Index) Suggest(name string, opts SearchOptions) string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	nameLower := strings.ToLower(name)
+	limit := maxEdits(nameLower)
+	if limit == 0 {
+		return ""
+	}
+
+	type suggestion struct {
+		name    string
+		dist    int
+		sources int
+	}
+	var suggestions []suggestion
+
+	for term, postings := range idx.invertedIndex {
+		candidate, ok := strings.CutPrefix(term, "__name:")
+		if !ok || candidate == nameLower || !fuzzyCandidate(nameLower, candidate, limit) {
+			continue
+		}
+		d := levenshtein(nameLower, candidate, limit)
+		if d > limit {
+			continue
+		}
+
+		s := suggestion{dist: d}
+		for _, docIdx := range postings {
+			pkg := idx.documents[docIdx].Package
+			if opts.SourceFilter != "" && pkg.Source != opts.SourceFilter ||
+				opts.InstalledOnly && !pkg.Installed ||
+				opts.AvailableOnly && pkg.Installed {
+				continue
+			}
+			s.name = pkg.Name
+			s.sources++
+		}
+		if s.sources > 0 {
+			suggestions = append(suggestions, s)
+		}
+	}
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	// The closest name, then the one in the most sources
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.dist != b.dist {
+			return a.dist < b.dist
+		}
+		if a.sources != b.sources {
+			return a.sources > b.sources
+		}
+		return a.name < b.name
+	})
+	return suggestions[0].name
+}
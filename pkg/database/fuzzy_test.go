@@ -0,0 +1,136 @@
+package database
+
+import (
+	"slices"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+// testIndex returns an index of a few packages.
+func testIndex() *Index {
+	idx := NewIndex()
+	idx.Reset([]manager.Package{
+		{Name: "firefox", Source: "pacman", Description: "Standalone web browser from mozilla.org"},
+		{Name: "firefox", Source: "flatpak", Description: "Fast, private and safe web browser"},
+		{Name: "firejail", Source: "pacman", Description: "Linux namespaces sandbox program"},
+		{Name: "thunderbird", Source: "pacman", Description: "Standalone mail and news reader", Installed: true},
+		{Name: "thunar", Source: "pacman", Description: "Modern file manager for Xfce"},
+		{Name: "vim", Source: "pacman", Description: "Vi Improved, a highly configurable text editor"},
+	})
+	return idx
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"firefox", "firefox", 2, 0},
+		{"firfox", "firefox", 2, 1},
+		{"fierfox", "firefox", 2, 2},
+		{"firefxo", "firefox", 2, 2},
+		{"thunderbrid", "thunderbird", 2, 2},
+		{"firefox", "firejail", 2, 3},
+		{"vim", "emacs", 2, 3},
+		{"café", "cafe", 1, 1},
+		{"", "vim", 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"-"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b, tt.limit); got != tt.want {
+				t.Errorf("levenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyCandidate(t *testing.T) {
+	tests := []struct {
+		term, candidate string
+		want            bool
+	}{
+		{"firfox", "firefox", true},
+		{"firfox", "irefox", false},
+		{"firfox", "firefox-developer-edition", false},
+		{"thunderbrid", "thunderbird", true},
+		{"firfox", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := fuzzyCandidate(tt.term, tt.candidate, maxEdits(tt.term)); got != tt.want {
+			t.Errorf("fuzzyCandidate(%q, %q) = %v, want %v", tt.term, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestCorrectQuery(t *testing.T) {
+	idx := testIndex()
+
+	tests := []struct {
+		name    string
+		terms   []string
+		want    []string
+		changed bool
+	}{
+		{"typo", []string{"firfox"}, []string{"firefox"}, true},
+		{"two typos", []string{"thunderbrid"}, []string{"thunderbird"}, true},
+		{"one of two terms", []string{"web", "browsr"}, []string{"web", "browser"}, true},
+		{"known term", []string{"firefox"}, []string{"firefox"}, false},
+		{"prefix of a known term", []string{"thund"}, []string{"thund"}, false},
+		{"short term", []string{"vmi"}, []string{"vmi"}, false},
+		{"first letter wrong", []string{"virefox"}, []string{"virefox"}, false},
+		{"nothing close", []string{"libreoffice"}, []string{"libreoffice"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := idx.correctQuery(tt.terms)
+			if !slices.Equal(got, tt.want) || changed != tt.changed {
+				t.Errorf("correctQuery(%q) = %q, %v, want %q, %v", tt.terms, got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestSearchCorrectsTypos(t *testing.T) {
+	results := testIndex().Search("firfox", DefaultSearchOptions())
+	if len(results) == 0 {
+		t.Fatal("Search(firfox) found nothing")
+	}
+	if results[0].Name != "firefox" || results[0].Correction != "firefox" {
+		t.Errorf("Search(firfox)[0] = %s corrected to %q, want firefox", results[0].Name, results[0].Correction)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	idx := testIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		want  string
+	}{
+		{"typo", "firfox", SearchOptions{}, "firefox"},
+		{"capitals", "FireFx", SearchOptions{}, "firefox"},
+		{"two typos", "thundrbrd", SearchOptions{}, "thunderbird"},
+		{"source filter", "firfox", SearchOptions{SourceFilter: "flatpak"}, "firefox"},
+		{"filtered out", "firfox", SearchOptions{SourceFilter: "apt"}, ""},
+		{"installed only", "thundrbird", SearchOptions{InstalledOnly: true}, "thunderbird"},
+		{"available only", "thundrbird", SearchOptions{AvailableOnly: true}, ""},
+		{"exact name", "firefox", SearchOptions{}, ""},
+		{"short name", "vmi", SearchOptions{}, ""},
+		{"first letter wrong", "hirefox", SearchOptions{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.Suggest(tt.query, tt.opts); got != tt.want {
+				t.Errorf("Suggest(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
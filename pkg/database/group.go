@@ -0,0 +1,66 @@
+package database
+
+import (
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// GroupKey returns the key shared by packages of different sources that
+// are the same software: the canonical name of their mapping or, without
+// one, the lowercased name without the reverse-DNS prefix of an
+// application ID (org.mozilla.firefox) or the "-bin" suffix of a prebuilt
+// AUR package.
+func (ms *MappingStore) GroupKey(source, name string) string {
+	if ms != nil {
+		if mapping := ms.GetBySourceName(source, name); mapping != nil {
+			return mapping.Canonical
+		}
+	}
+
+	key := strings.ToLower(name)
+	if strings.Count(key, ".") >= 2 {
+		key = key[strings.LastIndex(key, ".")+1:]
+	}
+	if source == "aur" {
+		key = strings.TrimSuffix(key, "-bin")
+	}
+	return key
+}
+
+// GroupPackages groups packages by GroupKey and returns each group as
+// indexes into packages. A group holds at most one package of each source,
+// so two packages of one source are never shown as alternatives. Groups
+// are in the order of their first package, so ranked packages stay ranked.
+func (ms *MappingStore) GroupPackages(packages []manager.Package) [][]int {
+	var groups [][]int
+	byKey := make(map[string][]int) // key -> indexes of its groups
+
+	for i, pkg := range packages {
+		key := ms.GroupKey(pkg.Source, pkg.Name)
+
+		placed := false
+		for _, g := range byKey[key] {
+			if !hasSource(packages, groups[g], pkg.Source) {
+				groups[g] = append(groups[g], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			byKey[key] = append(byKey[key], len(groups))
+			groups = append(groups, []int{i})
+		}
+	}
+	return groups
+}
+
+// hasSource reports whether the packages at indexes include one of source.
+func hasSource(packages []manager.Package, indexes []int, source string) bool {
+	for _, i := range indexes {
+		if packages[i].Source == source {
+			return true
+		}
+	}
+	return false
+}
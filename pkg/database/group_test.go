@@ -0,0 +1,93 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+func TestGroupKey(t *testing.T) {
+	ms := NewMappingStore()
+	ms.Add(&Mapping{Canonical: "vscode", Sources: map[string]string{"aur": "visual-studio-code-bin", "flatpak": "com.visualstudio.code", "snap": "code"}})
+
+	tests := []struct {
+		name   string
+		source string
+		pkg    string
+		want   string
+	}{
+		{"mapped", "flatpak", "com.visualstudio.code", "vscode"},
+		{"mapped by another source's name", "snap", "code", "vscode"},
+		{"application ID", "flatpak", "org.mozilla.firefox", "firefox"},
+		{"dotted name that is not an ID", "pacman", "python3.12", "python3.12"},
+		{"AUR prebuilt", "aur", "discord-bin", "discord"},
+		{"-bin elsewhere is kept", "pacman", "ruby-bin", "ruby-bin"},
+		{"case", "pacman", "Firefox", "firefox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ms.GroupKey(tt.source, tt.pkg); got != tt.want {
+				t.Errorf("GroupKey(%s, %s) = %q, want %q", tt.source, tt.pkg, got, tt.want)
+			}
+		})
+	}
+
+	var nilStore *MappingStore
+	if got := nilStore.GroupKey("flatpak", "org.mozilla.firefox"); got != "firefox" {
+		t.Errorf("GroupKey() without mappings = %q, want firefox", got)
+	}
+}
+
+func TestGroupPackages(t *testing.T) {
+	ms := NewMappingStore()
+	ms.Add(&Mapping{Canonical: "vscode", Sources: map[string]string{"aur": "visual-studio-code-bin", "flatpak": "com.visualstudio.code"}})
+
+	tests := []struct {
+		name     string
+		packages []manager.Package
+		want     [][]int
+	}{
+		{
+			name: "same package in several sources",
+			packages: []manager.Package{
+				{Name: "firefox", Source: "pacman"},
+				{Name: "vim", Source: "pacman"},
+				{Name: "org.mozilla.firefox", Source: "flatpak"},
+				{Name: "firefox", Source: "snap"},
+			},
+			want: [][]int{{0, 2, 3}, {1}},
+		},
+		{
+			name: "mapped names",
+			packages: []manager.Package{
+				{Name: "com.visualstudio.code", Source: "flatpak"},
+				{Name: "visual-studio-code-bin", Source: "aur"},
+			},
+			want: [][]int{{0, 1}},
+		},
+		{
+			name: "two packages of one source are not alternatives",
+			packages: []manager.Package{
+				{Name: "discord", Source: "aur"},
+				{Name: "discord-bin", Source: "aur"},
+				{Name: "discord", Source: "flatpak"},
+			},
+			want: [][]int{{0, 2}, {1}},
+		},
+		{
+			name:     "no packages",
+			packages: nil,
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ms.GroupPackages(tt.packages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GroupPackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"unicode"
 
 	"poxy/pkg/manager"
 )
@@ -14,6 +15,9 @@ type SearchResult struct {
 	manager.Package
 	Score       float64 // TF-IDF relevance score
 	MatchReason string  // Why this package matched
+	// Correction is the corrected query the package matched, if the query
+	// as typed matched nothing
+	Correction string
 }
 
 // Index provides TF-IDF based search over packages.
@@ -35,6 +39,7 @@ type Index struct {
 	boostPrefixMatch  float64
 	boostInstalled    float64
 	boostNativeSource float64
+	boostProvides     float64
 }
 
 type document struct {
@@ -53,6 +58,7 @@ func NewIndex() *Index {
 		boostPrefixMatch:  5.0,
 		boostInstalled:    1.5,
 		boostNativeSource: 1.2,
+		boostProvides:     8.0,
 	}
 }
 
@@ -88,6 +94,25 @@ func (idx *Index) AddBatch(packages []manager.Package) {
 	idx.rebuildIDFCache()
 }
 
+// Reset replaces the indexed packages with packages. The new index is
+// built aside and swapped in at once, so searches meanwhile use the old one.
+func (idx *Index) Reset(packages []manager.Package) {
+	next := NewIndex()
+	next.docByID = make(map[string]int, len(packages))
+	for _, pkg := range packages {
+		next.addUnlocked(pkg)
+	}
+	next.rebuildIDFCache()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.documents = next.documents
+	idx.docByID = next.docByID
+	idx.invertedIndex = next.invertedIndex
+	idx.idfCache = next.idfCache
+}
+
 func (idx *Index) addUnlocked(pkg manager.Package) {
 	docID := pkg.Source + ":" + pkg.Name
 
@@ -111,8 +136,11 @@ func (idx *Index) addUnlocked(pkg manager.Package) {
 }
 
 func (idx *Index) createDocument(pkg manager.Package) document {
-	// Combine name and description for indexing
-	text := pkg.Name + " " + pkg.Description
+	// Combine name, description, relations and application name and
+	// categories for indexing
+	text := pkg.Name + " " + pkg.Description + " " +
+		strings.Join(pkg.Provides, " ") + " " + strings.Join(pkg.Replaces, " ") + " " +
+		pkg.Metadata[manager.MetaAppName] + " " + splitCamel(strings.ReplaceAll(pkg.Metadata[manager.MetaCategories], ",", " "))
 
 	// Tokenize and count term frequencies
 	terms := make(map[string]int)
@@ -126,6 +154,14 @@ func (idx *Index) createDocument(pkg manager.Package) document {
 	// Add name as a special term for exact matching
 	terms["__name:"+strings.ToLower(pkg.Name)] = 1
 
+	// Add provided and replaced names for virtual package lookups
+	for _, provided := range pkg.Provides {
+		terms["__provides:"+strings.ToLower(manager.RelationName(provided))] = 1
+	}
+	for _, replaced := range pkg.Replaces {
+		terms["__replaces:"+strings.ToLower(manager.RelationName(replaced))] = 1
+	}
+
 	return document{
 		Package: pkg,
 		Terms:   terms,
@@ -162,7 +198,10 @@ func (idx *Index) rebuildIDFCache() {
 	}
 }
 
-// Search performs a TF-IDF search and returns ranked results.
+// Search performs a TF-IDF search and returns ranked results. If the query
+// matches nothing, terms with typos are corrected to the closest indexed
+// terms and the corrected query is searched instead; its results have
+// Correction set and lower scores.
 func (idx *Index) Search(query string, opts SearchOptions) []SearchResult {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
@@ -177,6 +216,27 @@ func (idx *Index) Search(query string, opts SearchOptions) []SearchResult {
 		return nil
 	}
 
+	results := idx.search(queryTerms, strings.ToLower(query), opts)
+	if len(results) > 0 {
+		return results
+	}
+
+	corrected, changed := idx.correctQuery(queryTerms)
+	if !changed {
+		return nil
+	}
+	correction := strings.Join(corrected, " ")
+	results = idx.search(corrected, correction, opts)
+	for i := range results {
+		results[i].Score *= boostFuzzy
+		results[i].Correction = correction
+	}
+	return results
+}
+
+// search scores the documents matching queryTerms, tokenized from
+// queryLower.
+func (idx *Index) search(queryTerms []string, queryLower string, opts SearchOptions) []SearchResult {
 	// Calculate query TF-IDF vector
 	queryVec := make(map[string]float64)
 	for _, term := range queryTerms {
@@ -192,8 +252,6 @@ func (idx *Index) Search(query string, opts SearchOptions) []SearchResult {
 	candidates := idx.findCandidates(queryTerms)
 	results := make([]SearchResult, 0, len(candidates))
 
-	queryLower := strings.ToLower(query)
-
 	for docIdx := range candidates {
 		doc := idx.documents[docIdx]
 		score := idx.scoreDocument(doc, queryVec, queryTerms, queryLower, opts)
@@ -277,6 +335,9 @@ func (idx *Index) scoreDocument(doc document, queryVec map[string]float64, query
 	} else if strings.HasPrefix(nameLower, queryLower) {
 		// Prefix match
 		score *= idx.boostPrefixMatch
+	} else if doc.Package.Relation(queryLower) != "" {
+		// Provides or replaces the queried name
+		score *= idx.boostProvides
 	} else if strings.Contains(nameLower, queryLower) {
 		// Contains match
 		score *= 2.0
@@ -302,6 +363,11 @@ func (idx *Index) scoreDocument(doc document, queryVec map[string]float64, query
 		return 0
 	}
 
+	// Filter available-only
+	if opts.AvailableOnly && doc.Package.Installed {
+		return 0
+	}
+
 	return score
 }
 
@@ -314,6 +380,12 @@ func (idx *Index) getMatchReason(doc document, queryTerms []string, queryLower s
 	if strings.HasPrefix(nameLower, queryLower) {
 		return "Name starts with query"
 	}
+	switch doc.Package.Relation(queryLower) {
+	case "provides":
+		return "Provides " + queryLower
+	case "replaces":
+		return "Replaces " + queryLower
+	}
 	if strings.Contains(nameLower, queryLower) {
 		return "Name contains query"
 	}
@@ -365,6 +437,7 @@ type SearchOptions struct {
 	Limit          int    // Maximum results (0 = unlimited)
 	SourceFilter   string // Only return results from this source
 	InstalledOnly  bool   // Only return installed packages
+	AvailableOnly  bool   // Only return packages that are not installed
 	BoostInstalled bool   // Boost installed packages in ranking
 	NativeSource   string // The native package manager source (for boosting)
 }
@@ -376,3 +449,18 @@ func DefaultSearchOptions() SearchOptions {
 		BoostInstalled: true,
 	}
 }
+
+// splitCamel splits CamelCase words, so categories such as "WebBrowser"
+// match searches for "web browser".
+func splitCamel(text string) string {
+	var b strings.Builder
+	prev := ' '
+	for _, r := range text {
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexFileVersion is the format of saved indexes. Bump it whenever the
+// saved data or the way documents are tokenized changes, so older files
+// are rebuilt rather than misread.
+const indexFileVersion = 2
+
+// ErrIndexVersion is returned when loading an index saved in another
+// format version.
+var ErrIndexVersion = errors.New("search index was saved by another version of poxy")
+
+// indexFile is the saved form of an Index.
+type indexFile struct {
+	Version   int
+	Built     time.Time // PackagesModified of the packages it was built from
+	Documents []document
+	DocByID   map[string]int
+	Inverted  map[string][]int
+	IDF       map[string]float64
+}
+
+// IndexPath returns the path the search index is saved at, next to the
+// package database.
+func IndexPath() (string, error) {
+	dir, err := storeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packages.idx"), nil
+}
+
+// SaveFile writes the index to path, recording built, the PackagesModified
+// time of the packages it was built from. The file is replaced atomically.
+func (idx *Index) SaveFile(path string, built time.Time) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	file := indexFile{
+		Version:   indexFileVersion,
+		Built:     built,
+		Documents: idx.documents,
+		DocByID:   idx.docByID,
+		Inverted:  idx.invertedIndex,
+		IDF:       idx.idfCache,
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = gob.NewEncoder(w).Encode(&file)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadFile replaces the indexed packages with those saved at path, and
+// returns the PackagesModified time they were built from. It returns
+// ErrIndexVersion for files of another format version.
+func (idx *Index) LoadFile(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	var file indexFile
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&file); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read search index: %w", err)
+	}
+	if file.Version != indexFileVersion {
+		return time.Time{}, ErrIndexVersion
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.documents = file.Documents
+	idx.docByID = file.DocByID
+	idx.invertedIndex = file.Inverted
+	idx.idfCache = file.IDF
+	if idx.docByID == nil {
+		idx.docByID = make(map[string]int)
+	}
+	if idx.invertedIndex == nil {
+		idx.invertedIndex = make(map[string][]int)
+	}
+	if idx.idfCache == nil {
+		idx.idfCache = make(map[string]float64)
+	}
+	return file.Built, nil
+}
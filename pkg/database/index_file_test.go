@@ -0,0 +1,123 @@
+package database
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"poxy/pkg/manager"
+)
+
+func TestIndexFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packages.idx")
+	built := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	saved := testIndex()
+	if err := saved.SaveFile(path, built); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	loaded := NewIndex()
+	got, err := loaded.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !got.Equal(built) {
+		t.Errorf("LoadFile() built = %s, want %s", got, built)
+	}
+	if loaded.Size() != saved.Size() {
+		t.Errorf("loaded %d packages, want %d", loaded.Size(), saved.Size())
+	}
+
+	want := saved.Search("browser", DefaultSearchOptions())
+	results := loaded.Search("browser", DefaultSearchOptions())
+	if len(results) != len(want) || len(results) == 0 {
+		t.Fatalf("Search() after loading = %d results, want %d", len(results), len(want))
+	}
+	// Results with equal scores come in no particular order and scores are
+	// summed in map order, so compare each package's score within a margin
+	scores := make(map[string]float64, len(want))
+	for _, r := range want {
+		scores[r.Package.Source+"/"+r.Package.Name] = r.Score
+	}
+	for _, r := range results {
+		key := r.Package.Source + "/" + r.Package.Name
+		if score, ok := scores[key]; !ok || math.Abs(score-r.Score) > 1e-9 {
+			t.Errorf("result %s scored %v, want %v", key, r.Score, score)
+		}
+	}
+
+	// The loaded index can still be changed
+	loaded.Add(manager.Package{Name: "chromium", Source: "pacman", Description: "A web browser built for speed"})
+	if loaded.Size() != saved.Size()+1 {
+		t.Errorf("Size() after Add() = %d, want %d", loaded.Size(), saved.Size()+1)
+	}
+}
+
+func TestIndexFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewIndex().LoadFile(filepath.Join(dir, "missing.idx")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadFile() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	old := filepath.Join(dir, "old.idx")
+	f, err := os.Create(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewEncoder(f).Encode(&indexFile{Version: indexFileVersion - 1}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := NewIndex().LoadFile(old); !errors.Is(err, ErrIndexVersion) {
+		t.Errorf("LoadFile() of an older format error = %v, want ErrIndexVersion", err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.idx")
+	if err := os.WriteFile(garbage, []byte("not an index"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIndex().LoadFile(garbage); err == nil {
+		t.Error("LoadFile() of a corrupt file succeeded")
+	}
+}
+
+func TestPackagesModified(t *testing.T) {
+	store := openTestStore(t)
+
+	modified := func() time.Time {
+		t.Helper()
+		m, err := store.PackagesModified()
+		if err != nil {
+			t.Fatalf("PackagesModified() error = %v", err)
+		}
+		return m
+	}
+
+	last := modified()
+	for _, change := range []struct {
+		name string
+		fn   func() error
+	}{
+		{"AddPackages", func() error {
+			return store.AddPackages([]manager.Package{{Name: "vim", Source: "pacman"}, {Name: "git", Source: "pacman"}})
+		}},
+		{"DeletePackage", func() error { return store.DeletePackage("pacman", "git") }},
+		{"ClearSource", func() error { return store.ClearSource("pacman") }},
+	} {
+		time.Sleep(time.Millisecond)
+		if err := change.fn(); err != nil {
+			t.Fatalf("%s() error = %v", change.name, err)
+		}
+		if m := modified(); !m.After(last) {
+			t.Errorf("PackagesModified() after %s = %s, want after %s", change.name, m, last)
+		} else {
+			last = m
+		}
+	}
+}
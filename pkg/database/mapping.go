@@ -2,6 +2,7 @@ package database
 
 import (
 	"encoding/json"
+	"sort"
 	"strings"
 	"sync"
 
@@ -97,6 +98,30 @@ func (ms *MappingStore) GetNameForSource(canonical, source string) string {
 	return ""
 }
 
+// Find retrieves a mapping by canonical name, or by the package name in any
+// source. Sources are checked in name order so the result is stable.
+func (ms *MappingStore) Find(name string) *Mapping {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if mapping, ok := ms.mappings[name]; ok {
+		return mapping
+	}
+
+	suffix := ":" + strings.ToLower(name)
+	var keys []string
+	for key := range ms.reverse {
+		if strings.HasSuffix(key, suffix) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	return ms.mappings[ms.reverse[keys[0]]]
+}
+
 // FindEquivalent finds equivalent packages across sources.
 func (ms *MappingStore) FindEquivalent(source, name string) map[string]string {
 	ms.mu.RLock()
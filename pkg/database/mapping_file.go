@@ -0,0 +1,88 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"poxy/internal/config"
+)
+
+// ReadMappingFile reads a mapping dataset: a JSON array of mappings, in the
+// format of Mapping. Mappings need a canonical name and at least one source.
+func ReadMappingFile(path string) ([]*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseMappings(path, data)
+}
+
+// parseMappings parses and validates a mapping dataset read from name.
+func parseMappings(name string, data []byte) ([]*Mapping, error) {
+	var mappings []*Mapping
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for i, mapping := range mappings {
+		if mapping == nil || mapping.Canonical == "" {
+			return nil, fmt.Errorf("%s: mapping %d has no canonical name", name, i+1)
+		}
+		if len(mapping.Sources) == 0 {
+			return nil, fmt.Errorf("%s: mapping %q has no sources", name, mapping.Canonical)
+		}
+	}
+	return mappings, nil
+}
+
+// LoadDir adds the mappings of every .json dataset in dir, in file name
+// order, so later files override earlier ones. A missing directory is not
+// an error. Files that cannot be read are skipped, and their errors
+// returned together.
+func (ms *MappingStore) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	for _, path := range paths {
+		mappings, err := ReadMappingFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ms.AddBatch(mappings)
+	}
+	return errors.Join(errs...)
+}
+
+// LoadMappings returns a store of the package mappings, each overriding
+// the ones before: the built-in mappings, those synced into store by
+// 'poxy mappings sync', the datasets in the mapping directory, and the
+// user mappings in store, which extend the mapping of the same canonical
+// name. store may be nil to leave out the stored mappings. The result is
+// usable even if some mappings could not be read; the error says which.
+func LoadMappings(store *Store) (*MappingStore, error) {
+	ms := NewMappingStore()
+	ms.AddBatch(CommonMappings())
+
+	var errs []error
+	if store != nil {
+		errs = append(errs, ms.LoadFromDB(store.db))
+	}
+	errs = append(errs, ms.LoadDir(config.MappingDir()))
+	if store != nil {
+		user, err := store.UserMappings()
+		errs = append(errs, err)
+		for _, mapping := range user {
+			ms.Extend(mapping)
+		}
+	}
+	return ms, errors.Join(errs...)
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	keyMappingsETag   = "mappings_etag"
+	keyMappingsSynced = "mappings_synced"
+	keyMappingsSerial = "mappings_serial"
+
+	// maxMappingFeedSize bounds the downloaded dataset.
+	maxMappingFeedSize = 16 << 20
+)
+
+var (
+	// ErrMappingsNotModified is returned by MappingFeed.Fetch when the
+	// dataset has not changed since it was last synced.
+	ErrMappingsNotModified = errors.New("mappings not modified")
+
+	// ErrMappingsRollback is returned by MappingFeed.Fetch for a dataset
+	// older than the one last synced, which may be an old dataset replayed
+	// to bring back mappings since fixed.
+	ErrMappingsRollback = errors.New("mapping dataset is older than the synced one")
+)
+
+// MappingDataset is a published mapping dataset. Serial is raised with
+// every release, and being signed with the mappings, lets a sync refuse
+// older releases.
+type MappingDataset struct {
+	Serial   int64      `json:"serial"`
+	Mappings []*Mapping `json:"mappings"`
+}
+
+// MappingFeed is a curated mapping dataset published at a URL, signed with
+// an ed25519 key. The base64 signature of the dataset is published at URL
+// + ".sig".
+type MappingFeed struct {
+	URL       string
+	PublicKey ed25519.PublicKey
+	Client    *http.Client
+}
+
+// NewMappingFeed returns the feed at url signed with the base64 publicKey.
+func NewMappingFeed(url, publicKey string) (*MappingFeed, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid mappings public key: expected a base64 ed25519 key")
+	}
+	return &MappingFeed{
+		URL:       url,
+		PublicKey: ed25519.PublicKey(key),
+		Client:    &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// Fetch downloads the dataset and verifies its signature. If etag is the
+// ETag of the last sync and the dataset has not changed since,
+// ErrMappingsNotModified is returned; if the dataset's serial is below
+// lastSerial, the serial of the last sync, ErrMappingsRollback is. It
+// returns the dataset and the new ETag.
+func (f *MappingFeed) Fetch(ctx context.Context, etag string, lastSerial int64) (*MappingDataset, string, error) {
+	data, newETag, err := f.get(ctx, f.URL, etag)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sig, _, err := f.get(ctx, f.URL+".sig", "")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download the signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(f.PublicKey, data, signature) {
+		return nil, "", fmt.Errorf("%s: signature verification failed", f.URL)
+	}
+
+	dataset, err := parseMappingDataset(f.URL, data)
+	if err != nil {
+		return nil, "", err
+	}
+	if dataset.Serial < lastSerial {
+		return nil, "", fmt.Errorf("%w: %s has serial %d, the last sync had %d", ErrMappingsRollback, f.URL, dataset.Serial, lastSerial)
+	}
+	return dataset, newETag, nil
+}
+
+// parseMappingDataset parses a published dataset: an object with a
+// positive serial and the mappings, an array in the format of local
+// datasets.
+func parseMappingDataset(name string, data []byte) (*MappingDataset, error) {
+	var raw struct {
+		Serial   int64           `json:"serial"`
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if raw.Serial <= 0 {
+		return nil, fmt.Errorf("%s: the dataset has no serial", name)
+	}
+
+	mappings, err := parseMappings(name, raw.Mappings)
+	if err != nil {
+		return nil, err
+	}
+	return &MappingDataset{Serial: raw.Serial, Mappings: mappings}, nil
+}
+
+// get downloads url, sending etag to skip an unchanged body.
+func (f *MappingFeed) get(ctx context.Context, url, etag string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, "", ErrMappingsNotModified
+	default:
+		return nil, "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMappingFeedSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxMappingFeedSize {
+		return nil, "", fmt.Errorf("%s: larger than %d MiB", url, maxMappingFeedSize>>20)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// MappingSync describes the last mapping sync.
+type MappingSync struct {
+	ETag     string
+	Serial   int64     // Serial of the synced dataset, 0 if never synced
+	Synced   time.Time // Zero if never synced
+	Mappings int       // Number of synced mappings
+}
+
+// SaveSyncedMappings replaces the synced mappings with those of dataset,
+// so mappings dropped from the dataset are dropped here too, and records
+// the sync.
+func (s *Store) SaveSyncedMappings(dataset *MappingDataset, etag string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(bucketMappings)) != nil {
+			if err := tx.DeleteBucket([]byte(bucketMappings)); err != nil {
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucket([]byte(bucketMappings))
+		if err != nil {
+			return err
+		}
+		for _, mapping := range dataset.Mappings {
+			data, err := json.Marshal(mapping)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(mapping.Canonical), data); err != nil {
+				return err
+			}
+		}
+
+		meta := tx.Bucket([]byte(bucketMeta))
+		if meta == nil {
+			return fmt.Errorf("meta bucket not found")
+		}
+		if err := meta.Put([]byte(keyMappingsSerial), []byte(strconv.FormatInt(dataset.Serial, 10))); err != nil {
+			return err
+		}
+		return recordMappingSync(tx, etag)
+	})
+}
+
+// TouchMappingSync records a sync that found the mappings unchanged.
+func (s *Store) TouchMappingSync(etag string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return recordMappingSync(tx, etag)
+	})
+}
+
+func recordMappingSync(tx *bbolt.Tx, etag string) error {
+	meta := tx.Bucket([]byte(bucketMeta))
+	if meta == nil {
+		return fmt.Errorf("meta bucket not found")
+	}
+	if err := meta.Put([]byte(keyMappingsETag), []byte(etag)); err != nil {
+		return err
+	}
+	return meta.Put([]byte(keyMappingsSynced), []byte(time.Now().Format(time.RFC3339)))
+}
+
+// LastMappingSync returns the last mapping sync.
+func (s *Store) LastMappingSync() (MappingSync, error) {
+	var last MappingSync
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket([]byte(bucketMappings)); bucket != nil {
+			last.Mappings = bucket.Stats().KeyN
+		}
+
+		meta := tx.Bucket([]byte(bucketMeta))
+		if meta == nil {
+			return nil
+		}
+		last.ETag = string(meta.Get([]byte(keyMappingsETag)))
+		if data := meta.Get([]byte(keyMappingsSerial)); data != nil {
+			serial, err := strconv.ParseInt(string(data), 10, 64)
+			if err != nil {
+				return err
+			}
+			last.Serial = serial
+		}
+		if data := meta.Get([]byte(keyMappingsSynced)); data != nil {
+			t, err := time.Parse(time.RFC3339, string(data))
+			if err != nil {
+				return err
+			}
+			last.Synced = t
+		}
+		return nil
+	})
+
+	return last, err
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"poxy/internal/config"
+)
+
+// openTestStore opens a package database in a temporary data directory.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := os.MkdirAll(config.DataDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// serveMappingFeed serves dataset with ETag "v1" and its signature made
+// with key, and returns the feed of it verified with the public key.
+func serveMappingFeed(t *testing.T, dataset string, key ed25519.PrivateKey, public ed25519.PublicKey) *MappingFeed {
+	t.Helper()
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(dataset)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mappings.json":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(dataset)) //nolint:errcheck
+		case "/mappings.json.sig":
+			_, _ = w.Write([]byte(signature)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	feed, err := NewMappingFeed(server.URL+"/mappings.json", base64.StdEncoding.EncodeToString(public))
+	if err != nil {
+		t.Fatalf("NewMappingFeed() error = %v", err)
+	}
+	return feed
+}
+
+// generateKey returns a new ed25519 key pair.
+func generateKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public, private
+}
+
+const testDataset = `{"serial": 7, "mappings": [
+	{"canonical": "firefox", "sources": {"apt": "firefox", "flatpak": "org.mozilla.firefox"}},
+	{"canonical": "gimp", "sources": {"flatpak": "org.gimp.GIMP"}}
+]}`
+
+func TestMappingFeedFetch(t *testing.T) {
+	ctx := context.Background()
+	public, private := generateKey(t)
+
+	t.Run("good signature", func(t *testing.T) {
+		feed := serveMappingFeed(t, testDataset, private, public)
+		dataset, etag, err := feed.Fetch(ctx, "", 0)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if dataset.Serial != 7 || len(dataset.Mappings) != 2 || dataset.Mappings[1].Sources["flatpak"] != "org.gimp.GIMP" {
+			t.Errorf("Fetch() = %+v", dataset)
+		}
+		if etag != `"v1"` {
+			t.Errorf("etag = %q, want \"v1\"", etag)
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		_, other := generateKey(t)
+		feed := serveMappingFeed(t, testDataset, other, public)
+		if _, _, err := feed.Fetch(ctx, "", 0); err == nil {
+			t.Error("Fetch() accepted a dataset signed with another key")
+		}
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		feed := serveMappingFeed(t, testDataset, private, public)
+		if _, _, err := feed.Fetch(ctx, `"v1"`, 7); !errors.Is(err, ErrMappingsNotModified) {
+			t.Errorf("Fetch() error = %v, want ErrMappingsNotModified", err)
+		}
+	})
+
+	t.Run("older serial", func(t *testing.T) {
+		feed := serveMappingFeed(t, testDataset, private, public)
+		if _, _, err := feed.Fetch(ctx, "", 8); !errors.Is(err, ErrMappingsRollback) {
+			t.Errorf("Fetch() error = %v, want ErrMappingsRollback", err)
+		}
+		if _, _, err := feed.Fetch(ctx, "", 7); err != nil {
+			t.Errorf("Fetch() of the same serial error = %v", err)
+		}
+	})
+
+	t.Run("no serial", func(t *testing.T) {
+		feed := serveMappingFeed(t, `{"mappings": [{"canonical": "gimp", "sources": {"flatpak": "org.gimp.GIMP"}}]}`, private, public)
+		if _, _, err := feed.Fetch(ctx, "", 0); err == nil {
+			t.Error("Fetch() accepted a dataset without a serial")
+		}
+	})
+}
+
+func TestSaveSyncedMappingsReplaces(t *testing.T) {
+	store := openTestStore(t)
+
+	first := &MappingDataset{Serial: 1, Mappings: []*Mapping{
+		{Canonical: "firefox", Sources: map[string]string{"apt": "firefox"}},
+		{Canonical: "gimp", Sources: map[string]string{"flatpak": "org.gimp.GIMP"}},
+	}}
+	if err := store.SaveSyncedMappings(first, `"v1"`); err != nil {
+		t.Fatalf("SaveSyncedMappings() error = %v", err)
+	}
+
+	second := &MappingDataset{Serial: 2, Mappings: []*Mapping{
+		{Canonical: "firefox", Sources: map[string]string{"apt": "firefox-esr"}},
+	}}
+	if err := store.SaveSyncedMappings(second, `"v2"`); err != nil {
+		t.Fatalf("SaveSyncedMappings() error = %v", err)
+	}
+
+	last, err := store.LastMappingSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Serial != 2 || last.ETag != `"v2"` || last.Mappings != 1 || last.Synced.IsZero() {
+		t.Errorf("LastMappingSync() = %+v, want serial 2, ETag \"v2\" and one mapping", last)
+	}
+
+	synced := NewMappingStore()
+	if err := synced.LoadFromDB(store.db); err != nil {
+		t.Fatal(err)
+	}
+	if synced.GetByCanonical("gimp") != nil {
+		t.Error("a mapping dropped from the dataset was kept")
+	}
+	if m := synced.GetByCanonical("firefox"); m == nil || m.Sources["apt"] != "firefox-esr" {
+		t.Errorf("firefox = %+v, want the second dataset's", m)
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	ms := NewMappingStore()
+	ms.AddBatch([]*Mapping{
+		{Canonical: "vscode", Sources: map[string]string{"apt": "code", "flatpak": "com.visualstudio.code"}},
+		{Canonical: "code-oss", Sources: map[string]string{"snap": "code", "pacman": "code"}},
+		{Canonical: "gimp", Sources: map[string]string{"flatpak": "org.gimp.GIMP", "apt": "gimp"}},
+	})
+
+	tests := []struct {
+		name string
+		find string
+		want string
+	}{
+		{"canonical name", "gimp", "gimp"},
+		{"source name", "com.visualstudio.code", "vscode"},
+		{"source name in another case", "org.gimp.gimp", "gimp"},
+		{"name in several sources picks the first source by name", "code", "vscode"},
+		{"canonical name before source names", "code-oss", "code-oss"},
+		{"unknown", "firefox", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map iteration order varies, so look up repeatedly
+			for i := 0; i < 20; i++ {
+				got := ""
+				if mapping := ms.Find(tt.find); mapping != nil {
+					got = mapping.Canonical
+				}
+				if got != tt.want {
+					t.Fatalf("Find(%q) = %q, want %q", tt.find, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// ErrUnknownSource is returned for a user mapping naming a source that is
+// not one of the package managers.
+var ErrUnknownSource = errors.New("unknown source")
+
+// CheckSources returns an error wrapping ErrUnknownSource if mapping names
+// a source that is not one of known, the names of the package managers.
+func CheckSources(mapping *Mapping, known []string) error {
+	sources := make([]string, 0, len(mapping.Sources))
+	for source := range mapping.Sources {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		if !slices.Contains(known, source) {
+			return fmt.Errorf("%w %q (known sources: %s)", ErrUnknownSource, source, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// Extend adds the sources of mapping to the mapping of the same canonical
+// name, replacing the names it already has for those sources, or adds
+// mapping if there is none.
+func (ms *MappingStore) Extend(mapping *Mapping) {
+	merged := &Mapping{Canonical: mapping.Canonical, Category: mapping.Category, Sources: make(map[string]string)}
+	if existing := ms.GetByCanonical(mapping.Canonical); existing != nil {
+		for source, name := range existing.Sources {
+			merged.Sources[source] = name
+		}
+		if merged.Category == "" {
+			merged.Category = existing.Category
+		}
+	}
+	for source, name := range mapping.Sources {
+		merged.Sources[source] = name
+	}
+	ms.Add(merged)
+}
+
+// AddUserMapping adds the sources of mapping to the user mapping of its
+// canonical name, creating it if needed, and returns the user mapping.
+// Every source must be one of known, as checked by CheckSources.
+func (s *Store) AddUserMapping(mapping *Mapping, known []string) (*Mapping, error) {
+	if err := CheckSources(mapping, known); err != nil {
+		return nil, err
+	}
+
+	var saved *Mapping
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketUserMappings))
+		if bucket == nil {
+			return fmt.Errorf("user mappings bucket not found")
+		}
+
+		saved = &Mapping{Canonical: mapping.Canonical, Sources: make(map[string]string)}
+		if data := bucket.Get([]byte(mapping.Canonical)); data != nil {
+			if err := json.Unmarshal(data, saved); err != nil {
+				return err
+			}
+		}
+		for source, name := range mapping.Sources {
+			saved.Sources[source] = name
+		}
+		if mapping.Category != "" {
+			saved.Category = mapping.Category
+		}
+
+		data, err := json.Marshal(saved)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(mapping.Canonical), data)
+	})
+
+	return saved, err
+}
+
+// RemoveUserMapping removes sources from the user mapping canonical, or the
+// whole mapping if no sources are given; a mapping left without sources is
+// removed too. It reports whether anything was removed.
+func (s *Store) RemoveUserMapping(canonical string, sources ...string) (bool, error) {
+	removed := false
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketUserMappings))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(canonical))
+		if data == nil {
+			return nil
+		}
+
+		var mapping Mapping
+		if err := json.Unmarshal(data, &mapping); err != nil {
+			return err
+		}
+		for _, source := range sources {
+			if _, ok := mapping.Sources[source]; ok {
+				delete(mapping.Sources, source)
+				removed = true
+			}
+		}
+
+		if len(sources) == 0 || len(mapping.Sources) == 0 {
+			removed = true
+			return bucket.Delete([]byte(canonical))
+		}
+		if !removed {
+			return nil
+		}
+		data, err := json.Marshal(&mapping)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(canonical), data)
+	})
+
+	return removed, err
+}
+
+// UserMappings returns the user mappings sorted by canonical name.
+func (s *Store) UserMappings() ([]*Mapping, error) {
+	var mappings []*Mapping
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketUserMappings))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(_, data []byte) error {
+			var mapping Mapping
+			if err := json.Unmarshal(data, &mapping); err != nil {
+				return nil // Skip malformed entries
+			}
+			mappings = append(mappings, &mapping)
+			return nil
+		})
+	})
+
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Canonical < mappings[j].Canonical })
+	return mappings, err
+}
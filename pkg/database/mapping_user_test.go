@@ -0,0 +1,108 @@
+package database
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+var knownSources = []string{"apt", "aur", "flatpak", "pacman", "snap"}
+
+func TestExtend(t *testing.T) {
+	ms := NewMappingStore()
+	ms.Add(&Mapping{Canonical: "vscode", Category: "development", Sources: map[string]string{"aur": "visual-studio-code-bin", "snap": "code"}})
+
+	ms.Extend(&Mapping{Canonical: "vscode", Sources: map[string]string{"aur": "code", "flatpak": "com.visualstudio.code"}})
+	got := ms.GetByCanonical("vscode")
+	want := map[string]string{"aur": "code", "snap": "code", "flatpak": "com.visualstudio.code"}
+	if got == nil || !maps.Equal(got.Sources, want) || got.Category != "development" {
+		t.Errorf("extended mapping = %+v, want sources %v and the category kept", got, want)
+	}
+
+	ms.Extend(&Mapping{Canonical: "ripgrep", Sources: map[string]string{"apt": "ripgrep"}})
+	if got := ms.GetByCanonical("ripgrep"); got == nil || got.Sources["apt"] != "ripgrep" {
+		t.Errorf("Extend() of a new name = %+v, want it added", got)
+	}
+}
+
+func TestCheckSources(t *testing.T) {
+	if err := CheckSources(&Mapping{Canonical: "gimp", Sources: map[string]string{"flatpak": "org.gimp.GIMP", "pacman": "gimp"}}, knownSources); err != nil {
+		t.Errorf("CheckSources() error = %v", err)
+	}
+	err := CheckSources(&Mapping{Canonical: "gimp", Sources: map[string]string{"flatpack": "org.gimp.GIMP"}}, knownSources)
+	if !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("CheckSources() of a misspelled source error = %v, want ErrUnknownSource", err)
+	}
+}
+
+func TestAddUserMapping(t *testing.T) {
+	store := openTestStore(t)
+
+	if _, err := store.AddUserMapping(&Mapping{Canonical: "vscode", Sources: map[string]string{"aur": "visual-studio-code-bin"}}, knownSources); err != nil {
+		t.Fatalf("AddUserMapping() error = %v", err)
+	}
+	saved, err := store.AddUserMapping(&Mapping{Canonical: "vscode", Sources: map[string]string{"aur": "code", "snap": "code"}}, knownSources)
+	if err != nil {
+		t.Fatalf("AddUserMapping() error = %v", err)
+	}
+	if want := map[string]string{"aur": "code", "snap": "code"}; !maps.Equal(saved.Sources, want) {
+		t.Errorf("AddUserMapping() = %v, want %v", saved.Sources, want)
+	}
+
+	_, err = store.AddUserMapping(&Mapping{Canonical: "vscode", Sources: map[string]string{"flatpack": "com.visualstudio.code"}}, knownSources)
+	if !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("AddUserMapping() of an unknown source error = %v, want ErrUnknownSource", err)
+	}
+
+	mappings, err := store.UserMappings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 1 || mappings[0].Sources["flatpack"] != "" {
+		t.Errorf("UserMappings() = %+v, want vscode without the unknown source", mappings)
+	}
+}
+
+func TestRemoveUserMapping(t *testing.T) {
+	store := openTestStore(t)
+
+	for _, mapping := range []*Mapping{
+		{Canonical: "vscode", Sources: map[string]string{"aur": "visual-studio-code-bin", "snap": "code"}},
+		{Canonical: "gimp", Sources: map[string]string{"flatpak": "org.gimp.GIMP"}},
+		{Canonical: "ripgrep", Sources: map[string]string{"apt": "ripgrep"}},
+	} {
+		if _, err := store.AddUserMapping(mapping, knownSources); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		canonical string
+		sources   []string
+		removed   bool
+	}{
+		{"one source", "vscode", []string{"aur"}, true},
+		{"source it does not have", "vscode", []string{"flatpak"}, false},
+		{"last source", "vscode", []string{"snap"}, true},
+		{"whole mapping", "gimp", nil, true},
+		{"no mapping", "firefox", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed, err := store.RemoveUserMapping(tt.canonical, tt.sources...)
+			if err != nil || removed != tt.removed {
+				t.Errorf("RemoveUserMapping(%s, %v) = %v, %v, want %v", tt.canonical, tt.sources, removed, err, tt.removed)
+			}
+		})
+	}
+
+	mappings, err := store.UserMappings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 1 || mappings[0].Canonical != "ripgrep" {
+		t.Errorf("UserMappings() = %+v, want only ripgrep left", mappings)
+	}
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"poxy/pkg/manager"
+
+	"go.etcd.io/bbolt"
+)
+
+// SetInstallReason records why packages of source were installed. Reasons
+// are kept apart from the cached packages, so clearing the cache keeps them.
+func (s *Store) SetInstallReason(source string, reason manager.InstallReason, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketReasons))
+		if bucket == nil {
+			return fmt.Errorf("reasons bucket not found")
+		}
+
+		sourceBucket, err := bucket.CreateBucketIfNotExists([]byte(source))
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if err := sourceBucket.Put([]byte(name), []byte(reason)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// InstallReasons returns the recorded install reasons of source's packages.
+func (s *Store) InstallReasons(source string) (map[string]manager.InstallReason, error) {
+	reasons := make(map[string]manager.InstallReason)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketReasons))
+		if bucket == nil {
+			return nil
+		}
+
+		sourceBucket := bucket.Bucket([]byte(source))
+		if sourceBucket == nil {
+			return nil
+		}
+
+		return sourceBucket.ForEach(func(name, reason []byte) error {
+			reasons[string(name)] = manager.InstallReason(reason)
+			return nil
+		})
+	})
+
+	return reasons, err
+}
+
+// ForgetInstallReasons drops the recorded reasons of removed packages.
+func (s *Store) ForgetInstallReasons(source string, names ...string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketReasons))
+		if bucket == nil {
+			return nil
+		}
+
+		sourceBucket := bucket.Bucket([]byte(source))
+		if sourceBucket == nil {
+			return nil
+		}
+
+		for _, name := range names {
+			if err := sourceBucket.Delete([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkInstallReasons sets the Reason of mgr's installed packages. Managers
+// that track install reasons are asked; the packages they do not report as
+// explicit are dependencies. For other managers the reasons recorded in
+// store are used, and packages without one stay unknown. store may be nil.
+func MarkInstallReasons(ctx context.Context, store *Store, mgr manager.Manager, packages []manager.Package) {
+	var explicit []string
+	if lister, ok := mgr.(manager.ExplicitLister); ok {
+		if names, err := lister.ListExplicit(ctx); err == nil {
+			explicit = append([]string{}, names...)
+		}
+	}
+
+	var recorded map[string]manager.InstallReason
+	if explicit == nil && store != nil {
+		recorded, _ = store.InstallReasons(mgr.Name()) //nolint:errcheck
+	}
+
+	applyReasons(packages, explicit, recorded)
+}
+
+// applyReasons sets the Reason of each package from explicit, the names a
+// manager reports as explicitly installed, or from recorded when explicit
+// is nil.
+func applyReasons(packages []manager.Package, explicit []string, recorded map[string]manager.InstallReason) {
+	var native map[string]bool
+	if explicit != nil {
+		native = make(map[string]bool, len(explicit))
+		for _, name := range explicit {
+			native[name] = true
+		}
+	}
+
+	for i := range packages {
+		switch {
+		case native != nil && native[packages[i].Name]:
+			packages[i].Reason = manager.ReasonExplicit
+		case native != nil:
+			packages[i].Reason = manager.ReasonDependency
+		default:
+			packages[i].Reason = recorded[packages[i].Name]
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+// Resolver maps the package names users type to the names a source uses:
+// configured aliases first, then cross-source mappings, so "vscode" becomes
+// "code" for apt and "com.visualstudio.code" for flatpak.
+type Resolver struct {
+	aliases  map[string]string
+	mappings *MappingStore
+}
+
+// NewResolver creates a resolver. With nil or empty mappings (such as a
+// store whose index has not loaded yet), the common mappings are used.
+func NewResolver(aliases map[string]string, mappings *MappingStore) *Resolver {
+	if mappings == nil || mappings.Size() == 0 {
+		mappings = NewMappingStore()
+		mappings.AddBatch(CommonMappings())
+	}
+	return &Resolver{aliases: aliases, mappings: mappings}
+}
+
+// Alias returns the package an alias stands for, or name if it is not an alias.
+func (r *Resolver) Alias(name string) string {
+	if target, ok := r.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// Mappings returns the mappings the resolver uses.
+func (r *Resolver) Mappings() *MappingStore {
+	return r.mappings
+}
+
+// Mapping returns the cross-source mapping of a package, after resolving
+// aliases, or nil if it has none.
+func (r *Resolver) Mapping(name string) *Mapping {
+	return r.mappings.Find(r.Alias(name))
+}
+
+// Resolve returns the name of a package in source. A name the source itself
+// uses is never rewritten.
+func (r *Resolver) Resolve(source, name string) string {
+	name = r.Alias(name)
+	if r.mappings.GetBySourceName(source, name) != nil {
+		return name
+	}
+	if mapping := r.mappings.Find(name); mapping != nil {
+		if mapped, ok := mapping.Sources[source]; ok {
+			return mapped
+		}
+	}
+	return name
+}
+
+// ResolveAll resolves a list of package names for source.
+func (r *Resolver) ResolveAll(source string, names []string) []string {
+	resolved := make([]string, len(names))
+	for i, name := range names {
+		resolved[i] = r.Resolve(source, name)
+	}
+	return resolved
+}
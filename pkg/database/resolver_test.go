@@ -0,0 +1,83 @@
+package database
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	mappings := NewMappingStore()
+	mappings.AddBatch([]*Mapping{
+		{Canonical: "vscode", Sources: map[string]string{"apt": "code", "flatpak": "com.visualstudio.code", "aur": "visual-studio-code-bin"}},
+		{Canonical: "fd", Sources: map[string]string{"apt": "fd-find", "pacman": "fd"}},
+	})
+	aliases := map[string]string{"code": "vscode", "find": "fd"}
+	r := NewResolver(aliases, mappings)
+
+	tests := []struct {
+		name   string
+		source string
+		pkg    string
+		want   string
+	}{
+		{"mapping for apt", "apt", "vscode", "code"},
+		{"mapping for flatpak", "flatpak", "vscode", "com.visualstudio.code"},
+		{"alias then mapping", "apt", "find", "fd-find"},
+		{"alias then mapping for another source", "flatpak", "code", "com.visualstudio.code"},
+		{"name the source uses is kept", "pacman", "fd", "fd"},
+		{"source the mapping lacks", "snap", "vscode", "vscode"},
+		{"mapped by another source's name", "apt", "visual-studio-code-bin", "code"},
+		{"unknown package", "apt", "htop", "htop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Resolve(tt.source, tt.pkg); got != tt.want {
+				t.Errorf("Resolve(%s, %s) = %q, want %q", tt.source, tt.pkg, got, tt.want)
+			}
+		})
+	}
+
+	got := r.ResolveAll("apt", []string{"vscode", "find", "htop"})
+	if want := []string{"code", "fd-find", "htop"}; !slices.Equal(got, want) {
+		t.Errorf("ResolveAll() = %v, want %v", got, want)
+	}
+}
+
+func TestResolverFallsBackToCommonMappings(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings *MappingStore
+	}{
+		{"nil store", nil},
+		{"empty store", NewMappingStore()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(nil, tt.mappings)
+			if got := r.Resolve("flatpak", "vscode"); got != "com.visualstudio.code" {
+				t.Errorf("Resolve(flatpak, vscode) = %q, want the common mapping", got)
+			}
+			if mapping := r.Mapping("firefox"); mapping == nil || mapping.Canonical != "firefox" {
+				t.Errorf("Mapping(firefox) = %+v, want the common mapping", mapping)
+			}
+		})
+	}
+
+	own := NewMappingStore()
+	own.Add(&Mapping{Canonical: "vscode", Sources: map[string]string{"flatpak": "com.vscodium.codium"}})
+	if got := NewResolver(nil, own).Resolve("flatpak", "vscode"); got != "com.vscodium.codium" {
+		t.Errorf("Resolve() with loaded mappings = %q, want them over the common ones", got)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	r := NewResolver(map[string]string{"code": "vscode"}, nil)
+	if got := r.Alias("code"); got != "vscode" {
+		t.Errorf("Alias(code) = %q, want vscode", got)
+	}
+	if got := r.Alias("vim"); got != "vim" {
+		t.Errorf("Alias(vim) = %q, want vim", got)
+	}
+}
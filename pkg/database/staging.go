@@ -0,0 +1,245 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+)
+
+// The staging bucket holds an index build in progress: the packages listed
+// so far, by source, and which sources are complete. Searches keep using
+// the packages bucket until CommitStaged swaps the staged sources in, and
+// a build that was interrupted can pick up where it stopped.
+const (
+	bucketStaging         = "staging"
+	bucketStagingPackages = "packages"
+	bucketStagingDone     = "done"
+
+	keyStagingStarted = "started"
+	keyStagingFull    = "full"
+)
+
+// StagedBuild describes an index build in progress.
+type StagedBuild struct {
+	Started time.Time
+	// Full is set for builds that download every catalog again
+	Full bool
+	// Done holds the sources staged completely
+	Done map[string]StagedSource
+}
+
+// StagedSource is a source staged completely.
+type StagedSource struct {
+	Packages int `json:"packages"`
+	// Catalog is when the source's catalog was downloaded, if this build
+	// downloaded it
+	Catalog time.Time `json:"catalog,omitempty"`
+}
+
+// StagedBuild returns the index build in progress, or nil if there is none.
+func (s *Store) StagedBuild() (*StagedBuild, error) {
+	var build *StagedBuild
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketStaging))
+		if bucket == nil {
+			return nil
+		}
+
+		build = &StagedBuild{
+			Full: string(bucket.Get([]byte(keyStagingFull))) == "true",
+			Done: make(map[string]StagedSource),
+		}
+		if data := bucket.Get([]byte(keyStagingStarted)); data != nil {
+			started, err := time.Parse(time.RFC3339Nano, string(data))
+			if err != nil {
+				return err
+			}
+			build.Started = started
+		}
+
+		done := bucket.Bucket([]byte(bucketStagingDone))
+		if done == nil {
+			return nil
+		}
+		return done.ForEach(func(source, data []byte) error {
+			var staged StagedSource
+			if err := json.Unmarshal(data, &staged); err != nil {
+				return nil // Staged again by the next build
+			}
+			build.Done[string(source)] = staged
+			return nil
+		})
+	})
+
+	return build, err
+}
+
+// BeginStaging starts a new index build, discarding any in progress.
+func (s *Store) BeginStaging(full bool) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(bucketStaging)); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
+			return err
+		}
+		bucket, err := tx.CreateBucket([]byte(bucketStaging))
+		if err != nil {
+			return err
+		}
+		if _, err := bucket.CreateBucket([]byte(bucketStagingPackages)); err != nil {
+			return err
+		}
+		if _, err := bucket.CreateBucket([]byte(bucketStagingDone)); err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(keyStagingFull), []byte(fmt.Sprint(full))); err != nil {
+			return err
+		}
+		return bucket.Put([]byte(keyStagingStarted), []byte(time.Now().Format(time.RFC3339Nano)))
+	})
+}
+
+// ClearStaged drops what was staged of source, so that it can be staged
+// again from the start.
+func (s *Store) ClearStaged(source string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		packages, done, err := stagingBuckets(tx)
+		if err != nil {
+			return err
+		}
+		if err := packages.DeleteBucket([]byte(source)); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
+			return err
+		}
+		return done.Delete([]byte(source))
+	})
+}
+
+// StagePackages adds entries to the staged packages of their source.
+// Entries without a LastSeen time are stamped with the current time.
+func (s *Store) StagePackages(source string, entries []PackageEntry) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		packages, _, err := stagingBuckets(tx)
+		if err != nil {
+			return err
+		}
+		sourceBucket, err := packages.CreateBucketIfNotExists([]byte(source))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, entry := range entries {
+			if entry.LastSeen.IsZero() {
+				entry.LastSeen = now
+			}
+			if entry.Keywords == nil {
+				entry.Keywords = tokenize(entry.Name + " " + entry.Description)
+			}
+
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			if err := sourceBucket.Put([]byte(entry.Name), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FinishStaged records that source is staged completely.
+func (s *Store) FinishStaged(source string, staged StagedSource) error {
+	data, err := json.Marshal(staged)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		packages, done, err := stagingBuckets(tx)
+		if err != nil {
+			return err
+		}
+		// A source with no packages still replaces the cached ones
+		if _, err := packages.CreateBucketIfNotExists([]byte(source)); err != nil {
+			return err
+		}
+		return done.Put([]byte(source), data)
+	})
+}
+
+// CommitStaged replaces the cached packages of every source staged
+// completely with the staged ones, records the catalog times of the build
+// and ends it, all in one transaction.
+func (s *Store) CommitStaged() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		staged, done, err := stagingBuckets(tx)
+		if err != nil {
+			return err
+		}
+		packages := tx.Bucket([]byte(bucketPackages))
+		meta := tx.Bucket([]byte(bucketMeta))
+		if packages == nil || meta == nil {
+			return fmt.Errorf("packages bucket not found")
+		}
+
+		err = done.ForEach(func(source, data []byte) error {
+			var info StagedSource
+			if err := json.Unmarshal(data, &info); err != nil {
+				return nil
+			}
+			if err := packages.DeleteBucket(source); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
+				return err
+			}
+			target, err := packages.CreateBucket(source)
+			if err != nil {
+				return err
+			}
+			if from := staged.Bucket(source); from != nil {
+				if err := from.ForEach(target.Put); err != nil {
+					return err
+				}
+			}
+			if !info.Catalog.IsZero() {
+				key := keyLastUpdate + ":" + string(source)
+				return meta.Put([]byte(key), []byte(info.Catalog.Format(time.RFC3339)))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := tx.DeleteBucket([]byte(bucketStaging)); err != nil {
+			return err
+		}
+		return touchPackages(tx)
+	})
+}
+
+// DiscardStaged drops the index build in progress, if any.
+func (s *Store) DiscardStaged() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(bucketStaging)); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// stagingBuckets returns the staged packages and done buckets of the build
+// in progress.
+func stagingBuckets(tx *bbolt.Tx) (packages, done *bbolt.Bucket, err error) {
+	bucket := tx.Bucket([]byte(bucketStaging))
+	if bucket == nil {
+		return nil, nil, fmt.Errorf("no index build in progress")
+	}
+	packages = bucket.Bucket([]byte(bucketStagingPackages))
+	done = bucket.Bucket([]byte(bucketStagingDone))
+	if packages == nil || done == nil {
+		return nil, nil, fmt.Errorf("index build in progress is damaged")
+	}
+	return packages, done, nil
+}
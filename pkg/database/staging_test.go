@@ -0,0 +1,204 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"poxy/pkg/manager"
+)
+
+// testEntries returns entries for packages called names.
+func testEntries(version string, names ...string) []PackageEntry {
+	entries := make([]PackageEntry, len(names))
+	for i, name := range names {
+		entries[i] = PackageEntry{Package: manager.Package{Name: name, Version: version}, Catalog: true}
+	}
+	return entries
+}
+
+// cachedVersions returns the cached packages of source as name-version
+// pairs, in name order.
+func cachedVersions(t *testing.T, store *Store, source string) []string {
+	t.Helper()
+
+	entries, err := store.GetPackagesBySource(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, entry.Name+"-"+entry.Version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+func TestCommitStagedSwapsSources(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.ReplaceSource("apt", testEntries("1.0", "curl", "vim")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ReplaceSource("flatpak", testEntries("1.0", "org.gimp.GIMP")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.BeginStaging(true); err != nil {
+		t.Fatalf("BeginStaging() error = %v", err)
+	}
+	if err := store.StagePackages("apt", testEntries("2.0", "curl")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.StagePackages("apt", testEntries("2.0", "git")); err != nil {
+		t.Fatal(err)
+	}
+	catalog := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.FinishStaged("apt", StagedSource{Packages: 2, Catalog: catalog}); err != nil {
+		t.Fatal(err)
+	}
+	// Staged, but not completely
+	if err := store.StagePackages("flatpak", testEntries("2.0", "org.inkscape.Inkscape")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Searches keep the cached packages until the build is committed
+	if got, want := cachedVersions(t, store, "apt"), []string{"curl-1.0", "vim-1.0"}; !slices.Equal(got, want) {
+		t.Errorf("apt before CommitStaged() = %v, want %v", got, want)
+	}
+
+	build, err := store.StagedBuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build == nil || !build.Full || len(build.Done) != 1 || build.Done["apt"].Packages != 2 {
+		t.Fatalf("StagedBuild() = %+v, want a full build with apt done", build)
+	}
+
+	if err := store.CommitStaged(); err != nil {
+		t.Fatalf("CommitStaged() error = %v", err)
+	}
+
+	if got, want := cachedVersions(t, store, "apt"), []string{"curl-2.0", "git-2.0"}; !slices.Equal(got, want) {
+		t.Errorf("apt after CommitStaged() = %v, want %v", got, want)
+	}
+	if got, want := cachedVersions(t, store, "flatpak"), []string{"org.gimp.GIMP-1.0"}; !slices.Equal(got, want) {
+		t.Errorf("flatpak, not staged completely, = %v, want %v", got, want)
+	}
+	if updated, err := store.GetLastUpdate("apt"); err != nil || !updated.Equal(catalog) {
+		t.Errorf("GetLastUpdate(apt) = %v, %v, want %v", updated, err, catalog)
+	}
+	if build, err := store.StagedBuild(); err != nil || build != nil {
+		t.Errorf("StagedBuild() after CommitStaged() = %+v, %v, want none", build, err)
+	}
+}
+
+func TestCommitStagedEmptySource(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.ReplaceSource("snap", testEntries("1.0", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.BeginStaging(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.FinishStaged("snap", StagedSource{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CommitStaged(); err != nil {
+		t.Fatalf("CommitStaged() error = %v", err)
+	}
+	if got := cachedVersions(t, store, "snap"); got != nil {
+		t.Errorf("snap after staging no packages = %v, want none", got)
+	}
+}
+
+func TestStagingAbort(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.ReplaceSource("apt", testEntries("1.0", "curl")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.BeginStaging(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.StagePackages("apt", testEntries("2.0", "curl", "git")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.FinishStaged("apt", StagedSource{Packages: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("clear a source", func(t *testing.T) {
+		if err := store.ClearStaged("apt"); err != nil {
+			t.Fatalf("ClearStaged() error = %v", err)
+		}
+		build, err := store.StagedBuild()
+		if err != nil || build == nil || len(build.Done) != 0 {
+			t.Errorf("StagedBuild() after ClearStaged() = %+v, %v, want a build with nothing done", build, err)
+		}
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		if err := store.DiscardStaged(); err != nil {
+			t.Fatalf("DiscardStaged() error = %v", err)
+		}
+		if build, err := store.StagedBuild(); err != nil || build != nil {
+			t.Errorf("StagedBuild() after DiscardStaged() = %+v, %v, want none", build, err)
+		}
+		if got, want := cachedVersions(t, store, "apt"), []string{"curl-1.0"}; !slices.Equal(got, want) {
+			t.Errorf("apt after DiscardStaged() = %v, want %v", got, want)
+		}
+		if err := store.DiscardStaged(); err != nil {
+			t.Errorf("DiscardStaged() without a build error = %v", err)
+		}
+	})
+
+	t.Run("without a build", func(t *testing.T) {
+		if err := store.StagePackages("apt", testEntries("2.0", "git")); err == nil {
+			t.Error("StagePackages() without a build succeeded")
+		}
+		if err := store.CommitStaged(); err == nil {
+			t.Error("CommitStaged() without a build succeeded")
+		}
+	})
+}
+
+func TestPackagesPage(t *testing.T) {
+	store := openTestStore(t)
+
+	var names []string
+	for i := 0; i < 7; i++ {
+		names = append(names, fmt.Sprintf("pkg%d", i))
+	}
+	if err := store.ReplaceSource("apt", testEntries("1.0", names...)); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	after := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("PackagesPage() does not reach the last page")
+		}
+		page, err := store.PackagesPage("apt", after, 3)
+		if err != nil {
+			t.Fatalf("PackagesPage() error = %v", err)
+		}
+		for _, entry := range page {
+			got = append(got, entry.Name)
+		}
+		if len(page) < 3 {
+			break
+		}
+		after = page[len(page)-1].Name
+	}
+	if !slices.Equal(got, names) {
+		t.Errorf("pages = %v, want %v", got, names)
+	}
+
+	if page, err := store.PackagesPage("flatpak", "", 3); err != nil || page != nil {
+		t.Errorf("PackagesPage() of an uncached source = %v, %v", page, err)
+	}
+}
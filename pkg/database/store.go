@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"poxy/internal/config"
@@ -15,12 +17,15 @@ import (
 )
 
 const (
-	bucketPackages = "packages"
-	bucketMeta     = "meta"
-	bucketMappings = "mappings"
+	bucketPackages     = "packages"
+	bucketMeta         = "meta"
+	bucketMappings     = "mappings"
+	bucketUserMappings = "user_mappings"
+	bucketReasons      = "reasons"
 
 	keyLastUpdate = "last_update"
 	keyVersion    = "version"
+	keyModified   = "packages_modified"
 )
 
 // PackageEntry represents a cached package with metadata.
@@ -28,6 +33,9 @@ type PackageEntry struct {
 	manager.Package
 	LastSeen time.Time `json:"last_seen"`
 	Keywords []string  `json:"keywords,omitempty"`
+	// Catalog is set for packages listed in the source's repositories or
+	// remotes, rather than only installed
+	Catalog bool `json:"catalog,omitempty"`
 }
 
 // Store manages the package metadata cache using BoltDB.
@@ -35,13 +43,27 @@ type Store struct {
 	db *bbolt.DB
 }
 
+// storeDir returns the directory of the package database. It is only a
+// cache, so if the data directory is not writable it is kept in a
+// temporary directory.
+func storeDir() (string, error) {
+	if config.DataDirWritable() {
+		return config.DataDir(), nil
+	}
+	dir := config.TempCacheDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return dir, nil
+}
+
 // Open opens or creates the package database.
 func Open() (*Store, error) {
-	if err := config.EnsureDataDir(); err != nil {
-		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	dir, err := storeDir()
+	if err != nil {
+		return nil, err
 	}
-
-	dbPath := config.DataDir() + "/packages.db"
+	dbPath := filepath.Join(dir, "packages.db")
 
 	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
 		Timeout: 1 * time.Second,
@@ -61,6 +83,12 @@ func Open() (*Store, error) {
 		if _, err := tx.CreateBucketIfNotExists([]byte(bucketMappings)); err != nil {
 			return err
 		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucketUserMappings)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucketReasons)); err != nil {
+			return err
+		}
 		return nil
 	})
 	if err != nil {
@@ -104,7 +132,10 @@ func (s *Store) AddPackage(pkg manager.Package) error {
 			return fmt.Errorf("failed to marshal package: %w", err)
 		}
 
-		return sourceBucket.Put([]byte(pkg.Name), data)
+		if err := sourceBucket.Put([]byte(pkg.Name), data); err != nil {
+			return err
+		}
+		return touchPackages(tx)
 	})
 }
 
@@ -140,10 +171,131 @@ func (s *Store) AddPackages(packages []manager.Package) error {
 			}
 		}
 
-		return nil
+		return touchPackages(tx)
 	})
 }
 
+// ReplaceSource replaces the cached packages of a source with entries.
+// Entries without a LastSeen time are stamped with the current time.
+func (s *Store) ReplaceSource(source string, entries []PackageEntry) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketPackages))
+		if bucket == nil {
+			return fmt.Errorf("packages bucket not found")
+		}
+
+		if err := bucket.DeleteBucket([]byte(source)); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
+			return err
+		}
+		sourceBucket, err := bucket.CreateBucket([]byte(source))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, entry := range entries {
+			if entry.LastSeen.IsZero() {
+				entry.LastSeen = now
+			}
+			if entry.Keywords == nil {
+				entry.Keywords = tokenize(entry.Name + " " + entry.Description)
+			}
+
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			if err := sourceBucket.Put([]byte(entry.Name), data); err != nil {
+				return err
+			}
+		}
+
+		return touchPackages(tx)
+	})
+}
+
+// UpdateInstalled brings the cached packages of source called names up to
+// date after an operation, given installed, the packages of source now
+// installed. Installed packages are marked installed at their current
+// version, or added. The others are marked not installed if they are in the
+// source's catalog, and deleted if not. It returns the changed entries and
+// the names of the deleted ones.
+func (s *Store) UpdateInstalled(source string, names []string, installed []manager.Package) ([]PackageEntry, []string, error) {
+	current := make(map[string]manager.Package, len(installed))
+	for _, pkg := range installed {
+		current[pkg.Name] = pkg
+	}
+
+	var (
+		changed []PackageEntry
+		deleted []string
+	)
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketPackages))
+		if bucket == nil {
+			return fmt.Errorf("packages bucket not found")
+		}
+		sourceBucket, err := bucket.CreateBucketIfNotExists([]byte(source))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, name := range names {
+			var entry PackageEntry
+			found := false
+			if data := sourceBucket.Get([]byte(name)); data != nil {
+				found = json.Unmarshal(data, &entry) == nil
+			}
+			pkg, isInstalled := current[name]
+
+			switch {
+			case isInstalled && found:
+				if entry.Installed && entry.Version == pkg.Version {
+					continue
+				}
+				entry.Installed = true
+				entry.Version = pkg.Version
+			case isInstalled:
+				pkg.Installed = true
+				entry = PackageEntry{Package: pkg, Keywords: tokenize(pkg.Name + " " + pkg.Description)}
+			case found && entry.Catalog:
+				if !entry.Installed {
+					continue
+				}
+				entry.Installed = false
+			case found:
+				if err := sourceBucket.Delete([]byte(name)); err != nil {
+					return err
+				}
+				deleted = append(deleted, name)
+				continue
+			default:
+				continue
+			}
+
+			entry.LastSeen = now
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("failed to marshal package: %w", err)
+			}
+			if err := sourceBucket.Put([]byte(name), data); err != nil {
+				return err
+			}
+			changed = append(changed, entry)
+		}
+
+		if len(changed) == 0 && len(deleted) == 0 {
+			return nil
+		}
+		return touchPackages(tx)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return changed, deleted, nil
+}
+
 // GetPackage retrieves a package from the cache.
 func (s *Store) GetPackage(source, name string) (*PackageEntry, error) {
 	var entry *PackageEntry
@@ -229,6 +381,44 @@ func (s *Store) GetPackagesBySource(source string) ([]PackageEntry, error) {
 	return packages, err
 }
 
+// PackagesPage returns up to limit cached packages of source in name order,
+// starting after the package named after, or at the first if after is
+// empty. A page shorter than limit is the last.
+func (s *Store) PackagesPage(source, after string, limit int) ([]PackageEntry, error) {
+	var packages []PackageEntry
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketPackages))
+		if bucket == nil {
+			return nil
+		}
+
+		sourceBucket := bucket.Bucket([]byte(source))
+		if sourceBucket == nil {
+			return nil
+		}
+
+		c := sourceBucket.Cursor()
+		k, data := c.First()
+		if after != "" {
+			k, data = c.Seek([]byte(after))
+			if k != nil && string(k) == after {
+				k, data = c.Next()
+			}
+		}
+		for ; k != nil && len(packages) < limit; k, data = c.Next() {
+			var entry PackageEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				continue
+			}
+			packages = append(packages, entry)
+		}
+		return nil
+	})
+
+	return packages, err
+}
+
 // DeletePackage removes a package from the cache.
 func (s *Store) DeletePackage(source, name string) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
@@ -242,7 +432,10 @@ func (s *Store) DeletePackage(source, name string) error {
 			return nil
 		}
 
-		return sourceBucket.Delete([]byte(name))
+		if err := sourceBucket.Delete([]byte(name)); err != nil {
+			return err
+		}
+		return touchPackages(tx)
 	})
 }
 
@@ -254,7 +447,10 @@ func (s *Store) ClearSource(source string) error {
 			return nil
 		}
 
-		return bucket.DeleteBucket([]byte(source))
+		if err := bucket.DeleteBucket([]byte(source)); err != nil {
+			return err
+		}
+		return touchPackages(tx)
 	})
 }
 
@@ -264,9 +460,44 @@ func (s *Store) Clear() error {
 		if err := tx.DeleteBucket([]byte(bucketPackages)); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
 			return err
 		}
-		_, err := tx.CreateBucket([]byte(bucketPackages))
+		if _, err := tx.CreateBucket([]byte(bucketPackages)); err != nil {
+			return err
+		}
+		return touchPackages(tx)
+	})
+}
+
+// touchPackages records that the cached packages changed, so indexes
+// saved from them are known to be stale.
+func touchPackages(tx *bbolt.Tx) error {
+	bucket := tx.Bucket([]byte(bucketMeta))
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Put([]byte(keyModified), []byte(time.Now().Format(time.RFC3339Nano)))
+}
+
+// PackagesModified returns when the cached packages last changed, or the
+// zero time if that was never recorded.
+func (s *Store) PackagesModified() (time.Time, error) {
+	var t time.Time
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketMeta))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(keyModified))
+		if data == nil {
+			return nil
+		}
+
+		var err error
+		t, err = time.Parse(time.RFC3339Nano, string(data))
 		return err
 	})
+
+	return t, err
 }
 
 // SetLastUpdate sets the last update time for a source.
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+func TestUpdateInstalled(t *testing.T) {
+	store := openTestStore(t)
+
+	entries := testEntries("1.0", "git", "vim", "nano")
+	entries[1].Installed = true
+	entries[2].Installed = true
+	entries = append(entries, PackageEntry{Package: manager.Package{Name: "local-tool", Version: "0.1", Installed: true}})
+	if err := store.ReplaceSource("pacman", entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// git installed, vim upgraded, nano and local-tool removed, htop
+	// installed without being cached, ghost never there
+	installed := []manager.Package{
+		{Name: "git", Version: "2.0", Source: "pacman"},
+		{Name: "vim", Version: "1.1", Source: "pacman"},
+		{Name: "htop", Version: "3.0", Source: "pacman", Description: "Interactive process viewer"},
+	}
+	names := []string{"git", "vim", "nano", "local-tool", "htop", "ghost"}
+	changed, deleted, err := store.UpdateInstalled("pacman", names, installed)
+	if err != nil {
+		t.Fatalf("UpdateInstalled() error = %v", err)
+	}
+
+	var changedNames []string
+	for _, entry := range changed {
+		changedNames = append(changedNames, entry.Name)
+	}
+	sort.Strings(changedNames)
+	if want := []string{"git", "htop", "nano", "vim"}; !slices.Equal(changedNames, want) {
+		t.Errorf("changed = %v, want %v", changedNames, want)
+	}
+	if want := []string{"local-tool"}; !slices.Equal(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+
+	cached, err := store.GetPackagesBySource("pacman")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range cached {
+		got = append(got, entry.Name+"-"+entry.Version+map[bool]string{true: " installed", false: ""}[entry.Installed])
+	}
+	sort.Strings(got)
+	if want := []string{"git-2.0 installed", "htop-3.0 installed", "nano-1.0", "vim-1.1 installed"}; !slices.Equal(got, want) {
+		t.Errorf("cached = %v, want %v", got, want)
+	}
+
+	before, err := store.PackagesModified()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, deleted, err = store.UpdateInstalled("pacman", names, installed)
+	if err != nil || len(changed) > 0 || len(deleted) > 0 {
+		t.Errorf("UpdateInstalled() again = %v, %v, %v, want no changes", changed, deleted, err)
+	}
+	if after, _ := store.PackagesModified(); !after.Equal(before) {
+		t.Error("UpdateInstalled() without changes marked the packages modified")
+	}
+}
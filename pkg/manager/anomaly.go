@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAnomalySample bounds the output kept with a parser anomaly.
+const maxAnomalySample = 512
+
+// ParserAnomaly is output of a package manager's tool that poxy could not
+// parse, usually because a new version of the tool changed its format.
+type ParserAnomaly struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Command string    `json:"command"`
+	Reason  string    `json:"reason"`
+	Sample  string    `json:"sample"` // Start of the output
+}
+
+var (
+	anomalyMu       sync.Mutex
+	anomalyRecorder func(ParserAnomaly)
+)
+
+// SetAnomalyRecorder sets where parser anomalies are sent. They are
+// dropped while no recorder is set, as in tests.
+func SetAnomalyRecorder(record func(ParserAnomaly)) {
+	anomalyMu.Lock()
+	defer anomalyMu.Unlock()
+	anomalyRecorder = record
+}
+
+// ReportParserAnomaly reports that the output of command, run for source,
+// could not be parsed.
+func ReportParserAnomaly(source, command, reason, output string) {
+	anomalyMu.Lock()
+	record := anomalyRecorder
+	anomalyMu.Unlock()
+	if record == nil {
+		return
+	}
+
+	sample := strings.TrimSpace(output)
+	if len(sample) > maxAnomalySample {
+		sample = sample[:maxAnomalySample]
+	}
+	record(ParserAnomaly{
+		Time:    time.Now(),
+		Source:  source,
+		Command: command,
+		Reason:  reason,
+		Sample:  sample,
+	})
+}
+
+// CheckParsed reports a parser anomaly if output that is not empty gave no
+// packages. Call it only where such output always lists packages, not
+// where packages are filtered out or the tool prints a "no matches"
+// message.
+func CheckParsed(source, command, output string, parsed int) {
+	if parsed == 0 && strings.TrimSpace(output) != "" {
+		ReportParserAnomaly(source, command, "no packages parsed from the output", output)
+	}
+}
@@ -0,0 +1,34 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckParsed(t *testing.T) {
+	var got []ParserAnomaly
+	SetAnomalyRecorder(func(a ParserAnomaly) { got = append(got, a) })
+	defer SetAnomalyRecorder(nil)
+
+	CheckParsed("apt", "apt-cache search", "", 0)
+	CheckParsed("apt", "apt-cache search", "vim - Vi IMproved", 1)
+	if len(got) != 0 {
+		t.Fatalf("anomalies for empty or parsed output: %+v", got)
+	}
+
+	CheckParsed("dnf", "dnf list installed", "Installed packages\n"+strings.Repeat("x", 1000), 0)
+	if len(got) != 1 {
+		t.Fatalf("got %d anomalies, want 1", len(got))
+	}
+	if got[0].Source != "dnf" || got[0].Command != "dnf list installed" || got[0].Time.IsZero() {
+		t.Errorf("anomaly = %+v", got[0])
+	}
+	if len(got[0].Sample) != maxAnomalySample || !strings.HasPrefix(got[0].Sample, "Installed packages") {
+		t.Errorf("sample = %q", got[0].Sample)
+	}
+}
+
+func TestReportParserAnomalyWithoutRecorder(t *testing.T) {
+	SetAnomalyRecorder(nil)
+	ReportParserAnomaly("snap", "snap find", "no packages", "output") // Must not panic
+}
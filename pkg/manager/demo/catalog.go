@@ -0,0 +1,69 @@
+package demo
+
+import (
+	"time"
+
+	"poxy/pkg/manager"
+)
+
+// catalog is the packages of the simulated system as it starts out. Some
+// names are offered by several sources, and some installed packages have
+// upgrades, so every flow has something to show.
+var catalog = map[string][]pkgState{
+	"apt": {
+		{Name: "vim", Version: "2:9.0.1378-2", NewVersion: "2:9.0.1378-2+deb12u1", Description: "Vi IMproved - enhanced vi editor", Size: "1.7 MB", License: "Vim", URL: "https://www.vim.org/", Dependencies: []string{"vim-runtime", "libc6"}, Installed: true, Reason: manager.ReasonExplicit},
+		{Name: "vim-runtime", Version: "2:9.0.1378-2", Description: "Vi IMproved - Runtime files", Size: "7.0 MB", License: "Vim", Installed: true, Reason: manager.ReasonDependency},
+		{Name: "git", Version: "1:2.39.2-1.1", NewVersion: "1:2.39.5-0+deb12u1", Description: "fast, scalable, distributed revision control system", Size: "7.3 MB", License: "GPL-2.0", URL: "https://git-scm.com/", Dependencies: []string{"libcurl4", "libc6"}, Installed: true, Reason: manager.ReasonExplicit},
+		{Name: "curl", Version: "7.88.1-10+deb12u5", Description: "command line tool for transferring data with URL syntax", Size: "315 kB", License: "curl", URL: "https://curl.se/", Dependencies: []string{"libcurl4"}, Installed: true, Reason: manager.ReasonExplicit},
+		{Name: "libcurl4", Version: "7.88.1-10+deb12u5", Description: "easy-to-use client-side URL transfer library (OpenSSL flavour)", Size: "391 kB", License: "curl", Installed: true, Reason: manager.ReasonDependency},
+		{Name: "libc6", Version: "2.36-9+deb12u4", NewVersion: "2.36-9+deb12u7", Description: "GNU C Library: Shared libraries", Size: "2.8 MB", License: "LGPL-2.1", Installed: true, Reason: manager.ReasonDependency},
+		{Name: "python3", Version: "3.11.2-1+b1", Description: "interactive high-level object-oriented language (default python3 version)", Size: "26 kB", License: "PSF-2.0", URL: "https://www.python.org/", Installed: true, Reason: manager.ReasonExplicit},
+		{Name: "htop", Version: "3.2.2-2", Description: "interactive processes viewer", Size: "152 kB", License: "GPL-2.0", URL: "https://htop.dev/", Dependencies: []string{"libc6"}},
+		{Name: "neovim", Version: "0.7.2-7", Description: "heavily refactored vim fork", Size: "1.4 MB", License: "Apache-2.0", URL: "https://neovim.io/", Dependencies: []string{"neovim-runtime", "libc6"}},
+		{Name: "neovim-runtime", Version: "0.7.2-7", Description: "heavily refactored vim fork (runtime files)", Size: "5.3 MB", License: "Apache-2.0"},
+		{Name: "tmux", Version: "3.3a-3", Description: "terminal multiplexer", Size: "455 kB", License: "ISC", URL: "https://github.com/tmux/tmux", Dependencies: []string{"libc6"}},
+		{Name: "ripgrep", Version: "13.0.0-4+b2", Description: "Recursively searches directories for a regex pattern", Size: "1.5 MB", License: "MIT", URL: "https://github.com/BurntSushi/ripgrep"},
+		{Name: "fd-find", Version: "8.6.0-3", Description: "Simple, fast and user-friendly alternative to find", Size: "1.0 MB", License: "MIT", URL: "https://github.com/sharkdp/fd"},
+		{Name: "bat", Version: "0.22.1-4", Description: "cat(1) clone with syntax highlighting and git integration", Size: "2.0 MB", License: "MIT", URL: "https://github.com/sharkdp/bat"},
+		{Name: "jq", Version: "1.6-2.1", Description: "lightweight and flexible command-line JSON processor", Size: "64 kB", License: "MIT", URL: "https://jqlang.github.io/jq/", Dependencies: []string{"libjq1"}},
+		{Name: "libjq1", Version: "1.6-2.1", Description: "lightweight and flexible command-line JSON processor - shared library", Size: "135 kB", License: "MIT"},
+		{Name: "firefox-esr", Version: "115.9.1esr-1~deb12u1", Description: "Mozilla Firefox web browser - Extended Support Release (ESR)", Size: "69 MB", License: "MPL-2.0", URL: "https://www.mozilla.org/firefox/"},
+		{Name: "docker.io", Version: "20.10.24+dfsg1-1+b3", Description: "Linux container runtime", Size: "27 MB", License: "Apache-2.0", URL: "https://mobyproject.org/"},
+		{Name: "gimp", Version: "2.10.34-1", Description: "GNU Image Manipulation Program", Size: "5.1 MB", License: "GPL-3.0", URL: "https://www.gimp.org/"},
+		{Name: "vlc", Version: "3.0.20-0+deb12u1", Description: "multimedia player and streamer", Size: "2.0 MB", License: "GPL-2.0", URL: "https://www.videolan.org/vlc/"},
+	},
+	"flatpak": {
+		{Name: "org.mozilla.firefox", Version: "124.0.2", Description: "Firefox - Fast, Private & Safe Web Browser", Size: "103 MB", License: "MPL-2.0", URL: "https://www.mozilla.org/firefox/"},
+		{Name: "com.spotify.Client", Version: "1.2.31.1205", Description: "Spotify - Online music streaming service", Size: "184 MB", License: "LicenseRef-proprietary", URL: "https://www.spotify.com/"},
+		{Name: "com.discordapp.Discord", Version: "0.0.49", Description: "Discord - Messaging, voice and video client", Size: "95 MB", License: "LicenseRef-proprietary", URL: "https://discord.com/"},
+		{Name: "org.gimp.GIMP", Version: "2.10.36", Description: "GNU Image Manipulation Program - Create images and edit photographs", Size: "139 MB", License: "GPL-3.0", URL: "https://www.gimp.org/"},
+		{Name: "com.visualstudio.code", Version: "1.88.0", Description: "Visual Studio Code - Code editing. Redefined.", Size: "112 MB", License: "LicenseRef-proprietary", URL: "https://code.visualstudio.com/"},
+		{Name: "org.videolan.VLC", Version: "3.0.20", NewVersion: "3.0.21", Description: "VLC - VLC media player, the open-source multimedia player", Size: "92 MB", License: "GPL-2.0", URL: "https://www.videolan.org/vlc/", Installed: true, Reason: manager.ReasonExplicit},
+		{Name: "com.obsproject.Studio", Version: "30.1.2", Description: "OBS Studio - Live stream and record videos", Size: "120 MB", License: "GPL-2.0", URL: "https://obsproject.com/"},
+	},
+	"snap": {
+		{Name: "spotify", Version: "1.2.31.1205", Description: "Music for everyone", Size: "184 MB", License: "Proprietary", URL: "https://www.spotify.com/"},
+		{Name: "discord", Version: "0.0.49", Description: "All-in-one voice and text chat for gamers", Size: "93 MB", License: "Proprietary", URL: "https://discord.com/"},
+		{Name: "code", Version: "1.88.0", Description: "Code editing. Redefined.", Size: "330 MB", License: "Proprietary", URL: "https://code.visualstudio.com/"},
+		{Name: "slack", Version: "4.37.101", Description: "Team communication for the 21st century.", Size: "139 MB", License: "Proprietary", URL: "https://slack.com/"},
+		{Name: "postman", Version: "10.24.16", NewVersion: "11.0.5", Description: "API Development Environment", Size: "180 MB", License: "Proprietary", URL: "https://www.postman.com/", Installed: true, Reason: manager.ReasonExplicit},
+		{Name: "firefox", Version: "124.0.2-1", Description: "Mozilla Firefox web browser", Size: "266 MB", License: "MPL-2.0", URL: "https://www.mozilla.org/firefox/"},
+	},
+}
+
+// seed returns a fresh copy of the catalog, with installed packages dated
+// a month back.
+func seed() map[string][]*pkgState {
+	installedAt := time.Now().AddDate(0, -1, 0)
+	packages := make(map[string][]*pkgState, len(catalog))
+	for mgr, entries := range catalog {
+		for _, entry := range entries {
+			p := entry
+			if p.Installed {
+				p.InstalledAt = installedAt
+			}
+			packages[mgr] = append(packages[mgr], &p)
+		}
+	}
+	return packages
+}
@@ -0,0 +1,130 @@
+// Package demo simulates a system with fake packages, so every command can
+// be tried without root and without changing anything: for screenshots,
+// onboarding, and for exercising Linux-only flows on macOS or Windows.
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"poxy/pkg/manager"
+	"poxy/pkg/manager/detector"
+)
+
+// System is a simulated Debian system with APT, Flatpak and Snap. Its
+// managers share one state, saved to a file so installs and removals carry
+// over between runs.
+type System struct {
+	path string
+
+	mu       sync.Mutex
+	packages map[string][]*pkgState // By manager name
+}
+
+// pkgState is a simulated package and whether it is installed.
+type pkgState struct {
+	Name         string                `json:"name"`
+	Version      string                `json:"version"`
+	NewVersion   string                `json:"new_version,omitempty"` // Version an upgrade brings
+	Description  string                `json:"description"`
+	Size         string                `json:"size,omitempty"`
+	License      string                `json:"license,omitempty"`
+	URL          string                `json:"url,omitempty"`
+	Dependencies []string              `json:"dependencies,omitempty"`
+	Installed    bool                  `json:"installed"`
+	Reason       manager.InstallReason `json:"reason,omitempty"`
+	InstalledAt  time.Time             `json:"installed_at,omitzero"`
+}
+
+// Open opens the simulated system whose state is saved at path, starting
+// from the demo catalog if there is no saved state yet.
+func Open(path string) (*System, error) {
+	s := &System{path: path}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		s.packages = seed()
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read the demo state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.packages); err != nil {
+		return nil, fmt.Errorf("failed to parse the demo state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Info describes the simulated system.
+func (s *System) Info() *detector.SystemInfo {
+	return &detector.SystemInfo{
+		OS:           detector.OSLinux,
+		Arch:         runtime.GOARCH,
+		Distribution: "debian",
+		PrettyName:   "Debian GNU/Linux 12 (bookworm, simulated)",
+		VersionID:    "12",
+	}
+}
+
+// Managers returns the simulated managers, the native one first.
+func (s *System) Managers() []manager.Manager {
+	return []manager.Manager{
+		&Manager{
+			sys:         s,
+			name:        "apt",
+			displayName: "APT (simulated)",
+			managerType: manager.TypeNative,
+			needsSudo:   true,
+			version:     "2.6.1",
+			commands:    commands{install: "apt-get install -y", uninstall: "apt-get remove -y", update: "apt-get update", upgrade: "apt-get upgrade -y", clean: "apt-get clean"},
+		},
+		&Manager{
+			sys:         s,
+			name:        "flatpak",
+			displayName: "Flatpak (simulated)",
+			managerType: manager.TypeUniversal,
+			version:     "1.14.4",
+			commands:    commands{install: "flatpak install -y flathub", uninstall: "flatpak uninstall -y", update: "flatpak update --appstream", upgrade: "flatpak update -y", clean: "flatpak uninstall --unused -y"},
+		},
+		&Manager{
+			sys:         s,
+			name:        "snap",
+			displayName: "Snap (simulated)",
+			managerType: manager.TypeUniversal,
+			needsSudo:   true,
+			version:     "2.61.3",
+			commands:    commands{install: "snap install", uninstall: "snap remove", update: "snap refresh --list", upgrade: "snap refresh", clean: "snap set system refresh.retain=2"},
+		},
+	}
+}
+
+// find returns the package name of mgr, or nil.
+func (s *System) find(mgr, name string) *pkgState {
+	for _, p := range s.packages[mgr] {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// save writes the state to its file.
+func (s *System) save() error {
+	data, err := json.MarshalIndent(s.packages, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to save the demo state: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save the demo state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package demo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+func TestSystemKeepsState(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "demo-system.json")
+
+	sys, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	apt := sys.Managers()[0]
+	if apt.Name() != "apt" || apt.Type() != manager.TypeNative {
+		t.Fatalf("first manager = %s, want the native apt", apt.Name())
+	}
+
+	if err := apt.Install(ctx, []string{"neovim"}, manager.InstallOpts{}); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if err := apt.Install(ctx, []string{"nothere"}, manager.InstallOpts{}); err == nil {
+		t.Error("Install() of an unknown package should fail")
+	}
+
+	// A new run sees the install, and the dependency it pulled in
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	apt = reopened.Managers()[0]
+	for _, name := range []string{"neovim", "neovim-runtime"} {
+		if installed, _ := apt.IsInstalled(ctx, name); !installed { //nolint:errcheck
+			t.Errorf("%s should be installed after reopening", name)
+		}
+	}
+
+	if err := apt.Uninstall(ctx, []string{"neovim"}, manager.UninstallOpts{}); err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	if err := apt.Autoremove(ctx); err != nil {
+		t.Fatalf("Autoremove() error: %v", err)
+	}
+	if installed, _ := apt.IsInstalled(ctx, "neovim-runtime"); installed { //nolint:errcheck
+		t.Error("Autoremove() should remove the dependency nothing needs")
+	}
+	if installed, _ := apt.IsInstalled(ctx, "vim-runtime"); !installed { //nolint:errcheck
+		t.Error("Autoremove() removed a dependency vim still needs")
+	}
+}
+
+func TestDryRunChangesNothing(t *testing.T) {
+	ctx := context.Background()
+	sys, err := Open(filepath.Join(t.TempDir(), "demo-system.json"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	apt := sys.Managers()[0]
+
+	if err := apt.Install(ctx, []string{"htop"}, manager.InstallOpts{DryRun: true}); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if installed, _ := apt.IsInstalled(ctx, "htop"); installed { //nolint:errcheck
+		t.Error("a dry run installed htop")
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	ctx := context.Background()
+	sys, err := Open(filepath.Join(t.TempDir(), "demo-system.json"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	apt := sys.Managers()[0]
+	checker := apt.(manager.UpgradeChecker)
+
+	before, _ := checker.ListUpgradable(ctx) //nolint:errcheck
+	if len(before) == 0 {
+		t.Fatal("the catalog should have upgrades")
+	}
+
+	if err := apt.Upgrade(ctx, manager.UpgradeOpts{Exclude: []string{"libc6"}}); err != nil {
+		t.Fatalf("Upgrade() error: %v", err)
+	}
+	after, _ := checker.ListUpgradable(ctx) //nolint:errcheck
+	if len(after) != 1 || after[0].Name != "libc6" {
+		t.Errorf("after upgrading all but libc6, upgradable = %+v", after)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	sys, err := Open(filepath.Join(t.TempDir(), "demo-system.json"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	flatpak := sys.Managers()[1]
+
+	results, err := flatpak.Search(context.Background(), "spotify", manager.SearchOpts{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %v, %v; want one result", results, err)
+	}
+	if got := results[0].Badge(); got != "flatpak/flathub" {
+		t.Errorf("Badge() = %q, want flatpak/flathub", got)
+	}
+}
+
+func TestManagersInterface(t *testing.T) {
+	sys, err := Open(filepath.Join(t.TempDir(), "demo-system.json"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for _, mgr := range sys.Managers() {
+		var _ manager.UpgradeChecker = mgr.(*Manager)
+		var _ manager.ExplicitLister = mgr.(*Manager)
+		var _ manager.VersionReporter = mgr.(*Manager)
+	}
+}
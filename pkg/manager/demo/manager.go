@@ -0,0 +1,329 @@
+package demo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"poxy/internal/executor"
+	"poxy/pkg/manager"
+)
+
+// commands are the commands the real manager would run, shown instead of
+// running anything.
+type commands struct {
+	install   string
+	uninstall string
+	update    string
+	upgrade   string
+	clean     string
+}
+
+// Manager is a simulated package manager of a System.
+type Manager struct {
+	sys         *System
+	name        string
+	displayName string
+	managerType manager.ManagerType
+	needsSudo   bool
+	version     string
+	commands    commands
+}
+
+// Name returns the short identifier for this manager.
+func (m *Manager) Name() string {
+	return m.name
+}
+
+// DisplayName returns a human-readable name.
+func (m *Manager) DisplayName() string {
+	return m.displayName
+}
+
+// Type returns the manager type.
+func (m *Manager) Type() manager.ManagerType {
+	return m.managerType
+}
+
+// IsAvailable returns true; simulated managers are always available.
+func (m *Manager) IsAvailable() bool {
+	return true
+}
+
+// NeedsSudo returns true if the real manager needs root privileges.
+func (m *Manager) NeedsSudo() bool {
+	return m.needsSudo
+}
+
+// Version returns the version the simulated manager pretends to be.
+func (m *Manager) Version(ctx context.Context) (string, error) {
+	return m.version, nil
+}
+
+// simulate shows the command the real manager would run with args. In a
+// dry run it goes through a dry-run executor, so it joins the plan.
+func (m *Manager) simulate(ctx context.Context, dryRun bool, command string, args ...string) error {
+	fields := append(strings.Fields(command), args...)
+	if dryRun {
+		exec := executor.New(true, false)
+		if m.needsSudo {
+			return exec.RunSudo(ctx, fields[0], fields[1:]...)
+		}
+		return exec.Run(ctx, fields[0], fields[1:]...)
+	}
+
+	line := strings.Join(fields, " ")
+	if m.needsSudo {
+		line = "sudo " + line
+	}
+	fmt.Printf("Simulating: %s\n", line)
+	return nil
+}
+
+// Install marks packages and their dependencies installed.
+func (m *Manager) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	for _, name := range packages {
+		if m.sys.find(m.name, name) == nil {
+			return fmt.Errorf("E: Unable to locate package %s", name)
+		}
+	}
+	if err := m.simulate(ctx, opts.DryRun, m.commands.install, append(slices.Clone(opts.ExtraArgs), packages...)...); err != nil || opts.DryRun {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range packages {
+		p := m.sys.find(m.name, name)
+		if p.Installed && !opts.Reinstall {
+			continue
+		}
+		p.Installed, p.Reason, p.InstalledAt = true, manager.ReasonExplicit, now
+		for _, dep := range p.Dependencies {
+			if d := m.sys.find(m.name, dep); d != nil && !d.Installed {
+				d.Installed, d.Reason, d.InstalledAt = true, manager.ReasonDependency, now
+			}
+		}
+	}
+	return m.sys.save()
+}
+
+// Uninstall marks packages not installed.
+func (m *Manager) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	for _, name := range packages {
+		if p := m.sys.find(m.name, name); p == nil || !p.Installed {
+			return fmt.Errorf("package %s is not installed", name)
+		}
+	}
+	if err := m.simulate(ctx, opts.DryRun, m.commands.uninstall, packages...); err != nil || opts.DryRun {
+		return err
+	}
+
+	for _, name := range packages {
+		p := m.sys.find(m.name, name)
+		p.Installed, p.Reason, p.InstalledAt = false, manager.ReasonUnknown, time.Time{}
+	}
+	return m.sys.save()
+}
+
+// Update pretends to refresh the package database.
+func (m *Manager) Update(ctx context.Context) error {
+	return m.simulate(ctx, false, m.commands.update)
+}
+
+// Upgrade brings installed packages to their new versions.
+func (m *Manager) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	if err := m.simulate(ctx, opts.DryRun, m.commands.upgrade, append(slices.Clone(opts.ExtraArgs), opts.Packages...)...); err != nil || opts.DryRun {
+		return err
+	}
+
+	for _, p := range m.sys.packages[m.name] {
+		if !p.Installed || p.NewVersion == "" || slices.Contains(opts.Exclude, p.Name) {
+			continue
+		}
+		if len(opts.Packages) > 0 && !slices.Contains(opts.Packages, p.Name) {
+			continue
+		}
+		p.Version, p.NewVersion = p.NewVersion, ""
+	}
+	return m.sys.save()
+}
+
+// ExcludesUpgrades reports that UpgradeOpts.Exclude is applied.
+func (m *Manager) ExcludesUpgrades() bool {
+	return true
+}
+
+// Search finds packages whose name or description contains query.
+func (m *Manager) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	query = strings.ToLower(query)
+	var results []manager.Package
+	for _, p := range m.sys.packages[m.name] {
+		name := strings.ToLower(p.Name)
+		switch {
+		case opts.ExactMatch && name != query:
+			continue
+		case !strings.Contains(name, query) && !strings.Contains(strings.ToLower(p.Description), query):
+			continue
+		case opts.InstalledOnly && !p.Installed, opts.AvailableOnly && p.Installed:
+			continue
+		}
+		results = append(results, m.pkg(p))
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Info returns the details of a package.
+func (m *Manager) Info(ctx context.Context, name string) (*manager.PackageInfo, error) {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	p := m.sys.find(m.name, name)
+	if p == nil {
+		return nil, fmt.Errorf("package %s not found", name)
+	}
+	return &manager.PackageInfo{
+		Package:      m.pkg(p),
+		Repository:   m.repository(),
+		Maintainer:   "Poxy Demo <demo@example.org>",
+		License:      p.License,
+		URL:          p.URL,
+		Dependencies: p.Dependencies,
+		InstallDate:  p.InstalledAt,
+	}, nil
+}
+
+// ListInstalled returns the installed packages.
+func (m *Manager) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	var installed []manager.Package
+	for _, p := range m.sys.packages[m.name] {
+		if !p.Installed || (opts.Upgradable && p.NewVersion == "") {
+			continue
+		}
+		if opts.Pattern != "" && !strings.Contains(p.Name, opts.Pattern) {
+			continue
+		}
+		installed = append(installed, m.pkg(p))
+		if opts.Limit > 0 && len(installed) >= opts.Limit {
+			break
+		}
+	}
+	return installed, nil
+}
+
+// IsInstalled checks if a package is installed.
+func (m *Manager) IsInstalled(ctx context.Context, name string) (bool, error) {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	p := m.sys.find(m.name, name)
+	return p != nil && p.Installed, nil
+}
+
+// Clean pretends to clean the package cache.
+func (m *Manager) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	return m.simulate(ctx, opts.DryRun, m.commands.clean)
+}
+
+// Autoremove removes dependencies no installed package needs anymore.
+func (m *Manager) Autoremove(ctx context.Context) error {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	needed := make(map[string]bool)
+	for _, p := range m.sys.packages[m.name] {
+		if p.Installed {
+			for _, dep := range p.Dependencies {
+				needed[dep] = true
+			}
+		}
+	}
+	for _, p := range m.sys.packages[m.name] {
+		if p.Installed && p.Reason == manager.ReasonDependency && !needed[p.Name] {
+			p.Installed, p.Reason, p.InstalledAt = false, manager.ReasonUnknown, time.Time{}
+		}
+	}
+	return m.sys.save()
+}
+
+// ListUpgradable returns the installed packages with a new version.
+func (m *Manager) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	var candidates []manager.UpgradeCandidate
+	for _, p := range m.sys.packages[m.name] {
+		if p.Installed && p.NewVersion != "" {
+			candidates = append(candidates, manager.UpgradeCandidate{
+				Name:           p.Name,
+				Source:         m.name,
+				CurrentVersion: p.Version,
+				NewVersion:     p.NewVersion,
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// ListExplicit returns the packages installed at the user's request.
+func (m *Manager) ListExplicit(ctx context.Context) ([]string, error) {
+	m.sys.mu.Lock()
+	defer m.sys.mu.Unlock()
+
+	var names []string
+	for _, p := range m.sys.packages[m.name] {
+		if p.Installed && p.Reason == manager.ReasonExplicit {
+			names = append(names, p.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pkg converts p to a Package of this manager.
+func (m *Manager) pkg(p *pkgState) manager.Package {
+	pkg := manager.Package{
+		Name:        p.Name,
+		Version:     p.Version,
+		Description: p.Description,
+		Source:      m.name,
+		Installed:   p.Installed,
+		Size:        p.Size,
+		Reason:      p.Reason,
+	}
+	if m.name == "flatpak" {
+		pkg.Metadata = map[string]string{manager.MetaRemote: m.repository()}
+	}
+	return pkg
+}
+
+// repository returns the repository or remote packages come from.
+func (m *Manager) repository() string {
+	switch m.name {
+	case "flatpak":
+		return "flathub"
+	case "snap":
+		return "stable"
+	}
+	return "main"
+}
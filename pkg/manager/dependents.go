@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"context"
+	"sort"
+)
+
+// FindDependents returns, for each package being removed, the installed
+// packages that depend on it and are not themselves part of the removal.
+// Managers that cannot report reverse dependencies yield an empty map.
+func FindDependents(ctx context.Context, mgr Manager, packages []string) map[string][]string {
+	dependents := make(map[string][]string)
+
+	checker, ok := mgr.(ReverseDependencyChecker)
+	if !ok {
+		return dependents
+	}
+
+	removing := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		removing[pkg] = true
+	}
+
+	for _, pkg := range packages {
+		deps, err := checker.ReverseDependencies(ctx, pkg)
+		if err != nil {
+			continue
+		}
+
+		var remaining []string
+		for _, dep := range deps {
+			if !removing[dep] {
+				remaining = append(remaining, dep)
+			}
+		}
+		if len(remaining) > 0 {
+			sort.Strings(remaining)
+			dependents[pkg] = remaining
+		}
+	}
+
+	return dependents
+}
@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// rdepsManager is a MockManager that reports fixed reverse dependencies.
+type rdepsManager struct {
+	MockManager
+	rdeps map[string][]string
+}
+
+func (m *rdepsManager) ReverseDependencies(_ context.Context, pkg string) ([]string, error) {
+	deps, ok := m.rdeps[pkg]
+	if !ok {
+		return nil, errors.New("not installed")
+	}
+	return deps, nil
+}
+
+func TestFindDependents(t *testing.T) {
+	mgr := &rdepsManager{
+		MockManager: MockManager{name: "mock", available: true},
+		rdeps: map[string][]string{
+			"libfoo":  {"foo-gui", "foo-cli"},
+			"foo-cli": {},
+			"libbar":  {"foo-gui"},
+		},
+	}
+
+	got := FindDependents(context.Background(), mgr, []string{"libfoo", "foo-cli", "missing"})
+	want := map[string][]string{"libfoo": {"foo-gui"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindDependents() = %v, want %v", got, want)
+	}
+}
+
+func TestFindDependentsUnsupported(t *testing.T) {
+	mgr := &MockManager{name: "mock", available: true}
+
+	if got := FindDependents(context.Background(), mgr, []string{"vim"}); len(got) != 0 {
+		t.Errorf("FindDependents() = %v, want empty", got)
+	}
+}
@@ -15,6 +15,12 @@ const (
 	OSUnknown OSType = "unknown"
 )
 
+// Kinds of image-based systems, whose root is an image updated as a whole.
+const (
+	ImageOSTree  = "ostree"  // Fedora Silverblue, Kinoite and other rpm-ostree systems
+	ImageSteamOS = "steamos" // SteamOS with its read-only root
+)
+
 // SystemInfo contains information about the detected system.
 type SystemInfo struct {
 	OS           OSType
@@ -23,6 +29,12 @@ type SystemInfo struct {
 	DistroFamily []string // Related distributions (from ID_LIKE)
 	PrettyName   string   // Human-readable name
 	VersionID    string   // Distribution version
+	ReadOnlyRoot bool     // The root filesystem is mounted read-only
+	ImageBased   string   // ImageOSTree, ImageSteamOS, or "" for a mutable system
+
+	// ManagerVersions maps manager names to their backend versions
+	// (e.g. "pacman" -> "6.1.0"). Filled on demand by the registry.
+	ManagerVersions map[string]string
 }
 
 // Detect detects the current system's OS and distribution.
@@ -42,6 +54,8 @@ func Detect() (*SystemInfo, error) {
 		info.DistroFamily = linuxInfo.IDLike
 		info.PrettyName = linuxInfo.PrettyName
 		info.VersionID = linuxInfo.VersionID
+		info.ReadOnlyRoot = readOnlyRoot()
+		info.ImageBased = imageBased(info)
 	case "darwin":
 		info.OS = OSDarwin
 		info.Distribution = "macos"
@@ -57,6 +71,25 @@ func Detect() (*SystemInfo, error) {
 	return info, nil
 }
 
+// DetectRoot detects the Linux system installed at root, such as a broken
+// system mounted from a live environment.
+func DetectRoot(root string) (*SystemInfo, error) {
+	info := &SystemInfo{
+		OS:   OSLinux,
+		Arch: runtime.GOARCH,
+	}
+
+	linuxInfo, err := DetectLinuxAt(root)
+	if err != nil {
+		return info, err
+	}
+	info.Distribution = linuxInfo.ID
+	info.DistroFamily = linuxInfo.IDLike
+	info.PrettyName = linuxInfo.PrettyName
+	info.VersionID = linuxInfo.VersionID
+	return info, nil
+}
+
 // MatchesDistro checks if the system matches any of the given distribution identifiers.
 // It checks both the direct distribution ID and the ID_LIKE family.
 func (s *SystemInfo) MatchesDistro(distros ...string) bool {
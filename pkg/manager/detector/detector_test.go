@@ -1,7 +1,10 @@
 package detector
 
 import (
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -157,3 +160,91 @@ func TestGetNativeManagerForFamily(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "usr/lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	osRelease := "NAME=\"Arch Linux\"\nPRETTY_NAME=\"Arch Linux\"\nID=arch\n"
+	if err := os.WriteFile(filepath.Join(root, "usr/lib/os-release"), []byte(osRelease), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := DetectRoot(root)
+	if err != nil {
+		t.Fatalf("DetectRoot() returned error: %v", err)
+	}
+	if info.OS != OSLinux || info.Distribution != "arch" || info.PrettyName != "Arch Linux" {
+		t.Errorf("DetectRoot() = %+v, want Arch Linux", info)
+	}
+
+	// A root without release files is unknown
+	info, _ = DetectRoot(t.TempDir())
+	if info.Distribution != "unknown" {
+		t.Errorf("DetectRoot() of an empty root = %q, want unknown", info.Distribution)
+	}
+}
+
+func TestNativeCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     SystemInfo
+		expected []string
+	}{
+		{"EndeavourOS", SystemInfo{Distribution: "endeavouros", DistroFamily: []string{"arch"}}, []string{"pacman"}},
+		{"Manjaro", SystemInfo{Distribution: "manjaro", DistroFamily: []string{"arch"}}, []string{"pacman"}},
+		{"Pop!_OS", SystemInfo{Distribution: "pop", DistroFamily: []string{"ubuntu", "debian"}}, []string{"apt"}},
+		{"Linux Mint", SystemInfo{Distribution: "linuxmint", DistroFamily: []string{"ubuntu", "debian"}}, []string{"apt"}},
+		{"Nobara", SystemInfo{Distribution: "nobara", DistroFamily: []string{"rhel", "centos", "fedora"}}, []string{"dnf"}},
+		{"unlisted derivative", SystemInfo{Distribution: "mydistro", DistroFamily: []string{"suse", "opensuse"}}, []string{"zypper"}},
+		{"SteamOS", SystemInfo{Distribution: "steamos", DistroFamily: []string{"arch"}, ReadOnlyRoot: true, ImageBased: ImageSteamOS}, []string{"flatpak", "pacman"}},
+		{"Silverblue", SystemInfo{Distribution: "fedora", ImageBased: ImageOSTree}, []string{"rpm-ostree", "dnf"}},
+		{"Bazzite", SystemInfo{Distribution: "bazzite", DistroFamily: []string{"fedora"}, ImageBased: ImageOSTree}, []string{"rpm-ostree", "dnf"}},
+		{"SteamOS with writable root", SystemInfo{Distribution: "steamos", DistroFamily: []string{"arch"}}, []string{"pacman"}},
+		{"unknown", SystemInfo{Distribution: "unknown"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := NativeCandidates(&tt.info)
+			var managers []string
+			for _, c := range candidates {
+				managers = append(managers, c.Manager)
+				if c.Reason == "" {
+					t.Errorf("candidate %s has no reason", c.Manager)
+				}
+			}
+			if strings.Join(managers, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("NativeCandidates() = %v, want %v", managers, tt.expected)
+			}
+		})
+	}
+
+	// A derivative picked by its family says so
+	info := &SystemInfo{Distribution: "mydistro", DistroFamily: []string{"arch"}}
+	if reason := NativeCandidates(info)[0].Reason; !strings.Contains(reason, "ID_LIKE") {
+		t.Errorf("reason = %q, want it to mention ID_LIKE", reason)
+	}
+}
+
+func TestParseReadOnlyRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		mounts   string
+		expected bool
+	}{
+		{"writable", "/dev/sda2 / ext4 rw,relatime 0 0\n", false},
+		{"read-only", "/dev/sda3 / btrfs ro,noatime 0 0\n/dev/sda4 /home ext4 rw 0 0\n", true},
+		{"remounted", "/dev/sda3 / btrfs ro 0 0\n/dev/sda3 / btrfs rw 0 0\n", false},
+		{"no root", "proc /proc proc rw 0 0\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseReadOnlyRoot(tt.mounts); got != tt.expected {
+				t.Errorf("parseReadOnlyRoot() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package detector
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/text/cases"
@@ -17,6 +19,11 @@ type LinuxInfo struct {
 	VersionID  string   // Version number (e.g., "22.04", "39")
 	PrettyName string   // Human-readable name
 	Name       string   // Distribution name
+	Codename   string   // Release codename (e.g., "noble", "bookworm")
+
+	// UbuntuCodename is the Ubuntu release an Ubuntu derivative such as
+	// Linux Mint is based on
+	UbuntuCodename string
 }
 
 // DetectLinux detects the Linux distribution by reading /etc/os-release.
@@ -24,7 +31,7 @@ func DetectLinux() (*LinuxInfo, error) {
 	info := &LinuxInfo{}
 
 	// Try /etc/os-release first (most common)
-	if err := parseOSRelease(info); err == nil {
+	if err := parseOSRelease(info, "/"); err == nil {
 		return info, nil
 	}
 
@@ -34,7 +41,25 @@ func DetectLinux() (*LinuxInfo, error) {
 	}
 
 	// Fall back to checking specific release files
-	if err := parseReleaseFiles(info); err == nil {
+	if err := parseReleaseFiles(info, "/"); err == nil {
+		return info, nil
+	}
+
+	// Return unknown if nothing works
+	info.ID = "unknown"
+	info.PrettyName = "Unknown Linux"
+	return info, nil
+}
+
+// DetectLinuxAt detects the Linux distribution installed at root, such as
+// a system mounted from a live environment, from its release files.
+func DetectLinuxAt(root string) (*LinuxInfo, error) {
+	info := &LinuxInfo{}
+
+	if err := parseOSRelease(info, root); err == nil {
+		return info, nil
+	}
+	if err := parseReleaseFiles(info, root); err == nil {
 		return info, nil
 	}
 
@@ -44,9 +69,13 @@ func DetectLinux() (*LinuxInfo, error) {
 	return info, nil
 }
 
-// parseOSRelease parses /etc/os-release file.
-func parseOSRelease(info *LinuxInfo) error {
-	file, err := os.Open("/etc/os-release")
+// parseOSRelease parses the os-release file of the system at root, which
+// lives in /etc or, on some systems, only in /usr/lib.
+func parseOSRelease(info *LinuxInfo, root string) error {
+	file, err := os.Open(filepath.Join(root, "etc/os-release"))
+	if os.IsNotExist(err) {
+		file, err = os.Open(filepath.Join(root, "usr/lib/os-release"))
+	}
 	if err != nil {
 		return err
 	}
@@ -74,6 +103,10 @@ func parseOSRelease(info *LinuxInfo) error {
 			info.PrettyName = value
 		case "NAME":
 			info.Name = value
+		case "VERSION_CODENAME":
+			info.Codename = value
+		case "UBUNTU_CODENAME":
+			info.UbuntuCodename = value
 		}
 	}
 
@@ -101,7 +134,7 @@ func parseLSBRelease(info *LinuxInfo) error {
 
 		switch key {
 		case "Distributor ID":
-			info.ID = strings.ToLower(value)
+			info.ID = value
 		case "Release":
 			info.VersionID = value
 		case "Description":
@@ -112,8 +145,9 @@ func parseLSBRelease(info *LinuxInfo) error {
 	return nil
 }
 
-// parseReleaseFiles checks distribution-specific release files.
-func parseReleaseFiles(info *LinuxInfo) error {
+// parseReleaseFiles checks the distribution-specific release files of the
+// system at root.
+func parseReleaseFiles(info *LinuxInfo, root string) error {
 	releaseFiles := []struct {
 		path   string
 		distro string
@@ -129,7 +163,7 @@ func parseReleaseFiles(info *LinuxInfo) error {
 	}
 
 	for _, rf := range releaseFiles {
-		if _, err := os.Stat(rf.path); err == nil {
+		if _, err := os.Stat(filepath.Join(root, rf.path)); err == nil {
 			info.ID = rf.distro
 			info.PrettyName = cases.Title(language.English).String(rf.distro) + " Linux"
 			return nil
@@ -169,6 +203,7 @@ var distroManagerMap = map[string]string{
 	"arcolinux":   "pacman",
 	"artix":       "pacman",
 	"cachyos":     "pacman",
+	"steamos":     "pacman",
 
 	// SUSE family
 	"opensuse":            "zypper",
@@ -185,6 +220,7 @@ var distroManagerMap = map[string]string{
 	"nixos":          "nix",
 	"slackware":      "slackpkg",
 	"clear-linux-os": "swupd",
+	"openwrt":        "opkg",
 }
 
 // GetNativeManager returns the native package manager for a distribution ID.
@@ -195,6 +231,99 @@ func GetNativeManager(distroID string) string {
 	return ""
 }
 
+// NativeCandidate is a manager that may be a system's native package
+// manager, with the reason it was picked.
+type NativeCandidate struct {
+	Manager string
+	Reason  string
+}
+
+// NativeCandidates returns the native package managers of a Linux system,
+// most preferred first. The registry uses the first one available, so a
+// derivative missing its family's usual manager falls back to the next.
+func NativeCandidates(info *SystemInfo) []NativeCandidate {
+	var candidates []NativeCandidate
+	add := func(mgr, reason string) {
+		for _, c := range candidates {
+			if c.Manager == mgr {
+				return
+			}
+		}
+		candidates = append(candidates, NativeCandidate{Manager: mgr, Reason: reason})
+	}
+
+	id := strings.ToLower(info.Distribution)
+
+	switch info.ImageBased {
+	case ImageOSTree:
+		// dnf may be installed, but cannot change the image
+		add("rpm-ostree", fmt.Sprintf("%s is image-based, so packages are layered onto the image with rpm-ostree", displayDistro(info)))
+	case ImageSteamOS:
+		// SteamOS is Arch based, but resets its read-only root on every
+		// update, taking packages installed with pacman with it
+		add("flatpak", "SteamOS has a read-only root that updates replace, so apps come from Flatpak")
+	}
+
+	if mgr := GetNativeManager(id); mgr != "" {
+		add(mgr, fmt.Sprintf("%s is the package manager of %s", mgr, displayDistro(info)))
+	}
+	for _, family := range info.DistroFamily {
+		if mgr := GetNativeManager(strings.ToLower(family)); mgr != "" {
+			add(mgr, fmt.Sprintf("%s is derived from %s (ID_LIKE), which uses %s", displayDistro(info), family, mgr))
+		}
+	}
+	return candidates
+}
+
+// displayDistro returns the name of the distribution for messages.
+func displayDistro(info *SystemInfo) string {
+	if info.PrettyName != "" {
+		return info.PrettyName
+	}
+	return info.Distribution
+}
+
+// imageBased returns the kind of image-based system info describes, or ""
+// if its root can be changed in place.
+func imageBased(info *SystemInfo) string {
+	if _, err := os.Stat("/run/ostree-booted"); err == nil {
+		return ImageOSTree
+	}
+	if strings.ToLower(info.Distribution) == "steamos" && info.ReadOnlyRoot {
+		return ImageSteamOS
+	}
+	return ""
+}
+
+// readOnlyRoot reports whether the root filesystem of the running system
+// is mounted read-only, as on SteamOS and other image-based systems.
+func readOnlyRoot() bool {
+	data, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return false
+	}
+	return parseReadOnlyRoot(string(data))
+}
+
+// parseReadOnlyRoot reports whether the last mount of / in a mount table
+// in the /proc/mounts format is read-only.
+func parseReadOnlyRoot(mounts string) bool {
+	readOnly := false
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[1] != "/" {
+			continue
+		}
+		readOnly = false
+		for _, opt := range strings.Split(fields[3], ",") {
+			if opt == "ro" {
+				readOnly = true
+			}
+		}
+	}
+	return readOnly
+}
+
 // GetNativeManagerForFamily checks the distribution ID and its family for a native manager.
 func GetNativeManagerForFamily(distroID string, idLike []string) string {
 	// Check direct ID first
@@ -0,0 +1,178 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the outcome of probing a package source.
+type HealthStatus int
+
+const (
+	// HealthOK means the source is configured and reachable.
+	HealthOK HealthStatus = iota
+	// HealthDegraded means the source works, but not fully (e.g. some
+	// mirrors are unreachable).
+	HealthDegraded
+	// HealthDown means searches and installs from the source will fail.
+	HealthDown
+)
+
+// String returns the status name.
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthOK:
+		return "ok"
+	case HealthDegraded:
+		return "degraded"
+	case HealthDown:
+		return "down"
+	}
+	return "unknown"
+}
+
+// Health is the result of probing a package source.
+type Health struct {
+	Status  HealthStatus
+	Summary string // One line, e.g. "3 of 3 mirrors reachable"
+	Fix     string // Suggested command or action when not OK
+}
+
+// HealthProber is implemented by managers that can check whether their
+// source is configured and reachable, so an empty search can be told apart
+// from a source that is down.
+type HealthProber interface {
+	// Probe checks the source. It should be quick and must not change the
+	// system.
+	Probe(ctx context.Context) Health
+}
+
+// SourceHealth is the health of one manager's source.
+type SourceHealth struct {
+	Source string
+	Health
+}
+
+// DefaultProbeTimeout bounds each manager's probe.
+const DefaultProbeTimeout = 10 * time.Second
+
+// ProbeSources probes the managers concurrently, each within timeout.
+// Managers that cannot probe their source are reported as OK, since they
+// are installed. Results are sorted by source name.
+func ProbeSources(ctx context.Context, managers []Manager, timeout time.Duration) []SourceHealth {
+	results := make([]SourceHealth, len(managers))
+
+	var wg sync.WaitGroup
+	for i, mgr := range managers {
+		results[i].Source = mgr.Name()
+
+		prober, ok := mgr.(HealthProber)
+		if !ok {
+			results[i].Health = Health{Status: HealthOK, Summary: "installed (no probe available)"}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, prober HealthProber) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			results[i].Health = prober.Probe(probeCtx)
+			if probeCtx.Err() == context.DeadlineExceeded && results[i].Status == HealthOK {
+				results[i].Health = Health{Status: HealthDown, Summary: fmt.Sprintf("probe timed out after %s", timeout)}
+			}
+		}(i, prober)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+	return results
+}
+
+// ProbeURL checks that an HTTP(S) server answers at rawURL. Any response
+// counts, except server errors: mirrors often return 403 or 404 for their
+// root.
+func ProbeURL(ctx context.Context, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("server error (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
+// ProbeMirrors probes the servers of urls concurrently. The source is OK
+// when every server answers, degraded when some do and down when none do.
+// Each server is probed once, however many of urls it hosts.
+func ProbeMirrors(ctx context.Context, urls []string, noun string) Health {
+	hosts := MirrorHosts(urls)
+	if len(hosts) == 0 {
+		return Health{Status: HealthDown, Summary: "no " + noun + "s configured"}
+	}
+
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			errs[i] = ProbeURL(ctx, host)
+		}(i, host)
+	}
+	wg.Wait()
+
+	var unreachable []string
+	for i, err := range errs {
+		if err != nil {
+			unreachable = append(unreachable, hosts[i])
+		}
+	}
+
+	summary := fmt.Sprintf("%d of %d %ss reachable", len(hosts)-len(unreachable), len(hosts), noun)
+	switch {
+	case len(unreachable) == 0:
+		return Health{Status: HealthOK, Summary: summary}
+	case len(unreachable) == len(hosts):
+		return Health{Status: HealthDown, Summary: summary, Fix: "Check your network connection"}
+	}
+	return Health{Status: HealthDegraded, Summary: summary + " (unreachable: " + strings.Join(unreachable, ", ") + ")"}
+}
+
+// MirrorHosts returns the scheme and host of each HTTP(S) URL, without
+// duplicates and in order. Other URLs (file://, cdrom:) are skipped.
+func MirrorHosts(urls []string) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		host := u.Scheme + "://" + u.Host + "/"
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
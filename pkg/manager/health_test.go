@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// probingManager is a MockManager whose source reports a fixed health
+type probingManager struct {
+	MockManager
+	health Health
+}
+
+func (m *probingManager) Probe(_ context.Context) Health { return m.health }
+
+func TestMirrorHosts(t *testing.T) {
+	urls := []string{
+		"http://deb.debian.org/debian",
+		"http://deb.debian.org/debian-security",
+		"https://mirror.example.com/archlinux/$repo/os/$arch",
+		"file:///srv/repo",
+		"cdrom:[Debian]/",
+	}
+	want := []string{"http://deb.debian.org/", "https://mirror.example.com/"}
+	if got := MirrorHosts(urls); !slices.Equal(got, want) {
+		t.Errorf("MirrorHosts() = %v, want %v", got, want)
+	}
+}
+
+func TestProbeMirrors(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound) // Mirror roots often 404
+	}))
+	defer up.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		urls []string
+		want HealthStatus
+	}{
+		{name: "all reachable", urls: []string{up.URL + "/repo"}, want: HealthOK},
+		{name: "some reachable", urls: []string{up.URL, broken.URL}, want: HealthDegraded},
+		{name: "none reachable", urls: []string{broken.URL}, want: HealthDown},
+		{name: "none configured", urls: nil, want: HealthDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProbeMirrors(ctx, tt.urls, "mirror"); got.Status != tt.want {
+				t.Errorf("ProbeMirrors() = %v (%s), want %v", got.Status, got.Summary, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeSources(t *testing.T) {
+	managers := []Manager{
+		&probingManager{MockManager: MockManager{name: "snap"}, health: Health{Status: HealthDown, Summary: "snapd is not running"}},
+		&MockManager{name: "cargo"},
+	}
+
+	results := ProbeSources(context.Background(), managers, time.Second)
+	if len(results) != 2 {
+		t.Fatalf("ProbeSources() returned %d results, want 2", len(results))
+	}
+	if results[0].Source != "cargo" || results[0].Status != HealthOK {
+		t.Errorf("results[0] = %+v, want cargo ok", results[0])
+	}
+	if results[1].Source != "snap" || results[1].Status != HealthDown {
+		t.Errorf("results[1] = %+v, want snap down", results[1])
+	}
+}
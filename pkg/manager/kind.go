@@ -0,0 +1,164 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is what a package is for, as far as can be told from its name and
+// metadata.
+type Kind string
+
+const (
+	KindApplication Kind = "application" // A program to run, graphical or not
+	KindLibrary     Kind = "library"     // Code, headers, bindings or data other packages use
+	KindFont        Kind = "font"
+	KindDriver      Kind = "driver" // Hardware drivers, firmware and kernel modules
+)
+
+// Kinds lists every kind, in the order they are shown.
+var Kinds = []Kind{KindApplication, KindLibrary, KindFont, KindDriver}
+
+// kindNames maps the names a kind can be given by, plurals and short
+// forms included, to the kind.
+var kindNames = map[string]Kind{
+	"application": KindApplication, "applications": KindApplication, "app": KindApplication, "apps": KindApplication,
+	"library": KindLibrary, "libraries": KindLibrary, "lib": KindLibrary, "libs": KindLibrary,
+	"font": KindFont, "fonts": KindFont,
+	"driver": KindDriver, "drivers": KindDriver,
+}
+
+// ParseKind parses a kind name, accepting plurals and short forms such as
+// "apps" and "lib".
+func ParseKind(s string) (Kind, error) {
+	if kind, ok := kindNames[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return kind, nil
+	}
+	return "", fmt.Errorf("unknown package kind %q (want application, library, font or driver)", s)
+}
+
+// Kind classifies the package. The section the source files it under and
+// AppStream metadata decide first; otherwise naming conventions do,
+// and a package that follows none is taken for an application.
+func (p Package) Kind() Kind {
+	if kind := sectionKind(p.Metadata[MetaSection]); kind != "" {
+		return kind
+	}
+	if p.Metadata[MetaAppName] != "" {
+		return KindApplication // AppStream only describes applications
+	}
+	switch p.Source {
+	case "flatpak":
+		return flatpakKind(p.Name)
+	case "snap":
+		return snapKind(p.Name)
+	}
+	return NameKind(p.Name)
+}
+
+// NameKind classifies a package by the naming conventions distributions
+// follow, such as the lib prefix and -dev suffix of libraries, for when
+// nothing else is known about it.
+func NameKind(name string) Kind {
+	name = strings.ToLower(name)
+	switch {
+	case hasAnyPrefix(name, fontPrefixes) || hasAnySuffix(name, fontSuffixes) || strings.Contains(name, "-fonts-"):
+		return KindFont
+	case hasAnyPrefix(name, driverPrefixes) || hasAnySuffix(name, driverSuffixes) || strings.Contains(name, "firmware"):
+		return KindDriver
+	case strings.HasPrefix(name, "lib") && !hasAnyPrefix(name, libNotLibraries):
+		return KindLibrary
+	case hasAnyPrefix(name, libraryPrefixes) || hasAnySuffix(name, librarySuffixes):
+		return KindLibrary
+	}
+	return KindApplication
+}
+
+var (
+	fontPrefixes   = []string{"fonts-", "font-", "ttf-", "otf-", "woff-", "xfonts-"}
+	fontSuffixes   = []string{"-fonts", "-font"}
+	driverPrefixes = []string{
+		"nvidia", "xf86-video-", "xf86-input-", "xserver-xorg-video-", "xserver-xorg-input-",
+		"kmod-", "vulkan-radeon", "vulkan-intel", "vulkan-nouveau", "mesa-vulkan-drivers",
+	}
+	driverSuffixes  = []string{"-dkms", "-driver", "-drivers", "-kmod"}
+	libNotLibraries = []string{"libreoffice", "librewolf", "libvirt-daemon", "libvirt-clients", "libinput-tools"}
+	libraryPrefixes = []string{
+		"python-", "python3-", "perl-", "ruby-", "rubygem-", "node-", "golang-", "r-cran-", "r-bioc-",
+		"haskell-", "ghc-", "php-", "lua-", "ocaml-", "gir1.2-", "qt5-", "qt6-",
+	}
+	librarySuffixes = []string{
+		"-dev", "-devel", "-dbg", "-dbgsym", "-debuginfo", "-headers", "-perl", "-java", "-common", "-data", "-runtime",
+	}
+)
+
+// sectionKind classifies a package by its APT section, or returns "" if
+// the section says nothing about its kind.
+func sectionKind(section string) Kind {
+	// APT sections of contrib and non-free carry the area, e.g. "non-free/libs"
+	if i := strings.LastIndexByte(section, '/'); i >= 0 {
+		section = section[i+1:]
+	}
+	switch section {
+	case "":
+		return ""
+	case "fonts":
+		return KindFont
+	case "kernel":
+		return KindDriver
+	case "libs", "oldlibs", "libdevel", "debug", "introspection",
+		"python", "perl", "ruby", "javascript", "golang", "rust", "haskell", "ocaml", "php", "java", "lisp":
+		return KindLibrary
+	}
+	return ""
+}
+
+// flatpakKind classifies a Flatpak ref: runtimes and their extensions are
+// libraries, GL extensions drivers, everything else an app.
+func flatpakKind(ref string) Kind {
+	switch {
+	case strings.Contains(ref, ".GL.") || strings.Contains(ref, ".GL32."):
+		return KindDriver
+	case strings.Contains(ref, ".Platform") || strings.Contains(ref, ".Sdk") ||
+		strings.Contains(ref, ".BaseApp") || strings.Contains(ref, ".Extension.") || strings.Contains(ref, ".Locale"):
+		return KindLibrary
+	}
+	return KindApplication
+}
+
+// snapKind classifies a snap: bases, such as core22, and the content snaps
+// applications share, such as gnome-42-2204, are libraries.
+func snapKind(name string) Kind {
+	switch {
+	case name == "bare" || name == "snapd" || name == "core" || versioned(name, "core"):
+		return KindLibrary
+	case versioned(name, "gnome-"), versioned(name, "kf5-"), versioned(name, "kf6-"), versioned(name, "mesa-"),
+		strings.HasSuffix(name, "-themes"):
+		return KindLibrary
+	}
+	return KindApplication
+}
+
+// versioned reports whether name is prefix followed by a version number.
+func versioned(name, prefix string) bool {
+	rest, ok := strings.CutPrefix(name, prefix)
+	return ok && rest != "" && rest[0] >= '0' && rest[0] <= '9'
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package manager
+
+import "testing"
+
+func TestPackageKind(t *testing.T) {
+	tests := []struct {
+		pkg  Package
+		want Kind
+	}{
+		{Package{Name: "vim", Source: "apt"}, KindApplication},
+		{Package{Name: "libssl3", Source: "apt"}, KindLibrary},
+		{Package{Name: "libreoffice-writer", Source: "apt"}, KindApplication},
+		{Package{Name: "zlib1g-dev", Source: "apt"}, KindLibrary},
+		{Package{Name: "python3-requests", Source: "apt"}, KindLibrary},
+		{Package{Name: "python3", Source: "apt"}, KindApplication},
+		{Package{Name: "fonts-noto-color-emoji", Source: "apt"}, KindFont},
+		{Package{Name: "google-noto-sans-fonts", Source: "dnf"}, KindFont},
+		{Package{Name: "ttf-jetbrains-mono", Source: "pacman"}, KindFont},
+		{Package{Name: "nvidia-dkms", Source: "pacman"}, KindDriver},
+		{Package{Name: "linux-firmware", Source: "pacman"}, KindDriver},
+		{Package{Name: "xf86-video-amdgpu", Source: "pacman"}, KindDriver},
+
+		// The section outweighs the name
+		{Package{Name: "gsfonts", Source: "apt", Metadata: map[string]string{MetaSection: "fonts"}}, KindFont},
+		{Package{Name: "intel-microcode", Source: "apt", Metadata: map[string]string{MetaSection: "non-free-firmware/kernel"}}, KindDriver},
+		{Package{Name: "zstd", Source: "apt", Metadata: map[string]string{MetaSection: "utils"}}, KindApplication},
+
+		// AppStream describes applications only
+		{Package{Name: "libreoffice-common", Source: "apt", Metadata: map[string]string{MetaAppName: "LibreOffice"}}, KindApplication},
+
+		{Package{Name: "org.mozilla.firefox", Source: "flatpak"}, KindApplication},
+		{Package{Name: "org.freedesktop.Platform", Source: "flatpak"}, KindLibrary},
+		{Package{Name: "org.freedesktop.Platform.GL.default", Source: "flatpak"}, KindDriver},
+		{Package{Name: "core22", Source: "snap"}, KindLibrary},
+		{Package{Name: "gnome-42-2204", Source: "snap"}, KindLibrary},
+		{Package{Name: "gnome-system-monitor", Source: "snap"}, KindApplication},
+		{Package{Name: "spotify", Source: "snap"}, KindApplication},
+	}
+	for _, tt := range tests {
+		if got := tt.pkg.Kind(); got != tt.want {
+			t.Errorf("Kind() of %s from %s = %s, want %s", tt.pkg.Name, tt.pkg.Source, got, tt.want)
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for input, want := range map[string]Kind{
+		"application": KindApplication,
+		"Apps":        KindApplication,
+		"libraries":   KindLibrary,
+		"font":        KindFont,
+		"drivers":     KindDriver,
+	} {
+		if got, err := ParseKind(input); err != nil || got != want {
+			t.Errorf("ParseKind(%q) = %s, %v; want %s", input, got, err, want)
+		}
+	}
+	if _, err := ParseKind("game"); err == nil {
+		t.Error("ParseKind() accepted an unknown kind")
+	}
+}
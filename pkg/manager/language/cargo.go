@@ -0,0 +1,503 @@
+package language
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"poxy/internal/executor"
+	"poxy/pkg/manager"
+)
+
+const (
+	// cratesIOBaseURL is the crates.io API endpoint
+	cratesIOBaseURL = "https://crates.io/api/v1"
+
+	// cratesIOTimeout is the HTTP timeout for crates.io requests
+	cratesIOTimeout = 30 * time.Second
+)
+
+// Cargo implements the Manager interface for binaries installed with cargo install.
+type Cargo struct {
+	name        string
+	displayName string
+	binary      string
+	baseURL     string
+	httpClient  *http.Client
+	exec        *executor.Executor
+}
+
+// NewCargo creates a new Cargo manager instance.
+func NewCargo() *Cargo {
+	return &Cargo{
+		name:        "cargo",
+		displayName: "Cargo (Rust)",
+		binary:      "cargo",
+		baseURL:     cratesIOBaseURL,
+		httpClient:  &http.Client{Timeout: cratesIOTimeout},
+		exec:        executor.New(false, false),
+	}
+}
+
+// Name returns the short identifier.
+func (c *Cargo) Name() string {
+	return c.name
+}
+
+// DisplayName returns the human-readable name.
+func (c *Cargo) DisplayName() string {
+	return c.displayName
+}
+
+// Type returns the manager type.
+func (c *Cargo) Type() manager.ManagerType {
+	return manager.TypeUniversal
+}
+
+// IsAvailable returns true if cargo is installed.
+func (c *Cargo) IsAvailable() bool {
+	_, err := exec.LookPath(c.binary)
+	return err == nil
+}
+
+// NeedsSudo returns true if this manager needs root privileges.
+func (c *Cargo) NeedsSudo() bool {
+	return false // cargo installs into ~/.cargo/bin
+}
+
+// InstallScope returns manager.ScopeUser: crates are installed into ~/.cargo.
+func (c *Cargo) InstallScope() manager.Scope {
+	return manager.ScopeUser
+}
+
+// Version returns the installed cargo version.
+func (c *Cargo) Version(ctx context.Context) (string, error) {
+	output, err := c.exec.OutputQuiet(ctx, c.binary, "--version")
+	if err != nil {
+		return "", err
+	}
+	return manager.ExtractVersion(output), nil
+}
+
+// Install builds and installs one or more crates.
+func (c *Cargo) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	args := []string{"install"}
+
+	if opts.Reinstall {
+		args = append(args, "--force")
+	}
+
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, packages...)
+
+	if opts.DryRun {
+		c.exec.SetDryRun(true)
+		defer c.exec.SetDryRun(false)
+	}
+
+	return c.exec.Run(ctx, c.binary, args...)
+}
+
+// Uninstall removes one or more installed crates.
+func (c *Cargo) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	args := []string{"uninstall"}
+	args = append(args, packages...)
+
+	if opts.DryRun {
+		c.exec.SetDryRun(true)
+		defer c.exec.SetDryRun(false)
+	}
+
+	return c.exec.Run(ctx, c.binary, args...)
+}
+
+// Update is a no-op for Cargo (the registry index is fetched on demand).
+func (c *Cargo) Update(ctx context.Context) error {
+	return nil
+}
+
+// Upgrade reinstalls crates whose latest version is newer than the installed one.
+// cargo install skips crates that are already up to date. Crates are upgraded
+// from where they were installed from: crates.io crates by name, git crates
+// from their repository; crates installed from a local path are left alone.
+func (c *Cargo) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	installed, err := c.installedCrates()
+	if err != nil {
+		return err
+	}
+
+	packages := opts.Packages
+	if len(packages) == 0 {
+		for name := range installed {
+			packages = append(packages, name)
+		}
+		sort.Strings(packages)
+	}
+
+	if opts.DryRun {
+		c.exec.SetDryRun(true)
+		defer c.exec.SetDryRun(false)
+	}
+
+	var registry []string
+	for _, name := range packages {
+		cr, ok := installed[name]
+		switch {
+		case !ok || cr.fromRegistry():
+			registry = append(registry, name)
+		case cr.fromGit():
+			args := append([]string{"install"}, gitInstallArgs(cr.Source)...)
+			args = append(args, opts.ExtraArgs...)
+			if err := c.exec.Run(ctx, c.binary, append(args, name)...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(registry) == 0 {
+		return nil
+	}
+	args := append([]string{"install"}, opts.ExtraArgs...)
+	return c.exec.Run(ctx, c.binary, append(args, registry...)...)
+}
+
+// crate is a crate as returned by the crates.io API.
+type crate struct {
+	Name        string `json:"name"`
+	MaxVersion  string `json:"max_version"`
+	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+	Repository  string `json:"repository"`
+}
+
+// crateVersion is a published crate version as returned by the crates.io API.
+type crateVersion struct {
+	Num     string `json:"num"`
+	License string `json:"license"`
+	Yanked  bool   `json:"yanked"`
+}
+
+// Search finds crates on crates.io matching the query.
+func (c *Cargo) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return c.ListInstalled(ctx, manager.ListOpts{Pattern: query, Limit: opts.Limit})
+	}
+
+	perPage := opts.Limit
+	if perPage <= 0 || perPage > 100 {
+		perPage = 50
+	}
+
+	var resp struct {
+		Crates []crate `json:"crates"`
+	}
+	endpoint := fmt.Sprintf("%s/crates?q=%s&per_page=%d", c.baseURL, url.QueryEscape(query), perPage)
+	if err := c.getJSON(ctx, endpoint, &resp); err != nil {
+		return []manager.Package{}, nil
+	}
+
+	packages := make([]manager.Package, 0, len(resp.Crates))
+	for _, cr := range resp.Crates {
+		if opts.ExactMatch && cr.Name != query {
+			continue
+		}
+		packages = append(packages, manager.Package{
+			Name:        cr.Name,
+			Version:     cr.MaxVersion,
+			Description: strings.TrimSpace(cr.Description),
+			Source:      c.name,
+		})
+	}
+
+	return packages, nil
+}
+
+// Info returns detailed information about a crate.
+func (c *Cargo) Info(ctx context.Context, pkg string) (*manager.PackageInfo, error) {
+	var resp struct {
+		Crate    crate          `json:"crate"`
+		Versions []crateVersion `json:"versions"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/crates/%s", c.baseURL, url.PathEscape(pkg)), &resp); err != nil {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+
+	info := &manager.PackageInfo{
+		Package: manager.Package{
+			Name:        resp.Crate.Name,
+			Version:     resp.Crate.MaxVersion,
+			Description: strings.TrimSpace(resp.Crate.Description),
+			Source:      c.name,
+		},
+		Repository: "crates.io",
+		URL:        resp.Crate.Homepage,
+	}
+	if info.URL == "" {
+		info.URL = resp.Crate.Repository
+	}
+
+	for _, v := range resp.Versions {
+		if v.Num == resp.Crate.MaxVersion {
+			info.License = v.License
+			break
+		}
+	}
+
+	if installed, err := c.installedCrates(); err == nil {
+		if cr, ok := installed[pkg]; ok {
+			info.Installed = true
+			info.Version = cr.Version
+		}
+	}
+
+	return info, nil
+}
+
+// ListInstalled returns crates installed with cargo install.
+func (c *Cargo) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
+	installed, err := c.installedCrates()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var packages []manager.Package
+	patternLower := strings.ToLower(opts.Pattern)
+
+	for _, name := range names {
+		if opts.Pattern != "" && !strings.Contains(strings.ToLower(name), patternLower) {
+			continue
+		}
+
+		pkg := manager.Package{
+			Name:      name,
+			Version:   installed[name].Version,
+			Source:    c.name,
+			Installed: true,
+		}
+
+		if opts.Upgradable {
+			// Only crates.io crates have a published version to compare with
+			if !installed[name].fromRegistry() {
+				continue
+			}
+			latest := c.latestVersion(ctx, name)
+			if latest == "" || latest == pkg.Version {
+				continue
+			}
+			pkg.Description = fmt.Sprintf("%s -> %s", pkg.Version, latest)
+		}
+
+		packages = append(packages, pkg)
+
+		if opts.Limit > 0 && len(packages) >= opts.Limit {
+			break
+		}
+	}
+
+	return packages, nil
+}
+
+// ListUpgradable returns installed crates.io crates with a newer version on
+// crates.io.
+func (c *Cargo) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	installed, err := c.installedCrates()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(installed))
+	for name, cr := range installed {
+		if cr.fromRegistry() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var candidates []manager.UpgradeCandidate
+	for _, name := range names {
+		latest := c.latestVersion(ctx, name)
+		if latest == "" || latest == installed[name].Version {
+			continue
+		}
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           name,
+			Source:         c.name,
+			CurrentVersion: installed[name].Version,
+			NewVersion:     latest,
+		})
+	}
+
+	return candidates, nil
+}
+
+// IsInstalled checks if a crate is installed.
+func (c *Cargo) IsInstalled(ctx context.Context, pkg string) (bool, error) {
+	installed, err := c.installedCrates()
+	if err != nil {
+		return false, nil
+	}
+
+	_, ok := installed[pkg]
+	return ok, nil
+}
+
+// Clean is a no-op for Cargo (cargo has no built-in cache cleanup).
+func (c *Cargo) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	return nil
+}
+
+// Autoremove is a no-op for Cargo.
+func (c *Cargo) Autoremove(ctx context.Context) error {
+	// Installed binaries have no dependency tracking
+	return nil
+}
+
+// latestVersion returns the newest published version of a crate, or "" on error.
+func (c *Cargo) latestVersion(ctx context.Context, name string) string {
+	var resp struct {
+		Crate crate `json:"crate"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/crates/%s", c.baseURL, url.PathEscape(name)), &resp); err != nil {
+		return ""
+	}
+	return resp.Crate.MaxVersion
+}
+
+// getJSON performs a GET request against the crates.io API and decodes the response.
+func (c *Cargo) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// crates.io rejects requests without a User-Agent
+	req.Header.Set("User-Agent", "poxy/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body) //nolint:errcheck
+		return fmt.Errorf("crates.io API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// installedCrate is a crate recorded in .crates.toml.
+type installedCrate struct {
+	Version string
+	// Source is where the crate was installed from, such as
+	// registry+https://github.com/rust-lang/crates.io-index,
+	// git+https://github.com/owner/repo?branch=main#<commit> or
+	// path+file:///home/user/tool
+	Source string
+}
+
+// fromRegistry returns true if the crate was installed from a registry.
+func (cr installedCrate) fromRegistry() bool {
+	return strings.HasPrefix(cr.Source, "registry+")
+}
+
+// fromGit returns true if the crate was installed from a git repository.
+func (cr installedCrate) fromGit() bool {
+	return strings.HasPrefix(cr.Source, "git+")
+}
+
+// gitInstallArgs returns the cargo install arguments that install from the
+// git repository of source, on the branch, tag or revision it was installed
+// from.
+func gitInstallArgs(source string) []string {
+	repo := strings.TrimPrefix(source, "git+")
+	repo, _, _ = strings.Cut(repo, "#")
+	repo, query, _ := strings.Cut(repo, "?")
+
+	args := []string{"--git", repo}
+	if values, err := url.ParseQuery(query); err == nil {
+		for _, ref := range []string{"branch", "tag", "rev"} {
+			if value := values.Get(ref); value != "" {
+				args = append(args, "--"+ref, value)
+			}
+		}
+	}
+	return args
+}
+
+// installedCrates reads $CARGO_HOME/.crates.toml and returns the installed
+// crates by name.
+func (c *Cargo) installedCrates() (map[string]installedCrate, error) {
+	data, err := os.ReadFile(filepath.Join(cargoHome(), ".crates.toml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]installedCrate{}, nil
+		}
+		return nil, err
+	}
+
+	return parseCratesToml(string(data))
+}
+
+// BinDir returns the directory cargo install writes binaries to.
+func (c *Cargo) BinDir(ctx context.Context) string {
+	return filepath.Join(cargoHome(), "bin")
+}
+
+// cargoHome returns the cargo home directory.
+func cargoHome() string {
+	if home := os.Getenv("CARGO_HOME"); home != "" {
+		return home
+	}
+	home, _ := os.UserHomeDir() //nolint:errcheck
+	return filepath.Join(home, ".cargo")
+}
+
+// parseCratesToml parses the contents of .crates.toml. Keys in the [v1] table
+// have the form "name version (source)" and map to the installed binaries.
+func parseCratesToml(data string) (map[string]installedCrate, error) {
+	var file struct {
+		V1 map[string][]string `toml:"v1"`
+	}
+	if _, err := toml.Decode(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse .crates.toml: %w", err)
+	}
+
+	installed := make(map[string]installedCrate, len(file.V1))
+	for key := range file.V1 {
+		fields := strings.Fields(key)
+		if len(fields) < 2 {
+			continue
+		}
+		cr := installedCrate{Version: fields[1]}
+		if len(fields) > 2 {
+			cr.Source = strings.Trim(fields[2], "()")
+		}
+		installed[fields[0]] = cr
+	}
+
+	return installed, nil
+}
@@ -0,0 +1,120 @@
+package language
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"poxy/internal/executor"
+	"poxy/pkg/manager"
+)
+
+const testCratesToml = `[v1]
+"ripgrep 14.1.0 (registry+https://github.com/rust-lang/crates.io-index)" = ["rg"]
+"cargo-edit 0.12.2 (registry+https://github.com/rust-lang/crates.io-index)" = ["cargo-add", "cargo-rm"]
+"mytool 0.1.0 (path+file:///home/user/mytool)" = ["mytool"]
+"helix-term 24.7.0 (git+https://github.com/helix-editor/helix?branch=master#0a4432b)" = ["hx"]
+`
+
+func TestCargoManager(t *testing.T) {
+	cargo := NewCargo()
+
+	if cargo.Name() != "cargo" {
+		t.Errorf("expected name 'cargo', got '%s'", cargo.Name())
+	}
+
+	if cargo.Type() != manager.TypeUniversal {
+		t.Errorf("expected Type Universal, got %s", cargo.Type())
+	}
+
+	if cargo.NeedsSudo() {
+		t.Error("Cargo should not need sudo")
+	}
+
+	var _ manager.Manager = cargo
+	var _ manager.UpgradeChecker = cargo
+	var _ manager.BinDirProvider = cargo
+}
+
+func TestParseCratesToml(t *testing.T) {
+	installed, err := parseCratesToml(testCratesToml)
+	if err != nil {
+		t.Fatalf("parseCratesToml() error = %v", err)
+	}
+
+	expected := map[string]installedCrate{
+		"ripgrep":    {Version: "14.1.0", Source: "registry+https://github.com/rust-lang/crates.io-index"},
+		"cargo-edit": {Version: "0.12.2", Source: "registry+https://github.com/rust-lang/crates.io-index"},
+		"mytool":     {Version: "0.1.0", Source: "path+file:///home/user/mytool"},
+		"helix-term": {Version: "24.7.0", Source: "git+https://github.com/helix-editor/helix?branch=master#0a4432b"},
+	}
+	if len(installed) != len(expected) {
+		t.Fatalf("expected %d crates, got %d", len(expected), len(installed))
+	}
+	for name, want := range expected {
+		if installed[name] != want {
+			t.Errorf("%s = %+v, want %+v", name, installed[name], want)
+		}
+	}
+
+	if !installed["ripgrep"].fromRegistry() || installed["mytool"].fromRegistry() || !installed["helix-term"].fromGit() {
+		t.Error("crate sources misclassified")
+	}
+
+	if _, err := parseCratesToml("not [valid"); err == nil {
+		t.Error("expected error for invalid toml")
+	}
+}
+
+func TestGitInstallArgs(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{"git+https://github.com/helix-editor/helix?branch=master#0a4432b", []string{"--git", "https://github.com/helix-editor/helix", "--branch", "master"}},
+		{"git+https://github.com/owner/tool?tag=v1.2.0#abc", []string{"--git", "https://github.com/owner/tool", "--tag", "v1.2.0"}},
+		{"git+https://github.com/owner/tool#abc", []string{"--git", "https://github.com/owner/tool"}},
+	}
+
+	for _, tt := range tests {
+		if got := gitInstallArgs(tt.source); !slices.Equal(got, tt.want) {
+			t.Errorf("gitInstallArgs(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestCargoUpgradeKeepsSources(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CARGO_HOME", home)
+	if err := os.WriteFile(filepath.Join(home, ".crates.toml"), []byte(testCratesToml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded []string
+	executor.RecordDryRun(func(c executor.Command) { recorded = append(recorded, c.String()) })
+	defer executor.RecordDryRun(nil)
+
+	if err := NewCargo().Upgrade(context.Background(), manager.UpgradeOpts{DryRun: true}); err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+
+	// mytool was installed from a local path and is not upgraded
+	want := []string{
+		"cargo install --git https://github.com/helix-editor/helix --branch master helix-term",
+		"cargo install cargo-edit ripgrep",
+	}
+	if !slices.Equal(recorded, want) {
+		t.Errorf("Upgrade() ran %q, want %q", recorded, want)
+	}
+}
+
+func TestCargoBinDir(t *testing.T) {
+	t.Setenv("CARGO_HOME", "/opt/cargo")
+
+	var provider manager.BinDirProvider = NewCargo()
+	if got := provider.BinDir(context.Background()); got != "/opt/cargo/bin" {
+		t.Errorf("BinDir() = %q, want /opt/cargo/bin", got)
+	}
+}
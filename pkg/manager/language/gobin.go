@@ -0,0 +1,481 @@
+package language
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"poxy/internal/executor"
+	"poxy/pkg/manager"
+)
+
+const (
+	// goProxyURL is the Go module proxy endpoint
+	goProxyURL = "https://proxy.golang.org"
+
+	// goProxyTimeout is the HTTP timeout for module proxy requests
+	goProxyTimeout = 30 * time.Second
+)
+
+// GoBin implements the Manager interface for binaries installed with go install.
+type GoBin struct {
+	name        string
+	displayName string
+	binary      string
+	proxyURL    string
+	httpClient  *http.Client
+	exec        *executor.Executor
+}
+
+// goBinary describes an installed Go binary as reported by `go version -m`.
+type goBinary struct {
+	File    string // Binary file name in the bin directory
+	Path    string // Main package path, e.g. golang.org/x/tools/gopls
+	Module  string // Module path, e.g. golang.org/x/tools/gopls
+	Version string // Module version, e.g. v0.15.2 or (devel)
+}
+
+// NewGoBin creates a new go install manager instance.
+func NewGoBin() *GoBin {
+	return &GoBin{
+		name:        "gobin",
+		displayName: "Go (go install)",
+		binary:      "go",
+		proxyURL:    goProxyURL,
+		httpClient:  &http.Client{Timeout: goProxyTimeout},
+		exec:        executor.New(false, false),
+	}
+}
+
+// Name returns the short identifier.
+func (g *GoBin) Name() string {
+	return g.name
+}
+
+// DisplayName returns the human-readable name.
+func (g *GoBin) DisplayName() string {
+	return g.displayName
+}
+
+// Type returns the manager type.
+func (g *GoBin) Type() manager.ManagerType {
+	return manager.TypeUniversal
+}
+
+// IsAvailable returns true if the go toolchain is installed.
+func (g *GoBin) IsAvailable() bool {
+	_, err := exec.LookPath(g.binary)
+	return err == nil
+}
+
+// NeedsSudo returns true if this manager needs root privileges.
+func (g *GoBin) NeedsSudo() bool {
+	return false // Binaries are installed into $GOBIN or $GOPATH/bin
+}
+
+// InstallScope returns manager.ScopeUser: binaries land in the user's
+// $GOBIN or $GOPATH/bin.
+func (g *GoBin) InstallScope() manager.Scope {
+	return manager.ScopeUser
+}
+
+// Version returns the installed Go toolchain version.
+func (g *GoBin) Version(ctx context.Context) (string, error) {
+	output, err := g.exec.OutputQuiet(ctx, g.binary, "version")
+	if err != nil {
+		return "", err
+	}
+	return manager.ExtractVersion(output), nil
+}
+
+// Install installs one or more Go binaries. Packages without an explicit
+// version are installed at @latest.
+func (g *GoBin) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	if opts.DryRun {
+		g.exec.SetDryRun(true)
+		defer g.exec.SetDryRun(false)
+	}
+
+	for _, pkg := range packages {
+		if !strings.Contains(pkg, "@") {
+			pkg += "@latest"
+		}
+		args := append(append([]string{"install"}, opts.ExtraArgs...), pkg)
+		if err := g.exec.Run(ctx, g.binary, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Uninstall removes installed binaries, matched by package path or file name.
+func (g *GoBin) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	installed, err := g.installedBinaries(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		g.exec.SetDryRun(true)
+		defer g.exec.SetDryRun(false)
+	}
+
+	binDir := g.BinDir(ctx)
+	for _, pkg := range packages {
+		bin := findGoBinary(installed, pkg)
+		if bin == nil {
+			return fmt.Errorf("package '%s' is not installed", pkg)
+		}
+		if err := g.exec.Run(ctx, "rm", "-f", filepath.Join(binDir, bin.File)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update is a no-op for Go (the module proxy is queried on demand).
+func (g *GoBin) Update(ctx context.Context) error {
+	return nil
+}
+
+// Upgrade reinstalls binaries at their latest version.
+func (g *GoBin) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		upgradable, err := g.ListUpgradable(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range upgradable {
+			packages = append(packages, u.Name)
+		}
+	}
+
+	if len(packages) == 0 {
+		return nil
+	}
+
+	targets := make([]string, len(packages))
+	for i, pkg := range packages {
+		targets[i] = strings.SplitN(pkg, "@", 2)[0] + "@latest"
+	}
+
+	return g.Install(ctx, targets, manager.InstallOpts{
+		AutoConfirm: opts.AutoConfirm,
+		DryRun:      opts.DryRun,
+		ExtraArgs:   opts.ExtraArgs,
+	})
+}
+
+// Search looks up a module path on the Go module proxy. The proxy has no
+// search API, so only full module paths can be found.
+func (g *GoBin) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return g.ListInstalled(ctx, manager.ListOpts{Pattern: query, Limit: opts.Limit})
+	}
+
+	if !strings.Contains(query, ".") || !strings.Contains(query, "/") {
+		return []manager.Package{}, nil
+	}
+
+	path := strings.SplitN(query, "@", 2)[0]
+	version, err := g.latestVersion(ctx, path)
+	if err != nil {
+		return []manager.Package{}, nil
+	}
+
+	return []manager.Package{{
+		Name:    path,
+		Version: version,
+		Source:  g.name,
+	}}, nil
+}
+
+// Info returns information about a module from the Go module proxy.
+func (g *GoBin) Info(ctx context.Context, pkg string) (*manager.PackageInfo, error) {
+	installed, _ := g.installedBinaries(ctx) //nolint:errcheck
+	bin := findGoBinary(installed, pkg)
+
+	module := strings.SplitN(pkg, "@", 2)[0]
+	if bin != nil {
+		module = bin.Module
+	}
+
+	info := &manager.PackageInfo{
+		Package: manager.Package{
+			Name:   module,
+			Source: g.name,
+		},
+		Repository: "proxy.golang.org",
+		URL:        "https://pkg.go.dev/" + module,
+	}
+
+	latest, err := g.latestVersion(ctx, module)
+	if err != nil && bin == nil {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+	info.Version = latest
+
+	if bin != nil {
+		info.Name = bin.Path
+		info.Installed = true
+		info.Version = bin.Version
+		if latest != "" && latest != bin.Version {
+			info.Description = fmt.Sprintf("Latest version: %s", latest)
+		}
+	}
+
+	return info, nil
+}
+
+// ListInstalled returns binaries installed with go install.
+func (g *GoBin) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
+	installed, err := g.installedBinaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []manager.Package
+	patternLower := strings.ToLower(opts.Pattern)
+
+	for _, bin := range installed {
+		if opts.Pattern != "" && !strings.Contains(strings.ToLower(bin.Path), patternLower) {
+			continue
+		}
+
+		packages = append(packages, manager.Package{
+			Name:        bin.Path,
+			Version:     bin.Version,
+			Description: bin.File,
+			Source:      g.name,
+			Installed:   true,
+		})
+
+		if opts.Limit > 0 && len(packages) >= opts.Limit {
+			break
+		}
+	}
+
+	return packages, nil
+}
+
+// ListUpgradable returns installed binaries whose module has a newer release.
+// Binaries built from local checkouts ((devel) versions) are skipped.
+func (g *GoBin) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	installed, err := g.installedBinaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []manager.UpgradeCandidate
+	for _, bin := range installed {
+		if bin.Module == "" || !strings.HasPrefix(bin.Version, "v") {
+			continue
+		}
+
+		latest, err := g.latestVersion(ctx, bin.Module)
+		if err != nil || latest == "" || latest == bin.Version {
+			continue
+		}
+
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           bin.Path,
+			Source:         g.name,
+			CurrentVersion: bin.Version,
+			NewVersion:     latest,
+		})
+	}
+
+	return candidates, nil
+}
+
+// IsInstalled checks if a binary is installed, by package path or file name.
+func (g *GoBin) IsInstalled(ctx context.Context, pkg string) (bool, error) {
+	installed, err := g.installedBinaries(ctx)
+	if err != nil {
+		return false, nil
+	}
+
+	return findGoBinary(installed, pkg) != nil, nil
+}
+
+// Clean removes the Go build cache, and the module cache with --all.
+func (g *GoBin) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	args := []string{"clean", "-cache"}
+	if opts.All {
+		args = append(args, "-modcache")
+	}
+
+	if opts.DryRun {
+		g.exec.SetDryRun(true)
+		defer g.exec.SetDryRun(false)
+	}
+
+	return g.exec.Run(ctx, g.binary, args...)
+}
+
+// Autoremove is a no-op for Go binaries.
+func (g *GoBin) Autoremove(ctx context.Context) error {
+	// Go binaries are statically linked and have no installed dependencies
+	return nil
+}
+
+// BinDir returns the directory go install writes binaries to.
+func (g *GoBin) BinDir(ctx context.Context) string {
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		return gobin
+	}
+
+	output, err := g.exec.OutputQuiet(ctx, g.binary, "env", "GOBIN", "GOPATH")
+	if err == nil {
+		lines := strings.Split(output, "\n")
+		if len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+			return strings.TrimSpace(lines[0])
+		}
+		if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+			gopath := filepath.SplitList(strings.TrimSpace(lines[1]))[0]
+			return filepath.Join(gopath, "bin")
+		}
+	}
+
+	home, _ := os.UserHomeDir() //nolint:errcheck
+	return filepath.Join(home, "go", "bin")
+}
+
+// installedBinaries reads build information from every binary in the bin directory.
+func (g *GoBin) installedBinaries(ctx context.Context) ([]goBinary, error) {
+	dir := g.BinDir(ctx)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	// go version -m exits non-zero if the directory has non-Go files, but
+	// still reports every Go binary it finds.
+	output, err := g.exec.OutputQuiet(ctx, g.binary, "version", "-m", dir)
+	if strings.TrimSpace(output) == "" && err != nil {
+		return nil, err
+	}
+
+	return parseGoVersionM(output), nil
+}
+
+// latestVersion queries the module proxy for the latest version of a module.
+func (g *GoBin) latestVersion(ctx context.Context, module string) (string, error) {
+	endpoint := fmt.Sprintf("%s/%s/@latest", g.proxyURL, escapeModulePath(module))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body) //nolint:errcheck
+		return "", fmt.Errorf("module proxy error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var latest struct {
+		Version string `json:"Version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return latest.Version, nil
+}
+
+// findGoBinary matches an installed binary by package path, module path or file name.
+func findGoBinary(installed []goBinary, pkg string) *goBinary {
+	pkg = strings.SplitN(pkg, "@", 2)[0]
+	for i := range installed {
+		if installed[i].Path == pkg || installed[i].File == pkg || installed[i].Module == pkg {
+			return &installed[i]
+		}
+	}
+	return nil
+}
+
+// escapeModulePath applies the module proxy case encoding: each uppercase
+// letter is replaced by '!' followed by its lowercase form.
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('!')
+			b.WriteRune(r + ('a' - 'A'))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// parseGoVersionM parses `go version -m <dir>` output.
+func parseGoVersionM(output string) []goBinary {
+	var binaries []goBinary
+	var current *goBinary
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, "\t") {
+			// Header line: "/path/to/bin/name: go1.22.1"
+			idx := strings.LastIndex(line, ": go")
+			if idx < 0 {
+				current = nil
+				continue
+			}
+			binaries = append(binaries, goBinary{File: filepath.Base(line[:idx])})
+			current = &binaries[len(binaries)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "path":
+			current.Path = fields[1]
+		case "mod":
+			current.Module = fields[1]
+			if len(fields) > 2 {
+				current.Version = fields[2]
+			}
+		}
+	}
+
+	// Binaries without build info cannot be managed
+	result := binaries[:0]
+	for _, bin := range binaries {
+		if bin.Path != "" {
+			result = append(result, bin)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+
+	return result
+}
@@ -0,0 +1,84 @@
+package language
+
+import (
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+func TestGoBinManager(t *testing.T) {
+	gobin := NewGoBin()
+
+	if gobin.Name() != "gobin" {
+		t.Errorf("expected name 'gobin', got '%s'", gobin.Name())
+	}
+
+	if gobin.Type() != manager.TypeUniversal {
+		t.Errorf("expected Type Universal, got %s", gobin.Type())
+	}
+
+	if gobin.NeedsSudo() {
+		t.Error("gobin should not need sudo")
+	}
+
+	var _ manager.Manager = gobin
+	var _ manager.UpgradeChecker = gobin
+	var _ manager.BinDirProvider = gobin
+}
+
+func TestParseGoVersionM(t *testing.T) {
+	output := "/home/user/go/bin/gopls: go1.22.1\n" +
+		"\tpath\tgolang.org/x/tools/gopls\n" +
+		"\tmod\tgolang.org/x/tools/gopls\tv0.15.2\th1:abc=\n" +
+		"\tdep\tgolang.org/x/mod\tv0.16.0\th1:def=\n" +
+		"\tbuild\t-compiler=gc\n" +
+		"/home/user/go/bin/dlv: go1.22.1\n" +
+		"\tpath\tgithub.com/go-delve/delve/cmd/dlv\n" +
+		"\tmod\tgithub.com/go-delve/delve\tv1.22.1\th1:ghi=\n" +
+		"/home/user/go/bin/mytool: go1.22.1\n" +
+		"\tpath\texample.com/mytool\n" +
+		"\tmod\texample.com/mytool\t(devel)\t\n"
+
+	binaries := parseGoVersionM(output)
+	if len(binaries) != 3 {
+		t.Fatalf("expected 3 binaries, got %d", len(binaries))
+	}
+
+	// Sorted by package path
+	dlv := binaries[1]
+	if dlv.File != "dlv" || dlv.Path != "github.com/go-delve/delve/cmd/dlv" {
+		t.Errorf("unexpected binary: %+v", dlv)
+	}
+	if dlv.Module != "github.com/go-delve/delve" || dlv.Version != "v1.22.1" {
+		t.Errorf("unexpected module for dlv: %s %s", dlv.Module, dlv.Version)
+	}
+
+	if binaries[2].Path != "golang.org/x/tools/gopls" || binaries[2].Version != "v0.15.2" {
+		t.Errorf("unexpected binary: %+v", binaries[2])
+	}
+
+	if binaries[0].Version != "(devel)" {
+		t.Errorf("expected (devel) version, got %s", binaries[0].Version)
+	}
+
+	if findGoBinary(binaries, "dlv") == nil {
+		t.Error("expected to find dlv by file name")
+	}
+	if findGoBinary(binaries, "golang.org/x/tools/gopls@latest") == nil {
+		t.Error("expected to find gopls by package path")
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	tests := map[string]string{
+		"golang.org/x/tools/gopls":      "golang.org/x/tools/gopls",
+		"github.com/BurntSushi/toml":    "github.com/!burnt!sushi/toml",
+		"github.com/Azure/azure-sdk-go": "github.com/!azure/azure-sdk-go",
+	}
+
+	for input, want := range tests {
+		if got := escapeModulePath(input); got != want {
+			t.Errorf("escapeModulePath(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
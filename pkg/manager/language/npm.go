@@ -0,0 +1,499 @@
+// Package language implements package managers for language ecosystems
+// whose globally installed tools live outside the system package manager.
+package language
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"poxy/internal/executor"
+	"poxy/pkg/manager"
+)
+
+const (
+	// npmRegistryURL is the npm registry endpoint
+	npmRegistryURL = "https://registry.npmjs.org"
+
+	// npmRegistryTimeout is the HTTP timeout for registry requests
+	npmRegistryTimeout = 30 * time.Second
+)
+
+// NPM implements the Manager interface for globally installed npm packages.
+// When pnpm is preferred and available, it is used instead of npm.
+type NPM struct {
+	name        string
+	displayName string
+	binary      string
+	registryURL string
+	httpClient  *http.Client
+	exec        *executor.Executor
+}
+
+// NewNPM creates a new npm-global manager instance.
+func NewNPM(usePnpm bool) *NPM {
+	binary := "npm"
+	displayName := "npm (global)"
+
+	if usePnpm {
+		if _, err := exec.LookPath("pnpm"); err == nil {
+			binary = "pnpm"
+			displayName = "pnpm (global)"
+		}
+	}
+
+	return &NPM{
+		name:        "npm",
+		displayName: displayName,
+		binary:      binary,
+		registryURL: npmRegistryURL,
+		httpClient:  &http.Client{Timeout: npmRegistryTimeout},
+		exec:        executor.New(false, false),
+	}
+}
+
+// Name returns the short identifier.
+func (n *NPM) Name() string {
+	return n.name
+}
+
+// DisplayName returns the human-readable name.
+func (n *NPM) DisplayName() string {
+	return n.displayName
+}
+
+// Type returns the manager type.
+func (n *NPM) Type() manager.ManagerType {
+	return manager.TypeUniversal
+}
+
+// IsAvailable returns true if npm (or pnpm) is installed.
+func (n *NPM) IsAvailable() bool {
+	_, err := exec.LookPath(n.binary)
+	return err == nil
+}
+
+// NeedsSudo returns true if this manager needs root privileges.
+func (n *NPM) NeedsSudo() bool {
+	return false // Global prefix is expected to be user-writable (nvm, ~/.npm-global)
+}
+
+// InstallScope returns manager.ScopeUser, for the same reason NeedsSudo
+// returns false.
+func (n *NPM) InstallScope() manager.Scope {
+	return manager.ScopeUser
+}
+
+// Version returns the installed npm (or pnpm) version.
+func (n *NPM) Version(ctx context.Context) (string, error) {
+	output, err := n.exec.OutputQuiet(ctx, n.binary, "--version")
+	if err != nil {
+		return "", err
+	}
+	return manager.ExtractVersion(output), nil
+}
+
+// isPnpm returns true if pnpm is used instead of npm.
+func (n *NPM) isPnpm() bool {
+	return n.binary == "pnpm"
+}
+
+// Install installs one or more packages globally.
+func (n *NPM) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	args := []string{"install", "-g"}
+	if n.isPnpm() {
+		args = []string{"add", "-g"}
+	}
+
+	if opts.Reinstall && !n.isPnpm() {
+		args = append(args, "--force")
+	}
+
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, packages...)
+
+	if opts.DryRun {
+		n.exec.SetDryRun(true)
+		defer n.exec.SetDryRun(false)
+	}
+
+	return n.exec.Run(ctx, n.binary, args...)
+}
+
+// Uninstall removes one or more globally installed packages.
+func (n *NPM) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	args := []string{"uninstall", "-g"}
+	if n.isPnpm() {
+		args = []string{"remove", "-g"}
+	}
+	args = append(args, packages...)
+
+	if opts.DryRun {
+		n.exec.SetDryRun(true)
+		defer n.exec.SetDryRun(false)
+	}
+
+	return n.exec.Run(ctx, n.binary, args...)
+}
+
+// Update is a no-op for npm (package metadata is fetched on demand).
+func (n *NPM) Update(ctx context.Context) error {
+	return nil
+}
+
+// Upgrade upgrades globally installed packages to their latest versions.
+func (n *NPM) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		upgradable, err := n.ListUpgradable(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range upgradable {
+			packages = append(packages, u.Name)
+		}
+	}
+
+	if len(packages) == 0 {
+		return nil
+	}
+
+	// Install @latest explicitly; `npm update -g` respects semver ranges
+	// and would not cross major versions.
+	targets := make([]string, len(packages))
+	for i, pkg := range packages {
+		targets[i] = pkg + "@latest"
+	}
+
+	return n.Install(ctx, targets, manager.InstallOpts{
+		AutoConfirm: opts.AutoConfirm,
+		DryRun:      opts.DryRun,
+		ExtraArgs:   opts.ExtraArgs,
+	})
+}
+
+// npmSearchResponse is the npm registry search API response.
+type npmSearchResponse struct {
+	Objects []struct {
+		Package npmPackage `json:"package"`
+	} `json:"objects"`
+}
+
+// npmPackage is package metadata as returned by the npm registry.
+type npmPackage struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Description  string            `json:"description"`
+	License      string            `json:"license"`
+	Homepage     string            `json:"homepage"`
+	Dependencies map[string]string `json:"dependencies"`
+	Maintainers  []struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"maintainers"`
+}
+
+// Search finds packages on the npm registry matching the query.
+func (n *NPM) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return n.ListInstalled(ctx, manager.ListOpts{Pattern: query, Limit: opts.Limit})
+	}
+
+	size := opts.Limit
+	if size <= 0 || size > 250 {
+		size = 50
+	}
+
+	var resp npmSearchResponse
+	endpoint := fmt.Sprintf("%s/-/v1/search?text=%s&size=%d", n.registryURL, url.QueryEscape(query), size)
+	if err := n.getJSON(ctx, endpoint, &resp); err != nil {
+		return []manager.Package{}, nil
+	}
+
+	packages := make([]manager.Package, 0, len(resp.Objects))
+	for _, obj := range resp.Objects {
+		if opts.ExactMatch && obj.Package.Name != query {
+			continue
+		}
+		packages = append(packages, manager.Package{
+			Name:        obj.Package.Name,
+			Version:     obj.Package.Version,
+			Description: obj.Package.Description,
+			Source:      n.name,
+		})
+	}
+
+	return packages, nil
+}
+
+// Info returns detailed information about a package from the npm registry.
+func (n *NPM) Info(ctx context.Context, pkg string) (*manager.PackageInfo, error) {
+	var meta npmPackage
+	if err := n.getJSON(ctx, fmt.Sprintf("%s/%s/latest", n.registryURL, url.PathEscape(pkg)), &meta); err != nil {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+
+	info := &manager.PackageInfo{
+		Package: manager.Package{
+			Name:        meta.Name,
+			Version:     meta.Version,
+			Description: meta.Description,
+			Source:      n.name,
+		},
+		Repository: "npmjs.org",
+		License:    meta.License,
+		URL:        meta.Homepage,
+	}
+
+	if len(meta.Maintainers) > 0 {
+		info.Maintainer = meta.Maintainers[0].Name
+		if info.Maintainer == "" {
+			info.Maintainer = meta.Maintainers[0].Username
+		}
+	}
+
+	for dep := range meta.Dependencies {
+		info.Dependencies = append(info.Dependencies, dep)
+	}
+	sort.Strings(info.Dependencies)
+
+	if installed, err := n.installedPackages(ctx); err == nil {
+		if version, ok := installed[pkg]; ok {
+			info.Installed = true
+			info.Version = version
+		}
+	}
+
+	return info, nil
+}
+
+// ListInstalled returns globally installed packages.
+func (n *NPM) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
+	installed, err := n.installedPackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var packages []manager.Package
+	patternLower := strings.ToLower(opts.Pattern)
+
+	for _, name := range names {
+		if opts.Pattern != "" && !strings.Contains(strings.ToLower(name), patternLower) {
+			continue
+		}
+
+		packages = append(packages, manager.Package{
+			Name:      name,
+			Version:   installed[name],
+			Source:    n.name,
+			Installed: true,
+		})
+
+		if opts.Limit > 0 && len(packages) >= opts.Limit {
+			break
+		}
+	}
+
+	return packages, nil
+}
+
+// ListUpgradable returns globally installed packages with a newer version available.
+func (n *NPM) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	args := []string{"outdated", "-g", "--json"}
+	if n.isPnpm() {
+		args = []string{"outdated", "-g", "--format", "json"}
+	}
+
+	// outdated exits non-zero when anything is outdated, so rely on the output
+	output, err := n.exec.OutputQuiet(ctx, n.binary, args...)
+	if strings.TrimSpace(output) == "" {
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	return parseOutdated(output, n.name)
+}
+
+// IsInstalled checks if a package is installed globally.
+func (n *NPM) IsInstalled(ctx context.Context, pkg string) (bool, error) {
+	installed, err := n.installedPackages(ctx)
+	if err != nil {
+		return false, nil
+	}
+
+	_, ok := installed[pkg]
+	return ok, nil
+}
+
+// Clean removes cached package tarballs.
+func (n *NPM) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	var args []string
+	switch {
+	case n.isPnpm():
+		args = []string{"store", "prune"}
+	case opts.All:
+		args = []string{"cache", "clean", "--force"}
+	default:
+		args = []string{"cache", "verify"}
+	}
+
+	if opts.DryRun {
+		n.exec.SetDryRun(true)
+		defer n.exec.SetDryRun(false)
+	}
+
+	return n.exec.Run(ctx, n.binary, args...)
+}
+
+// Autoremove is a no-op for npm.
+func (n *NPM) Autoremove(ctx context.Context) error {
+	// Global packages bundle their own dependencies
+	return nil
+}
+
+// installedPackages returns globally installed package names mapped to versions.
+func (n *NPM) installedPackages(ctx context.Context) (map[string]string, error) {
+	output, err := n.exec.OutputQuiet(ctx, n.binary, "ls", "-g", "--depth=0", "--json")
+	if strings.TrimSpace(output) == "" {
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{}, nil
+	}
+
+	return parseGlobalList(output)
+}
+
+// BinDir returns the directory global packages install their commands into.
+func (n *NPM) BinDir(ctx context.Context) string {
+	if n.isPnpm() {
+		output, err := n.exec.OutputQuiet(ctx, n.binary, "bin", "-g")
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(output)
+	}
+
+	output, err := n.exec.OutputQuiet(ctx, n.binary, "prefix", "-g")
+	prefix := strings.TrimSpace(output)
+	if err != nil || prefix == "" {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return prefix // Commands sit in the prefix itself
+	}
+	return filepath.Join(prefix, "bin")
+}
+
+// getJSON performs a GET request against the npm registry and decodes the response.
+func (n *NPM) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "poxy/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body) //nolint:errcheck
+		return fmt.Errorf("npm registry error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// npmListTree is the JSON shape of `npm ls -g --json`. pnpm prints an
+// array of these, one per global directory.
+type npmListTree struct {
+	Dependencies map[string]struct {
+		Version string `json:"version"`
+	} `json:"dependencies"`
+}
+
+// parseGlobalList parses `npm ls -g --json` or `pnpm ls -g --json` output.
+func parseGlobalList(output string) (map[string]string, error) {
+	var trees []npmListTree
+
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &trees); err != nil {
+			return nil, fmt.Errorf("failed to parse package list: %w", err)
+		}
+	} else {
+		var tree npmListTree
+		if err := json.Unmarshal([]byte(trimmed), &tree); err != nil {
+			return nil, fmt.Errorf("failed to parse package list: %w", err)
+		}
+		trees = append(trees, tree)
+	}
+
+	installed := make(map[string]string)
+	for _, tree := range trees {
+		for name, dep := range tree.Dependencies {
+			installed[name] = dep.Version
+		}
+	}
+
+	return installed, nil
+}
+
+// parseOutdated parses `npm outdated -g --json` (or pnpm's equivalent) output.
+func parseOutdated(output, source string) ([]manager.UpgradeCandidate, error) {
+	var outdated map[string]struct {
+		Current string `json:"current"`
+		Latest  string `json:"latest"`
+	}
+	if err := json.Unmarshal([]byte(output), &outdated); err != nil {
+		return nil, fmt.Errorf("failed to parse outdated packages: %w", err)
+	}
+
+	names := make([]string, 0, len(outdated))
+	for name := range outdated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	candidates := make([]manager.UpgradeCandidate, 0, len(names))
+	for _, name := range names {
+		entry := outdated[name]
+		if entry.Latest == "" || entry.Latest == entry.Current {
+			continue
+		}
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           name,
+			Source:         source,
+			CurrentVersion: entry.Current,
+			NewVersion:     entry.Latest,
+		})
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,79 @@
+package language
+
+import (
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+func TestNPMManager(t *testing.T) {
+	npm := NewNPM(false)
+
+	if npm.Name() != "npm" {
+		t.Errorf("expected name 'npm', got '%s'", npm.Name())
+	}
+
+	if npm.Type() != manager.TypeUniversal {
+		t.Errorf("expected Type Universal, got %s", npm.Type())
+	}
+
+	if npm.NeedsSudo() {
+		t.Error("npm should not need sudo")
+	}
+
+	var _ manager.Manager = npm
+	var _ manager.UpgradeChecker = npm
+	var _ manager.BinDirProvider = npm
+}
+
+func TestParseGlobalList(t *testing.T) {
+	npmOutput := `{
+  "name": "lib",
+  "dependencies": {
+    "typescript": {"version": "5.4.5", "overridden": false},
+    "@angular/cli": {"version": "17.3.0", "overridden": false}
+  }
+}`
+
+	installed, err := parseGlobalList(npmOutput)
+	if err != nil {
+		t.Fatalf("parseGlobalList() error = %v", err)
+	}
+	if installed["typescript"] != "5.4.5" || installed["@angular/cli"] != "17.3.0" {
+		t.Errorf("unexpected npm packages: %v", installed)
+	}
+
+	pnpmOutput := `[{"path": "/home/user/.local/share/pnpm/global/5", "dependencies": {"pnpm": {"version": "9.1.0"}}}]`
+
+	installed, err = parseGlobalList(pnpmOutput)
+	if err != nil {
+		t.Fatalf("parseGlobalList() error = %v", err)
+	}
+	if len(installed) != 1 || installed["pnpm"] != "9.1.0" {
+		t.Errorf("unexpected pnpm packages: %v", installed)
+	}
+
+	if _, err := parseGlobalList("not json"); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestParseOutdated(t *testing.T) {
+	output := `{
+  "typescript": {"current": "5.3.0", "wanted": "5.3.0", "latest": "5.4.5", "location": "/usr/lib/node_modules/typescript"},
+  "eslint": {"current": "9.0.0", "wanted": "9.0.0", "latest": "9.0.0"}
+}`
+
+	candidates, err := parseOutdated(output, "npm")
+	if err != nil {
+		t.Fatalf("parseOutdated() error = %v", err)
+	}
+
+	if len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+	c := candidates[0]
+	if c.Name != "typescript" || c.CurrentVersion != "5.3.0" || c.NewVersion != "5.4.5" || c.Source != "npm" {
+		t.Errorf("unexpected candidate: %+v", c)
+	}
+}
@@ -61,6 +61,52 @@ type Manager interface {
 	Autoremove(ctx context.Context) error
 }
 
+// UpgradeChecker is implemented by managers that can report available upgrades
+// without performing them.
+type UpgradeChecker interface {
+	// ListUpgradable returns installed packages with a newer version available,
+	// including upgrades the manager is deliberately holding back.
+	ListUpgradable(ctx context.Context) ([]UpgradeCandidate, error)
+}
+
+// UpgradeExcluder is implemented by managers that honor UpgradeOpts.Exclude,
+// leaving packages out of a full upgrade (pacman --ignore, dnf --exclude).
+type UpgradeExcluder interface {
+	// ExcludesUpgrades reports whether UpgradeOpts.Exclude is applied.
+	ExcludesUpgrades() bool
+}
+
+// Holder is implemented by managers with a native way to keep packages at
+// their installed version, such as apt-mark hold or dnf versionlock.
+type Holder interface {
+	// Hold prevents the packages from being upgraded.
+	Hold(ctx context.Context, pkgs []string, dryRun bool) error
+
+	// Unhold allows the packages to be upgraded again.
+	Unhold(ctx context.Context, pkgs []string, dryRun bool) error
+}
+
+// HoldAdvisor is implemented by managers whose holds have to be configured
+// by hand, such as pacman's IgnorePkg.
+type HoldAdvisor interface {
+	// HoldAdvice explains how to hold the packages natively.
+	HoldAdvice(pkgs []string) string
+}
+
+// StreamManager is implemented by managers that support module streams,
+// where a package is available in several parallel major versions.
+type StreamManager interface {
+	// ListStreams returns all module streams known to the manager.
+	ListStreams(ctx context.Context) ([]ModuleStream, error)
+
+	// EnableStream enables a module stream, switching from any other enabled
+	// stream of the same module.
+	EnableStream(ctx context.Context, name, stream string, dryRun bool) error
+
+	// ResetStream returns a module to its default, unenabled state.
+	ResetStream(ctx context.Context, name string, dryRun bool) error
+}
+
 // ManagerInfo provides static information about a manager without requiring instantiation.
 type ManagerInfo struct {
 	Name        string
@@ -69,3 +115,194 @@ type ManagerInfo struct {
 	Binary      string   // Primary binary to check for availability
 	Distros     []string // Linux distributions this manager is native to
 }
+
+// VersionReporter is implemented by managers that can report the version of
+// their backend tool (e.g. pacman 6.1.0 or flatpak 1.15.6).
+type VersionReporter interface {
+	// Version returns the backend version, such as "6.1.0".
+	Version(ctx context.Context) (string, error)
+}
+
+// ReverseDependencyChecker is implemented by managers that can list the
+// installed packages depending on a package, so removals can warn about
+// what else would break.
+type ReverseDependencyChecker interface {
+	// ReverseDependencies returns the installed packages that require pkg.
+	ReverseDependencies(ctx context.Context, pkg string) ([]string, error)
+}
+
+// FileOwnershipChecker is implemented by managers that track the files owned
+// by installed packages.
+type FileOwnershipChecker interface {
+	// OwnsFile returns the installed packages that own path. It returns no
+	// packages and no error when nothing owns the path.
+	OwnsFile(ctx context.Context, path string) ([]string, error)
+
+	// ListFiles returns the files installed by pkg.
+	ListFiles(ctx context.Context, pkg string) ([]string, error)
+}
+
+// OrphanLister is implemented by managers that can list installed packages
+// nothing needs any more, such as dependencies of removed packages.
+type OrphanLister interface {
+	// ListOrphans returns the names of orphaned packages.
+	ListOrphans(ctx context.Context) ([]string, error)
+}
+
+// Notice is something an operation's output asks the user to do by hand,
+// such as merging a new .pacnew file, which is easily lost as the output
+// scrolls by.
+type Notice struct {
+	Package string // Package whose files or scriptlet raised it, if known
+	Text    string
+	// Path is the configuration file left beside the live one, for
+	// notices about one
+	Path string
+}
+
+// NoticeReporter is implemented by managers that pick the notices out of
+// the output of their upgrades.
+type NoticeReporter interface {
+	// TakeNotices returns the notices raised since the last call.
+	TakeNotices() []Notice
+}
+
+// DeferredApplier is implemented by managers of image-based systems, such
+// as rpm-ostree, that stage changes into a new deployment of the system
+// instead of changing the running one.
+type DeferredApplier interface {
+	// AppliesOnReboot reports whether changes take effect only after the
+	// next reboot.
+	AppliesOnReboot() bool
+}
+
+// DeploymentLister is implemented by managers of image-based systems that
+// keep several deployments of the system to boot.
+type DeploymentLister interface {
+	// Deployments returns the deployments in boot order, the one the next
+	// boot uses first.
+	Deployments(ctx context.Context) ([]Deployment, error)
+}
+
+// BinDirProvider is implemented by managers that install commands into a
+// directory of their own, such as ~/.cargo/bin, which the shell may not
+// search.
+type BinDirProvider interface {
+	// BinDir returns the directory installed commands land in.
+	BinDir(ctx context.Context) string
+}
+
+// CommandPreviewer is implemented by managers that can tell which commands
+// a package installs before it is installed, so a plan can show where they
+// clash with commands already on the PATH.
+type CommandPreviewer interface {
+	// PackageCommands returns the paths pkg would install its commands at,
+	// e.g. /usr/bin/rg.
+	PackageCommands(ctx context.Context, pkg string) ([]string, error)
+}
+
+// UnusedDependencyLister is implemented by managers that can tell which
+// dependencies of installed packages nothing else needs, so removing an
+// application can take only its own dependencies with it.
+type UnusedDependencyLister interface {
+	// UnusedDependencies returns the installed packages that would be
+	// orphaned by removing packages, leaving out packages already orphaned.
+	UnusedDependencies(ctx context.Context, packages []string) ([]string, error)
+}
+
+// ExplicitLister is implemented by managers that record why packages were
+// installed, such as pacman (-Qe) and APT (apt-mark showmanual).
+type ExplicitLister interface {
+	// ListExplicit returns the names of installed packages the user asked
+	// for; other installed packages were pulled in as dependencies.
+	ListExplicit(ctx context.Context) ([]string, error)
+}
+
+// CatalogLister is implemented by managers that can list the packages they
+// know about with their relations, for the local search index.
+type CatalogLister interface {
+	// ListCatalog returns the known packages, marking installed ones.
+	ListCatalog(ctx context.Context) ([]Package, error)
+}
+
+// ProviderFinder is implemented by managers whose packages can provide
+// virtual packages, such as pacman's "java-runtime".
+type ProviderFinder interface {
+	// FindProviders returns the packages that provide name.
+	FindProviders(ctx context.Context, name string) ([]Package, error)
+}
+
+// RootSetter is implemented by managers that can operate on a system
+// mounted at another root, such as an image being built.
+type RootSetter interface {
+	// SetRoot makes the manager operate on the system at root.
+	SetRoot(root string)
+}
+
+// Chrooter is implemented by managers that can run chrooted into a system
+// mounted at another root, using the binaries installed there.
+type Chrooter interface {
+	// InstalledIn returns true if the manager is installed in the system
+	// at root.
+	InstalledIn(root string) bool
+
+	// Chroot makes the manager run its commands chrooted into root.
+	Chroot(root string)
+}
+
+// ScopeReporter is implemented by managers that install for the current
+// user only, such as Cargo into ~/.cargo/bin, or that can install either
+// way, such as Flatpak. Other managers install system-wide.
+type ScopeReporter interface {
+	// InstallScope returns who the packages the manager installs are for.
+	InstallScope() Scope
+}
+
+// ScopeSetter is implemented by managers that can install either for the
+// current user or system-wide, such as flatpak --user and --system.
+type ScopeSetter interface {
+	// SetInstallScope makes later installs use scope.
+	SetInstallScope(scope Scope)
+}
+
+// InfoPrefetcher is implemented by managers that can look up many packages
+// in one request, such as the AUR RPC interface, and keep the answers for
+// the Info calls that follow.
+type InfoPrefetcher interface {
+	// PrefetchInfo looks up pkgs ahead of Info calls for them.
+	PrefetchInfo(ctx context.Context, pkgs []string) error
+}
+
+// PermissionManager is implemented by managers that run apps in a sandbox
+// whose permissions the user can override, such as Flatpak.
+type PermissionManager interface {
+	// Permissions returns the permissions of the installed app pkg, with
+	// the user's overrides applied.
+	Permissions(ctx context.Context, pkg string) (*Permissions, error)
+
+	// OverridePermissions grants and revokes permissions of pkg for the
+	// current user.
+	OverridePermissions(ctx context.Context, pkg string, overrides []PermissionOverride, dryRun bool) error
+
+	// ResetPermissions removes the current user's overrides of pkg.
+	ResetPermissions(ctx context.Context, pkg string, dryRun bool) error
+}
+
+// RepoManager is implemented by managers whose repositories, or remotes,
+// poxy can list, enable, disable and add.
+type RepoManager interface {
+	// ListRepos returns the configured repositories, enabled or not.
+	ListRepos(ctx context.Context) ([]Repository, error)
+
+	// EnableRepo enables a configured repository.
+	EnableRepo(ctx context.Context, name string, dryRun bool) error
+
+	// DisableRepo disables a repository, keeping its configuration.
+	DisableRepo(ctx context.Context, name string, dryRun bool) error
+
+	// AddRepo adds a repository at url, importing its signing key if the
+	// manager knows it. name may be empty for sources that name the
+	// repository themselves, such as a DNF .repo file. The package database
+	// is not refreshed.
+	AddRepo(ctx context.Context, name, url string, dryRun bool) error
+}
@@ -26,6 +26,7 @@ func (a *APK) Install(ctx context.Context, packages []string, opts manager.Insta
 	args := []string{"add"}
 
 	// APK doesn't have a -y flag, it's non-interactive by default
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -63,6 +64,8 @@ func (a *APK) Update(ctx context.Context) error {
 func (a *APK) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"upgrade"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
@@ -77,6 +80,10 @@ func (a *APK) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 
 // Search finds packages matching the query.
 func (a *APK) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, a, query, opts)
+	}
+
 	args := []string{"search"}
 
 	if opts.SearchInDesc {
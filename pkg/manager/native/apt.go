@@ -48,6 +48,7 @@ func (a *APT) Install(ctx context.Context, packages []string, opts manager.Insta
 		args = append(args, "--reinstall")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -104,6 +105,12 @@ func (a *APT) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		args = append(args, "-y")
 	}
 
+	if opts.IncludePhased {
+		args = append(args, "-o", phasedUpdatesOption)
+	}
+
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		// Upgrade specific packages
 		args = append(args, opts.Packages...)
@@ -129,7 +136,9 @@ func (a *APT) Search(ctx context.Context, query string, opts manager.SearchOpts)
 		return nil, err
 	}
 
-	return a.parseSearchOutput(output, opts.Limit), nil
+	packages := a.parseSearchOutput(output, opts.Limit)
+	manager.CheckParsed("apt", "apt-cache search", output, len(packages))
+	return packages, nil
 }
 
 // searchInstalled searches installed packages.
@@ -162,12 +171,16 @@ func (a *APT) searchInstalled(ctx context.Context, query string, opts manager.Se
 			continue
 		}
 
-		packages = append(packages, manager.Package{
+		pkg := manager.Package{
 			Name:      name,
 			Version:   version,
 			Source:    "apt",
 			Installed: true,
-		})
+		}
+		if len(fields) > 3 && fields[3] != "" {
+			pkg.Metadata = map[string]string{manager.MetaSection: fields[3]}
+		}
+		packages = append(packages, pkg)
 
 		if opts.Limit > 0 && len(packages) >= opts.Limit {
 			break
@@ -289,7 +302,7 @@ func (a *APT) parseDependencies(deps string) []string {
 
 // ListInstalled returns all installed packages.
 func (a *APT) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
-	output, err := a.Executor().Output(ctx, "dpkg-query", "-W", "-f=${Package}\\t${Version}\\t${Status}\\n")
+	output, err := a.Executor().Output(ctx, "dpkg-query", "-W", "-f=${Package}\\t${Version}\\t${Status}\\t${Section}\\n")
 	if err != nil {
 		return nil, err
 	}
@@ -318,18 +331,25 @@ func (a *APT) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manag
 			continue
 		}
 
-		packages = append(packages, manager.Package{
+		pkg := manager.Package{
 			Name:      name,
 			Version:   version,
 			Source:    "apt",
 			Installed: true,
-		})
+		}
+		if len(fields) > 3 && fields[3] != "" {
+			pkg.Metadata = map[string]string{manager.MetaSection: fields[3]}
+		}
+		packages = append(packages, pkg)
 
 		if opts.Limit > 0 && len(packages) >= opts.Limit {
 			break
 		}
 	}
 
+	if opts.Pattern == "" {
+		manager.CheckParsed("apt", "dpkg-query -W", output, len(packages))
+	}
 	return packages, nil
 }
 
@@ -361,3 +381,44 @@ func (a *APT) Clean(ctx context.Context, opts manager.CleanOpts) error {
 func (a *APT) Autoremove(ctx context.Context) error {
 	return a.Executor().RunSudo(ctx, a.Binary(), "autoremove", "-y")
 }
+
+// ReverseDependencies returns the installed packages that require pkg.
+func (a *APT) ReverseDependencies(ctx context.Context, pkg string) ([]string, error) {
+	// Only hard dependencies: a package that merely recommends or suggests
+	// pkg keeps working without it
+	output, err := a.Executor().OutputQuiet(ctx, "apt-cache", "rdepends", "--installed",
+		"--no-recommends", "--no-suggests", "--no-enhances", "--no-conflicts", "--no-breaks", "--no-replaces", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("apt-cache rdepends failed for %s: %w", pkg, err)
+	}
+	return parseRdependsOutput(output, pkg), nil
+}
+
+// parseRdependsOutput parses `apt-cache rdepends` output. Alternatives are
+// prefixed with "|", multiarch packages carry an ":arch" qualifier, and a
+// package may be listed more than once.
+func parseRdependsOutput(output, pkg string) []string {
+	seen := make(map[string]bool)
+	var dependents []string
+	inList := false
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Reverse Depends:") {
+			inList = true
+			continue
+		}
+		if !inList {
+			continue
+		}
+
+		name := strings.TrimPrefix(strings.TrimSpace(line), "|")
+		name, _, _ = strings.Cut(name, ":")
+		if name == "" || name == pkg || seen[name] {
+			continue
+		}
+		seen[name] = true
+		dependents = append(dependents, name)
+	}
+
+	return dependents
+}
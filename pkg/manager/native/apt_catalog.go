@@ -0,0 +1,100 @@
+package native
+
+import (
+	"context"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ListCatalog returns every package in the APT repositories with its
+// relations (apt-cache dumpavail), marking the installed ones.
+func (a *APT) ListCatalog(ctx context.Context) ([]manager.Package, error) {
+	output, err := a.Executor().OutputQuiet(ctx, "apt-cache", "dumpavail")
+	if err != nil {
+		return nil, err
+	}
+	packages := parseDebRecords(output)
+	manager.CheckParsed("apt", "apt-cache dumpavail", output, len(packages))
+
+	installed := make(map[string]bool)
+	if output, err := a.Executor().OutputQuiet(ctx, "dpkg-query", "-W", "-f=${db:Status-Abbrev}\\t${Package}\\n"); err == nil {
+		for _, line := range strings.Split(output, "\n") {
+			status, name, ok := strings.Cut(line, "\t")
+			if ok && strings.HasPrefix(status, "ii") {
+				installed[name] = true
+			}
+		}
+	}
+	for i := range packages {
+		packages[i].Installed = installed[packages[i].Name]
+	}
+
+	return packages, nil
+}
+
+// parseDebRecords parses Debian control records, such as apt-cache
+// dumpavail output, one blank-line separated stanza per package. A package
+// listed by several repositories is kept once, with its first version.
+func parseDebRecords(output string) []manager.Package {
+	var packages []manager.Package
+	seen := make(map[string]bool)
+
+	for _, record := range strings.Split(output, "\n\n") {
+		pkg := manager.Package{Source: "apt"}
+		for _, line := range strings.Split(record, "\n") {
+			// Continuation lines of multi-line fields
+			if strings.HasPrefix(line, " ") {
+				continue
+			}
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+
+			switch key {
+			case "Package":
+				pkg.Name = value
+			case "Version":
+				pkg.Version = value
+			case "Description":
+				pkg.Description = value
+			case "Provides":
+				pkg.Provides = debRelations(value)
+			case "Conflicts":
+				pkg.Conflicts = debRelations(value)
+			case "Replaces":
+				pkg.Replaces = debRelations(value)
+			}
+		}
+
+		if pkg.Name != "" && !seen[pkg.Name] {
+			seen[pkg.Name] = true
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// debRelations parses a Debian relation field such as
+// "mail-transport-agent, libc6 (>= 2.34) | libc6-udeb" into names with
+// pacman-style constraints ("libc6>=2.34"), so relations read the same for
+// every source.
+func debRelations(value string) []string {
+	var relations []string
+	for _, group := range strings.Split(value, ",") {
+		for _, alternative := range strings.Split(group, "|") {
+			name, constraint, _ := strings.Cut(strings.TrimSpace(alternative), "(")
+			name = strings.TrimSpace(name)
+			// Drop architecture qualifiers such as ":any"
+			name, _, _ = strings.Cut(name, ":")
+			if name == "" {
+				continue
+			}
+			constraint = strings.TrimSuffix(strings.TrimSpace(constraint), ")")
+			relations = append(relations, name+strings.ReplaceAll(constraint, " ", ""))
+		}
+	}
+	return relations
+}
@@ -0,0 +1,57 @@
+package native
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDebRecords(t *testing.T) {
+	output := `Package: exim4-daemon-light
+Version: 4.96-15+deb12u5
+Provides: mail-transport-agent
+Conflicts: exim4-daemon-heavy, postfix (<< 3.7)
+Replaces: exim4-base (<= 4.92-1)
+Description: lightweight Exim MTA (v4) daemon
+Tag: mail::transport-agent,
+ role::program
+
+Package: bash
+Version: 5.2.15-2+b7
+Description: GNU Bourne Again SHell
+Section: shells
+
+Package: bash
+Version: 5.2.15-2
+Description: GNU Bourne Again SHell
+`
+	packages := parseDebRecords(output)
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packages), packages)
+	}
+
+	exim := packages[0]
+	if exim.Name != "exim4-daemon-light" || exim.Version != "4.96-15+deb12u5" || exim.Source != "apt" {
+		t.Errorf("unexpected package: %+v", exim)
+	}
+	if exim.Relation("mail-transport-agent") != "provides" {
+		t.Error("exim4-daemon-light should provide mail-transport-agent")
+	}
+	if want := []string{"exim4-daemon-heavy", "postfix<<3.7"}; !slices.Equal(exim.Conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", exim.Conflicts, want)
+	}
+	if exim.Relation("exim4-base") != "replaces" {
+		t.Error("exim4-daemon-light should replace exim4-base")
+	}
+
+	if packages[1].Name != "bash" || packages[1].Version != "5.2.15-2+b7" {
+		t.Errorf("expected the first bash record, got %+v", packages[1])
+	}
+}
+
+func TestDebRelations(t *testing.T) {
+	got := debRelations("libc6 (>= 2.34), default-mta | mail-transport-agent, python3:any")
+	want := []string{"libc6>=2.34", "default-mta", "mail-transport-agent", "python3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("debRelations() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,279 @@
+package native
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// phasedUpdatesOption makes apt install phased updates immediately.
+const phasedUpdatesOption = "APT::Get::Always-Include-Phased-Updates=true"
+
+// AptPolicyVersion is one entry of the apt-cache policy version table.
+type AptPolicyVersion struct {
+	Version   string
+	Priority  int
+	Phased    int // Phasing percentage, 0 if the version is not phased
+	Installed bool
+}
+
+// AptPolicy describes why APT selects a particular version of a package.
+type AptPolicy struct {
+	Package   string
+	Installed string
+	Candidate string
+	Pin       string // Version pinned via apt preferences, if any
+	Held      bool   // Marked with apt-mark hold
+	Versions  []AptPolicyVersion
+}
+
+// CandidatePhased returns the phasing percentage of the candidate version,
+// or 0 if it is fully rolled out.
+func (p *AptPolicy) CandidatePhased() int {
+	for _, v := range p.Versions {
+		if v.Version == p.Candidate {
+			return v.Phased
+		}
+	}
+	return 0
+}
+
+// Policy returns the APT version policy for a package, including pins,
+// holds and phasing state.
+func (a *APT) Policy(ctx context.Context, pkg string) (*AptPolicy, error) {
+	output, err := a.Executor().Output(ctx, "apt-cache", "policy", pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := parseAptPolicy(output)
+	if policy.Package == "" {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+
+	holds, _ := a.holds(ctx) //nolint:errcheck
+	policy.Held = holds[policy.Package]
+
+	return policy, nil
+}
+
+// ListUpgradable returns packages with pending upgrades. Upgrades deferred by
+// phasing, kept back, held or pinned are included and marked as held.
+func (a *APT) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	output, err := a.Executor().OutputQuiet(ctx, "apt", "list", "--upgradable")
+	if err != nil {
+		return nil, err
+	}
+	candidates := parseAptUpgradable(output)
+
+	// Simulate an upgrade to learn which packages apt would skip
+	simulated, _ := a.Executor().OutputQuiet(ctx, "apt-get", "-s", "upgrade") //nolint:errcheck
+	deferred, keptBack := parseAptHeldBack(simulated)
+
+	holds, _ := a.holds(ctx) //nolint:errcheck
+
+	pinOutput, _ := a.Executor().OutputQuiet(ctx, "apt-cache", "policy") //nolint:errcheck
+	pins := parseAptPins(pinOutput)
+
+	for i := range candidates {
+		name := candidates[i].Name
+		switch {
+		case holds[name]:
+			candidates[i].HeldReason = "held (apt-mark hold)"
+		case pins[name] != "":
+			candidates[i].HeldReason = "pinned: " + pins[name]
+		case deferred[name]:
+			candidates[i].HeldReason = "phased update (rollout in progress)"
+		case keptBack[name]:
+			candidates[i].HeldReason = "kept back (requires new or removed dependencies)"
+		}
+		candidates[i].Held = candidates[i].HeldReason != ""
+	}
+
+	return candidates, nil
+}
+
+// Hold marks the packages with apt-mark hold.
+func (a *APT) Hold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return a.mark(ctx, "hold", pkgs, dryRun)
+}
+
+// Unhold removes apt-mark holds from the packages.
+func (a *APT) Unhold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return a.mark(ctx, "unhold", pkgs, dryRun)
+}
+
+func (a *APT) mark(ctx context.Context, action string, pkgs []string, dryRun bool) error {
+	if dryRun {
+		a.SetDryRun(true)
+		defer a.SetDryRun(false)
+	}
+
+	return a.Executor().RunSudo(ctx, "apt-mark", append([]string{action}, pkgs...)...)
+}
+
+// holds returns the set of packages marked with apt-mark hold.
+func (a *APT) holds(ctx context.Context) (map[string]bool, error) {
+	output, err := a.Executor().Output(ctx, "apt-mark", "showhold")
+	if err != nil {
+		return nil, err
+	}
+
+	holds := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			holds[name] = true
+		}
+	}
+	return holds, nil
+}
+
+var (
+	// aptUpgradableRe matches "name/suite version arch [upgradable from: old]"
+	aptUpgradableRe = regexp.MustCompile(`^([^/\s]+)/\S+\s+(\S+)\s+\S+\s+\[upgradable from: ([^\]]+)\]`)
+
+	// aptPolicyVersionRe matches version table rows like " *** 1.2-1 500 (phased 20%)"
+	aptPolicyVersionRe = regexp.MustCompile(`^ (\*\*\*| {3}) (\S+) (-?\d+)(?: \(phased (\d+)%\))?`)
+
+	// aptPinnedRe matches "     name -> version with priority N" in apt-cache policy output
+	aptPinnedRe = regexp.MustCompile(`^\s+(\S+) -> (\S+) with priority (-?\d+)`)
+)
+
+// parseAptUpgradable parses `apt list --upgradable` output.
+func parseAptUpgradable(output string) []manager.UpgradeCandidate {
+	var candidates []manager.UpgradeCandidate
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		m := aptUpgradableRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           m[1],
+			Source:         "apt",
+			CurrentVersion: m[3],
+			NewVersion:     m[2],
+		})
+	}
+
+	return candidates
+}
+
+// parseAptHeldBack parses simulated `apt-get upgrade` output for packages
+// deferred due to phasing and packages kept back.
+func parseAptHeldBack(output string) (deferred, keptBack map[string]bool) {
+	deferred = make(map[string]bool)
+	keptBack = make(map[string]bool)
+
+	var current map[string]bool
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "The following upgrades have been deferred due to phasing"):
+			current = deferred
+			continue
+		case strings.HasPrefix(line, "The following packages have been kept back"):
+			current = keptBack
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			current = nil
+			continue
+		}
+		for _, name := range strings.Fields(line) {
+			current[name] = true
+		}
+	}
+
+	return deferred, keptBack
+}
+
+// parseAptPins parses the "Pinned packages:" section of `apt-cache policy`.
+func parseAptPins(output string) map[string]string {
+	pins := make(map[string]string)
+	inPinned := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Pinned packages:") {
+			inPinned = true
+			continue
+		}
+		if !inPinned {
+			continue
+		}
+
+		if m := aptPinnedRe.FindStringSubmatch(line); m != nil {
+			pins[m[1]] = fmt.Sprintf("%s (priority %s)", m[2], m[3])
+		}
+	}
+
+	return pins
+}
+
+// parseAptPolicy parses `apt-cache policy <pkg>` output.
+func parseAptPolicy(output string) *AptPolicy {
+	policy := &AptPolicy{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case policy.Package == "" && strings.HasSuffix(line, ":") && !strings.HasPrefix(line, " "):
+			policy.Package = strings.TrimSuffix(line, ":")
+		case strings.HasPrefix(trimmed, "Installed:"):
+			policy.Installed = strings.TrimSpace(strings.TrimPrefix(trimmed, "Installed:"))
+			if policy.Installed == "(none)" {
+				policy.Installed = ""
+			}
+		case strings.HasPrefix(trimmed, "Candidate:"):
+			policy.Candidate = strings.TrimSpace(strings.TrimPrefix(trimmed, "Candidate:"))
+			if policy.Candidate == "(none)" {
+				policy.Candidate = ""
+			}
+		case strings.HasPrefix(trimmed, "Package pin:"):
+			policy.Pin = strings.TrimSpace(strings.TrimPrefix(trimmed, "Package pin:"))
+		default:
+			m := aptPolicyVersionRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			priority, _ := strconv.Atoi(m[3]) //nolint:errcheck
+			phased, _ := strconv.Atoi(m[4])   //nolint:errcheck
+			policy.Versions = append(policy.Versions, AptPolicyVersion{
+				Version:   m[2],
+				Priority:  priority,
+				Phased:    phased,
+				Installed: m[1] == "***",
+			})
+		}
+	}
+
+	// A priority of 1000 or more forces a version, even a downgrade
+	if policy.Pin == "" {
+		for _, v := range policy.Versions {
+			if v.Priority >= 1000 {
+				policy.Pin = v.Version
+				break
+			}
+		}
+	}
+
+	return policy
+}
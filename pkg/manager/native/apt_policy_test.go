@@ -0,0 +1,117 @@
+package native
+
+import "testing"
+
+func TestParseAptUpgradable(t *testing.T) {
+	output := `Listing...
+firefox/noble-updates 1:1snap1-0ubuntu5 amd64 [upgradable from: 1:1snap1-0ubuntu4]
+libssl3t64/noble-updates,noble-security 3.0.13-0ubuntu3.4 amd64 [upgradable from: 3.0.13-0ubuntu3.1]
+`
+
+	candidates := parseAptUpgradable(output)
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(candidates))
+	}
+
+	if candidates[0].Name != "firefox" {
+		t.Errorf("expected firefox, got %s", candidates[0].Name)
+	}
+	if candidates[0].CurrentVersion != "1:1snap1-0ubuntu4" || candidates[0].NewVersion != "1:1snap1-0ubuntu5" {
+		t.Errorf("unexpected versions: %s -> %s", candidates[0].CurrentVersion, candidates[0].NewVersion)
+	}
+	if candidates[1].Name != "libssl3t64" {
+		t.Errorf("expected libssl3t64, got %s", candidates[1].Name)
+	}
+}
+
+func TestParseAptHeldBack(t *testing.T) {
+	output := `Reading package lists...
+Calculating upgrade...
+The following upgrades have been deferred due to phasing:
+  gnome-shell mutter-common
+The following packages have been kept back:
+  linux-generic
+0 upgraded, 0 newly installed, 0 to remove and 3 not upgraded.
+`
+
+	deferred, keptBack := parseAptHeldBack(output)
+
+	if !deferred["gnome-shell"] || !deferred["mutter-common"] {
+		t.Errorf("expected phased packages to be deferred, got %v", deferred)
+	}
+	if !keptBack["linux-generic"] {
+		t.Errorf("expected linux-generic to be kept back, got %v", keptBack)
+	}
+	if deferred["linux-generic"] || keptBack["gnome-shell"] {
+		t.Error("packages should not appear in both sections")
+	}
+}
+
+func TestParseAptPins(t *testing.T) {
+	output := `Package files:
+ 100 /var/lib/dpkg/status
+     release a=now
+Pinned packages:
+     firefox -> 1:1snap1-0ubuntu2 with priority 1001
+     thunderbird -> (not found) with priority -1
+`
+
+	pins := parseAptPins(output)
+	if pins["firefox"] != "1:1snap1-0ubuntu2 (priority 1001)" {
+		t.Errorf("unexpected firefox pin: %q", pins["firefox"])
+	}
+	if len(pins) != 1 {
+		t.Errorf("expected 1 pin, got %v", pins)
+	}
+}
+
+func TestParseAptPolicy(t *testing.T) {
+	output := `gnome-shell:
+  Installed: 46.0-0ubuntu5
+  Candidate: 46.0-0ubuntu6
+  Version table:
+     46.0-0ubuntu6 500 (phased 30%)
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+ *** 46.0-0ubuntu5 500
+        500 http://archive.ubuntu.com/ubuntu noble/main amd64 Packages
+        100 /var/lib/dpkg/status
+`
+
+	policy := parseAptPolicy(output)
+
+	if policy.Package != "gnome-shell" {
+		t.Errorf("expected package gnome-shell, got %q", policy.Package)
+	}
+	if policy.Installed != "46.0-0ubuntu5" || policy.Candidate != "46.0-0ubuntu6" {
+		t.Errorf("unexpected installed/candidate: %s / %s", policy.Installed, policy.Candidate)
+	}
+	if len(policy.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(policy.Versions))
+	}
+	if policy.CandidatePhased() != 30 {
+		t.Errorf("expected candidate phased at 30%%, got %d", policy.CandidatePhased())
+	}
+	if !policy.Versions[1].Installed {
+		t.Error("expected second version to be marked installed")
+	}
+	if policy.Pin != "" {
+		t.Errorf("expected no pin, got %q", policy.Pin)
+	}
+}
+
+func TestParseAptPolicyPinned(t *testing.T) {
+	output := `firefox:
+  Installed: 1:1snap1-0ubuntu2
+  Candidate: 1:1snap1-0ubuntu2
+  Version table:
+     1:1snap1-0ubuntu5 500
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+ *** 1:1snap1-0ubuntu2 1001
+        100 /var/lib/dpkg/status
+`
+
+	policy := parseAptPolicy(output)
+	if policy.Pin != "1:1snap1-0ubuntu2" {
+		t.Errorf("expected pin on installed version, got %q", policy.Pin)
+	}
+}
@@ -0,0 +1,245 @@
+package native
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"poxy/pkg/manager/detector"
+)
+
+const (
+	// launchpadAPI describes PPAs, including the key their packages are
+	// signed with
+	launchpadAPI = "https://api.launchpad.net/1.0"
+
+	// ppaArchive serves the packages of PPAs
+	ppaArchive = "https://ppa.launchpadcontent.net"
+
+	// ppaKeyserver has the signing keys of PPAs
+	ppaKeyserver = "https://keyserver.ubuntu.com"
+
+	// aptKeyringsDir holds the keys of third-party sources, each source
+	// trusting only its own with signed-by
+	aptKeyringsDir = "/etc/apt/keyrings"
+
+	ppaTimeout = 30 * time.Second
+)
+
+// errNotFound is returned by ppaGet for a 404
+var errNotFound = errors.New("not found")
+
+// ppa is a Launchpad Personal Package Archive, written ppa:owner/name.
+type ppa struct {
+	Owner string
+	Name  string
+}
+
+// parsePPA parses ppa:owner/name. ppa:owner is owner's archive named ppa,
+// as add-apt-repository has it.
+func parsePPA(spec string) (ppa, error) {
+	rest, ok := strings.CutPrefix(spec, "ppa:")
+	if !ok {
+		return ppa{}, fmt.Errorf("invalid PPA %q (want ppa:owner/name)", spec)
+	}
+	owner, name, found := strings.Cut(rest, "/")
+	if !found {
+		name = "ppa"
+	}
+	if owner == "" || name == "" || strings.ContainsAny(rest, " \t") || strings.Count(rest, "/") > 1 {
+		return ppa{}, fmt.Errorf("invalid PPA %q (want ppa:owner/name)", spec)
+	}
+	return ppa{Owner: owner, Name: name}, nil
+}
+
+// sourceName returns the name of the PPA's sources.list.d file, the one
+// add-apt-repository gives it.
+func (p ppa) sourceName(codename string) string {
+	return fmt.Sprintf("%s-ubuntu-%s-%s", p.Owner, p.Name, codename)
+}
+
+// sourceLine returns the deb line of the PPA, trusting only keyring.
+func (p ppa) sourceLine(keyring, codename string) string {
+	return fmt.Sprintf("deb [signed-by=%s] %s/%s/%s/ubuntu %s main", keyring, ppaArchive, p.Owner, p.Name, codename)
+}
+
+// addPPA adds a PPA with its signing key. add-apt-repository does both if
+// installed; otherwise the key is fetched from the Ubuntu keyserver by the
+// fingerprint Launchpad lists for the PPA, and the source trusts only it.
+func (a *APT) addPPA(ctx context.Context, spec string, dryRun bool) error {
+	p, err := parsePPA(spec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("add-apt-repository"); err == nil {
+		if dryRun {
+			a.SetDryRun(true)
+			defer a.SetDryRun(false)
+		}
+		return a.Executor().RunSudo(ctx, "add-apt-repository", "-y", "-n", spec)
+	}
+
+	codename, err := ubuntuCodename()
+	if err != nil {
+		return err
+	}
+
+	fingerprint, err := ppaFingerprint(ctx, p)
+	if err != nil {
+		return err
+	}
+	key, err := fetchPPAKey(ctx, fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the signing key of %s: %w", spec, err)
+	}
+
+	keyring := filepath.Join(aptKeyringsDir, p.sourceName(codename)+".asc")
+	if err := a.writeConfig(ctx, keyring, key, dryRun); err != nil {
+		return err
+	}
+	list := filepath.Join(aptSourcesDir, p.sourceName(codename)+".list")
+	return a.writeConfig(ctx, list, p.sourceLine(keyring, codename)+"\n", dryRun)
+}
+
+// ubuntuCodename returns the Ubuntu release PPAs are built for that this
+// system is, or is based on.
+func ubuntuCodename() (string, error) {
+	info, err := detector.DetectLinux()
+	if err != nil {
+		return "", err
+	}
+	if info.UbuntuCodename != "" {
+		return info.UbuntuCodename, nil
+	}
+	if info.ID == "ubuntu" && info.Codename != "" {
+		return info.Codename, nil
+	}
+	return "", fmt.Errorf("PPAs are built for Ubuntu releases, and %s is not based on one", info.PrettyName)
+}
+
+// ppaFingerprint asks Launchpad for the fingerprint of the key the PPA's
+// packages are signed with.
+func ppaFingerprint(ctx context.Context, p ppa) (string, error) {
+	endpoint := fmt.Sprintf("%s/~%s/+archive/ubuntu/%s", launchpadAPI, url.PathEscape(p.Owner), url.PathEscape(p.Name))
+	body, err := ppaGet(ctx, endpoint)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return "", fmt.Errorf("PPA ppa:%s/%s not found on Launchpad", p.Owner, p.Name)
+		}
+		return "", fmt.Errorf("failed to look up ppa:%s/%s: %w", p.Owner, p.Name, err)
+	}
+	return parsePPAFingerprint(body)
+}
+
+// parsePPAFingerprint reads signing_key_fingerprint from a Launchpad
+// archive.
+func parsePPAFingerprint(body []byte) (string, error) {
+	var archive struct {
+		Fingerprint string `json:"signing_key_fingerprint"`
+	}
+	if err := json.Unmarshal(body, &archive); err != nil {
+		return "", fmt.Errorf("failed to parse the Launchpad response: %w", err)
+	}
+	if archive.Fingerprint == "" {
+		return "", fmt.Errorf("the PPA has no signing key yet; it has not published any packages")
+	}
+	return archive.Fingerprint, nil
+}
+
+// fetchPPAKey returns the ASCII-armored key with fingerprint. The
+// keyserver's answer is checked with gpg to be that key and no other, since
+// the source will trust every key in the file.
+func fetchPPAKey(ctx context.Context, fingerprint string) (string, error) {
+	params := url.Values{}
+	params.Set("op", "get")
+	params.Set("options", "mr")
+	params.Set("search", "0x"+fingerprint)
+
+	body, err := ppaGet(ctx, ppaKeyserver+"/pks/lookup?"+params.Encode())
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(string(body), "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+		return "", fmt.Errorf("the keyserver did not return key %s", fingerprint)
+	}
+	if err := checkKeyFingerprint(ctx, string(body), fingerprint); err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// checkKeyFingerprint returns an error unless key holds exactly one key,
+// the one with fingerprint.
+func checkKeyFingerprint(ctx context.Context, key, fingerprint string) error {
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--show-keys", "--with-colons")
+	cmd.Stdin = strings.NewReader(key)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to read the key with gpg: %w", err)
+	}
+
+	fingerprints := parseKeyFingerprints(string(output))
+	if len(fingerprints) != 1 || !strings.EqualFold(fingerprints[0], fingerprint) {
+		return fmt.Errorf("the keyserver returned key %s, not %s", strings.Join(fingerprints, ", "), fingerprint)
+	}
+	return nil
+}
+
+// parseKeyFingerprints returns the fingerprints of the primary keys in
+// `gpg --show-keys --with-colons` output: the fpr record following each
+// pub record. Subkey fingerprints are skipped.
+func parseKeyFingerprints(output string) []string {
+	var fingerprints []string
+	primary := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ":")
+		switch fields[0] {
+		case "pub":
+			primary = true
+		case "sub", "ssb":
+			primary = false
+		case "fpr":
+			if primary && len(fields) > 9 {
+				fingerprints = append(fingerprints, fields[9])
+				primary = false
+			}
+		}
+	}
+	return fingerprints
+}
+
+// ppaGet fetches endpoint.
+func ppaGet(ctx context.Context, endpoint string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ppaTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "poxy/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
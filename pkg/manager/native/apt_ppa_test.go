@@ -0,0 +1,118 @@
+package native
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePPA(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ppa
+		wantErr bool
+	}{
+		{spec: "ppa:fish-shell/release-3", want: ppa{Owner: "fish-shell", Name: "release-3"}},
+		{spec: "ppa:git-core", want: ppa{Owner: "git-core", Name: "ppa"}},
+		{spec: "ppa:/name", wantErr: true},
+		{spec: "ppa:owner/", wantErr: true},
+		{spec: "ppa:a/b/c", wantErr: true},
+		{spec: "fish-shell/release-3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePPA(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePPA(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePPA(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestPPASource(t *testing.T) {
+	p := ppa{Owner: "fish-shell", Name: "release-3"}
+
+	if got := p.sourceName("noble"); got != "fish-shell-ubuntu-release-3-noble" {
+		t.Errorf("sourceName() = %q", got)
+	}
+
+	want := "deb [signed-by=/etc/apt/keyrings/k.asc] https://ppa.launchpadcontent.net/fish-shell/release-3/ubuntu noble main"
+	if got := p.sourceLine("/etc/apt/keyrings/k.asc", "noble"); got != want {
+		t.Errorf("sourceLine() = %q, want %q", got, want)
+	}
+	if _, ok := parseAptEntry(want); !ok {
+		t.Error("the source line should parse as a deb line")
+	}
+}
+
+func TestParsePPAFingerprint(t *testing.T) {
+	body := []byte(`{"displayname": "Fish shell 3", "signing_key_fingerprint": "88421E703EDC7AF54967DED473C9FCC9E2BB48DA"}`)
+	fingerprint, err := parsePPAFingerprint(body)
+	if err != nil || fingerprint != "88421E703EDC7AF54967DED473C9FCC9E2BB48DA" {
+		t.Errorf("parsePPAFingerprint() = %q, %v", fingerprint, err)
+	}
+
+	if _, err := parsePPAFingerprint([]byte(`{"signing_key_fingerprint": null}`)); err == nil {
+		t.Error("expected an error for a PPA without a key")
+	}
+}
+
+func TestParseKeyFingerprints(t *testing.T) {
+	output := `pub:-:4096:1:A5A7E80C57A21C27:1479337446:::-:::scSC::::::23::0:
+fpr:::::::::88421E703EDC7AF54967DED473C9FCC9E2BB48DA:
+uid:-::::1479337446::5B8BD45E1DBC6BCF01B2F8E3BC1EDD5B0A4B9E3C::Launchpad PPA for Fish shell maintainers::::::::::0:
+sub:-:4096:1:0123456789ABCDEF:1479337446::::::e::::::23:
+fpr:::::::::11111111111111111111111111111111DEADBEEF:
+pub:-:255:22:FEDCBA9876543210:1700000000:::-:::scSC::::::23::0:
+fpr:::::::::22222222222222222222222222222222CAFEF00D:
+`
+
+	got := parseKeyFingerprints(output)
+	want := []string{"88421E703EDC7AF54967DED473C9FCC9E2BB48DA", "22222222222222222222222222222222CAFEF00D"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseKeyFingerprints() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckKeyFingerprint(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	home := t.TempDir()
+	t.Setenv("GNUPGHOME", home)
+	t.Cleanup(func() { _ = exec.Command("gpgconf", "--kill", "gpg-agent").Run() }) //nolint:errcheck
+	ctx := context.Background()
+
+	gen := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-generate-key", "PPA Test <ppa@example.org>", "ed25519", "sign", "never")
+	if out, err := gen.CombinedOutput(); err != nil {
+		t.Skipf("gpg cannot generate a key here: %v: %s", err, out)
+	}
+	listing, err := exec.Command("gpg", "--batch", "--with-colons", "--list-keys").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprints := parseKeyFingerprints(string(listing))
+	if len(fingerprints) != 1 {
+		t.Fatalf("generated keys = %v, want one", fingerprints)
+	}
+	key, err := exec.Command("gpg", "--batch", "--armor", "--export").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkKeyFingerprint(ctx, string(key), strings.ToLower(fingerprints[0])); err != nil {
+		t.Errorf("checkKeyFingerprint() with the key's fingerprint error = %v", err)
+	}
+	if err := checkKeyFingerprint(ctx, string(key), "88421E703EDC7AF54967DED473C9FCC9E2BB48DA"); err == nil {
+		t.Error("checkKeyFingerprint() accepted a key with another fingerprint")
+	}
+	if err := checkKeyFingerprint(ctx, "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nnot a key\n-----END PGP PUBLIC KEY BLOCK-----\n", fingerprints[0]); err == nil {
+		t.Error("checkKeyFingerprint() accepted a block without a key")
+	}
+}
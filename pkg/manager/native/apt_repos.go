@@ -0,0 +1,321 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// aptEntry is a deb or deb-src line of a one-line sources file.
+type aptEntry struct {
+	Type      string // deb or deb-src
+	URI       string
+	Suite     string
+	Commented bool
+}
+
+// ListRepos returns the APT sources. Those of sources.list are named by
+// their suite, such as noble-updates; the files of sources.list.d by their
+// name without the extension.
+func (a *APT) ListRepos(ctx context.Context) ([]manager.Repository, error) {
+	var repos []manager.Repository
+
+	if data, err := os.ReadFile(aptSourcesList); err == nil {
+		repos = append(repos, aptListRepos(string(data), aptSourcesList)...)
+	}
+
+	files, err := aptSourceFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var repo manager.Repository
+		var ok bool
+		if strings.HasSuffix(file, ".sources") {
+			repo, ok = aptDeb822Repo(string(data))
+		} else {
+			repo, ok = aptListFileRepo(string(data))
+		}
+		if ok {
+			repo.Name = aptSourceName(file)
+			repo.File = file
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+// EnableRepo uncomments the deb lines of a source, or removes
+// "Enabled: no" from a deb822 one. deb-src lines are left commented out.
+func (a *APT) EnableRepo(ctx context.Context, name string, dryRun bool) error {
+	return a.toggleRepo(ctx, name, true, dryRun)
+}
+
+// DisableRepo comments out the deb and deb-src lines of a source, or marks
+// a deb822 one "Enabled: no".
+func (a *APT) DisableRepo(ctx context.Context, name string, dryRun bool) error {
+	return a.toggleRepo(ctx, name, false, dryRun)
+}
+
+func (a *APT) toggleRepo(ctx context.Context, name string, enable, dryRun bool) error {
+	files, err := aptSourceFiles()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if aptSourceName(file) != name {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var content string
+		if strings.HasSuffix(file, ".sources") {
+			content = toggleAptDeb822(string(data), enable)
+		} else {
+			content = toggleAptList(string(data), "", enable)
+		}
+		return a.writeConfig(ctx, file, content, dryRun)
+	}
+
+	data, err := os.ReadFile(aptSourcesList)
+	if err == nil {
+		for _, repo := range aptListRepos(string(data), aptSourcesList) {
+			if repo.Name == name {
+				return a.writeConfig(ctx, aptSourcesList, toggleAptList(string(data), name, enable), dryRun)
+			}
+		}
+	}
+	return fmt.Errorf("no APT source %s", name)
+}
+
+// AddRepo adds an APT source. ppa:owner/name adds a Launchpad PPA with its
+// signing key. A one-line source such as
+// "deb https://example.org/apt stable main" with a name is written to
+// sources.list.d/<name>.list; anything else is handed to
+// add-apt-repository, which names the source itself.
+func (a *APT) AddRepo(ctx context.Context, name, url string, dryRun bool) error {
+	if strings.HasPrefix(url, "ppa:") {
+		return a.addPPA(ctx, url, dryRun)
+	}
+	if name != "" && strings.HasPrefix(url, "deb ") {
+		if _, ok := parseAptEntry(url); !ok {
+			return fmt.Errorf("invalid APT source line %q", url)
+		}
+		path := filepath.Join(aptSourcesDir, name+".list")
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+		return a.writeConfig(ctx, path, url+"\n", dryRun)
+	}
+
+	if _, err := exec.LookPath("add-apt-repository"); err != nil {
+		return fmt.Errorf("add-apt-repository is not installed; add a named source line instead, e.g. poxy repo add -s apt example \"deb https://example.org/apt stable main\"")
+	}
+	if dryRun {
+		a.SetDryRun(true)
+		defer a.SetDryRun(false)
+	}
+	return a.Executor().RunSudo(ctx, "add-apt-repository", "-y", "-n", url)
+}
+
+// aptSourceFiles returns the .list and .sources files of sources.list.d.
+func aptSourceFiles() ([]string, error) {
+	entries, err := os.ReadDir(aptSourcesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && (strings.HasSuffix(name, ".list") || strings.HasSuffix(name, ".sources")) {
+			files = append(files, filepath.Join(aptSourcesDir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// aptSourceName returns the name of a sources.list.d file: its base name
+// without the extension.
+func aptSourceName(file string) string {
+	base := filepath.Base(file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// parseAptEntry parses a deb or deb-src line, which may be commented out
+// and may carry options: "deb [arch=amd64 signed-by=/k.gpg] URI suite main".
+func parseAptEntry(line string) (aptEntry, bool) {
+	line = strings.TrimSpace(line)
+	body := strings.TrimSpace(strings.TrimLeft(line, "#"))
+
+	fields := strings.Fields(body)
+	if len(fields) < 3 || (fields[0] != "deb" && fields[0] != "deb-src") {
+		return aptEntry{}, false
+	}
+	entry := aptEntry{Type: fields[0], Commented: body != line}
+
+	rest := fields[1:]
+	if strings.HasPrefix(rest[0], "[") {
+		for len(rest) > 0 {
+			done := strings.HasSuffix(rest[0], "]")
+			rest = rest[1:]
+			if done {
+				break
+			}
+		}
+	}
+	if len(rest) < 2 {
+		return aptEntry{}, false
+	}
+	entry.URI, entry.Suite = rest[0], rest[1]
+	return entry, true
+}
+
+// aptListRepos returns a repository for each suite of the deb lines of a
+// one-line sources file. A suite is enabled if any of its lines is.
+func aptListRepos(content, file string) []manager.Repository {
+	var repos []manager.Repository
+	index := map[string]int{}
+
+	for _, line := range strings.Split(content, "\n") {
+		entry, ok := parseAptEntry(line)
+		if !ok || entry.Type != "deb" {
+			continue
+		}
+		i, seen := index[entry.Suite]
+		if !seen {
+			index[entry.Suite] = len(repos)
+			repos = append(repos, manager.Repository{Name: entry.Suite, URL: entry.URI, File: file})
+			i = len(repos) - 1
+		}
+		if !entry.Commented {
+			repos[i].Enabled = true
+		}
+	}
+	return repos
+}
+
+// aptListFileRepo returns the repository of a sources.list.d .list file,
+// which is enabled if any of its deb lines is.
+func aptListFileRepo(content string) (manager.Repository, bool) {
+	repos := aptListRepos(content, "")
+	if len(repos) == 0 {
+		return manager.Repository{}, false
+	}
+
+	repo := repos[0]
+	for _, r := range repos {
+		repo.Enabled = repo.Enabled || r.Enabled
+	}
+	return repo, true
+}
+
+// aptDeb822Repo returns the repository of a deb822 .sources file, which
+// is enabled unless every stanza says "Enabled: no".
+func aptDeb822Repo(content string) (manager.Repository, bool) {
+	var repo manager.Repository
+	found := false
+	stanzaEnabled := true
+	inStanza := false
+
+	endStanza := func() {
+		if inStanza && stanzaEnabled {
+			repo.Enabled = true
+		}
+		inStanza, stanzaEnabled = false, true
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			endStanza()
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		inStanza, found = true, true
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(key) {
+		case "enabled":
+			stanzaEnabled = value != "no"
+		case "uris":
+			if repo.URL == "" {
+				repo.URL, _, _ = strings.Cut(value, " ")
+			}
+		}
+	}
+	endStanza()
+	return repo, found
+}
+
+// toggleAptList comments out the deb and deb-src lines of suite, or of
+// the whole file if suite is "", or uncomments its deb lines.
+func toggleAptList(content, suite string, enable bool) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		entry, ok := parseAptEntry(line)
+		if !ok || (suite != "" && entry.Suite != suite) {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case enable && entry.Commented && entry.Type == "deb":
+			lines[i] = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		case !enable && !entry.Commented:
+			lines[i] = "# " + trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toggleAptDeb822 drops the Enabled fields of a deb822 sources file and,
+// to disable it, ends each stanza with "Enabled: no".
+func toggleAptDeb822(content string, enable bool) string {
+	var out []string
+	inStanza := false
+
+	endStanza := func() {
+		if inStanza && !enable {
+			out = append(out, "Enabled: no")
+		}
+		inStanza = false
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			endStanza()
+		case strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(strings.ToLower(trimmed), "enabled:"):
+			continue
+		default:
+			inStanza = true
+		}
+		out = append(out, line)
+	}
+	endStanza()
+	return strings.Join(out, "\n") + "\n"
+}
@@ -0,0 +1,74 @@
+package native
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAptListRepos(t *testing.T) {
+	content := `deb http://archive.ubuntu.com/ubuntu noble main restricted
+deb-src http://archive.ubuntu.com/ubuntu noble main restricted
+# deb http://archive.ubuntu.com/ubuntu noble-backports main
+deb [arch=amd64 signed-by=/usr/share/keyrings/ubuntu.gpg] http://security.ubuntu.com/ubuntu noble-security main
+`
+	repos := aptListRepos(content, aptSourcesList)
+	if len(repos) != 3 {
+		t.Fatalf("expected 3 repositories, got %d: %+v", len(repos), repos)
+	}
+	if repos[0].Name != "noble" || !repos[0].Enabled || repos[0].URL != "http://archive.ubuntu.com/ubuntu" {
+		t.Errorf("unexpected repository: %+v", repos[0])
+	}
+	if repos[1].Name != "noble-backports" || repos[1].Enabled {
+		t.Errorf("unexpected repository: %+v", repos[1])
+	}
+	if repos[2].Name != "noble-security" || repos[2].URL != "http://security.ubuntu.com/ubuntu" {
+		t.Errorf("options should be skipped: %+v", repos[2])
+	}
+}
+
+func TestToggleAptList(t *testing.T) {
+	content := "deb http://example.org/apt stable main\ndeb-src http://example.org/apt stable main\n"
+
+	disabled := toggleAptList(content, "", false)
+	if disabled != "# deb http://example.org/apt stable main\n# deb-src http://example.org/apt stable main\n" {
+		t.Errorf("unexpected disabled source:\n%s", disabled)
+	}
+
+	enabled := toggleAptList(disabled, "stable", true)
+	if enabled != "deb http://example.org/apt stable main\n# deb-src http://example.org/apt stable main\n" {
+		t.Errorf("unexpected enabled source:\n%s", enabled)
+	}
+
+	if got := toggleAptList(content, "testing", false); got != content {
+		t.Errorf("other suites should be left alone:\n%s", got)
+	}
+}
+
+func TestAptDeb822(t *testing.T) {
+	content := `Types: deb
+URIs: http://archive.ubuntu.com/ubuntu
+Suites: noble noble-updates
+Components: main universe
+
+Types: deb
+URIs: http://security.ubuntu.com/ubuntu
+Suites: noble-security
+Components: main universe
+`
+	repo, ok := aptDeb822Repo(content)
+	if !ok || !repo.Enabled || repo.URL != "http://archive.ubuntu.com/ubuntu" {
+		t.Fatalf("unexpected repository: %+v", repo)
+	}
+
+	disabled := toggleAptDeb822(content, false)
+	if strings.Count(disabled, "Enabled: no") != 2 {
+		t.Errorf("each stanza should be disabled:\n%s", disabled)
+	}
+	if repo, _ := aptDeb822Repo(disabled); repo.Enabled {
+		t.Error("disabled source reported enabled")
+	}
+
+	if enabled := toggleAptDeb822(disabled, true); enabled != content {
+		t.Errorf("enabling should restore the file:\n%s", enabled)
+	}
+}
@@ -2,6 +2,8 @@
 package native
 
 import (
+	"context"
+	"fmt"
 	"os/exec"
 
 	"poxy/internal/executor"
@@ -16,6 +18,7 @@ type BaseManager struct {
 	managerType manager.ManagerType
 	needsSudo   bool
 	exec        *executor.Executor
+	chroot      string // Root commands run chrooted into, if any
 }
 
 // NewBaseManager creates a new BaseManager with the given parameters.
@@ -45,8 +48,12 @@ func (b *BaseManager) Type() manager.ManagerType {
 	return b.managerType
 }
 
-// IsAvailable returns true if this package manager is installed.
+// IsAvailable returns true if this package manager is installed, in the
+// chroot if it runs chrooted.
 func (b *BaseManager) IsAvailable() bool {
+	if b.chroot != "" {
+		return installedIn(b.chroot, b.binary)
+	}
 	_, err := exec.LookPath(b.binary)
 	return err == nil
 }
@@ -85,3 +92,15 @@ func (b *BaseManager) SetDryRun(dryRun bool) {
 func (b *BaseManager) SetVerbose(verbose bool) {
 	b.exec.SetVerbose(verbose)
 }
+
+// Version returns the backend version reported by `<binary> --version`.
+func (b *BaseManager) Version(ctx context.Context) (string, error) {
+	output, err := b.exec.OutputQuiet(ctx, b.binary, "--version")
+	if version := manager.ExtractVersion(output); version != "" {
+		return version, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("could not determine %s version", b.binary)
+}
@@ -4,6 +4,9 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"poxy/pkg/manager"
@@ -14,13 +17,52 @@ type Brew struct {
 	*BaseManager
 }
 
+// brewPrefixes are the standard places Homebrew installs itself into. Its
+// bin directory is only on the PATH once its shellenv is set up.
+var brewPrefixes = []string{"/home/linuxbrew/.linuxbrew", "/opt/homebrew", "/usr/local"}
+
 // NewBrew creates a new Homebrew manager instance.
 func NewBrew() *Brew {
 	return &Brew{
-		BaseManager: NewBaseManager("brew", "Homebrew", "brew", false),
+		BaseManager: NewBaseManager("brew", "Homebrew", brewBinary(), false),
 	}
 }
 
+// InstallScope returns manager.ScopeUser: Homebrew installs into a prefix
+// its user owns, without root.
+func (b *Brew) InstallScope() manager.Scope {
+	return manager.ScopeUser
+}
+
+// brewBinary returns "brew" if it is on the PATH, or else the brew of a
+// standard prefix, so Homebrew works before its shellenv is set up.
+func brewBinary() string {
+	if _, err := exec.LookPath("brew"); err == nil {
+		return "brew"
+	}
+
+	prefixes := brewPrefixes
+	if home, err := os.UserHomeDir(); err == nil {
+		prefixes = append(prefixes, filepath.Join(home, ".linuxbrew"))
+	}
+	for _, prefix := range prefixes {
+		binary := filepath.Join(prefix, "bin", "brew")
+		if _, err := os.Stat(binary); err == nil {
+			return binary
+		}
+	}
+	return "brew"
+}
+
+// BinDir returns the bin directory of the Homebrew prefix.
+func (b *Brew) BinDir(ctx context.Context) string {
+	path, err := exec.LookPath(b.Binary())
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(path)
+}
+
 // Install installs one or more packages.
 func (b *Brew) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	args := []string{"install"}
@@ -29,6 +71,7 @@ func (b *Brew) Install(ctx context.Context, packages []string, opts manager.Inst
 		args = append(args, "--force")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -67,6 +110,8 @@ func (b *Brew) Update(ctx context.Context) error {
 func (b *Brew) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"upgrade"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
@@ -79,6 +124,25 @@ func (b *Brew) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	return b.Executor().Run(ctx, b.Binary(), args...)
 }
 
+// Hold pins the formulae with brew pin.
+func (b *Brew) Hold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return b.pin(ctx, "pin", pkgs, dryRun)
+}
+
+// Unhold unpins the formulae with brew unpin.
+func (b *Brew) Unhold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return b.pin(ctx, "unpin", pkgs, dryRun)
+}
+
+func (b *Brew) pin(ctx context.Context, action string, pkgs []string, dryRun bool) error {
+	if dryRun {
+		b.SetDryRun(true)
+		defer b.SetDryRun(false)
+	}
+
+	return b.Executor().Run(ctx, b.Binary(), append([]string{action}, pkgs...)...)
+}
+
 // Search finds packages matching the query.
 func (b *Brew) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
 	if opts.InstalledOnly {
@@ -24,6 +24,7 @@ func NewChocolatey() *Chocolatey {
 // Install installs one or more packages.
 func (c *Chocolatey) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	args := []string{"install"}
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.AutoConfirm {
@@ -73,6 +74,8 @@ func (c *Chocolatey) Update(ctx context.Context) error {
 func (c *Chocolatey) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"upgrade"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) == 0 {
 		args = append(args, "all")
 	} else {
@@ -104,7 +107,14 @@ func (c *Chocolatey) Search(ctx context.Context, query string, opts manager.Sear
 		return []manager.Package{}, nil
 	}
 
-	return c.parseSearchOutput(output, opts.Limit), nil
+	packages := c.parseSearchOutput(output, opts.Limit)
+	if opts.InstalledOnly {
+		for i := range packages {
+			packages[i].Installed = true
+		}
+	}
+
+	return packages, nil
 }
 
 // parseSearchOutput parses choco search output.
@@ -33,6 +33,7 @@ func (d *DNF) Install(ctx context.Context, packages []string, opts manager.Insta
 		args = append(args, "--reinstall")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -83,8 +84,12 @@ func (d *DNF) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
+	} else if len(opts.Exclude) > 0 {
+		args = append(args, "--exclude="+strings.Join(opts.Exclude, ","))
 	}
 
 	if opts.DryRun {
@@ -95,6 +100,32 @@ func (d *DNF) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	return d.Executor().RunSudo(ctx, d.Binary(), args...)
 }
 
+// ExcludesUpgrades reports that full upgrades honor UpgradeOpts.Exclude.
+func (d *DNF) ExcludesUpgrades() bool {
+	return true
+}
+
+// Hold locks the packages at their installed version with dnf versionlock.
+func (d *DNF) Hold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return d.versionlock(ctx, "add", pkgs, dryRun)
+}
+
+// Unhold removes the versionlock entries of the packages.
+func (d *DNF) Unhold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return d.versionlock(ctx, "delete", pkgs, dryRun)
+}
+
+// versionlock runs a versionlock subcommand. DNF 4 needs the
+// python3-dnf-plugin-versionlock package; DNF 5 has it built in.
+func (d *DNF) versionlock(ctx context.Context, action string, pkgs []string, dryRun bool) error {
+	if dryRun {
+		d.SetDryRun(true)
+		defer d.SetDryRun(false)
+	}
+
+	return d.Executor().RunSudo(ctx, d.Binary(), append([]string{"versionlock", action}, pkgs...)...)
+}
+
 // Search finds packages matching the query.
 func (d *DNF) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
 	args := []string{"search"}
@@ -111,7 +142,9 @@ func (d *DNF) Search(ctx context.Context, query string, opts manager.SearchOpts)
 		return []manager.Package{}, nil
 	}
 
-	return d.parseSearchOutput(output, opts.Limit), nil
+	packages := d.parseSearchOutput(output, opts.Limit)
+	manager.CheckParsed("dnf", "dnf search", output, len(packages))
+	return packages, nil
 }
 
 // searchInstalled searches installed packages.
@@ -318,6 +351,9 @@ func (d *DNF) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manag
 		}
 	}
 
+	if opts.Pattern == "" {
+		manager.CheckParsed("dnf", "dnf list installed", output, len(packages))
+	}
 	return packages, nil
 }
 
@@ -341,3 +377,28 @@ func (d *DNF) Clean(ctx context.Context, opts manager.CleanOpts) error {
 func (d *DNF) Autoremove(ctx context.Context) error {
 	return d.Executor().RunSudo(ctx, d.Binary(), "autoremove", "-y")
 }
+
+// ReverseDependencies returns the installed packages that require pkg.
+func (d *DNF) ReverseDependencies(ctx context.Context, pkg string) ([]string, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "repoquery", "--installed", "--quiet",
+		"--whatrequires", pkg, "--queryformat", "%{name}\n")
+	if err != nil {
+		return nil, fmt.Errorf("dnf repoquery failed for %s: %w", pkg, err)
+	}
+	return parseRepoqueryNames(output, pkg), nil
+}
+
+// parseRepoqueryNames parses one package name per line, dropping duplicates
+// (one per installed architecture) and the package itself.
+func parseRepoqueryNames(output, pkg string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range strings.Fields(output) {
+		if name == pkg || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package native
+
+import (
+	"context"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ListCatalog returns the newest version of every package in the enabled
+// repositories (dnf repoquery --all), marking the installed ones.
+func (d *DNF) ListCatalog(ctx context.Context) ([]manager.Package, error) {
+	// dnf5 does not end each package with a newline; dnf4 does, making
+	// the explicit one an empty line
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "repoquery", "--quiet", "--all", "--latest-limit=1",
+		"--queryformat", `%{name}\t%{evr}\t%{summary}\n`)
+	if err != nil {
+		return nil, err
+	}
+	packages := parseRepoquery(output)
+	manager.CheckParsed("dnf", "dnf repoquery --all", output, len(packages))
+
+	installed := make(map[string]bool)
+	if output, err := d.Executor().OutputQuiet(ctx, "rpm", "-qa", "--queryformat", `%{NAME}\n`); err == nil {
+		for _, name := range strings.Fields(output) {
+			installed[name] = true
+		}
+	}
+	for i := range packages {
+		packages[i].Installed = installed[packages[i].Name]
+	}
+
+	return packages, nil
+}
+
+// parseRepoquery parses "name\tevr\tsummary" lines of dnf repoquery. A
+// package built for several architectures is kept once.
+func parseRepoquery(output string) []manager.Package {
+	var packages []manager.Package
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 || fields[0] == "" || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		packages = append(packages, manager.Package{
+			Name:        fields[0],
+			Version:     fields[1],
+			Description: fields[2],
+			Source:      "dnf",
+		})
+	}
+	return packages
+}
@@ -0,0 +1,21 @@
+package native
+
+import "testing"
+
+func TestParseRepoquery(t *testing.T) {
+	output := "bash\t5.2.26-3.fc40\tThe GNU Bourne Again shell\n\n" +
+		"glibc\t2.39-17.fc40\tThe GNU libc libraries\n" +
+		"glibc\t2.39-17.fc40\tThe GNU libc libraries\n" +
+		"Last metadata expiration check: 0:12:01 ago\n"
+
+	packages := parseRepoquery(output)
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packages), packages)
+	}
+	if packages[0].Name != "bash" || packages[0].Version != "5.2.26-3.fc40" || packages[0].Description != "The GNU Bourne Again shell" {
+		t.Errorf("unexpected package: %+v", packages[0])
+	}
+	if packages[1].Name != "glibc" || packages[1].Source != "dnf" {
+		t.Errorf("unexpected package: %+v", packages[1])
+	}
+}
@@ -0,0 +1,50 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// coprResults serves the packages and signing keys of COPR projects
+const coprResults = "https://download.copr.fedorainfracloud.org/results"
+
+// parseCopr parses copr:owner/project into owner/project. Group projects
+// have an owner starting with "@", e.g. copr:@kicad/kicad.
+func parseCopr(spec string) (string, error) {
+	project, ok := strings.CutPrefix(spec, "copr:")
+	owner, name, found := strings.Cut(project, "/")
+	if !ok || !found || owner == "" || owner == "@" || name == "" ||
+		strings.Contains(name, "/") || strings.ContainsAny(project, " \t") {
+		return "", fmt.Errorf("invalid COPR project %q (want copr:owner/project)", spec)
+	}
+	return project, nil
+}
+
+// coprKeyURL returns the URL of the key the packages of a COPR project are
+// signed with.
+func coprKeyURL(project string) string {
+	return coprResults + "/" + project + "/pubkey.gpg"
+}
+
+// addCopr enables a COPR project with dnf copr, which DNF 4 has from
+// dnf-plugins-core, and imports its signing key so the first install from
+// it does not stop to ask.
+func (d *DNF) addCopr(ctx context.Context, spec string, dryRun bool) error {
+	project, err := parseCopr(spec)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		d.SetDryRun(true)
+		defer d.SetDryRun(false)
+	}
+	if err := d.Executor().RunSudo(ctx, d.Binary(), "copr", "enable", "-y", project); err != nil {
+		return err
+	}
+	if err := d.Executor().RunSudo(ctx, "rpm", "--import", coprKeyURL(project)); err != nil {
+		return fmt.Errorf("enabled %s, but failed to import its signing key: %w", project, err)
+	}
+	return nil
+}
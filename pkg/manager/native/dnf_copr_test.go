@@ -0,0 +1,34 @@
+package native
+
+import "testing"
+
+func TestParseCopr(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "copr:atim/starship", want: "atim/starship"},
+		{spec: "copr:@kicad/kicad", want: "@kicad/kicad"},
+		{spec: "copr:atim", wantErr: true},
+		{spec: "copr:@/kicad", wantErr: true},
+		{spec: "copr:a/b/c", wantErr: true},
+		{spec: "atim/starship", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCopr(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCopr(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCopr(%q) = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+
+	want := "https://download.copr.fedorainfracloud.org/results/@kicad/kicad/pubkey.gpg"
+	if got := coprKeyURL("@kicad/kicad"); got != want {
+		t.Errorf("coprKeyURL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+package native
+
+import (
+	"bufio"
+	"context"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ListStreams returns all module streams known to DNF.
+func (d *DNF) ListStreams(ctx context.Context) ([]manager.ModuleStream, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "module", "list")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseModuleList(output), nil
+}
+
+// EnabledStreams returns only the enabled module streams.
+func (d *DNF) EnabledStreams(ctx context.Context) ([]manager.ModuleStream, error) {
+	streams, err := d.ListStreams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled []manager.ModuleStream
+	for _, s := range streams {
+		if s.Enabled {
+			enabled = append(enabled, s)
+		}
+	}
+	return enabled, nil
+}
+
+// EnableStream enables a module stream. If another stream of the module is
+// enabled, DNF switches to the requested stream and syncs installed packages.
+func (d *DNF) EnableStream(ctx context.Context, name, stream string, dryRun bool) error {
+	enabled, _ := d.EnabledStreams(ctx) //nolint:errcheck
+
+	action := "enable"
+	for _, s := range enabled {
+		if s.Name == name && s.Stream != stream {
+			action = "switch-to"
+			break
+		}
+	}
+
+	if dryRun {
+		d.SetDryRun(true)
+		defer d.SetDryRun(false)
+	}
+
+	return d.Executor().RunSudo(ctx, d.Binary(), "module", action, "-y", name+":"+stream)
+}
+
+// ResetStream resets a module to its default state.
+func (d *DNF) ResetStream(ctx context.Context, name string, dryRun bool) error {
+	if dryRun {
+		d.SetDryRun(true)
+		defer d.SetDryRun(false)
+	}
+
+	return d.Executor().RunSudo(ctx, d.Binary(), "module", "reset", "-y", name)
+}
+
+// StreamForPackage returns the module stream that provides a package,
+// or nil if the package is not modular.
+func (d *DNF) StreamForPackage(ctx context.Context, pkg string) (*manager.ModuleStream, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "module", "provides", pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseModuleProvides(output), nil
+}
+
+// parseModuleList parses `dnf module list` output. The output contains one
+// table per repository; stream and profile flags are marked with [d]efault,
+// [e]nabled, [x]disabled and [i]nstalled.
+func parseModuleList(output string) []manager.ModuleStream {
+	var streams []manager.ModuleStream
+	seen := make(map[string]int)
+
+	streamCol, profilesCol, summaryCol := -1, -1, -1
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "Name ") && strings.Contains(line, "Stream") {
+			streamCol = strings.Index(line, "Stream")
+			profilesCol = strings.Index(line, "Profiles")
+			summaryCol = strings.Index(line, "Summary")
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "Hint:") {
+			streamCol = -1
+			continue
+		}
+		if streamCol < 0 || profilesCol < streamCol || len(line) <= streamCol {
+			continue
+		}
+
+		name := strings.TrimSpace(line[:streamCol])
+		streamCell := columnText(line, streamCol, profilesCol)
+		profilesCell := columnText(line, profilesCol, summaryCol)
+		summary := ""
+		if summaryCol > 0 && len(line) > summaryCol {
+			summary = strings.TrimSpace(line[summaryCol:])
+		}
+
+		fields := strings.Fields(streamCell)
+		if name == "" || len(fields) == 0 {
+			continue
+		}
+
+		ms := manager.ModuleStream{
+			Name:    name,
+			Stream:  fields[0],
+			Summary: summary,
+		}
+		flags := strings.Join(fields[1:], "")
+		ms.Default = strings.Contains(flags, "[d]")
+		ms.Enabled = strings.Contains(flags, "[e]")
+
+		for _, profile := range strings.Split(profilesCell, ",") {
+			profile = strings.TrimSpace(profile)
+			if profile == "" {
+				continue
+			}
+			if strings.Contains(profile, "[i]") {
+				ms.Installed = true
+			}
+			if pf := strings.Fields(profile); len(pf) > 0 {
+				ms.Profiles = append(ms.Profiles, pf[0])
+			}
+		}
+
+		// The same stream can be listed by several repositories
+		if idx, ok := seen[ms.String()]; ok {
+			streams[idx].Enabled = streams[idx].Enabled || ms.Enabled
+			streams[idx].Installed = streams[idx].Installed || ms.Installed
+			continue
+		}
+		seen[ms.String()] = len(streams)
+		streams = append(streams, ms)
+	}
+
+	return streams
+}
+
+// columnText returns the trimmed text between two column offsets.
+func columnText(line string, start, end int) string {
+	if start < 0 || start >= len(line) {
+		return ""
+	}
+	if end < 0 || end > len(line) {
+		end = len(line)
+	}
+	return strings.TrimSpace(line[start:end])
+}
+
+// parseModuleProvides parses `dnf module provides` output and returns the
+// first module stream listed, e.g. "Module : nodejs:20:3820230518:abcd:x86_64".
+func parseModuleProvides(output string) *manager.ModuleStream {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != "Module" {
+			continue
+		}
+
+		spec := strings.Split(strings.TrimSpace(parts[1]), ":")
+		if len(spec) < 2 {
+			continue
+		}
+		return &manager.ModuleStream{Name: spec[0], Stream: spec[1]}
+	}
+
+	return nil
+}
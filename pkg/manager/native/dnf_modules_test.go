@@ -0,0 +1,72 @@
+package native
+
+import "testing"
+
+func TestParseModuleList(t *testing.T) {
+	output := `Last metadata expiration check: 0:12:01 ago on Mon 01 Jan 2024 10:00:00 AM UTC.
+Fedora Modular 38 - x86_64
+Name         Stream       Profiles                          Summary
+nodejs       18 [d][e]    common [d] [i], development       Javascript runtime
+nodejs       20           common [d], development, minimal  Javascript runtime
+postgresql   15 [d]       client, server [d]                PostgreSQL server and client module
+
+Fedora Modular 38 - x86_64 - Updates
+Name         Stream       Profiles                          Summary
+nodejs       18 [d][e]    common [d], development           Javascript runtime
+
+Hint: [d]efault, [e]nabled, [x]disabled, [i]nstalled
+`
+
+	streams := parseModuleList(output)
+	if len(streams) != 3 {
+		t.Fatalf("expected 3 streams, got %d: %+v", len(streams), streams)
+	}
+
+	node18 := streams[0]
+	if node18.String() != "nodejs:18" {
+		t.Errorf("expected nodejs:18, got %s", node18)
+	}
+	if !node18.Default || !node18.Enabled || !node18.Installed {
+		t.Errorf("expected nodejs:18 to be default, enabled and installed: %+v", node18)
+	}
+	if len(node18.Profiles) != 2 || node18.Profiles[0] != "common" {
+		t.Errorf("unexpected profiles: %v", node18.Profiles)
+	}
+	if node18.Summary != "Javascript runtime" {
+		t.Errorf("unexpected summary: %q", node18.Summary)
+	}
+
+	node20 := streams[1]
+	if node20.Enabled || node20.Default || node20.Installed {
+		t.Errorf("nodejs:20 should have no flags: %+v", node20)
+	}
+	if len(node20.Profiles) != 3 {
+		t.Errorf("expected 3 profiles for nodejs:20, got %v", node20.Profiles)
+	}
+
+	if streams[2].String() != "postgresql:15" || streams[2].Enabled {
+		t.Errorf("unexpected postgresql stream: %+v", streams[2])
+	}
+}
+
+func TestParseModuleProvides(t *testing.T) {
+	output := `Last metadata expiration check: 0:01:00 ago.
+nodejs-1:20.1.0-1.module_f38+16885+2b4d0c0f.x86_64
+Module   : nodejs:20:3820230518080915:f6e5b8e7:x86_64
+Profiles : common default development minimal
+Repo     : updates-modular
+Summary  : Javascript runtime
+`
+
+	stream := parseModuleProvides(output)
+	if stream == nil {
+		t.Fatal("expected a module stream")
+	}
+	if stream.Name != "nodejs" || stream.Stream != "20" {
+		t.Errorf("expected nodejs:20, got %s", stream)
+	}
+
+	if parseModuleProvides("No matches found\n") != nil {
+		t.Error("expected nil for non-modular package")
+	}
+}
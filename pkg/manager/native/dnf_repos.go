@@ -0,0 +1,149 @@
+package native
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// dnfReposDir holds the .repo files of DNF and yum
+const dnfReposDir = "/etc/yum.repos.d"
+
+// ListRepos returns the repositories of the .repo files in
+// /etc/yum.repos.d, named by their id.
+func (d *DNF) ListRepos(ctx context.Context) ([]manager.Repository, error) {
+	files, err := filepath.Glob(filepath.Join(dnfReposDir, "*.repo"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var repos []manager.Repository
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		repos = append(repos, parseDNFRepoFile(string(data), file)...)
+	}
+	return repos, nil
+}
+
+// EnableRepo enables a repository with dnf config-manager.
+func (d *DNF) EnableRepo(ctx context.Context, name string, dryRun bool) error {
+	return d.setRepoEnabled(ctx, name, true, dryRun)
+}
+
+// DisableRepo disables a repository with dnf config-manager.
+func (d *DNF) DisableRepo(ctx context.Context, name string, dryRun bool) error {
+	return d.setRepoEnabled(ctx, name, false, dryRun)
+}
+
+func (d *DNF) setRepoEnabled(ctx context.Context, name string, enable, dryRun bool) error {
+	var args []string
+	switch {
+	case d.isDNF5(ctx) && enable:
+		args = []string{"config-manager", "setopt", name + ".enabled=1"}
+	case d.isDNF5(ctx):
+		args = []string{"config-manager", "setopt", name + ".enabled=0"}
+	case enable:
+		args = []string{"config-manager", "--set-enabled", name}
+	default:
+		args = []string{"config-manager", "--set-disabled", name}
+	}
+	return d.configManager(ctx, args, dryRun)
+}
+
+// AddRepo adds a repository with dnf config-manager. copr:owner/project
+// enables a COPR project with its signing key. A URL of a .repo file adds
+// the repositories it defines; any other URL is a baseurl, for which DNF 5
+// needs a name to use as the id.
+func (d *DNF) AddRepo(ctx context.Context, name, url string, dryRun bool) error {
+	if strings.HasPrefix(url, "copr:") {
+		return d.addCopr(ctx, url, dryRun)
+	}
+	if !d.isDNF5(ctx) {
+		return d.configManager(ctx, []string{"config-manager", "--add-repo", url}, dryRun)
+	}
+
+	args := []string{"config-manager", "addrepo"}
+	if strings.HasSuffix(url, ".repo") || name == "" {
+		args = append(args, "--from-repofile="+url)
+	} else {
+		args = append(args, "--id="+name, "--set=baseurl="+url)
+	}
+	return d.configManager(ctx, args, dryRun)
+}
+
+// configManager runs a config-manager subcommand. DNF 4 needs the
+// dnf-plugins-core package for it.
+func (d *DNF) configManager(ctx context.Context, args []string, dryRun bool) error {
+	if dryRun {
+		d.SetDryRun(true)
+		defer d.SetDryRun(false)
+	}
+	return d.Executor().RunSudo(ctx, d.Binary(), args...)
+}
+
+// isDNF5 returns true if dnf is DNF 5, whose config-manager takes
+// subcommands instead of options.
+func (d *DNF) isDNF5(ctx context.Context) bool {
+	version, err := d.Version(ctx)
+	return err == nil && strings.HasPrefix(version, "5.")
+}
+
+// parseDNFRepoFile returns the repositories of a .repo file. name= is the
+// description; the URL is the baseurl, metalink or mirrorlist, in that
+// order of preference.
+func parseDNFRepoFile(content, file string) []manager.Repository {
+	var repos []manager.Repository
+	var metalink, mirrorlist []string
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			repos = append(repos, manager.Repository{Name: line[1 : len(line)-1], Enabled: true, File: file})
+			metalink, mirrorlist = append(metalink, ""), append(mirrorlist, "")
+			continue
+		}
+		if len(repos) == 0 {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		repo := &repos[len(repos)-1]
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "name":
+			repo.Description = value
+		case "enabled":
+			repo.Enabled = value == "1" || value == "true" || value == "yes"
+		case "baseurl":
+			repo.URL, _, _ = strings.Cut(value, " ")
+		case "metalink":
+			metalink[len(repos)-1] = value
+		case "mirrorlist":
+			mirrorlist[len(repos)-1] = value
+		}
+	}
+
+	for i := range repos {
+		if repos[i].URL == "" {
+			repos[i].URL = metalink[i]
+		}
+		if repos[i].URL == "" {
+			repos[i].URL = mirrorlist[i]
+		}
+	}
+	return repos
+}
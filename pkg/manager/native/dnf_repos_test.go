@@ -0,0 +1,38 @@
+package native
+
+import "testing"
+
+func TestParseDNFRepoFile(t *testing.T) {
+	content := `[fedora]
+name=Fedora $releasever - $basearch
+#baseurl=http://download.example/pub/fedora/linux/releases/$releasever/Everything/$basearch/os/
+metalink=https://mirrors.fedoraproject.org/metalink?repo=fedora-$releasever&arch=$basearch
+enabled=1
+
+[fedora-debuginfo]
+name=Fedora $releasever - $basearch - Debug
+baseurl=http://download.example/debug/ http://mirror.example/debug/
+enabled=0
+
+[docker-ce-stable]
+name=Docker CE Stable
+mirrorlist=https://example.org/mirrors
+`
+	repos := parseDNFRepoFile(content, "/etc/yum.repos.d/fedora.repo")
+	if len(repos) != 3 {
+		t.Fatalf("expected 3 repositories, got %d: %+v", len(repos), repos)
+	}
+
+	if repos[0].Name != "fedora" || !repos[0].Enabled || repos[0].Description != "Fedora $releasever - $basearch" {
+		t.Errorf("unexpected repository: %+v", repos[0])
+	}
+	if repos[0].URL != "https://mirrors.fedoraproject.org/metalink?repo=fedora-$releasever&arch=$basearch" {
+		t.Errorf("metalink should be used without a baseurl: %q", repos[0].URL)
+	}
+	if repos[1].Enabled || repos[1].URL != "http://download.example/debug/" {
+		t.Errorf("unexpected repository: %+v", repos[1])
+	}
+	if !repos[2].Enabled || repos[2].URL != "https://example.org/mirrors" {
+		t.Errorf("repositories should default to enabled: %+v", repos[2])
+	}
+}
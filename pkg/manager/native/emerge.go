@@ -30,6 +30,7 @@ func (e *Emerge) Install(ctx context.Context, packages []string, opts manager.In
 		args = append(args, "--pretend")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	return e.Executor().RunSudo(ctx, e.Binary(), args...)
@@ -61,6 +62,8 @@ func (e *Emerge) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		args = append(args, "--pretend")
 	}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	} else {
@@ -72,6 +75,10 @@ func (e *Emerge) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 
 // Search finds packages matching the query.
 func (e *Emerge) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, e, query, opts)
+	}
+
 	args := []string{"-s", query}
 
 	if opts.SearchInDesc {
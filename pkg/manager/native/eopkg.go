@@ -29,6 +29,7 @@ func (e *Eopkg) Install(ctx context.Context, packages []string, opts manager.Ins
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -70,6 +71,8 @@ func (e *Eopkg) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
@@ -84,6 +87,10 @@ func (e *Eopkg) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 
 // Search finds packages matching the query.
 func (e *Eopkg) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, e, query, opts)
+	}
+
 	output, err := e.Executor().Output(ctx, e.Binary(), "search", query)
 	if err != nil {
 		return []manager.Package{}, nil
@@ -13,8 +13,24 @@ const (
 	PacmanErrorDependencyConflict
 	PacmanErrorPackageNotFound
 	PacmanErrorDatabaseLocked
+	PacmanErrorKeyring
 )
 
+// String returns the error category name.
+func (t PacmanErrorType) String() string {
+	switch t {
+	case PacmanErrorDependencyConflict:
+		return "dependency_conflict"
+	case PacmanErrorPackageNotFound:
+		return "not_found"
+	case PacmanErrorDatabaseLocked:
+		return "database_locked"
+	case PacmanErrorKeyring:
+		return "keyring"
+	}
+	return "unknown"
+}
+
 // PacmanError represents a structured error from pacman.
 type PacmanError struct {
 	ErrorType   PacmanErrorType
@@ -37,6 +53,31 @@ func (e *PacmanError) Unwrap() error {
 	return e.OriginalErr
 }
 
+// ErrorKind returns the parsed error category.
+func (e *PacmanError) ErrorKind() string {
+	return e.ErrorType.String()
+}
+
+// ErrorOutput returns the raw pacman output.
+func (e *PacmanError) ErrorOutput() string {
+	return e.RawOutput
+}
+
+// AffectedPackages returns the packages named in the error.
+func (e *PacmanError) AffectedPackages() []string {
+	return e.Packages
+}
+
+// ErrorSuggestion returns the suggested remedy, if any.
+func (e *PacmanError) ErrorSuggestion() string {
+	return e.Suggestion
+}
+
+// IsKeyringProblem returns true if this is a signature or keyring error.
+func (e *PacmanError) IsKeyringProblem() bool {
+	return e.ErrorType == PacmanErrorKeyring
+}
+
 // IsDependencyConflict returns true if this is a dependency conflict error.
 func (e *PacmanError) IsDependencyConflict() bool {
 	return e.ErrorType == PacmanErrorDependencyConflict
@@ -58,6 +99,20 @@ var (
 
 	// Matches: "error: failed to init transaction (unable to lock database)"
 	dbLockedPattern = regexp.MustCompile(`failed to init transaction.*unable to lock database`)
+
+	// Matches: "error: pkg: signature from "Packager <p@archlinux.org>" is unknown trust"
+	signatureTrustPattern = regexp.MustCompile(`error: (\S+): signature from .* is (unknown trust|marginal trust|invalid|expired)`)
+
+	// Matches signature failures caused by a missing, expired or corrupted keyring
+	keyringPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`invalid or corrupted package \(PGP signature\)`),
+		regexp.MustCompile(`required key missing from keyring`),
+		regexp.MustCompile(`key ".*" could not be looked up remotely`),
+		regexp.MustCompile(`(?i)trustdb`),
+		regexp.MustCompile(`GPGME error`),
+		regexp.MustCompile(`keyring is not writable`),
+		regexp.MustCompile(`public keyring not found`),
+	}
 )
 
 // ParsePacmanError parses pacman stderr output and returns a structured error.
@@ -107,6 +162,16 @@ func ParsePacmanError(stderr string, originalErr error) *PacmanError {
 		return pacErr
 	}
 
+	// Check for signature and keyring problems
+	if isKeyringError(stderr) {
+		pacErr.ErrorType = PacmanErrorKeyring
+		for _, m := range signatureTrustPattern.FindAllStringSubmatch(stderr, -1) {
+			pacErr.Packages = append(pacErr.Packages, m[1])
+		}
+		pacErr.Suggestion = "Run 'poxy keyring repair' to refresh and repopulate the pacman keyring"
+		return pacErr
+	}
+
 	// Unknown error type - return nil to indicate no special handling needed
 	return nil
 }
@@ -147,6 +212,27 @@ func extractAffectedPackages(stderr string) []string {
 	return packages
 }
 
+// isKeyringError reports whether pacman output contains a signature or keyring failure.
+func isKeyringError(stderr string) bool {
+	if signatureTrustPattern.MatchString(stderr) {
+		return true
+	}
+	for _, pattern := range keyringPatterns {
+		if pattern.MatchString(stderr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPacmanKeyringProblem checks if an error is a pacman signature or keyring error.
+func IsPacmanKeyringProblem(err error) (*PacmanError, bool) {
+	if pacErr, ok := err.(*PacmanError); ok && pacErr.IsKeyringProblem() {
+		return pacErr, true
+	}
+	return nil, false
+}
+
 // IsPacmanDependencyConflict checks if an error is a pacman dependency conflict.
 func IsPacmanDependencyConflict(err error) (*PacmanError, bool) {
 	if pacErr, ok := err.(*PacmanError); ok && pacErr.IsDependencyConflict() {
@@ -175,3 +261,24 @@ func FormatDependencyConflictMessage(pacErr *PacmanError) string {
 
 	return sb.String()
 }
+
+// FormatKeyringMessage returns a user-friendly message for signature and keyring errors.
+func FormatKeyringMessage(pacErr *PacmanError) string {
+	var sb strings.Builder
+	sb.WriteString("Package signature check failed!\n")
+	sb.WriteString("  This usually means the pacman keyring is outdated or its trust database is damaged.\n")
+	sb.WriteString("-> Suggestion: ")
+	sb.WriteString(pacErr.Suggestion)
+	sb.WriteString("\n")
+
+	if len(pacErr.Packages) > 0 {
+		sb.WriteString("  Affected packages:\n")
+		for _, pkg := range pacErr.Packages {
+			sb.WriteString("    - ")
+			sb.WriteString(pkg)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
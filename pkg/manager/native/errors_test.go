@@ -131,6 +131,10 @@ error: could not lock database: File exists
 	if pacErr.Suggestion == "" {
 		t.Error("expected non-empty Suggestion for database lock")
 	}
+
+	if pacErr.ErrorKind() != "database_locked" {
+		t.Errorf("expected ErrorKind 'database_locked', got '%s'", pacErr.ErrorKind())
+	}
 }
 
 func TestParsePacmanError_UnknownError(t *testing.T) {
@@ -270,3 +274,40 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestParsePacmanError_UnknownTrust(t *testing.T) {
+	stderr := `(2/2) checking keys in keyring
+(2/2) checking package integrity
+error: linux-firmware: signature from "Some Packager <packager@archlinux.org>" is unknown trust
+:: File /var/cache/pacman/pkg/linux-firmware-20240312-1-any.pkg.tar.zst is corrupted (invalid or corrupted package (PGP signature)).
+error: failed to commit transaction (invalid or corrupted package (PGP signature))`
+
+	pacErr := ParsePacmanError(stderr, errors.New("exit status 1"))
+
+	if pacErr == nil {
+		t.Fatal("expected PacmanError, got nil")
+	}
+
+	if !pacErr.IsKeyringProblem() {
+		t.Errorf("expected keyring error, got %s", pacErr.ErrorKind())
+	}
+
+	if len(pacErr.Packages) != 1 || pacErr.Packages[0] != "linux-firmware" {
+		t.Errorf("expected [linux-firmware], got %v", pacErr.Packages)
+	}
+
+	if _, ok := IsPacmanKeyringProblem(pacErr); !ok {
+		t.Error("expected IsPacmanKeyringProblem() to return true")
+	}
+}
+
+func TestParsePacmanError_TrustDB(t *testing.T) {
+	stderr := `gpg: /etc/pacman.d/gnupg/trustdb.gpg: invalid record type 0 at recnum 4
+error: GPGME error: Invalid crypto engine`
+
+	pacErr := ParsePacmanError(stderr, errors.New("exit status 1"))
+
+	if pacErr == nil || pacErr.ErrorKind() != "keyring" {
+		t.Fatalf("expected keyring error, got %v", pacErr)
+	}
+}
@@ -0,0 +1,213 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"poxy/internal/executor"
+)
+
+// OwnsFile returns the installed packages that own path (pacman -Qo).
+func (p *Pacman) OwnsFile(ctx context.Context, path string) ([]string, error) {
+	// pacman exits non-zero when no package owns the path
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qqo", path)
+	if err != nil {
+		return nil, nil
+	}
+	return parseFileList(output), nil
+}
+
+// ListFiles returns the files installed by pkg (pacman -Ql).
+func (p *Pacman) ListFiles(ctx context.Context, pkg string) ([]string, error) {
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qlq", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package %s is not installed", pkg)
+	}
+	return parseFileList(output), nil
+}
+
+// OwnsFile returns the installed packages that own path (dpkg -S). On
+// merged-/usr systems dpkg may know the file by its other path, such as
+// /bin/ls for /usr/bin/ls, so both are tried.
+func (a *APT) OwnsFile(ctx context.Context, path string) ([]string, error) {
+	paths := []string{path}
+	if alias := usrMergeAlias(path); alias != path {
+		paths = append(paths, alias)
+	}
+
+	for _, p := range paths {
+		// dpkg-query exits non-zero when no package owns the path
+		output, err := a.Executor().OutputQuiet(ctx, "dpkg-query", "-S", p)
+		if err != nil {
+			continue
+		}
+		if owners := parseDpkgSearch(output, p); len(owners) > 0 {
+			return owners, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListFiles returns the files installed by pkg (dpkg -L).
+func (a *APT) ListFiles(ctx context.Context, pkg string) ([]string, error) {
+	output, err := a.Executor().OutputQuiet(ctx, "dpkg-query", "-L", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package %s is not installed", pkg)
+	}
+	return parseFileList(output), nil
+}
+
+// OwnsFile returns the installed packages that own path (rpm -qf).
+func (d *DNF) OwnsFile(ctx context.Context, path string) ([]string, error) {
+	return rpmOwnsFile(ctx, d.Executor(), path)
+}
+
+// ListFiles returns the files installed by pkg (rpm -ql).
+func (d *DNF) ListFiles(ctx context.Context, pkg string) ([]string, error) {
+	return rpmListFiles(ctx, d.Executor(), pkg)
+}
+
+// OwnsFile returns the installed packages that own path (rpm -qf).
+func (z *Zypper) OwnsFile(ctx context.Context, path string) ([]string, error) {
+	return rpmOwnsFile(ctx, z.Executor(), path)
+}
+
+// ListFiles returns the files installed by pkg (rpm -ql).
+func (z *Zypper) ListFiles(ctx context.Context, pkg string) ([]string, error) {
+	return rpmListFiles(ctx, z.Executor(), pkg)
+}
+
+func rpmOwnsFile(ctx context.Context, exec *executor.Executor, path string) ([]string, error) {
+	// rpm exits non-zero with "file ... is not owned by any package"
+	output, err := exec.OutputQuiet(ctx, "rpm", "-qf", "--queryformat", "%{NAME}\n", path)
+	if err != nil {
+		return nil, nil
+	}
+	return parseRepoqueryNames(output, ""), nil
+}
+
+func rpmListFiles(ctx context.Context, exec *executor.Executor, pkg string) ([]string, error) {
+	output, err := exec.OutputQuiet(ctx, "rpm", "-ql", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package %s is not installed", pkg)
+	}
+	return parseFileList(output), nil
+}
+
+// PackageCommands returns the commands pkg installs, from the files
+// database of the repositories (pacman -Fl). It fails if the database was
+// never downloaded with pacman -Fy.
+func (p *Pacman) PackageCommands(ctx context.Context, pkg string) ([]string, error) {
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Fl", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("no file list for %s (pacman -Fy downloads them): %w", pkg, err)
+	}
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if _, file, found := strings.Cut(strings.TrimSpace(line), " "); found {
+			files = append(files, "/"+file)
+		}
+	}
+	return commandPaths(files), nil
+}
+
+// PackageCommands returns the commands pkg installs, from the file lists
+// apt-file downloads. It fails if apt-file is not installed.
+func (a *APT) PackageCommands(ctx context.Context, pkg string) ([]string, error) {
+	if _, err := exec.LookPath("apt-file"); err != nil {
+		return nil, fmt.Errorf("apt-file is not installed")
+	}
+	output, err := a.Executor().OutputQuiet(ctx, "apt-file", "list", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("apt-file list failed for %s: %w", pkg, err)
+	}
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if name, file, found := strings.Cut(line, ": "); found && name == pkg {
+			files = append(files, strings.TrimSpace(file))
+		}
+	}
+	return commandPaths(files), nil
+}
+
+// PackageCommands returns the commands pkg installs (dnf repoquery -l).
+func (d *DNF) PackageCommands(ctx context.Context, pkg string) ([]string, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "repoquery", "--quiet", "-l", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("dnf repoquery failed for %s: %w", pkg, err)
+	}
+	return commandPaths(parseFileList(output)), nil
+}
+
+// commandDirs are the directories packages install commands into.
+var commandDirs = []string{"/usr/bin", "/usr/sbin", "/bin", "/sbin", "/usr/local/bin", "/usr/games"}
+
+// commandPaths returns the files directly in a command directory.
+func commandPaths(files []string) []string {
+	var commands []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "/") || !slices.Contains(commandDirs, filepath.Dir(file)) {
+			continue
+		}
+		if !slices.Contains(commands, file) {
+			commands = append(commands, file)
+		}
+	}
+	return commands
+}
+
+// usrMergeAlias returns the other path of a file on a merged-/usr system,
+// where /bin, /sbin and /lib* are symlinks into /usr.
+func usrMergeAlias(path string) string {
+	for _, dir := range []string{"/bin/", "/sbin/", "/lib/", "/lib32/", "/lib64/", "/libx32/"} {
+		if strings.HasPrefix(path, "/usr"+dir) {
+			return strings.TrimPrefix(path, "/usr")
+		}
+		if strings.HasPrefix(path, dir) {
+			return "/usr" + path
+		}
+	}
+	return path
+}
+
+// parseFileList parses one path or package per line. rpm prints
+// "(contains no files)" for packages without files, and dpkg lists "/.".
+func parseFileList(output string) []string {
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "/." || line == "(contains no files)" {
+			continue
+		}
+		files = append(files, line)
+	}
+	return files
+}
+
+// parseDpkgSearch parses `dpkg-query -S` output such as
+// "libc6:amd64, libc6:i386: /usr/lib/locale" into package names without
+// architecture qualifiers. Diversion lines and other paths matching the
+// pattern are skipped.
+func parseDpkgSearch(output, path string) []string {
+	seen := make(map[string]bool)
+	var owners []string
+
+	for _, line := range strings.Split(output, "\n") {
+		pkgs, file, found := strings.Cut(line, ": ")
+		if !found || strings.HasPrefix(line, "diversion by") || strings.TrimSpace(file) != path {
+			continue
+		}
+		for _, pkg := range strings.Split(pkgs, ", ") {
+			name, _, _ := strings.Cut(strings.TrimSpace(pkg), ":")
+			if name != "" && !seen[name] {
+				seen[name] = true
+				owners = append(owners, name)
+			}
+		}
+	}
+	return owners
+}
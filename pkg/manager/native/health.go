@@ -0,0 +1,144 @@
+package native
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+const (
+	// aptSourcesList and aptSourcesDir hold APT's repositories
+	aptSourcesList = "/etc/apt/sources.list"
+	aptSourcesDir  = "/etc/apt/sources.list.d"
+
+	// pacmanMirrorlist holds the mirrors of the official Arch repositories
+	pacmanMirrorlist = "/etc/pacman.d/mirrorlist"
+
+	// pacmanProbedMirrors is how many mirrors are probed; pacman falls back
+	// to the next mirror only when one fails, so the first few matter
+	pacmanProbedMirrors = 3
+)
+
+// Probe checks that the servers of the configured APT repositories answer.
+func (a *APT) Probe(ctx context.Context) manager.Health {
+	var uris []string
+	if data, err := os.ReadFile(aptSourcesList); err == nil {
+		uris = append(uris, parseAptSourcesList(string(data))...)
+	}
+	files, _ := filepath.Glob(filepath.Join(aptSourcesDir, "*")) //nolint:errcheck
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		switch filepath.Ext(file) {
+		case ".list":
+			uris = append(uris, parseAptSourcesList(string(data))...)
+		case ".sources":
+			uris = append(uris, parseAptDeb822(string(data))...)
+		}
+	}
+
+	health := manager.ProbeMirrors(ctx, uris, "repository server")
+	if len(uris) == 0 {
+		health.Fix = "Add repositories to " + aptSourcesList + " or " + aptSourcesDir
+	}
+	return health
+}
+
+// parseAptSourcesList returns the URIs of the enabled one-line style
+// entries (deb [options] uri suite components).
+func parseAptSourcesList(content string) []string {
+	var uris []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || (fields[0] != "deb" && fields[0] != "deb-src") {
+			continue
+		}
+		fields = fields[1:]
+		if strings.HasPrefix(fields[0], "[") {
+			// Skip the options, which may contain spaces
+			for len(fields) > 0 && !strings.HasSuffix(fields[0], "]") {
+				fields = fields[1:]
+			}
+			if len(fields) > 0 {
+				fields = fields[1:]
+			}
+		}
+		if len(fields) > 0 {
+			uris = append(uris, fields[0])
+		}
+	}
+	return uris
+}
+
+// parseAptDeb822 returns the URIs of the enabled deb822 style stanzas.
+func parseAptDeb822(content string) []string {
+	var uris, stanza []string
+	enabled := true
+	flush := func() {
+		if enabled {
+			uris = append(uris, stanza...)
+		}
+		stanza, enabled = nil, true
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "uris":
+			stanza = append(stanza, strings.Fields(value)...)
+		case "enabled":
+			enabled = strings.TrimSpace(value) != "no"
+		}
+	}
+	flush()
+	return uris
+}
+
+// Probe checks that the first mirrors in the mirrorlist answer.
+func (p *Pacman) Probe(ctx context.Context) manager.Health {
+	data, err := os.ReadFile(pacmanMirrorlist)
+	if err != nil {
+		return manager.Health{Status: manager.HealthDown, Summary: "cannot read " + pacmanMirrorlist, Fix: "sudo pacman -S pacman-mirrorlist"}
+	}
+
+	mirrors := parseMirrorlist(string(data))
+	if len(mirrors) > pacmanProbedMirrors {
+		mirrors = mirrors[:pacmanProbedMirrors]
+	}
+
+	health := manager.ProbeMirrors(ctx, mirrors, "mirror")
+	if health.Status != manager.HealthOK && health.Fix == "" {
+		health.Fix = "Uncomment or rank mirrors in " + pacmanMirrorlist
+	}
+	return health
+}
+
+// parseMirrorlist returns the uncommented Server entries of a pacman
+// mirrorlist.
+func parseMirrorlist(content string) []string {
+	var servers []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(key) == "Server" {
+			servers = append(servers, strings.TrimSpace(value))
+		}
+	}
+	return servers
+}
@@ -0,0 +1,65 @@
+package native
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAptSourcesList(t *testing.T) {
+	content := `# Main repositories
+deb http://deb.debian.org/debian bookworm main
+deb-src http://deb.debian.org/debian bookworm main
+deb [arch=amd64 signed-by=/usr/share/keyrings/nodesource.gpg] https://deb.nodesource.com/node_20.x nodistro main
+# deb http://disabled.example.com/debian bookworm main
+deb cdrom:[Debian GNU/Linux]/ bookworm main
+`
+	want := []string{
+		"http://deb.debian.org/debian",
+		"http://deb.debian.org/debian",
+		"https://deb.nodesource.com/node_20.x",
+		"cdrom:[Debian",
+	}
+	if got := parseAptSourcesList(content); !slices.Equal(got, want) {
+		t.Errorf("parseAptSourcesList() = %v, want %v", got, want)
+	}
+}
+
+func TestParseAptDeb822(t *testing.T) {
+	content := `Types: deb
+URIs: http://deb.debian.org/debian
+Suites: bookworm bookworm-updates
+Components: main
+
+Types: deb
+URIs: http://disabled.example.com/debian
+Suites: bookworm
+Enabled: no
+
+Types: deb
+URIs: http://deb.debian.org/debian-security https://security.example.com/debian
+Suites: bookworm-security
+`
+	want := []string{
+		"http://deb.debian.org/debian",
+		"http://deb.debian.org/debian-security",
+		"https://security.example.com/debian",
+	}
+	if got := parseAptDeb822(content); !slices.Equal(got, want) {
+		t.Errorf("parseAptDeb822() = %v, want %v", got, want)
+	}
+}
+
+func TestParseMirrorlist(t *testing.T) {
+	content := `## Worldwide
+#Server = https://disabled.example.com/$repo/os/$arch
+Server = https://geo.mirror.pkgbuild.com/$repo/os/$arch
+Server=https://mirror.example.org/archlinux/$repo/os/$arch
+`
+	want := []string{
+		"https://geo.mirror.pkgbuild.com/$repo/os/$arch",
+		"https://mirror.example.org/archlinux/$repo/os/$arch",
+	}
+	if got := parseMirrorlist(content); !slices.Equal(got, want) {
+		t.Errorf("parseMirrorlist() = %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,12 @@
 package native
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"poxy/pkg/manager"
@@ -11,6 +17,7 @@ func TestManagerInterface(t *testing.T) {
 	managers := []manager.Manager{
 		NewAPT(false),
 		NewDNF(),
+		NewRPMOSTree(),
 		NewPacman(),
 		NewZypper(),
 		NewXBPS(),
@@ -20,6 +27,7 @@ func TestManagerInterface(t *testing.T) {
 		NewNix(),
 		NewSlackpkg(),
 		NewSwupd(),
+		NewOPKG(),
 		NewBrew(),
 		NewWinget(),
 		NewChocolatey(),
@@ -82,6 +90,86 @@ func TestDNFManager(t *testing.T) {
 	}
 }
 
+func TestRPMOSTreeManager(t *testing.T) {
+	ostree := NewRPMOSTree()
+
+	if ostree.Name() != "rpm-ostree" {
+		t.Errorf("expected name 'rpm-ostree', got '%s'", ostree.Name())
+	}
+
+	var applier manager.DeferredApplier = ostree
+	if !applier.AppliesOnReboot() {
+		t.Error("rpm-ostree changes should apply on reboot")
+	}
+}
+
+func TestParseOSTreeStatus(t *testing.T) {
+	output := `{"deployments": [
+		{"checksum": "1a2b3c", "version": "40.20240502.0", "origin": "fedora:fedora/40/x86_64/silverblue", "timestamp": 1714610455,
+		 "staged": true, "booted": false, "requested-packages": ["htop", "vim"], "requested-base-removals": ["firefox"]},
+		{"checksum": "9f8e7d", "version": "40.20240425.0", "container-image-reference": "ostree-image-signed:docker://quay.io/fedora/fedora-silverblue:40",
+		 "staged": false, "booted": true, "pinned": true, "requested-packages": ["htop"], "requested-base-removals": []}
+	]}`
+
+	deployments, err := parseOSTreeStatus(output)
+	if err != nil {
+		t.Fatalf("parseOSTreeStatus() error: %v", err)
+	}
+	if len(deployments) != 2 {
+		t.Fatalf("parseOSTreeStatus() returned %d deployments, want 2", len(deployments))
+	}
+	staged := deployments[0]
+	if !staged.Staged || len(staged.RequestedPackages) != 2 || staged.RequestedBaseRemovals[0] != "firefox" {
+		t.Errorf("first deployment = %+v, want the staged one", staged)
+	}
+
+	pending := staged.deployment()
+	if pending.Checksum != "1a2b3c" || pending.Version != "40.20240502.0" || pending.Time.Unix() != 1714610455 {
+		t.Errorf("deployment() = %+v", pending)
+	}
+	booted := deployments[1].deployment()
+	if !booted.Booted || !booted.Pinned || booted.Origin != "ostree-image-signed:docker://quay.io/fedora/fedora-silverblue:40" {
+		t.Errorf("deployment() of a container image = %+v", booted)
+	}
+
+	if _, err := parseOSTreeStatus(`{"deployments": []}`); err == nil {
+		t.Error("expected an error without deployments")
+	}
+}
+
+func TestParseOSTreePreview(t *testing.T) {
+	output := `AvailableUpdate:
+        Version: 40.20240502.0 (2024-05-02T00:40:55Z)
+         Commit: 1a2b3c
+   GPGSignature: Valid signature by 115DF9AEF857853EE8445D0A0727707EA15B79CC
+  SecAdvisories: FEDORA-2024-1234  Moderate  kernel-6.8.8-300.fc40.x86_64
+       Upgraded: firefox 125.0.2-1.fc40 -> 125.0.3-1.fc40
+                 kernel 6.8.7-300.fc40 -> 6.8.8-300.fc40
+        Removed: old-lib-1.0-1.fc40.x86_64
+          Added: new-lib-2.0-1.fc40.x86_64
+`
+
+	candidates := parseOSTreePreview(output)
+	if len(candidates) != 2 {
+		t.Fatalf("parseOSTreePreview() returned %d candidates, want 2: %+v", len(candidates), candidates)
+	}
+	if candidates[0].Name != "firefox" || candidates[0].CurrentVersion != "125.0.2-1.fc40" || candidates[0].NewVersion != "125.0.3-1.fc40" {
+		t.Errorf("first candidate = %+v", candidates[0])
+	}
+	if candidates[1].Name != "kernel" || candidates[1].Source != "rpm-ostree" {
+		t.Errorf("second candidate = %+v", candidates[1])
+	}
+}
+
+func TestRPMOSTreeSearchOutput(t *testing.T) {
+	output := "===== Name Matched =====\nhtop : Interactive process viewer\n===== Summary Matched =====\nhtop : Interactive process viewer\nbtop : Resource monitor\n"
+
+	packages := NewRPMOSTree().parseSearchOutput(output, 0)
+	if len(packages) != 2 || packages[0].Name != "htop" || packages[1].Description != "Resource monitor" {
+		t.Errorf("parseSearchOutput() = %+v", packages)
+	}
+}
+
 func TestPacmanManager(t *testing.T) {
 	pacman := NewPacman()
 
@@ -163,6 +251,14 @@ func TestSwupdManager(t *testing.T) {
 	}
 }
 
+func TestOPKGManager(t *testing.T) {
+	opkg := NewOPKG()
+
+	if opkg.Name() != "opkg" {
+		t.Errorf("expected name 'opkg', got '%s'", opkg.Name())
+	}
+}
+
 func TestBrewManager(t *testing.T) {
 	brew := NewBrew()
 
@@ -246,3 +342,324 @@ func TestBaseManager(t *testing.T) {
 		t.Error("Executor() should not return nil")
 	}
 }
+
+func TestReverseDependencyCheckers(t *testing.T) {
+	var _ manager.ReverseDependencyChecker = NewPacman()
+	var _ manager.ReverseDependencyChecker = NewAPT(false)
+	var _ manager.ReverseDependencyChecker = NewDNF()
+}
+
+func TestHolders(t *testing.T) {
+	var _ manager.Holder = NewAPT(false)
+	var _ manager.Holder = NewDNF()
+	var _ manager.Holder = NewBrew()
+	var _ manager.HoldAdvisor = NewPacman()
+	var _ manager.UpgradeExcluder = NewPacman()
+	var _ manager.UpgradeExcluder = NewDNF()
+}
+
+func TestRepoManagers(t *testing.T) {
+	var _ manager.RepoManager = NewPacman()
+	var _ manager.RepoManager = NewAPT(false)
+	var _ manager.RepoManager = NewDNF()
+}
+
+func TestDeploymentListers(t *testing.T) {
+	var _ manager.DeploymentLister = NewRPMOSTree()
+	var _ manager.UpgradeChecker = NewRPMOSTree()
+}
+
+func TestNoticeReporters(t *testing.T) {
+	var _ manager.NoticeReporter = NewPacman()
+}
+
+func TestFileOwnershipCheckers(t *testing.T) {
+	var _ manager.FileOwnershipChecker = NewPacman()
+	var _ manager.FileOwnershipChecker = NewAPT(false)
+	var _ manager.FileOwnershipChecker = NewDNF()
+	var _ manager.FileOwnershipChecker = NewZypper()
+}
+
+func TestRootSetters(t *testing.T) {
+	var _ manager.RootSetter = NewPacman()
+	var _ manager.RootSetter = NewAPT(false)
+	var _ manager.RootSetter = NewDNF()
+	var _ manager.RootSetter = NewAPK()
+	var _ manager.RootSetter = NewXBPS()
+	var _ manager.RootSetter = NewOPKG()
+}
+
+func TestParseOpkgList(t *testing.T) {
+	output := "busybox - 1.36.1-1 - Core utilities for embedded Linux\nkmod-nft-core - 6.6.30-1 - Netfilter nf_tables support\n\nluci - git-24.086.45142-09d5a38\n"
+
+	entries := parseOpkgList(output)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0][0] != "busybox" || entries[0][1] != "1.36.1-1" || entries[0][2] != "Core utilities for embedded Linux" {
+		t.Errorf("unexpected first entry: %q", entries[0])
+	}
+	if len(entries[2]) != 2 || entries[2][1] != "git-24.086.45142-09d5a38" {
+		t.Errorf("unexpected entry without description: %q", entries[2])
+	}
+}
+
+func TestParseDpkgSearch(t *testing.T) {
+	output := `diversion by dash from: /bin/sh
+libc6:amd64, libc6:i386: /usr/lib/locale
+locales: /usr/lib/locale/C.utf8
+`
+	got := parseDpkgSearch(output, "/usr/lib/locale")
+	if len(got) != 1 || got[0] != "libc6" {
+		t.Errorf("parseDpkgSearch() = %v, want [libc6]", got)
+	}
+
+	if got := parseDpkgSearch(output, "/bin/sh"); len(got) != 0 {
+		t.Errorf("parseDpkgSearch() of a diversion = %v, want none", got)
+	}
+}
+
+func TestUsrMergeAlias(t *testing.T) {
+	tests := map[string]string{
+		"/usr/bin/ls":       "/bin/ls",
+		"/bin/ls":           "/usr/bin/ls",
+		"/usr/lib64/ld.so":  "/lib64/ld.so",
+		"/usr/share/doc/ls": "/usr/share/doc/ls",
+		"/etc/passwd":       "/etc/passwd",
+	}
+	for in, want := range tests {
+		if got := usrMergeAlias(in); got != want {
+			t.Errorf("usrMergeAlias(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseFileList(t *testing.T) {
+	got := parseFileList("/.\n/usr\n/usr/bin/ls\n\n")
+	if len(got) != 2 || got[0] != "/usr" || got[1] != "/usr/bin/ls" {
+		t.Errorf("parseFileList() = %v", got)
+	}
+
+	if got := parseFileList("(contains no files)\n"); len(got) != 0 {
+		t.Errorf("parseFileList() of an empty rpm = %v, want none", got)
+	}
+}
+
+func TestOrphanListers(t *testing.T) {
+	var _ manager.OrphanLister = NewPacman()
+	var _ manager.OrphanLister = NewAPT(false)
+	var _ manager.OrphanLister = NewDNF()
+}
+
+func TestExplicitListers(t *testing.T) {
+	var _ manager.ExplicitLister = NewPacman()
+	var _ manager.ExplicitLister = NewAPT(false)
+	var _ manager.ExplicitLister = NewDNF()
+}
+
+func TestUnusedDependencyListers(t *testing.T) {
+	var _ manager.UnusedDependencyLister = NewPacman()
+	var _ manager.UnusedDependencyLister = NewAPT(false)
+}
+
+func TestWithoutNames(t *testing.T) {
+	got := withoutNames([]string{"firefox", "libfoo", "libbar"}, []string{"firefox", "libbar", "other"})
+	if len(got) != 1 || got[0] != "libfoo" {
+		t.Errorf("withoutNames() = %v, want [libfoo]", got)
+	}
+}
+
+func TestParseAptSimulatedRemovals(t *testing.T) {
+	output := `Reading package lists...
+The following packages will be REMOVED:
+  libfoo1 linux-image-6.1.0-17-amd64
+Remv libfoo1 [1.2-3]
+Remv linux-image-6.1.0-17-amd64 [6.1.69-1]
+`
+	got := parseAptSimulatedRemovals(output)
+	if len(got) != 2 || got[0] != "libfoo1" || got[1] != "linux-image-6.1.0-17-amd64" {
+		t.Errorf("parseAptSimulatedRemovals() = %v", got)
+	}
+}
+
+func TestParseDpkgAudit(t *testing.T) {
+	output := `The following packages are only half configured, probably due to problems
+configuring them the first time.  The configuration should be retried using
+dpkg --configure <package> or the configure menu option in dselect:
+ libfoo1              Foo runtime library
+ foo-utils            Utilities for foo
+`
+	got := parseDpkgAudit(output)
+	if len(got) != 2 || got[0] != "libfoo1" || got[1] != "foo-utils" {
+		t.Errorf("parseDpkgAudit() = %v", got)
+	}
+}
+
+func TestParseAptSimulatedChanges(t *testing.T) {
+	output := `NOTE: This is only a simulation!
+Correcting dependencies... Done
+Inst libbar2 (2.0-1 Debian:12/stable [amd64])
+Remv libbar1 [1.9-4]
+Conf libbar2 (2.0-1 Debian:12/stable [amd64])
+`
+	got := parseAptSimulatedChanges(output)
+	if len(got) != 3 || got[0] != "install libbar2" || got[1] != "remove libbar1" || got[2] != "configure libbar2" {
+		t.Errorf("parseAptSimulatedChanges() = %v", got)
+	}
+}
+
+func TestParsePactreeOutput(t *testing.T) {
+	output := "glibc\nbash\ncoreutils\n"
+	got := parsePactreeOutput(output, "glibc")
+	if len(got) != 2 || got[0] != "bash" || got[1] != "coreutils" {
+		t.Errorf("parsePactreeOutput() = %v", got)
+	}
+}
+
+func TestParseRequiredBy(t *testing.T) {
+	output := `Name            : libpng
+Version         : 1.6.43-1
+Required By     : cairo  freetype2  gdk-pixbuf2
+Optional For    : None`
+
+	got := parseRequiredBy(output)
+	if len(got) != 3 || got[0] != "cairo" || got[2] != "gdk-pixbuf2" {
+		t.Errorf("parseRequiredBy() = %v", got)
+	}
+
+	if got := parseRequiredBy("Required By     : None"); got != nil {
+		t.Errorf("parseRequiredBy(None) = %v, want nil", got)
+	}
+}
+
+func TestParseRdependsOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		pkg    string
+		want   []string
+	}{
+		{
+			name: "alternatives and duplicates",
+			output: `libssl3
+Reverse Depends:
+  openssh-client
+ |curl
+  openssh-client
+  libssl3`,
+			pkg:  "libssl3",
+			want: []string{"openssh-client", "curl"},
+		},
+		{
+			name: "multiarch qualifiers",
+			output: `libc6
+Reverse Depends:
+  libstdc++6:i386
+  libstdc++6
+ |libgcc-s1:amd64
+  libc6:i386`,
+			pkg:  "libc6",
+			want: []string{"libstdc++6", "libgcc-s1"},
+		},
+		{
+			name:   "no dependents",
+			output: "libfoo\nReverse Depends:\n",
+			pkg:    "libfoo",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRdependsOutput(tt.output, tt.pkg); !slices.Equal(got, tt.want) {
+				t.Errorf("parseRdependsOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubCommand puts a script named name on PATH that records its arguments
+// and prints output, and returns the file the arguments are written to.
+func stubCommand(t *testing.T, name, output string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	args := filepath.Join(dir, name+".args")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\ncat <<'EOF'\n%s\nEOF\n", args, output)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return args
+}
+
+func TestAPTReverseDependenciesOnlyHardDependencies(t *testing.T) {
+	args := stubCommand(t, "apt-cache", "libssl3\nReverse Depends:\n  curl:amd64")
+
+	got, err := NewAPT(false).ReverseDependencies(context.Background(), "libssl3")
+	if err != nil {
+		t.Fatalf("ReverseDependencies() error = %v", err)
+	}
+	if !slices.Equal(got, []string{"curl"}) {
+		t.Errorf("ReverseDependencies() = %v, want [curl]", got)
+	}
+
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, flag := range []string{"--installed", "--no-recommends", "--no-suggests", "--no-enhances"} {
+		if !strings.Contains(string(data), flag) {
+			t.Errorf("apt-cache was run with %q, missing %s", strings.TrimSpace(string(data)), flag)
+		}
+	}
+}
+
+func TestParseRepoqueryNames(t *testing.T) {
+	got := parseRepoqueryNames("glibc-devel\nsystemd\nsystemd\nglibc\n", "glibc")
+	if len(got) != 2 || got[0] != "glibc-devel" || got[1] != "systemd" {
+		t.Errorf("parseRepoqueryNames() = %v", got)
+	}
+}
+
+func TestPacmanSearchRepository(t *testing.T) {
+	output := `extra/vim 9.1.0785-1 [installed]
+    Vi Improved, a highly configurable, improved version of the vi text editor
+core/vi 1:070224-6
+    The original ex/vi text editor
+`
+	pkgs := NewPacman().parseSearchOutput(output, 0)
+	if len(pkgs) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(pkgs))
+	}
+	if pkgs[0].Badge() != "pacman/extra" || !pkgs[0].Installed {
+		t.Errorf("vim = %+v, want installed from extra", pkgs[0])
+	}
+	if pkgs[1].Metadata[manager.MetaRepository] != "core" {
+		t.Errorf("vi metadata = %v", pkgs[1].Metadata)
+	}
+}
+
+func TestBinDirProviders(t *testing.T) {
+	var _ manager.BinDirProvider = NewBrew()
+	var _ manager.BinDirProvider = NewNix()
+}
+
+func TestCommandPaths(t *testing.T) {
+	files := []string{
+		"/usr/",
+		"/usr/bin/",
+		"/usr/bin/rg",
+		"/usr/share/man/man1/rg.1.gz",
+		"/usr/sbin/rgd",
+		"/usr/bin/rg",
+		"/usr/lib/rg/helper",
+	}
+	if got := commandPaths(files); !slices.Equal(got, []string{"/usr/bin/rg", "/usr/sbin/rgd"}) {
+		t.Errorf("commandPaths() = %v", got)
+	}
+
+	var _ manager.CommandPreviewer = NewPacman()
+	var _ manager.CommandPreviewer = NewAPT(false)
+	var _ manager.CommandPreviewer = NewDNF()
+}
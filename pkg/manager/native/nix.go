@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"poxy/pkg/manager"
@@ -21,10 +23,27 @@ func NewNix() *Nix {
 	}
 }
 
+// InstallScope returns manager.ScopeUser: nix-env installs into the user's
+// profile.
+func (n *Nix) InstallScope() manager.Scope {
+	return manager.ScopeUser
+}
+
+// BinDir returns the bin directory of the user's profile, which nix-env
+// installs into.
+func (n *Nix) BinDir(ctx context.Context) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".nix-profile", "bin")
+}
+
 // Install installs one or more packages.
 func (n *Nix) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	// Convert package names to nix attribute paths
 	args := []string{"-iA"}
+	args = append(args, opts.ExtraArgs...)
 
 	for _, pkg := range packages {
 		// If not already prefixed, add nixpkgs prefix
@@ -64,6 +83,8 @@ func (n *Nix) Update(ctx context.Context) error {
 func (n *Nix) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"-u"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
@@ -78,6 +99,10 @@ func (n *Nix) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 
 // Search finds packages matching the query.
 func (n *Nix) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, n, query, opts)
+	}
+
 	// Try new nix search first
 	output, err := n.Executor().Output(ctx, "nix", "search", "nixpkgs", query, "--json")
 	if err == nil && output != "" {
@@ -0,0 +1,293 @@
+package native
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// OPKG implements the Manager interface for opkg, the package manager of
+// OpenWrt and other embedded distributions.
+type OPKG struct {
+	*BaseManager
+}
+
+// NewOPKG creates a new OPKG manager instance.
+func NewOPKG() *OPKG {
+	return &OPKG{
+		BaseManager: NewBaseManager("opkg", "opkg (OpenWrt)", "opkg", true),
+	}
+}
+
+// Install installs one or more packages.
+func (o *OPKG) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	// opkg never prompts, so there is no -y flag
+	args := []string{"install"}
+
+	if opts.Reinstall {
+		args = append(args, "--force-reinstall")
+	}
+
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, packages...)
+
+	if opts.DryRun {
+		o.SetDryRun(true)
+		defer o.SetDryRun(false)
+	}
+
+	return o.Executor().RunSudo(ctx, o.Binary(), args...)
+}
+
+// Uninstall removes one or more packages.
+func (o *OPKG) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	args := []string{"remove"}
+
+	if opts.Recursive {
+		args = append(args, "--autoremove")
+	}
+
+	args = append(args, packages...)
+
+	if opts.DryRun {
+		o.SetDryRun(true)
+		defer o.SetDryRun(false)
+	}
+
+	return o.Executor().RunSudo(ctx, o.Binary(), args...)
+}
+
+// Update refreshes the package lists.
+func (o *OPKG) Update(ctx context.Context) error {
+	return o.Executor().RunSudo(ctx, o.Binary(), "update")
+}
+
+// Upgrade upgrades installed packages. opkg has no full upgrade, so without
+// a package list every upgradable package is named. OpenWrt recommends
+// sysupgrade over upgrading base packages in place.
+func (o *OPKG) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		candidates, err := o.ListUpgradable(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range candidates {
+			packages = append(packages, c.Name)
+		}
+		if len(packages) == 0 {
+			return nil
+		}
+	}
+
+	if opts.DryRun {
+		o.SetDryRun(true)
+		defer o.SetDryRun(false)
+	}
+
+	args := append([]string{"upgrade"}, opts.ExtraArgs...)
+	return o.Executor().RunSudo(ctx, o.Binary(), append(args, packages...)...)
+}
+
+// ListUpgradable returns installed packages with a newer version available.
+func (o *OPKG) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	output, err := o.Executor().Output(ctx, o.Binary(), "list-upgradable")
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []manager.UpgradeCandidate
+	for _, fields := range parseOpkgList(output) {
+		if len(fields) < 3 {
+			continue
+		}
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           fields[0],
+			Source:         "opkg",
+			CurrentVersion: fields[1],
+			NewVersion:     fields[2],
+		})
+	}
+	return candidates, nil
+}
+
+// Search finds packages matching the query.
+func (o *OPKG) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, o, query, opts)
+	}
+
+	// "find" also matches descriptions
+	command := "list"
+	if opts.SearchInDesc {
+		command = "find"
+	}
+
+	output, err := o.Executor().Output(ctx, o.Binary(), command, "*"+query+"*")
+	if err != nil {
+		return []manager.Package{}, nil
+	}
+
+	var packages []manager.Package
+	for _, fields := range parseOpkgList(output) {
+		pkg := manager.Package{Name: fields[0], Source: "opkg"}
+		if len(fields) > 1 {
+			pkg.Version = fields[1]
+		}
+		if len(fields) > 2 {
+			pkg.Description = fields[2]
+		}
+		packages = append(packages, pkg)
+
+		if opts.Limit > 0 && len(packages) >= opts.Limit {
+			break
+		}
+	}
+
+	return packages, nil
+}
+
+// parseOpkgList parses the "name - version - description" lines opkg list
+// commands print.
+func parseOpkgList(output string) [][]string {
+	var entries [][]string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " - ", 3)
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		entries = append(entries, fields)
+	}
+
+	return entries
+}
+
+// Info returns detailed information about a package.
+func (o *OPKG) Info(ctx context.Context, pkg string) (*manager.PackageInfo, error) {
+	output, err := o.Executor().Output(ctx, o.Binary(), "info", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+
+	info := o.parsePackageInfo(output)
+	// opkg prints nothing, successfully, for unknown packages
+	if info.Name == "" {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+	return info, nil
+}
+
+// parsePackageInfo parses the control fields opkg info prints. For a
+// package that is both installed and available, the first record is used.
+func (o *OPKG) parsePackageInfo(output string) *manager.PackageInfo {
+	info := &manager.PackageInfo{
+		Package: manager.Package{
+			Source: "opkg",
+		},
+	}
+
+	record, _, _ := strings.Cut(output, "\n\n")
+	scanner := bufio.NewScanner(strings.NewReader(record))
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Package":
+			info.Name = value
+		case "Version":
+			info.Version = value
+		case "Description":
+			info.Description = value
+		case "Maintainer":
+			info.Maintainer = value
+		case "License":
+			info.License = value
+		case "Installed-Size", "Size":
+			if info.Size == "" {
+				info.Size = value
+			}
+		case "Status":
+			info.Installed = strings.Contains(value, " installed")
+		case "Depends":
+			for _, dep := range strings.Split(value, ",") {
+				if dep = strings.TrimSpace(dep); dep != "" {
+					info.Dependencies = append(info.Dependencies, dep)
+				}
+			}
+		case "Provides":
+			info.Provides = strings.Fields(strings.ReplaceAll(value, ",", " "))
+		case "Conflicts":
+			info.Conflicts = strings.Fields(strings.ReplaceAll(value, ",", " "))
+		}
+	}
+
+	return info
+}
+
+// ListInstalled returns all installed packages.
+func (o *OPKG) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
+	output, err := o.Executor().Output(ctx, o.Binary(), "list-installed")
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []manager.Package
+	patternLower := strings.ToLower(opts.Pattern)
+
+	for _, fields := range parseOpkgList(output) {
+		name := fields[0]
+		if opts.Pattern != "" && !strings.Contains(strings.ToLower(name), patternLower) {
+			continue
+		}
+
+		pkg := manager.Package{Name: name, Source: "opkg", Installed: true}
+		if len(fields) > 1 {
+			pkg.Version = fields[1]
+		}
+		packages = append(packages, pkg)
+
+		if opts.Limit > 0 && len(packages) >= opts.Limit {
+			break
+		}
+	}
+
+	return packages, nil
+}
+
+// IsInstalled checks if a package is installed.
+func (o *OPKG) IsInstalled(ctx context.Context, pkg string) (bool, error) {
+	output, err := o.Executor().OutputQuiet(ctx, o.Binary(), "list-installed", pkg)
+	if err != nil {
+		return false, nil
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+// Clean removes cached package files.
+func (o *OPKG) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	// opkg removes downloaded packages after installing them and keeps its
+	// lists in RAM on OpenWrt, so there is no cache to clean
+	return nil
+}
+
+// Autoremove removes orphaned packages.
+func (o *OPKG) Autoremove(ctx context.Context) error {
+	// opkg only removes orphans while removing the package that pulled
+	// them in (opkg remove --autoremove)
+	return nil
+}
@@ -0,0 +1,192 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ListOrphans returns dependencies no installed package requires (pacman -Qdt).
+func (p *Pacman) ListOrphans(ctx context.Context) ([]string, error) {
+	// pacman exits non-zero when there are no orphans
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qdtq")
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Fields(output), nil
+}
+
+// ListForeign returns installed packages that are not in any sync
+// repository (pacman -Qm), such as AUR packages.
+func (p *Pacman) ListForeign(ctx context.Context) ([]string, error) {
+	// pacman exits non-zero when there are no foreign packages
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qmq")
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Fields(output), nil
+}
+
+// ListOrphans returns the packages apt autoremove would remove.
+func (a *APT) ListOrphans(ctx context.Context) ([]string, error) {
+	output, err := a.Executor().OutputQuiet(ctx, "apt-get", "-s", "autoremove")
+	if err != nil {
+		return nil, fmt.Errorf("apt-get autoremove simulation failed: %w", err)
+	}
+	return parseAptSimulatedRemovals(output), nil
+}
+
+// UnusedDependencies returns the dependencies pacman -Rs would remove with
+// packages, found with a printed (-p) run that changes nothing.
+func (p *Pacman) UnusedDependencies(ctx context.Context, packages []string) ([]string, error) {
+	args := append([]string{"-Rsp", "--print-format", "%n"}, packages...)
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("pacman -Rs simulation failed: %w", err)
+	}
+	return withoutNames(strings.Fields(output), packages), nil
+}
+
+// UnusedDependencies returns the packages apt autoremove would remove
+// together with packages and not without them.
+func (a *APT) UnusedDependencies(ctx context.Context, packages []string) ([]string, error) {
+	args := append([]string{"-s", "autoremove"}, packages...)
+	output, err := a.Executor().OutputQuiet(ctx, "apt-get", args...)
+	if err != nil {
+		return nil, fmt.Errorf("apt-get autoremove simulation failed: %w", err)
+	}
+	orphans, err := a.ListOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return withoutNames(parseAptSimulatedRemovals(output), append(orphans, packages...)), nil
+}
+
+// withoutNames returns names except those in exclude.
+func withoutNames(names, exclude []string) []string {
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+	var kept []string
+	for _, name := range names {
+		if !skip[name] {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// ListOrphans returns dependencies no installed package requires.
+func (d *DNF) ListOrphans(ctx context.Context) ([]string, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "repoquery", "--unneeded", "--quiet",
+		"--queryformat", "%{name}\n")
+	if err != nil {
+		return nil, fmt.Errorf("dnf repoquery failed: %w", err)
+	}
+	return parseRepoqueryNames(output, ""), nil
+}
+
+// ListExtras returns installed packages that are not available from any
+// enabled repository, such as leftovers of removed repositories.
+func (d *DNF) ListExtras(ctx context.Context) ([]string, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "repoquery", "--extras", "--quiet",
+		"--queryformat", "%{name}\n")
+	if err != nil {
+		return nil, fmt.Errorf("dnf repoquery failed: %w", err)
+	}
+	return parseRepoqueryNames(output, ""), nil
+}
+
+// parseAptSimulatedRemovals parses the "Remv name [version]" lines of a
+// simulated apt-get run.
+func parseAptSimulatedRemovals(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "Remv" {
+			names = append(names, fields[1])
+		}
+	}
+	return names
+}
+
+// BrokenPackages returns packages dpkg reports as not fully installed and
+// the changes apt would make to fix broken dependencies (apt-get -f
+// install), which is what ends in "you have held broken packages".
+func (a *APT) BrokenPackages(ctx context.Context) (audit, fixes []string, err error) {
+	output, err := a.Executor().OutputQuiet(ctx, "dpkg", "--audit")
+	if err != nil {
+		return nil, nil, fmt.Errorf("dpkg --audit failed: %w", err)
+	}
+	audit = parseDpkgAudit(output)
+
+	// The simulation fails when apt cannot resolve the breakage at all
+	output, err = a.Executor().OutputQuiet(ctx, "apt-get", "-s", "-f", "install")
+	if err != nil {
+		return audit, []string{"apt-get cannot resolve the broken dependencies"}, nil
+	}
+	return audit, parseAptSimulatedChanges(output), nil
+}
+
+// parseDpkgAudit returns the package names listed by dpkg --audit, which
+// indents each package under a description of its problem.
+func parseDpkgAudit(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, " ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
+
+// parseAptSimulatedChanges returns the "Inst", "Remv" and "Conf" actions
+// of a simulated apt-get run as "install name", "remove name" and so on.
+func parseAptSimulatedChanges(output string) []string {
+	actions := map[string]string{"Inst": "install", "Remv": "remove", "Conf": "configure"}
+
+	var changes []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if action, ok := actions[fields[0]]; ok {
+			changes = append(changes, action+" "+fields[1])
+		}
+	}
+	return changes
+}
+
+// ListExplicit returns the explicitly installed packages (pacman -Qe).
+func (p *Pacman) ListExplicit(ctx context.Context) ([]string, error) {
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qqe")
+	if err != nil {
+		return nil, fmt.Errorf("pacman -Qe failed: %w", err)
+	}
+	return strings.Fields(output), nil
+}
+
+// ListExplicit returns the packages marked as manually installed.
+func (a *APT) ListExplicit(ctx context.Context) ([]string, error) {
+	output, err := a.Executor().OutputQuiet(ctx, "apt-mark", "showmanual")
+	if err != nil {
+		return nil, fmt.Errorf("apt-mark showmanual failed: %w", err)
+	}
+	return strings.Fields(output), nil
+}
+
+// ListExplicit returns the packages installed at the user's request.
+func (d *DNF) ListExplicit(ctx context.Context) ([]string, error) {
+	output, err := d.Executor().OutputQuiet(ctx, d.Binary(), "repoquery", "--userinstalled", "--quiet",
+		"--queryformat", "%{name}\n")
+	if err != nil {
+		return nil, fmt.Errorf("dnf repoquery failed: %w", err)
+	}
+	return parseRepoqueryNames(output, ""), nil
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"os/exec"
 	"strings"
+	"sync"
 
 	"poxy/pkg/manager"
 )
@@ -12,6 +14,11 @@ import (
 // Pacman implements the Manager interface for Arch Linux's pacman package manager.
 type Pacman struct {
 	*BaseManager
+
+	root string // System operated on, see SetRoot
+
+	noticesMu sync.Mutex
+	notices   []manager.Notice // Picked out of the log by upgrades
 }
 
 // NewPacman creates a new Pacman manager instance.
@@ -33,6 +40,7 @@ func (p *Pacman) Install(ctx context.Context, packages []string, opts manager.In
 		args = append(args, "--needed")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -89,6 +97,7 @@ func (p *Pacman) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	if opts.AutoConfirm {
 		args = append(args, "--noconfirm")
 	}
+	args = append(args, opts.ExtraArgs...)
 
 	if len(opts.Packages) > 0 {
 		// Upgrade specific packages only
@@ -96,7 +105,10 @@ func (p *Pacman) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		if opts.AutoConfirm {
 			args = append(args, "--noconfirm")
 		}
+		args = append(args, opts.ExtraArgs...)
 		args = append(args, opts.Packages...)
+	} else if len(opts.Exclude) > 0 {
+		args = append(args, "--ignore", strings.Join(opts.Exclude, ","))
 	}
 
 	if opts.DryRun {
@@ -104,7 +116,29 @@ func (p *Pacman) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		defer p.SetDryRun(false)
 	}
 
-	return p.Executor().RunSudo(ctx, p.Binary(), args...)
+	offset := p.logSize()
+	stderr, err := p.Executor().RunSudoWithStderr(ctx, p.Binary(), args...)
+	if !opts.DryRun {
+		p.addNotices(offset)
+	}
+	if err != nil {
+		if pacErr := ParsePacmanError(stderr, err); pacErr != nil {
+			return pacErr
+		}
+		return err
+	}
+	return nil
+}
+
+// ExcludesUpgrades reports that full upgrades honor UpgradeOpts.Exclude.
+func (p *Pacman) ExcludesUpgrades() bool {
+	return true
+}
+
+// HoldAdvice explains how to hold the packages with IgnorePkg, which poxy
+// does not edit itself.
+func (p *Pacman) HoldAdvice(pkgs []string) string {
+	return fmt.Sprintf("To hold these packages for pacman itself, add them to /etc/pacman.conf:\n  IgnorePkg = %s", strings.Join(pkgs, " "))
 }
 
 // Search finds packages matching the query.
@@ -119,7 +153,9 @@ func (p *Pacman) Search(ctx context.Context, query string, opts manager.SearchOp
 		return []manager.Package{}, nil
 	}
 
-	return p.parseSearchOutput(output, opts.Limit), nil
+	packages := p.parseSearchOutput(output, opts.Limit)
+	manager.CheckParsed("pacman", "pacman -Ss", output, len(packages))
+	return packages, nil
 }
 
 // searchInstalled searches installed packages.
@@ -178,6 +214,7 @@ func (p *Pacman) parseSearchOutput(output string, limit int) []manager.Package {
 				Description: description,
 				Source:      "pacman",
 				Installed:   installed,
+				Metadata:    map[string]string{manager.MetaRepository: repoPkg[0]},
 			})
 
 			if limit > 0 && len(packages) >= limit {
@@ -290,6 +327,12 @@ func (p *Pacman) parsePackageInfo(output string) *manager.PackageInfo {
 			if value != "None" {
 				info.Dependencies = strings.Fields(value)
 			}
+		case "Provides":
+			info.Provides = relationList(value)
+		case "Conflicts With":
+			info.Conflicts = relationList(value)
+		case "Replaces":
+			info.Replaces = relationList(value)
 		}
 	}
 
@@ -333,6 +376,9 @@ func (p *Pacman) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]ma
 		}
 	}
 
+	if opts.Pattern == "" {
+		manager.CheckParsed("pacman", "pacman -Q", output, len(packages))
+	}
 	return packages, nil
 }
 
@@ -371,3 +417,52 @@ func (p *Pacman) Autoremove(ctx context.Context) error {
 
 	return p.Executor().RunSudo(ctx, p.Binary(), args...)
 }
+
+// ReverseDependencies returns the installed packages that require pkg.
+// pactree (pacman-contrib) is used when available to include indirect
+// dependents; otherwise the direct "Required By" list from pacman is used.
+func (p *Pacman) ReverseDependencies(ctx context.Context, pkg string) ([]string, error) {
+	if _, err := exec.LookPath("pactree"); err == nil {
+		output, err := p.Executor().OutputQuiet(ctx, "pactree", "-r", "-u", "-l", pkg)
+		if err != nil {
+			return nil, fmt.Errorf("pactree failed for %s: %w", pkg, err)
+		}
+		return parsePactreeOutput(output, pkg), nil
+	}
+
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qi", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package not installed: %s", pkg)
+	}
+	return parseRequiredBy(output), nil
+}
+
+// parsePactreeOutput parses `pactree -r -u -l` output, which lists the
+// package itself followed by every package depending on it.
+func parsePactreeOutput(output, pkg string) []string {
+	var dependents []string
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name != "" && name != pkg {
+			dependents = append(dependents, name)
+		}
+	}
+	return dependents
+}
+
+// parseRequiredBy returns the "Required By" field of pacman -Qi output.
+func parseRequiredBy(output string) []string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != "Required By" {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if value == "None" {
+			return nil
+		}
+		return strings.Fields(value)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package native
+
+import (
+	"context"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ListCatalog returns every package in the sync repositories with its
+// relations (pacman -Si), marking the installed ones.
+func (p *Pacman) ListCatalog(ctx context.Context) ([]manager.Package, error) {
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Si")
+	if err != nil {
+		return nil, err
+	}
+	packages := p.parsePackageRecords(output)
+
+	installed := make(map[string]bool)
+	if output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Qq"); err == nil {
+		for _, name := range strings.Fields(output) {
+			installed[name] = true
+		}
+	}
+	for i := range packages {
+		packages[i].Installed = installed[packages[i].Name]
+	}
+
+	return packages, nil
+}
+
+// FindProviders returns the repository packages that provide name.
+func (p *Pacman) FindProviders(ctx context.Context, name string) ([]manager.Package, error) {
+	catalog, err := p.ListCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []manager.Package
+	for _, pkg := range catalog {
+		if pkg.Relation(name) == "provides" {
+			providers = append(providers, pkg)
+		}
+	}
+	return providers, nil
+}
+
+// parsePackageRecords parses pacman -Si/-Qi output listing several
+// packages, one blank-line separated record each.
+func (p *Pacman) parsePackageRecords(output string) []manager.Package {
+	var packages []manager.Package
+	for _, record := range strings.Split(output, "\n\n") {
+		info := p.parsePackageInfo(record)
+		if info.Name != "" {
+			packages = append(packages, info.Package)
+		}
+	}
+	return packages
+}
+
+// relationList parses a pacman relation field, which is "None" when empty.
+func relationList(value string) []string {
+	if value == "None" {
+		return nil
+	}
+	return strings.Fields(value)
+}
@@ -0,0 +1,50 @@
+package native
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePackageRecords(t *testing.T) {
+	output := `Repository      : extra
+Name            : jre17-openjdk
+Version         : 17.0.12.u7-1
+Description     : OpenJDK Java 17 full runtime environment
+Provides        : java-runtime=17  jre17-openjdk-headless=17.0.12.u7-1
+Conflicts With  : jre17-openjdk-headless
+Replaces        : None
+Optional Deps   : alsa-lib: for basic sound support
+                  gtk3: for the Gtk+ 3 look and feel
+
+Repository      : core
+Name            : bash
+Version         : 5.2.032-1
+Description     : The GNU Bourne Again shell
+Provides        : sh
+Conflicts With  : None
+Replaces        : None
+`
+	p := NewPacman()
+	packages := p.parsePackageRecords(output)
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packages), packages)
+	}
+
+	jre := packages[0]
+	if want := []string{"java-runtime=17", "jre17-openjdk-headless=17.0.12.u7-1"}; !slices.Equal(jre.Provides, want) {
+		t.Errorf("provides = %v, want %v", jre.Provides, want)
+	}
+	if !slices.Equal(jre.Conflicts, []string{"jre17-openjdk-headless"}) {
+		t.Errorf("conflicts = %v", jre.Conflicts)
+	}
+	if jre.Replaces != nil {
+		t.Errorf("replaces = %v, want none", jre.Replaces)
+	}
+	if jre.Relation("java-runtime") != "provides" {
+		t.Error("jre17-openjdk should provide java-runtime")
+	}
+
+	if packages[1].Name != "bash" || packages[1].Relation("sh") != "provides" {
+		t.Errorf("unexpected package: %+v", packages[1])
+	}
+}
@@ -0,0 +1,197 @@
+package native
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// pacmanGnupgDir is the GnuPG home used by pacman-key
+	pacmanGnupgDir = "/etc/pacman.d/gnupg"
+
+	// pacmanKeyringsDir holds the keys pacman-key --populate reads, shipped
+	// by the distribution's and third-party repositories' keyring packages
+	pacmanKeyringsDir = "/usr/share/pacman/keyrings"
+
+	// trustRecordLen is the fixed size of a GnuPG trust database record
+	trustRecordLen = 40
+)
+
+// KeyringPackage is an installed distribution keyring package.
+type KeyringPackage struct {
+	Name      string
+	Installed string
+	Available string // Version in the sync database, empty if unknown
+}
+
+// Outdated returns true if the sync database has a newer keyring.
+func (k KeyringPackage) Outdated() bool {
+	return k.Available != "" && k.Available != k.Installed
+}
+
+// KeyringStatus describes the health of the pacman keyring.
+type KeyringStatus struct {
+	GnupgDir    string
+	Initialized bool // The keyring has a public keyring
+	TrustDBOK   bool // The trust database exists and is well formed
+	Packages    []KeyringPackage
+}
+
+// Problems returns a description of each detected keyring problem.
+func (s *KeyringStatus) Problems() []string {
+	var problems []string
+
+	if !s.Initialized {
+		problems = append(problems, fmt.Sprintf("keyring in %s is not initialized", s.GnupgDir))
+	}
+	if s.Initialized && !s.TrustDBOK {
+		problems = append(problems, "trust database is missing or corrupted")
+	}
+	for _, pkg := range s.Packages {
+		if pkg.Outdated() {
+			problems = append(problems, fmt.Sprintf("%s is outdated (%s installed, %s available)",
+				pkg.Name, pkg.Installed, pkg.Available))
+		}
+	}
+
+	return problems
+}
+
+// Healthy returns true if no keyring problems were detected.
+func (s *KeyringStatus) Healthy() bool {
+	return len(s.Problems()) == 0
+}
+
+// KeyringStatus checks the pacman keyring and the installed keyring packages.
+func (p *Pacman) KeyringStatus(ctx context.Context) (*KeyringStatus, error) {
+	status := &KeyringStatus{GnupgDir: pacmanGnupgDir}
+	status.Initialized, status.TrustDBOK = checkKeyringFiles(pacmanGnupgDir)
+
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Q")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed packages: %w", err)
+	}
+
+	for _, pkg := range parseKeyringPackages(output, p.keyringOwners(ctx)) {
+		info, err := p.Executor().OutputQuiet(ctx, p.Binary(), "-Si", pkg.Name)
+		if err == nil {
+			pkg.Available = p.parsePackageInfo(info).Version
+		}
+		status.Packages = append(status.Packages, pkg)
+	}
+
+	return status, nil
+}
+
+// keyringOwners returns the packages owning the keyrings in
+// /usr/share/pacman/keyrings, which are the keyring packages; names ending
+// in -keyring such as gnome-keyring are not a sign of one.
+func (p *Pacman) keyringOwners(ctx context.Context) map[string]bool {
+	files, _ := filepath.Glob(filepath.Join(pacmanKeyringsDir, "*.gpg"))
+	if len(files) == 0 {
+		return nil
+	}
+	output, err := p.Executor().OutputQuiet(ctx, p.Binary(), append([]string{"-Qqo"}, files...)...)
+	if err != nil && output == "" {
+		return nil
+	}
+
+	owners := make(map[string]bool)
+	for _, name := range strings.Fields(output) {
+		owners[name] = true
+	}
+	return owners
+}
+
+// KeyringRepairPlan returns the commands RepairKeyring will run for a status.
+func (p *Pacman) KeyringRepairPlan(status *KeyringStatus) []string {
+	var plan []string
+	for _, step := range keyringRepairSteps(status) {
+		plan = append(plan, strings.Join(step, " "))
+	}
+	return plan
+}
+
+// RepairKeyring initializes the keyring if needed, refreshes the keyring
+// packages and repopulates the keys from them.
+func (p *Pacman) RepairKeyring(ctx context.Context, status *KeyringStatus, dryRun bool) error {
+	if dryRun {
+		p.SetDryRun(true)
+		defer p.SetDryRun(false)
+	}
+
+	for _, step := range keyringRepairSteps(status) {
+		if err := p.Executor().RunSudo(ctx, step[0], step[1:]...); err != nil {
+			return fmt.Errorf("%s failed: %w", strings.Join(step, " "), err)
+		}
+	}
+
+	return nil
+}
+
+// keyringRepairSteps builds the repair commands for a keyring status.
+func keyringRepairSteps(status *KeyringStatus) [][]string {
+	var steps [][]string
+
+	if !status.Initialized {
+		steps = append(steps, []string{"pacman-key", "--init"})
+	} else if !status.TrustDBOK {
+		// The trust database is derived data; --populate rebuilds it
+		trustdb := filepath.Join(status.GnupgDir, "trustdb.gpg")
+		steps = append(steps, []string{"mv", "-f", trustdb, trustdb + ".bak"})
+	}
+
+	packages := []string{"archlinux-keyring"}
+	if len(status.Packages) > 0 {
+		packages = packages[:0]
+		for _, pkg := range status.Packages {
+			packages = append(packages, pkg.Name)
+		}
+	}
+
+	// Upgrade the whole system together with the keyring packages:
+	// refreshing the sync database without upgrading is a partial upgrade,
+	// which Arch does not support
+	steps = append(steps, append([]string{"pacman", "-Syu", "--needed", "--noconfirm"}, packages...))
+	steps = append(steps, []string{"pacman-key", "--populate"})
+
+	return steps
+}
+
+// checkKeyringFiles reports whether a pacman GnuPG home has a public keyring
+// and a well-formed trust database.
+func checkKeyringFiles(dir string) (initialized, trustOK bool) {
+	for _, name := range []string{"pubring.gpg", "pubring.kbx"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Size() > 0 {
+			initialized = true
+			break
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "trustdb.gpg"))
+	trustOK = err == nil && info.Size() > 0 && info.Size()%trustRecordLen == 0
+
+	return initialized, trustOK
+}
+
+// parseKeyringPackages parses `pacman -Q` output for installed keyring
+// packages: archlinux-keyring and the owners of the keyrings pacman-key
+// populates from.
+func parseKeyringPackages(output string, owners map[string]bool) []KeyringPackage {
+	var packages []KeyringPackage
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || (fields[0] != "archlinux-keyring" && !owners[fields[0]]) {
+			continue
+		}
+		packages = append(packages, KeyringPackage{Name: fields[0], Installed: fields[1]})
+	}
+
+	return packages
+}
@@ -0,0 +1,90 @@
+package native
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyringPackages(t *testing.T) {
+	output := `acl 2.3.2-1
+archlinux-keyring 20240313-1
+bash 5.2.026-2
+chaotic-keyring 20230616-1
+gnome-keyring 1:46.1-1
+keyringer 0.5.6-1
+python-keyring 25.2.1-1`
+
+	packages := parseKeyringPackages(output, map[string]bool{"chaotic-keyring": true})
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 keyring packages, got %d: %v", len(packages), packages)
+	}
+
+	if packages[0].Name != "archlinux-keyring" || packages[0].Installed != "20240313-1" {
+		t.Errorf("unexpected package: %+v", packages[0])
+	}
+}
+
+func TestCheckKeyringFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	initialized, trustOK := checkKeyringFiles(dir)
+	if initialized || trustOK {
+		t.Error("expected empty directory to be uninitialized")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "pubring.gpg"), []byte("keys"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "trustdb.gpg"), make([]byte, 3*trustRecordLen), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	initialized, trustOK = checkKeyringFiles(dir)
+	if !initialized || !trustOK {
+		t.Errorf("expected healthy keyring, got initialized=%v trustOK=%v", initialized, trustOK)
+	}
+
+	// A truncated trust database is not a whole number of records
+	if err := os.WriteFile(filepath.Join(dir, "trustdb.gpg"), make([]byte, trustRecordLen+7), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, trustOK = checkKeyringFiles(dir)
+	if trustOK {
+		t.Error("expected truncated trust database to be reported")
+	}
+}
+
+func TestKeyringStatusProblems(t *testing.T) {
+	status := &KeyringStatus{
+		GnupgDir:    "/etc/pacman.d/gnupg",
+		Initialized: true,
+		TrustDBOK:   false,
+		Packages: []KeyringPackage{
+			{Name: "archlinux-keyring", Installed: "20230704-1", Available: "20240313-1"},
+		},
+	}
+
+	if status.Healthy() {
+		t.Error("expected unhealthy keyring")
+	}
+	if problems := status.Problems(); len(problems) != 2 {
+		t.Errorf("expected 2 problems, got %d: %v", len(problems), problems)
+	}
+
+	steps := keyringRepairSteps(status)
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 repair steps, got %d: %v", len(steps), steps)
+	}
+	if steps[0][0] != "mv" {
+		t.Errorf("expected trust database to be moved aside first, got %v", steps[0])
+	}
+	if got := strings.Join(steps[1], " "); got != "pacman -Syu --needed --noconfirm archlinux-keyring" {
+		t.Errorf("unexpected refresh step: %s", got)
+	}
+	if got := strings.Join(steps[2], " "); got != "pacman-key --populate" {
+		t.Errorf("unexpected populate step: %s", got)
+	}
+}
@@ -0,0 +1,149 @@
+package native
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+var (
+	// pacmanLogAction matches the lines pacman logs for each package of a
+	// transaction, e.g. "[ALPM] upgraded linux (6.7.1 -> 6.7.2)"
+	pacmanLogAction = regexp.MustCompile(`^\[ALPM\] (?:installed|upgraded|reinstalled|downgraded) (\S+) `)
+
+	// pacmanLogConfig matches the warnings about configuration files
+	// pacman left beside the live ones
+	pacmanLogConfig = regexp.MustCompile(`^\[ALPM\] warning: (\S+) (?:installed|saved) as (\S+)$`)
+)
+
+// manualMarkers are phrases scriptlets use for steps the user has to take.
+var manualMarkers = []string{
+	"manual intervention",
+	"action required",
+	"you must",
+	"you need to",
+	"you should",
+	"please run",
+	"reboot",
+}
+
+// TakeNotices returns the notices picked out of pacman's log by upgrades
+// since the last call.
+func (p *Pacman) TakeNotices() []manager.Notice {
+	p.noticesMu.Lock()
+	defer p.noticesMu.Unlock()
+
+	notices := p.notices
+	p.notices = nil
+	return notices
+}
+
+// logPath returns the path of pacman's log in the system pacman operates
+// on.
+func (p *Pacman) logPath() string {
+	root := p.root
+	if p.chroot != "" {
+		root = p.chroot
+	}
+	return filepath.Join("/", root, "var/log/pacman.log")
+}
+
+// logSize returns the size of pacman's log, where the entries of the next
+// transaction will start.
+func (p *Pacman) logSize() int64 {
+	info, err := os.Stat(p.logPath())
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// addNotices keeps the notices in the log entries written after offset
+// for TakeNotices. pacman logs scriptlet output, which it shows without
+// keeping, so no wrapper around the terminal output is needed.
+func (p *Pacman) addNotices(offset int64) {
+	f, err := os.Open(p.logPath())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// The log was rotated during the transaction
+	if info, err := f.Stat(); err != nil || info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return
+	}
+
+	notices := parsePacmanLogNotices(string(data))
+	if len(notices) == 0 {
+		return
+	}
+	p.noticesMu.Lock()
+	p.notices = append(p.notices, notices...)
+	p.noticesMu.Unlock()
+}
+
+// parsePacmanLogNotices picks out of pacman's log the configuration files
+// it left beside the live ones and the scriptlet messages that ask for
+// manual steps. Scriptlets run after pacman logs their package, so each
+// message is credited to the package logged before it.
+func parsePacmanLogNotices(log string) []manager.Notice {
+	var notices []manager.Notice
+	seen := make(map[string]bool)
+	pkg := ""
+
+	for _, line := range strings.Split(log, "\n") {
+		// Drop the "[2024-01-15T10:00:00+0100] " timestamp
+		if strings.HasPrefix(line, "[") {
+			if _, rest, ok := strings.Cut(line, "] "); ok {
+				line = rest
+			}
+		}
+		line = strings.TrimSpace(line)
+
+		if m := pacmanLogAction.FindStringSubmatch(line); m != nil {
+			pkg = m[1]
+			continue
+		}
+
+		var notice manager.Notice
+		if m := pacmanLogConfig.FindStringSubmatch(line); m != nil {
+			notice = manager.Notice{Text: m[2] + " was left beside " + m[1], Path: m[2]}
+		} else if text, ok := strings.CutPrefix(line, "[ALPM-SCRIPTLET]"); ok && isManualStep(text) {
+			text = strings.TrimSpace(text)
+			for _, prefix := range []string{"==>", ">>>", "->", "::"} {
+				text = strings.TrimSpace(strings.TrimPrefix(text, prefix))
+			}
+			notice = manager.Notice{Package: pkg, Text: text}
+		} else {
+			continue
+		}
+
+		if !seen[notice.Text] {
+			seen[notice.Text] = true
+			notices = append(notices, notice)
+		}
+	}
+	return notices
+}
+
+// isManualStep reports whether a line of output asks for a manual step.
+func isManualStep(line string) bool {
+	lower := strings.ToLower(line)
+	for _, marker := range manualMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
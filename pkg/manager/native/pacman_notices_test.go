@@ -0,0 +1,70 @@
+package native
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"poxy/pkg/manager"
+)
+
+const pacmanLogSample = `[2024-01-15T10:00:00+0100] [PACMAN] Running 'pacman -Syu'
+[2024-01-15T10:00:05+0100] [ALPM] transaction started
+[2024-01-15T10:00:06+0100] [ALPM] warning: /etc/pacman.conf installed as /etc/pacman.conf.pacnew
+[2024-01-15T10:00:06+0100] [ALPM] upgraded pacman (6.0.2-9 -> 6.1.0-1)
+[2024-01-15T10:00:07+0100] [ALPM] upgraded linux (6.7.1.arch1-1 -> 6.7.2.arch1-1)
+[2024-01-15T10:00:07+0100] [ALPM-SCRIPTLET] ==> Updating module dependencies
+[2024-01-15T10:00:07+0100] [ALPM-SCRIPTLET] ==> You need to run 'grub-mkconfig -o /boot/grub/grub.cfg'
+[2024-01-15T10:00:08+0100] [ALPM] installed postgresql (16.1-1)
+[2024-01-15T10:00:08+0100] [ALPM-SCRIPTLET] >>> Manual intervention: run pg_upgrade for existing clusters
+[2024-01-15T10:00:08+0100] [ALPM-SCRIPTLET] >>> Manual intervention: run pg_upgrade for existing clusters
+[2024-01-15T10:00:09+0100] [ALPM] transaction completed
+`
+
+func TestParsePacmanLogNotices(t *testing.T) {
+	got := parsePacmanLogNotices(pacmanLogSample)
+	want := []manager.Notice{
+		{Text: "/etc/pacman.conf.pacnew was left beside /etc/pacman.conf", Path: "/etc/pacman.conf.pacnew"},
+		{Package: "linux", Text: "You need to run 'grub-mkconfig -o /boot/grub/grub.cfg'"},
+		{Package: "postgresql", Text: "Manual intervention: run pg_upgrade for existing clusters"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parsePacmanLogNotices() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("notice %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPacmanAddNotices(t *testing.T) {
+	root := t.TempDir()
+	logPath := filepath.Join(root, "var/log/pacman.log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := "[2024-01-01T00:00:00+0100] [ALPM-SCRIPTLET] You must reboot\n"
+	if err := os.WriteFile(logPath, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPacman()
+	p.SetRoot(root)
+	offset := p.logSize()
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(pacmanLogSample)
+	f.Close()
+
+	p.addNotices(offset)
+	if notices := p.TakeNotices(); len(notices) != 3 {
+		t.Errorf("TakeNotices() = %+v, want only the notices after the offset", notices)
+	}
+	if notices := p.TakeNotices(); len(notices) != 0 {
+		t.Errorf("second TakeNotices() = %+v, want none", notices)
+	}
+}
@@ -0,0 +1,179 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// pacmanConf is pacman's configuration, which defines its repositories
+const pacmanConf = "/etc/pacman.conf"
+
+// pacmanRepoKeys are the settings of a repository section, which are
+// commented out with it when it is disabled
+var pacmanRepoKeys = []string{"Server", "Include", "SigLevel", "Usage", "CacheServer"}
+
+// ListRepos returns the repositories of pacman.conf, including the
+// sections commented out, which are disabled.
+func (p *Pacman) ListRepos(ctx context.Context) ([]manager.Repository, error) {
+	data, err := os.ReadFile(pacmanConf)
+	if err != nil {
+		return nil, err
+	}
+	return parsePacmanRepos(string(data)), nil
+}
+
+// EnableRepo uncomments the section of a repository in pacman.conf.
+func (p *Pacman) EnableRepo(ctx context.Context, name string, dryRun bool) error {
+	return p.toggleRepo(ctx, name, true, dryRun)
+}
+
+// DisableRepo comments out the section of a repository in pacman.conf.
+func (p *Pacman) DisableRepo(ctx context.Context, name string, dryRun bool) error {
+	return p.toggleRepo(ctx, name, false, dryRun)
+}
+
+func (p *Pacman) toggleRepo(ctx context.Context, name string, enable, dryRun bool) error {
+	data, err := os.ReadFile(pacmanConf)
+	if err != nil {
+		return err
+	}
+	content, err := togglePacmanRepo(string(data), name, enable)
+	if err != nil {
+		return err
+	}
+	return p.writeConfig(ctx, pacmanConf, content, dryRun)
+}
+
+// AddRepo appends a repository section with one server to pacman.conf.
+// Packages are checked against the SigLevel in [options].
+func (p *Pacman) AddRepo(ctx context.Context, name, url string, dryRun bool) error {
+	if name == "" {
+		return fmt.Errorf("pacman repositories need a name")
+	}
+	data, err := os.ReadFile(pacmanConf)
+	if err != nil {
+		return err
+	}
+	content, err := addPacmanRepo(string(data), name, url)
+	if err != nil {
+		return err
+	}
+	return p.writeConfig(ctx, pacmanConf, content, dryRun)
+}
+
+// pacmanSection returns the repository name of a section header such as
+// "[extra]" or "#[multilib]", and whether the header is commented out.
+// ok is false for other lines and for [options].
+func pacmanSection(line string) (name string, commented, ok bool) {
+	line = strings.TrimSpace(line)
+	body := strings.TrimSpace(strings.TrimLeft(line, "#"))
+	if !strings.HasPrefix(body, "[") || !strings.HasSuffix(body, "]") {
+		return "", false, false
+	}
+	name = body[1 : len(body)-1]
+	if name == "" || name == "options" || strings.ContainsAny(name, " \t[]") {
+		return "", false, false
+	}
+	return name, body != line, true
+}
+
+// pacmanSetting returns the key of a repository setting such as
+// "Include = /etc/pacman.d/mirrorlist" or "#Server = ...", its value, and
+// whether it is commented out.
+func pacmanSetting(line string) (key, value string, commented, ok bool) {
+	line = strings.TrimSpace(line)
+	body := strings.TrimSpace(strings.TrimLeft(line, "#"))
+	key, value, found := strings.Cut(body, "=")
+	key = strings.TrimSpace(key)
+	if !found || !slices.Contains(pacmanRepoKeys, key) {
+		return "", "", false, false
+	}
+	return key, strings.TrimSpace(value), body != line, true
+}
+
+// parsePacmanRepos returns the repository sections of pacman.conf, with
+// their first Server or Include as the URL.
+func parsePacmanRepos(content string) []manager.Repository {
+	var repos []manager.Repository
+	var current *manager.Repository
+
+	for _, line := range strings.Split(content, "\n") {
+		if name, commented, ok := pacmanSection(line); ok {
+			repos = append(repos, manager.Repository{Name: name, Enabled: !commented, File: pacmanConf})
+			current = &repos[len(repos)-1]
+			continue
+		}
+		if strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#")) == "[options]" {
+			current = nil
+			continue
+		}
+		key, value, commented, ok := pacmanSetting(line)
+		if !ok || current == nil || current.URL != "" || commented == current.Enabled {
+			continue
+		}
+		if key == "Server" || key == "Include" {
+			current.URL = value
+		}
+	}
+	return repos
+}
+
+// togglePacmanRepo comments out the section of the repository name and
+// its settings, or uncomments them. Other comments are left alone.
+func togglePacmanRepo(content, name string, enable bool) (string, error) {
+	lines := strings.Split(content, "\n")
+
+	start := -1
+	for i, line := range lines {
+		if section, _, ok := pacmanSection(line); ok && section == name {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "", fmt.Errorf("no repository %s in %s", name, pacmanConf)
+	}
+
+	for i := start; i < len(lines); i++ {
+		_, _, _, isSetting := pacmanSetting(lines[i])
+		if i > start {
+			if _, _, ok := pacmanSection(lines[i]); ok {
+				break
+			}
+			if strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lines[i]), "#")) == "[options]" {
+				break
+			}
+			if !isSetting {
+				continue
+			}
+		}
+
+		trimmed := strings.TrimSpace(lines[i])
+		commented := strings.HasPrefix(trimmed, "#")
+		switch {
+		case enable && commented:
+			lines[i] = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		case !enable && !commented:
+			lines[i] = "#" + trimmed
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// addPacmanRepo appends a section for the repository name at server.
+func addPacmanRepo(content, name, server string) (string, error) {
+	for _, repo := range parsePacmanRepos(content) {
+		if repo.Name == name {
+			return "", fmt.Errorf("%s already has a repository %s; enable it with: poxy repo enable %s", pacmanConf, name, name)
+		}
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + fmt.Sprintf("\n[%s]\nServer = %s\n", name, server), nil
+}
@@ -0,0 +1,88 @@
+package native
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPacmanConf = `[options]
+HoldPkg     = pacman glibc
+SigLevel    = Required DatabaseOptional
+
+#[core-testing]
+#Include = /etc/pacman.d/mirrorlist
+
+[core]
+Include = /etc/pacman.d/mirrorlist
+
+[extra]
+Include = /etc/pacman.d/mirrorlist
+
+# An example of a custom package repository.
+#[multilib]
+#Include = /etc/pacman.d/mirrorlist
+`
+
+func TestParsePacmanRepos(t *testing.T) {
+	repos := parsePacmanRepos(testPacmanConf)
+	if len(repos) != 4 {
+		t.Fatalf("expected 4 repositories, got %d: %+v", len(repos), repos)
+	}
+
+	want := map[string]bool{"core-testing": false, "core": true, "extra": true, "multilib": false}
+	for _, repo := range repos {
+		if enabled, ok := want[repo.Name]; !ok || enabled != repo.Enabled {
+			t.Errorf("unexpected repository: %+v", repo)
+		}
+		if repo.URL != "/etc/pacman.d/mirrorlist" {
+			t.Errorf("%s URL = %q", repo.Name, repo.URL)
+		}
+	}
+}
+
+func TestTogglePacmanRepo(t *testing.T) {
+	enabled, err := togglePacmanRepo(testPacmanConf, "multilib", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(enabled, "\n[multilib]\nInclude = /etc/pacman.d/mirrorlist\n") {
+		t.Errorf("multilib not enabled:\n%s", enabled)
+	}
+	if !strings.Contains(enabled, "# An example of a custom package repository.") {
+		t.Error("comments outside the section should be kept")
+	}
+	if !strings.Contains(enabled, "#[core-testing]") {
+		t.Error("other sections should be left alone")
+	}
+
+	disabled, err := togglePacmanRepo(enabled, "extra", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(disabled, "\n#[extra]\n#Include = /etc/pacman.d/mirrorlist\n") {
+		t.Errorf("extra not disabled:\n%s", disabled)
+	}
+	if !strings.Contains(disabled, "HoldPkg     = pacman glibc") {
+		t.Error("[options] should be left alone")
+	}
+
+	if _, err := togglePacmanRepo(testPacmanConf, "chaotic-aur", true); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
+
+func TestAddPacmanRepo(t *testing.T) {
+	content, err := addPacmanRepo(testPacmanConf, "chaotic-aur", "https://example.org/$repo/$arch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := parsePacmanRepos(content)
+	last := repos[len(repos)-1]
+	if last.Name != "chaotic-aur" || !last.Enabled || last.URL != "https://example.org/$repo/$arch" {
+		t.Errorf("unexpected added repository: %+v", last)
+	}
+
+	if _, err := addPacmanRepo(testPacmanConf, "multilib", "https://example.org"); err == nil {
+		t.Error("expected an error for an existing repository")
+	}
+}
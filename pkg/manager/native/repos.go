@@ -0,0 +1,36 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// writeConfig replaces the root-owned file at path with content. A dry run
+// only says so.
+func (b *BaseManager) writeConfig(ctx context.Context, path, content string, dryRun bool) error {
+	if dryRun {
+		fmt.Printf("Would update %s\n", path)
+		return nil
+	}
+
+	tmp, err := os.CreateTemp("", "poxy-config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// install sets the owner and mode of new files too, and creates
+	// missing directories such as /etc/apt/keyrings
+	if err := b.exec.RunSudo(ctx, "install", "-D", "-m", "0644", tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
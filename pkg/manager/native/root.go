@@ -0,0 +1,78 @@
+package native
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// InstalledIn returns true if the manager's binary is installed in the
+// system at root.
+func (b *BaseManager) InstalledIn(root string) bool {
+	return installedIn(root, b.binary)
+}
+
+// Chroot makes the manager run its commands chrooted into root, with the
+// binaries installed there. Backends whose own root option is unusable,
+// because the live environment lacks the tool, are run this way.
+func (b *BaseManager) Chroot(root string) {
+	b.chroot = root
+	b.exec.SetChroot(root)
+}
+
+// installedIn reports whether binary is installed in the usual binary
+// directories of the system at root. Symlinks are not followed, since
+// absolute ones point into the host.
+func installedIn(root, binary string) bool {
+	for _, dir := range []string{"usr/bin", "usr/sbin", "bin", "sbin", "usr/local/bin"} {
+		info, err := os.Lstat(filepath.Join(root, dir, binary))
+		if err != nil {
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 || (info.Mode().IsRegular() && info.Mode()&0111 != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRoot makes pacman and pactree operate on the system at root.
+func (p *Pacman) SetRoot(root string) {
+	p.root = root
+	p.Executor().SetCommandArgs(p.Binary(), "--root", root)
+	p.Executor().SetCommandArgs("pactree", "--dbpath", filepath.Join(root, "var/lib/pacman"))
+}
+
+// SetRoot makes dnf and rpm operate on the system at root.
+func (d *DNF) SetRoot(root string) {
+	d.Executor().SetCommandArgs(d.Binary(), "--installroot="+root)
+	d.Executor().SetCommandArgs("rpm", "--root", root)
+}
+
+// SetRoot makes the APT tools and dpkg operate on the system at root. APT
+// reads its state, cache and config below Dir and has dpkg install there.
+func (a *APT) SetRoot(root string) {
+	aptArgs := []string{"-o", "Dir=" + root, "-o", "DPkg::Options::=--root=" + root}
+	for _, tool := range []string{a.Binary(), "apt", "apt-get", "apt-cache", "apt-mark"} {
+		a.Executor().SetCommandArgs(tool, aptArgs...)
+	}
+	a.Executor().SetCommandArgs("dpkg", "--root="+root)
+	a.Executor().SetCommandArgs("dpkg-query", "--admindir="+filepath.Join(root, "var/lib/dpkg"))
+}
+
+// SetRoot makes apk operate on the system at root.
+func (a *APK) SetRoot(root string) {
+	a.Executor().SetCommandArgs(a.Binary(), "--root", root)
+}
+
+// SetRoot makes the xbps tools operate on the system at root.
+func (x *XBPS) SetRoot(root string) {
+	for _, tool := range []string{"xbps-install", "xbps-remove", "xbps-query"} {
+		x.Executor().SetCommandArgs(tool, "-r", root)
+	}
+}
+
+// SetRoot makes opkg operate on the system at root, such as a mounted
+// OpenWrt image.
+func (o *OPKG) SetRoot(root string) {
+	o.Executor().SetCommandArgs(o.Binary(), "--offline-root", root)
+}
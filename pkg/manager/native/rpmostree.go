@@ -0,0 +1,390 @@
+package native
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"poxy/pkg/manager"
+)
+
+// RPMOSTree implements the Manager interface for rpm-ostree, which manages
+// Fedora Silverblue, Kinoite and other image-based Fedora systems. Packages
+// are layered onto the system image, and packages of the image itself are
+// removed by overriding them. Changes are staged into a new deployment that
+// is booted on the next restart.
+type RPMOSTree struct {
+	*BaseManager
+}
+
+// NewRPMOSTree creates a new rpm-ostree manager instance.
+func NewRPMOSTree() *RPMOSTree {
+	return &RPMOSTree{
+		BaseManager: NewBaseManager("rpm-ostree", "rpm-ostree (Fedora Atomic)", "rpm-ostree", true),
+	}
+}
+
+// ostreeStatus is the output of `rpm-ostree status --json`.
+type ostreeStatus struct {
+	Deployments []ostreeDeployment `json:"deployments"`
+}
+
+// ostreeDeployment is a deployment of the system image.
+type ostreeDeployment struct {
+	Checksum  string `json:"checksum"`
+	Version   string `json:"version"`
+	Origin    string `json:"origin"`
+	Image     string `json:"container-image-reference"`
+	Timestamp int64  `json:"timestamp"`
+	Booted    bool   `json:"booted"`
+	Staged    bool   `json:"staged"`
+	Pinned    bool   `json:"pinned"`
+	// RequestedPackages are the packages layered onto the image
+	RequestedPackages []string `json:"requested-packages"`
+	// RequestedBaseRemovals are the packages of the image overridden away
+	RequestedBaseRemovals []string `json:"requested-base-removals"`
+}
+
+// deployment converts the deployment to the manager's form.
+func (d ostreeDeployment) deployment() manager.Deployment {
+	origin := d.Origin
+	if origin == "" {
+		origin = d.Image
+	}
+	deployment := manager.Deployment{
+		Checksum: d.Checksum,
+		Version:  d.Version,
+		Origin:   origin,
+		Booted:   d.Booted,
+		Staged:   d.Staged,
+		Pinned:   d.Pinned,
+		Layered:  d.RequestedPackages,
+		Removed:  d.RequestedBaseRemovals,
+	}
+	if d.Timestamp > 0 {
+		deployment.Time = time.Unix(d.Timestamp, 0)
+	}
+	return deployment
+}
+
+// AppliesOnReboot reports that rpm-ostree changes apply after a reboot.
+func (r *RPMOSTree) AppliesOnReboot() bool {
+	return true
+}
+
+// status returns the deployment the next boot will use, which carries the
+// changes staged since booting.
+func (r *RPMOSTree) status(ctx context.Context) (*ostreeDeployment, error) {
+	deployments, err := r.deployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &deployments[0], nil
+}
+
+// deployments returns the deployments of `rpm-ostree status --json`.
+func (r *RPMOSTree) deployments(ctx context.Context) ([]ostreeDeployment, error) {
+	output, err := r.Executor().OutputQuiet(ctx, r.Binary(), "status", "--json")
+	if err != nil {
+		return nil, err
+	}
+	return parseOSTreeStatus(output)
+}
+
+// parseOSTreeStatus returns the deployments of `rpm-ostree status --json`
+// output, which lists the default deployment first.
+func parseOSTreeStatus(output string) ([]ostreeDeployment, error) {
+	var status ostreeStatus
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse rpm-ostree status: %w", err)
+	}
+	if len(status.Deployments) == 0 {
+		return nil, fmt.Errorf("rpm-ostree reported no deployments")
+	}
+	return status.Deployments, nil
+}
+
+// Deployments returns the deployments of the system in boot order.
+func (r *RPMOSTree) Deployments(ctx context.Context) ([]manager.Deployment, error) {
+	deployments, err := r.deployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]manager.Deployment, len(deployments))
+	for i, d := range deployments {
+		result[i] = d.deployment()
+	}
+	return result, nil
+}
+
+// Install layers packages onto the system image. Packages of the image
+// that were overridden away are restored instead.
+func (r *RPMOSTree) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	var restore, layer []string
+	removed := make(map[string]bool)
+	if deployment, err := r.status(ctx); err == nil {
+		for _, pkg := range deployment.RequestedBaseRemovals {
+			removed[pkg] = true
+		}
+	}
+	for _, pkg := range packages {
+		if removed[pkg] {
+			restore = append(restore, pkg)
+		} else {
+			layer = append(layer, pkg)
+		}
+	}
+
+	if opts.DryRun {
+		r.SetDryRun(true)
+		defer r.SetDryRun(false)
+	}
+
+	if len(restore) > 0 {
+		args := append([]string{"override", "reset"}, restore...)
+		if err := r.Executor().RunSudo(ctx, r.Binary(), args...); err != nil {
+			return err
+		}
+	}
+	if len(layer) > 0 {
+		args := append([]string{"install", "--idempotent"}, opts.ExtraArgs...)
+		args = append(args, layer...)
+		return r.Executor().RunSudo(ctx, r.Binary(), args...)
+	}
+	return nil
+}
+
+// Uninstall removes layered packages and overrides away packages of the
+// system image.
+func (r *RPMOSTree) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
+	deployment, err := r.status(ctx)
+	if err != nil {
+		return err
+	}
+	layered := make(map[string]bool)
+	for _, pkg := range deployment.RequestedPackages {
+		layered[pkg] = true
+	}
+
+	var unlayer, override []string
+	for _, pkg := range packages {
+		if layered[pkg] {
+			unlayer = append(unlayer, pkg)
+		} else {
+			override = append(override, pkg)
+		}
+	}
+
+	if opts.DryRun {
+		r.SetDryRun(true)
+		defer r.SetDryRun(false)
+	}
+
+	if len(unlayer) > 0 {
+		args := append([]string{"uninstall"}, unlayer...)
+		if err := r.Executor().RunSudo(ctx, r.Binary(), args...); err != nil {
+			return err
+		}
+	}
+	if len(override) > 0 {
+		args := append([]string{"override", "remove"}, override...)
+		return r.Executor().RunSudo(ctx, r.Binary(), args...)
+	}
+	return nil
+}
+
+// Update refreshes the repository metadata.
+func (r *RPMOSTree) Update(ctx context.Context) error {
+	return r.Executor().RunSudo(ctx, r.Binary(), "refresh-md")
+}
+
+// Upgrade stages the latest system image with the layered packages. The
+// image is upgraded as a whole, so single packages cannot be upgraded.
+func (r *RPMOSTree) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
+	if len(opts.Packages) > 0 {
+		return fmt.Errorf("rpm-ostree upgrades the whole system image; run the upgrade without package names")
+	}
+
+	if opts.DryRun {
+		r.SetDryRun(true)
+		defer r.SetDryRun(false)
+	}
+
+	return r.Executor().RunSudo(ctx, r.Binary(), append([]string{"upgrade"}, opts.ExtraArgs...)...)
+}
+
+// ListUpgradable returns the package changes of the next system image,
+// from `rpm-ostree upgrade --preview`, which checks without staging it.
+func (r *RPMOSTree) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	output, err := r.Executor().OutputQuiet(ctx, r.Binary(), "upgrade", "--preview", "--unchanged-exit-77")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 77 {
+			return nil, nil // No update
+		}
+		return nil, err
+	}
+	return parseOSTreePreview(output), nil
+}
+
+// parseOSTreePreview parses the "Upgraded:" list of `rpm-ostree upgrade
+// --preview`, whose entries read "name old -> new":
+//
+//	AvailableUpdate:
+//	        Version: 40.20240502.0 (2024-05-02T00:40:55Z)
+//	       Upgraded: firefox 125.0.2-1.fc40 -> 125.0.3-1.fc40
+//	                 kernel 6.8.7-300.fc40 -> 6.8.8-300.fc40
+func parseOSTreePreview(output string) []manager.UpgradeCandidate {
+	var candidates []manager.UpgradeCandidate
+	inUpgraded := false
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if key, rest, ok := strings.Cut(trimmed, ":"); ok && !strings.Contains(key, " ") {
+			inUpgraded = key == "Upgraded"
+			trimmed = strings.TrimSpace(rest)
+		}
+		if !inUpgraded {
+			continue
+		}
+
+		fields := strings.Fields(trimmed)
+		if len(fields) != 4 || fields[2] != "->" {
+			continue
+		}
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           fields[0],
+			Source:         "rpm-ostree",
+			CurrentVersion: fields[1],
+			NewVersion:     fields[3],
+		})
+	}
+	return candidates
+}
+
+// Search finds packages matching the query.
+func (r *RPMOSTree) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, r, query, opts)
+	}
+
+	output, err := r.Executor().Output(ctx, r.Binary(), "search", query)
+	if err != nil {
+		return []manager.Package{}, nil
+	}
+
+	return r.parseSearchOutput(output, opts.Limit), nil
+}
+
+// parseSearchOutput parses rpm-ostree search output, which lists matches
+// as "name : summary" under "===== ... Matched =====" headers.
+func (r *RPMOSTree) parseSearchOutput(output string, limit int) []manager.Package {
+	var packages []manager.Package
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		name, summary, ok := strings.Cut(scanner.Text(), " : ")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		packages = append(packages, manager.Package{
+			Name:        name,
+			Description: strings.TrimSpace(summary),
+			Source:      "rpm-ostree",
+		})
+
+		if limit > 0 && len(packages) >= limit {
+			break
+		}
+	}
+
+	return packages
+}
+
+// Info returns detailed information about an installed package.
+func (r *RPMOSTree) Info(ctx context.Context, pkg string) (*manager.PackageInfo, error) {
+	output, err := r.Executor().OutputQuiet(ctx, "rpm", "-qi", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+
+	// rpm -qi prints the fields of dnf info
+	info := (&DNF{}).parsePackageInfo(output)
+	info.Source = "rpm-ostree"
+	info.Installed = true
+	return info, nil
+}
+
+// ListInstalled returns all installed packages, of the image and layered.
+func (r *RPMOSTree) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manager.Package, error) {
+	output, err := r.Executor().Output(ctx, "rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}-%{RELEASE}\n")
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []manager.Package
+	patternLower := strings.ToLower(opts.Pattern)
+
+	for _, line := range strings.Split(output, "\n") {
+		name, version, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+
+		if opts.Pattern != "" && !strings.Contains(strings.ToLower(name), patternLower) {
+			continue
+		}
+
+		packages = append(packages, manager.Package{
+			Name:      name,
+			Version:   version,
+			Source:    "rpm-ostree",
+			Installed: true,
+		})
+
+		if opts.Limit > 0 && len(packages) >= opts.Limit {
+			break
+		}
+	}
+
+	return packages, nil
+}
+
+// IsInstalled checks if a package is installed.
+func (r *RPMOSTree) IsInstalled(ctx context.Context, pkg string) (bool, error) {
+	err := r.Executor().Run(ctx, "rpm", "-q", pkg)
+	return err == nil, nil
+}
+
+// Clean removes cached repository metadata and, with opts.All, the
+// rollback deployment too.
+func (r *RPMOSTree) Clean(ctx context.Context, opts manager.CleanOpts) error {
+	if opts.DryRun {
+		r.SetDryRun(true)
+		defer r.SetDryRun(false)
+	}
+
+	args := []string{"cleanup", "--repomd"}
+	if opts.All {
+		args = append(args, "--rollback")
+	}
+	return r.Executor().RunSudo(ctx, r.Binary(), args...)
+}
+
+// Autoremove removes orphaned packages. Layered packages are requested one
+// by one and their dependencies leave with them, so there are none.
+func (r *RPMOSTree) Autoremove(ctx context.Context) error {
+	return nil
+}
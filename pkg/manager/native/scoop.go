@@ -21,9 +21,16 @@ func NewScoop() *Scoop {
 	}
 }
 
+// InstallScope returns manager.ScopeUser: apps go to ~/scoop unless
+// installed with --global.
+func (s *Scoop) InstallScope() manager.Scope {
+	return manager.ScopeUser
+}
+
 // Install installs one or more packages.
 func (s *Scoop) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	args := []string{"install"}
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -60,6 +67,8 @@ func (s *Scoop) Update(ctx context.Context) error {
 func (s *Scoop) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"update"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) == 0 {
 		args = append(args, "*")
 	} else {
@@ -76,6 +85,10 @@ func (s *Scoop) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 
 // Search finds packages matching the query.
 func (s *Scoop) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, s, query, opts)
+	}
+
 	output, err := s.Executor().Output(ctx, s.Binary(), "search", query)
 	if err != nil {
 		return []manager.Package{}, nil
@@ -29,7 +29,8 @@ func (s *Slackpkg) Install(ctx context.Context, packages []string, opts manager.
 	}
 
 	for _, pkg := range packages {
-		if err := s.Executor().RunSudo(ctx, s.Binary(), "install", pkg); err != nil {
+		args := append(append([]string{"install"}, opts.ExtraArgs...), pkg)
+		if err := s.Executor().RunSudo(ctx, s.Binary(), args...); err != nil {
 			return err
 		}
 	}
@@ -67,18 +68,23 @@ func (s *Slackpkg) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error
 
 	if len(opts.Packages) > 0 {
 		for _, pkg := range opts.Packages {
-			if err := s.Executor().RunSudo(ctx, s.Binary(), "upgrade", pkg); err != nil {
+			args := append(append([]string{"upgrade"}, opts.ExtraArgs...), pkg)
+			if err := s.Executor().RunSudo(ctx, s.Binary(), args...); err != nil {
 				return err
 			}
 		}
 		return nil
 	}
 
-	return s.Executor().RunSudo(ctx, s.Binary(), "upgrade-all")
+	return s.Executor().RunSudo(ctx, s.Binary(), append([]string{"upgrade-all"}, opts.ExtraArgs...)...)
 }
 
 // Search finds packages matching the query.
 func (s *Slackpkg) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, s, query, opts)
+	}
+
 	output, err := s.Executor().Output(ctx, s.Binary(), "search", query)
 	if err != nil {
 		return []manager.Package{}, nil
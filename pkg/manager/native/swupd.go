@@ -24,6 +24,7 @@ func NewSwupd() *Swupd {
 // Install installs one or more bundles.
 func (s *Swupd) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	args := []string{"bundle-add"}
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -59,11 +60,15 @@ func (s *Swupd) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		defer s.SetDryRun(false)
 	}
 
-	return s.Executor().RunSudo(ctx, s.Binary(), "update")
+	return s.Executor().RunSudo(ctx, s.Binary(), append([]string{"update"}, opts.ExtraArgs...)...)
 }
 
 // Search finds bundles matching the query.
 func (s *Swupd) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, s, query, opts)
+	}
+
 	args := []string{"search", query}
 
 	output, err := s.Executor().Output(ctx, s.Binary(), args...)
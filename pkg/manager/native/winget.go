@@ -25,6 +25,7 @@ func NewWinget() *Winget {
 func (w *Winget) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	for _, pkg := range packages {
 		args := []string{"install", pkg}
+		args = append(args, opts.ExtraArgs...)
 
 		if opts.AutoConfirm {
 			args = append(args, "--accept-package-agreements", "--accept-source-agreements")
@@ -75,6 +76,8 @@ func (w *Winget) Update(ctx context.Context) error {
 func (w *Winget) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"upgrade"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) == 0 {
 		args = append(args, "--all")
 	} else {
@@ -95,6 +98,10 @@ func (w *Winget) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 
 // Search finds packages matching the query.
 func (w *Winget) Search(ctx context.Context, query string, opts manager.SearchOpts) ([]manager.Package, error) {
+	if opts.InstalledOnly {
+		return manager.SearchInstalled(ctx, w, query, opts)
+	}
+
 	output, err := w.Executor().Output(ctx, w.Binary(), "search", query)
 	if err != nil {
 		return []manager.Package{}, nil
@@ -29,6 +29,7 @@ func (x *XBPS) Install(ctx context.Context, packages []string, opts manager.Inst
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -73,12 +74,14 @@ func (x *XBPS) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	if opts.AutoConfirm {
 		args = append(args, "-y")
 	}
+	args = append(args, opts.ExtraArgs...)
 
 	if len(opts.Packages) > 0 {
 		args = []string{"-S"}
 		if opts.AutoConfirm {
 			args = append(args, "-y")
 		}
+		args = append(args, opts.ExtraArgs...)
 		args = append(args, opts.Packages...)
 	}
 
@@ -29,6 +29,7 @@ func (z *Zypper) Install(ctx context.Context, packages []string, opts manager.In
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -70,6 +71,8 @@ func (z *Zypper) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
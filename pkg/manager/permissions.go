@@ -0,0 +1,141 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Permissions are what a sandboxed app, such as a Flatpak app, may access
+// outside its sandbox.
+type Permissions struct {
+	Shared      []string          `json:"shared,omitempty"`      // Subsystems shared with the host: network, ipc
+	Sockets     []string          `json:"sockets,omitempty"`     // x11, wayland, pulseaudio, ...
+	Devices     []string          `json:"devices,omitempty"`     // dri, input, kvm, all, ...
+	Filesystems []string          `json:"filesystems,omitempty"` // host, home, xdg-download:ro, ~/Games, ...
+	Features    []string          `json:"features,omitempty"`    // devel, multiarch, bluetooth, ...
+	SessionBus  map[string]string `json:"session_bus,omitempty"` // Bus name to policy: see, talk or own
+	SystemBus   map[string]string `json:"system_bus,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+
+	// Overrides are the permissions the user granted or revoked (with a
+	// leading "!"), already applied to the others. Nil if there are none.
+	Overrides *Permissions `json:"overrides,omitempty"`
+}
+
+// Network reports whether the app can use the network.
+func (p *Permissions) Network() bool {
+	return slices.Contains(p.Shared, "network")
+}
+
+// Empty reports whether the app has no permissions at all.
+func (p *Permissions) Empty() bool {
+	return len(p.Shared) == 0 && len(p.Sockets) == 0 && len(p.Devices) == 0 &&
+		len(p.Filesystems) == 0 && len(p.Features) == 0 && len(p.SessionBus) == 0 &&
+		len(p.SystemBus) == 0 && len(p.Environment) == 0
+}
+
+// PermissionGroup is a group of permissions, for display.
+type PermissionGroup struct {
+	Label  string
+	Values []string
+}
+
+// Groups returns the permissions grouped for display, leaving out empty
+// groups. Revoked permissions of Overrides start with "!".
+func (p *Permissions) Groups() []PermissionGroup {
+	groups := []PermissionGroup{
+		{"Shared", p.Shared},
+		{"Sockets", p.Sockets},
+		{"Devices", p.Devices},
+		{"Filesystems", p.Filesystems},
+		{"Features", p.Features},
+		{"Session bus", policyList(p.SessionBus)},
+		{"System bus", policyList(p.SystemBus)},
+		{"Environment", envList(p.Environment)},
+	}
+	return slices.DeleteFunc(groups, func(g PermissionGroup) bool { return len(g.Values) == 0 })
+}
+
+// policyList returns bus names with their policy, e.g.
+// "org.freedesktop.Notifications (talk)", sorted.
+func policyList(policies map[string]string) []string {
+	var list []string
+	for _, name := range slices.Sorted(maps.Keys(policies)) {
+		list = append(list, fmt.Sprintf("%s (%s)", name, policies[name]))
+	}
+	return list
+}
+
+// envList returns VAR=value entries, sorted.
+func envList(env map[string]string) []string {
+	var list []string
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		list = append(list, name+"="+env[name])
+	}
+	return list
+}
+
+// PermissionKind is the kind of permission an override changes.
+type PermissionKind string
+
+const (
+	PermShare          PermissionKind = "share"            // network or ipc
+	PermSocket         PermissionKind = "socket"           // x11, wayland, ...
+	PermDevice         PermissionKind = "device"           // dri, all, ...
+	PermFilesystem     PermissionKind = "filesystem"       // host, home, a path, with :ro or :create
+	PermFeature        PermissionKind = "feature"          // devel, bluetooth, ...
+	PermTalkName       PermissionKind = "talk-name"        // A session bus name
+	PermOwnName        PermissionKind = "own-name"         // A session bus name
+	PermSystemTalkName PermissionKind = "system-talk-name" // A system bus name
+	PermSystemOwnName  PermissionKind = "system-own-name"  // A system bus name
+	PermEnv            PermissionKind = "env"              // VAR=value to set, VAR to unset
+)
+
+// PermissionKinds lists every kind of permission, in the order they are
+// shown.
+var PermissionKinds = []PermissionKind{
+	PermShare, PermSocket, PermDevice, PermFilesystem, PermFeature,
+	PermTalkName, PermOwnName, PermSystemTalkName, PermSystemOwnName, PermEnv,
+}
+
+// PermissionOverride grants or revokes one permission of an app.
+type PermissionOverride struct {
+	Kind  PermissionKind
+	Value string // e.g. "network", "~/Games:ro", "org.freedesktop.Notifications"
+	Grant bool   // false revokes the permission
+}
+
+// ParsePermissionOverride parses an override written as +kind=value to
+// grant a permission or -kind=value to revoke it, such as "-share=network"
+// or "+filesystem=~/Games:ro".
+func ParsePermissionOverride(s string) (PermissionOverride, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '+' && s[0] != '-') {
+		return PermissionOverride{}, fmt.Errorf("invalid permission override %q (want +kind=value to grant or -kind=value to revoke)", s)
+	}
+
+	kind, value, ok := strings.Cut(s[1:], "=")
+	if !ok || value == "" {
+		return PermissionOverride{}, fmt.Errorf("invalid permission override %q (want +kind=value to grant or -kind=value to revoke)", s)
+	}
+	if !slices.Contains(PermissionKinds, PermissionKind(kind)) {
+		var kinds []string
+		for _, k := range PermissionKinds {
+			kinds = append(kinds, string(k))
+		}
+		return PermissionOverride{}, fmt.Errorf("unknown permission kind %q (want %s)", kind, strings.Join(kinds, ", "))
+	}
+
+	return PermissionOverride{Kind: PermissionKind(kind), Value: value, Grant: s[0] == '+'}, nil
+}
+
+// String returns the override as ParsePermissionOverride reads it.
+func (o PermissionOverride) String() string {
+	sign := "-"
+	if o.Grant {
+		sign = "+"
+	}
+	return sign + string(o.Kind) + "=" + o.Value
+}
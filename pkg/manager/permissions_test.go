@@ -0,0 +1,31 @@
+package manager
+
+import "testing"
+
+func TestParsePermissionOverride(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    PermissionOverride
+		wantErr bool
+	}{
+		{"-share=network", PermissionOverride{Kind: PermShare, Value: "network"}, false},
+		{"+filesystem=~/Games:ro", PermissionOverride{Kind: PermFilesystem, Value: "~/Games:ro", Grant: true}, false},
+		{"+env=GTK_THEME=Adwaita:dark", PermissionOverride{Kind: PermEnv, Value: "GTK_THEME=Adwaita:dark", Grant: true}, false},
+		{"share=network", PermissionOverride{}, true},
+		{"+share", PermissionOverride{}, true},
+		{"+camera=on", PermissionOverride{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePermissionOverride(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePermissionOverride(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePermissionOverride(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if !tt.wantErr && got.String() != tt.input {
+			t.Errorf("String() = %q, want %q", got.String(), tt.input)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"poxy/internal/config"
 	"poxy/pkg/manager/detector"
@@ -17,6 +19,16 @@ type Registry struct {
 	sysInfo  *detector.SystemInfo
 	cfg      *config.Config
 	mu       sync.RWMutex
+
+	// nativeReason says why native was chosen
+	nativeReason string
+
+	// root is the system managers operate on, if not the running one
+	root     string
+	chrooted map[string]bool
+
+	// simulated is set for a simulated system, which Detect leaves alone
+	simulated bool
 }
 
 // NewRegistry creates a new package manager registry.
@@ -28,40 +40,80 @@ func NewRegistry(cfg *config.Config) *Registry {
 }
 
 // Register adds a manager to the registry.
+// Managers excluded by the configured policy are ignored, and those that
+// can install for the user or system-wide get the configured scope.
 func (r *Registry) Register(mgr Manager) {
+	if r.cfg != nil && !r.cfg.IsManagerAllowed(mgr.Name()) {
+		return
+	}
+	if setter, ok := mgr.(ScopeSetter); ok && r.cfg != nil {
+		if scope, err := ParseScope(r.cfg.InstallScope(mgr.Name())); err == nil && scope != "" {
+			setter.SetInstallScope(scope)
+		}
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.managers[mgr.Name()] = mgr
 }
 
-// Detect detects the system and identifies available package managers.
+// Detect detects the system, or the one at the root set with SetRoot, and
+// identifies available package managers.
 func (r *Registry) Detect() error {
-	info, err := detector.Detect()
+	if r.simulated {
+		return nil
+	}
+
+	detect := detector.Detect
+	if r.root != "" {
+		detect = func() (*detector.SystemInfo, error) { return detector.DetectRoot(r.root) }
+	}
+	info, err := detect()
 	if err != nil {
 		return fmt.Errorf("failed to detect system: %w", err)
 	}
 	r.sysInfo = info
 
 	// Determine native package manager based on OS
-	var nativeName string
+	var candidates []detector.NativeCandidate
 	switch info.OS {
 	case detector.OSLinux:
-		nativeName = detector.GetNativeManagerForFamily(info.Distribution, info.DistroFamily)
+		candidates = detector.NativeCandidates(info)
 	case detector.OSDarwin:
-		nativeName = detector.GetDarwinManager()
+		candidates = []detector.NativeCandidate{{Manager: detector.GetDarwinManager(), Reason: "Homebrew is the package manager of macOS"}}
 	case detector.OSWindows:
-		nativeName = detector.GetWindowsManager()
+		if name := detector.GetWindowsManager(); name != "" {
+			candidates = []detector.NativeCandidate{{Manager: name, Reason: name + " is the first Windows package manager installed"}}
+		}
 	}
 
-	if nativeName != "" {
-		if mgr, ok := r.managers[nativeName]; ok && mgr.IsAvailable() {
-			r.native = mgr
+	for i, candidate := range candidates {
+		mgr, ok := r.managers[candidate.Manager]
+		if !ok || !mgr.IsAvailable() {
+			continue
 		}
+		r.native = mgr
+		r.nativeReason = candidate.Reason
+		if i > 0 {
+			r.nativeReason += fmt.Sprintf(" (%s is not available)", candidates[0].Manager)
+		}
+		break
 	}
 
 	return nil
 }
 
+// Simulate makes the registry describe a simulated system instead of
+// detecting the running one, with native as its native manager.
+func (r *Registry) Simulate(info *detector.SystemInfo, native Manager, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sysInfo = info
+	r.native = native
+	r.nativeReason = reason
+	r.simulated = true
+}
+
 // Native returns the detected native package manager for this system.
 func (r *Registry) Native() Manager {
 	r.mu.RLock()
@@ -69,6 +121,14 @@ func (r *Registry) Native() Manager {
 	return r.native
 }
 
+// NativeReason returns why the native package manager was chosen, or ""
+// if none was.
+func (r *Registry) NativeReason() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nativeReason
+}
+
 // Get returns a specific manager by name.
 func (r *Registry) Get(name string) (Manager, bool) {
 	r.mu.RLock()
@@ -122,23 +182,107 @@ func (r *Registry) All() []Manager {
 	return managers
 }
 
+// SetRoot points the registered managers at the system mounted at root.
+// An installed system is only operated on with the managers installed in
+// it; an empty root, such as an image being bootstrapped, with any that
+// support alternate roots. Managers installed on the running system use
+// their alternate root option, others run chrooted with the target's
+// binaries, and the rest are removed so nothing touches the running system
+// by accident. Detect then detects the system at root.
+func (r *Registry) SetRoot(root string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, _ := detector.DetectRoot(root) //nolint:errcheck
+	populated := info.Distribution != "unknown"
+
+	r.root = root
+	r.chrooted = make(map[string]bool)
+	for name, mgr := range r.managers {
+		chrooter, canChroot := mgr.(Chrooter)
+		installed := canChroot && chrooter.InstalledIn(root)
+		if populated && !installed {
+			delete(r.managers, name)
+			continue
+		}
+
+		if setter, ok := mgr.(RootSetter); ok && mgr.IsAvailable() {
+			setter.SetRoot(root)
+			continue
+		}
+		if installed {
+			chrooter.Chroot(root)
+			r.chrooted[name] = true
+			continue
+		}
+		delete(r.managers, name)
+	}
+	if r.native != nil {
+		if _, ok := r.managers[r.native.Name()]; !ok {
+			r.native = nil
+			r.nativeReason = ""
+		}
+	}
+}
+
+// Root returns the root set with SetRoot, or "" for the running system.
+func (r *Registry) Root() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.root
+}
+
+// Chrooted returns true if the named manager runs chrooted into the root.
+func (r *Registry) Chrooted(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.chrooted[name]
+}
+
 // SystemInfo returns the detected system information.
 func (r *Registry) SystemInfo() *detector.SystemInfo {
 	return r.sysInfo
 }
 
-// SearchAll searches for packages across all available managers concurrently.
+// ManagerVersions returns the backend version of each available manager and
+// records them in the system information. Versions are detected once and cached.
+func (r *Registry) ManagerVersions(ctx context.Context) map[string]string {
+	r.mu.RLock()
+	if r.sysInfo != nil && r.sysInfo.ManagerVersions != nil {
+		versions := r.sysInfo.ManagerVersions
+		r.mu.RUnlock()
+		return versions
+	}
+	r.mu.RUnlock()
+
+	versions := DetectVersions(ctx, r.Available())
+
+	r.mu.Lock()
+	if r.sysInfo != nil {
+		r.sysInfo.ManagerVersions = versions
+	}
+	r.mu.Unlock()
+
+	return versions
+}
+
+// SearchAll searches for packages across all available managers
+// concurrently. Each manager gets the configured probe timeout; the results
+// of the others are returned with a *SourceTimeoutError naming the managers
+// that took longer, joined to the first error of any other manager.
 func (r *Registry) SearchAll(ctx context.Context, query string, opts SearchOpts) ([]Package, error) {
 	available := r.Available()
 	if len(available) == 0 {
 		return nil, fmt.Errorf("no package managers available")
 	}
+	timeout := r.sourceTimeout()
 
 	var (
 		mu       sync.Mutex
 		wg       sync.WaitGroup
 		results  []Package
 		firstErr error
+		timedOut []string
 	)
 
 	for _, mgr := range available {
@@ -146,19 +290,18 @@ func (r *Registry) SearchAll(ctx context.Context, query string, opts SearchOpts)
 		go func(m Manager) {
 			defer wg.Done()
 
-			pkgs, err := m.Search(ctx, query, opts)
-			if err != nil {
-				mu.Lock()
-				if firstErr == nil {
-					firstErr = fmt.Errorf("%s: %w", m.Name(), err)
-				}
-				mu.Unlock()
-				return
-			}
+			pkgs, err := SearchWithin(ctx, m, query, opts, timeout)
 
 			mu.Lock()
-			results = append(results, pkgs...)
-			mu.Unlock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				results = append(results, pkgs...)
+			case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+				timedOut = append(timedOut, m.Name())
+			case firstErr == nil:
+				firstErr = fmt.Errorf("%s: %w", m.Name(), err)
+			}
 		}(mgr)
 	}
 
@@ -167,12 +310,34 @@ func (r *Registry) SearchAll(ctx context.Context, query string, opts SearchOpts)
 	// Sort results by source priority
 	r.sortPackagesByPriority(results)
 
+	if len(timedOut) > 0 {
+		sort.Strings(timedOut)
+		return results, errors.Join(firstErr, &SourceTimeoutError{Sources: timedOut, Timeout: timeout})
+	}
 	return results, firstErr
 }
 
+// sourceTimeout returns how long each source is given to answer in a
+// search across several sources.
+func (r *Registry) sourceTimeout() time.Duration {
+	if r.cfg == nil {
+		return 0
+	}
+	return r.cfg.Timeouts.Probe
+}
+
 // GetManagerForSource returns the appropriate manager for a source string.
 // Source can be a manager name (e.g., "apt") or a type (e.g., "native").
 func (r *Registry) GetManagerForSource(source string) (Manager, error) {
+	if r.cfg != nil && !r.cfg.IsManagerAllowed(source) {
+		switch source {
+		case "native", "universal", "aur":
+			// Types are resolved below against the allowed managers
+		default:
+			return nil, fmt.Errorf("package manager '%s' is disabled by policy", source)
+		}
+	}
+
 	// Check if it's a direct manager name
 	if mgr, ok := r.Get(source); ok {
 		if !mgr.IsAvailable() {
@@ -2,7 +2,11 @@ package manager
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"poxy/internal/config"
 )
@@ -68,6 +72,25 @@ func TestRegistryRegister(t *testing.T) {
 	}
 }
 
+func TestRegistryRegisterPolicy(t *testing.T) {
+	cfg := config.Default()
+	cfg.Policy.AllowedManagers = []string{"allowed"}
+	registry := NewRegistry(cfg)
+
+	registry.Register(&MockManager{name: "allowed", available: true, mgrType: TypeNative})
+	registry.Register(&MockManager{name: "blocked", available: true, mgrType: TypeUniversal})
+
+	if _, ok := registry.Get("blocked"); ok {
+		t.Error("Register() should ignore managers excluded by policy")
+	}
+	if _, err := registry.GetManagerForSource("blocked"); err == nil {
+		t.Error("GetManagerForSource() should fail for managers excluded by policy")
+	}
+	if _, err := registry.GetManagerForSource("allowed"); err != nil {
+		t.Errorf("GetManagerForSource() error: %v", err)
+	}
+}
+
 func TestRegistryGet(t *testing.T) {
 	cfg := config.Default()
 	registry := NewRegistry(cfg)
@@ -155,6 +178,73 @@ func TestRegistryAll(t *testing.T) {
 	}
 }
 
+// rootMockManager is a MockManager that supports alternate roots and
+// chroots.
+type rootMockManager struct {
+	MockManager
+	installed bool // Installed in the target root
+	root      string
+	chroot    string
+}
+
+func (m *rootMockManager) SetRoot(root string)       { m.root = root }
+func (m *rootMockManager) InstalledIn(_ string) bool { return m.installed }
+func (m *rootMockManager) Chroot(root string)        { m.chroot = root }
+
+func TestRegistrySetRoot(t *testing.T) {
+	root := t.TempDir()
+	registry := NewRegistry(config.Default())
+
+	rooted := &rootMockManager{MockManager: MockManager{name: "pacman", available: true}}
+	chrooted := &rootMockManager{MockManager: MockManager{name: "dnf"}, installed: true}
+	registry.Register(rooted)
+	registry.Register(chrooted)
+	registry.Register(&rootMockManager{MockManager: MockManager{name: "apt"}})
+	registry.Register(&MockManager{name: "flatpak", available: true})
+
+	// An empty root, such as an image being built
+	registry.SetRoot(root)
+
+	if rooted.root != root || rooted.chroot != "" {
+		t.Errorf("SetRoot() should use the root option of managers on the running system")
+	}
+	if chrooted.chroot != root || !registry.Chrooted("dnf") || registry.Chrooted("pacman") {
+		t.Errorf("SetRoot() should chroot managers only installed in the root")
+	}
+	if _, ok := registry.Get("apt"); ok {
+		t.Error("SetRoot() should remove managers installed in neither system")
+	}
+	if _, ok := registry.Get("flatpak"); ok {
+		t.Error("SetRoot() should remove managers without alternate root support")
+	}
+	if registry.Root() != root {
+		t.Errorf("Root() = %q, want %q", registry.Root(), root)
+	}
+}
+
+func TestRegistrySetRootInstalledSystem(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "etc/os-release"), []byte("ID=arch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(config.Default())
+
+	registry.Register(&rootMockManager{MockManager: MockManager{name: "pacman", available: true}, installed: true})
+	registry.Register(&rootMockManager{MockManager: MockManager{name: "apt", available: true}})
+
+	registry.SetRoot(root)
+
+	if _, ok := registry.Get("pacman"); !ok {
+		t.Error("SetRoot() should keep managers installed in the system")
+	}
+	if _, ok := registry.Get("apt"); ok {
+		t.Error("SetRoot() should remove managers the installed system does not have")
+	}
+}
+
 func TestRegistryGetManagerForSource(t *testing.T) {
 	cfg := config.Default()
 	registry := NewRegistry(cfg)
@@ -208,3 +298,48 @@ func TestRegistrySystemInfo(t *testing.T) {
 		t.Error("SystemInfo() should not be nil after detection")
 	}
 }
+
+// searchMockManager answers searches with its packages, or never answers
+// while hang is open, whatever the context.
+type searchMockManager struct {
+	MockManager
+	packages []Package
+	hang     chan struct{}
+}
+
+func (m *searchMockManager) Search(_ context.Context, _ string, _ SearchOpts) ([]Package, error) {
+	if m.hang != nil {
+		<-m.hang
+	}
+	return m.packages, nil
+}
+
+func TestRegistrySearchAllTimeout(t *testing.T) {
+	cfg := config.Default()
+	cfg.Timeouts.Probe = 50 * time.Millisecond
+	registry := NewRegistry(cfg)
+
+	hang := make(chan struct{})
+	defer close(hang)
+	registry.Register(&searchMockManager{
+		MockManager: MockManager{name: "apt", available: true, mgrType: TypeNative},
+		packages:    []Package{{Name: "htop", Source: "apt", Installed: true}},
+	})
+	registry.Register(&searchMockManager{
+		MockManager: MockManager{name: "snap", available: true, mgrType: TypeUniversal},
+		hang:        hang,
+	})
+
+	start := time.Now()
+	results, err := registry.SearchAll(context.Background(), "htop", SearchOpts{})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SearchAll() waited %s for the stuck source", elapsed)
+	}
+	if len(results) != 1 || results[0].Name != "htop" {
+		t.Errorf("SearchAll() = %v, want the answer of apt", results)
+	}
+	var timeoutErr *SourceTimeoutError
+	if !errors.As(err, &timeoutErr) || len(timeoutErr.Sources) != 1 || timeoutErr.Sources[0] != "snap" {
+		t.Errorf("SearchAll() error = %v, want snap timed out", err)
+	}
+}
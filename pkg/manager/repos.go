@@ -0,0 +1,11 @@
+package manager
+
+// Repository is a source of packages a manager is configured with: a
+// pacman repository, an APT source, a DNF repository or a Flatpak remote.
+type Repository struct {
+	Name        string `json:"name"`                  // What poxy repo enable and disable take
+	Description string `json:"description,omitempty"` // e.g. "Fedora 40 - x86_64"
+	URL         string `json:"url,omitempty"`         // Server, mirror list or remote URL
+	Enabled     bool   `json:"enabled"`
+	File        string `json:"file,omitempty"` // Config file it is defined in, if any
+}
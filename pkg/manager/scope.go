@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope is who an installed package is for.
+type Scope string
+
+const (
+	ScopeSystem Scope = "system" // Every user of the machine; usually needs root
+	ScopeUser   Scope = "user"   // The current user only, in their home directory
+)
+
+// ParseScope parses a scope name. The empty string is the zero Scope,
+// which leaves the choice to each manager.
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(strings.ToLower(strings.TrimSpace(s))); scope {
+	case "", ScopeSystem, ScopeUser:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown install scope %q (want user or system)", s)
+}
+
+// Describe returns how a plan names the scope: "for this user" or
+// "system-wide".
+func (s Scope) Describe() string {
+	if s == ScopeUser {
+		return "for this user"
+	}
+	return "system-wide"
+}
+
+// InstallScope returns who the packages m installs are for.
+func InstallScope(m Manager) Scope {
+	if reporter, ok := m.(ScopeReporter); ok {
+		return reporter.InstallScope()
+	}
+	return ScopeSystem
+}
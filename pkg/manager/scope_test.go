@@ -0,0 +1,32 @@
+package manager
+
+import "testing"
+
+func TestParseScope(t *testing.T) {
+	for input, want := range map[string]Scope{
+		"":       "",
+		"user":   ScopeUser,
+		" User ": ScopeUser,
+		"system": ScopeSystem,
+	} {
+		if got, err := ParseScope(input); err != nil || got != want {
+			t.Errorf("ParseScope(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseScope("global"); err == nil {
+		t.Error("ParseScope() accepted an unknown scope")
+	}
+}
+
+type userManager struct{ MockManager }
+
+func (*userManager) InstallScope() Scope { return ScopeUser }
+
+func TestInstallScope(t *testing.T) {
+	if got := InstallScope(&MockManager{name: "apt"}); got != ScopeSystem {
+		t.Errorf("InstallScope() of a plain manager = %q, want system", got)
+	}
+	if got := InstallScope(&userManager{MockManager{name: "cargo"}}); got != ScopeUser {
+		t.Errorf("InstallScope() of a user manager = %q, want user", got)
+	}
+}
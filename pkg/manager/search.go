@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchInstalled searches installed packages through ListInstalled. It is
+// used by backends whose native search only queries remote repositories.
+func SearchInstalled(ctx context.Context, m Manager, query string, opts SearchOpts) ([]Package, error) {
+	packages, err := m.ListInstalled(ctx, ListOpts{Pattern: query, Limit: opts.Limit})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range packages {
+		packages[i].Installed = true
+	}
+	return packages, nil
+}
+
+// SearchScoped runs a search and enforces the InstalledOnly and AvailableOnly
+// options on the results, so callers get the same semantics from every
+// backend regardless of how much of the scope its native search honors.
+func SearchScoped(ctx context.Context, m Manager, query string, opts SearchOpts) ([]Package, error) {
+	packages, err := m.Search(ctx, query, opts)
+	if err != nil || (!opts.AvailableOnly && !(opts.InstalledOnly && hasUnmarked(packages))) {
+		return packages, err
+	}
+
+	// Remote search results rarely carry the installed state, so mark it
+	// from the installed package list before filtering
+	installed, err := m.ListInstalled(ctx, ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(installed))
+	for _, pkg := range installed {
+		names[pkg.Name] = true
+	}
+
+	filtered := packages[:0]
+	for _, pkg := range packages {
+		pkg.Installed = pkg.Installed || names[pkg.Name]
+		if opts.InstalledOnly && !pkg.Installed {
+			continue
+		}
+		if opts.AvailableOnly && pkg.Installed {
+			continue
+		}
+		filtered = append(filtered, pkg)
+	}
+	return filtered, nil
+}
+
+// SourceTimeoutError reports the sources of a search across several that
+// did not answer in time. The results of the others are still returned.
+type SourceTimeoutError struct {
+	Sources []string
+	Timeout time.Duration
+}
+
+func (e *SourceTimeoutError) Error() string {
+	return fmt.Sprintf("no answer from %s within %s", strings.Join(e.Sources, ", "), e.Timeout)
+}
+
+// SearchWithin runs SearchScoped, giving up after timeout (0 = none). A
+// search that does not return when its context ends is abandoned rather
+// than waited for, so one stuck source cannot hold up the others; the
+// error is then context.DeadlineExceeded.
+func SearchWithin(ctx context.Context, m Manager, query string, opts SearchOpts, timeout time.Duration) ([]Package, error) {
+	if timeout <= 0 {
+		return SearchScoped(ctx, m, query, opts)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		packages []Package
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		packages, err := SearchScoped(ctx, m, query, opts)
+		done <- result{packages, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, context.DeadlineExceeded
+		}
+		return r.packages, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// hasUnmarked reports whether any package lacks the installed flag.
+func hasUnmarked(packages []Package) bool {
+	for _, pkg := range packages {
+		if !pkg.Installed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"context"
+	"testing"
+)
+
+// searchMock returns fixed remote results and an installed package list
+type searchMock struct {
+	MockManager
+	results   []Package
+	installed []Package
+}
+
+func (m *searchMock) Search(_ context.Context, _ string, _ SearchOpts) ([]Package, error) {
+	return append([]Package(nil), m.results...), nil
+}
+
+func (m *searchMock) ListInstalled(_ context.Context, _ ListOpts) ([]Package, error) {
+	return m.installed, nil
+}
+
+func TestSearchScoped(t *testing.T) {
+	mock := &searchMock{
+		results:   []Package{{Name: "vim"}, {Name: "neovim"}, {Name: "vim-airline"}},
+		installed: []Package{{Name: "vim", Installed: true}},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opts SearchOpts
+		want []string
+	}{
+		{"unscoped", SearchOpts{}, []string{"vim", "neovim", "vim-airline"}},
+		{"installed only", SearchOpts{InstalledOnly: true}, []string{"vim"}},
+		{"available only", SearchOpts{AvailableOnly: true}, []string{"neovim", "vim-airline"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SearchScoped(ctx, mock, "vim", tt.opts)
+			if err != nil {
+				t.Fatalf("SearchScoped() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("SearchScoped() = %v, want %v", got, tt.want)
+			}
+			for i, pkg := range got {
+				if pkg.Name != tt.want[i] {
+					t.Errorf("SearchScoped()[%d] = %s, want %s", i, pkg.Name, tt.want[i])
+				}
+				if tt.opts.InstalledOnly && !pkg.Installed {
+					t.Errorf("SearchScoped()[%d] not marked installed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchInstalled(t *testing.T) {
+	mock := &searchMock{installed: []Package{{Name: "vim"}}}
+
+	got, err := SearchInstalled(context.Background(), mock, "vim", SearchOpts{InstalledOnly: true})
+	if err != nil {
+		t.Fatalf("SearchInstalled() error = %v", err)
+	}
+	if len(got) != 1 || !got[0].Installed {
+		t.Errorf("SearchInstalled() = %v, want vim marked installed", got)
+	}
+}
@@ -1,7 +1,10 @@
 // Package manager provides the core abstraction for package managers across different operating systems.
 package manager
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // ManagerType represents the category of package manager.
 type ManagerType string
@@ -23,6 +26,79 @@ type Package struct {
 	Source      string `json:"source"`    // Manager name: "apt", "flatpak", etc.
 	Installed   bool   `json:"installed"` // Whether the package is currently installed
 	Size        string `json:"size"`      // Optional: download/install size
+
+	// Reason tells whether an installed package was asked for or pulled in
+	// as a dependency, when known
+	Reason InstallReason `json:"reason,omitempty"`
+
+	// Package relations, as pacman declares them. Entries may carry a
+	// version constraint, e.g. "java-runtime=17".
+	Provides  []string `json:"provides,omitempty"`  // Virtual packages it satisfies
+	Conflicts []string `json:"conflicts,omitempty"` // Packages it cannot be installed with
+	Replaces  []string `json:"replaces,omitempty"`  // Packages it supersedes
+
+	// Metadata holds source-specific details without a field of their own,
+	// keyed by the Meta constants
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Metadata keys set by the sources that know them.
+const (
+	MetaRepository = "repository" // Repository the package comes from, e.g. "extra"
+	MetaRemote     = "remote"     // Flatpak remote, e.g. "flathub"
+	MetaVotes      = "votes"      // AUR votes
+	MetaPopularity = "popularity" // AUR popularity
+	MetaAppName    = "app_name"   // Application name from AppStream, e.g. "Firefox"
+	MetaCategories = "categories" // Comma-separated AppStream categories
+	MetaSection    = "section"    // APT section, e.g. "libs" or "non-free/kernel"
+	MetaChannel    = "channel"    // Snap channel an installed snap tracks, e.g. "latest/stable"
+)
+
+// InstallReason is why an installed package is on the system.
+type InstallReason string
+
+const (
+	ReasonUnknown    InstallReason = ""           // Not tracked by the source
+	ReasonExplicit   InstallReason = "explicit"   // Installed at the user's request
+	ReasonDependency InstallReason = "dependency" // Pulled in by another package
+)
+
+// Badge returns the source qualified by the repository or remote the
+// package comes from, e.g. "pacman/extra" or "flatpak/flathub", or just the
+// source if neither is known.
+func (p Package) Badge() string {
+	if repo := p.Metadata[MetaRepository]; repo != "" {
+		return p.Source + "/" + repo
+	}
+	if remote := p.Metadata[MetaRemote]; remote != "" {
+		return p.Source + "/" + remote
+	}
+	return p.Source
+}
+
+// Relation reports how the package relates to the package called name:
+// "provides" or "replaces" it, or "" if it does neither.
+func (p Package) Relation(name string) string {
+	for _, provided := range p.Provides {
+		if strings.EqualFold(RelationName(provided), name) {
+			return "provides"
+		}
+	}
+	for _, replaced := range p.Replaces {
+		if strings.EqualFold(RelationName(replaced), name) {
+			return "replaces"
+		}
+	}
+	return ""
+}
+
+// RelationName strips the version constraint from a relation entry, so
+// "java-runtime>=17" becomes "java-runtime".
+func RelationName(entry string) string {
+	if i := strings.IndexAny(entry, "<>="); i >= 0 {
+		return entry[:i]
+	}
+	return entry
 }
 
 // PackageInfo contains detailed information about a package.
@@ -41,6 +117,18 @@ type InstallOpts struct {
 	AutoConfirm bool // Automatically confirm prompts
 	DryRun      bool // Show what would happen without executing
 	Reinstall   bool // Reinstall if already installed
+	Force       bool // Install despite safety checks, such as the AUR risk limit
+
+	// SandboxProfile overrides the sandbox profile builds run in (native AUR)
+	SandboxProfile string
+	// CleanChroot builds in a clean chroot even if not configured (native AUR)
+	CleanChroot bool
+	// Channel installs from a channel other than the default, e.g. "edge" or
+	// "insiders/stable" (Snap)
+	Channel string
+	// ExtraArgs are passed to the manager's tool as given, before the
+	// package names
+	ExtraArgs []string
 }
 
 // UninstallOpts contains options for package removal.
@@ -53,15 +141,26 @@ type UninstallOpts struct {
 
 // UpgradeOpts contains options for package upgrades.
 type UpgradeOpts struct {
-	AutoConfirm bool     // Automatically confirm prompts
-	DryRun      bool     // Show what would happen without executing
-	Packages    []string // Specific packages to upgrade (empty = upgrade all)
+	AutoConfirm   bool     // Automatically confirm prompts
+	DryRun        bool     // Show what would happen without executing
+	Packages      []string // Specific packages to upgrade (empty = upgrade all)
+	IncludePhased bool     // Include phased updates that would otherwise be deferred (APT)
+	Exclude       []string // Packages to leave out of a full upgrade (see UpgradeExcluder)
+
+	// SandboxProfile overrides the sandbox profile builds run in (native AUR)
+	SandboxProfile string
+	// CleanChroot builds in a clean chroot even if not configured (native AUR)
+	CleanChroot bool
+	// ExtraArgs are passed to the manager's tool as given, before the
+	// package names
+	ExtraArgs []string
 }
 
 // SearchOpts contains options for package search.
 type SearchOpts struct {
 	Limit         int  // Maximum number of results
 	InstalledOnly bool // Only show installed packages
+	AvailableOnly bool // Only show packages that are not installed
 	SearchInDesc  bool // Search in package descriptions too
 	ExactMatch    bool // Require exact name match
 }
@@ -79,3 +178,77 @@ type ListOpts struct {
 	Upgradable    bool   // Only show packages with available upgrades
 	Pattern       string // Filter by name pattern
 }
+
+// UpgradeCandidate describes an installed package with a newer version available.
+type UpgradeCandidate struct {
+	Name           string `json:"name"`
+	Source         string `json:"source"`
+	CurrentVersion string `json:"current_version"`
+	NewVersion     string `json:"new_version"`
+	Held           bool   `json:"held"`                  // Upgrade will not be applied by a normal upgrade
+	HeldReason     string `json:"held_reason,omitempty"` // Why the upgrade is held back
+}
+
+// ModuleStream describes a module stream such as nodejs:20 (DNF modularity).
+type ModuleStream struct {
+	Name      string   `json:"name"`
+	Stream    string   `json:"stream"`
+	Profiles  []string `json:"profiles,omitempty"`
+	Summary   string   `json:"summary,omitempty"`
+	Default   bool     `json:"default"`
+	Enabled   bool     `json:"enabled"`
+	Installed bool     `json:"installed"` // A profile of this stream is installed
+}
+
+// String returns the stream in name:stream form.
+func (m ModuleStream) String() string {
+	return m.Name + ":" + m.Stream
+}
+
+// Deployment is a bootable version of an image-based system, such as an
+// rpm-ostree deployment.
+type Deployment struct {
+	Checksum string    `json:"checksum"`          // Commit it was built from
+	Version  string    `json:"version,omitempty"` // Version of the image, e.g. "40.20240501.0"
+	Origin   string    `json:"origin,omitempty"`  // Ref or container image it follows
+	Time     time.Time `json:"time"`              // When the image was built
+	Booted   bool      `json:"booted"`
+	Staged   bool      `json:"staged"`            // Prepared since booting, for the next boot
+	Pinned   bool      `json:"pinned"`            // Kept when newer deployments are cleaned up
+	Layered  []string  `json:"layered,omitempty"` // Packages layered onto the image
+	Removed  []string  `json:"removed,omitempty"` // Packages of the image overridden away
+}
+
+// PendingDeployment returns the deployment the next boot switches to, or
+// nil if it boots the running one again. deployments are in boot order.
+func PendingDeployment(deployments []Deployment) *Deployment {
+	if len(deployments) == 0 || deployments[0].Booted {
+		return nil
+	}
+	return &deployments[0]
+}
+
+// Match scores of a Resolution, best first.
+const (
+	MatchExact    = 0 // Same name, or a known mapping
+	MatchSuffix   = 1 // Name followed by a separator, e.g. "spotify-bin"
+	MatchPrefix   = 2 // Name followed by anything, e.g. "spotifyd"
+	MatchProvides = 3 // Provides the requested virtual package
+)
+
+// Resolution records why a source was chosen for a requested package, so
+// the decision can be reconstructed later.
+type Resolution struct {
+	Requested string `json:"requested"`         // Name as given by the user
+	Alias     string `json:"alias,omitempty"`   // Configured alias it expanded to
+	Mapping   string `json:"mapping,omitempty"` // Canonical name of the package mapping used
+	Source    string `json:"source"`            // Manager chosen
+	Name      string `json:"name"`              // Package name in that source
+	Score     int    `json:"score"`             // MatchExact, MatchSuffix or MatchPrefix
+	Reason    string `json:"reason"`            // Human-readable summary
+}
+
+// Key returns the source/name pair the resolution installed.
+func (r Resolution) Key() string {
+	return r.Source + "/" + r.Name
+}
@@ -103,3 +103,69 @@ func TestSearchOpts(t *testing.T) {
 		t.Error("expected SearchInDesc to be true")
 	}
 }
+
+func TestRelationName(t *testing.T) {
+	tests := map[string]string{
+		"java-runtime":    "java-runtime",
+		"java-runtime=17": "java-runtime",
+		"libfoo.so>=1-64": "libfoo.so",
+		"python<3.13":     "python",
+		"":                "",
+	}
+	for entry, want := range tests {
+		if got := RelationName(entry); got != want {
+			t.Errorf("RelationName(%q) = %q, want %q", entry, got, want)
+		}
+	}
+}
+
+func TestPackageRelation(t *testing.T) {
+	pkg := Package{
+		Name:     "jdk-temurin",
+		Provides: []string{"java-runtime=21", "java-environment=21"},
+		Replaces: []string{"jdk-adoptopenjdk"},
+	}
+
+	if got := pkg.Relation("java-runtime"); got != "provides" {
+		t.Errorf("Relation(java-runtime) = %q, want provides", got)
+	}
+	if got := pkg.Relation("jdk-adoptopenjdk"); got != "replaces" {
+		t.Errorf("Relation(jdk-adoptopenjdk) = %q, want replaces", got)
+	}
+	if got := pkg.Relation("java"); got != "" {
+		t.Errorf("Relation(java) = %q, want none", got)
+	}
+}
+
+func TestPackageBadge(t *testing.T) {
+	tests := []struct {
+		pkg  Package
+		want string
+	}{
+		{Package{Source: "apt"}, "apt"},
+		{Package{Source: "pacman", Metadata: map[string]string{MetaRepository: "extra"}}, "pacman/extra"},
+		{Package{Source: "flatpak", Metadata: map[string]string{MetaRemote: "flathub"}}, "flatpak/flathub"},
+		{Package{Source: "aur", Metadata: map[string]string{MetaVotes: "12"}}, "aur"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.pkg.Badge(); got != tt.want {
+			t.Errorf("Badge() of %v = %q, want %q", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestPendingDeployment(t *testing.T) {
+	booted := Deployment{Checksum: "9f8e7d", Booted: true}
+	staged := Deployment{Checksum: "1a2b3c", Staged: true}
+
+	if got := PendingDeployment([]Deployment{booted}); got != nil {
+		t.Errorf("PendingDeployment() = %+v, want nil when the booted deployment boots next", got)
+	}
+	if got := PendingDeployment([]Deployment{staged, booted}); got == nil || got.Checksum != "1a2b3c" {
+		t.Errorf("PendingDeployment() = %+v, want the staged deployment", got)
+	}
+	if got := PendingDeployment(nil); got != nil {
+		t.Errorf("PendingDeployment(nil) = %+v, want nil", got)
+	}
+}
@@ -94,6 +94,15 @@ func (a *AUR) NeedsSudo() bool {
 	return false
 }
 
+// Version returns the installed AUR helper version.
+func (a *AUR) Version(ctx context.Context) (string, error) {
+	output, err := a.exec.OutputQuiet(ctx, a.binary, "--version")
+	if err != nil {
+		return "", err
+	}
+	return manager.ExtractVersion(output), nil
+}
+
 // Install installs one or more AUR packages.
 func (a *AUR) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	args := []string{"-S"}
@@ -102,6 +111,7 @@ func (a *AUR) Install(ctx context.Context, packages []string, opts manager.Insta
 		args = append(args, "--noconfirm")
 	}
 
+	args = append(args, opts.ExtraArgs...)
 	args = append(args, packages...)
 
 	if opts.DryRun {
@@ -146,12 +156,14 @@ func (a *AUR) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	if opts.AutoConfirm {
 		args = append(args, "--noconfirm")
 	}
+	args = append(args, opts.ExtraArgs...)
 
 	if len(opts.Packages) > 0 {
 		args = []string{"-S"}
 		if opts.AutoConfirm {
 			args = append(args, "--noconfirm")
 		}
+		args = append(args, opts.ExtraArgs...)
 		args = append(args, opts.Packages...)
 	}
 
@@ -219,6 +231,18 @@ func (a *AUR) searchInstalled(ctx context.Context, query string, opts manager.Se
 	return packages, nil
 }
 
+// parseAURSearchStats parses the votes and popularity a helper prints after
+// the version, e.g. "(+1520 12.34)". It returns nil if they are missing.
+func parseAURSearchStats(fields []string) map[string]string {
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "(+") {
+		return nil
+	}
+	return map[string]string{
+		manager.MetaVotes:      strings.TrimPrefix(fields[0], "(+"),
+		manager.MetaPopularity: strings.TrimSuffix(fields[1], ")"),
+	}
+}
+
 // parseSearchOutput parses AUR helper search output.
 func (a *AUR) parseSearchOutput(output string, limit int) []manager.Package {
 	var packages []manager.Package
@@ -255,6 +279,7 @@ func (a *AUR) parseSearchOutput(output string, limit int) []manager.Package {
 				Version:     version,
 				Description: description,
 				Source:      "aur",
+				Metadata:    parseAURSearchStats(parts[2:]),
 			})
 
 			if limit > 0 && len(packages) >= limit {
@@ -363,6 +388,16 @@ func (a *AUR) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]manag
 	return packages, nil
 }
 
+// ListExplicit returns the explicitly installed AUR packages (-Qme).
+func (a *AUR) ListExplicit(ctx context.Context) ([]string, error) {
+	// pacman exits non-zero when no package matches
+	output, err := a.exec.OutputQuiet(ctx, a.binary, "-Qqme")
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Fields(output), nil
+}
+
 // IsInstalled checks if an AUR package is installed.
 func (a *AUR) IsInstalled(ctx context.Context, pkg string) (bool, error) {
 	err := a.exec.Run(ctx, a.binary, "-Qi", pkg)
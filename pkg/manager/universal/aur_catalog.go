@@ -0,0 +1,130 @@
+package universal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"poxy/pkg/aur"
+	"poxy/pkg/manager"
+)
+
+// ListCatalog returns every AUR package from the AUR's metadata dump, with
+// the installed ones and their relations from the local database.
+func (a *NativeAUR) ListCatalog(ctx context.Context) ([]manager.Package, error) {
+	// pacman exits non-zero when there are no foreign packages
+	var installed []manager.Package
+	if output, err := a.exec.OutputQuiet(ctx, "pacman", "-Qmi"); err == nil {
+		installed = parseInstalledRecords(output, "aur")
+	}
+	return aurCatalog(ctx, a.client, installed)
+}
+
+// FindProviders returns the AUR packages that provide name.
+func (a *NativeAUR) FindProviders(ctx context.Context, name string) ([]manager.Package, error) {
+	return findAURProviders(ctx, a.client, name)
+}
+
+// ListCatalog returns every AUR package from the AUR's metadata dump, with
+// the installed ones and their relations from the local database.
+func (a *AUR) ListCatalog(ctx context.Context) ([]manager.Package, error) {
+	var installed []manager.Package
+	if output, err := a.exec.OutputQuiet(ctx, a.binary, "-Qmi"); err == nil {
+		installed = parseInstalledRecords(output, "aur")
+	}
+	return aurCatalog(ctx, aur.NewClient(), installed)
+}
+
+// aurCatalog lists the packages of the AUR metadata dump, replacing those
+// installed with their installed records, which have relations. Installed
+// packages no longer in the AUR are kept.
+func aurCatalog(ctx context.Context, client *aur.Client, installed []manager.Package) ([]manager.Package, error) {
+	dump, err := client.Dump(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]manager.Package, len(installed))
+	for _, pkg := range installed {
+		byName[pkg.Name] = pkg
+	}
+
+	packages := make([]manager.Package, 0, len(dump)+len(installed))
+	for _, pkg := range dump {
+		entry := manager.Package{
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Description: pkg.Description,
+			Source:      "aur",
+		}
+		if local, ok := byName[pkg.Name]; ok {
+			entry = local
+			delete(byName, pkg.Name)
+		}
+		entry.Metadata = map[string]string{
+			manager.MetaVotes:      strconv.Itoa(pkg.NumVotes),
+			manager.MetaPopularity: strconv.FormatFloat(pkg.Popularity, 'f', 2, 64),
+		}
+		packages = append(packages, entry)
+	}
+	for _, pkg := range installed {
+		if _, ok := byName[pkg.Name]; ok {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// FindProviders returns the AUR packages that provide name. Neither yay
+// nor paru can search by provides, so the AUR is queried directly.
+func (a *AUR) FindProviders(ctx context.Context, name string) ([]manager.Package, error) {
+	return findAURProviders(ctx, aur.NewClient(), name)
+}
+
+// findAURProviders queries the AUR for the packages that provide name.
+func findAURProviders(ctx context.Context, client *aur.Client, name string) ([]manager.Package, error) {
+	results, err := client.SearchByProvides(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []manager.Package
+	for _, pkg := range results {
+		provider := manager.Package{
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Description: pkg.Description,
+			Source:      "aur",
+			Provides:    pkg.Provides,
+			Conflicts:   pkg.Conflicts,
+			Replaces:    pkg.Replaces,
+		}
+		// The AUR also matches packages named name, which are not providers
+		if provider.Relation(name) == "provides" {
+			providers = append(providers, provider)
+		}
+	}
+	return providers, nil
+}
+
+// parseInstalledRecords parses pacman -Qi output listing several installed
+// packages, one blank-line separated record each.
+func parseInstalledRecords(output, source string) []manager.Package {
+	var packages []manager.Package
+	for _, record := range strings.Split(output, "\n\n") {
+		info := parsePackageInfo(record, source)
+		if info.Name != "" {
+			info.Installed = true
+			packages = append(packages, info.Package)
+		}
+	}
+	return packages
+}
+
+// relationList parses a pacman relation field, which is "None" when empty.
+func relationList(value string) []string {
+	if value == "None" {
+		return nil
+	}
+	return strings.Fields(value)
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 
 	"poxy/internal/executor"
@@ -21,6 +23,15 @@ type NativeAUR struct {
 	builder        *aur.Builder
 	exec           *executor.Executor
 	reviewPKGBUILD bool
+	approvals      *aur.ApprovalStore
+	maxRisk        int
+	sandboxProfile string
+	offlineBuild   bool
+	cleanChroot    bool
+	chrootDir      string
+	repo           *aur.LocalRepo
+	prebuilt       *aur.RemoteRepo
+	keyserver      string
 }
 
 // NewNativeAUR creates a new native AUR manager.
@@ -35,6 +46,51 @@ func NewNativeAUR(reviewPKGBUILD bool) *NativeAUR {
 	}
 }
 
+// SetApprovals enables audit mode, where each PKGBUILD revision must be
+// approved through review before it is built.
+func (a *NativeAUR) SetApprovals(store *aur.ApprovalStore) {
+	a.approvals = store
+}
+
+// SetMaxRisk refuses to build PKGBUILDs whose risk score is above score,
+// unless installed with InstallOpts.Force. 0 disables the check.
+func (a *NativeAUR) SetMaxRisk(score int) {
+	a.maxRisk = score
+}
+
+// SetSandboxProfile builds in the named sandbox profile unless an
+// operation overrides it. Empty uses the default build sandbox.
+func (a *NativeAUR) SetSandboxProfile(name string) {
+	a.sandboxProfile = name
+}
+
+// SetOfflineBuild downloads sources before building and builds without
+// network access.
+func (a *NativeAUR) SetOfflineBuild(enabled bool) {
+	a.offlineBuild = enabled
+}
+
+// SetCleanChroot builds in a clean chroot kept in dir unless an operation
+// asks for it. Empty dir uses the builder's default.
+func (a *NativeAUR) SetCleanChroot(enabled bool, dir string) {
+	a.cleanChroot = enabled
+	a.chrootDir = dir
+}
+
+// SetRepo adds every package built to repo, if not nil, and installs the
+// packages prebuilt has at their current version instead of building
+// them, if not nil.
+func (a *NativeAUR) SetRepo(repo *aur.LocalRepo, prebuilt *aur.RemoteRepo) {
+	a.repo = repo
+	a.prebuilt = prebuilt
+}
+
+// SetKeyserver looks up and imports the unknown PGP keys builds report
+// from keyserver. Empty uses aur.DefaultKeyserver.
+func (a *NativeAUR) SetKeyserver(keyserver string) {
+	a.keyserver = keyserver
+}
+
 // Name returns the short identifier.
 func (a *NativeAUR) Name() string {
 	return a.name
@@ -68,17 +124,18 @@ func (a *NativeAUR) NeedsSudo() bool {
 	return false
 }
 
-// Install builds and installs one or more AUR packages.
-func (a *NativeAUR) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
-	buildOpts := aur.DefaultBuildOptions()
-	buildOpts.NoConfirm = opts.AutoConfirm
-	buildOpts.ReviewPKGBUILD = a.reviewPKGBUILD && !opts.AutoConfirm
-
-	if a.reviewPKGBUILD && !opts.AutoConfirm {
-		buildOpts.OnReview = aur.CreateReviewCallback(true)
+// Version returns the installed makepkg version used for native builds.
+func (a *NativeAUR) Version(ctx context.Context) (string, error) {
+	output, err := a.exec.OutputQuiet(ctx, "makepkg", "--version")
+	if err != nil {
+		return "", err
 	}
+	return manager.ExtractVersion(output), nil
+}
 
-	a.builder.SetOptions(buildOpts)
+// Install builds and installs one or more AUR packages.
+func (a *NativeAUR) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
+	a.configureBuilder(opts.AutoConfirm, opts.Force, opts.SandboxProfile, opts.CleanChroot, opts.ExtraArgs)
 
 	if opts.DryRun {
 		fmt.Printf("Would build and install from AUR: %s\n", strings.Join(packages, ", "))
@@ -94,6 +151,44 @@ func (a *NativeAUR) Install(ctx context.Context, packages []string, opts manager
 	return nil
 }
 
+// configureBuilder sets the build options shared by installs and upgrades,
+// prompting for PKGBUILD and install scriptlet review, and for importing
+// unknown PGP keys, unless autoConfirm is set. force builds PKGBUILDs above
+// the risk limit. sandboxProfile overrides the configured sandbox profile
+// when set, and cleanChroot builds in a clean chroot even if not configured.
+// makepkgArgs are passed on to makepkg.
+func (a *NativeAUR) configureBuilder(autoConfirm, force bool, sandboxProfile string, cleanChroot bool, makepkgArgs []string) {
+	buildOpts := aur.DefaultBuildOptions()
+	buildOpts.NoConfirm = autoConfirm
+	buildOpts.ReviewPKGBUILD = a.reviewPKGBUILD && !autoConfirm
+
+	if !autoConfirm {
+		// One reviewer, so all prompts read stdin through the same buffer
+		reviewer := aur.NewReviewer()
+		if a.reviewPKGBUILD || a.approvals != nil {
+			buildOpts.OnReview = reviewer.Review
+			buildOpts.OnScriptletReview = reviewer.ReviewScriptlets
+		}
+		buildOpts.OnUnknownKeys = reviewer.ReviewKeys
+	}
+	buildOpts.Keyserver = a.keyserver
+	buildOpts.Approvals = a.approvals
+	buildOpts.MaxRisk = a.maxRisk
+	buildOpts.IgnoreRisk = force
+	buildOpts.OfflineBuild = a.offlineBuild
+	buildOpts.CleanChroot = a.cleanChroot || cleanChroot
+	buildOpts.ChrootDir = a.chrootDir
+	buildOpts.MakepkgArgs = makepkgArgs
+	buildOpts.Repo = a.repo
+	buildOpts.Prebuilt = a.prebuilt
+	buildOpts.SandboxProfile = a.sandboxProfile
+	if sandboxProfile != "" {
+		buildOpts.SandboxProfile = sandboxProfile
+	}
+
+	a.builder.SetOptions(buildOpts)
+}
+
 // Uninstall removes one or more packages using pacman.
 func (a *NativeAUR) Uninstall(ctx context.Context, packages []string, opts manager.UninstallOpts) error {
 	args := []string{"-R"}
@@ -118,25 +213,162 @@ func (a *NativeAUR) Uninstall(ctx context.Context, packages []string, opts manag
 
 // Update refreshes the package database.
 func (a *NativeAUR) Update(ctx context.Context) error {
+	if cache := a.client.Cache(); cache != nil {
+		_ = cache.Clear() //nolint:errcheck // Stale answers only expire later
+	}
 	return a.exec.RunSudo(ctx, "pacman", "-Sy")
 }
 
-// Upgrade upgrades all packages including AUR.
+// PrefetchInfo looks pkgs up in batched requests, so the Info calls that
+// follow are answered from the RPC cache. Without a cache it does nothing.
+func (a *NativeAUR) PrefetchInfo(ctx context.Context, pkgs []string) error {
+	if a.client.Cache() == nil {
+		return nil
+	}
+	_, err := a.client.InfoAll(ctx, pkgs)
+	return err
+}
+
+// Upgrade upgrades repository packages with pacman, then rebuilds the AUR
+// packages that have a newer version in the AUR. With opts.Packages only
+// those AUR packages are rebuilt.
 func (a *NativeAUR) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
-	// For AUR upgrades, we'd need to compare installed versions with AUR
-	// For now, just upgrade official packages via pacman
-	args := []string{"-Syu"}
+	if len(opts.Packages) == 0 {
+		args := []string{"-Syu"}
+		if opts.AutoConfirm {
+			args = append(args, "--noconfirm")
+		}
+		if len(opts.Exclude) > 0 {
+			args = append(args, "--ignore", strings.Join(opts.Exclude, ","))
+		}
 
-	if opts.AutoConfirm {
-		args = append(args, "--noconfirm")
+		if opts.DryRun {
+			fmt.Printf("Would run: sudo pacman %s\n", strings.Join(args, " "))
+		} else if err := a.exec.RunSudo(ctx, "pacman", args...); err != nil {
+			return err
+		}
+	}
+
+	upgrades, err := a.outdated(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check AUR for upgrades: %w", err)
+	}
+	upgrades = filterAURUpgrades(upgrades, opts.Packages, opts.Exclude)
+	if len(upgrades) == 0 {
+		return nil
 	}
 
 	if opts.DryRun {
-		fmt.Printf("Would run: sudo pacman %s\n", strings.Join(args, " "))
+		for _, u := range upgrades {
+			fmt.Printf("Would rebuild from AUR: %s %s -> %s\n", u.installed.Name, u.installed.Version, u.latest.Version)
+		}
 		return nil
 	}
 
-	return a.exec.RunSudo(ctx, "pacman", args...)
+	a.configureBuilder(opts.AutoConfirm, false, opts.SandboxProfile, opts.CleanChroot, opts.ExtraArgs)
+
+	// Split packages share a package base, which builds them all at once
+	built := make(map[string]bool)
+	for i, u := range upgrades {
+		if built[u.latest.PackageBase] {
+			continue
+		}
+		built[u.latest.PackageBase] = true
+
+		fmt.Printf(":: (%d/%d) Upgrading %s %s -> %s\n", i+1, len(upgrades), u.installed.Name, u.installed.Version, u.latest.Version)
+		if err := a.builder.BuildAndInstall(ctx, u.installed.Name); err != nil {
+			return fmt.Errorf("failed to upgrade %s: %w", u.installed.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ExcludesUpgrades reports that upgrades honor UpgradeOpts.Exclude, for
+// both pacman and AUR packages.
+func (a *NativeAUR) ExcludesUpgrades() bool {
+	return true
+}
+
+// ListUpgradable returns installed AUR packages with a newer version in the
+// AUR.
+func (a *NativeAUR) ListUpgradable(ctx context.Context) ([]manager.UpgradeCandidate, error) {
+	upgrades, err := a.outdated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]manager.UpgradeCandidate, 0, len(upgrades))
+	for _, u := range upgrades {
+		candidates = append(candidates, manager.UpgradeCandidate{
+			Name:           u.installed.Name,
+			Source:         a.name,
+			CurrentVersion: u.installed.Version,
+			NewVersion:     u.latest.Version,
+		})
+	}
+
+	return candidates, nil
+}
+
+// aurUpgrade pairs an installed AUR package with its newer AUR version.
+type aurUpgrade struct {
+	installed manager.Package
+	latest    aur.Package
+}
+
+// outdated looks up every installed foreign package in the AUR and returns
+// those whose AUR version is newer, compared the way pacman's vercmp does.
+// Foreign packages that are not in the AUR are skipped.
+func (a *NativeAUR) outdated(ctx context.Context) ([]aurUpgrade, error) {
+	installed, err := a.ListInstalled(ctx, manager.ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	if len(installed) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(installed))
+	for _, pkg := range installed {
+		names = append(names, pkg.Name)
+	}
+
+	found, err := a.client.InfoAll(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]aur.Package, len(found))
+	for _, pkg := range found {
+		latest[pkg.Name] = pkg
+	}
+
+	var upgrades []aurUpgrade
+	for _, pkg := range installed {
+		remote, ok := latest[pkg.Name]
+		if !ok || manager.CompareVersions(remote.Version, pkg.Version) <= 0 {
+			continue
+		}
+		upgrades = append(upgrades, aurUpgrade{installed: pkg, latest: remote})
+	}
+
+	return upgrades, nil
+}
+
+// filterAURUpgrades keeps the upgrades of the named packages, or all of them
+// if none are named, minus the excluded ones.
+func filterAURUpgrades(upgrades []aurUpgrade, only, exclude []string) []aurUpgrade {
+	var filtered []aurUpgrade
+	for _, u := range upgrades {
+		if len(only) > 0 && !slices.Contains(only, u.installed.Name) {
+			continue
+		}
+		if slices.Contains(exclude, u.installed.Name) {
+			continue
+		}
+		filtered = append(filtered, u)
+	}
+	return filtered
 }
 
 // Search finds packages matching the query in the AUR.
@@ -162,6 +394,10 @@ func (a *NativeAUR) Search(ctx context.Context, query string, opts manager.Searc
 			Version:     pkg.Version,
 			Description: pkg.Description,
 			Source:      "aur",
+			Metadata: map[string]string{
+				manager.MetaVotes:      strconv.Itoa(pkg.NumVotes),
+				manager.MetaPopularity: strconv.FormatFloat(pkg.Popularity, 'f', 2, 64),
+			},
 		})
 	}
 
@@ -219,6 +455,9 @@ func (a *NativeAUR) Info(ctx context.Context, pkg string) (*manager.PackageInfo,
 				Version:     aurPkg.Version,
 				Description: aurPkg.Description,
 				Source:      "aur",
+				Provides:    aurPkg.Provides,
+				Conflicts:   aurPkg.Conflicts,
+				Replaces:    aurPkg.Replaces,
 			},
 			URL:        aurPkg.URL,
 			Maintainer: aurPkg.Maintainer,
@@ -275,6 +514,16 @@ func (a *NativeAUR) ListInstalled(ctx context.Context, opts manager.ListOpts) ([
 	return packages, nil
 }
 
+// ListExplicit returns the explicitly installed AUR packages (pacman -Qme).
+func (a *NativeAUR) ListExplicit(ctx context.Context) ([]string, error) {
+	// pacman exits non-zero when no package matches
+	output, err := a.exec.OutputQuiet(ctx, "pacman", "-Qqme")
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Fields(output), nil
+}
+
 // IsInstalled checks if an AUR package is installed.
 func (a *NativeAUR) IsInstalled(ctx context.Context, pkg string) (bool, error) {
 	err := a.exec.Run(ctx, "pacman", "-Qi", pkg)
@@ -344,6 +593,12 @@ func parsePackageInfo(output, source string) *manager.PackageInfo {
 			info.Maintainer = value
 		case "Installed Size":
 			info.Size = value
+		case "Provides":
+			info.Provides = relationList(value)
+		case "Conflicts With":
+			info.Conflicts = relationList(value)
+		case "Replaces":
+			info.Replaces = relationList(value)
 		}
 	}
 
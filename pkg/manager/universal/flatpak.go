@@ -18,6 +18,7 @@ type Flatpak struct {
 	displayName   string
 	binary        string
 	defaultRemote string
+	scope         manager.Scope // "" leaves it to flatpak, which installs system-wide
 	exec          *executor.Executor
 }
 
@@ -61,10 +62,36 @@ func (f *Flatpak) NeedsSudo() bool {
 	return false // User-level installations don't need sudo
 }
 
+// InstallScope returns whether apps are installed for the current user
+// (flatpak --user) or system-wide.
+func (f *Flatpak) InstallScope() manager.Scope {
+	if f.scope == manager.ScopeUser {
+		return manager.ScopeUser
+	}
+	return manager.ScopeSystem
+}
+
+// SetInstallScope makes installs use the user or the system installation.
+func (f *Flatpak) SetInstallScope(scope manager.Scope) {
+	f.scope = scope
+}
+
+// Version returns the installed flatpak version.
+func (f *Flatpak) Version(ctx context.Context) (string, error) {
+	output, err := f.exec.OutputQuiet(ctx, f.binary, "--version")
+	if err != nil {
+		return "", err
+	}
+	return manager.ExtractVersion(output), nil
+}
+
 // Install installs one or more Flatpak applications.
 func (f *Flatpak) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	for _, pkg := range packages {
 		args := []string{"install"}
+		if f.scope != "" {
+			args = append(args, "--"+string(f.scope))
+		}
 
 		if opts.AutoConfirm {
 			args = append(args, "-y")
@@ -75,6 +102,7 @@ func (f *Flatpak) Install(ctx context.Context, packages []string, opts manager.I
 			args = append(args, f.defaultRemote)
 		}
 
+		args = append(args, opts.ExtraArgs...)
 		args = append(args, pkg)
 
 		if opts.DryRun {
@@ -130,6 +158,8 @@ func (f *Flatpak) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 		args = append(args, "-y")
 	}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
@@ -153,7 +183,11 @@ func (f *Flatpak) Search(ctx context.Context, query string, opts manager.SearchO
 		return []manager.Package{}, nil
 	}
 
-	return f.parseSearchOutput(output, opts.Limit), nil
+	packages := f.parseSearchOutput(output, opts.Limit)
+	if !strings.HasPrefix(strings.TrimSpace(output), "No matches found") {
+		manager.CheckParsed("flatpak", "flatpak search", output, len(packages))
+	}
+	return packages, nil
 }
 
 // searchInstalled searches installed applications.
@@ -229,12 +263,16 @@ func (f *Flatpak) parseSearchOutput(output string, limit int) []manager.Package
 			version = fields[3]
 		}
 
-		packages = append(packages, manager.Package{
+		pkg := manager.Package{
 			Name:        appID,
 			Version:     version,
 			Description: name + ": " + description,
 			Source:      "flatpak",
-		})
+		}
+		if len(fields) > 4 && fields[4] != "" {
+			pkg.Metadata = map[string]string{manager.MetaRemote: fields[4]}
+		}
+		packages = append(packages, pkg)
 
 		if limit > 0 && len(packages) >= limit {
 			break
@@ -338,6 +376,9 @@ func (f *Flatpak) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]m
 		}
 	}
 
+	if opts.Pattern == "" {
+		manager.CheckParsed("flatpak", "flatpak list", output, len(packages))
+	}
 	return packages, nil
 }
 
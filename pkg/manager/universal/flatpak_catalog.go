@@ -0,0 +1,64 @@
+package universal
+
+import (
+	"context"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ListCatalog returns the applications of every configured remote
+// (flatpak remote-ls --app), marking the installed ones.
+func (f *Flatpak) ListCatalog(ctx context.Context) ([]manager.Package, error) {
+	output, err := f.exec.OutputQuiet(ctx, f.binary, "remote-ls", "--app", "--columns=application,version,name,description,origin")
+	if err != nil {
+		return nil, err
+	}
+	packages := parseRemoteLs(output)
+	if strings.TrimSpace(output) != "" {
+		manager.CheckParsed("flatpak", "flatpak remote-ls", output, len(packages))
+	}
+
+	installed := make(map[string]bool)
+	if output, err := f.exec.OutputQuiet(ctx, f.binary, "list", "--app", "--columns=application"); err == nil {
+		for _, appID := range strings.Fields(output) {
+			installed[appID] = true
+		}
+	}
+	for i := range packages {
+		packages[i].Installed = installed[packages[i].Name]
+	}
+
+	return packages, nil
+}
+
+// parseRemoteLs parses flatpak remote-ls output with the columns
+// application, version, name, description and origin. An application in
+// several remotes is kept once, from the first remote listed.
+func parseRemoteLs(output string) []manager.Package {
+	var packages []manager.Package
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 || fields[0] == "" || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+
+		pkg := manager.Package{
+			Name:        fields[0],
+			Version:     fields[1],
+			Description: fields[2],
+			Source:      "flatpak",
+		}
+		if fields[3] != "" {
+			pkg.Description = fields[2] + ": " + fields[3]
+		}
+		if fields[4] != "" {
+			pkg.Metadata = map[string]string{manager.MetaRemote: fields[4]}
+		}
+		packages = append(packages, pkg)
+	}
+	return packages
+}
@@ -0,0 +1,175 @@
+package universal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// Permissions returns the sandbox permissions of an installed app, with
+// overrides applied, and the current user's overrides.
+func (f *Flatpak) Permissions(ctx context.Context, pkg string) (*manager.Permissions, error) {
+	output, err := f.exec.OutputQuiet(ctx, f.binary, "info", "--show-permissions", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an installed Flatpak app", pkg)
+	}
+	perms := parsePermissions(output)
+
+	// Apps without overrides print nothing
+	overrides, err := f.exec.OutputQuiet(ctx, f.binary, "override", "--user", "--show", pkg)
+	if err == nil {
+		if o := parsePermissions(overrides); !o.Empty() {
+			perms.Overrides = o
+		}
+	}
+	return perms, nil
+}
+
+// OverridePermissions grants and revokes permissions of an app with
+// flatpak override, for the current user.
+func (f *Flatpak) OverridePermissions(ctx context.Context, pkg string, overrides []manager.PermissionOverride, dryRun bool) error {
+	args := []string{"override", "--user"}
+	for _, o := range overrides {
+		arg, err := overrideArg(o)
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+	args = append(args, pkg)
+
+	return f.override(ctx, args, dryRun)
+}
+
+// ResetPermissions removes the current user's overrides of an app.
+func (f *Flatpak) ResetPermissions(ctx context.Context, pkg string, dryRun bool) error {
+	args := []string{"override", "--user", "--reset", pkg}
+	return f.override(ctx, args, dryRun)
+}
+
+// override runs flatpak with args, returning its messages with the error
+// so they are not lost in the TUI.
+func (f *Flatpak) override(ctx context.Context, args []string, dryRun bool) error {
+	if dryRun {
+		fmt.Printf("Would run: %s %s\n", f.binary, strings.Join(args, " "))
+		return nil
+	}
+	if output, err := f.exec.OutputCombined(ctx, f.binary, args...); err != nil {
+		if msg := strings.TrimSpace(output); msg != "" {
+			return fmt.Errorf("flatpak override failed: %s", msg)
+		}
+		return fmt.Errorf("flatpak override failed: %w", err)
+	}
+	return nil
+}
+
+// overrideFlags are the flatpak override options granting and revoking
+// each kind of permission.
+var overrideFlags = map[manager.PermissionKind][2]string{
+	manager.PermShare:          {"--share", "--unshare"},
+	manager.PermSocket:         {"--socket", "--nosocket"},
+	manager.PermDevice:         {"--device", "--nodevice"},
+	manager.PermFilesystem:     {"--filesystem", "--nofilesystem"},
+	manager.PermFeature:        {"--allow", "--disallow"},
+	manager.PermTalkName:       {"--talk-name", "--no-talk-name"},
+	manager.PermOwnName:        {"--own-name", "--no-talk-name"},
+	manager.PermSystemTalkName: {"--system-talk-name", "--system-no-talk-name"},
+	manager.PermSystemOwnName:  {"--system-own-name", "--system-no-talk-name"},
+	manager.PermEnv:            {"--env", "--unset-env"},
+}
+
+// overrideArg returns the flatpak override option applying o.
+func overrideArg(o manager.PermissionOverride) (string, error) {
+	flags, ok := overrideFlags[o.Kind]
+	if !ok {
+		return "", fmt.Errorf("unknown permission kind %q", o.Kind)
+	}
+	if !o.Grant {
+		value := o.Value
+		if o.Kind == manager.PermEnv {
+			value, _, _ = strings.Cut(value, "=")
+		}
+		return flags[1] + "=" + value, nil
+	}
+	if o.Kind == manager.PermEnv && !strings.Contains(o.Value, "=") {
+		return "", fmt.Errorf("env overrides need a value: +env=VAR=value")
+	}
+	return flags[0] + "=" + o.Value, nil
+}
+
+// parsePermissions parses the keyfile flatpak info --show-permissions and
+// flatpak override --show print:
+//
+//	[Context]
+//	shared=network;ipc;
+//	filesystems=xdg-download;~/Games:ro;
+//
+//	[Session Bus Policy]
+//	org.freedesktop.Notifications=talk
+func parsePermissions(output string) *manager.Permissions {
+	perms := &manager.Permissions{}
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch section {
+		case "Context":
+			list := splitList(value)
+			switch key {
+			case "shared":
+				perms.Shared = list
+			case "sockets":
+				perms.Sockets = list
+			case "devices":
+				perms.Devices = list
+			case "filesystems":
+				perms.Filesystems = list
+			case "features":
+				perms.Features = list
+			}
+		case "Session Bus Policy":
+			perms.SessionBus = setEntry(perms.SessionBus, key, value)
+		case "System Bus Policy":
+			perms.SystemBus = setEntry(perms.SystemBus, key, value)
+		case "Environment":
+			perms.Environment = setEntry(perms.Environment, key, value)
+		}
+	}
+	return perms
+}
+
+// splitList splits a keyfile list such as "network;ipc;".
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// setEntry sets key in m, creating m if needed.
+func setEntry(m map[string]string, key, value string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[key] = value
+	return m
+}
@@ -0,0 +1,80 @@
+package universal
+
+import (
+	"context"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// ListRepos returns the Flatpak remotes of the user and system
+// installations, including disabled ones.
+func (f *Flatpak) ListRepos(ctx context.Context) ([]manager.Repository, error) {
+	output, err := f.exec.OutputQuiet(ctx, f.binary, "remotes", "--show-disabled", "--columns=name,url,options")
+	if err != nil {
+		return nil, err
+	}
+	return parseFlatpakRemoteList(output), nil
+}
+
+// EnableRepo enables a disabled remote.
+func (f *Flatpak) EnableRepo(ctx context.Context, name string, dryRun bool) error {
+	return f.remoteCommand(ctx, dryRun, "remote-modify", "--enable", name)
+}
+
+// DisableRepo disables a remote, keeping its configuration and the apps
+// installed from it.
+func (f *Flatpak) DisableRepo(ctx context.Context, name string, dryRun bool) error {
+	return f.remoteCommand(ctx, dryRun, "remote-modify", "--disable", name)
+}
+
+// AddRepo adds a remote from a .flatpakrepo URL, such as
+// https://dl.flathub.org/repo/flathub.flatpakrepo.
+func (f *Flatpak) AddRepo(ctx context.Context, name, url string, dryRun bool) error {
+	return f.remoteCommand(ctx, dryRun, "remote-add", "--if-not-exists", name, url)
+}
+
+// remoteCommand runs a remote subcommand in the installation installs use.
+func (f *Flatpak) remoteCommand(ctx context.Context, dryRun bool, command string, args ...string) error {
+	if dryRun {
+		f.exec.SetDryRun(true)
+		defer f.exec.SetDryRun(false)
+	}
+
+	cmdArgs := []string{command}
+	if f.scope != "" {
+		cmdArgs = append(cmdArgs, "--"+string(f.scope))
+	}
+	return f.exec.Run(ctx, f.binary, append(cmdArgs, args...)...)
+}
+
+// parseFlatpakRemoteList parses flatpak remotes --columns=name,url,options
+// output, whose options are a comma-separated list such as
+// "system,disabled".
+func parseFlatpakRemoteList(output string) []manager.Repository {
+	var repos []manager.Repository
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		name := strings.TrimSpace(fields[0])
+		if name == "" || name == "Name" {
+			continue
+		}
+
+		repo := manager.Repository{Name: name, Enabled: true}
+		if len(fields) > 1 {
+			repo.URL = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			for _, option := range strings.Split(fields[2], ",") {
+				switch strings.TrimSpace(option) {
+				case "disabled":
+					repo.Enabled = false
+				case "user", "system":
+					repo.Description = strings.TrimSpace(option) + " installation"
+				}
+			}
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
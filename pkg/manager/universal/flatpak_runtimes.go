@@ -0,0 +1,155 @@
+package universal
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"strings"
+)
+
+// FlatpakRuntime represents an installed Flatpak runtime or extension.
+type FlatpakRuntime struct {
+	ID      string   // Runtime ID, e.g. org.freedesktop.Platform
+	Arch    string   // Architecture
+	Branch  string   // Branch, e.g. 23.08
+	Version string   // Version string, if published
+	Origin  string   // Remote the runtime was installed from
+	Size    string   // Installed size as reported by flatpak
+	EOL     string   // End-of-life reason, empty if supported
+	Apps    []string // Applications that depend on this runtime
+	Unused  bool     // No installed application depends on it
+}
+
+// Ref returns the runtime reference as used by flatpak (id/arch/branch).
+func (r *FlatpakRuntime) Ref() string {
+	return r.ID + "/" + r.Arch + "/" + r.Branch
+}
+
+// IsEOL returns true if the runtime has been marked end-of-life.
+func (r *FlatpakRuntime) IsEOL() bool {
+	return r.EOL != ""
+}
+
+// ListRuntimes returns installed runtimes with the applications that use them.
+func (f *Flatpak) ListRuntimes(ctx context.Context) ([]FlatpakRuntime, error) {
+	output, err := f.exec.Output(ctx, f.binary, "list", "--runtime",
+		"--columns=application,arch,branch,version,origin,size,options")
+	if err != nil {
+		return nil, err
+	}
+	runtimes := parseRuntimeList(output)
+
+	appOutput, err := f.exec.Output(ctx, f.binary, "list", "--app", "--columns=application,runtime")
+	if err != nil {
+		return nil, err
+	}
+	linkRuntimeApps(runtimes, parseAppRuntimes(appOutput))
+
+	return runtimes, nil
+}
+
+// RemoveUnusedRuntimes uninstalls runtimes and extensions no application needs.
+func (f *Flatpak) RemoveUnusedRuntimes(ctx context.Context, dryRun bool) error {
+	if dryRun {
+		f.exec.SetDryRun(true)
+		defer f.exec.SetDryRun(false)
+	}
+
+	return f.exec.Run(ctx, f.binary, "uninstall", "--unused", "-y")
+}
+
+// parseRuntimeList parses `flatpak list --runtime` tab-separated output.
+func parseRuntimeList(output string) []FlatpakRuntime {
+	var runtimes []FlatpakRuntime
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+
+		rt := FlatpakRuntime{
+			ID:     fields[0],
+			Arch:   fields[1],
+			Branch: fields[2],
+		}
+		if len(fields) > 3 {
+			rt.Version = fields[3]
+		}
+		if len(fields) > 4 {
+			rt.Origin = fields[4]
+		}
+		if len(fields) > 5 {
+			rt.Size = fields[5]
+		}
+		if len(fields) > 6 {
+			rt.EOL = parseEOLOption(fields[6])
+		}
+
+		runtimes = append(runtimes, rt)
+	}
+
+	return runtimes
+}
+
+// parseEOLOption extracts the end-of-life reason from the options column.
+func parseEOLOption(options string) string {
+	for _, opt := range strings.Split(options, ",") {
+		opt = strings.TrimSpace(opt)
+		if strings.HasPrefix(opt, "eol=") {
+			return strings.TrimPrefix(opt, "eol=")
+		}
+		if strings.HasPrefix(opt, "eol-rebase=") {
+			return "replaced by " + strings.TrimPrefix(opt, "eol-rebase=")
+		}
+	}
+	return ""
+}
+
+// parseAppRuntimes parses `flatpak list --app --columns=application,runtime`
+// into a map of runtime ref to dependent applications.
+func parseAppRuntimes(output string) map[string][]string {
+	result := make(map[string][]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 || fields[1] == "" {
+			continue
+		}
+		result[fields[1]] = append(result[fields[1]], fields[0])
+	}
+
+	return result
+}
+
+// linkRuntimeApps attaches dependent applications to each runtime and marks
+// runtimes that nothing uses. Extensions (e.g. org.freedesktop.Platform.GL.default)
+// count as used when their parent runtime is used.
+func linkRuntimeApps(runtimes []FlatpakRuntime, appRuntimes map[string][]string) {
+	usedIDs := make(map[string]bool)
+	for i := range runtimes {
+		apps := appRuntimes[runtimes[i].Ref()]
+		sort.Strings(apps)
+		runtimes[i].Apps = apps
+		if len(apps) > 0 {
+			usedIDs[runtimes[i].ID] = true
+		}
+	}
+
+	for i := range runtimes {
+		if len(runtimes[i].Apps) > 0 {
+			continue
+		}
+
+		used := false
+		for id := range usedIDs {
+			if strings.HasPrefix(runtimes[i].ID, id+".") {
+				used = true
+				break
+			}
+		}
+		runtimes[i].Unused = !used
+	}
+}
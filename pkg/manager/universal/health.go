@@ -0,0 +1,115 @@
+package universal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"poxy/pkg/aur"
+	"poxy/pkg/manager"
+)
+
+const (
+	// snapdSocket is where snapd listens; snap commands fail without it
+	snapdSocket = "/run/snapd.socket"
+
+	// snapStoreURL is the Snap Store API searches go to
+	snapStoreURL = "https://api.snapcraft.io/"
+
+	// flathubURL is the repository of the flathub remote
+	flathubURL = "https://dl.flathub.org/repo/flathub.flatpakrepo"
+)
+
+// Probe checks that snapd is running and the Snap Store is reachable.
+func (s *Snap) Probe(ctx context.Context) manager.Health {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", snapdSocket)
+	if err != nil {
+		return manager.Health{
+			Status:  manager.HealthDown,
+			Summary: "snapd is not running",
+			Fix:     "sudo systemctl enable --now snapd.socket",
+		}
+	}
+	conn.Close()
+
+	if err := manager.ProbeURL(ctx, snapStoreURL); err != nil {
+		return manager.Health{
+			Status:  manager.HealthDegraded,
+			Summary: "snapd is running, but the Snap Store is unreachable",
+			Fix:     "Check your network connection",
+		}
+	}
+	return manager.Health{Status: manager.HealthOK, Summary: "snapd is running, Snap Store reachable"}
+}
+
+// Probe checks that the default remote is configured and reachable.
+func (f *Flatpak) Probe(ctx context.Context) manager.Health {
+	output, err := f.exec.OutputQuiet(ctx, f.binary, "remotes", "--columns=name,url")
+	if err != nil {
+		return manager.Health{Status: manager.HealthDown, Summary: fmt.Sprintf("cannot list remotes: %v", err)}
+	}
+
+	remotes := parseFlatpakRemotes(output)
+	addRemote := fmt.Sprintf("flatpak remote-add --if-not-exists %s %s", f.defaultRemote, flathubURL)
+	if len(remotes) == 0 {
+		return manager.Health{Status: manager.HealthDown, Summary: "no remotes configured", Fix: addRemote}
+	}
+
+	remoteURL, ok := remotes[f.defaultRemote]
+	if !ok {
+		return manager.Health{
+			Status:  manager.HealthDegraded,
+			Summary: fmt.Sprintf("default remote %q is not configured (%d other remotes)", f.defaultRemote, len(remotes)),
+			Fix:     addRemote,
+		}
+	}
+
+	if err := manager.ProbeURL(ctx, remoteURL); err != nil {
+		return manager.Health{
+			Status:  manager.HealthDown,
+			Summary: fmt.Sprintf("remote %s is unreachable", f.defaultRemote),
+			Fix:     "Check your network connection",
+		}
+	}
+	return manager.Health{Status: manager.HealthOK, Summary: fmt.Sprintf("remote %s reachable", f.defaultRemote)}
+}
+
+// parseFlatpakRemotes parses `flatpak remotes --columns=name,url` output
+// into a map of remote name to URL.
+func parseFlatpakRemotes(output string) map[string]string {
+	remotes := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		remotes[fields[0]] = fields[1]
+	}
+	return remotes
+}
+
+// Probe checks that the AUR RPC interface answers.
+func (a *AUR) Probe(ctx context.Context) manager.Health {
+	return probeAURRPC(ctx, aur.NewClient())
+}
+
+// Probe checks that the AUR RPC interface answers.
+func (a *NativeAUR) Probe(ctx context.Context) manager.Health {
+	return probeAURRPC(ctx, a.client)
+}
+
+// probeAURRPC checks that the AUR RPC interface answers a query.
+func probeAURRPC(ctx context.Context, client *aur.Client) manager.Health {
+	if err := client.Ping(ctx); err != nil {
+		return manager.Health{
+			Status:  manager.HealthDown,
+			Summary: fmt.Sprintf("AUR RPC unreachable: %v", err),
+			Fix:     "Check your network connection or https://status.archlinux.org",
+		}
+	}
+	return manager.Health{Status: manager.HealthOK, Summary: "AUR RPC reachable"}
+}
@@ -1,6 +1,9 @@
 package universal
 
 import (
+	"context"
+	"slices"
+	"strings"
 	"testing"
 
 	"poxy/pkg/manager"
@@ -38,6 +41,18 @@ func TestFlatpakWithRemote(t *testing.T) {
 	}
 }
 
+func TestFlatpakInstallScope(t *testing.T) {
+	flatpak := NewFlatpak("")
+	if got := manager.InstallScope(flatpak); got != manager.ScopeSystem {
+		t.Errorf("default scope = %q, want system", got)
+	}
+
+	flatpak.SetInstallScope(manager.ScopeUser)
+	if got := manager.InstallScope(flatpak); got != manager.ScopeUser {
+		t.Errorf("scope after SetInstallScope(user) = %q, want user", got)
+	}
+}
+
 func TestSnapManager(t *testing.T) {
 	snap := NewSnap(false)
 
@@ -141,3 +156,309 @@ func TestUniversalManagersInterface(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlatpakRuntimes(t *testing.T) {
+	runtimeOutput := "org.freedesktop.Platform\tx86_64\t23.08\t23.08.1\tflathub\t500.0 MB\tcurrent,runtime\n" +
+		"org.freedesktop.Platform.GL.default\tx86_64\t23.08\t\tflathub\t150.0 MB\truntime\n" +
+		"org.gnome.Platform\tx86_64\t43\t\tflathub\t800.0 MB\truntime,eol=No longer supported\n" +
+		"org.kde.Platform\tx86_64\t5.15\t\tflathub\t1.0 GB\truntime\n"
+	appOutput := "org.mozilla.firefox\torg.freedesktop.Platform/x86_64/23.08\n" +
+		"org.gnome.Calculator\torg.gnome.Platform/x86_64/43\n" +
+		"com.example.App\torg.freedesktop.Platform/x86_64/23.08\n"
+
+	runtimes := parseRuntimeList(runtimeOutput)
+	if len(runtimes) != 4 {
+		t.Fatalf("expected 4 runtimes, got %d", len(runtimes))
+	}
+	linkRuntimeApps(runtimes, parseAppRuntimes(appOutput))
+
+	fdo := runtimes[0]
+	if fdo.Ref() != "org.freedesktop.Platform/x86_64/23.08" {
+		t.Errorf("unexpected ref: %s", fdo.Ref())
+	}
+	if len(fdo.Apps) != 2 || fdo.Apps[0] != "com.example.App" {
+		t.Errorf("expected 2 sorted apps, got %v", fdo.Apps)
+	}
+	if fdo.Unused || fdo.IsEOL() {
+		t.Error("freedesktop runtime should be used and supported")
+	}
+
+	if runtimes[1].Unused {
+		t.Error("extension of a used runtime should not be unused")
+	}
+
+	if !runtimes[2].IsEOL() || runtimes[2].EOL != "No longer supported" {
+		t.Errorf("expected gnome runtime to be EOL, got %q", runtimes[2].EOL)
+	}
+
+	if !runtimes[3].Unused {
+		t.Error("kde runtime should be unused")
+	}
+}
+
+func TestParseFlatpakPermissions(t *testing.T) {
+	output := "[Context]\n" +
+		"shared=network;ipc;\n" +
+		"sockets=x11;wayland;pulseaudio;\n" +
+		"devices=dri;\n" +
+		"filesystems=xdg-download;~/Games:ro;\n" +
+		"\n" +
+		"[Session Bus Policy]\n" +
+		"org.freedesktop.Notifications=talk\n" +
+		"\n" +
+		"[Environment]\n" +
+		"MOZ_ENABLE_WAYLAND=1\n"
+
+	perms := parsePermissions(output)
+	if !perms.Network() || !slices.Equal(perms.Sockets, []string{"x11", "wayland", "pulseaudio"}) {
+		t.Errorf("unexpected shared or sockets: %v %v", perms.Shared, perms.Sockets)
+	}
+	if !slices.Equal(perms.Devices, []string{"dri"}) || !slices.Equal(perms.Filesystems, []string{"xdg-download", "~/Games:ro"}) {
+		t.Errorf("unexpected devices or filesystems: %v %v", perms.Devices, perms.Filesystems)
+	}
+	if perms.SessionBus["org.freedesktop.Notifications"] != "talk" || perms.Environment["MOZ_ENABLE_WAYLAND"] != "1" {
+		t.Errorf("unexpected bus policy or environment: %v %v", perms.SessionBus, perms.Environment)
+	}
+	if !parsePermissions("").Empty() {
+		t.Error("no output should have no permissions")
+	}
+}
+
+func TestFlatpakOverrideArgs(t *testing.T) {
+	tests := []struct {
+		override manager.PermissionOverride
+		want     string
+	}{
+		{manager.PermissionOverride{Kind: manager.PermShare, Value: "network"}, "--unshare=network"},
+		{manager.PermissionOverride{Kind: manager.PermFilesystem, Value: "~/Games:ro", Grant: true}, "--filesystem=~/Games:ro"},
+		{manager.PermissionOverride{Kind: manager.PermFeature, Value: "bluetooth", Grant: true}, "--allow=bluetooth"},
+		{manager.PermissionOverride{Kind: manager.PermEnv, Value: "FOO=bar", Grant: true}, "--env=FOO=bar"},
+		{manager.PermissionOverride{Kind: manager.PermEnv, Value: "FOO"}, "--unset-env=FOO"},
+	}
+	for _, tt := range tests {
+		got, err := overrideArg(tt.override)
+		if err != nil || got != tt.want {
+			t.Errorf("overrideArg(%v) = %q, %v; want %q", tt.override, got, err, tt.want)
+		}
+	}
+
+	if _, err := overrideArg(manager.PermissionOverride{Kind: manager.PermEnv, Value: "FOO", Grant: true}); err == nil {
+		t.Error("granting env without a value should fail")
+	}
+}
+
+func TestParseInstalledRecords(t *testing.T) {
+	output := `Name            : jdk-temurin
+Version         : 21.0.4.u7-1
+Description     : Temurin (OpenJDK) Java 21 JDK
+Provides        : java-runtime=21  java-environment=21
+Conflicts With  : None
+Replaces        : jdk-adoptopenjdk
+
+Name            : yay-bin
+Version         : 12.4.2-1
+Description     : Yet another yogurt
+Provides        : yay
+Conflicts With  : yay
+Replaces        : None
+`
+	packages := parseInstalledRecords(output, "aur")
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(packages))
+	}
+
+	jdk := packages[0]
+	if !jdk.Installed || jdk.Source != "aur" {
+		t.Errorf("unexpected package: %+v", jdk)
+	}
+	if !slices.Equal(jdk.Provides, []string{"java-runtime=21", "java-environment=21"}) || jdk.Conflicts != nil {
+		t.Errorf("unexpected relations: %+v", jdk)
+	}
+	if jdk.Relation("jdk-adoptopenjdk") != "replaces" {
+		t.Error("jdk-temurin should replace jdk-adoptopenjdk")
+	}
+	if !slices.Equal(packages[1].Conflicts, []string{"yay"}) {
+		t.Errorf("conflicts = %v", packages[1].Conflicts)
+	}
+}
+
+func TestParseRemoteLs(t *testing.T) {
+	output := "org.gimp.GIMP\t2.10.38\tGNU Image Manipulation Program\tCreate images and edit photographs\tflathub\n" +
+		"org.gimp.GIMP\t3.0.0\tGNU Image Manipulation Program\tCreate images and edit photographs\tflathub-beta\n" +
+		"org.example.Tool\t\tTool\t\tlocal\n" +
+		"malformed line\n"
+
+	packages := parseRemoteLs(output)
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packages), packages)
+	}
+
+	gimp := packages[0]
+	if gimp.Name != "org.gimp.GIMP" || gimp.Version != "2.10.38" || gimp.Source != "flatpak" {
+		t.Errorf("unexpected package: %+v", gimp)
+	}
+	if gimp.Description != "GNU Image Manipulation Program: Create images and edit photographs" {
+		t.Errorf("description = %q", gimp.Description)
+	}
+	if gimp.Metadata[manager.MetaRemote] != "flathub" {
+		t.Errorf("remote = %q, want flathub", gimp.Metadata[manager.MetaRemote])
+	}
+	if packages[1].Description != "Tool" {
+		t.Errorf("description = %q, want the name alone", packages[1].Description)
+	}
+}
+
+func TestParseSnapVersion(t *testing.T) {
+	output := `snap    2.63+24.04
+snapd   2.63+24.04
+series  16
+ubuntu  24.04
+kernel  6.8.0-31-generic`
+
+	if got := parseSnapVersion(output); got != "2.63+24.04" {
+		t.Errorf("parseSnapVersion() = %q, want %q", got, "2.63+24.04")
+	}
+
+	if got := parseSnapVersion("snap 2.58"); got != "2.58" {
+		t.Errorf("parseSnapVersion() fallback = %q, want %q", got, "2.58")
+	}
+}
+
+func TestFilterAURUpgrades(t *testing.T) {
+	upgrades := []aurUpgrade{
+		{installed: manager.Package{Name: "yay-bin"}},
+		{installed: manager.Package{Name: "spotify"}},
+		{installed: manager.Package{Name: "zoom"}},
+	}
+
+	names := func(us []aurUpgrade) []string {
+		var out []string
+		for _, u := range us {
+			out = append(out, u.installed.Name)
+		}
+		return out
+	}
+
+	if got := names(filterAURUpgrades(upgrades, nil, []string{"zoom"})); !slices.Equal(got, []string{"yay-bin", "spotify"}) {
+		t.Errorf("excluding zoom = %v", got)
+	}
+	if got := names(filterAURUpgrades(upgrades, []string{"spotify", "vim"}, nil)); !slices.Equal(got, []string{"spotify"}) {
+		t.Errorf("only spotify = %v", got)
+	}
+	if got := filterAURUpgrades(upgrades, []string{"zoom"}, []string{"zoom"}); len(got) != 0 {
+		t.Errorf("only and excluding zoom = %v", names(got))
+	}
+}
+
+func TestSearchMetadata(t *testing.T) {
+	flatpak := NewFlatpak("")
+	pkgs := flatpak.parseSearchOutput("Firefox\tFast, private web browser\torg.mozilla.firefox\t131.0\tflathub\n", 0)
+	if len(pkgs) != 1 || pkgs[0].Badge() != "flatpak/flathub" {
+		t.Errorf("flatpak search = %+v, want one result from flathub", pkgs)
+	}
+
+	aur := NewAUR("")
+	output := `aur/yay-bin 12.4.2-1 (+120 3.21)
+    Yet another yogurt. Pacman wrapper and AUR helper written in go.
+aur/yay-git 12.4.2.r0-1 (+55 0.10)
+    Yet another yogurt (development version).
+`
+	pkgs = aur.parseSearchOutput(output, 0)
+	if len(pkgs) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(pkgs))
+	}
+	if pkgs[0].Metadata[manager.MetaVotes] != "120" || pkgs[0].Metadata[manager.MetaPopularity] != "3.21" {
+		t.Errorf("yay-bin metadata = %v", pkgs[0].Metadata)
+	}
+
+	if stats := parseAURSearchStats([]string{"[Installed]"}); stats != nil {
+		t.Errorf("parseAURSearchStats() without stats = %v, want nil", stats)
+	}
+}
+
+func TestSnapBinDir(t *testing.T) {
+	if got := NewSnap(false).BinDir(context.Background()); got != "/snap/bin" {
+		t.Errorf("snap BinDir() = %q, want /snap/bin", got)
+	}
+}
+
+func TestParseSnapCommands(t *testing.T) {
+	output := `name:      code
+summary:   Code editing. Redefined.
+commands:
+  - code
+  - code.url-handler
+services:
+  - code.daemon: simple, enabled, active
+installed:          1.88.0 (157) 330MB classic
+`
+	got := parseSnapCommands(output)
+	if !slices.Equal(got, []string{"code", "code.url-handler"}) {
+		t.Errorf("parseSnapCommands() = %v", got)
+	}
+	if got := parseSnapCommands("name: code\nchannels:\n  latest/stable: 1.88.0\n"); len(got) != 0 {
+		t.Errorf("parseSnapCommands() without commands = %v", got)
+	}
+
+	var _ manager.CommandPreviewer = NewSnap(false)
+}
+
+func TestParseSnapChannels(t *testing.T) {
+	output := `name:      code
+summary:   Code editing. Redefined.
+tracking:     latest/stable
+refresh-date: 3 days ago
+channels:
+  latest/stable:      1.88.0 2024-04-04 (157) 330MB classic
+  latest/candidate:   ↑
+  latest/beta:        –
+  insiders/stable:    1.89.0-insider 2024-04-09 (160) 331MB classic
+  insiders/edge:      ↑
+installed:          1.88.0            (157) 330MB classic
+`
+	got := parseSnapChannels(output)
+	if got.Tracking != "latest/stable" {
+		t.Errorf("Tracking = %q, want latest/stable", got.Tracking)
+	}
+	want := []SnapChannel{
+		{Name: "latest/stable", Version: "1.88.0", Released: "2024-04-04"},
+		{Name: "latest/candidate", Version: "1.88.0", Released: "2024-04-04"},
+		{Name: "insiders/stable", Version: "1.89.0-insider", Released: "2024-04-09"},
+		{Name: "insiders/edge", Version: "1.89.0-insider", Released: "2024-04-09"},
+	}
+	if !slices.Equal(got.Channels, want) {
+		t.Errorf("Channels = %+v, want %+v", got.Channels, want)
+	}
+
+	info := NewSnap(false).parsePackageInfo(output)
+	if info.Metadata[manager.MetaChannel] != "latest/stable" {
+		t.Errorf("channel = %q, want latest/stable", info.Metadata[manager.MetaChannel])
+	}
+
+	if got := snapTracking(strings.Fields("hello  2.10  42  -  canonical✓  -")); got != "" {
+		t.Errorf("snapTracking() of a local snap = %q", got)
+	}
+	if got := snapTracking(strings.Fields("firefox  125.0  4173  latest/beta  mozilla✓  -")); got != "latest/beta" {
+		t.Errorf("snapTracking() = %q, want latest/beta", got)
+	}
+
+	var _ manager.Holder = NewSnap(false)
+}
+
+func TestParseFlatpakRemoteList(t *testing.T) {
+	output := "flathub\thttps://dl.flathub.org/repo/\tsystem\n" +
+		"flathub-beta\thttps://dl.flathub.org/beta-repo/\tuser,disabled\n"
+
+	repos := parseFlatpakRemoteList(output)
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 remotes, got %d: %+v", len(repos), repos)
+	}
+	if repos[0].Name != "flathub" || !repos[0].Enabled || repos[0].URL != "https://dl.flathub.org/repo/" {
+		t.Errorf("unexpected remote: %+v", repos[0])
+	}
+	if repos[1].Enabled || repos[1].Description != "user installation" {
+		t.Errorf("unexpected remote: %+v", repos[1])
+	}
+
+	var _ manager.RepoManager = NewFlatpak("")
+}
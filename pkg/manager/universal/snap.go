@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"poxy/internal/executor"
@@ -57,6 +58,20 @@ func (s *Snap) NeedsSudo() bool {
 	return true // Snap typically requires sudo
 }
 
+// BinDir returns the directory snapd links the commands of snaps into.
+func (s *Snap) BinDir(ctx context.Context) string {
+	return "/snap/bin"
+}
+
+// Version returns the version of the snapd daemon.
+func (s *Snap) Version(ctx context.Context) (string, error) {
+	output, err := s.exec.OutputQuiet(ctx, s.binary, "version")
+	if err != nil {
+		return "", err
+	}
+	return parseSnapVersion(output), nil
+}
+
 // Install installs one or more Snap packages.
 func (s *Snap) Install(ctx context.Context, packages []string, opts manager.InstallOpts) error {
 	for _, pkg := range packages {
@@ -65,6 +80,10 @@ func (s *Snap) Install(ctx context.Context, packages []string, opts manager.Inst
 		if s.allowClassic {
 			args = append(args, "--classic")
 		}
+		if opts.Channel != "" {
+			args = append(args, "--channel="+opts.Channel)
+		}
+		args = append(args, opts.ExtraArgs...)
 
 		if opts.DryRun {
 			s.exec.SetDryRun(true)
@@ -105,6 +124,8 @@ func (s *Snap) Update(ctx context.Context) error {
 func (s *Snap) Upgrade(ctx context.Context, opts manager.UpgradeOpts) error {
 	args := []string{"refresh"}
 
+	args = append(args, opts.ExtraArgs...)
+
 	if len(opts.Packages) > 0 {
 		args = append(args, opts.Packages...)
 	}
@@ -128,7 +149,9 @@ func (s *Snap) Search(ctx context.Context, query string, opts manager.SearchOpts
 		return []manager.Package{}, nil
 	}
 
-	return s.parseSearchOutput(output, opts.Limit), nil
+	packages := s.parseSearchOutput(output, opts.Limit)
+	manager.CheckParsed("snap", "snap find", output, len(packages))
+	return packages, nil
 }
 
 // searchInstalled searches installed snaps.
@@ -164,12 +187,12 @@ func (s *Snap) searchInstalled(ctx context.Context, query string, opts manager.S
 			continue
 		}
 
-		packages = append(packages, manager.Package{
+		packages = append(packages, withChannel(manager.Package{
 			Name:      name,
 			Version:   version,
 			Source:    "snap",
 			Installed: true,
-		})
+		}, snapTracking(fields)))
 
 		if opts.Limit > 0 && len(packages) >= opts.Limit {
 			break
@@ -234,6 +257,42 @@ func (s *Snap) Info(ctx context.Context, pkg string) (*manager.PackageInfo, erro
 	return s.parsePackageInfo(output), nil
 }
 
+// PackageCommands returns the commands the snap pkg exports into BinDir,
+// as snap info lists them. snap info lists none for snaps that are not
+// installed, whose main command is named after the snap.
+func (s *Snap) PackageCommands(ctx context.Context, pkg string) ([]string, error) {
+	output, err := s.exec.OutputQuiet(ctx, s.binary, "info", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("package '%s' not found", pkg)
+	}
+	names := parseSnapCommands(output)
+	if len(names) == 0 {
+		names = []string{pkg}
+	}
+	var commands []string
+	for _, name := range names {
+		commands = append(commands, filepath.Join(s.BinDir(ctx), name))
+	}
+	return commands, nil
+}
+
+// parseSnapCommands returns the command names in the commands: section of
+// snap info output.
+func parseSnapCommands(output string) []string {
+	var names []string
+	inCommands := false
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, " ") {
+			inCommands = strings.TrimSpace(line) == "commands:"
+			continue
+		}
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && inCommands {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
+
 // parsePackageInfo parses snap info output.
 func (s *Snap) parsePackageInfo(output string) *manager.PackageInfo {
 	info := &manager.PackageInfo{
@@ -287,6 +346,8 @@ func (s *Snap) parsePackageInfo(output string) *manager.PackageInfo {
 			info.Size = value
 		case "contact":
 			info.URL = value
+		case "tracking":
+			info.Package = withChannel(info.Package, value)
 		}
 	}
 
@@ -326,18 +387,21 @@ func (s *Snap) ListInstalled(ctx context.Context, opts manager.ListOpts) ([]mana
 			continue
 		}
 
-		packages = append(packages, manager.Package{
+		packages = append(packages, withChannel(manager.Package{
 			Name:      name,
 			Version:   version,
 			Source:    "snap",
 			Installed: true,
-		})
+		}, snapTracking(fields)))
 
 		if opts.Limit > 0 && len(packages) >= opts.Limit {
 			break
 		}
 	}
 
+	if opts.Pattern == "" {
+		manager.CheckParsed("snap", "snap list", output, len(packages))
+	}
 	return packages, nil
 }
 
@@ -369,3 +433,15 @@ func (s *Snap) Autoremove(ctx context.Context) error {
 	// Snap doesn't have orphan package concept
 	return nil
 }
+
+// parseSnapVersion returns the snapd version from `snap version` output,
+// falling back to the first version found.
+func parseSnapVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "snapd" {
+			return fields[1]
+		}
+	}
+	return manager.ExtractVersion(output)
+}
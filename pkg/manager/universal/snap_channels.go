@@ -0,0 +1,135 @@
+package universal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"poxy/pkg/manager"
+)
+
+// SnapChannel is a channel a snap is published in, such as latest/stable
+// or insiders/edge.
+type SnapChannel struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Released string `json:"released,omitempty"` // Date of the release, e.g. "2024-03-13"
+}
+
+// SnapChannels are the open channels of a snap and the one an installed
+// snap tracks.
+type SnapChannels struct {
+	Tracking string        `json:"tracking,omitempty"` // Empty if not installed
+	Channels []SnapChannel `json:"channels"`
+}
+
+// Channels returns the open channels of a snap.
+func (s *Snap) Channels(ctx context.Context, pkg string) (*SnapChannels, error) {
+	output, err := s.exec.OutputQuiet(ctx, s.binary, "info", pkg)
+	if err != nil {
+		return nil, fmt.Errorf("snap '%s' not found", pkg)
+	}
+	return parseSnapChannels(output), nil
+}
+
+// SwitchChannel makes an installed snap track channel and refreshes it to
+// the channel's revision.
+func (s *Snap) SwitchChannel(ctx context.Context, pkg, channel string, dryRun bool) error {
+	if dryRun {
+		s.exec.SetDryRun(true)
+		defer s.exec.SetDryRun(false)
+	}
+	return s.exec.RunSudo(ctx, s.binary, "refresh", "--channel="+channel, pkg)
+}
+
+// Hold stops snapd from refreshing the snaps, with snap refresh --hold
+// (snapd 2.58 or later).
+func (s *Snap) Hold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return s.refreshHold(ctx, "--hold", pkgs, dryRun)
+}
+
+// Unhold lets snapd refresh the snaps again.
+func (s *Snap) Unhold(ctx context.Context, pkgs []string, dryRun bool) error {
+	return s.refreshHold(ctx, "--unhold", pkgs, dryRun)
+}
+
+func (s *Snap) refreshHold(ctx context.Context, flag string, pkgs []string, dryRun bool) error {
+	if dryRun {
+		s.exec.SetDryRun(true)
+		defer s.exec.SetDryRun(false)
+	}
+	return s.exec.RunSudo(ctx, s.binary, append([]string{"refresh", flag}, pkgs...)...)
+}
+
+// parseSnapChannels parses the tracking: line and channels: section of snap
+// info output:
+//
+//	tracking:     latest/stable
+//	channels:
+//	  latest/stable:    1.85.1 2024-03-13 (157) 313MB classic
+//	  latest/candidate: ↑
+//	  latest/beta:      –
+//
+// "↑" means the channel has the release of the more stable channel above
+// it; "–" means the channel is closed.
+func parseSnapChannels(output string) *SnapChannels {
+	result := &SnapChannels{}
+	inChannels := false
+	prev := SnapChannel{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, " ") {
+			key, value, _ := strings.Cut(line, ":")
+			inChannels = key == "channels"
+			if key == "tracking" {
+				result.Tracking = strings.TrimSpace(value)
+			}
+			continue
+		}
+		if !inChannels {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		track, _, _ := strings.Cut(name, "/")
+		prevTrack, _, _ := strings.Cut(prev.Name, "/")
+
+		fields := strings.Fields(value)
+		switch {
+		case len(fields) == 0 || fields[0] == "–" || fields[0] == "-":
+			continue
+		case fields[0] == "↑":
+			if prev.Name == "" || prevTrack != track {
+				continue
+			}
+			prev = SnapChannel{Name: name, Version: prev.Version, Released: prev.Released}
+		default:
+			prev = SnapChannel{Name: name, Version: fields[0]}
+			if len(fields) > 1 && !strings.HasPrefix(fields[1], "(") {
+				prev.Released = fields[1]
+			}
+		}
+		result.Channels = append(result.Channels, prev)
+	}
+	return result
+}
+
+// snapTracking returns the channel of the Tracking column of snap list, or
+// "" for snaps installed from a file, which track none.
+func snapTracking(fields []string) string {
+	if len(fields) < 4 || fields[3] == "-" {
+		return ""
+	}
+	return fields[3]
+}
+
+// withChannel records the channel a snap tracks in its metadata.
+func withChannel(pkg manager.Package, channel string) manager.Package {
+	if channel != "" {
+		pkg.Metadata = map[string]string{manager.MetaChannel: channel}
+	}
+	return pkg
+}
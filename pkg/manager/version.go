@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// versionPattern matches a dotted version number such as 6.1.0 or 2.61.3.
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+(?:[-+~][0-9A-Za-z.]+)?`)
+
+// ExtractVersion returns the first dotted version number in a tool's
+// --version output, or an empty string if there is none.
+func ExtractVersion(output string) string {
+	return versionPattern.FindString(output)
+}
+
+// DetectVersions returns the backend version of each manager that reports one.
+func DetectVersions(ctx context.Context, managers []Manager) map[string]string {
+	versions := make(map[string]string)
+	for _, mgr := range managers {
+		vr, ok := mgr.(VersionReporter)
+		if !ok || !mgr.IsAvailable() {
+			continue
+		}
+		if version, err := vr.Version(ctx); err == nil && version != "" {
+			versions[mgr.Name()] = version
+		}
+	}
+	return versions
+}
+
+// CompareVersions compares two package versions of the form
+// [epoch:]version[-release] the way pacman's vercmp does. It returns -1 if
+// a is older than b, 0 if they are equal and 1 if a is newer. The release
+// is only compared when both versions have one.
+func CompareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	epochA, verA, relA := splitEVR(a)
+	epochB, verB, relB := splitEVR(b)
+
+	if ret := compareSegments(epochA, epochB); ret != 0 {
+		return ret
+	}
+	if ret := compareSegments(verA, verB); ret != 0 {
+		return ret
+	}
+	if relA != "" && relB != "" {
+		return compareSegments(relA, relB)
+	}
+	return 0
+}
+
+// splitEVR splits a version into epoch, version and release. The epoch
+// defaults to "0".
+func splitEVR(evr string) (epoch, version, release string) {
+	epoch = "0"
+	version = evr
+
+	digits := 0
+	for digits < len(evr) && isDigit(evr[digits]) {
+		digits++
+	}
+	if digits < len(evr) && evr[digits] == ':' {
+		if digits > 0 {
+			epoch = evr[:digits]
+		}
+		version = evr[digits+1:]
+	}
+
+	if i := strings.LastIndexByte(version, '-'); i >= 0 {
+		version, release = version[:i], version[i+1:]
+	}
+	return epoch, version, release
+}
+
+// compareSegments is rpmvercmp: versions are compared segment by segment,
+// where a segment is a run of digits or of letters. Numeric segments compare
+// as numbers and are newer than alphabetic ones.
+func compareSegments(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	one, two := 0, 0
+	for one < len(a) && two < len(b) {
+		start1, start2 := one, two
+		for one < len(a) && !isAlnum(a[one]) {
+			one++
+		}
+		for two < len(b) && !isAlnum(b[two]) {
+			two++
+		}
+		if one == len(a) || two == len(b) {
+			break
+		}
+
+		// Different separator lengths decide on their own
+		if one-start1 != two-start2 {
+			if one-start1 < two-start2 {
+				return -1
+			}
+			return 1
+		}
+
+		end1, end2 := one, two
+		numeric := isDigit(a[one])
+		if numeric {
+			for end1 < len(a) && isDigit(a[end1]) {
+				end1++
+			}
+			for end2 < len(b) && isDigit(b[end2]) {
+				end2++
+			}
+		} else {
+			for end1 < len(a) && isAlpha(a[end1]) {
+				end1++
+			}
+			for end2 < len(b) && isAlpha(b[end2]) {
+				end2++
+			}
+		}
+
+		// Segments of different types: numeric is newer
+		if end2 == two {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		seg1, seg2 := a[one:end1], b[two:end2]
+		if numeric {
+			seg1 = strings.TrimLeft(seg1, "0")
+			seg2 = strings.TrimLeft(seg2, "0")
+			if len(seg1) != len(seg2) {
+				if len(seg1) > len(seg2) {
+					return 1
+				}
+				return -1
+			}
+		}
+		if ret := strings.Compare(seg1, seg2); ret != 0 {
+			return ret
+		}
+
+		one, two = end1, end2
+	}
+
+	if one == len(a) && two == len(b) {
+		return 0
+	}
+
+	// A remaining alphabetic segment never beats the end of the version,
+	// like 1.0rc < 1.0, but anything else does, like 1.0.1 > 1.0
+	if (one == len(a) && !isAlpha(b[two])) || (one < len(a) && isAlpha(a[one])) {
+		return -1
+	}
+	return 1
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isAlpha(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isAlnum(c byte) bool { return isDigit(c) || isAlpha(c) }
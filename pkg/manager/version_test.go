@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "pacman",
+			output: " .--.                  Pacman v6.1.0 - libalpm v14.0.0\n/ _.-' .-.  .-.  .-.   Copyright (C) 2006-2024 Pacman Development Team",
+			want:   "6.1.0",
+		},
+		{name: "apt", output: "apt 2.7.14 (amd64)", want: "2.7.14"},
+		{name: "flatpak", output: "Flatpak 1.15.8", want: "1.15.8"},
+		{name: "go", output: "go version go1.22.1 linux/amd64", want: "1.22.1"},
+		{name: "suffix", output: "cargo 1.78.0-nightly (abc 2024-02-01)", want: "1.78.0-nightly"},
+		{name: "no dot", output: "dnf5 version 5", want: ""},
+		{name: "empty", output: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractVersion(tt.output); got != tt.want {
+				t.Errorf("ExtractVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectVersionsSkipsUnavailable(t *testing.T) {
+	managers := []Manager{
+		&MockManager{name: "missing", available: false},
+		&MockManager{name: "plain", available: true},
+	}
+
+	if versions := DetectVersions(context.Background(), managers); len(versions) != 0 {
+		t.Errorf("DetectVersions() = %v, want empty", versions)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	// Cases from pacman's vercmp test suite
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.5.0", "1.5.0", 0},
+		{"1.5.1", "1.5.0", 1},
+		{"1.5.1", "1.5", 1},
+		{"1.5.0-1", "1.5.0-2", -1},
+		{"1.5-2", "1.5.1-1", -1},
+		{"1.5", "1.5-1", 0},
+		{"1.1-1", "1.0", 1},
+		{"1.5b-1", "1.5-1", -1},
+		{"1.5b", "1.5.1", -1},
+		{"1.0a", "1.0alpha", -1},
+		{"1.0beta", "1.0rc", -1},
+		{"1.0rc", "1.0", -1},
+		{"1.5.a", "1.5", 1},
+		{"1.5.1", "1.5.b", 1},
+		{"1.5.b-1", "1.5.b", 0},
+		{"1.5-1", "1.5.b", -1},
+		{"2.0", "2_0", 0},
+		{"2.0_a", "2_0.a", 0},
+		{"2.0a", "2.0.a", -1},
+		{"2___a", "2_a", 1},
+		{"1.010", "1.9", 1},
+		{"1:1.0", "0:1.1", 1},
+		{"1:1.0", "2:1.1", -1},
+		{"1:1.0", "0:1.0-1", 1},
+		{"0:1.0", "1.0", 0},
+		{"0:1.1", "1.0", 1},
+		{"1:1.1", "1.1", 1},
+		{"r1234.abcdef-1", "r1235.abcdef-1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
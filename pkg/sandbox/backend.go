@@ -0,0 +1,170 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoBackend is returned when no sandbox backend works on the system
+	ErrNoBackend = errors.New("no sandbox backend available (install bubblewrap, firejail or systemd)")
+
+	// ErrBackendUnavailable is returned when the configured backend does
+	// not work on the system
+	ErrBackendUnavailable = errors.New("sandbox backend is not available")
+)
+
+// BackendAuto selects the first backend that works: bubblewrap, then
+// firejail, systemd-run and unshare.
+const BackendAuto = "auto"
+
+// Backend runs commands under a profile with one isolation tool.
+//
+// Bubblewrap builds the filesystem from the profile's binds. Firejail and
+// systemd-run approximate it: the filesystem is read-only except for the
+// workdir and the read-write binds, rather than limited to the binds.
+// Unshare only isolates processes, network and IPC.
+type Backend interface {
+	// Name identifies the backend in the config, e.g. "bubblewrap"
+	Name() string
+
+	// Available reports whether the tool is installed and works, which it
+	// may not if the kernel restricts unprivileged user namespaces
+	Available() bool
+
+	// Command returns the command that runs cmd with args under profile,
+	// with workdir, if set, writable and the working directory
+	Command(ctx context.Context, profile *Profile, workdir, cmd string, args []string) *exec.Cmd
+}
+
+// backends are the supported backends, most isolating first.
+var backends = []Backend{
+	&bubblewrap{},
+	&firejail{},
+	&systemdRun{},
+	&unshare{},
+}
+
+var (
+	backendMu   sync.RWMutex
+	backendName = BackendAuto
+)
+
+// SetBackend selects the backend sandboxes use by name, or BackendAuto to
+// use the first available one.
+func SetBackend(name string) error {
+	if name == "" {
+		name = BackendAuto
+	}
+	if name != BackendAuto && findBackend(name) == nil {
+		return fmt.Errorf("unknown sandbox backend: %s", name)
+	}
+
+	backendMu.Lock()
+	backendName = name
+	backendMu.Unlock()
+	return nil
+}
+
+// SelectBackend returns the backend sandboxes use.
+func SelectBackend() (Backend, error) {
+	backendMu.RLock()
+	name := backendName
+	backendMu.RUnlock()
+
+	if name != BackendAuto {
+		backend := findBackend(name)
+		if !backend.Available() {
+			return nil, fmt.Errorf("%w: %s", ErrBackendUnavailable, name)
+		}
+		return backend, nil
+	}
+
+	for _, backend := range backends {
+		if backend.Available() {
+			return backend, nil
+		}
+	}
+	return nil, ErrNoBackend
+}
+
+// BackendNames returns the names of the supported backends, in the order
+// BackendAuto tries them.
+func BackendNames() []string {
+	names := make([]string, 0, len(backends))
+	for _, backend := range backends {
+		names = append(names, backend.Name())
+	}
+	return names
+}
+
+// findBackend returns the backend called name, or nil.
+func findBackend(name string) Backend {
+	for _, backend := range backends {
+		if backend.Name() == name {
+			return backend
+		}
+	}
+	return nil
+}
+
+// probeTimeout bounds a backend's availability check.
+const probeTimeout = 5 * time.Second
+
+// probe runs a check command once and remembers whether it succeeded.
+type probe struct {
+	once sync.Once
+	ok   bool
+}
+
+// run reports whether tool is installed and "tool args..." exits cleanly.
+func (p *probe) run(tool string, args ...string) bool {
+	p.once.Do(func() {
+		if _, err := exec.LookPath(tool); err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		p.ok = exec.CommandContext(ctx, tool, args...).Run() == nil
+	})
+	return p.ok
+}
+
+// profileEnv returns the environment of a command under profile, for
+// backends that start it with the caller's environment.
+func profileEnv(profile *Profile) []string {
+	var env []string
+	if profile.ClearEnv {
+		for _, key := range profile.EnvPass {
+			if val, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+val)
+			}
+		}
+	} else {
+		env = os.Environ()
+	}
+	for key, val := range profile.Env {
+		env = append(env, key+"="+val)
+	}
+	return env
+}
+
+// writablePaths returns the workdir and the read-write binds that exist,
+// for backends that make everything else read-only.
+func writablePaths(profile *Profile, workdir string) []string {
+	var paths []string
+	if workdir != "" {
+		paths = append(paths, workdir)
+	}
+	for _, path := range profile.BindReadWrite {
+		if pathExists(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -2,156 +2,103 @@ package sandbox
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"strings"
 )
 
-var (
-	// ErrBubblewrapNotFound is returned when bwrap is not installed
-	ErrBubblewrapNotFound = errors.New("bubblewrap (bwrap) is not installed")
-
-	// ErrSandboxFailed is returned when sandbox execution fails
-	ErrSandboxFailed = errors.New("sandbox execution failed")
-)
-
-// Sandbox provides sandboxed execution using bubblewrap.
-type Sandbox struct {
-	bwrapPath string
-	profile   *Profile
-	workdir   string
-	verbose   bool
+// bubblewrap runs commands with bwrap, building the sandbox's filesystem
+// from the profile's binds.
+type bubblewrap struct {
+	probe probe
 }
 
-// New creates a new sandbox with the given profile.
-func New(profile *Profile) (*Sandbox, error) {
-	bwrapPath, err := exec.LookPath("bwrap")
-	if err != nil {
-		return nil, ErrBubblewrapNotFound
-	}
-
-	return &Sandbox{
-		bwrapPath: bwrapPath,
-		profile:   profile,
-	}, nil
+// Name returns the backend name.
+func (b *bubblewrap) Name() string {
+	return "bubblewrap"
 }
 
-// NewWithProfile creates a sandbox with a named profile.
-func NewWithProfile(profileName string) (*Sandbox, error) {
-	var profile *Profile
-	switch profileName {
-	case "build":
-		profile = ProfileBuild.Clone()
-	case "fetch":
-		profile = ProfileFetch.Clone()
-	case "minimal":
-		profile = ProfileMinimal.Clone()
-	default:
-		return nil, fmt.Errorf("unknown profile: %s", profileName)
-	}
-
-	return New(profile)
+// Available reports whether bwrap can create the namespaces builds use. It
+// cannot when it is not setuid and unprivileged user namespaces are
+// disabled.
+func (b *bubblewrap) Available() bool {
+	return b.probe.run("bwrap", "--ro-bind", "/", "/", "--unshare-pid", "--unshare-net", "--proc", "/proc", "true")
 }
 
-// IsAvailable checks if bubblewrap is available on the system.
-func IsAvailable() bool {
-	_, err := exec.LookPath("bwrap")
-	return err == nil
+// Command returns the bwrap command running cmd under profile.
+func (b *bubblewrap) Command(ctx context.Context, profile *Profile, workdir, cmd string, args []string) *exec.Cmd {
+	return exec.CommandContext(ctx, "bwrap", bwrapArgs(profile, workdir, cmd, args)...)
 }
 
-// SetWorkdir sets the working directory for sandboxed commands.
-// This directory will be bind-mounted read-write.
-func (s *Sandbox) SetWorkdir(dir string) error {
-	absDir, err := filepath.Abs(dir)
-	if err != nil {
-		return err
-	}
-	s.workdir = absDir
-	return nil
-}
-
-// SetVerbose enables verbose output of bwrap arguments.
-func (s *Sandbox) SetVerbose(verbose bool) {
-	s.verbose = verbose
-}
-
-// Profile returns the current profile.
-func (s *Sandbox) Profile() *Profile {
-	return s.profile
-}
-
-// buildArgs constructs the bwrap command line arguments.
-func (s *Sandbox) buildArgs(cmd string, args []string) []string {
+// bwrapArgs constructs the bwrap command line arguments.
+func bwrapArgs(profile *Profile, workdir, cmd string, args []string) []string {
 	var bwrapArgs []string
 
 	// Unshare namespaces
-	if s.profile.UnshareUser {
+	if profile.UnshareUser {
 		bwrapArgs = append(bwrapArgs, "--unshare-user")
-		if s.profile.UID > 0 {
-			bwrapArgs = append(bwrapArgs, "--uid", fmt.Sprintf("%d", s.profile.UID))
+		if profile.UID > 0 {
+			bwrapArgs = append(bwrapArgs, "--uid", fmt.Sprintf("%d", profile.UID))
 		}
-		if s.profile.GID > 0 {
-			bwrapArgs = append(bwrapArgs, "--gid", fmt.Sprintf("%d", s.profile.GID))
+		if profile.GID > 0 {
+			bwrapArgs = append(bwrapArgs, "--gid", fmt.Sprintf("%d", profile.GID))
 		}
 	}
-	if s.profile.UnsharePID {
+	if profile.UnsharePID {
 		bwrapArgs = append(bwrapArgs, "--unshare-pid")
 	}
-	if s.profile.UnshareNet {
+	if profile.UnshareNet {
 		bwrapArgs = append(bwrapArgs, "--unshare-net")
 	}
-	if s.profile.UnshareIPC {
+	if profile.UnshareIPC {
 		bwrapArgs = append(bwrapArgs, "--unshare-ipc")
 	}
-	if s.profile.UnshareCgroup {
+	if profile.UnshareCgroup {
 		bwrapArgs = append(bwrapArgs, "--unshare-cgroup")
 	}
 
 	// Process settings
-	if s.profile.DieWithParent {
+	if profile.DieWithParent {
 		bwrapArgs = append(bwrapArgs, "--die-with-parent")
 	}
-	if s.profile.NewSession {
+	if profile.NewSession {
 		bwrapArgs = append(bwrapArgs, "--new-session")
 	}
 
 	// Read-only binds
-	for _, bind := range s.profile.BindReadOnly {
+	for _, bind := range profile.BindReadOnly {
 		if pathExists(bind) {
 			bwrapArgs = append(bwrapArgs, "--ro-bind", bind, bind)
 		}
 	}
 
 	// Read-write binds
-	for _, bind := range s.profile.BindReadWrite {
+	for _, bind := range profile.BindReadWrite {
 		if pathExists(bind) {
 			bwrapArgs = append(bwrapArgs, "--bind", bind, bind)
 		}
 	}
 
 	// Workdir (always read-write)
-	if s.workdir != "" {
-		bwrapArgs = append(bwrapArgs, "--bind", s.workdir, s.workdir)
-		bwrapArgs = append(bwrapArgs, "--chdir", s.workdir)
+	if workdir != "" {
+		bwrapArgs = append(bwrapArgs, "--bind", workdir, workdir)
+		bwrapArgs = append(bwrapArgs, "--chdir", workdir)
 	}
 
 	// Device binds
-	for _, dev := range s.profile.DevBinds {
+	for _, dev := range profile.DevBinds {
 		if pathExists(dev) {
 			bwrapArgs = append(bwrapArgs, "--dev-bind", dev, dev)
 		}
 	}
 
 	// Tmpfs mounts
-	for _, tmp := range s.profile.Tmpfs {
+	for _, tmp := range profile.Tmpfs {
 		bwrapArgs = append(bwrapArgs, "--tmpfs", tmp)
 	}
 
 	// Symlinks
-	for dest, src := range s.profile.Symlinks {
+	for dest, src := range profile.Symlinks {
 		bwrapArgs = append(bwrapArgs, "--symlink", src, dest)
 	}
 
@@ -159,24 +106,24 @@ func (s *Sandbox) buildArgs(cmd string, args []string) []string {
 	bwrapArgs = append(bwrapArgs, "--proc", "/proc")
 
 	// Environment
-	if s.profile.ClearEnv {
+	if profile.ClearEnv {
 		bwrapArgs = append(bwrapArgs, "--clearenv")
 	}
 
 	// Pass through environment variables
-	for _, envVar := range s.profile.EnvPass {
+	for _, envVar := range profile.EnvPass {
 		if val, ok := os.LookupEnv(envVar); ok {
 			bwrapArgs = append(bwrapArgs, "--setenv", envVar, val)
 		}
 	}
 
 	// Set environment variables
-	for key, val := range s.profile.Env {
+	for key, val := range profile.Env {
 		bwrapArgs = append(bwrapArgs, "--setenv", key, val)
 	}
 
 	// Capability dropping
-	for _, cap := range s.profile.DropCaps {
+	for _, cap := range profile.DropCaps {
 		if cap == "ALL" {
 			bwrapArgs = append(bwrapArgs, "--cap-drop", "ALL")
 		} else {
@@ -191,93 +138,3 @@ func (s *Sandbox) buildArgs(cmd string, args []string) []string {
 
 	return bwrapArgs
 }
-
-// Run executes a command in the sandbox.
-func (s *Sandbox) Run(ctx context.Context, cmd string, args ...string) error {
-	bwrapArgs := s.buildArgs(cmd, args)
-
-	if s.verbose {
-		fmt.Fprintf(os.Stderr, "bwrap %s\n", strings.Join(bwrapArgs, " "))
-	}
-
-	execCmd := exec.CommandContext(ctx, s.bwrapPath, bwrapArgs...)
-	execCmd.Stdin = os.Stdin
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
-
-	if err := execCmd.Run(); err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return fmt.Errorf("%w: %s exited with code %d", ErrSandboxFailed, cmd, exitErr.ExitCode())
-		}
-		return fmt.Errorf("%w: %v", ErrSandboxFailed, err)
-	}
-
-	return nil
-}
-
-// RunOutput executes a command in the sandbox and returns its output.
-func (s *Sandbox) RunOutput(ctx context.Context, cmd string, args ...string) (string, error) {
-	bwrapArgs := s.buildArgs(cmd, args)
-
-	if s.verbose {
-		fmt.Fprintf(os.Stderr, "bwrap %s\n", strings.Join(bwrapArgs, " "))
-	}
-
-	execCmd := exec.CommandContext(ctx, s.bwrapPath, bwrapArgs...)
-	output, err := execCmd.CombinedOutput()
-	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return string(output), fmt.Errorf("%w: %s exited with code %d: %s",
-				ErrSandboxFailed, cmd, exitErr.ExitCode(), string(output))
-		}
-		return string(output), fmt.Errorf("%w: %v", ErrSandboxFailed, err)
-	}
-
-	return string(output), nil
-}
-
-// RunShell executes a shell command in the sandbox.
-func (s *Sandbox) RunShell(ctx context.Context, shellCmd string) error {
-	return s.Run(ctx, "/bin/bash", "-c", shellCmd)
-}
-
-// pathExists checks if a path exists.
-func pathExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
-
-// BuildSandbox creates a sandbox configured for building packages.
-func BuildSandbox(workdir string, extraBinds ...string) (*Sandbox, error) {
-	profile := ProfileBuild.Clone()
-	profile.AddBindReadWrite(extraBinds...)
-
-	sandbox, err := New(profile)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := sandbox.SetWorkdir(workdir); err != nil {
-		return nil, err
-	}
-
-	return sandbox, nil
-}
-
-// FetchSandbox creates a sandbox configured for fetching sources.
-func FetchSandbox(workdir string) (*Sandbox, error) {
-	profile := ProfileFetch.Clone()
-
-	sandbox, err := New(profile)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := sandbox.SetWorkdir(workdir); err != nil {
-		return nil, err
-	}
-
-	return sandbox, nil
-}
@@ -0,0 +1,101 @@
+package sandbox
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Custom describes a user-defined profile that extends a built-in one.
+type Custom struct {
+	// Base is the built-in profile extended: "build" (default), "fetch"
+	// or "minimal"
+	Base string
+
+	BindReadOnly  []string          // Extra read-only bind mounts
+	BindReadWrite []string          // Extra read-write bind mounts
+	EnvPass       []string          // Extra environment variables to pass through
+	Env           map[string]string // Extra environment variables to set
+
+	// Network allows network access. nil allows it, since makepkg
+	// downloads sources inside the sandbox.
+	Network *bool
+}
+
+// builtinProfiles are the profiles poxy ships, by name.
+var builtinProfiles = map[string]*Profile{
+	"build":   &ProfileBuild,
+	"fetch":   &ProfileFetch,
+	"minimal": &ProfileMinimal,
+}
+
+var (
+	customMu       sync.RWMutex
+	customProfiles = make(map[string]*Profile)
+)
+
+// Define registers a custom profile under name, so Lookup and
+// NewWithProfile can find it. Built-in profiles cannot be redefined.
+func Define(name string, c Custom) error {
+	if _, ok := builtinProfiles[name]; ok {
+		return fmt.Errorf("sandbox profile %q is built in and cannot be redefined", name)
+	}
+
+	base := c.Base
+	if base == "" {
+		base = "build"
+	}
+	builtin, ok := builtinProfiles[base]
+	if !ok {
+		return fmt.Errorf("sandbox profile %q: unknown base profile %q", name, base)
+	}
+
+	profile := builtin.Clone()
+	profile.Name = name
+	profile.AddBindReadOnly(c.BindReadOnly...)
+	profile.AddBindReadWrite(c.BindReadWrite...)
+	profile.EnvPass = append(profile.EnvPass, c.EnvPass...)
+	for key, value := range c.Env {
+		profile.SetEnv(key, value)
+	}
+	if c.Network == nil || *c.Network {
+		profile.AllowNetwork()
+	} else {
+		profile.DenyNetwork()
+	}
+
+	customMu.Lock()
+	customProfiles[name] = profile
+	customMu.Unlock()
+	return nil
+}
+
+// Lookup returns a copy of the named built-in or custom profile.
+func Lookup(name string) (*Profile, error) {
+	if profile, ok := builtinProfiles[name]; ok {
+		return profile.Clone(), nil
+	}
+
+	customMu.RLock()
+	defer customMu.RUnlock()
+	if profile, ok := customProfiles[name]; ok {
+		return profile.Clone(), nil
+	}
+	return nil, fmt.Errorf("unknown sandbox profile: %s", name)
+}
+
+// Names returns the names of the built-in and custom profiles, sorted.
+func Names() []string {
+	customMu.RLock()
+	defer customMu.RUnlock()
+
+	names := make([]string, 0, len(builtinProfiles)+len(customProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	for name := range customProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
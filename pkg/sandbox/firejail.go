@@ -0,0 +1,64 @@
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// firejail runs commands with firejail, which is setuid and so works where
+// unprivileged user namespaces are disabled.
+type firejail struct {
+	probe probe
+}
+
+// Name returns the backend name.
+func (f *firejail) Name() string {
+	return "firejail"
+}
+
+// Available reports whether firejail is installed and can start a sandbox.
+func (f *firejail) Available() bool {
+	return f.probe.run("firejail", "--quiet", "--noprofile", "--net=none", "true")
+}
+
+// Command returns the firejail command running cmd under profile.
+func (f *firejail) Command(ctx context.Context, profile *Profile, workdir, cmd string, args []string) *exec.Cmd {
+	execCmd := exec.CommandContext(ctx, "firejail", firejailArgs(profile, workdir, cmd, args)...)
+	execCmd.Dir = workdir
+	execCmd.Env = profileEnv(profile)
+	return execCmd
+}
+
+// firejailArgs constructs the firejail command line arguments. Firejail
+// cannot clear the environment itself, so Command sets it.
+func firejailArgs(profile *Profile, workdir, cmd string, args []string) []string {
+	fjArgs := []string{"--quiet", "--noprofile", "--read-only=/", "--private-dev", "--nonewprivs"}
+
+	for _, path := range writablePaths(profile, workdir) {
+		fjArgs = append(fjArgs, "--read-write="+path)
+	}
+	if slices.Contains(profile.Tmpfs, "/tmp") {
+		fjArgs = append(fjArgs, "--private-tmp")
+	}
+	if profile.UnshareNet {
+		fjArgs = append(fjArgs, "--net=none")
+	}
+	if profile.UnshareIPC {
+		fjArgs = append(fjArgs, "--ipc-namespace")
+	}
+
+	if slices.Contains(profile.DropCaps, "ALL") {
+		fjArgs = append(fjArgs, "--caps.drop=all")
+	} else if len(profile.DropCaps) > 0 {
+		caps := make([]string, 0, len(profile.DropCaps))
+		for _, c := range profile.DropCaps {
+			caps = append(caps, strings.ToLower(strings.TrimPrefix(c, "CAP_")))
+		}
+		fjArgs = append(fjArgs, "--caps.drop="+strings.Join(caps, ","))
+	}
+
+	fjArgs = append(fjArgs, "--", cmd)
+	return append(fjArgs, args...)
+}
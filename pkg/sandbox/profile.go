@@ -1,4 +1,5 @@
-// Package sandbox provides sandboxed execution using bubblewrap.
+// Package sandbox provides sandboxed execution using bubblewrap, or
+// firejail, systemd-run or unshare where bubblewrap is not available.
 package sandbox
 
 // Profile defines a sandbox configuration for different use cases.
@@ -0,0 +1,203 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ErrSandboxFailed is returned when sandbox execution fails
+var ErrSandboxFailed = errors.New("sandbox execution failed")
+
+// Sandbox runs commands under a profile with the selected backend.
+type Sandbox struct {
+	backend Backend
+	profile *Profile
+	workdir string
+	verbose bool
+
+	// Output of Run; nil for poxy's own
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// New creates a new sandbox with the given profile, using the backend
+// selected with SetBackend.
+func New(profile *Profile) (*Sandbox, error) {
+	backend, err := SelectBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sandbox{
+		backend: backend,
+		profile: profile,
+	}, nil
+}
+
+// NewWithProfile creates a sandbox with a named built-in or custom profile.
+func NewWithProfile(profileName string) (*Sandbox, error) {
+	profile, err := Lookup(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(profile)
+}
+
+// IsAvailable checks if a sandbox backend is available on the system.
+func IsAvailable() bool {
+	_, err := SelectBackend()
+	return err == nil
+}
+
+// SetWorkdir sets the working directory for sandboxed commands.
+// This directory will be bind-mounted read-write.
+func (s *Sandbox) SetWorkdir(dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	s.workdir = absDir
+	return nil
+}
+
+// SetVerbose enables verbose output of the sandbox command line.
+func (s *Sandbox) SetVerbose(verbose bool) {
+	s.verbose = verbose
+}
+
+// SetOutput makes Run write the command's output to stdout and stderr
+// instead of poxy's own.
+func (s *Sandbox) SetOutput(stdout, stderr io.Writer) {
+	s.stdout = stdout
+	s.stderr = stderr
+}
+
+// Profile returns the current profile.
+func (s *Sandbox) Profile() *Profile {
+	return s.profile
+}
+
+// Backend returns the backend commands run with.
+func (s *Sandbox) Backend() Backend {
+	return s.backend
+}
+
+// command returns the command that runs cmd in the sandbox.
+func (s *Sandbox) command(ctx context.Context, cmd string, args []string) *exec.Cmd {
+	execCmd := s.backend.Command(ctx, s.profile, s.workdir, cmd, args)
+	if s.verbose {
+		fmt.Fprintln(os.Stderr, execCmd.String())
+	}
+	return execCmd
+}
+
+// Run executes a command in the sandbox.
+func (s *Sandbox) Run(ctx context.Context, cmd string, args ...string) error {
+	execCmd := s.command(ctx, cmd, args)
+	execCmd.Stdin = os.Stdin
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+	if s.stdout != nil {
+		execCmd.Stdout = s.stdout
+	}
+	if s.stderr != nil {
+		execCmd.Stderr = s.stderr
+	}
+
+	if err := execCmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("%w: %s exited with code %d", ErrSandboxFailed, cmd, exitErr.ExitCode())
+		}
+		return fmt.Errorf("%w: %v", ErrSandboxFailed, err)
+	}
+
+	return nil
+}
+
+// RunOutput executes a command in the sandbox and returns its output.
+func (s *Sandbox) RunOutput(ctx context.Context, cmd string, args ...string) (string, error) {
+	execCmd := s.command(ctx, cmd, args)
+	output, err := execCmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return string(output), fmt.Errorf("%w: %s exited with code %d: %s",
+				ErrSandboxFailed, cmd, exitErr.ExitCode(), string(output))
+		}
+		return string(output), fmt.Errorf("%w: %v", ErrSandboxFailed, err)
+	}
+
+	return string(output), nil
+}
+
+// RunShell executes a shell command in the sandbox.
+func (s *Sandbox) RunShell(ctx context.Context, shellCmd string) error {
+	return s.Run(ctx, "/bin/bash", "-c", shellCmd)
+}
+
+// pathExists checks if a path exists.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// BuildSandbox creates a sandbox configured for building packages.
+func BuildSandbox(workdir string, extraBinds ...string) (*Sandbox, error) {
+	profile := ProfileBuild.Clone()
+	profile.AddBindReadWrite(extraBinds...)
+
+	sandbox, err := New(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sandbox.SetWorkdir(workdir); err != nil {
+		return nil, err
+	}
+
+	return sandbox, nil
+}
+
+// ProfileSandbox creates a sandbox for building packages with a named
+// built-in or custom profile instead of the build profile.
+func ProfileSandbox(profileName, workdir string, extraBinds ...string) (*Sandbox, error) {
+	profile, err := Lookup(profileName)
+	if err != nil {
+		return nil, err
+	}
+	profile.AddBindReadWrite(extraBinds...)
+
+	sandbox, err := New(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sandbox.SetWorkdir(workdir); err != nil {
+		return nil, err
+	}
+
+	return sandbox, nil
+}
+
+// FetchSandbox creates a sandbox configured for fetching sources.
+func FetchSandbox(workdir string) (*Sandbox, error) {
+	profile := ProfileFetch.Clone()
+
+	sandbox, err := New(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sandbox.SetWorkdir(workdir); err != nil {
+		return nil, err
+	}
+
+	return sandbox, nil
+}
@@ -0,0 +1,78 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// systemdRun runs commands as transient units of the user's systemd
+// manager, sandboxed with unit properties.
+type systemdRun struct {
+	probe probe
+}
+
+// Name returns the backend name.
+func (s *systemdRun) Name() string {
+	return "systemd-run"
+}
+
+// Available reports whether the user's systemd manager can start a
+// sandboxed unit.
+func (s *systemdRun) Available() bool {
+	return s.probe.run("systemd-run", "--user", "--quiet", "--wait", "--collect", "--pipe",
+		"--property=PrivateNetwork=yes", "--property=ProtectSystem=strict", "true")
+}
+
+// Command returns the systemd-run command running cmd under profile.
+func (s *systemdRun) Command(ctx context.Context, profile *Profile, workdir, cmd string, args []string) *exec.Cmd {
+	return exec.CommandContext(ctx, "systemd-run", systemdRunArgs(profile, workdir, cmd, args)...)
+}
+
+// systemdRunArgs constructs the systemd-run command line arguments. Units
+// start with the manager's environment, so only the profile's passed
+// through and set variables are added to it.
+func systemdRunArgs(profile *Profile, workdir, cmd string, args []string) []string {
+	runArgs := []string{"--user", "--quiet", "--wait", "--collect", "--pipe"}
+	if workdir != "" {
+		runArgs = append(runArgs, "--working-directory="+workdir)
+	}
+
+	properties := []string{
+		"ProtectSystem=strict",
+		"ProtectHome=read-only",
+		"PrivateDevices=yes",
+		"NoNewPrivileges=yes",
+	}
+	for _, path := range writablePaths(profile, workdir) {
+		properties = append(properties, "ReadWritePaths="+path)
+	}
+	if slices.Contains(profile.Tmpfs, "/tmp") {
+		properties = append(properties, "PrivateTmp=yes")
+	}
+	if profile.UnshareNet {
+		properties = append(properties, "PrivateNetwork=yes")
+	}
+	if slices.Contains(profile.DropCaps, "ALL") {
+		properties = append(properties, "CapabilityBoundingSet=")
+	} else if len(profile.DropCaps) > 0 {
+		properties = append(properties, "CapabilityBoundingSet=~"+strings.Join(profile.DropCaps, " "))
+	}
+	for _, p := range properties {
+		runArgs = append(runArgs, "--property="+p)
+	}
+
+	for _, key := range profile.EnvPass {
+		if val, ok := os.LookupEnv(key); ok {
+			runArgs = append(runArgs, "--setenv="+key+"="+val)
+		}
+	}
+	for key, val := range profile.Env {
+		runArgs = append(runArgs, "--setenv="+key+"="+val)
+	}
+
+	runArgs = append(runArgs, "--", cmd)
+	return append(runArgs, args...)
+}
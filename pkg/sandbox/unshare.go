@@ -0,0 +1,50 @@
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+)
+
+// unshare runs commands in new namespaces with util-linux unshare. It
+// isolates processes, network and IPC but not the filesystem, so it is the
+// last resort.
+type unshare struct {
+	probe probe
+}
+
+// Name returns the backend name.
+func (u *unshare) Name() string {
+	return "unshare"
+}
+
+// Available reports whether unshare can create a user namespace mapped to
+// the current user, which needs util-linux 2.38 or newer.
+func (u *unshare) Available() bool {
+	return u.probe.run("unshare", "--user", "--map-current-user", "--fork", "--pid", "--mount-proc", "--net", "true")
+}
+
+// Command returns the unshare command running cmd under profile.
+func (u *unshare) Command(ctx context.Context, profile *Profile, workdir, cmd string, args []string) *exec.Cmd {
+	execCmd := exec.CommandContext(ctx, "unshare", unshareArgs(profile, cmd, args)...)
+	execCmd.Dir = workdir
+	execCmd.Env = profileEnv(profile)
+	return execCmd
+}
+
+// unshareArgs constructs the unshare command line arguments. The current
+// user is kept, since makepkg refuses to run as root.
+func unshareArgs(profile *Profile, cmd string, args []string) []string {
+	usArgs := []string{"--user", "--map-current-user", "--fork", "--kill-child"}
+	if profile.UnsharePID {
+		usArgs = append(usArgs, "--pid", "--mount-proc")
+	}
+	if profile.UnshareNet {
+		usArgs = append(usArgs, "--net")
+	}
+	if profile.UnshareIPC {
+		usArgs = append(usArgs, "--ipc")
+	}
+
+	usArgs = append(usArgs, "--", cmd)
+	return append(usArgs, args...)
+}
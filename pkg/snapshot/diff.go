@@ -2,7 +2,9 @@ package snapshot
 
 import (
 	"fmt"
+	"path"
 	"sort"
+	"strings"
 )
 
 // ChangeType represents the type of change between snapshots.
@@ -174,7 +176,8 @@ func Compare(from, to *Snapshot) *Diff {
 				Source:     toPkg.Source,
 				NewVersion: toPkg.Version,
 			})
-		} else if fromPkg.Version != toPkg.Version {
+		} else if fromPkg.Version != toPkg.Version && fromPkg.Version != "" && toPkg.Version != "" {
+			// Versions are only compared when both are known
 			// Package version changed
 			changeType := ChangeUpgraded
 			if compareVersions(fromPkg.Version, toPkg.Version) > 0 {
@@ -272,6 +275,43 @@ func (d *Diff) Invert() *Diff {
 	return inverted
 }
 
+// Except returns the diff without the changes to packages matching any of
+// patterns. A pattern is a glob on the package name, such as "vim*", or
+// "source:glob" to only match packages of that source, such as
+// "flatpak:*".
+func (d *Diff) Except(patterns []string) (*Diff, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	filtered := &Diff{From: d.From, To: d.To, Changes: []Change{}}
+	for _, c := range d.Changes {
+		if !c.Matches(patterns) {
+			filtered.Changes = append(filtered.Changes, c)
+		}
+	}
+	return filtered, nil
+}
+
+// Matches reports whether the changed package matches any of patterns, as
+// described for Except.
+func (c Change) Matches(patterns []string) bool {
+	for _, pattern := range patterns {
+		if source, glob, ok := strings.Cut(pattern, ":"); ok {
+			if source != c.Source {
+				continue
+			}
+			pattern = glob
+		}
+		if ok, _ := path.Match(pattern, c.Package); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // compareVersions does a simple version comparison.
 // Returns -1 if a < b, 0 if a == b, 1 if a > b.
 // This is a simple lexicographic comparison; for more accurate
@@ -295,3 +335,62 @@ func DiffFromCurrent(from *Snapshot, current *Snapshot) *Diff {
 func DiffToRestore(target *Snapshot, current *Snapshot) *Diff {
 	return Compare(current, target)
 }
+
+// ManagerChange is a package manager whose backend version differs between
+// two snapshots.
+type ManagerChange struct {
+	Manager     string
+	FromVersion string
+	ToVersion   string
+}
+
+// DeploymentChange is a manager whose booted deployment differs between
+// two snapshots.
+type DeploymentChange struct {
+	Manager string
+	From    DeploymentState
+	To      DeploymentState
+}
+
+// CompareDeployments returns the managers that booted another deployment.
+// Managers without a booted deployment in either snapshot are ignored.
+func CompareDeployments(from, to *Snapshot) []DeploymentChange {
+	var changes []DeploymentChange
+	for _, f := range from.Deployments {
+		if !f.Booted {
+			continue
+		}
+		t := to.BootedDeployment(f.Source)
+		if t == nil || t.Checksum == f.Checksum {
+			continue
+		}
+		changes = append(changes, DeploymentChange{Manager: f.Source, From: f, To: *t})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Manager < changes[j].Manager
+	})
+	return changes
+}
+
+// CompareManagerVersions returns the managers whose backend version changed.
+// Managers without a recorded version in either snapshot are ignored.
+func CompareManagerVersions(from, to *Snapshot) []ManagerChange {
+	var changes []ManagerChange
+	for name, fromVersion := range from.ManagerVersions {
+		toVersion, ok := to.ManagerVersions[name]
+		if !ok || toVersion == fromVersion {
+			continue
+		}
+		changes = append(changes, ManagerChange{
+			Manager:     name,
+			FromVersion: fromVersion,
+			ToVersion:   toVersion,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Manager < changes[j].Manager
+	})
+	return changes
+}
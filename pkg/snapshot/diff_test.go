@@ -0,0 +1,55 @@
+package snapshot
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffExcept(t *testing.T) {
+	diff := &Diff{From: "a", To: "b", Changes: []Change{
+		{Type: ChangeAdded, Package: "vim", Source: "pacman", NewVersion: "9.1"},
+		{Type: ChangeAdded, Package: "vim-runtime", Source: "pacman", NewVersion: "9.1"},
+		{Type: ChangeUpgraded, Package: "org.gimp.GIMP", Source: "flatpak", OldVersion: "2.10", NewVersion: "3.0"},
+		{Type: ChangeRemoved, Package: "requests", Source: "pip", OldVersion: "2.31"},
+		{Type: ChangeAdded, Package: "vim", Source: "snap", NewVersion: "9.1"},
+	}}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"no patterns", nil, []string{"pacman/vim", "pacman/vim-runtime", "flatpak/org.gimp.GIMP", "pip/requests", "snap/vim"}},
+		{"name glob in any source", []string{"vim*"}, []string{"flatpak/org.gimp.GIMP", "pip/requests"}},
+		{"exact name", []string{"vim"}, []string{"pacman/vim-runtime", "flatpak/org.gimp.GIMP", "pip/requests"}},
+		{"whole source", []string{"flatpak:*"}, []string{"pacman/vim", "pacman/vim-runtime", "pip/requests", "snap/vim"}},
+		{"name in one source", []string{"snap:vim"}, []string{"pacman/vim", "pacman/vim-runtime", "flatpak/org.gimp.GIMP", "pip/requests"}},
+		{"several patterns", []string{"pip:*", "pacman:vim*", "flatpak:org.gimp.*"}, []string{"snap/vim"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diff.Except(tt.patterns)
+			if err != nil {
+				t.Fatalf("Except() error = %v", err)
+			}
+			var changes []string
+			for _, c := range got.Changes {
+				changes = append(changes, c.Source+"/"+c.Package)
+			}
+			if !slices.Equal(changes, tt.want) {
+				t.Errorf("Except(%v) = %v, want %v", tt.patterns, changes, tt.want)
+			}
+			if got.From != diff.From || got.To != diff.To {
+				t.Errorf("Except() = %s..%s, want %s..%s", got.From, got.To, diff.From, diff.To)
+			}
+		})
+	}
+
+	if _, err := diff.Except([]string{"vim[", "pip:*"}); err == nil {
+		t.Error("Except() of an invalid pattern succeeded")
+	}
+	if len(diff.Changes) != 5 {
+		t.Errorf("Except() changed the diff it filtered: %v", diff.Changes)
+	}
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"poxy/pkg/manager"
+
+	"go.etcd.io/bbolt"
+)
+
+// RecordResolutions remembers why packages were installed from their
+// source. Snapshots attach the resolution to the package for as long as it
+// stays installed.
+func (s *Store) RecordResolutions(resolutions []manager.Resolution) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketResolved))
+		if bucket == nil {
+			return fmt.Errorf("resolutions bucket not found")
+		}
+
+		for _, r := range resolutions {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("failed to marshal resolution: %w", err)
+			}
+			if err := bucket.Put([]byte(r.Key()), data); err != nil {
+				return fmt.Errorf("failed to save resolution: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ForgetResolutions removes the resolutions of packages that were removed.
+func (s *Store) ForgetResolutions(source string, names []string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketResolved))
+		if bucket == nil {
+			return nil
+		}
+		for _, name := range names {
+			if err := bucket.Delete([]byte(source + "/" + name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Resolutions returns the recorded resolutions keyed by source/name.
+func (s *Store) Resolutions() (map[string]manager.Resolution, error) {
+	resolutions := make(map[string]manager.Resolution)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketResolved))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var r manager.Resolution
+			if err := json.Unmarshal(v, &r); err != nil {
+				return nil // Skip corrupted entries
+			}
+			resolutions[string(k)] = r
+			return nil
+		})
+	})
+	return resolutions, err
+}
+
+// attachResolutions sets the resolution of each package in snap that poxy
+// recorded one for.
+func (s *Store) attachResolutions(snap *Snapshot) error {
+	resolutions, err := s.Resolutions()
+	if err != nil {
+		return err
+	}
+
+	for i := range snap.Packages {
+		pkg := &snap.Packages[i]
+		if r, ok := resolutions[pkg.Source+"/"+pkg.Name]; ok {
+			pkg.Resolution = &r
+		}
+	}
+	return nil
+}
@@ -2,12 +2,19 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 
 	"poxy/pkg/manager"
 )
 
+// ErrIncompleteSnapshot is returned when a restore would remove packages of a
+// source that failed to capture in the target snapshot. The snapshot has no
+// packages for that source, so every installed package would be removed.
+var ErrIncompleteSnapshot = errors.New("target snapshot is missing sources that failed to capture")
+
 // RestoreOpts configures how a restore operation is performed.
 type RestoreOpts struct {
 	// DryRun only shows what would be done without making changes.
@@ -21,6 +28,10 @@ type RestoreOpts struct {
 
 	// Sources limits the restore to specific package managers.
 	Sources []string
+
+	// Force plans removals even for sources that failed to capture in the
+	// target snapshot.
+	Force bool
 }
 
 // RestorePlan represents the operations needed to restore a snapshot.
@@ -30,10 +41,18 @@ type RestorePlan struct {
 	Diff     *Diff               // Difference between current and target
 	ToAdd    map[string][]string // Packages to install, by source
 	ToRemove map[string][]string // Packages to uninstall, by source
+	Streams  []ModuleState       // Module streams to enable before installing
+
+	// Skipped lists sources left out of the plan because their current
+	// packages could not be listed
+	Skipped []string
 }
 
 // IsEmpty returns true if no actions are needed.
 func (p *RestorePlan) IsEmpty() bool {
+	if len(p.Streams) > 0 {
+		return false
+	}
 	for _, pkgs := range p.ToAdd {
 		if len(pkgs) > 0 {
 			return false
@@ -58,11 +77,14 @@ func (p *RestorePlan) Summary() string {
 		removeCount += len(pkgs)
 	}
 
-	if addCount == 0 && removeCount == 0 {
+	if addCount == 0 && removeCount == 0 && len(p.Streams) == 0 {
 		return "No changes needed"
 	}
 
 	parts := []string{}
+	if len(p.Streams) > 0 {
+		parts = append(parts, fmt.Sprintf("%d module streams to enable", len(p.Streams)))
+	}
 	if addCount > 0 {
 		parts = append(parts, fmt.Sprintf("%d to install", addCount))
 	}
@@ -80,6 +102,41 @@ func (p *RestorePlan) Summary() string {
 	return result
 }
 
+// Sources returns the package managers the plan changes, sorted by name.
+func (p *RestorePlan) Sources() []string {
+	seen := make(map[string]bool)
+	for source, pkgs := range p.ToAdd {
+		if len(pkgs) > 0 {
+			seen[source] = true
+		}
+	}
+	for source, pkgs := range p.ToRemove {
+		if len(pkgs) > 0 {
+			seen[source] = true
+		}
+	}
+	for _, mod := range p.Streams {
+		seen[mod.Source] = true
+	}
+
+	sources := make([]string, 0, len(seen))
+	for source := range seen {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Packages returns the packages the plan installs or removes.
+func (p *RestorePlan) Packages() []string {
+	var packages []string
+	for _, source := range p.Sources() {
+		packages = append(packages, p.ToAdd[source]...)
+		packages = append(packages, p.ToRemove[source]...)
+	}
+	return packages
+}
+
 // PlanRestore creates a plan to restore to a previous snapshot.
 func PlanRestore(ctx context.Context, target *Snapshot, managers []manager.Manager, opts RestoreOpts) (*RestorePlan, error) {
 	// Capture current state
@@ -128,6 +185,12 @@ func PlanRestore(ctx context.Context, target *Snapshot, managers []manager.Manag
 		}
 	}
 
+	plan.Streams = planStreams(filteredTarget, filteredCurrent)
+
+	if err := checkCapture(plan, filteredTarget, filteredCurrent, opts); err != nil {
+		return nil, err
+	}
+
 	// Sort packages for consistent ordering
 	for source := range plan.ToAdd {
 		sort.Strings(plan.ToAdd[source])
@@ -139,6 +202,51 @@ func PlanRestore(ctx context.Context, target *Snapshot, managers []manager.Manag
 	return plan, nil
 }
 
+// checkCapture keeps sources that failed to capture from producing a plan
+// that would mass-install or mass-remove their packages. Sources whose
+// current state is unknown are skipped; removals for sources missing from
+// the target are refused unless forced.
+func checkCapture(plan *RestorePlan, target, current *Snapshot, opts RestoreOpts) error {
+	for _, source := range current.FailedSourceNames() {
+		if len(plan.ToAdd[source]) == 0 && len(plan.ToRemove[source]) == 0 {
+			continue
+		}
+		delete(plan.ToAdd, source)
+		delete(plan.ToRemove, source)
+		plan.Skipped = append(plan.Skipped, source)
+	}
+
+	if opts.Force {
+		return nil
+	}
+
+	var refused []string
+	for _, source := range target.FailedSourceNames() {
+		if pkgs := plan.ToRemove[source]; len(pkgs) > 0 {
+			refused = append(refused, fmt.Sprintf("%s (%d packages)", source, len(pkgs)))
+		}
+	}
+	if len(refused) > 0 {
+		return fmt.Errorf("%w: refusing to remove packages from %s; use --force to remove them anyway",
+			ErrIncompleteSnapshot, strings.Join(refused, ", "))
+	}
+
+	return nil
+}
+
+// planStreams returns the module streams enabled in target that differ from
+// the current state. Streams enabled since the target are left alone.
+func planStreams(target, current *Snapshot) []ModuleState {
+	var streams []ModuleState
+	for _, mod := range target.Modules {
+		if cur := current.GetModule(mod.Name, mod.Source); cur != nil && cur.Stream == mod.Stream {
+			continue
+		}
+		streams = append(streams, mod)
+	}
+	return streams
+}
+
 // filterSnapshot returns a new snapshot with only packages from the specified sources.
 func filterSnapshot(snap *Snapshot, sources map[string]bool) *Snapshot {
 	filtered := &Snapshot{
@@ -156,9 +264,86 @@ func filterSnapshot(snap *Snapshot, sources map[string]bool) *Snapshot {
 		}
 	}
 
+	for source, reason := range snap.FailedSources {
+		if sources[source] {
+			if filtered.FailedSources == nil {
+				filtered.FailedSources = make(map[string]string)
+			}
+			filtered.FailedSources[source] = reason
+		}
+	}
+
+	for _, mod := range snap.Modules {
+		if sources[mod.Source] {
+			filtered.Modules = append(filtered.Modules, mod)
+		}
+	}
+
 	return filtered
 }
 
+// RestoreAction identifies the kind of change a restore makes.
+type RestoreAction string
+
+const (
+	ActionEnableStream RestoreAction = "enable"
+	ActionInstall      RestoreAction = "install"
+	ActionRemove       RestoreAction = "remove"
+)
+
+// ActionResult is the outcome of restoring a single package or module stream.
+type ActionResult struct {
+	Action  RestoreAction
+	Source  string
+	Package string // Package name, or name:stream for module streams
+	Err     error  // Set if the action failed
+	Skipped string // Set to the reason if the action was not attempted
+}
+
+// OK returns true if the action was carried out.
+func (r ActionResult) OK() bool {
+	return r.Err == nil && r.Skipped == ""
+}
+
+// Results are the per-package outcomes of a restore, in execution order.
+type Results []ActionResult
+
+// Successful returns the number of actions that were carried out.
+func (r Results) Successful() int {
+	n := 0
+	for _, res := range r {
+		if res.OK() {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the actions that failed or were skipped.
+func (r Results) Failed() Results {
+	var failed Results
+	for _, res := range r {
+		if !res.OK() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err returns the failures joined into one error, or nil if every action
+// was carried out.
+func (r Results) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s [%s]: %w", res.Action, res.Package, res.Source, res.Err))
+		} else {
+			errs = append(errs, fmt.Errorf("%s %s [%s]: skipped: %s", res.Action, res.Package, res.Source, res.Skipped))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Executor performs restore operations.
 type Executor struct {
 	managers map[string]manager.Manager
@@ -178,70 +363,211 @@ func NewExecutor(managers []manager.Manager, opts RestoreOpts) *Executor {
 	}
 }
 
-// Execute performs the restore according to the plan.
-// Returns the number of successful operations and any error.
-func (e *Executor) Execute(ctx context.Context, plan *RestorePlan) (int, error) {
+// Execute performs the restore according to the plan and returns the result
+// of every action along with the joined failures.
+//
+// Module streams are enabled first so installs resolve against the right
+// stream, then each source's missing packages are installed in one batch,
+// then extra packages are removed in one batch per source. Sources are
+// processed in name order. Removals that installed packages outside the
+// plan still depend on are skipped rather than breaking those packages.
+func (e *Executor) Execute(ctx context.Context, plan *RestorePlan) (Results, error) {
 	if plan.IsEmpty() {
-		return 0, nil
+		return nil, nil
 	}
 
-	successful := 0
-	var lastErr error
+	var results Results
 
-	// First, install missing packages (safer to install before removing)
-	for source, packages := range plan.ToAdd {
-		mgr, ok := e.managers[source]
-		if !ok {
-			lastErr = fmt.Errorf("package manager not available: %s", source)
-			continue
-		}
+	for _, mod := range plan.Streams {
+		results = append(results, e.enableStream(ctx, mod))
+	}
 
-		if e.opts.DryRun {
-			successful += len(packages)
-			continue
+	installFailed := make(map[string]bool)
+	for _, source := range sortedSources(plan.ToAdd) {
+		res := e.install(ctx, source, plan.ToAdd[source])
+		if res.Failed() != nil {
+			installFailed[source] = true
 		}
+		results = append(results, res...)
+	}
 
-		opts := manager.InstallOpts{
-			AutoConfirm: e.opts.AutoConfirm,
-			DryRun:      e.opts.DryRun,
-		}
+	for _, source := range sortedSources(plan.ToRemove) {
+		// Dependencies that removal orphans were installed after the target,
+		// so they are in the plan too. That only holds if the target captured
+		// the source and the reinstalls that still need them succeeded.
+		recursive := !installFailed[source] && (plan.Target == nil || !plan.Target.CaptureFailed(source))
+		results = append(results, e.remove(ctx, source, plan.ToRemove[source], recursive)...)
+	}
 
-		if err := mgr.Install(ctx, packages, opts); err != nil {
-			lastErr = fmt.Errorf("failed to install packages from %s: %w", source, err)
-		} else {
-			successful += len(packages)
-		}
+	return results, results.Err()
+}
+
+// enableStream enables a single module stream.
+func (e *Executor) enableStream(ctx context.Context, mod ModuleState) ActionResult {
+	res := ActionResult{Action: ActionEnableStream, Source: mod.Source, Package: mod.Name + ":" + mod.Stream}
+
+	mgr, ok := e.managers[mod.Source]
+	if !ok {
+		res.Err = fmt.Errorf("package manager not available")
+		return res
 	}
 
-	// Then, remove extra packages
-	for source, packages := range plan.ToRemove {
-		mgr, ok := e.managers[source]
-		if !ok {
-			lastErr = fmt.Errorf("package manager not available: %s", source)
-			continue
+	sm, ok := mgr.(manager.StreamManager)
+	if !ok {
+		res.Err = fmt.Errorf("%s does not support module streams", mod.Source)
+		return res
+	}
+
+	if !e.opts.DryRun {
+		res.Err = sm.EnableStream(ctx, mod.Name, mod.Stream, false)
+	}
+	return res
+}
+
+// install installs a source's missing packages in one transaction.
+func (e *Executor) install(ctx context.Context, source string, packages []string) Results {
+	mgr, ok := e.managers[source]
+	if !ok {
+		return resultsFor(ActionInstall, source, packages, fmt.Errorf("package manager not available"))
+	}
+
+	if e.opts.DryRun {
+		return resultsFor(ActionInstall, source, packages, nil)
+	}
+
+	opts := manager.InstallOpts{
+		AutoConfirm: e.opts.AutoConfirm,
+		DryRun:      e.opts.DryRun,
+	}
+
+	err := mgr.Install(ctx, packages, opts)
+	if err == nil {
+		return resultsFor(ActionInstall, source, packages, nil)
+	}
+
+	// Attribute a failed batch to the packages that did not end up installed
+	return attribute(ctx, mgr, ActionInstall, packages, err, true)
+}
+
+// remove removes a source's extra packages in one transaction, keeping any
+// that installed packages outside the plan still depend on. Removal is
+// recursive only if recursive is set and nothing had to be kept.
+func (e *Executor) remove(ctx context.Context, source string, packages []string, recursive bool) Results {
+	mgr, ok := e.managers[source]
+	if !ok {
+		return resultsFor(ActionRemove, source, packages, fmt.Errorf("package manager not available"))
+	}
+
+	var results Results
+	removing, kept := holdBackRequired(ctx, mgr, packages)
+	for _, pkg := range sortedKeys(kept) {
+		results = append(results, ActionResult{
+			Action:  ActionRemove,
+			Source:  source,
+			Package: pkg,
+			Skipped: "required by " + strings.Join(kept[pkg], ", "),
+		})
+	}
+
+	if len(removing) == 0 {
+		return results
+	}
+
+	if e.opts.DryRun {
+		return append(resultsFor(ActionRemove, source, removing, nil), results...)
+	}
+
+	opts := manager.UninstallOpts{
+		AutoConfirm: e.opts.AutoConfirm,
+		DryRun:      e.opts.DryRun,
+		Recursive:   recursive && len(kept) == 0,
+	}
+
+	err := mgr.Uninstall(ctx, removing, opts)
+	if err == nil {
+		return append(resultsFor(ActionRemove, source, removing, nil), results...)
+	}
+
+	return append(attribute(ctx, mgr, ActionRemove, removing, err, false), results...)
+}
+
+// holdBackRequired splits packages into those safe to remove and those that
+// installed packages outside the removal still depend on. Keeping a package
+// can in turn require keeping its own dependencies, so this repeats until
+// the removal set is stable.
+func holdBackRequired(ctx context.Context, mgr manager.Manager, packages []string) ([]string, map[string][]string) {
+	removing := packages
+	kept := make(map[string][]string)
+
+	for len(removing) > 0 {
+		dependents := manager.FindDependents(ctx, mgr, removing)
+		if len(dependents) == 0 {
+			break
 		}
 
-		if e.opts.DryRun {
-			successful += len(packages)
-			continue
+		var next []string
+		for _, pkg := range removing {
+			if deps, ok := dependents[pkg]; ok {
+				kept[pkg] = deps
+			} else {
+				next = append(next, pkg)
+			}
 		}
+		removing = next
+	}
+
+	return removing, kept
+}
 
-		opts := manager.UninstallOpts{
-			AutoConfirm: e.opts.AutoConfirm,
-			DryRun:      e.opts.DryRun,
+// attribute works out which packages of a failed batch were affected by
+// checking their installed state afterwards. If that cannot be determined,
+// every package is reported as failed.
+func attribute(ctx context.Context, mgr manager.Manager, action RestoreAction, packages []string, err error, wantInstalled bool) Results {
+	results := make(Results, 0, len(packages))
+	for _, pkg := range packages {
+		res := ActionResult{Action: action, Source: mgr.Name(), Package: pkg}
+		installed, checkErr := mgr.IsInstalled(ctx, pkg)
+		if checkErr != nil || installed != wantInstalled {
+			res.Err = err
 		}
+		results = append(results, res)
+	}
+	return results
+}
 
-		if err := mgr.Uninstall(ctx, packages, opts); err != nil {
-			lastErr = fmt.Errorf("failed to remove packages from %s: %w", source, err)
-		} else {
-			successful += len(packages)
+// resultsFor returns the same outcome for every package.
+func resultsFor(action RestoreAction, source string, packages []string, err error) Results {
+	results := make(Results, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, ActionResult{Action: action, Source: source, Package: pkg, Err: err})
+	}
+	return results
+}
+
+// sortedSources returns the sources of a plan map with packages, sorted by name.
+func sortedSources(bySource map[string][]string) []string {
+	var sources []string
+	for source, pkgs := range bySource {
+		if len(pkgs) > 0 {
+			sources = append(sources, source)
 		}
 	}
+	sort.Strings(sources)
+	return sources
+}
 
-	return successful, lastErr
+// sortedKeys returns the keys of m sorted.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
-// Undo reverts the most recent operation by restoring the previous snapshot.
+// Undo reverts the most recent operation by restoring the snapshot taken
+// before it.
 func Undo(ctx context.Context, managers []manager.Manager, opts RestoreOpts) (*RestorePlan, error) {
 	store, err := OpenStore()
 	if err != nil {
@@ -249,20 +575,17 @@ func Undo(ctx context.Context, managers []manager.Manager, opts RestoreOpts) (*R
 	}
 	defer store.Close()
 
-	// Get the two most recent snapshots
-	snapshots, err := store.List(2, "")
+	// Snapshots are taken before each operation, so the newest of those
+	// is the state before the last operation. Manual and scheduled
+	// snapshots do not mark an operation.
+	target, err := store.LatestBeforeOperation()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+		return nil, fmt.Errorf("failed to read snapshots: %w", err)
 	}
-
-	if len(snapshots) < 2 {
-		return nil, fmt.Errorf("not enough snapshots to undo (need at least 2)")
+	if target == nil {
+		return nil, fmt.Errorf("no snapshot from before an operation to undo")
 	}
 
-	// The most recent snapshot is the current state (post-operation)
-	// The second-most-recent is the state before the operation
-	target := &snapshots[1] // Restore to the state before
-
 	return PlanRestore(ctx, target, managers, opts)
 }
 
@@ -286,6 +609,7 @@ func RestoreToSnapshot(ctx context.Context, snapshotID string, managers []manage
 type UndoResult struct {
 	Plan       *RestorePlan
 	Successful int
+	Results    Results
 	Error      error
 }
 
@@ -297,11 +621,12 @@ func PerformUndo(ctx context.Context, managers []manager.Manager, opts RestoreOp
 	}
 
 	executor := NewExecutor(managers, opts)
-	successful, execErr := executor.Execute(ctx, plan)
+	results, execErr := executor.Execute(ctx, plan)
 
 	return &UndoResult{
 		Plan:       plan,
-		Successful: successful,
+		Successful: results.Successful(),
+		Results:    results,
 		Error:      execErr,
 	}, nil
 }
@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 const (
 	bucketSnapshots = "snapshots"
 	bucketMeta      = "snapshot_meta"
+	bucketResolved  = "resolutions"
 	keyLatest       = "latest_id"
 	keyAutoPrefix   = "auto_"
 
@@ -39,13 +42,47 @@ const (
 	TriggerUpgrade   Trigger = "upgrade"   // Before system upgrade
 	TriggerUpdate    Trigger = "update"    // Before package database update
 	TriggerScheduled Trigger = "scheduled" // Scheduled/periodic snapshot
+	TriggerModule    Trigger = "module"    // Before a module stream change
+	TriggerUndo      Trigger = "undo"      // Before an undo restores a previous state
 )
 
+// BeforeOperation reports whether snapshots with this trigger are taken
+// before an operation, and so record the state that undoing it restores.
+func (t Trigger) BeforeOperation() bool {
+	switch t {
+	case TriggerManual, TriggerScheduled:
+		return false
+	}
+	return true
+}
+
 // PackageState represents a single installed package.
 type PackageState struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
 	Source  string `json:"source"` // Package manager that installed it
+
+	// Resolution explains why poxy installed the package from Source, if
+	// poxy picked the source
+	Resolution *manager.Resolution `json:"resolution,omitempty"`
+}
+
+// ModuleState represents an enabled module stream (DNF modularity).
+type ModuleState struct {
+	Name   string `json:"name"`
+	Stream string `json:"stream"`
+	Source string `json:"source"` // Package manager that owns the module
+}
+
+// DeploymentState records a deployment of an image-based system, such as
+// rpm-ostree's. Packages cannot bring back another image, so the checksum
+// tells which image the packages were layered onto.
+type DeploymentState struct {
+	Source   string `json:"source"` // Package manager that owns the deployment
+	Checksum string `json:"checksum"`
+	Version  string `json:"version,omitempty"`
+	Booted   bool   `json:"booted,omitempty"`
+	Pending  bool   `json:"pending,omitempty"` // Used by the next boot
 }
 
 // Snapshot represents the system state at a point in time.
@@ -55,6 +92,19 @@ type Snapshot struct {
 	Description string         `json:"description,omitempty"`
 	Trigger     Trigger        `json:"trigger"`
 	Packages    []PackageState `json:"packages"`
+	Modules     []ModuleState  `json:"modules,omitempty"`
+
+	// Deployments records the deployments of image-based systems
+	Deployments []DeploymentState `json:"deployments,omitempty"`
+
+	// ManagerVersions records each backend's version (e.g. "pacman" -> "6.1.0"),
+	// since restore and diff behavior can depend on it
+	ManagerVersions map[string]string `json:"manager_versions,omitempty"`
+
+	// FailedSources records managers whose packages could not be listed,
+	// mapped to the error. The snapshot has no packages for these sources,
+	// so it must not be read as "nothing installed"
+	FailedSources map[string]string `json:"failed_sources,omitempty"`
 
 	// Metadata about the operation that triggered this snapshot
 	Operation string   `json:"operation,omitempty"` // install, uninstall, upgrade
@@ -96,6 +146,43 @@ func (s *Snapshot) PackagesBySource() map[string][]PackageState {
 	return result
 }
 
+// GetModule returns the enabled stream of a module, or nil if none is recorded.
+func (s *Snapshot) GetModule(name, source string) *ModuleState {
+	for i := range s.Modules {
+		if s.Modules[i].Name == name && s.Modules[i].Source == source {
+			return &s.Modules[i]
+		}
+	}
+	return nil
+}
+
+// BootedDeployment returns the booted deployment of source, or nil if none
+// is recorded.
+func (s *Snapshot) BootedDeployment(source string) *DeploymentState {
+	for i := range s.Deployments {
+		if s.Deployments[i].Source == source && s.Deployments[i].Booted {
+			return &s.Deployments[i]
+		}
+	}
+	return nil
+}
+
+// CaptureFailed returns true if the packages of source could not be captured.
+func (s *Snapshot) CaptureFailed(source string) bool {
+	_, failed := s.FailedSources[source]
+	return failed
+}
+
+// FailedSourceNames returns the sources that failed to capture, sorted by name.
+func (s *Snapshot) FailedSourceNames() []string {
+	names := make([]string, 0, len(s.FailedSources))
+	for name := range s.FailedSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // HasPackage checks if a package is in this snapshot.
 func (s *Snapshot) HasPackage(name, source string) bool {
 	for _, pkg := range s.Packages {
@@ -130,13 +217,13 @@ type Store struct {
 	db *bbolt.DB
 }
 
-// OpenStore opens or creates the snapshot database.
+// OpenStore opens or creates the snapshot database. If the data directory
+// is not writable, an existing database is opened read-only.
 func OpenStore() (*Store, error) {
-	if err := config.EnsureDataDir(); err != nil {
-		return nil, fmt.Errorf("failed to create data directory: %w", err)
-	}
-
 	dbPath := config.SnapshotPath()
+	if !config.DataDirWritable() {
+		return openReadOnlyStore(dbPath)
+	}
 
 	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
 		Timeout: 1 * time.Second,
@@ -153,7 +240,10 @@ func OpenStore() (*Store, error) {
 		if _, err := tx.CreateBucketIfNotExists([]byte(bucketMeta)); err != nil {
 			return err
 		}
-		return nil
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucketResolved)); err != nil {
+			return err
+		}
+		return migrate(tx)
 	})
 	if err != nil {
 		db.Close()
@@ -163,6 +253,24 @@ func OpenStore() (*Store, error) {
 	return &Store{db: db}, nil
 }
 
+// openReadOnlyStore opens an existing snapshot database without write
+// access, failing with config.ErrDataDirReadOnly if it does not exist.
+func openReadOnlyStore(dbPath string) (*Store, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("%w: %s", config.ErrDataDirReadOnly, filepath.Dir(dbPath))
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
+		Timeout:  1 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
 // Close closes the database.
 func (s *Store) Close() error {
 	if s.db != nil {
@@ -179,20 +287,14 @@ func (s *Store) Save(snap *Snapshot) error {
 			return fmt.Errorf("snapshots bucket not found")
 		}
 
-		data, err := json.Marshal(snap)
-		if err != nil {
-			return fmt.Errorf("failed to marshal snapshot: %w", err)
-		}
-
-		key := []byte(snap.ID)
-		if err := bucket.Put(key, data); err != nil {
-			return fmt.Errorf("failed to save snapshot: %w", err)
+		if err := put(bucket, snap); err != nil {
+			return err
 		}
 
 		// Update latest reference
 		metaBucket := tx.Bucket([]byte(bucketMeta))
 		if metaBucket != nil {
-			_ = metaBucket.Put([]byte(keyLatest), key) //nolint:errcheck
+			_ = metaBucket.Put([]byte(keyLatest), []byte(snap.ID)) //nolint:errcheck
 		}
 
 		return nil
@@ -209,16 +311,14 @@ func (s *Store) Get(id string) (*Snapshot, error) {
 			return fmt.Errorf("snapshots bucket not found")
 		}
 
-		data := bucket.Get([]byte(id))
-		if data == nil {
-			return fmt.Errorf("snapshot not found: %s", id)
+		found, err := newReader(bucket).get(id)
+		if err != nil {
+			return err
 		}
-
-		var snapshot Snapshot
-		if err := json.Unmarshal(data, &snapshot); err != nil {
-			return fmt.Errorf("failed to unmarshal snapshot: %w", err)
+		if found == nil {
+			return fmt.Errorf("snapshot not found: %s", id)
 		}
-		snap = &snapshot
+		snap = found
 		return nil
 	})
 
@@ -241,11 +341,42 @@ func (s *Store) Latest() (*Snapshot, error) {
 			return nil
 		}
 
-		var snapshot Snapshot
-		if err := json.Unmarshal(v, &snapshot); err != nil {
+		found, err := newReader(bucket).decode(v)
+		if err != nil {
 			return err
 		}
-		snap = &snapshot
+		snap = found
+		return nil
+	})
+
+	return snap, err
+}
+
+// LatestBeforeOperation returns the most recent snapshot taken before an
+// operation, or nil if there is none.
+func (s *Store) LatestBeforeOperation() (*Snapshot, error) {
+	var snap *Snapshot
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketSnapshots))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			var meta Snapshot
+			if err := json.Unmarshal(v, &meta); err != nil || !meta.Trigger.BeforeOperation() {
+				continue
+			}
+
+			found, err := newReader(bucket).decode(v)
+			if err != nil {
+				return err
+			}
+			snap = found
+			return nil
+		}
 		return nil
 	})
 
@@ -263,20 +394,24 @@ func (s *Store) List(limit int, trigger Trigger) ([]Snapshot, error) {
 		}
 
 		cursor := bucket.Cursor()
+		r := newReader(bucket)
 
 		// Start from the end (most recent) and go backwards
 		for k, v := cursor.Last(); k != nil && (limit <= 0 || len(snapshots) < limit); k, v = cursor.Prev() {
-			var snap Snapshot
-			if err := json.Unmarshal(v, &snap); err != nil {
+			// Filter by trigger if specified, before reconstructing packages
+			var meta Snapshot
+			if err := json.Unmarshal(v, &meta); err != nil {
 				continue // Skip malformed entries
 			}
-
-			// Filter by trigger if specified
-			if trigger != "" && snap.Trigger != trigger {
+			if trigger != "" && meta.Trigger != trigger {
 				continue
 			}
 
-			snapshots = append(snapshots, snap)
+			snap, err := r.decode(v)
+			if err != nil {
+				continue // Skip entries whose base is missing
+			}
+			snapshots = append(snapshots, *snap)
 		}
 
 		return nil
@@ -293,7 +428,7 @@ func (s *Store) Delete(id string) error {
 			return fmt.Errorf("snapshots bucket not found")
 		}
 
-		return bucket.Delete([]byte(id))
+		return remove(bucket, id)
 	})
 }
 
@@ -366,7 +501,7 @@ func (s *Store) Prune(keepCount int, keepAutoCount int) (int, error) {
 
 		// Delete marked snapshots
 		for id := range toDelete {
-			if err := bucket.Delete([]byte(id)); err != nil {
+			if err := remove(bucket, id); err != nil {
 				return err
 			}
 			deleted++
@@ -404,7 +539,7 @@ func (s *Store) PruneByAge(maxAge time.Duration) (int, error) {
 		}
 
 		for _, k := range toDelete {
-			if err := bucket.Delete(k); err != nil {
+			if err := remove(bucket, string(k)); err != nil {
 				return err
 			}
 			deleted++
@@ -427,7 +562,11 @@ func Capture(ctx context.Context, trigger Trigger, description string, managers
 
 		packages, err := mgr.ListInstalled(ctx, manager.ListOpts{})
 		if err != nil {
-			// Log but continue - don't fail the whole snapshot for one manager
+			// Record but continue - don't fail the whole snapshot for one manager
+			if snap.FailedSources == nil {
+				snap.FailedSources = make(map[string]string)
+			}
+			snap.FailedSources[mgr.Name()] = err.Error()
 			continue
 		}
 
@@ -438,15 +577,47 @@ func Capture(ctx context.Context, trigger Trigger, description string, managers
 				Source:  mgr.Name(),
 			})
 		}
+
+		// Record enabled module streams so restores can re-enable them
+		if sm, ok := mgr.(manager.StreamManager); ok {
+			streams, err := sm.ListStreams(ctx)
+			if err != nil {
+				continue
+			}
+			for _, s := range streams {
+				if s.Enabled {
+					snap.Modules = append(snap.Modules, ModuleState{
+						Name:   s.Name,
+						Stream: s.Stream,
+						Source: mgr.Name(),
+					})
+				}
+			}
+		}
+
+		// Record the deployments, whose checksums identify the image
+		if dl, ok := mgr.(manager.DeploymentLister); ok {
+			deployments, err := dl.Deployments(ctx)
+			if err != nil {
+				continue
+			}
+			pending := manager.PendingDeployment(deployments)
+			for i, d := range deployments {
+				snap.Deployments = append(snap.Deployments, DeploymentState{
+					Source:   mgr.Name(),
+					Checksum: d.Checksum,
+					Version:  d.Version,
+					Booted:   d.Booted,
+					Pending:  pending != nil && i == 0,
+				})
+			}
+		}
 	}
 
+	snap.ManagerVersions = manager.DetectVersions(ctx, managers)
+
 	// Sort packages for consistent ordering
-	sort.Slice(snap.Packages, func(i, j int) bool {
-		if snap.Packages[i].Source != snap.Packages[j].Source {
-			return snap.Packages[i].Source < snap.Packages[j].Source
-		}
-		return snap.Packages[i].Name < snap.Packages[j].Name
-	})
+	sortPackages(snap.Packages)
 
 	return snap, nil
 }
@@ -464,6 +635,9 @@ func CaptureAndSave(ctx context.Context, trigger Trigger, description string, ma
 	}
 	defer store.Close()
 
+	// Resolutions are best effort; the snapshot is still valid without them
+	_ = store.attachResolutions(snap) //nolint:errcheck
+
 	if err := store.Save(snap); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,309 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"poxy/pkg/manager"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// keyFormat records the storage format of the snapshots bucket.
+	keyFormat = "storage_format"
+
+	// formatDelta stores most snapshots as changes from a full one.
+	// Databases without a format hold full snapshots only.
+	formatDelta = "delta"
+
+	// fullEvery is how many snapshots share a full snapshot: after
+	// fullEvery-1 deltas, the next snapshot is stored in full again.
+	fullEvery = 10
+)
+
+// record is a snapshot as stored. A full record holds the packages; a
+// delta record holds the changes from the full record Delta.From instead,
+// so systems with thousands of packages do not store them all each time.
+type record struct {
+	Snapshot
+
+	// Delta is the package changes from the base snapshot
+	Delta *Diff `json:"delta,omitempty"`
+
+	// Resolutions are the package resolutions that differ from the base,
+	// keyed by source/name; nil means the package has none
+	Resolutions map[string]*manager.Resolution `json:"delta_resolutions,omitempty"`
+}
+
+// isDelta returns true if the record depends on a base snapshot.
+func (r *record) isDelta() bool {
+	return r.Delta != nil
+}
+
+// reader decodes records from the snapshots bucket, caching the full
+// snapshots deltas are based on.
+type reader struct {
+	bucket *bbolt.Bucket
+	bases  map[string]*Snapshot
+}
+
+func newReader(bucket *bbolt.Bucket) *reader {
+	return &reader{bucket: bucket, bases: make(map[string]*Snapshot)}
+}
+
+// get returns the snapshot stored under id, or nil if there is none.
+func (r *reader) get(id string) (*Snapshot, error) {
+	data := r.bucket.Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+	return r.decode(data)
+}
+
+// decode returns the snapshot of a stored record, applying its delta to
+// the base snapshot.
+func (r *reader) decode(data []byte) (*Snapshot, error) {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+	if !rec.isDelta() {
+		return &rec.Snapshot, nil
+	}
+
+	base, err := r.base(rec.Delta.From)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", rec.ID, err)
+	}
+	snap := rec.Snapshot
+	snap.Packages = applyDelta(base.Packages, rec.Delta, rec.Resolutions)
+	return &snap, nil
+}
+
+// base returns the full snapshot id.
+func (r *reader) base(id string) (*Snapshot, error) {
+	if snap, ok := r.bases[id]; ok {
+		return snap, nil
+	}
+
+	data := r.bucket.Get([]byte(id))
+	if data == nil {
+		return nil, fmt.Errorf("base snapshot %s is missing", id)
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal base snapshot %s: %w", id, err)
+	}
+	if rec.isDelta() {
+		return nil, fmt.Errorf("base snapshot %s is not a full snapshot", id)
+	}
+
+	r.bases[id] = &rec.Snapshot
+	return &rec.Snapshot, nil
+}
+
+// applyDelta returns the packages of base with the changes and resolutions
+// of a delta applied, sorted like captured snapshots.
+func applyDelta(base []PackageState, diff *Diff, resolutions map[string]*manager.Resolution) []PackageState {
+	state := make(map[string]PackageState, len(base))
+	for _, pkg := range base {
+		state[pkg.Source+"/"+pkg.Name] = pkg
+	}
+
+	for _, c := range diff.Changes {
+		key := c.Source + "/" + c.Package
+		switch c.Type {
+		case ChangeRemoved:
+			delete(state, key)
+		case ChangeAdded:
+			state[key] = PackageState{Name: c.Package, Version: c.NewVersion, Source: c.Source}
+		default:
+			pkg := state[key]
+			pkg.Version = c.NewVersion
+			state[key] = pkg
+		}
+	}
+
+	for key, resolution := range resolutions {
+		if pkg, ok := state[key]; ok {
+			pkg.Resolution = resolution
+			state[key] = pkg
+		}
+	}
+
+	packages := make([]PackageState, 0, len(state))
+	for _, pkg := range state {
+		packages = append(packages, pkg)
+	}
+	sortPackages(packages)
+	return packages
+}
+
+// sortPackages sorts packages by source, then name.
+func sortPackages(packages []PackageState) {
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Source != packages[j].Source {
+			return packages[i].Source < packages[j].Source
+		}
+		return packages[i].Name < packages[j].Name
+	})
+}
+
+// encode returns the record of snap: a delta from base if that reproduces
+// snap exactly and is smaller, otherwise the full snapshot. Compare skips
+// version changes it cannot order, so not every snapshot has a delta.
+func encode(snap, base *Snapshot) *record {
+	full := &record{Snapshot: *snap}
+	if base == nil || base.ID == snap.ID {
+		return full
+	}
+
+	diff := Compare(base, snap)
+	if len(diff.Changes) > len(snap.Packages)/2 {
+		return full
+	}
+
+	resolutions := make(map[string]*manager.Resolution)
+	previous := make(map[string]*manager.Resolution, len(base.Packages))
+	for _, pkg := range base.Packages {
+		previous[pkg.Source+"/"+pkg.Name] = pkg.Resolution
+	}
+	for _, pkg := range snap.Packages {
+		key := pkg.Source + "/" + pkg.Name
+		if !reflect.DeepEqual(previous[key], pkg.Resolution) {
+			resolutions[key] = pkg.Resolution
+		}
+	}
+
+	if !reflect.DeepEqual(applyDelta(base.Packages, diff, resolutions), snap.Packages) {
+		return full
+	}
+
+	delta := &record{Snapshot: *snap, Delta: diff}
+	delta.Packages = nil
+	if len(resolutions) > 0 {
+		delta.Resolutions = resolutions
+	}
+	return delta
+}
+
+// put stores snap under its ID, as a delta from the newest full snapshot
+// unless fullEvery snapshots already share it. A snapshot already stored
+// under the ID is replaced.
+func put(bucket *bbolt.Bucket, snap *Snapshot) error {
+	if bucket.Get([]byte(snap.ID)) != nil {
+		if err := remove(bucket, snap.ID); err != nil {
+			return err
+		}
+	}
+
+	return write(bucket, encode(snap, newestBase(bucket)))
+}
+
+// newestBase returns the newest full snapshot, or nil if the next snapshot
+// should be stored in full.
+func newestBase(bucket *bbolt.Bucket) *Snapshot {
+	cursor := bucket.Cursor()
+	deltas := 0
+	for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+		var rec record
+		if err := json.Unmarshal(v, &rec); err != nil {
+			continue // Skip malformed entries
+		}
+		if !rec.isDelta() {
+			if deltas+1 >= fullEvery {
+				return nil
+			}
+			return &rec.Snapshot
+		}
+		deltas++
+	}
+	return nil
+}
+
+// write stores a record under its snapshot ID.
+func write(bucket *bbolt.Bucket, rec *record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := bucket.Put([]byte(rec.ID), data); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the snapshot id. If deltas are based on it, the oldest of
+// them is stored in full and the others are rebased onto it first.
+func remove(bucket *bbolt.Bucket, id string) error {
+	r := newReader(bucket)
+
+	var dependents []*Snapshot
+	cursor := bucket.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var rec record
+		if err := json.Unmarshal(v, &rec); err != nil || !rec.isDelta() || rec.Delta.From != id {
+			continue
+		}
+		snap, err := r.decode(v)
+		if err != nil {
+			return err
+		}
+		dependents = append(dependents, snap)
+	}
+
+	if len(dependents) > 0 {
+		base := dependents[0]
+		if err := write(bucket, encode(base, nil)); err != nil {
+			return err
+		}
+		for _, snap := range dependents[1:] {
+			if err := write(bucket, encode(snap, base)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bucket.Delete([]byte(id))
+}
+
+// migrate converts a database of full snapshots to delta storage. It runs
+// once; later opens find the format recorded.
+func migrate(tx *bbolt.Tx) error {
+	meta := tx.Bucket([]byte(bucketMeta))
+	bucket := tx.Bucket([]byte(bucketSnapshots))
+	if meta == nil || bucket == nil {
+		return nil
+	}
+	if string(meta.Get([]byte(keyFormat))) == formatDelta {
+		return nil
+	}
+
+	// Legacy records are all full, so they decode on their own
+	var snapshots []*Snapshot
+	cursor := bucket.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var rec record
+		if err := json.Unmarshal(v, &rec); err != nil {
+			continue // Left in place, as before
+		}
+		snap := rec.Snapshot
+		snapshots = append(snapshots, &snap)
+	}
+
+	for _, snap := range snapshots {
+		if err := bucket.Delete([]byte(snap.ID)); err != nil {
+			return err
+		}
+	}
+	for _, snap := range snapshots {
+		if err := put(bucket, snap); err != nil {
+			return fmt.Errorf("failed to migrate snapshot %s: %w", snap.ID, err)
+		}
+	}
+
+	return meta.Put([]byte(keyFormat), []byte(formatDelta))
+}
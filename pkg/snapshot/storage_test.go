@@ -0,0 +1,278 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"poxy/internal/config"
+	"poxy/pkg/manager"
+
+	"go.etcd.io/bbolt"
+)
+
+// openTestStore opens a snapshot store in a temporary data directory.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := os.MkdirAll(config.DataDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := OpenStore()
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// testSnapshot returns snapshot n of a system with count packages, of which
+// the first n have been upgraded once.
+func testSnapshot(n, count int) *Snapshot {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	snap := &Snapshot{
+		ID:        fmt.Sprintf("snap-%03d", n),
+		Timestamp: start.Add(time.Duration(n) * time.Hour),
+		Trigger:   TriggerInstall,
+	}
+	for i := 0; i < count; i++ {
+		version := "1.0"
+		if i < n {
+			version = "1.1"
+		}
+		snap.Packages = append(snap.Packages, PackageState{Name: fmt.Sprintf("pkg%03d", i), Version: version, Source: "pacman"})
+	}
+	return snap
+}
+
+// storedRecord returns the record stored under id.
+func storedRecord(t *testing.T, store *Store, id string) *record {
+	t.Helper()
+
+	var rec *record
+	err := store.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(bucketSnapshots)).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("no record %s", id)
+		}
+		rec = &record{}
+		return json.Unmarshal(data, rec)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	base := testSnapshot(0, 20)
+	snap := testSnapshot(2, 20)
+	snap.ID = "next"
+	snap.Packages = append(snap.Packages[1:], PackageState{Name: "zsh", Version: "5.9", Source: "pacman"})
+	snap.Packages[0].Resolution = &manager.Resolution{Requested: "pkg001", Source: "pacman", Name: "pkg001"}
+
+	rec := encode(snap, base)
+	if !rec.isDelta() || rec.Delta.From != base.ID || rec.Packages != nil {
+		t.Fatalf("encode() = %+v, want a delta from %s without packages", rec, base.ID)
+	}
+
+	packages := applyDelta(base.Packages, rec.Delta, rec.Resolutions)
+	if !reflect.DeepEqual(packages, snap.Packages) {
+		t.Errorf("decoded packages = %+v, want %+v", packages, snap.Packages)
+	}
+
+	// Too many changes are stored in full
+	if rec := encode(testSnapshot(15, 20), base); rec.isDelta() {
+		t.Error("encode() stored a mostly changed snapshot as a delta")
+	}
+	if rec := encode(base, nil); rec.isDelta() {
+		t.Error("encode() without a base stored a delta")
+	}
+}
+
+func TestStoreDeltas(t *testing.T) {
+	store := openTestStore(t)
+
+	for n := 0; n < fullEvery+1; n++ {
+		if err := store.Save(testSnapshot(n, 20)); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	for n := 0; n < fullEvery+1; n++ {
+		id := testSnapshot(n, 20).ID
+		rec := storedRecord(t, store, id)
+		full := n%fullEvery == 0
+		if rec.isDelta() == full {
+			t.Errorf("%s stored as delta = %v, want %v", id, rec.isDelta(), !full)
+		}
+
+		got, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if !reflect.DeepEqual(got.Packages, testSnapshot(n, 20).Packages) {
+			t.Errorf("Get(%s) packages differ from those saved", id)
+		}
+	}
+}
+
+func TestDeleteBaseRebasesDependents(t *testing.T) {
+	store := openTestStore(t)
+
+	for n := 0; n < 4; n++ {
+		if err := store.Save(testSnapshot(n, 20)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Delete("snap-000"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if rec := storedRecord(t, store, "snap-001"); rec.isDelta() {
+		t.Error("the oldest dependent was not stored in full")
+	}
+	for _, id := range []string{"snap-002", "snap-003"} {
+		if rec := storedRecord(t, store, id); !rec.isDelta() || rec.Delta.From != "snap-001" {
+			t.Errorf("%s was not rebased onto snap-001", id)
+		}
+	}
+
+	for n := 1; n < 4; n++ {
+		got, err := store.Get(testSnapshot(n, 20).ID)
+		if err != nil {
+			t.Fatalf("Get() after deleting the base error = %v", err)
+		}
+		if !reflect.DeepEqual(got.Packages, testSnapshot(n, 20).Packages) {
+			t.Errorf("%s changed when its base was deleted", got.ID)
+		}
+	}
+	if _, err := store.Get("snap-000"); err == nil {
+		t.Error("Get() found the deleted snapshot")
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	store := openTestStore(t)
+
+	// Write full snapshots the way databases without a format hold them
+	err := store.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket([]byte(bucketMeta)).Delete([]byte(keyFormat)); err != nil {
+			return err
+		}
+		for n := 0; n < 3; n++ {
+			snap := testSnapshot(n, 20)
+			data, err := json.Marshal(snap)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket([]byte(bucketSnapshots)).Put([]byte(snap.ID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.db.Update(migrate); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+
+	if rec := storedRecord(t, store, "snap-000"); rec.isDelta() {
+		t.Error("the oldest snapshot was not kept in full")
+	}
+	for _, id := range []string{"snap-001", "snap-002"} {
+		if rec := storedRecord(t, store, id); !rec.isDelta() {
+			t.Errorf("%s was not converted to a delta", id)
+		}
+	}
+	for n := 0; n < 3; n++ {
+		got, err := store.Get(testSnapshot(n, 20).ID)
+		if err != nil || !reflect.DeepEqual(got.Packages, testSnapshot(n, 20).Packages) {
+			t.Errorf("Get(%s) after migrating = %v, %v", testSnapshot(n, 20).ID, got, err)
+		}
+	}
+
+	err = store.db.View(func(tx *bbolt.Tx) error {
+		if format := string(tx.Bucket([]byte(bucketMeta)).Get([]byte(keyFormat))); format != formatDelta {
+			t.Errorf("format = %q, want %q", format, formatDelta)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store := openTestStore(t)
+
+	for n := 0; n < 6; n++ {
+		snap := testSnapshot(n, 20)
+		if n%2 == 0 {
+			snap.Trigger = TriggerManual
+		}
+		if err := store.Save(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Keep the four newest, and of those the newest automatic one
+	deleted, err := store.Prune(4, 1)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Prune() deleted %d, want 3", deleted)
+	}
+
+	remaining, err := store.List(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, snap := range remaining {
+		ids = append(ids, snap.ID)
+		var n int
+		if _, err := fmt.Sscanf(snap.ID, "snap-%d", &n); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(snap.Packages, testSnapshot(n, 20).Packages) {
+			t.Errorf("%s changed when pruning", snap.ID)
+		}
+	}
+	if want := []string{"snap-005", "snap-004", "snap-002"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("after Prune() = %v, want %v", ids, want)
+	}
+}
+
+func TestLatestBeforeOperation(t *testing.T) {
+	store := openTestStore(t)
+
+	if got, err := store.LatestBeforeOperation(); err != nil || got != nil {
+		t.Errorf("LatestBeforeOperation() in an empty store = %+v, %v, want none", got, err)
+	}
+
+	for n, trigger := range []Trigger{TriggerInstall, TriggerUndo, TriggerManual, TriggerScheduled} {
+		snap := testSnapshot(n, 5)
+		snap.Trigger = trigger
+		if err := store.Save(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.LatestBeforeOperation()
+	if err != nil {
+		t.Fatalf("LatestBeforeOperation() error = %v", err)
+	}
+	if got == nil || got.ID != "snap-001" || len(got.Packages) != 5 {
+		t.Errorf("LatestBeforeOperation() = %+v, want snap-001 from before the undo", got)
+	}
+}
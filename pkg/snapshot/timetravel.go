@@ -0,0 +1,141 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Delta is a recorded package change used to replay state between snapshots.
+// It is deliberately independent of the history package so callers can build
+// deltas from any operation log.
+type Delta struct {
+	Timestamp time.Time
+	Added     bool     // true for installs, false for removals
+	Source    string   // Package manager that performed the change
+	Packages  []string // Packages affected
+}
+
+// Nearest returns the snapshot closest to the given time.
+// Snapshots taken at or before the time are preferred; if none exist,
+// the oldest snapshot taken after it is returned. Returns nil if the
+// store is empty.
+func (s *Store) Nearest(at time.Time) (*Snapshot, error) {
+	var before, after *Snapshot
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketSnapshots))
+		if bucket == nil {
+			return nil
+		}
+
+		var beforeData, afterData []byte
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var snap Snapshot
+			if err := json.Unmarshal(v, &snap); err != nil {
+				continue // Skip malformed entries
+			}
+
+			if !snap.Timestamp.After(at) {
+				if before == nil || snap.Timestamp.After(before.Timestamp) {
+					found := snap
+					before, beforeData = &found, v
+				}
+			} else if after == nil || snap.Timestamp.Before(after.Timestamp) {
+				found := snap
+				after, afterData = &found, v
+			}
+		}
+
+		// Only the chosen snapshot needs its packages reconstructed
+		data := beforeData
+		if before == nil {
+			data = afterData
+		}
+		if data == nil {
+			return nil
+		}
+		snap, err := newReader(bucket).decode(data)
+		if err != nil {
+			return err
+		}
+		before = snap
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// Reconstruct derives the package set at the given time from a base snapshot
+// and the deltas recorded around it. Deltas after the base snapshot are
+// replayed forward; if the base was taken after the target time, deltas in
+// between are undone instead. Packages added by a delta have no known version.
+func Reconstruct(base *Snapshot, deltas []Delta, at time.Time) *Snapshot {
+	state := make(map[string]PackageState, len(base.Packages))
+	for _, pkg := range base.Packages {
+		state[pkg.Source+"/"+pkg.Name] = pkg
+	}
+
+	sorted := make([]Delta, len(deltas))
+	copy(sorted, deltas)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	apply := func(d Delta, added bool) {
+		for _, name := range d.Packages {
+			key := d.Source + "/" + name
+			if added {
+				if _, exists := state[key]; !exists {
+					state[key] = PackageState{Name: name, Source: d.Source}
+				}
+			} else {
+				delete(state, key)
+			}
+		}
+	}
+
+	if !base.Timestamp.After(at) {
+		// Replay changes made after the snapshot up to the target time
+		for _, d := range sorted {
+			if d.Timestamp.After(base.Timestamp) && !d.Timestamp.After(at) {
+				apply(d, d.Added)
+			}
+		}
+	} else {
+		// Undo changes made between the target time and the snapshot
+		for i := len(sorted) - 1; i >= 0; i-- {
+			d := sorted[i]
+			if d.Timestamp.After(at) && d.Timestamp.Before(base.Timestamp) {
+				apply(d, !d.Added)
+			}
+		}
+	}
+
+	result := &Snapshot{
+		ID:          "as-of-" + at.Format("20060102-150405"),
+		Timestamp:   at,
+		Description: fmt.Sprintf("reconstructed from %s", base.ID),
+		Trigger:     base.Trigger,
+		Packages:    make([]PackageState, 0, len(state)),
+	}
+	for _, pkg := range state {
+		result.Packages = append(result.Packages, pkg)
+	}
+
+	sort.Slice(result.Packages, func(i, j int) bool {
+		if result.Packages[i].Source != result.Packages[j].Source {
+			return result.Packages[i].Source < result.Packages[j].Source
+		}
+		return result.Packages[i].Name < result.Packages[j].Name
+	})
+
+	return result
+}
@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// packageNames returns the names of the packages in snap, in order.
+func packageNames(snap *Snapshot) []string {
+	names := make([]string, 0, len(snap.Packages))
+	for _, pkg := range snap.Packages {
+		names = append(names, pkg.Name)
+	}
+	return names
+}
+
+func TestNearest(t *testing.T) {
+	store := openTestStore(t)
+
+	if got, err := store.Nearest(time.Now()); err != nil || got != nil {
+		t.Errorf("Nearest() in an empty store = %+v, %v, want none", got, err)
+	}
+
+	for _, n := range []int{1, 3, 5} {
+		if err := store.Save(testSnapshot(n, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hour := func(n int) time.Time { return testSnapshot(n, 0).Timestamp }
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"before the first snapshot", hour(0), "snap-001"},
+		{"exact match", hour(3), "snap-003"},
+		{"between snapshots", hour(4), "snap-003"},
+		{"after the last snapshot", hour(9), "snap-005"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Nearest(tt.at)
+			if err != nil {
+				t.Fatalf("Nearest() error = %v", err)
+			}
+			if got == nil || got.ID != tt.want {
+				t.Fatalf("Nearest(%s) = %+v, want %s", tt.at, got, tt.want)
+			}
+			if len(got.Packages) != 10 {
+				t.Errorf("Nearest() returned %d packages, want the 10 of %s", len(got.Packages), tt.want)
+			}
+		})
+	}
+}
+
+func TestReconstruct(t *testing.T) {
+	taken := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	base := &Snapshot{
+		ID:        "base",
+		Timestamp: taken,
+		Trigger:   TriggerInstall,
+		Packages: []PackageState{
+			{Name: "curl", Version: "8.0", Source: "pacman"},
+			{Name: "git", Version: "2.45", Source: "pacman"},
+			{Name: "vim", Version: "9.1", Source: "pacman"},
+		},
+	}
+	deltas := []Delta{
+		{Timestamp: taken.Add(2 * time.Hour), Added: false, Source: "pacman", Packages: []string{"git"}},
+		{Timestamp: taken.Add(-time.Hour), Added: true, Source: "pacman", Packages: []string{"curl"}},
+		{Timestamp: taken.Add(time.Hour), Added: true, Source: "pacman", Packages: []string{"htop"}},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []string
+	}{
+		{"before the snapshot", taken.Add(-2 * time.Hour), []string{"git", "vim"}},
+		{"exact match", taken, []string{"curl", "git", "vim"}},
+		{"between deltas", taken.Add(90 * time.Minute), []string{"curl", "git", "htop", "vim"}},
+		{"after every delta", taken.Add(3 * time.Hour), []string{"curl", "htop", "vim"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Reconstruct(base, deltas, tt.at)
+			if names := packageNames(got); !slices.Equal(names, tt.want) {
+				t.Errorf("Reconstruct() at %s = %v, want %v", tt.at, names, tt.want)
+			}
+			if !got.Timestamp.Equal(tt.at) {
+				t.Errorf("Reconstruct() timestamp = %s, want %s", got.Timestamp, tt.at)
+			}
+		})
+	}
+
+	got := Reconstruct(base, deltas, taken.Add(90*time.Minute))
+	for _, pkg := range got.Packages {
+		if want := map[string]string{"curl": "8.0", "git": "2.45", "htop": "", "vim": "9.1"}[pkg.Name]; pkg.Version != want {
+			t.Errorf("%s version = %q, want %q", pkg.Name, pkg.Version, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package theme defines the named color tokens shared by the poxy CLI and
+// TUI, and loads themes that override them from TOML files.
+//
+// A theme file sets any subset of the tokens; the rest keep their default:
+//
+//	name = "nord"
+//
+//	[colors]
+//	accent = "#88C0D0"
+//	success = "#A3BE8C"
+//	badge-pacman = "#5E81AC"
+//
+// Colors are hex values ("#RRGGBB" or "#RGB") or ANSI 256-color indexes
+// ("0" to "255").
+package theme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Semantic color tokens.
+const (
+	Accent     = "accent"     // Headers, the active tab, the cursor
+	Info       = "info"       // Informational messages, subtitles, help keys
+	Success    = "success"    // Success messages, versions
+	Warning    = "warning"    // Warnings, pinned markers
+	Error      = "error"      // Errors
+	Muted      = "muted"      // Secondary text, descriptions
+	Text       = "text"       // Regular text
+	Background = "background" // TUI background
+	Surface    = "surface"    // TUI header, status bar and input background
+	BadgeText  = "badge-text" // Text on source badges
+
+	// BadgePrefix starts the per-source badge tokens, e.g. "badge-apt".
+	BadgePrefix = "badge-"
+)
+
+// Theme maps color tokens to colors.
+type Theme struct {
+	Name   string            `toml:"name"`
+	Colors map[string]string `toml:"colors"`
+}
+
+// defaultColors is the built-in palette.
+var defaultColors = map[string]string{
+	Accent:     "#7C3AED", // Purple
+	Info:       "#06B6D4", // Cyan
+	Success:    "#10B981", // Green
+	Warning:    "#F59E0B", // Yellow
+	Error:      "#EF4444", // Red
+	Muted:      "#6B7280", // Gray
+	Text:       "#F3F4F6", // Light gray
+	Background: "#1F2937", // Dark gray
+	Surface:    "#374151", // Slightly lighter
+	BadgeText:  "#FFFFFF",
+
+	BadgePrefix + "pacman":     "#1793D1", // Arch blue
+	BadgePrefix + "apt":        "#A80030", // Debian red
+	BadgePrefix + "dnf":        "#294172", // Fedora blue
+	BadgePrefix + "rpm-ostree": "#294172", // Fedora blue
+	BadgePrefix + "brew":       "#FBB040", // Homebrew yellow
+	BadgePrefix + "flatpak":    "#4A90D9", // Flatpak blue
+	BadgePrefix + "snap":       "#E95420", // Ubuntu orange
+	BadgePrefix + "cargo":      "#DEA584", // Rust orange
+	BadgePrefix + "npm":        "#CB3837", // npm red
+	BadgePrefix + "gobin":      "#00ADD8", // Go blue
+	BadgePrefix + "aur":        "#1793D1", // Arch blue
+	BadgePrefix + "winget":     "#0078D4", // Windows blue
+}
+
+// DefaultName is the name of the built-in theme.
+const DefaultName = "default"
+
+// Default returns the built-in theme.
+func Default() *Theme {
+	colors := make(map[string]string, len(defaultColors))
+	for token, value := range defaultColors {
+		colors[token] = value
+	}
+	return &Theme{Name: DefaultName, Colors: colors}
+}
+
+// Tokens returns the semantic tokens and the badge tokens of the built-in
+// theme, sorted.
+func Tokens() []string {
+	tokens := make([]string, 0, len(defaultColors))
+	for token := range defaultColors {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// Color returns the color of a token, or "" if the theme does not define it.
+func (t *Theme) Color(token string) string {
+	return t.Colors[token]
+}
+
+// Badge returns the badge color of a package source, falling back to the
+// muted color for sources without one.
+func (t *Theme) Badge(source string) string {
+	if c := t.Colors[BadgePrefix+source]; c != "" {
+		return c
+	}
+	return t.Colors[Muted]
+}
+
+// Parse reads a theme file's contents. Tokens the file does not set keep
+// their default color.
+func Parse(data []byte) (*Theme, error) {
+	var file Theme
+	if _, err := toml.Decode(string(data), &file); err != nil {
+		return nil, err
+	}
+
+	t := Default()
+	t.Name = file.Name
+	for token, value := range file.Colors {
+		if _, ok := defaultColors[token]; !ok && !strings.HasPrefix(token, BadgePrefix) {
+			return nil, fmt.Errorf("unknown color token %q", token)
+		}
+		if !ValidColor(value) {
+			return nil, fmt.Errorf("invalid color %q for %s", value, token)
+		}
+		t.Colors[token] = value
+	}
+	return t, nil
+}
+
+// Load reads a theme file. A theme without a name is named after the file.
+func Load(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return t, nil
+}
+
+// Resolve returns the theme a setting refers to: the built-in theme for ""
+// or "default", the file at a path, or <name>.toml in dir for a bare name.
+func Resolve(setting, dir string) (*Theme, error) {
+	switch {
+	case setting == "" || setting == DefaultName:
+		return Default(), nil
+	case strings.ContainsRune(setting, filepath.Separator) || filepath.Ext(setting) == ".toml":
+		return Load(setting)
+	default:
+		return Load(filepath.Join(dir, setting+".toml"))
+	}
+}
+
+// ValidColor reports whether value is a hex color or an ANSI 256-color index.
+func ValidColor(value string) bool {
+	if _, _, _, ok := RGB(value); ok {
+		return true
+	}
+	_, ok := ANSI(value)
+	return ok
+}
+
+// RGB parses a "#RRGGBB" or "#RGB" color.
+func RGB(value string) (r, g, b uint8, ok bool) {
+	hex, found := strings.CutPrefix(value, "#")
+	if !found {
+		return 0, 0, 0, false
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(n >> 16), uint8(n >> 8), uint8(n), true
+}
+
+// ANSI parses an ANSI 256-color index.
+func ANSI(value string) (uint8, bool) {
+	n, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, false
+	}
+	return uint8(n), true
+}
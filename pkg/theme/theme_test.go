@@ -0,0 +1,135 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	th := Default()
+	if th.Name != DefaultName {
+		t.Errorf("Name = %q, want %q", th.Name, DefaultName)
+	}
+	for _, token := range Tokens() {
+		if !ValidColor(th.Color(token)) {
+			t.Errorf("default %s = %q is not a valid color", token, th.Color(token))
+		}
+	}
+
+	// Changing a copy must not change the built-in palette
+	th.Colors[Accent] = "#000000"
+	if Default().Color(Accent) == "#000000" {
+		t.Error("Default() shares its color map")
+	}
+}
+
+func TestBadge(t *testing.T) {
+	th := Default()
+	if got := th.Badge("apt"); got != "#A80030" {
+		t.Errorf("Badge(apt) = %q, want #A80030", got)
+	}
+	if got := th.Badge("unknown"); got != th.Color(Muted) {
+		t.Errorf("Badge(unknown) = %q, want muted %q", got, th.Color(Muted))
+	}
+}
+
+func TestParse(t *testing.T) {
+	th, err := Parse([]byte(`
+name = "test"
+
+[colors]
+accent = "#abc"
+success = "2"
+badge-zypper = "#73BA25"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if th.Name != "test" {
+		t.Errorf("Name = %q, want test", th.Name)
+	}
+	if got := th.Color(Accent); got != "#abc" {
+		t.Errorf("accent = %q, want #abc", got)
+	}
+	if got := th.Color(Success); got != "2" {
+		t.Errorf("success = %q, want 2", got)
+	}
+	if got := th.Badge("zypper"); got != "#73BA25" {
+		t.Errorf("Badge(zypper) = %q, want #73BA25", got)
+	}
+	if got, want := th.Color(Error), Default().Color(Error); got != want {
+		t.Errorf("unset error = %q, want default %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown token", "[colors]\naccnet = \"#fff\""},
+		{"bad hex", "[colors]\naccent = \"#ggg\""},
+		{"no hash", "[colors]\naccent = \"fff\""},
+		{"ansi out of range", "[colors]\naccent = \"256\""},
+		{"bad toml", "[colors"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); err == nil {
+				t.Error("Parse() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRGB(t *testing.T) {
+	tests := []struct {
+		value   string
+		r, g, b uint8
+		ok      bool
+	}{
+		{"#7C3AED", 0x7C, 0x3A, 0xED, true},
+		{"#fff", 0xFF, 0xFF, 0xFF, true},
+		{"#12345", 0, 0, 0, false},
+		{"7C3AED", 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		r, g, b, ok := RGB(tt.value)
+		if r != tt.r || g != tt.g || b != tt.b || ok != tt.ok {
+			t.Errorf("RGB(%q) = %d, %d, %d, %v; want %d, %d, %d, %v",
+				tt.value, r, g, b, ok, tt.r, tt.g, tt.b, tt.ok)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nord.toml")
+	if err := os.WriteFile(path, []byte("[colors]\naccent = \"#88C0D0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, setting := range []string{"", "default"} {
+		th, err := Resolve(setting, dir)
+		if err != nil || th.Name != DefaultName {
+			t.Errorf("Resolve(%q) = %v, %v; want the default theme", setting, th, err)
+		}
+	}
+
+	for _, setting := range []string{"nord", path} {
+		th, err := Resolve(setting, dir)
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", setting, err)
+		}
+		if th.Name != "nord" || th.Color(Accent) != "#88C0D0" {
+			t.Errorf("Resolve(%q) = %q with accent %q", setting, th.Name, th.Color(Accent))
+		}
+	}
+
+	if _, err := Resolve("missing", dir); err == nil {
+		t.Error("Resolve(missing) error = nil, want error")
+	}
+}
@@ -0,0 +1,150 @@
+// Package transaction groups package operations across several managers so
+// that a partial failure can be rolled back to the state before the first step.
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+)
+
+// Step is a single operation in a transaction, usually one manager call.
+type Step struct {
+	Manager  manager.Manager
+	Packages []string
+	Run      func(ctx context.Context) error
+}
+
+// StepResult records the outcome of a step.
+type StepResult struct {
+	Step Step
+	Err  error
+}
+
+// Result summarizes an executed transaction.
+type Result struct {
+	Completed []StepResult
+	Failed    []StepResult
+	Skipped   []Step // Steps not run because an earlier step failed
+}
+
+// Err returns an error describing the failed steps, or nil if every step succeeded.
+func (r *Result) Err() error {
+	switch len(r.Failed) {
+	case 0:
+		return nil
+	case 1:
+		return r.Failed[0].Err
+	}
+
+	errs := make([]error, 0, len(r.Failed))
+	for _, f := range r.Failed {
+		errs = append(errs, fmt.Errorf("%s: %w", f.Step.Manager.Name(), f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Partial returns true if some steps succeeded and others failed.
+func (r *Result) Partial() bool {
+	return len(r.Completed) > 0 && len(r.Failed) > 0
+}
+
+// Transaction runs steps against a recorded pre-transaction state.
+type Transaction struct {
+	// StopOnFailure skips the remaining steps after the first failure.
+	StopOnFailure bool
+
+	steps  []Step
+	before *snapshot.Snapshot
+}
+
+// New creates an empty transaction.
+func New() *Transaction {
+	return &Transaction{}
+}
+
+// Add appends a step to the transaction.
+func (t *Transaction) Add(step Step) {
+	t.steps = append(t.steps, step)
+}
+
+// Steps returns the steps in execution order.
+func (t *Transaction) Steps() []Step {
+	return t.steps
+}
+
+// Managers returns the distinct managers touched by the transaction.
+func (t *Transaction) Managers() []manager.Manager {
+	seen := make(map[string]bool)
+	var managers []manager.Manager
+	for _, step := range t.steps {
+		if seen[step.Manager.Name()] {
+			continue
+		}
+		seen[step.Manager.Name()] = true
+		managers = append(managers, step.Manager)
+	}
+	return managers
+}
+
+// Begin records the state to roll back to. If snap is nil, the packages of
+// the touched managers are captured in memory without saving a snapshot.
+func (t *Transaction) Begin(ctx context.Context, snap *snapshot.Snapshot) error {
+	if snap != nil {
+		t.before = snap
+		return nil
+	}
+
+	before, err := snapshot.Capture(ctx, snapshot.TriggerManual, "transaction start", t.Managers())
+	if err != nil {
+		return fmt.Errorf("failed to capture pre-transaction state: %w", err)
+	}
+	t.before = before
+	return nil
+}
+
+// Execute runs the steps in order.
+func (t *Transaction) Execute(ctx context.Context) *Result {
+	result := &Result{}
+
+	for i, step := range t.steps {
+		if err := step.Run(ctx); err != nil {
+			result.Failed = append(result.Failed, StepResult{Step: step, Err: err})
+			if t.StopOnFailure {
+				result.Skipped = append(result.Skipped, t.steps[i+1:]...)
+				break
+			}
+			continue
+		}
+		result.Completed = append(result.Completed, StepResult{Step: step})
+	}
+
+	return result
+}
+
+// RollbackPlan computes the changes that restore the pre-transaction state
+// for the managers touched by the transaction. Version changes are not
+// reverted; packages added or removed by the transaction are.
+func (t *Transaction) RollbackPlan(ctx context.Context) (*snapshot.RestorePlan, error) {
+	if t.before == nil {
+		return nil, fmt.Errorf("transaction has no recorded starting state")
+	}
+
+	managers := t.Managers()
+	sources := make([]string, 0, len(managers))
+	for _, mgr := range managers {
+		sources = append(sources, mgr.Name())
+	}
+
+	return snapshot.PlanRestore(ctx, t.before, managers, snapshot.RestoreOpts{Sources: sources})
+}
+
+// Rollback executes a rollback plan and returns the number of packages processed.
+func (t *Transaction) Rollback(ctx context.Context, plan *snapshot.RestorePlan) (int, error) {
+	executor := snapshot.NewExecutor(t.Managers(), snapshot.RestoreOpts{AutoConfirm: true})
+	results, err := executor.Execute(ctx, plan)
+	return results.Successful(), err
+}
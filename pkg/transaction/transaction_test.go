@@ -0,0 +1,255 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"poxy/pkg/manager"
+	"poxy/pkg/snapshot"
+)
+
+// fakeManager tracks installed packages in memory.
+type fakeManager struct {
+	name      string
+	installed map[string]bool
+	listErr   error               // Returned by ListInstalled when set
+	rdeps     map[string][]string // Reverse dependencies by package
+}
+
+func newFakeManager(name string, installed ...string) *fakeManager {
+	m := &fakeManager{name: name, installed: make(map[string]bool)}
+	for _, pkg := range installed {
+		m.installed[pkg] = true
+	}
+	return m
+}
+
+func (m *fakeManager) Name() string                 { return m.name }
+func (m *fakeManager) DisplayName() string          { return m.name }
+func (m *fakeManager) Type() manager.ManagerType    { return manager.TypeNative }
+func (m *fakeManager) IsAvailable() bool            { return true }
+func (m *fakeManager) NeedsSudo() bool              { return false }
+func (m *fakeManager) Update(context.Context) error { return nil }
+
+func (m *fakeManager) Install(_ context.Context, pkgs []string, _ manager.InstallOpts) error {
+	for _, pkg := range pkgs {
+		m.installed[pkg] = true
+	}
+	return nil
+}
+
+func (m *fakeManager) Uninstall(_ context.Context, pkgs []string, _ manager.UninstallOpts) error {
+	for _, pkg := range pkgs {
+		delete(m.installed, pkg)
+	}
+	return nil
+}
+
+func (m *fakeManager) Upgrade(context.Context, manager.UpgradeOpts) error { return nil }
+func (m *fakeManager) Search(context.Context, string, manager.SearchOpts) ([]manager.Package, error) {
+	return nil, nil
+}
+func (m *fakeManager) Info(context.Context, string) (*manager.PackageInfo, error) { return nil, nil }
+func (m *fakeManager) ListInstalled(context.Context, manager.ListOpts) ([]manager.Package, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	var pkgs []manager.Package
+	for name := range m.installed {
+		pkgs = append(pkgs, manager.Package{Name: name, Version: "1.0", Installed: true})
+	}
+	return pkgs, nil
+}
+func (m *fakeManager) IsInstalled(_ context.Context, pkg string) (bool, error) {
+	return m.installed[pkg], nil
+}
+func (m *fakeManager) ReverseDependencies(_ context.Context, pkg string) ([]string, error) {
+	var deps []string
+	for _, dep := range m.rdeps[pkg] {
+		if m.installed[dep] {
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+func (m *fakeManager) Clean(context.Context, manager.CleanOpts) error { return nil }
+func (m *fakeManager) Autoremove(context.Context) error               { return nil }
+
+func installStep(mgr *fakeManager, pkgs ...string) Step {
+	return Step{
+		Manager:  mgr,
+		Packages: pkgs,
+		Run: func(ctx context.Context) error {
+			return mgr.Install(ctx, pkgs, manager.InstallOpts{})
+		},
+	}
+}
+
+func failingStep(mgr *fakeManager, err error) Step {
+	return Step{
+		Manager: mgr,
+		Run:     func(context.Context) error { return err },
+	}
+}
+
+func TestExecuteStopOnFailure(t *testing.T) {
+	native := newFakeManager("native")
+	flatpak := newFakeManager("flatpak")
+	boom := errors.New("boom")
+
+	tx := New()
+	tx.StopOnFailure = true
+	tx.Add(installStep(native, "vim"))
+	tx.Add(failingStep(flatpak, boom))
+	tx.Add(installStep(native, "git"))
+
+	result := tx.Execute(context.Background())
+
+	if len(result.Completed) != 1 || len(result.Failed) != 1 || len(result.Skipped) != 1 {
+		t.Fatalf("unexpected result: %d completed, %d failed, %d skipped",
+			len(result.Completed), len(result.Failed), len(result.Skipped))
+	}
+	if !result.Partial() {
+		t.Error("expected a partial result")
+	}
+	if !errors.Is(result.Err(), boom) {
+		t.Errorf("expected step error, got %v", result.Err())
+	}
+	if native.installed["git"] {
+		t.Error("step after the failure should not have run")
+	}
+}
+
+func TestExecuteContinuesByDefault(t *testing.T) {
+	native := newFakeManager("native")
+	flatpak := newFakeManager("flatpak")
+
+	tx := New()
+	tx.Add(failingStep(flatpak, errors.New("flatpak failed")))
+	tx.Add(failingStep(native, errors.New("native failed")))
+	tx.Add(installStep(native, "git"))
+
+	result := tx.Execute(context.Background())
+
+	if len(result.Completed) != 1 || len(result.Failed) != 2 {
+		t.Fatalf("unexpected result: %d completed, %d failed", len(result.Completed), len(result.Failed))
+	}
+	if result.Err() == nil {
+		t.Error("expected a joined error")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	native := newFakeManager("native", "bash")
+	flatpak := newFakeManager("flatpak")
+
+	tx := New()
+	tx.StopOnFailure = true
+	tx.Add(installStep(native, "vim"))
+	tx.Add(failingStep(flatpak, errors.New("boom")))
+
+	if err := tx.Begin(ctx, nil); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	tx.Execute(ctx)
+
+	plan, err := tx.RollbackPlan(ctx)
+	if err != nil {
+		t.Fatalf("RollbackPlan() error = %v", err)
+	}
+
+	if got := plan.ToRemove["native"]; len(got) != 1 || got[0] != "vim" {
+		t.Fatalf("expected to remove [vim], got %v", got)
+	}
+	if len(plan.ToAdd) != 0 {
+		t.Errorf("expected nothing to reinstall, got %v", plan.ToAdd)
+	}
+
+	if _, err := tx.Rollback(ctx, plan); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if native.installed["vim"] || !native.installed["bash"] {
+		t.Errorf("unexpected state after rollback: %v", native.installed)
+	}
+}
+
+func TestRollbackKeepsRequiredPackages(t *testing.T) {
+	ctx := context.Background()
+	native := newFakeManager("native", "bash")
+	native.rdeps = map[string][]string{"libfoo": {"bash"}}
+
+	tx := New()
+	tx.Add(installStep(native, "vim", "libfoo"))
+
+	if err := tx.Begin(ctx, nil); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	tx.Execute(ctx)
+
+	plan, err := tx.RollbackPlan(ctx)
+	if err != nil {
+		t.Fatalf("RollbackPlan() error = %v", err)
+	}
+
+	// bash now depends on libfoo, so only vim can be removed
+	n, err := tx.Rollback(ctx, plan)
+	if n != 1 || err == nil {
+		t.Errorf("Rollback() = %d, %v; want 1 removal and an error for libfoo", n, err)
+	}
+	if native.installed["vim"] || !native.installed["libfoo"] {
+		t.Errorf("unexpected state after rollback: %v", native.installed)
+	}
+}
+
+func TestRollbackPlanRefusesIncompleteSnapshot(t *testing.T) {
+	ctx := context.Background()
+	native := newFakeManager("native", "bash")
+	native.listErr = errors.New("database locked")
+
+	tx := New()
+	tx.Add(installStep(native, "vim"))
+
+	if err := tx.Begin(ctx, nil); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	native.listErr = nil
+	tx.Execute(ctx)
+
+	// The starting state has no native packages, so a plan would remove bash too
+	if _, err := tx.RollbackPlan(ctx); !errors.Is(err, snapshot.ErrIncompleteSnapshot) {
+		t.Errorf("RollbackPlan() error = %v, want ErrIncompleteSnapshot", err)
+	}
+}
+
+func TestRollbackPlanSkipsUncapturedSource(t *testing.T) {
+	ctx := context.Background()
+	native := newFakeManager("native", "bash")
+
+	tx := New()
+	tx.Add(installStep(native, "vim"))
+
+	if err := tx.Begin(ctx, nil); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	tx.Execute(ctx)
+	native.listErr = errors.New("database locked")
+
+	plan, err := tx.RollbackPlan(ctx)
+	if err != nil {
+		t.Fatalf("RollbackPlan() error = %v", err)
+	}
+	if !plan.IsEmpty() || len(plan.Skipped) != 1 || plan.Skipped[0] != "native" {
+		t.Errorf("expected native to be skipped, got plan %+v", plan)
+	}
+}
+
+func TestRollbackPlanWithoutBegin(t *testing.T) {
+	tx := New()
+	tx.Add(installStep(newFakeManager("native"), "vim"))
+
+	if _, err := tx.RollbackPlan(context.Background()); err == nil {
+		t.Error("expected an error without a recorded starting state")
+	}
+}